@@ -238,6 +238,7 @@ func (th *TestHelper) GetKeystoneService(name types.NamespacedName) *keystonev1.
 func (th *TestHelper) SimulateKeystoneServiceReady(name types.NamespacedName) {
 	gomega.Eventually(func(g gomega.Gomega) {
 		service := th.GetKeystoneService(name)
+		service.Status.ObservedGeneration = service.Generation
 		service.Status.Conditions.MarkTrue(condition.ReadyCondition, "Ready")
 		g.Expect(th.K8sClient.Status().Update(th.Ctx, service)).To(gomega.Succeed())
 	}, th.Timeout, th.Interval).Should(gomega.Succeed())
@@ -35,11 +35,101 @@ const (
 
 	// KeystoneServiceOSUserReadyCondition Status=True condition which indicates if the service user got created in the keystone instance is ready/was successful
 	KeystoneServiceOSUserReadyCondition condition.Type = "KeystoneServiceOSUserReady"
+
+	// KeystoneEndpointReadinessGateReadyCondition Status=True condition which indicates if the readiness gate of the endpoint passed
+	KeystoneEndpointReadinessGateReadyCondition condition.Type = "KeystoneEndpointReadinessGateReady"
+
+	// KeystoneEndpointURLPreflightReadyCondition Status=True condition which indicates if the changed endpoint URLs passed the probe before they got registered
+	KeystoneEndpointURLPreflightReadyCondition condition.Type = "KeystoneEndpointURLPreflightReady"
+
+	// KeystoneEndpointURLRollbackReadyCondition Status=True condition which indicates if no endpoint URL change got rolled back
+	KeystoneEndpointURLRollbackReadyCondition condition.Type = "KeystoneEndpointURLRollbackReady"
+
+	// KeystoneEndpointRegionPruneReadyCondition Status=True condition which indicates if no removed region waits for the confirmation to delete its endpoints
+	KeystoneEndpointRegionPruneReadyCondition condition.Type = "KeystoneEndpointRegionPruneReady"
+
+	// KeystoneEndpointGroupReadyCondition Status=True condition which indicates if the endpoint group got created in the keystone instance is ready/was successful
+	KeystoneEndpointGroupReadyCondition condition.Type = "KeystoneEndpointGroupReady"
+
+	// KeystoneEndpointGroupProjectsReadyCondition Status=True condition which indicates if the endpoint group got associated with the projects
+	KeystoneEndpointGroupProjectsReadyCondition condition.Type = "KeystoneEndpointGroupProjectsReady"
+
+	// KeystoneCatalogAuditReadyCondition Status=True condition which indicates if the last catalog audit completed
+	KeystoneCatalogAuditReadyCondition condition.Type = "KeystoneCatalogAuditReady"
+
+	// KeystoneRegisteredLimitReadyCondition Status=True condition which indicates if the registered limit got created in the keystone instance is ready/was successful
+	KeystoneRegisteredLimitReadyCondition condition.Type = "KeystoneRegisteredLimitReady"
+
+	// KeystoneLimitReadyCondition Status=True condition which indicates if the limit got created in the keystone instance is ready/was successful
+	KeystoneLimitReadyCondition condition.Type = "KeystoneLimitReady"
+
+	// KeystoneEC2CredentialReadyCondition Status=True condition which indicates if the EC2 credential got created in the keystone instance is ready/was successful
+	KeystoneEC2CredentialReadyCondition condition.Type = "KeystoneEC2CredentialReady"
+
+	// KeystoneCredentialReadyCondition Status=True condition which indicates if the credential got created in the keystone instance is ready/was successful
+	KeystoneCredentialReadyCondition condition.Type = "KeystoneCredentialReady"
+
+	// KeystoneCatalogReadyCondition Status=True condition which indicates if the services and endpoints of the catalog got registered in the keystone instance
+	KeystoneCatalogReadyCondition condition.Type = "KeystoneCatalogReady"
+
+	// KeystoneUserReadyCondition Status=True condition which indicates if the user got created in the keystone instance with the requested enabled state
+	KeystoneUserReadyCondition condition.Type = "KeystoneUserReady"
+
+	// KeystoneUserBatchReadyCondition Status=True condition which indicates if all users of the batch got provisioned in the keystone instance
+	KeystoneUserBatchReadyCondition condition.Type = "KeystoneUserBatchReady"
+
+	// KeystoneMappingReadyCondition Status=True condition which indicates if the mapping and its identity provider got set up in the keystone instance
+	KeystoneMappingReadyCondition condition.Type = "KeystoneMappingReady"
+
+	// DegradedCondition Status=True condition which indicates that the reconcile failed repeatedly, it is removed once a reconcile succeeds
+	DegradedCondition condition.Type = "Degraded"
+
+	// OwnershipConflictCondition Status=True condition which indicates that a service or endpoint in keystone is managed by the operator of another cluster, it is removed once a reconcile succeeds
+	OwnershipConflictCondition condition.Type = "OwnershipConflict"
+
+	// KeystoneBootstrapRolesReadyCondition Status=True condition which indicates if the default roles and role assignments of the bootstrap exist in the keystone instance
+	KeystoneBootstrapRolesReadyCondition condition.Type = "BootstrapRolesReady"
+
+	// KeystoneGreenDeploymentReadyCondition Status=True condition which indicates if the green deployment of a blue/green setup is ready, or blue/green is disabled
+	KeystoneGreenDeploymentReadyCondition condition.Type = "GreenDeploymentReady"
+
+	// KeystoneImageVerifiedCondition Status=True condition which indicates if the keystone container images passed the configured verification
+	KeystoneImageVerifiedCondition condition.Type = "ImageVerified"
+
+	// KeystoneAutoscalingReadyCondition Status=True condition which indicates if the KEDA ScaledObject of the keystone API deployment got created
+	KeystoneAutoscalingReadyCondition condition.Type = "AutoscalingReady"
+
+	// KeystonePublicDeploymentReadyCondition Status=True condition which indicates if the separate deployment serving the public endpoint is ready
+	KeystonePublicDeploymentReadyCondition condition.Type = "PublicDeploymentReady"
+
+	// KeystoneLDAPReadyCondition Status=True condition which indicates if the LDAP servers of the LDAP domains are reachable
+	KeystoneLDAPReadyCondition condition.Type = "LDAPReady"
+
+	// KeystoneImpliedRolesReadyCondition Status=True condition which indicates if the implied roles got created in the keystone instance
+	KeystoneImpliedRolesReadyCondition condition.Type = "KeystoneImpliedRolesReady"
+
+	// KeystoneFernetKeysReadyCondition Status=True condition which indicates if the fernet keys got rotated in time and all keystone pods use the current keys
+	KeystoneFernetKeysReadyCondition condition.Type = "FernetKeysReady"
+
+	// KeystonePublicDNSReadyCondition Status=True condition which indicates if the hostname of the public DNS record resolves and got registered in the catalog
+	KeystonePublicDNSReadyCondition condition.Type = "PublicDNSReady"
+
+	// KeystoneDeletionBlockedCondition Status=True condition which indicates that the deletion of the KeystoneAPI waits for the CRs registered in keystone to be deleted
+	KeystoneDeletionBlockedCondition condition.Type = "DeletionBlocked"
+
+	// KeystoneTokenSmokeTestReadyCondition Status=True condition which indicates if the smoke test user can issue and validate a token
+	KeystoneTokenSmokeTestReadyCondition condition.Type = "TokenSmokeTestReady"
+
+	// KeystoneTokenRevocationReadyCondition Status=True condition which indicates if the tokens revoked by the revoke-tokens annotation are rejected by all keystone pods
+	KeystoneTokenRevocationReadyCondition condition.Type = "TokenRevocationReady"
 )
 
 // Common Messages used by API objects.
 const (
 
+	// ObservedGenerationWaitingMessage
+	ObservedGenerationWaitingMessage = "Waiting for generation %d of the spec to be reconciled, observed generation %d"
+
 	//
 	// KeystoneAPIReady condition messages
 	//
@@ -111,4 +201,409 @@ const (
 
 	// KeystoneServiceOSUserReadyErrorMessage
 	KeystoneServiceOSUserReadyErrorMessage = "Keystone Service user error occured %s"
+
+	//
+	// KeystoneEndpointReadinessGateReady condition messages
+	//
+	// KeystoneEndpointReadinessGateReadyInitMessage
+	KeystoneEndpointReadinessGateReadyInitMessage = "Keystone Endpoint readiness gate not checked"
+
+	// KeystoneEndpointReadinessGateReadyMessage
+	KeystoneEndpointReadinessGateReadyMessage = "Keystone Endpoint readiness gate passed"
+
+	// KeystoneEndpointReadinessGateReadyWaitingMessage
+	KeystoneEndpointReadinessGateReadyWaitingMessage = "Keystone Endpoint readiness gate not yet passed: %s"
+
+	// KeystoneEndpointReadinessGateReadyErrorMessage
+	KeystoneEndpointReadinessGateReadyErrorMessage = "Keystone Endpoint readiness gate error occured %s"
+
+	//
+	// KeystoneEndpointURLPreflightReady condition messages
+	//
+	// KeystoneEndpointURLPreflightReadyMessage
+	KeystoneEndpointURLPreflightReadyMessage = "Keystone Endpoint URL changes verified"
+
+	// KeystoneEndpointURLPreflightReadyErrorMessage
+	KeystoneEndpointURLPreflightReadyErrorMessage = "Keystone Endpoint kept the registered URLs, the new URLs failed the probe: %s"
+
+	//
+	// KeystoneEndpointURLRollbackReady condition messages
+	//
+	// KeystoneEndpointURLRollbackReadyMessage
+	KeystoneEndpointURLRollbackReadyMessage = "Keystone Endpoint URL changes not rolled back"
+
+	// KeystoneEndpointURLRollbackReadyErrorMessage
+	KeystoneEndpointURLRollbackReadyErrorMessage = "Keystone Endpoint rolled back the URLs %s, change the spec to register a new URL"
+
+	//
+	// KeystoneEndpointRegionPruneReady condition messages
+	//
+	// KeystoneEndpointRegionPruneReadyMessage
+	KeystoneEndpointRegionPruneReadyMessage = "Keystone Endpoint has no regions pending prune"
+
+	// KeystoneEndpointRegionPruneReadyPendingMessage
+	KeystoneEndpointRegionPruneReadyPendingMessage = "Keystone Endpoint kept the endpoints of the removed regions %s, list them in the %s annotation to delete them"
+
+	//
+	// KeystoneEndpointGroupReady condition messages
+	//
+	// KeystoneEndpointGroupReadyInitMessage
+	KeystoneEndpointGroupReadyInitMessage = "Keystone Endpoint Group registration not started"
+
+	// KeystoneEndpointGroupReadyMessage
+	KeystoneEndpointGroupReadyMessage = "Keystone Endpoint Group %s - %s ready"
+
+	// KeystoneEndpointGroupReadyWaitingMessage
+	KeystoneEndpointGroupReadyWaitingMessage = "Keystone Endpoint Group waiting for KeystoneService %s"
+
+	// KeystoneEndpointGroupReadyErrorMessage
+	KeystoneEndpointGroupReadyErrorMessage = "Keystone Endpoint Group error occured %s"
+
+	//
+	// KeystoneEndpointGroupProjectsReady condition messages
+	//
+	// KeystoneEndpointGroupProjectsReadyInitMessage
+	KeystoneEndpointGroupProjectsReadyInitMessage = "Keystone Endpoint Group project association not started"
+
+	// KeystoneEndpointGroupProjectsReadyMessage
+	KeystoneEndpointGroupProjectsReadyMessage = "Keystone Endpoint Group associated with projects %v"
+
+	// KeystoneEndpointGroupProjectsReadyErrorMessage
+	KeystoneEndpointGroupProjectsReadyErrorMessage = "Keystone Endpoint Group project association error occured %s"
+
+	//
+	// KeystoneCatalogAuditReady condition messages
+	//
+	// KeystoneCatalogAuditReadyInitMessage
+	KeystoneCatalogAuditReadyInitMessage = "Keystone catalog audit not started"
+
+	// KeystoneCatalogAuditReadyMessage
+	KeystoneCatalogAuditReadyMessage = "Keystone catalog audit completed, %d mismatches found"
+
+	// KeystoneCatalogAuditReadyErrorMessage
+	KeystoneCatalogAuditReadyErrorMessage = "Keystone catalog audit error occured %s"
+
+	//
+	// KeystoneRegisteredLimitReady condition messages
+	//
+	// KeystoneRegisteredLimitReadyInitMessage
+	KeystoneRegisteredLimitReadyInitMessage = "Keystone registered limit registration not started"
+
+	// KeystoneRegisteredLimitReadyMessage
+	KeystoneRegisteredLimitReadyMessage = "Keystone registered limit %s - %s ready"
+
+	// KeystoneRegisteredLimitReadyWaitingMessage
+	KeystoneRegisteredLimitReadyWaitingMessage = "Keystone registered limit waiting for KeystoneService %s"
+
+	// KeystoneRegisteredLimitReadyErrorMessage
+	KeystoneRegisteredLimitReadyErrorMessage = "Keystone registered limit error occured %s"
+
+	//
+	// KeystoneLimitReady condition messages
+	//
+	// KeystoneLimitReadyInitMessage
+	KeystoneLimitReadyInitMessage = "Keystone limit registration not started"
+
+	// KeystoneLimitReadyMessage
+	KeystoneLimitReadyMessage = "Keystone limit %s - %s ready"
+
+	// KeystoneLimitReadyWaitingMessage
+	KeystoneLimitReadyWaitingMessage = "Keystone limit waiting for %s"
+
+	// KeystoneLimitReadyErrorMessage
+	KeystoneLimitReadyErrorMessage = "Keystone limit error occured %s"
+
+	//
+	// KeystoneEC2CredentialReady condition messages
+	//
+	// KeystoneEC2CredentialReadyInitMessage
+	KeystoneEC2CredentialReadyInitMessage = "Keystone EC2 credential creation not started"
+
+	// KeystoneEC2CredentialReadyMessage
+	KeystoneEC2CredentialReadyMessage = "Keystone EC2 credential ready, stored in secret %s"
+
+	// KeystoneEC2CredentialReadyWaitingMessage
+	KeystoneEC2CredentialReadyWaitingMessage = "Keystone EC2 credential waiting for %s"
+
+	// KeystoneEC2CredentialReadyErrorMessage
+	KeystoneEC2CredentialReadyErrorMessage = "Keystone EC2 credential error occured %s"
+
+	//
+	// KeystoneCredentialReady condition messages
+	//
+	// KeystoneCredentialReadyInitMessage
+	KeystoneCredentialReadyInitMessage = "Keystone credential creation not started"
+
+	// KeystoneCredentialReadyMessage
+	KeystoneCredentialReadyMessage = "Keystone credential ready, ID %s"
+
+	// KeystoneCredentialReadyWaitingMessage
+	KeystoneCredentialReadyWaitingMessage = "Keystone credential waiting for %s"
+
+	// KeystoneCredentialReadyErrorMessage
+	KeystoneCredentialReadyErrorMessage = "Keystone credential error occured %s"
+
+	//
+	// KeystoneUserReady condition messages
+	//
+	// KeystoneUserReadyInitMessage
+	KeystoneUserReadyInitMessage = "Keystone user creation not started"
+
+	// KeystoneUserReadyMessage
+	KeystoneUserReadyMessage = "Keystone user ready, ID %s, %s"
+
+	// KeystoneUserReadyWaitingMessage
+	KeystoneUserReadyWaitingMessage = "Keystone user waiting for %s"
+
+	// KeystoneUserReadyErrorMessage
+	KeystoneUserReadyErrorMessage = "Keystone user error occured %s"
+
+	//
+	// KeystoneUserBatchReady condition messages
+	//
+	// KeystoneUserBatchReadyInitMessage
+	KeystoneUserBatchReadyInitMessage = "Keystone user batch import not started"
+
+	// KeystoneUserBatchReadyMessage
+	KeystoneUserBatchReadyMessage = "Keystone user batch ready, %d users"
+
+	// KeystoneUserBatchReadyWaitingMessage
+	KeystoneUserBatchReadyWaitingMessage = "Keystone user batch waiting for %s"
+
+	// KeystoneUserBatchReadyPendingMessage
+	KeystoneUserBatchReadyPendingMessage = "Keystone user batch import in progress, %d of %d users pending because of the users per minute safety limit"
+
+	// KeystoneUserBatchReadyFailedMessage
+	KeystoneUserBatchReadyFailedMessage = "Keystone user batch %d of %d users failed, line %d: %s"
+
+	// KeystoneUserBatchReadyErrorMessage
+	KeystoneUserBatchReadyErrorMessage = "Keystone user batch error occured %s"
+
+	//
+	// KeystoneMappingReady condition messages
+	//
+	// KeystoneMappingReadyInitMessage
+	KeystoneMappingReadyInitMessage = "Keystone mapping creation not started"
+
+	// KeystoneMappingReadyMessage
+	KeystoneMappingReadyMessage = "Keystone mapping %s ready"
+
+	// KeystoneMappingReadyWaitingMessage
+	KeystoneMappingReadyWaitingMessage = "Keystone mapping waiting for %s"
+
+	// KeystoneMappingReadyErrorMessage
+	KeystoneMappingReadyErrorMessage = "Keystone mapping error occured %s"
+
+	//
+	// KeystoneCatalogReady condition messages
+	//
+	// KeystoneCatalogReadyInitMessage
+	KeystoneCatalogReadyInitMessage = "Keystone catalog registration not started"
+
+	// KeystoneCatalogReadyMessage
+	KeystoneCatalogReadyMessage = "Keystone catalog ready, %d services registered"
+
+	// KeystoneCatalogReadyErrorMessage
+	KeystoneCatalogReadyErrorMessage = "Keystone catalog error occured %s"
+
+	//
+	// DeploymentReady condition messages
+	//
+	// KeystoneFernetScaleUpWaitingMessage
+	KeystoneFernetScaleUpWaitingMessage = "Scale up to %d replicas waiting for all pods to use the current fernet keys"
+
+	//
+	// BootstrapReady condition messages
+	//
+	// KeystoneRebootstrapRunningMessage
+	KeystoneRebootstrapRunningMessage = "Bootstrap job re-run requested by annotation %s=%s in progress"
+
+	//
+	// Degraded condition messages
+	//
+	// DegradedMessage
+	DegradedMessage = "Reconcile keeps failing: %s"
+
+	// DegradedReadyMessage
+	DegradedReadyMessage = "Reconcile degraded, see the Degraded condition"
+
+	//
+	// TokenSmokeTestReady condition messages
+	//
+	// KeystoneTokenSmokeTestReadyInitMessage
+	KeystoneTokenSmokeTestReadyInitMessage = "Token smoke test not started"
+
+	// KeystoneTokenSmokeTestReadyMessage
+	KeystoneTokenSmokeTestReadyMessage = "Token issued and validated in %dms"
+
+	// KeystoneTokenSmokeTestReadyErrorMessage
+	KeystoneTokenSmokeTestReadyErrorMessage = "Token smoke test failed %d times in a row: %s"
+
+	//
+	// TokenRevocationReady condition messages
+	//
+	// KeystoneTokenRevocationReadyMessage
+	KeystoneTokenRevocationReadyMessage = "Tokens issued before %s revoked on all keystone pods"
+
+	// KeystoneTokenRevocationPodsMessage
+	KeystoneTokenRevocationPodsMessage = "Token revocation requested by annotation %s=%s in progress, %d of %d keystone pods do not use the new fernet keys"
+
+	// KeystoneTokenRevocationCacheMessage
+	KeystoneTokenRevocationCacheMessage = "Token revocation requested by annotation %s=%s in progress, cached token validations expire at %s"
+
+	//
+	// DeletionBlocked condition messages
+	//
+	// KeystoneDeletionBlockedMessage
+	KeystoneDeletionBlockedMessage = "KeystoneAPI deletion waits for the deletion of %s"
+
+	//
+	// OwnershipConflict condition messages
+	//
+	// OwnershipConflictMessage
+	OwnershipConflictMessage = "%s, set the annotation %s=true to take over the ownership"
+
+	//
+	// BootstrapRolesReady condition messages
+	//
+	// KeystoneBootstrapRolesReadyInitMessage
+	KeystoneBootstrapRolesReadyInitMessage = "Keystone bootstrap roles verification not started"
+
+	// KeystoneBootstrapRolesReadyMessage
+	KeystoneBootstrapRolesReadyMessage = "Keystone bootstrap roles verified"
+
+	// KeystoneBootstrapRolesReadyWaitingMessage
+	KeystoneBootstrapRolesReadyWaitingMessage = "Keystone bootstrap roles verification waiting for the keystone deployment"
+
+	// KeystoneBootstrapRolesReadyErrorMessage
+	KeystoneBootstrapRolesReadyErrorMessage = "Keystone bootstrap roles verification error occured %s"
+
+	//
+	// GreenDeploymentReady condition messages
+	//
+	// KeystoneGreenDeploymentReadyInitMessage
+	KeystoneGreenDeploymentReadyInitMessage = "Green deployment not started"
+
+	// KeystoneGreenDeploymentReadyMessage
+	KeystoneGreenDeploymentReadyMessage = "Green deployment ready, active deployment %s"
+
+	// KeystoneGreenDeploymentDisabledMessage
+	KeystoneGreenDeploymentDisabledMessage = "Blue/green deployment disabled"
+
+	// KeystoneGreenDeploymentReadyRunningMessage
+	KeystoneGreenDeploymentReadyRunningMessage = "Green deployment in progress"
+
+	// KeystoneGreenDeploymentReadyErrorMessage
+	KeystoneGreenDeploymentReadyErrorMessage = "Green deployment error occured %s"
+
+	//
+	// PublicDeploymentReady condition messages
+	//
+	// KeystonePublicDeploymentReadyInitMessage
+	KeystonePublicDeploymentReadyInitMessage = "Public deployment not started"
+
+	// KeystonePublicDeploymentReadyMessage
+	KeystonePublicDeploymentReadyMessage = "Public deployment ready"
+
+	// KeystonePublicDeploymentReadyRunningMessage
+	KeystonePublicDeploymentReadyRunningMessage = "Public deployment in progress"
+
+	// KeystonePublicDeploymentReadyErrorMessage
+	KeystonePublicDeploymentReadyErrorMessage = "Public deployment error occured %s"
+
+	//
+	// LDAPReady condition messages
+	//
+	// KeystoneLDAPReadyInitMessage
+	KeystoneLDAPReadyInitMessage = "LDAP connectivity check not started"
+
+	// KeystoneLDAPReadyMessage
+	KeystoneLDAPReadyMessage = "LDAP servers reachable"
+
+	// KeystoneLDAPReadyErrorMessage
+	KeystoneLDAPReadyErrorMessage = "LDAP connectivity check failed %s"
+
+	//
+	// ImageVerified condition messages
+	//
+	// KeystoneImageVerifiedInitMessage
+	KeystoneImageVerifiedInitMessage = "Image verification not started"
+
+	// KeystoneImageVerifiedMessage
+	KeystoneImageVerifiedMessage = "Image verification passed"
+
+	// KeystoneImageVerifiedRunningMessage
+	KeystoneImageVerifiedRunningMessage = "Image verification in progress"
+
+	// KeystoneImageVerifiedDigestMessage
+	KeystoneImageVerifiedDigestMessage = "Image %s is not referenced by digest"
+
+	// KeystoneImageVerifiedErrorMessage
+	KeystoneImageVerifiedErrorMessage = "Image verification failed %s"
+
+	//
+	// AutoscalingReady condition messages
+	//
+	// KeystoneAutoscalingReadyInitMessage
+	KeystoneAutoscalingReadyInitMessage = "Autoscaling not started"
+
+	// KeystoneAutoscalingReadyMessage
+	KeystoneAutoscalingReadyMessage = "Autoscaling ready"
+
+	// KeystoneAutoscalingReadyErrorMessage
+	KeystoneAutoscalingReadyErrorMessage = "Autoscaling error occured %s"
+
+	//
+	// KeystoneImpliedRolesReady condition messages
+	//
+	// KeystoneImpliedRolesReadyInitMessage
+	KeystoneImpliedRolesReadyInitMessage = "Keystone implied roles not started"
+
+	// KeystoneImpliedRolesReadyMessage
+	KeystoneImpliedRolesReadyMessage = "Keystone implied roles ready"
+
+	// KeystoneImpliedRolesReadyWaitingMessage
+	KeystoneImpliedRolesReadyWaitingMessage = "Keystone implied roles waiting for the keystone deployment"
+
+	// KeystoneImpliedRolesReadyErrorMessage
+	KeystoneImpliedRolesReadyErrorMessage = "Keystone implied roles error occured %s"
+
+	//
+	// FernetKeysReady condition messages
+	//
+	// KeystoneFernetKeysReadyInitMessage
+	KeystoneFernetKeysReadyInitMessage = "Fernet keys check not started"
+
+	// KeystoneFernetKeysReadyMessage
+	KeystoneFernetKeysReadyMessage = "Fernet keys ready"
+
+	// KeystoneFernetKeysRotationOverdueMessage
+	KeystoneFernetKeysRotationOverdueMessage = "Fernet keys rotation overdue, last rotated at %s"
+
+	// KeystoneFernetKeysCountMismatchMessage
+	KeystoneFernetKeysCountMismatchMessage = "Fernet keys secret holds %d keys, expected %d"
+
+	// KeystoneFernetKeysPodsMismatchMessage
+	KeystoneFernetKeysPodsMismatchMessage = "%d of %d keystone pods do not use the current fernet keys"
+
+	//
+	// PublicDNSReady condition messages
+	//
+	// KeystonePublicDNSReadyInitMessage
+	KeystonePublicDNSReadyInitMessage = "Public DNS record check not started"
+
+	// KeystonePublicDNSReadyMessage
+	KeystonePublicDNSReadyMessage = "Public DNS record ready"
+
+	// KeystonePublicDNSReadyWaitingMessage
+	KeystonePublicDNSReadyWaitingMessage = "Waiting for %s to resolve, the catalog keeps the public endpoint %s"
+
+	//
+	// Dev mode condition messages
+	//
+	// KeystoneDevModeDBMessage
+	KeystoneDevModeDBMessage = "Dev mode, the keystone API pod initializes its SQLite database"
+
+	// KeystoneDevModeTransportURLMessage
+	KeystoneDevModeTransportURLMessage = "Dev mode, notifications are disabled"
 )
@@ -20,12 +20,16 @@ import (
 	"fmt"
 	"net/url"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/utils"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/endpoint"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -100,6 +104,32 @@ func GetKeystoneAPI(
 	return &keystoneList.Items[0], nil
 }
 
+// keystoneAPIFinalizer - finalizer of the KeystoneAPI controller
+const keystoneAPIFinalizer = "openstack.org/keystoneapi"
+
+// GetDependents - returns the CRs the deletion of the KeystoneAPI waits for.
+// The KeystoneService, KeystoneEndpoint and the other CRs registering
+// resources in keystone add a finalizer to the KeystoneAPI,
+// "openstack.org/<kind>-<name>", which gets returned as "<kind>/<name>".
+// Other finalizers not belonging to the KeystoneAPI get returned unchanged.
+func (instance *KeystoneAPI) GetDependents() []string {
+	dependents := []string{}
+	for _, finalizer := range instance.Finalizers {
+		if finalizer == keystoneAPIFinalizer {
+			continue
+		}
+		dependent := finalizer
+		if kindName, ok := strings.CutPrefix(finalizer, "openstack.org/keystone"); ok {
+			if kind, name, ok := strings.Cut(kindName, "-"); ok {
+				dependent = "keystone" + kind + "/" + name
+			}
+		}
+		dependents = append(dependents, dependent)
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
 // GetAdminServiceClient - get a system scoped admin serviceClient for the keystoneAPI instance
 func GetAdminServiceClient(
 	ctx context.Context,
@@ -121,22 +151,59 @@ func GetAdminServiceClient(
 	return os, ctrlResult, nil
 }
 
-// GetScopedAdminServiceClient - get a scoped admin serviceClient for the keystoneAPI instance
-func GetScopedAdminServiceClient(
+// GetInternalAuthConfig - returns the internal endpoint of the keystoneAPI
+// instance as auth URL and the TLS config to connect to it
+func GetInternalAuthConfig(
 	ctx context.Context,
 	h *helper.Helper,
 	keystoneAPI *KeystoneAPI,
-	scope *gophercloud.AuthScope,
-) (*openstack.OpenStack, ctrl.Result, error) {
-	// get public endpoint as authurl from keystone instance
+) (string, *openstack.TLSConfig, error) {
 	authURL, err := keystoneAPI.GetEndpoint(endpoint.EndpointInternal)
 	if err != nil {
-		return nil, ctrl.Result{}, err
+		return "", nil, err
+	}
+
+	return getAuthConfig(ctx, h, keystoneAPI, authURL, tls.InternalCABundleKey)
+}
+
+// GetAdminAuthConfig - returns the endpoint set in spec.adminClient of the
+// keystoneAPI instance as auth URL and the TLS config to connect to it
+func GetAdminAuthConfig(
+	ctx context.Context,
+	h *helper.Helper,
+	keystoneAPI *KeystoneAPI,
+) (string, *openstack.TLSConfig, error) {
+	endpointType, source := keystoneAPI.Spec.GetAdminClientEndpoint()
+
+	var authURL string
+	var err error
+	if source == AdminClientURLService {
+		authURL, err = keystoneAPI.GetServiceEndpoint(endpoint.Endpoint(endpointType))
+	} else {
+		authURL, err = keystoneAPI.GetEndpoint(endpoint.Endpoint(endpointType))
+	}
+	if err != nil {
+		return "", nil, err
 	}
 
+	// the public endpoint can be signed by a CA outside of the internal one
+	caBundleKey := tls.InternalCABundleKey
+	if endpointType == service.EndpointPublic {
+		caBundleKey = tls.CABundleKey
+	}
+	return getAuthConfig(ctx, h, keystoneAPI, authURL, caBundleKey)
+}
+
+func getAuthConfig(
+	ctx context.Context,
+	h *helper.Helper,
+	keystoneAPI *KeystoneAPI,
+	authURL string,
+	caBundleKey string,
+) (string, *openstack.TLSConfig, error) {
 	parsedAuthURL, err := url.Parse(authURL)
 	if err != nil {
-		return nil, ctrl.Result{}, err
+		return "", nil, err
 	}
 
 	tlsConfig := &openstack.TLSConfig{}
@@ -146,12 +213,12 @@ func GetScopedAdminServiceClient(
 			h,
 			keystoneAPI.Spec.TLS.CaBundleSecretName,
 			10*time.Second,
-			tls.InternalCABundleKey)
+			caBundleKey)
 		if err != nil {
-			return nil, ctrl.Result{}, err
+			return "", nil, err
 		}
 		if (ctrlResult != ctrl.Result{}) {
-			return nil, ctrl.Result{}, fmt.Errorf("the CABundleSecret %s not found", keystoneAPI.Spec.TLS.CaBundleSecretName)
+			return "", nil, fmt.Errorf("the CABundleSecret %s not found", keystoneAPI.Spec.TLS.CaBundleSecretName)
 		}
 
 		tlsConfig = &openstack.TLSConfig{
@@ -161,6 +228,21 @@ func GetScopedAdminServiceClient(
 		}
 	}
 
+	return authURL, tlsConfig, nil
+}
+
+// GetScopedAdminServiceClient - get a scoped admin serviceClient for the keystoneAPI instance
+func GetScopedAdminServiceClient(
+	ctx context.Context,
+	h *helper.Helper,
+	keystoneAPI *KeystoneAPI,
+	scope *gophercloud.AuthScope,
+) (*openstack.OpenStack, ctrl.Result, error) {
+	authURL, tlsConfig, err := GetAdminAuthConfig(ctx, h, keystoneAPI)
+	if err != nil {
+		return nil, ctrl.Result{}, err
+	}
+
 	// get the password of the admin user from Spec.Secret
 	// using PasswordSelectors.Admin
 	authPassword, ctrlResult, err := secret.GetDataFromSecret(
@@ -192,5 +274,15 @@ func GetScopedAdminServiceClient(
 		return nil, ctrl.Result{}, err
 	}
 
+	// the identity client uses the internal endpoint of the catalog, unless
+	// spec.adminClient selects another endpoint
+	if endpointType, source := keystoneAPI.Spec.GetAdminClientEndpoint(); endpointType != service.EndpointInternal || source != AdminClientURLCatalog {
+		base, err := utils.BaseEndpoint(authURL)
+		if err != nil {
+			return nil, ctrl.Result{}, err
+		}
+		os.GetOSClient().Endpoint = gophercloud.NormalizeURL(base) + "v3/"
+	}
+
 	return os, ctrl.Result{}, nil
 }
@@ -18,6 +18,13 @@ package v1beta1
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	topologyv1 "github.com/openstack-k8s-operators/infra-operator/apis/topology/v1beta1"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
@@ -28,6 +35,7 @@ import (
 	"github.com/openstack-k8s-operators/lib-common/modules/storage"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
@@ -41,9 +49,60 @@ const (
 	// BootstrapHash completed
 	BootstrapHash = "bootstrap"
 
+	// DbGrantsHash - hash of the job which restricted the privileges of the
+	// DatabaseAccount, set while they are restricted
+	DbGrantsHash = "dbgrants"
+
+	// BootstrapRolesHash - bootstrap hash the default roles got verified for
+	BootstrapRolesHash = "bootstraproles"
+
+	// IdentityEndpointsHash - hash of the API endpoints the identity endpoints
+	// of the catalog got updated to
+	IdentityEndpointsHash = "identityendpoints"
+
+	// CatalogHash - hash of the services and endpoints of the catalog the
+	// CatalogVersion got bumped for
+	CatalogHash = "catalog"
+
 	// FernetKeysHash completed
 	FernetKeysHash = "fernetkeys"
 
+	// ConfigReloadHash - hash of the config data the running pods got
+	// reloaded with
+	ConfigReloadHash = "configreload"
+
+	// AutoscalingHash - hash of the KEDA ScaledObject spec
+	AutoscalingHash = "autoscaling"
+
+	// ImageVerifyHash - hash of the image verification job
+	ImageVerifyHash = "imageverify"
+
+	// TokenSmokeTestHash - hash of the password of the smoke test user
+	TokenSmokeTestHash = "tokensmoketest"
+
+	// RebootstrapAnnotation - annotation on the KeystoneAPI, setting it or
+	// changing its value re-runs the bootstrap job to recreate missing admin
+	// user, project, role and endpoint entries, e.g. after a database restore
+	RebootstrapAnnotation = "keystone.openstack.org/rebootstrap"
+
+	// ForceCleanupAnnotation - annotation on the KeystoneAPI or on a CR
+	// registering resources in keystone, e.g. a KeystoneService. Set to
+	// "true" a CR being deleted skips the deletion of its resources in
+	// keystone and only removes its finalizers. On the KeystoneAPI it applies
+	// to all CRs of the namespace. Meant for a keystone or database which is
+	// irrecoverably gone, the resources in keystone are left behind.
+	ForceCleanupAnnotation = "keystone.openstack.org/force-cleanup"
+
+	// RevokeTokensAnnotation - annotation on the KeystoneAPI, setting it or
+	// changing its value replaces all fernet token keys, which invalidates
+	// all issued tokens, e.g. for the response to a leaked token
+	RevokeTokensAnnotation = "keystone.openstack.org/revoke-tokens"
+
+	// KeystoneAPILabel - label the operator sets on the keystone CRs, e.g. a
+	// KeystoneService, with the name of the KeystoneAPI they belong to. Lets
+	// meta-operators select all keystone CRs of a control plane.
+	KeystoneAPILabel = "keystone.openstack.org/keystoneapi"
+
 	// Container image fall-back defaults
 
 	// KeystoneAPIContainerImage is the fall-back container image for KeystoneAPI
@@ -51,8 +110,108 @@ const (
 
 	// APIDefaultTimeout default timeout for HAProxy, Apache
 	APIDefaultTimeout = 60
+
+	// MinFernetMaxActiveKeys - minimum number of fernet keys, the staged, the
+	// primary and a secondary key
+	MinFernetMaxActiveKeys = 3
+
+	// DefaultTokenExpiration - seconds keystone tokens are valid for unless
+	// [token] expiration is set
+	DefaultTokenExpiration = 3600
+)
+
+// DefaultAuthMethods - auth methods keystone enables by default
+var DefaultAuthMethods = []string{
+	"external", "password", "token", "oauth1", "mapped", "application_credential",
+}
+
+// reservedEnvVars - environment variables the operator sets in the keystone
+// containers
+var reservedEnvVars = []string{
+	"KOLLA_CONFIG_STRATEGY", "KOLLA_BOOTSTRAP", "CONFIG_HASH", "KEYSTONE_REBOOTSTRAP_REQUEST",
+}
+
+// reservedEnvVarPrefixes - prefixes of the environment variables the operator
+// sets in the keystone containers
+var reservedEnvVarPrefixes = []string{
+	"OS_BOOTSTRAP_",
+}
+
+// DatabaseReaderSpec - read-only replica of the keystone database, configured
+// as the [database] slave_connection. It gets accessed with the account of
+// DatabaseAccount.
+type DatabaseReaderSpec struct {
+	// +kubebuilder:validation:Required
+	// Host - hostname of the replica, e.g. of a Service selecting the Galera
+	// read replicas, optionally followed by the port
+	Host string `json:"host"`
+}
+
+// PostgreSQLSSLMode - libpq sslmode of the PostgreSQL connection
+type PostgreSQLSSLMode string
+
+const (
+	// PostgreSQLSSLModeDisable - connect without TLS
+	PostgreSQLSSLModeDisable PostgreSQLSSLMode = "disable"
+	// PostgreSQLSSLModePrefer - use TLS if the server supports it
+	PostgreSQLSSLModePrefer PostgreSQLSSLMode = "prefer"
+	// PostgreSQLSSLModeRequire - require TLS without verifying the server
+	PostgreSQLSSLModeRequire PostgreSQLSSLMode = "require"
+	// PostgreSQLSSLModeVerifyCA - require TLS and verify the server certificate
+	PostgreSQLSSLModeVerifyCA PostgreSQLSSLMode = "verify-ca"
+	// PostgreSQLSSLModeVerifyFull - require TLS and verify the server
+	// certificate and hostname
+	PostgreSQLSSLModeVerifyFull PostgreSQLSSLMode = "verify-full"
 )
 
+// PostgreSQLSpec - existing PostgreSQL database of keystone
+type PostgreSQLSpec struct {
+	// +kubebuilder:validation:Required
+	// Host - hostname of the PostgreSQL server, e.g. of the read-write
+	// Service of the PostgreSQL cluster
+	Host string `json:"host"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=5432
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// Port - port of the PostgreSQL server
+	Port int32 `json:"port"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=keystone
+	// Database - name of the database, it has to exist and be owned by the
+	// user
+	Database string `json:"database"`
+
+	// +kubebuilder:validation:Required
+	// SecretName - Secret holding the username and password of the database
+	// user, e.g. the app user Secret of the PostgreSQL operator
+	SecretName string `json:"secretName"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=username
+	// UsernameKey - key of the username in the Secret
+	UsernameKey string `json:"usernameKey"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=password
+	// PasswordKey - key of the password in the Secret
+	PasswordKey string `json:"passwordKey"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=prefer
+	// +kubebuilder:validation:Enum=disable;prefer;require;verify-ca;verify-full
+	// SSLMode - sslmode of the connection. verify-ca and verify-full verify
+	// the server certificate with the CA bundle of tls.caBundleSecretName.
+	SSLMode PostgreSQLSSLMode `json:"sslMode"`
+
+	// +kubebuilder:validation:Optional
+	// Options - additional libpq connection parameters, e.g.
+	// connect_timeout or application_name
+	Options map[string]string `json:"options,omitempty"`
+}
+
 type KeystoneAPISpec struct {
 	KeystoneAPISpecCore `json:",inline"`
 
@@ -74,6 +233,43 @@ type KeystoneAPISpecCore struct {
 	// DatabaseAccount - name of MariaDBAccount which will be used to connect.
 	DatabaseAccount string `json:"databaseAccount"`
 
+	// +kubebuilder:validation:Optional
+	// DatabaseSyncAccount - name of the MariaDBAccount keystone-manage db_sync
+	// connects with, created like DatabaseAccount if it does not exist. With
+	// it set only db_sync may change the schema, the privileges of
+	// DatabaseAccount get restricted to reading and writing rows.
+	DatabaseSyncAccount string `json:"databaseSyncAccount,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// DatabaseReader - read-only replica of the database keystone sends read
+	// queries to, e.g. to take the token validation load off the primary
+	DatabaseReader *DatabaseReaderSpec `json:"databaseReader,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// PostgreSQL - connect keystone to an existing PostgreSQL database, e.g.
+	// of a PostgreSQL operator, instead of creating a MariaDB database on
+	// DatabaseInstance
+	PostgreSQL *PostgreSQLSpec `json:"postgreSQL,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// DevMode - NOT FOR PRODUCTION. Runs keystone with an SQLite database in
+	// the keystone API pod, which gets lost whenever the pod restarts, and
+	// without a RabbitMQ transport URL. The admin password Secret gets
+	// generated if it does not exist. Meant for the CI of operators depending
+	// on keystone, it requires a single replica.
+	DevMode bool `json:"devMode,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// TokenSmokeTest - periodically issue and validate a token with a
+	// dedicated monitoring user from the operator
+	TokenSmokeTest *TokenSmokeTestSpec `json:"tokenSmokeTest,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// AdminClient - endpoint the admin client of the operator and the token
+	// smoke test connect to, e.g. when the internal network is firewalled
+	// from the operator namespace
+	AdminClient *AdminClientSpec `json:"adminClient,omitempty"`
+
 	// +kubebuilder:validation:Required
 	// +kubebuilder:default=memcached
 	// Memcached instance name.
@@ -125,6 +321,32 @@ type KeystoneAPISpecCore struct {
 	// TrustFlushSuspend - Suspend the cron job to purge trusts
 	TrustFlushSuspend bool `json:"trustFlushSuspend"`
 
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=0
+	// +kubebuilder:validation:Minimum=0
+	// TrustFlushAge - Only purge trusts which expired or got soft-deleted more
+	// than the number of days ago. 0 purges all of them.
+	TrustFlushAge int `json:"trustFlushAge"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// TrustFlushSuccessfulJobsHistoryLimit - number of successful trust flush
+	// jobs to keep. Unset uses the Kubernetes default of 3.
+	TrustFlushSuccessfulJobsHistoryLimit *int32 `json:"trustFlushSuccessfulJobsHistoryLimit,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// TrustFlushFailedJobsHistoryLimit - number of failed trust flush jobs to
+	// keep. Unset uses the Kubernetes default of 1.
+	TrustFlushFailedJobsHistoryLimit *int32 `json:"trustFlushFailedJobsHistoryLimit,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// TimeZone - IANA time zone, e.g. Europe/Berlin, the trust flush schedule
+	// gets interpreted in and the keystone containers log their timestamps
+	// in. Unset uses the time zone of the kube-controller-manager for the
+	// schedule and the time zone of the image, usually UTC, for the logs.
+	TimeZone string `json:"timeZone,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:default=1
 	// +kubebuilder:validation:Minimum=1
@@ -146,17 +368,126 @@ type KeystoneAPISpecCore struct {
 	// NodeSelector to target subset of worker nodes running this service
 	NodeSelector *map[string]string `json:"nodeSelector,omitempty"`
 
+	// +kubebuilder:validation:Optional
+	// ImagePullSecrets - secrets used to pull the container images of the
+	// keystone API deployment and jobs from authenticated registries
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// PriorityClassName - priority class of the keystone API pods and jobs,
+	// e.g. to protect them from eviction
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// RuntimeClassName - runtime class of the keystone API pods and jobs,
+	// e.g. to run them in kata containers
+	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// SchedulerName - scheduler of the keystone API pods and jobs, the
+	// default scheduler is used if not set
+	SchedulerName string `json:"schedulerName,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// DNSPolicy - DNS policy of the keystone API pods and jobs
+	DNSPolicy corev1.DNSPolicy `json:"dnsPolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// DNSConfig - DNS parameters of the keystone API pods and jobs, merged
+	// into the config generated by the DNSPolicy
+	DNSConfig *corev1.PodDNSConfig `json:"dnsConfig,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// HostAliases - entries added to /etc/hosts of the keystone API pods and
+	// jobs, e.g. to resolve the public keystone hostname in split-horizon
+	// DNS environments
+	HostAliases []corev1.HostAlias `json:"hostAliases,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=name
+	// ExtraEnv - additional environment variables of the keystone API
+	// container and of the db-sync, bootstrap and trust flush jobs, e.g.
+	// proxy settings or OS_ debug variables. Values can reference Secrets and
+	// ConfigMaps. The variables set by the operator can not be overridden.
+	ExtraEnv []corev1.EnvVar `json:"extraEnv,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:default=false
 	// PreserveJobs - do not delete jobs after they finished e.g. to check logs
 	PreserveJobs bool `json:"preserveJobs"`
 
+	// +kubebuilder:validation:Optional
+	// JobSettings - backoff and retention of the bootstrap, db-sync and cron jobs.
+	// Unset values use the cluster defaults.
+	JobSettings JobSettings `json:"jobSettings,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Restart
+	// +kubebuilder:validation:Enum=Restart;GracefulReload
+	// ConfigReloadStrategy - how changes of the keystone and httpd config get
+	// applied. Restart triggers a rolling restart of the pods, GracefulReload
+	// updates the config in the running pods and gracefully reloads the WSGI
+	// server.
+	// Changes of other inputs, e.g. certificates or fernet keys, always
+	// trigger a rolling restart.
+	ConfigReloadStrategy ConfigReloadStrategy `json:"configReloadStrategy"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=httpd
+	// +kubebuilder:validation:Enum=httpd;uwsgi
+	// WSGIServer - server running the keystone WSGI application. httpd runs it
+	// with mod_wsgi, uwsgi runs it standalone with uWSGI using the
+	// httpdCustomization processNumber. The httpd customConfigSecret and
+	// accessLogFormat, federation and mod_security require httpd.
+	WSGIServer WSGIServer `json:"wsgiServer"`
+
+	// +kubebuilder:validation:Optional
+	// Autoscaling - scale the keystone API deployment with a KEDA
+	// ScaledObject based on the request rate and optionally the CPU
+	// utilization. Requires KEDA to be installed. Replicas is ignored while
+	// autoscaling is enabled.
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ImageVerification - verify the keystone container images before they
+	// get rolled out
+	ImageVerification *ImageVerificationSpec `json:"imageVerification,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// BlueGreen - run a second, green, keystone deployment with a different
+	// container image side by side to the default, blue, one. Both use the
+	// same config and database, the Active field switches the traffic of the
+	// keystone services between them.
+	BlueGreen *BlueGreenSpec `json:"blueGreen,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// PublicDeployment - serve the public endpoint from a separate keystone
+	// deployment with its own replicas, resources and timeout. The default
+	// deployment then only serves the internal endpoint, which isolates the
+	// control plane authentication from the external traffic.
+	PublicDeployment *PublicDeploymentSpec `json:"publicDeployment,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	// CustomServiceConfig - customize the service config using this parameter to change service defaults,
 	// or overwrite rendered information using raw OpenStack config format. The content gets added to
 	// to /etc/<service>/<service>.conf.d directory as custom.conf file.
 	CustomServiceConfig string `json:"customServiceConfig,omitempty"`
 
+	// +kubebuilder:validation:Optional
+	// +listType=atomic
+	// CustomServiceConfigLayers - ordered layers of service config, e.g. a
+	// base, an environment and a site layer shared by many KeystoneAPI CRs.
+	// The layers get merged in order, an option set in a later layer
+	// replaces the option of the earlier layers. CustomServiceConfig gets
+	// merged last.
+	CustomServiceConfigLayers []ServiceConfigLayer `json:"customServiceConfigLayers,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ConfigDiff - add the diff of the rendered keystone config against the
+	// last applied one to the config preview Secret
+	ConfigDiff bool `json:"configDiff,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	// ConfigOverwrite - interface to overwrite default config files like e.g. policy.json.
 	// But can also be used to add additional files. Those get added to the service config dir in /etc/<service> .
@@ -168,6 +499,37 @@ type KeystoneAPISpecCore struct {
 	// HttpdCustomization - customize the httpd service
 	HttpdCustomization HttpdCustomization `json:"httpdCustomization"`
 
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=60
+	// +kubebuilder:validation:Minimum=1
+	// TerminationGracePeriodSeconds - duration in seconds the keystone API
+	// pods get to drain connections and shut down before they get killed
+	TerminationGracePeriodSeconds int64 `json:"terminationGracePeriodSeconds"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=5
+	// +kubebuilder:validation:Minimum=0
+	// PreStopDrainSeconds - duration in seconds a stopping keystone API pod
+	// keeps serving requests after it reported itself unhealthy, before the
+	// WSGI server gracefully stops and finishes the requests in flight. Gives the
+	// services and routes time to stop sending new requests to the pod.
+	// 0 disables the preStop hook.
+	PreStopDrainSeconds int64 `json:"preStopDrainSeconds"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// ZoneSpreadMaxSkew - spread the keystone API pods across the
+	// topology.kubernetes.io/zone zones with the given maximum skew. The pods
+	// still get scheduled if the skew can not be satisfied. Ignored if a
+	// topologyRef is set.
+	ZoneSpreadMaxSkew *int32 `json:"zoneSpreadMaxSkew,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default={backends: {disable_by_file}}
+	// Healthcheck - configure the oslo.middleware healthcheck the readiness
+	// probe of the keystone API uses
+	Healthcheck HealthcheckSpec `json:"healthcheck"`
+
 	// +kubebuilder:validation:Optional
 	// Resources - Compute Resources required by this service (Limits/Requests).
 	// https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/
@@ -181,6 +543,14 @@ type KeystoneAPISpecCore struct {
 	// Override, provides the ability to override the generated manifest of several child resources.
 	Override APIOverrideSpec `json:"override,omitempty"`
 
+	// +kubebuilder:validation:Optional
+	// PublishedEndpoints - overrides parts of the identity endpoint URLs
+	// registered in the catalog and published in the status, e.g. for an
+	// external load balancer, proxy or CDN in front of keystone. The Services
+	// keep listening on their ports. The key must be the endpoint type
+	// (public, internal).
+	PublishedEndpoints map[service.Endpoint]PublishedEndpointSpec `json:"publishedEndpoints,omitempty"`
+
 	// +kubebuilder:validation:Required
 	// +kubebuilder:default=rabbitmq
 	// RabbitMQ instance name
@@ -198,6 +568,62 @@ type KeystoneAPISpecCore struct {
 	// APITimeout for HAProxy, Apache
 	APITimeout int `json:"apiTimeout"`
 
+	// +kubebuilder:validation:Optional
+	// RateLimit - per client IP rate limits of the public route, enforced by
+	// the OpenShift router to protect keystone from credential stuffing and
+	// runaway clients
+	RateLimit *RateLimitSpec `json:"rateLimit,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// RouteTLS - TLS termination of the routes exposing the endpoints. The
+	// key must be the endpoint type (public, internal). Endpoints without an
+	// entry keep the termination chosen by the route creator.
+	RouteTLS map[service.Endpoint]RouteTLSSpec `json:"routeTLS,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// PublicDNS - publish a DNS record of the public endpoint with
+	// external-dns. The hostname gets registered in the catalog once it
+	// resolves.
+	PublicDNS *PublicDNSSpec `json:"publicDNS,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// PublicSecurity - security headers and web application firewall of the
+	// public endpoint, which is frequently exposed to the internet
+	PublicSecurity *PublicSecuritySpec `json:"publicSecurity,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// AuditLog - write the CADF audit events of keystone to a separate audit
+	// log, streamed by a sidecar container, instead of mixing them with the
+	// access and service logs
+	AuditLog *AuditLogSpec `json:"auditLog,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Notifications - format, opted out event types, topics and routing of
+	// the notifications keystone emits
+	Notifications *NotificationsSpec `json:"notifications,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Kerberos - authenticate users with Kerberos tickets of an Active
+	// Directory or KDC, the tickets get negotiated by mod_auth_gssapi on the
+	// /krb path of the API
+	Kerberos *KerberosSpec `json:"kerberos,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// TokenlessAuth - authorize requests of services by their X.509 client
+	// certificate instead of a token. The client certificates get verified
+	// by httpd on port 5001 of the internal service.
+	TokenlessAuth *TokenlessAuthSpec `json:"tokenlessAuth,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// MFA - enable the auth methods of multi-factor authentication. The
+	// rules requiring the factors get set per user in its options.
+	MFA *MFASpec `json:"mfa,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Token - [token] caching and [revoke] settings of keystone, trading the
+	// token validation performance against how fast revocations take effect
+	Token *TokenSpec `json:"token,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	// TopologyRef to apply the Topology defined by the associated CR referenced
 	// by name
@@ -217,6 +643,164 @@ type KeystoneAPISpecCore struct {
 	// This is only needed when multiple realms are federated.
 	// If not specified, "/etc/httpd/conf" is used
 	FederationMountPath string `json:"federationMountPath"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=atomic
+	// FederationTrustedDashboards - URLs of the dashboards keystone redirects
+	// to after a WebSSO login, e.g. https://horizon.example.com/dashboard/auth/websso/
+	FederationTrustedDashboards []string `json:"federationTrustedDashboards,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// FederationSSOCallbackTemplate - HTML template keystone renders to post the
+	// token of a WebSSO login back to the trusted dashboard. If not specified,
+	// the template shipped with keystone is used.
+	FederationSSOCallbackTemplate string `json:"federationSSOCallbackTemplate,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=flat
+	// +kubebuilder:validation:Enum=flat;strict_two_level
+	// LimitEnforcementModel - enforcement model of the unified limits which
+	// services using oslo.limit apply. Domain limits of KeystoneLimit
+	// require strict_two_level.
+	LimitEnforcementModel string `json:"limitEnforcementModel,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=atomic
+	// ImpliedRoles - implied role relationships to ensure after bootstrap,
+	// e.g. admin implies member and member implies reader. Roles which do not
+	// exist get created. Relationships removed from the list get deleted.
+	ImpliedRoles []ImpliedRole `json:"impliedRoles,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// DomainConfigs - configuration files of domain specific identity drivers,
+	// e.g. LDAP, keyed by domain name. Enables domain_specific_drivers_enabled
+	// and places the files into domain_config_dir.
+	DomainConfigs map[string]DomainConfigSource `json:"domainConfigs,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Drivers - drivers of the identity, assignment and resource backends of
+	// keystone, instead of setting their driver options in
+	// customServiceConfig
+	Drivers *KeystoneDrivers `json:"drivers,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// CloudEvents - send CloudEvents when the operator creates, updates or
+	// deletes services, endpoints or users in this keystone
+	CloudEvents *CloudEventsSpec `json:"cloudEvents,omitempty"`
+}
+
+// CloudEventsSpec - sink of the CloudEvents about catalog changes
+type CloudEventsSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// SinkURL - http(s) URL the events get posted to using the binary
+	// content mode of the CloudEvents HTTP protocol binding
+	SinkURL string `json:"sinkURL"`
+}
+
+// ServiceConfigLayer - source of a service config layer, exactly one of
+// SecretKeyRef and ConfigMapKeyRef must be set
+type ServiceConfigLayer struct {
+	// +kubebuilder:validation:Optional
+	// SecretKeyRef - key of a Secret holding the service config layer
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ConfigMapKeyRef - key of a ConfigMap holding the service config layer
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// KeystoneDriver - keystone backend driver
+// +kubebuilder:validation:Enum=sql;ldap
+type KeystoneDriver string
+
+const (
+	// KeystoneDriverSQL - data kept in the keystone database
+	KeystoneDriverSQL KeystoneDriver = "sql"
+	// KeystoneDriverLDAP - data read from an LDAP server
+	KeystoneDriverLDAP KeystoneDriver = "ldap"
+)
+
+// DefaultDomainName - name of the domain keystone-manage bootstrap creates,
+// holding the admin and the service users
+const DefaultDomainName = "Default"
+
+// KeystoneDrivers - drivers of the keystone backends
+type KeystoneDrivers struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=sql
+	// Identity - identity driver of the Default domain. With ldap the users
+	// and groups of the Default domain get read from the LDAP server of
+	// domainConfigs.Default, the other domains keep their driver.
+	Identity KeystoneDriver `json:"identity,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=sql
+	// Assignment - driver of the role assignments, keystone only keeps them
+	// in sql
+	Assignment KeystoneDriver `json:"assignment,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=sql
+	// Resource - driver of the projects and domains, keystone only keeps
+	// them in sql
+	Resource KeystoneDriver `json:"resource,omitempty"`
+}
+
+// DomainConfigSource - source of the configuration file of a domain, exactly
+// one of SecretKeyRef and ConfigMapKeyRef must be set
+type DomainConfigSource struct {
+	// +kubebuilder:validation:Optional
+	// SecretKeyRef - key of a Secret holding the domain configuration
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ConfigMapKeyRef - key of a ConfigMap holding the domain configuration
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// LDAP - connection settings of an LDAP domain, added to the [ldap]
+	// section of the domain configuration. The operator periodically checks
+	// the LDAP servers of the url option of the domain are reachable.
+	LDAP *LDAPDomainSpec `json:"ldap,omitempty"`
+}
+
+// LDAPDomainSpec - connection settings of an LDAP domain, unset options keep
+// the value of the domain configuration or the keystone default
+type LDAPDomainSpec struct {
+	// +kubebuilder:validation:Optional
+	// UsePool - use a pool of LDAP connections
+	UsePool *bool `json:"usePool,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// PoolSize - size of the LDAP connection pool
+	PoolSize *int32 `json:"poolSize,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// PoolRetryMax - number of retries to get a connection of the pool
+	PoolRetryMax *int32 `json:"poolRetryMax,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ChaseReferrals - follow the referrals returned by the LDAP server
+	ChaseReferrals *bool `json:"chaseReferrals,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// CACertSecretRef - key of a Secret holding the CA certificate verifying
+	// the LDAP servers, set as tls_cacertfile of the domain
+	CACertSecretRef *corev1.SecretKeySelector `json:"caCertSecretRef,omitempty"`
+}
+
+// ImpliedRole - a prior role which implies another role
+type ImpliedRole struct {
+	// +kubebuilder:validation:Required
+	// PriorRole - name of the role which implies ImpliedRole
+	PriorRole string `json:"priorRole"`
+
+	// +kubebuilder:validation:Required
+	// ImpliedRole - name of the role implied by PriorRole
+	ImpliedRole string `json:"impliedRole"`
 }
 
 // APIOverrideSpec to override the generated manifest of several child resources.
@@ -226,6 +810,56 @@ type APIOverrideSpec struct {
 	Service map[service.Endpoint]service.RoutedOverrideSpec `json:"service,omitempty"`
 }
 
+// PublishedEndpointSpec - parts of a published endpoint URL, unset parts keep
+// the ones of the URL derived from the Service
+type PublishedEndpointSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=http;https
+	// Scheme - scheme of the URL
+	Scheme string `json:"scheme,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Host - hostname of the URL
+	Host string `json:"host,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// Port - port of the URL
+	Port *int32 `json:"port,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Path - path of the URL, e.g. /identity
+	Path string `json:"path,omitempty"`
+}
+
+// Apply - returns the endpoint URL with the parts of the published endpoint
+func (instance PublishedEndpointSpec) Apply(endpointURL string) (string, error) {
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return "", err
+	}
+	if instance.Scheme != "" {
+		u.Scheme = instance.Scheme
+	}
+	host := u.Hostname()
+	if instance.Host != "" {
+		host = instance.Host
+	}
+	port := u.Port()
+	if instance.Port != nil {
+		port = strconv.Itoa(int(*instance.Port))
+	}
+	u.Host = host
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	}
+	if instance.Path != "" {
+		u.Path = "/" + strings.Trim(instance.Path, "/")
+	}
+	return u.String(), nil
+}
+
 // PasswordSelector to identify the DB and AdminUser password from the Secret
 type PasswordSelector struct {
 	// +kubebuilder:validation:Optional
@@ -251,41 +885,901 @@ type HttpdCustomization struct {
 	// For information on how sections in httpd configuration get merged, check section
 	// "How the sections are merged" in https://httpd.apache.org/docs/current/sections.html#merging
 	CustomConfigSecret *string `json:"customConfigSecret,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// AccessLogFormat - httpd LogFormat of the access log entries following the
+	// client address. If not specified, the combined format extended with the
+	// global request ID clients send and the request ID keystone returns in the
+	// X-OpenStack-Request-ID headers is used, which allows tracing requests
+	// across services.
+	AccessLogFormat string `json:"accessLogFormat,omitempty"`
 }
 
-// KeystoneAPIStatus defines the observed state of KeystoneAPI
-type KeystoneAPIStatus struct {
-	// ReadyCount of keystone API instances
-	ReadyCount int32 `json:"readyCount,omitempty"`
+// AutoscalingSpec - KEDA based autoscaling of the keystone API deployment
+type AutoscalingSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// MinReplicas - lower limit of the keystone API replicas
+	MinReplicas int32 `json:"minReplicas"`
 
-	// Map of hashes to track e.g. job status
-	Hash map[string]string `json:"hash,omitempty"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// MaxReplicas - upper limit of the keystone API replicas
+	MaxReplicas int32 `json:"maxReplicas"`
 
-	// API endpoint
-	APIEndpoints map[string]string `json:"apiEndpoints,omitempty"`
+	// +kubebuilder:validation:Required
+	// PrometheusServerAddress - address of the Prometheus server the request
+	// rate gets queried from
+	PrometheusServerAddress string `json:"prometheusServerAddress"`
 
-	// Conditions
-	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+	// +kubebuilder:validation:Optional
+	// Query - Prometheus query returning the requests per second of the
+	// keystone API. Defaults to the rate of the HAProxy router responses of
+	// the keystone public route.
+	Query string `json:"query,omitempty"`
 
-	// Keystone Database Hostname
-	DatabaseHostname string `json:"databaseHostname,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="50"
+	// RequestsPerSecondThreshold - requests per second per replica the
+	// deployment gets scaled on
+	RequestsPerSecondThreshold string `json:"requestsPerSecondThreshold"`
 
-	// NetworkAttachments status of the deployment pods
-	NetworkAttachments map[string][]string `json:"networkAttachments,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// CPUUtilization - average CPU utilization in percent the deployment
+	// additionally gets scaled on
+	CPUUtilization *int32 `json:"cpuUtilization,omitempty"`
 
-	// TransportURLSecret - Secret containing RabbitMQ transportURL
-	TransportURLSecret string `json:"transportURLSecret,omitempty"`
+	// +kubebuilder:validation:Optional
+	// TriggerAuthenticationName - name of a KEDA TriggerAuthentication used
+	// to authenticate against the Prometheus server
+	TriggerAuthenticationName string `json:"triggerAuthenticationName,omitempty"`
+}
 
-	//ObservedGeneration - the most recent generation observed for this service. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
-	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+// ImageVerificationSpec - verify the keystone container images
+type ImageVerificationSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// RequireDigest - only accept container images referenced by digest,
+	// e.g. quay.io/podified-antelope-centos9/openstack-keystone@sha256:<digest>
+	RequireDigest bool `json:"requireDigest"`
 
-	// LastAppliedTopology - the last applied Topology
-	LastAppliedTopology *topologyv1.TopoRef `json:"lastAppliedTopology,omitempty"`
-}
+	// +kubebuilder:validation:Optional
+	// CosignKeySecret - name of a Secret holding the cosign public key in the
+	// cosign.pub key the image signatures get verified against
+	CosignKeySecret string `json:"cosignKeySecret,omitempty"`
 
-//+kubebuilder:object:root=true
-//+kubebuilder:subresource:status
-//+kubebuilder:printcolumn:name="NetworkAttachments",type="string",JSONPath=".spec.networkAttachments",description="NetworkAttachments"
+	// +kubebuilder:validation:Optional
+	// CertificateIdentity - identity of the keyless signing certificate the
+	// image signatures get verified against, requires CertificateOIDCIssuer
+	CertificateIdentity string `json:"certificateIdentity,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// CertificateOIDCIssuer - OIDC issuer of the keyless signing certificate
+	CertificateOIDCIssuer string `json:"certificateOIDCIssuer,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="ghcr.io/sigstore/cosign/cosign:v2.4.1"
+	// CosignImage - container image of the job running cosign verify
+	CosignImage string `json:"cosignImage"`
+}
+
+// VerifySignature - returns true if the image signatures get verified
+func (spec ImageVerificationSpec) VerifySignature() bool {
+	return spec.CosignKeySecret != "" || spec.CertificateIdentity != "" || spec.CertificateOIDCIssuer != ""
+}
+
+// BlueGreenColor - deployment of a blue/green setup
+type BlueGreenColor string
+
+const (
+	// BlueGreenBlue - the default keystone deployment
+	BlueGreenBlue BlueGreenColor = "blue"
+	// BlueGreenGreen - the keystone deployment running BlueGreenSpec.ContainerImage
+	BlueGreenGreen BlueGreenColor = "green"
+)
+
+// RateLimitSpec - per client IP rate limits of the public route. Unset limits
+// are not enforced.
+type RateLimitSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// ConcurrentConnections - concurrent TCP connections of a client IP
+	ConcurrentConnections *int32 `json:"concurrentConnections,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// HTTPRequestRate - HTTP requests of a client IP within the tracking
+	// period of the router
+	HTTPRequestRate *int32 `json:"httpRequestRate,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// TCPConnectionRate - new TCP connections of a client IP within the
+	// tracking period of the router
+	TCPConnectionRate *int32 `json:"tcpConnectionRate,omitempty"`
+}
+
+const (
+	// ExternalDNSHostnameAnnotation - annotation with the hostname external-dns
+	// publishes a record for
+	ExternalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+	// ExternalDNSTTLAnnotation - annotation with the TTL of the record
+	// external-dns publishes
+	ExternalDNSTTLAnnotation = "external-dns.alpha.kubernetes.io/ttl"
+)
+
+// PublicDNSSpec - DNS record of the public endpoint published by external-dns
+type PublicDNSSpec struct {
+	// +kubebuilder:validation:Required
+	// Hostname - hostname of the public endpoint
+	Hostname string `json:"hostname"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// TTL - TTL of the record in seconds, the external-dns default is used if
+	// not set
+	TTL *int32 `json:"ttl,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Annotations - additional annotations for external-dns, e.g.
+	// external-dns.alpha.kubernetes.io/target or provider specific ones
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// GetAnnotations - returns the annotations of the public Service and route
+// external-dns publishes the record from
+func (instance PublicDNSSpec) GetAnnotations() map[string]string {
+	annotations := map[string]string{}
+	for k, v := range instance.Annotations {
+		annotations[k] = v
+	}
+	annotations[ExternalDNSHostnameAnnotation] = instance.Hostname
+	if instance.TTL != nil {
+		annotations[ExternalDNSTTLAnnotation] = strconv.Itoa(int(*instance.TTL))
+	}
+	return annotations
+}
+
+// RouteTermination - TLS termination of a route
+type RouteTermination string
+
+const (
+	// RouteTerminationEdge - the router terminates TLS and forwards plain
+	// HTTP to keystone
+	RouteTerminationEdge RouteTermination = "edge"
+	// RouteTerminationReencrypt - the router terminates TLS and opens a new
+	// TLS connection to keystone
+	RouteTerminationReencrypt RouteTermination = "reencrypt"
+	// RouteTerminationPassthrough - the router forwards the TLS connection,
+	// keystone terminates TLS with its own certificate
+	RouteTerminationPassthrough RouteTermination = "passthrough"
+)
+
+// RouteTLSSpec - TLS termination of the route exposing an endpoint
+type RouteTLSSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=edge;reencrypt;passthrough
+	// Termination - where TLS gets terminated. reencrypt and passthrough
+	// require TLS of the endpoint.
+	Termination RouteTermination `json:"termination"`
+
+	// +kubebuilder:validation:Optional
+	// DestinationCACertificate - PEM encoded CA the router verifies the
+	// keystone certificate with on reencrypt. The route creator's default is
+	// kept if not set.
+	DestinationCACertificate string `json:"destinationCACertificate,omitempty"`
+}
+
+// AuditLogVerbosity - audit events written to the audit log
+type AuditLogVerbosity string
+
+const (
+	// AuditLogStandard - the keystone default events, without the
+	// authentication events
+	AuditLogStandard AuditLogVerbosity = "Standard"
+	// AuditLogFull - all events, including the authentication events
+	AuditLogFull AuditLogVerbosity = "Full"
+)
+
+// NotificationFormat - format of the notifications keystone emits
+type NotificationFormat string
+
+const (
+	// NotificationFormatBasic - basic notifications, only the ID of the
+	// changed resource
+	NotificationFormatBasic NotificationFormat = "basic"
+	// NotificationFormatCADF - CADF audit events
+	NotificationFormatCADF NotificationFormat = "cadf"
+)
+
+// NotificationEventType - event type of a keystone notification
+// +kubebuilder:validation:Pattern=`^identity\.`
+type NotificationEventType string
+
+// NotificationDestination - where routed notifications get sent to
+type NotificationDestination string
+
+const (
+	// NotificationDestinationMessaging - the notification topics on RabbitMQ
+	NotificationDestinationMessaging NotificationDestination = "messaging"
+	// NotificationDestinationLog - the log, the audit log with auditLog set
+	NotificationDestinationLog NotificationDestination = "log"
+)
+
+// NotificationsSpec - notifications keystone emits
+type NotificationsSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=basic;cadf
+	// Format - format of the notifications, keystone defaults to cadf
+	Format NotificationFormat `json:"format,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// OptOut - event types keystone does not emit, e.g.
+	// identity.authenticate.success. Unset keeps the keystone default, which
+	// opts out of the authentication events, or with the Full auditLog
+	// verbosity emits all events
+	OptOut []NotificationEventType `json:"optOut,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Topics - topics the notifications get sent to on RabbitMQ, defaults to
+	// barbican_notifications
+	Topics []string `json:"topics,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Routes - send the event types to destinations, e.g. to keep the high
+	// volume authentication events off RabbitMQ but in the audit log. Events
+	// not matching any route get dropped. Unset sends all events to RabbitMQ,
+	// or with auditLog set to the audit log.
+	Routes []NotificationRoute `json:"routes,omitempty"`
+}
+
+// NotificationRoute - event types sent to a destination
+type NotificationRoute struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=messaging;log
+	// Destination - messaging sends the events to the topics on RabbitMQ, log
+	// writes them to the log
+	Destination NotificationDestination `json:"destination"`
+
+	// +kubebuilder:validation:Optional
+	// EventTypes - shell style patterns of the event types routed, e.g.
+	// identity.user.*, all event types if empty
+	EventTypes []string `json:"eventTypes,omitempty"`
+}
+
+// AuditLogSpec - separate audit log of the keystone API
+type AuditLogSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Standard
+	// +kubebuilder:validation:Enum=Standard;Full
+	// Verbosity - Standard writes the keystone default events, Full also the
+	// authentication success and failure events
+	Verbosity AuditLogVerbosity `json:"verbosity"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=100
+	// +kubebuilder:validation:Minimum=1
+	// MaxFileSizeMB - size in MB the audit log gets rotated at
+	MaxFileSizeMB int32 `json:"maxFileSizeMB"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=5
+	// +kubebuilder:validation:Minimum=1
+	// MaxFiles - number of rotated audit logs kept in the pod
+	MaxFiles int32 `json:"maxFiles"`
+
+	// +kubebuilder:validation:Optional
+	// Shipper - container shipping the audit log, replaces the default
+	// sidecar streaming the audit log to its output. The audit log directory
+	// is mounted into the container and its path set in the AUDIT_LOG_FILE
+	// environment variable.
+	Shipper *AuditLogShipperSpec `json:"shipper,omitempty"`
+}
+
+// AuditLogShipperSpec - container shipping the audit log
+type AuditLogShipperSpec struct {
+	// +kubebuilder:validation:Required
+	// ContainerImage - image of the shipper container
+	ContainerImage string `json:"containerImage"`
+
+	// +kubebuilder:validation:Optional
+	// Command - entrypoint of the shipper container, defaults to the one of
+	// the image
+	Command []string `json:"command,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Args - arguments of the shipper container
+	Args []string `json:"args,omitempty"`
+}
+
+// KerberosSpec - Kerberos authentication of the keystone API
+type KerberosSpec struct {
+	// +kubebuilder:validation:Required
+	// KeytabSecretRef - key of the Secret holding the keytab with the
+	// HTTP/<host> service principals of the keystone API
+	KeytabSecretRef corev1.SecretKeySelector `json:"keytabSecretRef"`
+
+	// +kubebuilder:validation:Optional
+	// Krb5Conf - content of /etc/krb5.conf with the realms and KDCs, the
+	// realm is looked up in DNS if not set
+	Krb5Conf string `json:"krb5Conf,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// Domain - keystone domain of the users authenticated with Kerberos, set
+	// as REMOTE_DOMAIN
+	Domain string `json:"domain"`
+}
+
+// TokenlessAuthSpec - X.509 tokenless authorization of the keystone API
+type TokenlessAuthSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// TrustedIssuers - distinguished names of the issuers of the client
+	// certificates allowed to use tokenless authorization, e.g.
+	// CN=Keystone CA,O=OpenStack
+	TrustedIssuers []string `json:"trustedIssuers"`
+
+	// +kubebuilder:validation:Required
+	// CABundleSecretRef - key of the Secret holding the CA bundle the client
+	// certificates get verified with
+	CABundleSecretRef corev1.SecretKeySelector `json:"caBundleSecretRef"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=x509
+	// Protocol - federation protocol id the client certificate attributes
+	// get mapped with
+	Protocol string `json:"protocol"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=SSL_CLIENT_I_DN
+	// IssuerAttribute - request environment variable holding the issuer of
+	// the client certificate
+	IssuerAttribute string `json:"issuerAttribute"`
+}
+
+// MFAMethod - auth method used as additional factor
+// +kubebuilder:validation:Enum=totp
+type MFAMethod string
+
+const (
+	// MFAMethodTOTP - time-based one-time password
+	MFAMethodTOTP MFAMethod = "totp"
+)
+
+// MFASpec - multi-factor authentication of the keystone API
+type MFASpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default={totp}
+	// Methods - auth methods enabled as additional factors
+	Methods []MFAMethod `json:"methods"`
+
+	// +kubebuilder:validation:Optional
+	// Receipt - settings of the receipts issued when only some of the
+	// required factors got provided
+	Receipt *ReceiptSpec `json:"receipt,omitempty"`
+}
+
+// ReceiptSpec - [receipt] settings of keystone, unset options keep the
+// keystone defaults
+type ReceiptSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=86400
+	// Expiration - seconds a receipt is valid for, the time a user has to
+	// provide the remaining factors
+	Expiration *int32 `json:"expiration,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=fernet
+	// Provider - receipt provider
+	Provider string `json:"provider,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Caching - cache the validated receipts
+	Caching *bool `json:"caching,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// CacheTime - seconds validated receipts are cached for
+	CacheTime *int32 `json:"cacheTime,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// CacheOnIssue - cache receipts when they get issued
+	CacheOnIssue *bool `json:"cacheOnIssue,omitempty"`
+}
+
+// AdminClientURL - where the admin client takes the endpoint URL from
+type AdminClientURL string
+
+const (
+	// AdminClientURLCatalog - the endpoint URL registered in the catalog and
+	// published in status.apiEndpoints
+	AdminClientURLCatalog AdminClientURL = "catalog"
+	// AdminClientURLService - the URL of the k8s Service of the endpoint,
+	// published in status.serviceEndpoints
+	AdminClientURLService AdminClientURL = "service"
+)
+
+// AdminClientSpec - endpoint the operator connects to keystone through
+type AdminClientSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=internal
+	// +kubebuilder:validation:Enum=internal;public
+	// Interface - endpoint interface of keystone to connect to
+	Interface service.Endpoint `json:"interface,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=catalog
+	// +kubebuilder:validation:Enum=catalog;service
+	// URL - use the endpoint URL of the catalog or of the k8s Service
+	URL AdminClientURL `json:"url,omitempty"`
+}
+
+// TokenSpec - [token] caching and revocation settings of keystone, unset
+// options keep the keystone defaults
+type TokenSpec struct {
+	// +kubebuilder:validation:Optional
+	// Caching - cache the validated tokens, a revoked token stays valid on
+	// the API pods which cached it until the cache entry expires
+	Caching *bool `json:"caching,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// CacheTime - seconds validated tokens are cached for, defaults to the
+	// [cache] expiration_time
+	CacheTime *int32 `json:"cacheTime,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// CacheOnIssue - cache tokens when they get issued. Deprecated in
+	// keystone, which always caches issued tokens when caching is enabled
+	CacheOnIssue *bool `json:"cacheOnIssue,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// RevokeByID - revoke individual tokens by their ID, when disabled a
+	// token revoked by the API stays valid until it expires
+	RevokeByID *bool `json:"revokeByID,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Revoke - [revoke] settings of the revocation events
+	Revoke *RevokeSpec `json:"revoke,omitempty"`
+}
+
+// RevokeSpec - [revoke] settings of keystone, unset options keep the
+// keystone defaults
+type RevokeSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=sql
+	// Driver - backend of the revocation events
+	Driver string `json:"driver,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// ExpirationBuffer - seconds revocation events are kept after the tokens
+	// they revoke expired
+	ExpirationBuffer *int32 `json:"expirationBuffer,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Caching - cache the revocation events
+	Caching *bool `json:"caching,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// CacheTime - seconds the revocation events are cached for, defaults to
+	// the [cache] expiration_time
+	CacheTime *int32 `json:"cacheTime,omitempty"`
+}
+
+// PublicSecuritySpec - security headers and web application firewall of the
+// public endpoint
+type PublicSecuritySpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// HSTSMaxAge - max-age in seconds of the Strict-Transport-Security header,
+	// the header is not sent if unset
+	HSTSMaxAge int64 `json:"hstsMaxAge,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// HSTSIncludeSubDomains - apply the Strict-Transport-Security header to
+	// the subdomains
+	HSTSIncludeSubDomains bool `json:"hstsIncludeSubDomains,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=true
+	// NoSniff - send the X-Content-Type-Options: nosniff header
+	NoSniff bool `json:"noSniff"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=DENY;SAMEORIGIN
+	// FrameOptions - value of the X-Frame-Options header, the header is not
+	// sent if unset
+	FrameOptions string `json:"frameOptions,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ExtraHeaders - additional response headers
+	ExtraHeaders map[string]string `json:"extraHeaders,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ModSecurity - filter the requests with mod_security, requires the
+	// mod_security module in the keystone container image and wsgiServer
+	// httpd
+	ModSecurity *ModSecuritySpec `json:"modSecurity,omitempty"`
+}
+
+// ModSecurityEngine - mode of the mod_security rule engine
+type ModSecurityEngine string
+
+const (
+	// ModSecurityOn - requests matching the rules get blocked
+	ModSecurityOn ModSecurityEngine = "On"
+	// ModSecurityDetectionOnly - requests matching the rules only get logged
+	ModSecurityDetectionOnly ModSecurityEngine = "DetectionOnly"
+)
+
+// ModSecuritySpec - mod_security config of the public endpoint
+type ModSecuritySpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=On
+	// +kubebuilder:validation:Enum=On;DetectionOnly
+	// RuleEngine - On blocks requests matching the rules, DetectionOnly only
+	// logs them
+	RuleEngine ModSecurityEngine `json:"ruleEngine"`
+
+	// +kubebuilder:validation:Optional
+	// CustomRules - mod_security rules added to the rules activated in the
+	// container image
+	CustomRules string `json:"customRules,omitempty"`
+}
+
+// GetHeaders - response headers of the public endpoint
+func (instance *PublicSecuritySpec) GetHeaders() map[string]string {
+	headers := map[string]string{}
+	for name, value := range instance.ExtraHeaders {
+		headers[name] = value
+	}
+	if instance.HSTSMaxAge > 0 {
+		hsts := fmt.Sprintf("max-age=%d", instance.HSTSMaxAge)
+		if instance.HSTSIncludeSubDomains {
+			hsts += "; includeSubDomains"
+		}
+		headers["Strict-Transport-Security"] = hsts
+	}
+	if instance.NoSniff {
+		headers["X-Content-Type-Options"] = "nosniff"
+	}
+	if instance.FrameOptions != "" {
+		headers["X-Frame-Options"] = instance.FrameOptions
+	}
+	return headers
+}
+
+// PublicDeploymentSpec - separate deployment serving the public endpoint
+type PublicDeploymentSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Maximum=32
+	// +kubebuilder:validation:Minimum=0
+	// Replicas of the public keystone API deployment
+	Replicas *int32 `json:"replicas"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=10
+	// APITimeout - timeout of the public endpoint for HAProxy and Apache,
+	// defaults to the apiTimeout of the KeystoneAPI
+	APITimeout *int `json:"apiTimeout,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Resources - Compute Resources of the public keystone API pods, defaults
+	// to the resources of the KeystoneAPI
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// GetPublicAPITimeout - timeout of the public endpoint
+func (instance *KeystoneAPISpecCore) GetPublicAPITimeout() int {
+	if instance.PublicDeployment != nil && instance.PublicDeployment.APITimeout != nil {
+		return *instance.PublicDeployment.APITimeout
+	}
+	return instance.APITimeout
+}
+
+// BlueGreenSpec - run a second keystone deployment side by side
+type BlueGreenSpec struct {
+	// +kubebuilder:validation:Required
+	// ContainerImage - keystone API container image of the green deployment
+	ContainerImage string `json:"containerImage"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=blue
+	// +kubebuilder:validation:Enum=blue;green
+	// Active - deployment the keystone services send the traffic to
+	Active BlueGreenColor `json:"active"`
+}
+
+// ConfigReloadStrategy - how config changes get applied to the running pods
+type ConfigReloadStrategy string
+
+const (
+	// ConfigReloadRestart - config changes trigger a rolling restart
+	ConfigReloadRestart ConfigReloadStrategy = "Restart"
+	// ConfigReloadGraceful - config changes trigger a graceful reload
+	ConfigReloadGraceful ConfigReloadStrategy = "GracefulReload"
+)
+
+// WSGIServer - server running the keystone WSGI application
+type WSGIServer string
+
+const (
+	// WSGIServerHttpd - httpd with mod_wsgi
+	WSGIServerHttpd WSGIServer = "httpd"
+	// WSGIServerUWSGI - standalone uWSGI
+	WSGIServerUWSGI WSGIServer = "uwsgi"
+)
+
+// JobSettings - backoff and retention of the jobs run for the keystone API
+type JobSettings struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// BackoffLimit - number of retries before a job is marked failed
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// ActiveDeadlineSeconds - duration in seconds a job may run before it
+	// gets terminated and marked failed
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// TTLSecondsAfterFinished - duration in seconds a finished job is kept
+	// before it gets deleted. Ignored for the bootstrap and db-sync jobs
+	// if PreserveJobs is set.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// TokenSmokeTestSpec - token issuance smoke test of the keystone API
+type TokenSmokeTestSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=60
+	// Interval - seconds between the smoke tests, defaults to 300
+	Interval *int32 `json:"interval,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// FailureThreshold - number of failed smoke tests in a row which set the
+	// TokenSmokeTestReady condition to False, defaults to 3
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+}
+
+// TokenSmokeTestStatus - result of the token issuance smoke tests
+type TokenSmokeTestStatus struct {
+	// LastRunTime - time of the last smoke test
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+
+	// LastSuccessTime - time of the last successful smoke test
+	LastSuccessTime *metav1.Time `json:"lastSuccessTime,omitempty"`
+
+	// LastLatencyMilliseconds - time the last successful smoke test took to
+	// issue and validate the token
+	LastLatencyMilliseconds int64 `json:"lastLatencyMilliseconds,omitempty"`
+
+	// ConsecutiveFailures - number of failed smoke tests in a row
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+
+	// LastError - error of the last failed smoke test
+	LastError string `json:"lastError,omitempty"`
+}
+
+// KeystoneChildrenStatus - readiness summary of the keystone CRs of the
+// namespace, e.g. the KeystoneServices and KeystoneEndpoints
+type KeystoneChildrenStatus struct {
+	// Count - number of keystone CRs
+	Count int32 `json:"count"`
+
+	// ReadyCount - number of keystone CRs which are ready for their current
+	// generation
+	ReadyCount int32 `json:"readyCount"`
+
+	// Failing - the keystone CRs which are not ready, as <kind>/<name> in
+	// lowercase, sorted
+	Failing []string `json:"failing,omitempty"`
+}
+
+// TokenRevocationStatus - progress of the token revocation requested by the
+// RevokeTokensAnnotation
+type TokenRevocationStatus struct {
+	// Request - value of the revoke-tokens annotation the tokens got revoked
+	// for
+	Request string `json:"request"`
+
+	// KeysReplacedTime - time the fernet token keys got replaced, tokens
+	// issued before are revoked
+	KeysReplacedTime *metav1.Time `json:"keysReplacedTime,omitempty"`
+
+	// KeysRolledOutTime - time all keystone pods used the new keys
+	KeysRolledOutTime *metav1.Time `json:"keysRolledOutTime,omitempty"`
+
+	// CompletionTime - time the token validations cached before the keys
+	// rolled out expired
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// ConfigPreviewStatus - hash of the rendered keystone config and the options
+// which differ from the config the keystone pods last got rolled out with
+type ConfigPreviewStatus struct {
+	// SecretName - name of the Secret holding the rendered and the last
+	// applied keystone config, and their diff if ConfigDiff is set
+	SecretName string `json:"secretName"`
+
+	// Hash - hash of the rendered keystone config
+	Hash string `json:"hash"`
+
+	// AppliedHash - hash of the keystone config all keystone pods use
+	AppliedHash string `json:"appliedHash,omitempty"`
+
+	// ChangedOptions - options of the rendered keystone config which differ
+	// from the applied one, as [section] option
+	ChangedOptions []string `json:"changedOptions,omitempty"`
+}
+
+// FernetKeysDistributionStatus - fernet keys all keystone pods serve with
+type FernetKeysDistributionStatus struct {
+	// Hash - hash of the fernet keys secret all ready keystone pods got
+	// created with
+	Hash string `json:"hash"`
+
+	// DistributedTime - time all keystone pods used the keys
+	DistributedTime *metav1.Time `json:"distributedTime,omitempty"`
+}
+
+// WebSSOStatus - WebSSO login options of the federated identity providers,
+// for dashboards like Horizon or Skyline to configure their SSO logins
+type WebSSOStatus struct {
+	// Options - WebSSO login options, sorted by identity provider and
+	// protocol
+	Options []WebSSOOption `json:"options,omitempty"`
+
+	// ConfigMap - name of the ConfigMap with the login options as JSON
+	ConfigMap string `json:"configMap,omitempty"`
+}
+
+// WebSSOOption - WebSSO login with a protocol of an identity provider
+type WebSSOOption struct {
+	// IdentityProvider - ID of the identity provider in keystone
+	IdentityProvider string `json:"identityProvider"`
+
+	// Protocol - ID of the federation protocol, e.g. openid or saml2
+	Protocol string `json:"protocol"`
+
+	// Description - description of the identity provider
+	Description string `json:"description,omitempty"`
+
+	// URL - WebSSO URL of the public endpoint for the login
+	URL string `json:"url"`
+}
+
+// HealthcheckSpec - configure the oslo.middleware healthcheck
+type HealthcheckSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default={disable_by_file}
+	// +listType=atomic
+	// Backends - healthcheck backends to report the health of keystone.
+	// With the disable_by_file backend keystone reports 503 while the
+	// DisableByFilePath file exists, e.g. to take a pod out of the service.
+	Backends []string `json:"backends"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="/etc/keystone/healthcheck_disable"
+	// DisableByFilePath - file checked by the disable_by_file backend
+	DisableByFilePath string `json:"disableByFilePath"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// Detailed - include details about the backends and the keystone process
+	// in the healthcheck response. Useful for debugging, but exposes
+	// internals of the service.
+	Detailed bool `json:"detailed"`
+}
+
+// KeystoneAPIStatus defines the observed state of KeystoneAPI
+type KeystoneAPIStatus struct {
+	// ReadyCount of keystone API instances
+	ReadyCount int32 `json:"readyCount,omitempty"`
+
+	// GreenReadyCount of keystone API instances of the green deployment
+	GreenReadyCount int32 `json:"greenReadyCount,omitempty"`
+
+	// PublicReadyCount of keystone API instances of the public deployment
+	PublicReadyCount int32 `json:"publicReadyCount,omitempty"`
+
+	// Map of hashes to track e.g. job status
+	Hash map[string]string `json:"hash,omitempty"`
+
+	// API endpoint
+	APIEndpoints map[string]string `json:"apiEndpoints,omitempty"`
+
+	// ServiceEndpoints - URLs of the k8s Services of the API endpoints
+	ServiceEndpoints map[string]string `json:"serviceEndpoints,omitempty"`
+
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// Keystone Database Hostname
+	DatabaseHostname string `json:"databaseHostname,omitempty"`
+
+	// NetworkAttachments status of the deployment pods
+	NetworkAttachments map[string][]string `json:"networkAttachments,omitempty"`
+
+	// TransportURLSecret - Secret containing RabbitMQ transportURL
+	TransportURLSecret string `json:"transportURLSecret,omitempty"`
+
+	//ObservedGeneration - the most recent generation observed for this service. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime - time of the last reconcile. While the result of the
+	// reconcile and the applied spec do not change it gets updated at most once
+	// a minute.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastSuccessfulReconcile - time of the last reconcile which finished
+	// without an error and left the instance ready
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// AppliedSpecHash - hash of the spec applied by the last successful reconcile
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+
+	// LastRebootstrapRequest - value of the rebootstrap annotation the
+	// bootstrap job got re-run for last
+	LastRebootstrapRequest string `json:"lastRebootstrapRequest,omitempty"`
+
+	// LastAppliedTopology - the last applied Topology
+	LastAppliedTopology *topologyv1.TopoRef `json:"lastAppliedTopology,omitempty"`
+
+	// TrustFlushLastSuccessfulTime - time the trust flush cron job last
+	// completed successfully
+	TrustFlushLastSuccessfulTime *metav1.Time `json:"trustFlushLastSuccessfulTime,omitempty"`
+
+	// TrustFlushLastScheduleTime - time the trust flush cron job was last
+	// scheduled
+	TrustFlushLastScheduleTime *metav1.Time `json:"trustFlushLastScheduleTime,omitempty"`
+
+	// ImpliedRoles - implied role relationships managed by the operator
+	ImpliedRoles []ImpliedRole `json:"impliedRoles,omitempty"`
+
+	// CatalogVersion - counter which gets incremented whenever a service or
+	// endpoint registered by a KeystoneService, KeystoneEndpoint or
+	// KeystoneCatalog of the namespace changes. Dependent operators can watch
+	// it to detect catalog changes without polling keystone.
+	CatalogVersion int64 `json:"catalogVersion,omitempty"`
+
+	// TokenSmokeTest - result of the token issuance smoke tests
+	TokenSmokeTest *TokenSmokeTestStatus `json:"tokenSmokeTest,omitempty"`
+
+	// Children - readiness summary of the keystone CRs of the namespace, to
+	// roll up the keystone health without checking every CR
+	Children *KeystoneChildrenStatus `json:"children,omitempty"`
+
+	// WebSSO - WebSSO login options of the federated identity providers, set
+	// if FederationTrustedDashboards is configured
+	WebSSO *WebSSOStatus `json:"webSSO,omitempty"`
+
+	// TokenRevocation - progress of the last token revocation
+	TokenRevocation *TokenRevocationStatus `json:"tokenRevocation,omitempty"`
+
+	// FernetKeysDistribution - fernet keys all keystone pods serve with, the
+	// next scheduled rotation waits until the current keys got distributed
+	FernetKeysDistribution *FernetKeysDistributionStatus `json:"fernetKeysDistribution,omitempty"`
+
+	// ConfigPreview - hash of the rendered keystone config and the options
+	// which change with the next rollout
+	ConfigPreview *ConfigPreviewStatus `json:"configPreview,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="NetworkAttachments",type="string",JSONPath=".spec.networkAttachments",description="NetworkAttachments"
 //+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
 //+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
 
@@ -307,8 +1801,32 @@ type KeystoneAPIList struct {
 	Items           []KeystoneAPI `json:"items"`
 }
 
-func init() {
-	SchemeBuilder.Register(&KeystoneAPI{}, &KeystoneAPIList{})
+func init() {
+	SchemeBuilder.Register(&KeystoneAPI{}, &KeystoneAPIList{})
+}
+
+// GetAdminClientEndpoint - returns the endpoint interface and where the URL
+// gets taken from for the admin client of the operator
+func (instance *KeystoneAPISpecCore) GetAdminClientEndpoint() (service.Endpoint, AdminClientURL) {
+	endpointType := service.EndpointInternal
+	url := AdminClientURLCatalog
+	if instance.AdminClient != nil {
+		if instance.AdminClient.Interface != "" {
+			endpointType = instance.AdminClient.Interface
+		}
+		if instance.AdminClient.URL != "" {
+			url = instance.AdminClient.URL
+		}
+	}
+	return endpointType, url
+}
+
+// GetServiceEndpoint - returns the URL of the k8s Service of the endpoint type
+func (instance KeystoneAPI) GetServiceEndpoint(endpointType endpoint.Endpoint) (string, error) {
+	if url, found := instance.Status.ServiceEndpoints[string(endpointType)]; found {
+		return url, nil
+	}
+	return "", fmt.Errorf("%s service endpoint not found", string(endpointType))
 }
 
 // GetEndpoint - returns OpenStack endpoint url for type
@@ -319,9 +1837,11 @@ func (instance KeystoneAPI) GetEndpoint(endpointType endpoint.Endpoint) (string,
 	return "", fmt.Errorf("%s endpoint not found", string(endpointType))
 }
 
-// IsReady - returns true if KeystoneAPI is reconciled successfully
+// IsReady - returns true if KeystoneAPI is reconciled successfully for
+// the current generation of the spec
 func (instance KeystoneAPI) IsReady() bool {
-	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+	return instance.Generation == instance.Status.ObservedGeneration &&
+		instance.Status.Conditions.IsTrue(condition.ReadyCondition)
 }
 
 // RbacConditionsSet - set the conditions for the rbac object
@@ -382,3 +1902,737 @@ func (instance *KeystoneAPISpecCore) ValidateTopology(
 		*basePath.Child("topologyRef"), namespace)...)
 	return allErrs
 }
+
+// ValidateDomainConfigs - validates the domain names can be used as file
+// names and that each domain config references exactly one source
+func (instance *KeystoneAPISpecCore) ValidateDomainConfigs(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	path := basePath.Child("domainConfigs")
+
+	for domain, src := range instance.DomainConfigs {
+		for _, msg := range validation.IsConfigMapKey(GetDomainConfigFileName(domain)) {
+			allErrs = append(allErrs, field.Invalid(path.Key(domain), domain, msg))
+		}
+		if (src.SecretKeyRef == nil) == (src.ConfigMapKeyRef == nil) {
+			allErrs = append(allErrs, field.Invalid(path.Key(domain), src,
+				"exactly one of secretKeyRef and configMapKeyRef must be set"))
+		}
+	}
+	return allErrs
+}
+
+// ValidateDrivers - validates the driver combination is supported by
+// keystone. An LDAP identity of the Default domain needs its LDAP server in
+// domainConfigs.Default.
+func (instance *KeystoneAPISpecCore) ValidateDrivers(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.Drivers == nil {
+		return allErrs
+	}
+	path := basePath.Child("drivers")
+	d := instance.Drivers
+
+	// LDAP assignment and resource drivers got removed from keystone
+	if d.Assignment == KeystoneDriverLDAP {
+		allErrs = append(allErrs, field.NotSupported(path.Child("assignment"), d.Assignment,
+			[]string{string(KeystoneDriverSQL)}))
+	}
+	if d.Resource == KeystoneDriverLDAP {
+		allErrs = append(allErrs, field.NotSupported(path.Child("resource"), d.Resource,
+			[]string{string(KeystoneDriverSQL)}))
+	}
+	if d.Identity == KeystoneDriverLDAP {
+		if _, ok := instance.DomainConfigs[DefaultDomainName]; !ok {
+			allErrs = append(allErrs, field.Invalid(path.Child("identity"), d.Identity,
+				fmt.Sprintf("the ldap identity driver needs the LDAP server of the %s domain in %s",
+					DefaultDomainName, basePath.Child("domainConfigs").Key(DefaultDomainName))))
+		}
+	}
+	return allErrs
+}
+
+// DriverWarnings - returns warnings about driver settings keystone accepts
+// but which likely break the deployment
+func (instance *KeystoneAPISpecCore) DriverWarnings(
+	basePath *field.Path,
+) []string {
+	var warnings []string
+	if instance.Drivers == nil {
+		return warnings
+	}
+	path := basePath.Child("drivers")
+
+	if instance.Drivers.Identity == KeystoneDriverLDAP {
+		warnings = append(warnings, fmt.Sprintf(
+			"%s: keystone does not write to LDAP, the admin user and the service users of the %s domain have to exist on the LDAP server",
+			path.Child("identity"), DefaultDomainName))
+	}
+	for _, section := range []string{"identity", "assignment", "resource"} {
+		if _, ok := instance.CustomServiceConfigOption(section, "driver"); ok {
+			warnings = append(warnings, fmt.Sprintf("%s: driver of [%s] overrides %s",
+				basePath.Child("customServiceConfig"), section, path))
+		}
+	}
+	return warnings
+}
+
+// ValidateNotifications - validates the notification options are consistent
+// with the audit log and dev mode and not set twice through
+// customServiceConfig
+func (instance *KeystoneAPISpecCore) ValidateNotifications(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.Notifications == nil {
+		return allErrs
+	}
+	path := basePath.Child("notifications")
+	notifications := instance.Notifications
+
+	if instance.AuditLog != nil && notifications.Format == NotificationFormatBasic {
+		allErrs = append(allErrs, field.Invalid(path.Child("format"), notifications.Format,
+			"the audit log requires cadf notifications"))
+	}
+	for i, route := range notifications.Routes {
+		if instance.DevMode && route.Destination == NotificationDestinationMessaging {
+			allErrs = append(allErrs, field.Invalid(path.Child("routes").Index(i).Child("destination"),
+				route.Destination, "dev mode runs without RabbitMQ"))
+		}
+	}
+
+	options := []struct {
+		section string
+		option  string
+		set     bool
+	}{
+		{"DEFAULT", "notification_format", notifications.Format != ""},
+		{"DEFAULT", "notification_opt_out", len(notifications.OptOut) > 0},
+		{"oslo_messaging_notifications", "topics", len(notifications.Topics) > 0},
+		{"oslo_messaging_notifications", "driver", len(notifications.Routes) > 0},
+		{"oslo_messaging_notifications", "routing_config", len(notifications.Routes) > 0},
+	}
+	for _, o := range options {
+		if _, ok := instance.CustomServiceConfigOption(o.section, o.option); ok && o.set {
+			allErrs = append(allErrs, field.Forbidden(basePath.Child("customServiceConfig"), fmt.Sprintf(
+				"[%s] %s is already set by %s", o.section, o.option, path)))
+		}
+	}
+	return allErrs
+}
+
+// ValidateTokenOptions - validates the token caching and revocation options
+// are consistent and not set twice through customServiceConfig
+func (instance *KeystoneAPISpecCore) ValidateTokenOptions(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.Token == nil {
+		return allErrs
+	}
+	path := basePath.Child("token")
+	token := instance.Token
+
+	cachingDisabled := token.Caching != nil && !*token.Caching
+	if cachingDisabled && token.CacheTime != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("cacheTime"), *token.CacheTime,
+			"has no effect with caching disabled"))
+	}
+	if cachingDisabled && token.CacheOnIssue != nil && *token.CacheOnIssue {
+		allErrs = append(allErrs, field.Invalid(path.Child("cacheOnIssue"), *token.CacheOnIssue,
+			"has no effect with caching disabled"))
+	}
+	if revoke := token.Revoke; revoke != nil && revoke.Caching != nil && !*revoke.Caching && revoke.CacheTime != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("revoke", "cacheTime"), *revoke.CacheTime,
+			"has no effect with caching disabled"))
+	}
+
+	options := token.options()
+	for _, section := range []string{"token", "revoke"} {
+		keys := make([]string, 0, len(options[section]))
+		for option := range options[section] {
+			keys = append(keys, option)
+		}
+		slices.Sort(keys)
+		for _, option := range keys {
+			if _, ok := instance.CustomServiceConfigOption(section, option); ok {
+				allErrs = append(allErrs, field.Forbidden(basePath.Child("customServiceConfig"), fmt.Sprintf(
+					"[%s] %s is already set by %s", section, option, path)))
+			}
+		}
+	}
+	return allErrs
+}
+
+// TokenWarnings - returns warnings about token options which delay or
+// prevent revocations from taking effect
+func (instance *KeystoneAPISpecCore) TokenWarnings(
+	basePath *field.Path,
+) []string {
+	var warnings []string
+	if instance.Token == nil {
+		return warnings
+	}
+	path := basePath.Child("token")
+
+	if instance.Token.RevokeByID != nil && !*instance.Token.RevokeByID {
+		warnings = append(warnings, fmt.Sprintf(
+			"%s: tokens revoked individually stay valid until they expire",
+			path.Child("revokeByID")))
+	}
+	if instance.Token.CacheOnIssue != nil {
+		warnings = append(warnings, fmt.Sprintf(
+			"%s: is deprecated in keystone, issued tokens are always cached when caching is enabled",
+			path.Child("cacheOnIssue")))
+	}
+	return warnings
+}
+
+// GetTokenOptions - returns the [token] and [revoke] options set in the spec
+// by section
+func (instance *KeystoneAPISpecCore) GetTokenOptions() map[string]map[string]interface{} {
+	if instance.Token == nil {
+		return map[string]map[string]interface{}{}
+	}
+	return instance.Token.options()
+}
+
+// GetTokenCacheTime - returns how long a validated token can stay cached,
+// zero with caching disabled and false if the [cache] default applies
+func (instance *KeystoneAPISpecCore) GetTokenCacheTime() (time.Duration, bool) {
+	if instance.Token == nil {
+		return 0, false
+	}
+	if instance.Token.Caching != nil && !*instance.Token.Caching {
+		return 0, true
+	}
+	if instance.Token.CacheTime != nil {
+		return time.Duration(*instance.Token.CacheTime) * time.Second, true
+	}
+	return 0, false
+}
+
+func (t *TokenSpec) options() map[string]map[string]interface{} {
+	options := map[string]map[string]interface{}{
+		"token":  {},
+		"revoke": {},
+	}
+	if t.Caching != nil {
+		options["token"]["caching"] = *t.Caching
+	}
+	if t.CacheTime != nil {
+		options["token"]["cache_time"] = *t.CacheTime
+	}
+	if t.CacheOnIssue != nil {
+		options["token"]["cache_on_issue"] = *t.CacheOnIssue
+	}
+	if t.RevokeByID != nil {
+		options["token"]["revoke_by_id"] = *t.RevokeByID
+	}
+	if revoke := t.Revoke; revoke != nil {
+		if revoke.Driver != "" {
+			options["revoke"]["driver"] = revoke.Driver
+		}
+		if revoke.ExpirationBuffer != nil {
+			options["revoke"]["expiration_buffer"] = *revoke.ExpirationBuffer
+		}
+		if revoke.Caching != nil {
+			options["revoke"]["caching"] = *revoke.Caching
+		}
+		if revoke.CacheTime != nil {
+			options["revoke"]["cache_time"] = *revoke.CacheTime
+		}
+	}
+	return options
+}
+
+// GetIdentityDriver - returns the identity driver of the Default domain
+func (instance *KeystoneAPISpecCore) GetIdentityDriver() KeystoneDriver {
+	if instance.Drivers == nil || instance.Drivers.Identity == "" {
+		return KeystoneDriverSQL
+	}
+	return instance.Drivers.Identity
+}
+
+// ValidateCustomServiceConfigLayers - validates that each service config
+// layer references exactly one source
+func (instance *KeystoneAPISpecCore) ValidateCustomServiceConfigLayers(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	path := basePath.Child("customServiceConfigLayers")
+
+	for i, layer := range instance.CustomServiceConfigLayers {
+		if (layer.SecretKeyRef == nil) == (layer.ConfigMapKeyRef == nil) {
+			allErrs = append(allErrs, field.Invalid(path.Index(i), layer,
+				"exactly one of secretKeyRef and configMapKeyRef must be set"))
+		}
+	}
+	return allErrs
+}
+
+// imageDigestRegexp - matches container images referenced by digest
+var imageDigestRegexp = regexp.MustCompile(`@sha256:[a-f0-9]{64}$`)
+
+// ValidateImageVerification - validates the image verification settings and
+// that the images are referenced by digest if required
+func (instance *KeystoneAPISpecCore) ValidateImageVerification(
+	basePath *field.Path,
+	containerImage string,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.ImageVerification == nil {
+		return allErrs
+	}
+	path := basePath.Child("imageVerification")
+	v := instance.ImageVerification
+
+	if v.CosignKeySecret != "" && (v.CertificateIdentity != "" || v.CertificateOIDCIssuer != "") {
+		allErrs = append(allErrs, field.Invalid(path.Child("cosignKeySecret"), v.CosignKeySecret,
+			"cosignKeySecret can not be combined with certificateIdentity and certificateOIDCIssuer"))
+	}
+	if (v.CertificateIdentity == "") != (v.CertificateOIDCIssuer == "") {
+		allErrs = append(allErrs, field.Invalid(path.Child("certificateIdentity"), v.CertificateIdentity,
+			"certificateIdentity and certificateOIDCIssuer must be set together"))
+	}
+
+	if v.RequireDigest {
+		if !IsImageDigest(containerImage) {
+			allErrs = append(allErrs, field.Invalid(basePath.Child("containerImage"), containerImage,
+				"container image must be referenced by digest"))
+		}
+		if instance.BlueGreen != nil && !IsImageDigest(instance.BlueGreen.ContainerImage) {
+			allErrs = append(allErrs, field.Invalid(basePath.Child("blueGreen", "containerImage"),
+				instance.BlueGreen.ContainerImage, "container image must be referenced by digest"))
+		}
+	}
+	return allErrs
+}
+
+// IsImageDigest - returns true if the container image is referenced by digest
+func IsImageDigest(image string) bool {
+	return imageDigestRegexp.MatchString(image)
+}
+
+// ValidateAutoscaling - validates the autoscaling replica limits
+func (instance *KeystoneAPISpecCore) ValidateAutoscaling(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.Autoscaling != nil && instance.Autoscaling.MinReplicas > instance.Autoscaling.MaxReplicas {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("autoscaling", "minReplicas"),
+			instance.Autoscaling.MinReplicas, "must not be greater than maxReplicas"))
+	}
+	return allErrs
+}
+
+// ValidateWSGIServer - validates the settings requiring httpd are not used
+// with uWSGI
+func (instance *KeystoneAPISpecCore) ValidateWSGIServer(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.WSGIServer != WSGIServerUWSGI {
+		return allErrs
+	}
+	if instance.HttpdCustomization.CustomConfigSecret != nil && *instance.HttpdCustomization.CustomConfigSecret != "" {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("httpdCustomization", "customConfigSecret"),
+			*instance.HttpdCustomization.CustomConfigSecret, "not supported with wsgiServer uwsgi"))
+	}
+	if instance.HttpdCustomization.AccessLogFormat != "" {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("httpdCustomization", "accessLogFormat"),
+			instance.HttpdCustomization.AccessLogFormat, "not supported with wsgiServer uwsgi"))
+	}
+	if instance.FederatedRealmConfig != "" {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("federatedRealmConfig"),
+			instance.FederatedRealmConfig, "federation requires wsgiServer httpd"))
+	}
+	if instance.PublicSecurity != nil && instance.PublicSecurity.ModSecurity != nil {
+		allErrs = append(allErrs, field.Forbidden(basePath.Child("publicSecurity", "modSecurity"),
+			"mod_security requires wsgiServer httpd"))
+	}
+	if instance.Kerberos != nil {
+		allErrs = append(allErrs, field.Forbidden(basePath.Child("kerberos"),
+			"kerberos requires wsgiServer httpd"))
+	}
+	if instance.TokenlessAuth != nil {
+		allErrs = append(allErrs, field.Forbidden(basePath.Child("tokenlessAuth"),
+			"tokenlessAuth requires wsgiServer httpd"))
+	}
+	return allErrs
+}
+
+// ValidatePublicDeployment - validates the public deployment is not combined
+// with blue/green, the green deployment only serves a single endpoint
+func (instance *KeystoneAPISpecCore) ValidatePublicDeployment(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.PublicDeployment != nil && instance.BlueGreen != nil {
+		allErrs = append(allErrs, field.Forbidden(basePath.Child("publicDeployment"),
+			"publicDeployment can not be combined with blueGreen"))
+	}
+	return allErrs
+}
+
+// ValidateTokenlessAuth - validates the internal endpoint has a certificate,
+// the tokenless vhost serves it to the clients
+func (instance *KeystoneAPISpecCore) ValidateTokenlessAuth(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.TokenlessAuth != nil && !instance.TLS.API.Enabled(service.EndpointInternal) {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("tls", "api", "internal", "secretName"),
+			"", "tokenlessAuth requires TLS of the internal endpoint"))
+	}
+	return allErrs
+}
+
+// ValidatePublishedEndpoints - validates the endpoint types and hostnames of
+// the published endpoints
+func (instance *KeystoneAPISpecCore) ValidatePublishedEndpoints(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	for endpt, published := range instance.PublishedEndpoints {
+		path := basePath.Child("publishedEndpoints").Key(string(endpt))
+		if endpt != service.EndpointPublic && endpt != service.EndpointInternal {
+			allErrs = append(allErrs, field.NotSupported(path, endpt,
+				[]string{string(service.EndpointPublic), string(service.EndpointInternal)}))
+		}
+		if published.Host != "" {
+			for _, msg := range validation.IsDNS1123Subdomain(published.Host) {
+				allErrs = append(allErrs, field.Invalid(path.Child("host"), published.Host, msg))
+			}
+		}
+	}
+	return allErrs
+}
+
+// ValidatePublicDNS - validates the hostname of the public DNS record, which
+// can not be combined with a published public endpoint host
+func (instance *KeystoneAPISpecCore) ValidatePublicDNS(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.PublicDNS == nil {
+		return allErrs
+	}
+	path := basePath.Child("publicDNS", "hostname")
+	for _, msg := range validation.IsDNS1123Subdomain(instance.PublicDNS.Hostname) {
+		allErrs = append(allErrs, field.Invalid(path, instance.PublicDNS.Hostname, msg))
+	}
+	if instance.PublishedEndpoints[service.EndpointPublic].Host != "" {
+		allErrs = append(allErrs, field.Invalid(path, instance.PublicDNS.Hostname,
+			"publicDNS can not be combined with the host of the published public endpoint"))
+	}
+	return allErrs
+}
+
+// ValidateDatabaseReader - validates the host of the database replica
+func (instance *KeystoneAPISpecCore) ValidateDatabaseReader(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.DatabaseReader == nil {
+		return allErrs
+	}
+	path := basePath.Child("databaseReader", "host")
+	host := instance.DatabaseReader.Host
+	if h, port, err := net.SplitHostPort(host); err == nil {
+		host = h
+		if p, err := strconv.Atoi(port); err != nil || validation.IsValidPortNum(p) != nil {
+			allErrs = append(allErrs, field.Invalid(path, instance.DatabaseReader.Host, "invalid port"))
+		}
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(host) {
+		allErrs = append(allErrs, field.Invalid(path, instance.DatabaseReader.Host, msg))
+	}
+	return allErrs
+}
+
+// ValidateDatabaseSyncAccount - validates db_sync uses a MariaDBAccount of
+// its own, the privileges of DatabaseAccount get restricted
+func (instance *KeystoneAPISpecCore) ValidateDatabaseSyncAccount(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.DatabaseSyncAccount == "" {
+		return allErrs
+	}
+	path := basePath.Child("databaseSyncAccount")
+	if instance.DatabaseSyncAccount == instance.DatabaseAccount {
+		allErrs = append(allErrs, field.Invalid(path, instance.DatabaseSyncAccount,
+			"must differ from databaseAccount"))
+	}
+	if instance.PostgreSQL != nil {
+		allErrs = append(allErrs, field.Forbidden(path, "not supported with postgreSQL"))
+	}
+	return allErrs
+}
+
+// ValidatePostgreSQL - validates the host of the PostgreSQL server and that
+// the server certificate can be verified
+func (instance *KeystoneAPISpecCore) ValidatePostgreSQL(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	pg := instance.PostgreSQL
+	if pg == nil {
+		return allErrs
+	}
+	path := basePath.Child("postgreSQL")
+	for _, msg := range validation.IsDNS1123Subdomain(pg.Host) {
+		allErrs = append(allErrs, field.Invalid(path.Child("host"), pg.Host, msg))
+	}
+	if (pg.SSLMode == PostgreSQLSSLModeVerifyCA || pg.SSLMode == PostgreSQLSSLModeVerifyFull) &&
+		instance.TLS.CaBundleSecretName == "" {
+		allErrs = append(allErrs, field.Invalid(path.Child("sslMode"), pg.SSLMode,
+			"verifying the server certificate requires tls.caBundleSecretName"))
+	}
+	for key := range pg.Options {
+		if key == "sslmode" || key == "sslrootcert" {
+			allErrs = append(allErrs, field.Forbidden(path.Child("options").Key(key),
+				"set by the operator from sslMode"))
+		}
+	}
+	return allErrs
+}
+
+// ValidateDevMode - validates dev mode runs a single keystone pod without an
+// external database, every pod would have its own SQLite database
+func (instance *KeystoneAPISpecCore) ValidateDevMode(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if !instance.DevMode {
+		return allErrs
+	}
+	if instance.Replicas != nil && *instance.Replicas != 1 {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("replicas"),
+			*instance.Replicas, "devMode requires a single replica"))
+	}
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"postgreSQL", instance.PostgreSQL != nil},
+		{"databaseReader", instance.DatabaseReader != nil},
+		{"databaseSyncAccount", instance.DatabaseSyncAccount != ""},
+		{"autoscaling", instance.Autoscaling != nil},
+		{"blueGreen", instance.BlueGreen != nil},
+		{"publicDeployment", instance.PublicDeployment != nil},
+	} {
+		if f.set {
+			allErrs = append(allErrs, field.Forbidden(basePath.Child(f.name),
+				"not supported with devMode"))
+		}
+	}
+	return allErrs
+}
+
+// ValidateFernetKeys - validates the fernet keys outlive the tokens issued
+// with them. A key gets removed fernetMaxActiveKeys - 2 rotations after it
+// became the primary key, tokens issued before fail validation from then on.
+// The fernet options managed by the operator are only rejected in
+// customServiceConfig if the old spec, nil on create, did not set them
+// already, so existing instances can still get updated.
+func (instance *KeystoneAPISpecCore) ValidateFernetKeys(
+	basePath *field.Path,
+	old *KeystoneAPISpecCore,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.FernetMaxActiveKeys != nil && *instance.FernetMaxActiveKeys < MinFernetMaxActiveKeys {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("fernetMaxActiveKeys"),
+			*instance.FernetMaxActiveKeys, fmt.Sprintf(
+				"at least %d keys are required, the staged, the primary and a secondary key",
+				MinFernetMaxActiveKeys)))
+	}
+	for _, option := range []string{"key_repository", "max_active_keys"} {
+		if _, ok := instance.CustomServiceConfigOption("fernet_tokens", option); !ok {
+			continue
+		}
+		if old != nil {
+			if _, ok := old.CustomServiceConfigOption("fernet_tokens", option); ok {
+				continue
+			}
+		}
+		allErrs = append(allErrs, field.Forbidden(basePath.Child("customServiceConfig"), fmt.Sprintf(
+			"[fernet_tokens] %s is managed by the operator, use fernetMaxActiveKeys instead", option)))
+	}
+	if len(allErrs) > 0 || instance.FernetMaxActiveKeys == nil || instance.FernetRotationDays == nil {
+		return allErrs
+	}
+
+	expiration := DefaultTokenExpiration
+	if value, ok := instance.CustomServiceConfigOption("token", "expiration"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return append(allErrs, field.Invalid(basePath.Child("customServiceConfig"), value,
+				"[token] expiration must be a positive number of seconds"))
+		}
+		expiration = parsed
+	}
+	keyLifetime := int(*instance.FernetMaxActiveKeys-2) * int(*instance.FernetRotationDays) * 24 * 60 * 60
+	if keyLifetime < expiration {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("fernetRotationDays"),
+			*instance.FernetRotationDays, fmt.Sprintf(
+				"fernet keys get removed %d seconds after they got used, before the tokens issued with them "+
+					"expire after %d seconds, increase fernetRotationDays or fernetMaxActiveKeys or lower "+
+					"[token] expiration", keyLifetime, expiration)))
+	}
+	return allErrs
+}
+
+// ValidateCustomServiceConfig - validates oslo.config is able to parse
+// CustomServiceConfig, keystone fails to start otherwise. The errors only
+// hold the line numbers, the config may hold passwords.
+func (instance *KeystoneAPISpecCore) ValidateCustomServiceConfig(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	_, errs := parseServiceConfig(instance.CustomServiceConfig)
+	for _, err := range errs {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("customServiceConfig"),
+			fmt.Sprintf("line %d", err.line), err.message))
+	}
+	return allErrs
+}
+
+// CustomServiceConfigOption - returns the last value of the option in the
+// section of CustomServiceConfig, like oslo.config reads it
+func (instance *KeystoneAPISpecCore) CustomServiceConfigOption(section string, option string) (string, bool) {
+	config, _ := parseServiceConfig(instance.CustomServiceConfig)
+	values := config.values(section, option)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// ValidateTimeZone - validates the time zone is known and not set by the
+// extra environment variables as well
+func (instance *KeystoneAPISpecCore) ValidateTimeZone(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.TimeZone == "" {
+		return allErrs
+	}
+	path := basePath.Child("timeZone")
+	// the Local time zone is the one of the operator, not of the pods
+	if _, err := time.LoadLocation(instance.TimeZone); err != nil || instance.TimeZone == "Local" {
+		allErrs = append(allErrs, field.Invalid(path, instance.TimeZone, "unknown time zone"))
+	}
+	if slices.ContainsFunc(instance.ExtraEnv, func(e corev1.EnvVar) bool { return e.Name == "TZ" }) {
+		allErrs = append(allErrs, field.Invalid(path, instance.TimeZone,
+			"timeZone can not be combined with TZ in extraEnv"))
+	}
+	return allErrs
+}
+
+// ValidateExtraEnv - validates the names of the extra environment variables,
+// which must not be set by the operator already
+func (instance *KeystoneAPISpecCore) ValidateExtraEnv(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, envVar := range instance.ExtraEnv {
+		path := basePath.Child("extraEnv").Index(i).Child("name")
+		for _, msg := range validation.IsEnvVarName(envVar.Name) {
+			allErrs = append(allErrs, field.Invalid(path, envVar.Name, msg))
+		}
+		if IsReservedEnvVar(envVar.Name) {
+			allErrs = append(allErrs, field.Forbidden(path,
+				fmt.Sprintf("%s is set by the operator", envVar.Name)))
+		}
+	}
+	return allErrs
+}
+
+// IsReservedEnvVar - returns true if the operator sets the environment
+// variable in the keystone containers
+func IsReservedEnvVar(name string) bool {
+	for _, prefix := range reservedEnvVarPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return slices.Contains(reservedEnvVars, name)
+}
+
+// ValidateRouteTLS - validates the endpoint types of the route TLS settings and
+// that keystone serves TLS for the endpoints the router does not terminate TLS
+// for
+func (instance *KeystoneAPISpecCore) ValidateRouteTLS(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	for endpt, routeTLS := range instance.RouteTLS {
+		path := basePath.Child("routeTLS").Key(string(endpt))
+		if endpt != service.EndpointPublic && endpt != service.EndpointInternal {
+			allErrs = append(allErrs, field.NotSupported(path, endpt,
+				[]string{string(service.EndpointPublic), string(service.EndpointInternal)}))
+			continue
+		}
+		if routeTLS.Termination != RouteTerminationEdge && !instance.TLS.API.Enabled(endpt) {
+			allErrs = append(allErrs, field.Invalid(path.Child("termination"), routeTLS.Termination,
+				fmt.Sprintf("%s termination requires TLS of the %s endpoint", routeTLS.Termination, endpt)))
+		}
+		if routeTLS.DestinationCACertificate != "" && routeTLS.Termination != RouteTerminationReencrypt {
+			allErrs = append(allErrs, field.Invalid(path.Child("destinationCACertificate"), "",
+				"destinationCACertificate is only supported with reencrypt termination"))
+		}
+	}
+	return allErrs
+}
+
+// ValidateTermination - validates the preStop drain finishes within the
+// termination grace period
+func (instance *KeystoneAPISpecCore) ValidateTermination(
+	basePath *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+	if instance.PreStopDrainSeconds > 0 &&
+		instance.PreStopDrainSeconds >= instance.TerminationGracePeriodSeconds {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("preStopDrainSeconds"),
+			instance.PreStopDrainSeconds, "must be lower than terminationGracePeriodSeconds"))
+	}
+	return allErrs
+}
+
+// GetAuthMethods - returns the auth methods of keystone, the keystone defaults
+// extended by the methods of the enabled features
+func (instance *KeystoneAPISpecCore) GetAuthMethods() []string {
+	methods := append([]string{}, DefaultAuthMethods...)
+	if instance.Kerberos != nil {
+		methods = append(methods, "kerberos")
+	}
+	if instance.MFA != nil {
+		for _, method := range instance.MFA.Methods {
+			if !slices.Contains(methods, string(method)) {
+				methods = append(methods, string(method))
+			}
+		}
+	}
+	return methods
+}
+
+// HasLDAPDomains - returns true if LDAP settings are configured for a domain
+func (instance *KeystoneAPISpecCore) HasLDAPDomains() bool {
+	for _, src := range instance.DomainConfigs {
+		if src.LDAP != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDomainConfigFileName - returns the name of the configuration file of the
+// domain keystone expects in domain_config_dir
+func GetDomainConfigFileName(domain string) string {
+	return fmt.Sprintf("keystone.%s.conf", domain)
+}
@@ -0,0 +1,1266 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+func TestValidateDomainConfigs(t *testing.T) {
+
+	secretRef := &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "ldap"},
+		Key:                  "keystone.conf",
+	}
+	configMapRef := &corev1.ConfigMapKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "ldap"},
+		Key:                  "keystone.conf",
+	}
+
+	tests := []struct {
+		name          string
+		domainConfigs map[string]DomainConfigSource
+		wantErrs      int
+	}{
+		{
+			name:          "No domain configs",
+			domainConfigs: nil,
+			wantErrs:      0,
+		},
+		{
+			name: "Secret and ConfigMap sources",
+			domainConfigs: map[string]DomainConfigSource{
+				"ldap":   {SecretKeyRef: secretRef},
+				"ldap-2": {ConfigMapKeyRef: configMapRef},
+			},
+			wantErrs: 0,
+		},
+		{
+			name: "No source",
+			domainConfigs: map[string]DomainConfigSource{
+				"ldap": {},
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "Both sources",
+			domainConfigs: map[string]DomainConfigSource{
+				"ldap": {SecretKeyRef: secretRef, ConfigMapKeyRef: configMapRef},
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "Domain name not usable as file name",
+			domainConfigs: map[string]DomainConfigSource{
+				"ldap/users": {SecretKeyRef: secretRef},
+			},
+			wantErrs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := KeystoneAPISpecCore{DomainConfigs: tt.domainConfigs}
+			g.Expect(spec.ValidateDomainConfigs(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidateImageVerification(t *testing.T) {
+
+	digestImage := "quay.io/podified-antelope-centos9/openstack-keystone@sha256:" + strings.Repeat("a", 64)
+	tagImage := "quay.io/podified-antelope-centos9/openstack-keystone:current-podified"
+
+	tests := []struct {
+		name              string
+		imageVerification *ImageVerificationSpec
+		blueGreen         *BlueGreenSpec
+		containerImage    string
+		wantErrs          int
+	}{
+		{
+			name:              "No image verification",
+			imageVerification: nil,
+			containerImage:    tagImage,
+			wantErrs:          0,
+		},
+		{
+			name:              "Digest required and used",
+			imageVerification: &ImageVerificationSpec{RequireDigest: true},
+			containerImage:    digestImage,
+			wantErrs:          0,
+		},
+		{
+			name:              "Digest required but tag used",
+			imageVerification: &ImageVerificationSpec{RequireDigest: true},
+			containerImage:    tagImage,
+			wantErrs:          1,
+		},
+		{
+			name:              "Digest required but green image uses tag",
+			imageVerification: &ImageVerificationSpec{RequireDigest: true},
+			blueGreen:         &BlueGreenSpec{ContainerImage: tagImage},
+			containerImage:    digestImage,
+			wantErrs:          1,
+		},
+		{
+			name:              "Cosign key",
+			imageVerification: &ImageVerificationSpec{CosignKeySecret: "cosign"},
+			containerImage:    tagImage,
+			wantErrs:          0,
+		},
+		{
+			name: "Keyless",
+			imageVerification: &ImageVerificationSpec{
+				CertificateIdentity:   "release@example.com",
+				CertificateOIDCIssuer: "https://accounts.example.com",
+			},
+			containerImage: tagImage,
+			wantErrs:       0,
+		},
+		{
+			name: "Keyless without issuer",
+			imageVerification: &ImageVerificationSpec{
+				CertificateIdentity: "release@example.com",
+			},
+			containerImage: tagImage,
+			wantErrs:       1,
+		},
+		{
+			name: "Cosign key and keyless",
+			imageVerification: &ImageVerificationSpec{
+				CosignKeySecret:       "cosign",
+				CertificateIdentity:   "release@example.com",
+				CertificateOIDCIssuer: "https://accounts.example.com",
+			},
+			containerImage: tagImage,
+			wantErrs:       1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := KeystoneAPISpecCore{
+				ImageVerification: tt.imageVerification,
+				BlueGreen:         tt.blueGreen,
+			}
+			g.Expect(spec.ValidateImageVerification(field.NewPath("spec"), tt.containerImage)).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidateTermination(t *testing.T) {
+
+	tests := []struct {
+		name                          string
+		terminationGracePeriodSeconds int64
+		preStopDrainSeconds           int64
+		wantErrs                      int
+	}{
+		{
+			name:                          "Drain within grace period",
+			terminationGracePeriodSeconds: 60,
+			preStopDrainSeconds:           5,
+			wantErrs:                      0,
+		},
+		{
+			name:                          "Drain disabled",
+			terminationGracePeriodSeconds: 5,
+			preStopDrainSeconds:           0,
+			wantErrs:                      0,
+		},
+		{
+			name:                          "Drain exceeds grace period",
+			terminationGracePeriodSeconds: 30,
+			preStopDrainSeconds:           30,
+			wantErrs:                      1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := KeystoneAPISpecCore{
+				TerminationGracePeriodSeconds: tt.terminationGracePeriodSeconds,
+				PreStopDrainSeconds:           tt.preStopDrainSeconds,
+			}
+			g.Expect(spec.ValidateTermination(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidateAutoscaling(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		autoscaling *AutoscalingSpec
+		wantErrs    int
+	}{
+		{
+			name:        "No autoscaling",
+			autoscaling: nil,
+			wantErrs:    0,
+		},
+		{
+			name:        "Valid replica limits",
+			autoscaling: &AutoscalingSpec{MinReplicas: 2, MaxReplicas: 5},
+			wantErrs:    0,
+		},
+		{
+			name:        "Min replicas greater than max replicas",
+			autoscaling: &AutoscalingSpec{MinReplicas: 6, MaxReplicas: 5},
+			wantErrs:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := KeystoneAPISpecCore{Autoscaling: tt.autoscaling}
+			g.Expect(spec.ValidateAutoscaling(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidateWSGIServer(t *testing.T) {
+
+	customConfigSecret := "httpd-custom"
+
+	tests := []struct {
+		name     string
+		spec     KeystoneAPISpecCore
+		wantErrs int
+	}{
+		{
+			name:     "httpd with federation",
+			spec:     KeystoneAPISpecCore{WSGIServer: WSGIServerHttpd, FederatedRealmConfig: "federation"},
+			wantErrs: 0,
+		},
+		{
+			name:     "uwsgi",
+			spec:     KeystoneAPISpecCore{WSGIServer: WSGIServerUWSGI},
+			wantErrs: 0,
+		},
+		{
+			name: "uwsgi with httpd customizations",
+			spec: KeystoneAPISpecCore{
+				WSGIServer: WSGIServerUWSGI,
+				HttpdCustomization: HttpdCustomization{
+					CustomConfigSecret: &customConfigSecret,
+					AccessLogFormat:    "%t",
+				},
+			},
+			wantErrs: 2,
+		},
+		{
+			name:     "uwsgi with federation",
+			spec:     KeystoneAPISpecCore{WSGIServer: WSGIServerUWSGI, FederatedRealmConfig: "federation"},
+			wantErrs: 1,
+		},
+		{
+			name: "uwsgi with mod_security",
+			spec: KeystoneAPISpecCore{
+				WSGIServer:     WSGIServerUWSGI,
+				PublicSecurity: &PublicSecuritySpec{ModSecurity: &ModSecuritySpec{RuleEngine: ModSecurityOn}},
+			},
+			wantErrs: 1,
+		},
+		{
+			name:     "uwsgi with kerberos",
+			spec:     KeystoneAPISpecCore{WSGIServer: WSGIServerUWSGI, Kerberos: &KerberosSpec{Domain: "Default"}},
+			wantErrs: 1,
+		},
+		{
+			name:     "uwsgi with tokenless authorization",
+			spec:     KeystoneAPISpecCore{WSGIServer: WSGIServerUWSGI, TokenlessAuth: &TokenlessAuthSpec{}},
+			wantErrs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.ValidateWSGIServer(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidatePublicDeployment(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		spec     KeystoneAPISpecCore
+		wantErrs int
+	}{
+		{
+			name:     "No public deployment",
+			spec:     KeystoneAPISpecCore{BlueGreen: &BlueGreenSpec{ContainerImage: "green"}},
+			wantErrs: 0,
+		},
+		{
+			name:     "Public deployment",
+			spec:     KeystoneAPISpecCore{PublicDeployment: &PublicDeploymentSpec{}},
+			wantErrs: 0,
+		},
+		{
+			name: "Public deployment with blue/green",
+			spec: KeystoneAPISpecCore{
+				PublicDeployment: &PublicDeploymentSpec{},
+				BlueGreen:        &BlueGreenSpec{ContainerImage: "green"},
+			},
+			wantErrs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.ValidatePublicDeployment(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidateTokenlessAuth(t *testing.T) {
+
+	internalCert := "cert-keystone-internal-svc"
+
+	tests := []struct {
+		name     string
+		spec     KeystoneAPISpecCore
+		wantErrs int
+	}{
+		{
+			name:     "No tokenless authorization",
+			spec:     KeystoneAPISpecCore{},
+			wantErrs: 0,
+		},
+		{
+			name: "Tokenless authorization with internal TLS",
+			spec: KeystoneAPISpecCore{
+				TokenlessAuth: &TokenlessAuthSpec{TrustedIssuers: []string{"CN=CA"}},
+				TLS:           tls.API{API: tls.APIService{Internal: tls.GenericService{SecretName: &internalCert}}},
+			},
+			wantErrs: 0,
+		},
+		{
+			name: "Tokenless authorization without internal TLS",
+			spec: KeystoneAPISpecCore{
+				TokenlessAuth: &TokenlessAuthSpec{TrustedIssuers: []string{"CN=CA"}},
+			},
+			wantErrs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.ValidateTokenlessAuth(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestGetAuthMethods(t *testing.T) {
+
+	tests := []struct {
+		name string
+		spec KeystoneAPISpecCore
+		want []string
+	}{
+		{
+			name: "Defaults",
+			spec: KeystoneAPISpecCore{},
+			want: DefaultAuthMethods,
+		},
+		{
+			name: "Kerberos and TOTP",
+			spec: KeystoneAPISpecCore{
+				Kerberos: &KerberosSpec{},
+				MFA:      &MFASpec{Methods: []MFAMethod{MFAMethodTOTP, MFAMethodTOTP}},
+			},
+			want: append(append([]string{}, DefaultAuthMethods...), "kerberos", "totp"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.GetAuthMethods()).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestPublishedEndpointApply(t *testing.T) {
+
+	port := int32(8443)
+
+	tests := []struct {
+		name      string
+		published PublishedEndpointSpec
+		url       string
+		want      string
+	}{
+		{
+			name:      "No override",
+			published: PublishedEndpointSpec{},
+			url:       "https://keystone-public.openstack.svc:5000",
+			want:      "https://keystone-public.openstack.svc:5000",
+		},
+		{
+			name:      "Host only keeps the port",
+			published: PublishedEndpointSpec{Host: "identity.example.com"},
+			url:       "https://keystone-public.openstack.svc:5000",
+			want:      "https://identity.example.com:5000",
+		},
+		{
+			name:      "Route URL without port",
+			published: PublishedEndpointSpec{Host: "identity.example.com", Path: "identity/"},
+			url:       "https://keystone-public-openstack.apps.example.com",
+			want:      "https://identity.example.com/identity",
+		},
+		{
+			name:      "All parts",
+			published: PublishedEndpointSpec{Scheme: "https", Host: "lb.example.com", Port: &port, Path: "/v3"},
+			url:       "http://keystone-internal.openstack.svc:5000",
+			want:      "https://lb.example.com:8443/v3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := tt.published.Apply(tt.url)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestValidatePublishedEndpoints(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		spec     KeystoneAPISpecCore
+		wantErrs int
+	}{
+		{
+			name: "Public and internal",
+			spec: KeystoneAPISpecCore{PublishedEndpoints: map[service.Endpoint]PublishedEndpointSpec{
+				service.EndpointPublic:   {Host: "identity.example.com"},
+				service.EndpointInternal: {Path: "/identity"},
+			}},
+			wantErrs: 0,
+		},
+		{
+			name: "Unknown endpoint type and invalid host",
+			spec: KeystoneAPISpecCore{PublishedEndpoints: map[service.Endpoint]PublishedEndpointSpec{
+				"admin":                {},
+				service.EndpointPublic: {Host: "Identity_Example"},
+			}},
+			wantErrs: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.ValidatePublishedEndpoints(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidatePublicDNS(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		spec     KeystoneAPISpecCore
+		wantErrs int
+	}{
+		{
+			name:     "No public DNS",
+			spec:     KeystoneAPISpecCore{},
+			wantErrs: 0,
+		},
+		{
+			name:     "Public DNS",
+			spec:     KeystoneAPISpecCore{PublicDNS: &PublicDNSSpec{Hostname: "identity.example.com"}},
+			wantErrs: 0,
+		},
+		{
+			name: "Invalid hostname and published public host",
+			spec: KeystoneAPISpecCore{
+				PublicDNS: &PublicDNSSpec{Hostname: "Identity_Example"},
+				PublishedEndpoints: map[service.Endpoint]PublishedEndpointSpec{
+					service.EndpointPublic: {Host: "identity.example.com"},
+				},
+			},
+			wantErrs: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.ValidatePublicDNS(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidateRouteTLS(t *testing.T) {
+
+	publicCert := "cert-keystone-public-svc"
+	publicTLS := tls.API{API: tls.APIService{Public: tls.GenericService{SecretName: &publicCert}}}
+
+	tests := []struct {
+		name     string
+		spec     KeystoneAPISpecCore
+		wantErrs int
+	}{
+		{
+			name: "Edge without TLS",
+			spec: KeystoneAPISpecCore{RouteTLS: map[service.Endpoint]RouteTLSSpec{
+				service.EndpointPublic: {Termination: RouteTerminationEdge},
+			}},
+			wantErrs: 0,
+		},
+		{
+			name: "Reencrypt with destination CA and TLS",
+			spec: KeystoneAPISpecCore{
+				RouteTLS: map[service.Endpoint]RouteTLSSpec{
+					service.EndpointPublic: {Termination: RouteTerminationReencrypt, DestinationCACertificate: "CA"},
+				},
+				TLS: publicTLS,
+			},
+			wantErrs: 0,
+		},
+		{
+			name: "Passthrough without TLS",
+			spec: KeystoneAPISpecCore{RouteTLS: map[service.Endpoint]RouteTLSSpec{
+				service.EndpointPublic: {Termination: RouteTerminationPassthrough},
+			}},
+			wantErrs: 1,
+		},
+		{
+			name: "Edge with destination CA and unknown endpoint type",
+			spec: KeystoneAPISpecCore{RouteTLS: map[service.Endpoint]RouteTLSSpec{
+				service.EndpointPublic: {Termination: RouteTerminationEdge, DestinationCACertificate: "CA"},
+				"admin":                {Termination: RouteTerminationEdge},
+			}},
+			wantErrs: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.ValidateRouteTLS(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestPublicSecurityGetHeaders(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		security PublicSecuritySpec
+		want     map[string]string
+	}{
+		{
+			name:     "No headers",
+			security: PublicSecuritySpec{},
+			want:     map[string]string{},
+		},
+		{
+			name: "All headers",
+			security: PublicSecuritySpec{
+				HSTSMaxAge:            31536000,
+				HSTSIncludeSubDomains: true,
+				NoSniff:               true,
+				FrameOptions:          "DENY",
+				ExtraHeaders:          map[string]string{"Referrer-Policy": "no-referrer"},
+			},
+			want: map[string]string{
+				"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "DENY",
+				"Referrer-Policy":           "no-referrer",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.security.GetHeaders()).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestValidateExtraEnv(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		spec     KeystoneAPISpecCore
+		wantErrs int
+	}{
+		{
+			name:     "No extra env",
+			spec:     KeystoneAPISpecCore{},
+			wantErrs: 0,
+		},
+		{
+			name: "Extra env",
+			spec: KeystoneAPISpecCore{ExtraEnv: []corev1.EnvVar{
+				{Name: "HTTPS_PROXY", Value: "http://proxy.example.com:3128"},
+				{Name: "OS_DEBUG", Value: "1"},
+			}},
+			wantErrs: 0,
+		},
+		{
+			name: "Invalid and reserved names",
+			spec: KeystoneAPISpecCore{ExtraEnv: []corev1.EnvVar{
+				{Name: "1INVALID", Value: "1"},
+				{Name: "CONFIG_HASH", Value: "abc"},
+				{Name: "OS_BOOTSTRAP_USERNAME", Value: "admin"},
+			}},
+			wantErrs: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.ValidateExtraEnv(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidateTimeZone(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		spec     KeystoneAPISpecCore
+		wantErrs int
+	}{
+		{
+			name:     "No time zone",
+			spec:     KeystoneAPISpecCore{},
+			wantErrs: 0,
+		},
+		{
+			name:     "Time zone",
+			spec:     KeystoneAPISpecCore{TimeZone: "Europe/Berlin"},
+			wantErrs: 0,
+		},
+		{
+			name:     "Unknown time zone",
+			spec:     KeystoneAPISpecCore{TimeZone: "Mars/Olympus_Mons"},
+			wantErrs: 1,
+		},
+		{
+			name:     "Local time zone",
+			spec:     KeystoneAPISpecCore{TimeZone: "Local"},
+			wantErrs: 1,
+		},
+		{
+			name: "Time zone and TZ in extra env",
+			spec: KeystoneAPISpecCore{
+				TimeZone: "Europe/Berlin",
+				ExtraEnv: []corev1.EnvVar{{Name: "TZ", Value: "UTC"}},
+			},
+			wantErrs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.ValidateTimeZone(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidateDatabaseReader(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		spec     KeystoneAPISpecCore
+		wantErrs int
+	}{
+		{
+			name:     "No database reader",
+			spec:     KeystoneAPISpecCore{},
+			wantErrs: 0,
+		},
+		{
+			name:     "Database reader",
+			spec:     KeystoneAPISpecCore{DatabaseReader: &DatabaseReaderSpec{Host: "openstack-reader.openstack.svc"}},
+			wantErrs: 0,
+		},
+		{
+			name:     "Database reader with port",
+			spec:     KeystoneAPISpecCore{DatabaseReader: &DatabaseReaderSpec{Host: "openstack-reader.openstack.svc:3307"}},
+			wantErrs: 0,
+		},
+		{
+			name:     "Invalid host and port",
+			spec:     KeystoneAPISpecCore{DatabaseReader: &DatabaseReaderSpec{Host: "Reader_Host:99999"}},
+			wantErrs: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.ValidateDatabaseReader(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidateDatabaseSyncAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     KeystoneAPISpecCore
+		wantErrs int
+	}{
+		{
+			name:     "No sync account",
+			spec:     KeystoneAPISpecCore{DatabaseAccount: "keystone"},
+			wantErrs: 0,
+		},
+		{
+			name:     "Sync account",
+			spec:     KeystoneAPISpecCore{DatabaseAccount: "keystone", DatabaseSyncAccount: "keystone-db-sync"},
+			wantErrs: 0,
+		},
+		{
+			name:     "Same account",
+			spec:     KeystoneAPISpecCore{DatabaseAccount: "keystone", DatabaseSyncAccount: "keystone"},
+			wantErrs: 1,
+		},
+		{
+			name: "With PostgreSQL",
+			spec: KeystoneAPISpecCore{
+				DatabaseAccount:     "keystone",
+				DatabaseSyncAccount: "keystone-db-sync",
+				PostgreSQL: &PostgreSQLSpec{
+					Host:       "keystone-db-rw.openstack.svc",
+					SecretName: "keystone-db-app",
+				},
+			},
+			wantErrs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.ValidateDatabaseSyncAccount(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidatePostgreSQL(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		spec     KeystoneAPISpecCore
+		wantErrs int
+	}{
+		{
+			name:     "No PostgreSQL",
+			spec:     KeystoneAPISpecCore{},
+			wantErrs: 0,
+		},
+		{
+			name: "PostgreSQL",
+			spec: KeystoneAPISpecCore{PostgreSQL: &PostgreSQLSpec{
+				Host:       "keystone-db-rw.openstack.svc",
+				SecretName: "keystone-db-app",
+				SSLMode:    PostgreSQLSSLModeRequire,
+				Options:    map[string]string{"connect_timeout": "10"},
+			}},
+			wantErrs: 0,
+		},
+		{
+			name: "Verify full with CA bundle",
+			spec: KeystoneAPISpecCore{
+				PostgreSQL: &PostgreSQLSpec{
+					Host:       "keystone-db-rw.openstack.svc",
+					SecretName: "keystone-db-app",
+					SSLMode:    PostgreSQLSSLModeVerifyFull,
+				},
+				TLS: tls.API{Ca: tls.Ca{CaBundleSecretName: "combined-ca-bundle"}},
+			},
+			wantErrs: 0,
+		},
+		{
+			name: "Invalid host, verify full without CA bundle and sslmode option",
+			spec: KeystoneAPISpecCore{PostgreSQL: &PostgreSQLSpec{
+				Host:       "Keystone_DB",
+				SecretName: "keystone-db-app",
+				SSLMode:    PostgreSQLSSLModeVerifyFull,
+				Options:    map[string]string{"sslmode": "disable"},
+			}},
+			wantErrs: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.ValidatePostgreSQL(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidateDevMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     KeystoneAPISpecCore
+		wantErrs int
+	}{
+		{
+			name:     "Dev mode disabled",
+			spec:     KeystoneAPISpecCore{Replicas: ptr.To[int32](3), PostgreSQL: &PostgreSQLSpec{Host: "keystone-db"}},
+			wantErrs: 0,
+		},
+		{
+			name:     "Dev mode with a single replica",
+			spec:     KeystoneAPISpecCore{DevMode: true, Replicas: ptr.To[int32](1)},
+			wantErrs: 0,
+		},
+		{
+			name: "Dev mode with replicas and an external database",
+			spec: KeystoneAPISpecCore{
+				DevMode:        true,
+				Replicas:       ptr.To[int32](3),
+				PostgreSQL:     &PostgreSQLSpec{Host: "keystone-db"},
+				DatabaseReader: &DatabaseReaderSpec{Host: "openstack-reader"},
+			},
+			wantErrs: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.ValidateDevMode(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidateFernetKeys(t *testing.T) {
+	managedOptions := "[fernet_tokens]\nkey_repository = /tmp/keys\n# max_active_keys = 3\nmax_active_keys: 3\n"
+
+	tests := []struct {
+		name     string
+		spec     KeystoneAPISpecCore
+		old      *KeystoneAPISpecCore
+		wantErrs int
+	}{
+		{
+			name:     "Defaults",
+			spec:     KeystoneAPISpecCore{FernetMaxActiveKeys: ptr.To[int32](5), FernetRotationDays: ptr.To[int32](1)},
+			wantErrs: 0,
+		},
+		{
+			name:     "Too few keys",
+			spec:     KeystoneAPISpecCore{FernetMaxActiveKeys: ptr.To[int32](2), FernetRotationDays: ptr.To[int32](1)},
+			wantErrs: 1,
+		},
+		{
+			name: "Keys outlive a longer token expiration",
+			spec: KeystoneAPISpecCore{
+				FernetMaxActiveKeys: ptr.To[int32](4),
+				FernetRotationDays:  ptr.To[int32](1),
+				CustomServiceConfig: "[token]\nexpiration = 172800\n",
+			},
+			wantErrs: 0,
+		},
+		{
+			name: "Keys removed before the tokens expire",
+			spec: KeystoneAPISpecCore{
+				FernetMaxActiveKeys: ptr.To[int32](3),
+				FernetRotationDays:  ptr.To[int32](1),
+				CustomServiceConfig: "[DEFAULT]\ndebug = true\n[token]\nexpiration = 3600\nexpiration=172800\n",
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "Invalid token expiration",
+			spec: KeystoneAPISpecCore{
+				FernetMaxActiveKeys: ptr.To[int32](5),
+				FernetRotationDays:  ptr.To[int32](1),
+				CustomServiceConfig: "[token]\nexpiration = 1h\n",
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "Fernet options managed by the operator",
+			spec: KeystoneAPISpecCore{
+				FernetMaxActiveKeys: ptr.To[int32](5),
+				FernetRotationDays:  ptr.To[int32](1),
+				CustomServiceConfig: managedOptions,
+			},
+			wantErrs: 2,
+		},
+		{
+			name: "Fernet options added on update",
+			spec: KeystoneAPISpecCore{
+				FernetMaxActiveKeys: ptr.To[int32](5),
+				FernetRotationDays:  ptr.To[int32](1),
+				CustomServiceConfig: managedOptions,
+			},
+			old: &KeystoneAPISpecCore{
+				FernetMaxActiveKeys: ptr.To[int32](5),
+				FernetRotationDays:  ptr.To[int32](1),
+				CustomServiceConfig: "[fernet_tokens]\nkey_repository = /tmp/keys\n",
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "Fernet options kept on update",
+			spec: KeystoneAPISpecCore{
+				FernetMaxActiveKeys: ptr.To[int32](5),
+				FernetRotationDays:  ptr.To[int32](1),
+				CustomServiceConfig: "[DEFAULT]\ndebug = true\n" + managedOptions,
+			},
+			old: &KeystoneAPISpecCore{
+				FernetMaxActiveKeys: ptr.To[int32](5),
+				FernetRotationDays:  ptr.To[int32](1),
+				CustomServiceConfig: managedOptions,
+			},
+			wantErrs: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.ValidateFernetKeys(field.NewPath("spec"), tt.old)).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidateDrivers(t *testing.T) {
+
+	defaultDomain := map[string]DomainConfigSource{
+		DefaultDomainName: {SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "ldap"},
+			Key:                  "keystone.conf",
+		}},
+	}
+
+	tests := []struct {
+		name                string
+		drivers             *KeystoneDrivers
+		domainConfigs       map[string]DomainConfigSource
+		customServiceConfig string
+		wantErrs            int
+		wantWarnings        int
+	}{
+		{
+			name:         "No drivers",
+			drivers:      nil,
+			wantErrs:     0,
+			wantWarnings: 0,
+		},
+		{
+			name:         "SQL drivers",
+			drivers:      &KeystoneDrivers{Identity: KeystoneDriverSQL, Assignment: KeystoneDriverSQL, Resource: KeystoneDriverSQL},
+			wantErrs:     0,
+			wantWarnings: 0,
+		},
+		{
+			name:          "LDAP identity of the Default domain",
+			drivers:       &KeystoneDrivers{Identity: KeystoneDriverLDAP, Assignment: KeystoneDriverSQL, Resource: KeystoneDriverSQL},
+			domainConfigs: defaultDomain,
+			wantErrs:      0,
+			wantWarnings:  1,
+		},
+		{
+			name:         "LDAP identity without the Default domain config",
+			drivers:      &KeystoneDrivers{Identity: KeystoneDriverLDAP, Assignment: KeystoneDriverSQL, Resource: KeystoneDriverSQL},
+			wantErrs:     1,
+			wantWarnings: 1,
+		},
+		{
+			name:          "LDAP assignment and resource",
+			drivers:       &KeystoneDrivers{Identity: KeystoneDriverLDAP, Assignment: KeystoneDriverLDAP, Resource: KeystoneDriverLDAP},
+			domainConfigs: defaultDomain,
+			wantErrs:      2,
+			wantWarnings:  1,
+		},
+		{
+			name:                "Driver overridden by customServiceConfig",
+			drivers:             &KeystoneDrivers{Identity: KeystoneDriverSQL, Assignment: KeystoneDriverSQL, Resource: KeystoneDriverSQL},
+			customServiceConfig: "[assignment]\ndriver = sql\n",
+			wantErrs:            0,
+			wantWarnings:        1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := KeystoneAPISpecCore{
+				Drivers:             tt.drivers,
+				DomainConfigs:       tt.domainConfigs,
+				CustomServiceConfig: tt.customServiceConfig,
+			}
+			g.Expect(spec.ValidateDrivers(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+			g.Expect(spec.DriverWarnings(field.NewPath("spec"))).To(HaveLen(tt.wantWarnings))
+		})
+	}
+}
+
+func TestValidateTokenOptions(t *testing.T) {
+
+	tests := []struct {
+		name                string
+		token               *TokenSpec
+		customServiceConfig string
+		wantErrs            int
+		wantWarnings        int
+		wantCacheTime       time.Duration
+		wantCacheTimeSet    bool
+	}{
+		{
+			name:  "No token options",
+			token: nil,
+		},
+		{
+			name: "Caching with cache time",
+			token: &TokenSpec{
+				Caching:   ptr.To(true),
+				CacheTime: ptr.To[int32](60),
+				Revoke:    &RevokeSpec{Driver: "sql", ExpirationBuffer: ptr.To[int32](1800)},
+			},
+			wantCacheTime:    60 * time.Second,
+			wantCacheTimeSet: true,
+		},
+		{
+			name: "Caching disabled",
+			token: &TokenSpec{
+				Caching: ptr.To(false),
+			},
+			wantCacheTime:    0,
+			wantCacheTimeSet: true,
+		},
+		{
+			name: "Cache options with caching disabled",
+			token: &TokenSpec{
+				Caching:      ptr.To(false),
+				CacheTime:    ptr.To[int32](60),
+				CacheOnIssue: ptr.To(true),
+				Revoke:       &RevokeSpec{Caching: ptr.To(false), CacheTime: ptr.To[int32](60)},
+			},
+			wantErrs:         3,
+			wantWarnings:     1,
+			wantCacheTime:    0,
+			wantCacheTimeSet: true,
+		},
+		{
+			name: "Revocation by ID disabled",
+			token: &TokenSpec{
+				RevokeByID: ptr.To(false),
+			},
+			wantWarnings: 1,
+		},
+		{
+			name: "Options also set in customServiceConfig",
+			token: &TokenSpec{
+				Caching: ptr.To(true),
+				Revoke:  &RevokeSpec{Driver: "sql"},
+			},
+			customServiceConfig: "[token]\ncaching = false\n[revoke]\ndriver = sql\n",
+			wantErrs:            2,
+		},
+		{
+			name: "Other options in customServiceConfig",
+			token: &TokenSpec{
+				Caching: ptr.To(true),
+			},
+			customServiceConfig: "[token]\nexpiration = 7200\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := KeystoneAPISpecCore{
+				Token:               tt.token,
+				CustomServiceConfig: tt.customServiceConfig,
+			}
+			g.Expect(spec.ValidateTokenOptions(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+			g.Expect(spec.TokenWarnings(field.NewPath("spec"))).To(HaveLen(tt.wantWarnings))
+
+			cacheTime, ok := spec.GetTokenCacheTime()
+			g.Expect(ok).To(Equal(tt.wantCacheTimeSet))
+			g.Expect(cacheTime).To(Equal(tt.wantCacheTime))
+		})
+	}
+}
+
+func TestGetAdminClientEndpoint(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		adminClient  *AdminClientSpec
+		wantEndpoint service.Endpoint
+		wantURL      AdminClientURL
+	}{
+		{
+			name:         "Default",
+			adminClient:  nil,
+			wantEndpoint: service.EndpointInternal,
+			wantURL:      AdminClientURLCatalog,
+		},
+		{
+			name:         "Public catalog endpoint",
+			adminClient:  &AdminClientSpec{Interface: service.EndpointPublic},
+			wantEndpoint: service.EndpointPublic,
+			wantURL:      AdminClientURLCatalog,
+		},
+		{
+			name:         "Internal Service",
+			adminClient:  &AdminClientSpec{URL: AdminClientURLService},
+			wantEndpoint: service.EndpointInternal,
+			wantURL:      AdminClientURLService,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := KeystoneAPISpecCore{AdminClient: tt.adminClient}
+			endpointType, url := spec.GetAdminClientEndpoint()
+			g.Expect(endpointType).To(Equal(tt.wantEndpoint))
+			g.Expect(url).To(Equal(tt.wantURL))
+		})
+	}
+}
+
+func TestValidateNotifications(t *testing.T) {
+
+	tests := []struct {
+		name                string
+		notifications       *NotificationsSpec
+		auditLog            *AuditLogSpec
+		devMode             bool
+		customServiceConfig string
+		wantErrs            int
+	}{
+		{
+			name:          "No notification options",
+			notifications: nil,
+			wantErrs:      0,
+		},
+		{
+			name: "Token events only in the audit log",
+			notifications: &NotificationsSpec{
+				Format: NotificationFormatCADF,
+				Topics: []string{"notifications"},
+				Routes: []NotificationRoute{
+					{Destination: NotificationDestinationLog},
+					{Destination: NotificationDestinationMessaging, EventTypes: []string{"identity.user.*", "identity.project.*"}},
+				},
+			},
+			auditLog: &AuditLogSpec{Verbosity: AuditLogFull},
+			wantErrs: 0,
+		},
+		{
+			name:          "Basic format with the audit log",
+			notifications: &NotificationsSpec{Format: NotificationFormatBasic},
+			auditLog:      &AuditLogSpec{Verbosity: AuditLogStandard},
+			wantErrs:      1,
+		},
+		{
+			name: "Messaging route in dev mode",
+			notifications: &NotificationsSpec{
+				Routes: []NotificationRoute{
+					{Destination: NotificationDestinationLog},
+					{Destination: NotificationDestinationMessaging},
+				},
+			},
+			devMode:  true,
+			wantErrs: 1,
+		},
+		{
+			name: "Options also set in customServiceConfig",
+			notifications: &NotificationsSpec{
+				OptOut: []NotificationEventType{"identity.authenticate.success"},
+				Routes: []NotificationRoute{{Destination: NotificationDestinationLog}},
+			},
+			customServiceConfig: "[DEFAULT]\nnotification_opt_out = identity.user.created\n[oslo_messaging_notifications]\ndriver = log\n",
+			wantErrs:            2,
+		},
+		{
+			name:                "Unset options in customServiceConfig",
+			notifications:       &NotificationsSpec{Format: NotificationFormatCADF},
+			customServiceConfig: "[oslo_messaging_notifications]\ntopics = notifications\n",
+			wantErrs:            0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := KeystoneAPISpecCore{
+				Notifications:       tt.notifications,
+				AuditLog:            tt.auditLog,
+				DevMode:             tt.devMode,
+				CustomServiceConfig: tt.customServiceConfig,
+			}
+			g.Expect(spec.ValidateNotifications(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
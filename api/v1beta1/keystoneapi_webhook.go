@@ -24,7 +24,10 @@ package v1beta1
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
+	routev1 "github.com/openshift/api/route/v1"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -88,8 +91,7 @@ func (spec *KeystoneAPISpecCore) Default() {
 	}
 }
 
-// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
-//+kubebuilder:webhook:path=/validate-keystone-openstack-org-v1beta1-keystoneapi,mutating=false,failurePolicy=fail,sideEffects=None,groups=keystone.openstack.org,resources=keystoneapis,verbs=create;update,versions=v1beta1,name=vkeystoneapi.kb.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-keystone-openstack-org-v1beta1-keystoneapi,mutating=false,failurePolicy=fail,sideEffects=None,groups=keystone.openstack.org,resources=keystoneapis,verbs=create;update;delete,versions=v1beta1,name=vkeystoneapi.kb.io,admissionReviewVersions=v1
 
 var _ webhook.Validator = &KeystoneAPI{}
 
@@ -108,26 +110,18 @@ func (r *KeystoneAPI) ValidateCreate() (admission.Warnings, error) {
 		return nil, apierrors.NewInvalid(GroupVersion.WithKind("KeystoneAPI").GroupKind(), r.Name, allErrs)
 	}
 
-	return nil, nil
+	return r.Spec.warnings(basePath), nil
 }
 
 // ValidateCreate - Exported function wrapping non-exported validate functions,
 // this function can be called externally to validate an KeystoneAPI spec.
 func (spec *KeystoneAPISpec) ValidateCreate(basePath *field.Path, namespace string) field.ErrorList {
-	return spec.KeystoneAPISpecCore.ValidateCreate(basePath, namespace)
+	allErrs := spec.KeystoneAPISpecCore.ValidateCreate(basePath, namespace)
+	return append(allErrs, spec.ValidateImageVerification(basePath, spec.ContainerImage)...)
 }
 
 func (spec *KeystoneAPISpecCore) ValidateCreate(basePath *field.Path, namespace string) field.ErrorList {
-	var allErrs field.ErrorList
-
-	// validate the service override key is valid
-	allErrs = append(allErrs, service.ValidateRoutedOverrides(basePath.Child("override").Child("service"), spec.Override.Service)...)
-
-	// When a TopologyRef CR is referenced, fail if a different Namespace is
-	// referenced because is not supported
-	allErrs = append(allErrs, spec.ValidateTopology(basePath, namespace)...)
-
-	return allErrs
+	return spec.validate(basePath, namespace, nil)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -150,16 +144,23 @@ func (r *KeystoneAPI) ValidateUpdate(old runtime.Object) (admission.Warnings, er
 		return nil, apierrors.NewInvalid(GroupVersion.WithKind("KeystoneAPI").GroupKind(), r.Name, allErrs)
 	}
 
-	return nil, nil
+	return r.Spec.warnings(basePath), nil
 }
 
 // ValidateUpdate - Exported function wrapping non-exported validate functions,
 // this function can be called externally to validate an ironic spec.
 func (spec *KeystoneAPISpec) ValidateUpdate(old KeystoneAPISpec, basePath *field.Path, namespace string) field.ErrorList {
-	return spec.KeystoneAPISpecCore.ValidateUpdate(old.KeystoneAPISpecCore, basePath, namespace)
+	allErrs := spec.KeystoneAPISpecCore.ValidateUpdate(old.KeystoneAPISpecCore, basePath, namespace)
+	return append(allErrs, spec.ValidateImageVerification(basePath, spec.ContainerImage)...)
 }
 
-func (spec *KeystoneAPISpecCore) ValidateUpdate(_ KeystoneAPISpecCore, basePath *field.Path, namespace string) field.ErrorList {
+func (spec *KeystoneAPISpecCore) ValidateUpdate(old KeystoneAPISpecCore, basePath *field.Path, namespace string) field.ErrorList {
+	return spec.validate(basePath, namespace, &old)
+}
+
+// validate - validations shared by the create and the update of the spec,
+// old is nil on create
+func (spec *KeystoneAPISpecCore) validate(basePath *field.Path, namespace string, old *KeystoneAPISpecCore) field.ErrorList {
 	var allErrs field.ErrorList
 
 	// validate the service override key is valid
@@ -169,19 +170,137 @@ func (spec *KeystoneAPISpecCore) ValidateUpdate(_ KeystoneAPISpecCore, basePath
 	// referenced because is not supported
 	allErrs = append(allErrs, spec.ValidateTopology(basePath, namespace)...)
 
+	allErrs = append(allErrs, spec.ValidateDomainConfigs(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidateDrivers(basePath)...)
+	allErrs = append(allErrs, spec.ValidateTokenOptions(basePath)...)
+	allErrs = append(allErrs, spec.ValidateNotifications(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidateTermination(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidateAutoscaling(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidateWSGIServer(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidatePublicDeployment(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidateTokenlessAuth(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidatePublishedEndpoints(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidateRouteTLS(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidatePublicDNS(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidateExtraEnv(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidateTimeZone(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidateDatabaseReader(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidateDatabaseSyncAccount(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidatePostgreSQL(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidateDevMode(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidateFernetKeys(basePath, old)...)
+
+	allErrs = append(allErrs, spec.ValidateCustomServiceConfigLayers(basePath)...)
+
+	allErrs = append(allErrs, spec.ValidateCustomServiceConfig(basePath)...)
+
 	return allErrs
 }
 
+// warnings - admission warnings of the create and the update of the spec
+func (spec *KeystoneAPISpecCore) warnings(basePath *field.Path) admission.Warnings {
+	return append(spec.DriverWarnings(basePath), spec.TokenWarnings(basePath)...)
+}
+
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
 func (r *KeystoneAPI) ValidateDelete() (admission.Warnings, error) {
 	keystoneapilog.Info("validate delete", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object deletion.
+	// the deletion is not blocked, the KeystoneAPI gets deleted once the CRs
+	// registered in keystone are gone
+	if dependents := r.GetDependents(); len(dependents) > 0 {
+		return admission.Warnings{
+			fmt.Sprintf(KeystoneDeletionBlockedMessage, strings.Join(dependents, ", ")),
+		}, nil
+	}
 	return nil, nil
 }
 
-// SetDefaultRouteAnnotations sets HAProxy timeout values of the route
+// SetDefaultRouteAnnotations sets HAProxy timeout and rate limit values and
+// the external-dns annotations of the route
 func (spec *KeystoneAPISpecCore) SetDefaultRouteAnnotations(annotations map[string]string) {
+	spec.setRouteTimeoutAnnotations(annotations)
+	spec.setRouteRateLimitAnnotations(annotations)
+	spec.setRouteDNSAnnotations(annotations)
+}
+
+// setRouteDNSAnnotations sets the external-dns annotations of the route.
+// The annotations set by the operator get removed once publicDNS is unset.
+func (spec *KeystoneAPISpecCore) setRouteDNSAnnotations(annotations map[string]string) {
+	// Use a custom annotation to record the external-dns annotations set by
+	// the operator
+	const keystoneAnno = "api.keystone.openstack.org/external-dns"
+
+	if managed, ok := annotations[keystoneAnno]; ok {
+		for _, anno := range strings.Split(managed, ",") {
+			delete(annotations, anno)
+		}
+		delete(annotations, keystoneAnno)
+	}
+	if spec.PublicDNS == nil {
+		return
+	}
+
+	dnsAnnotations := spec.PublicDNS.GetAnnotations()
+	managed := make([]string, 0, len(dnsAnnotations))
+	for anno, value := range dnsAnnotations {
+		annotations[anno] = value
+		managed = append(managed, anno)
+	}
+	sort.Strings(managed)
+	annotations[keystoneAnno] = strings.Join(managed, ",")
+}
+
+// SetRouteTLSConfig sets the TLS termination of the route exposing the
+// endpoint from the routeTLS spec. The TLS config is kept unchanged if the
+// endpoint has no routeTLS entry.
+func (spec *KeystoneAPISpecCore) SetRouteTLSConfig(endpoint service.Endpoint, tlsConfig *routev1.TLSConfig) {
+	routeTLS, ok := spec.RouteTLS[endpoint]
+	if !ok || tlsConfig == nil {
+		return
+	}
+
+	switch routeTLS.Termination {
+	case RouteTerminationEdge:
+		tlsConfig.Termination = routev1.TLSTerminationEdge
+		tlsConfig.DestinationCACertificate = ""
+	case RouteTerminationReencrypt:
+		tlsConfig.Termination = routev1.TLSTerminationReencrypt
+		if routeTLS.DestinationCACertificate != "" {
+			tlsConfig.DestinationCACertificate = routeTLS.DestinationCACertificate
+		}
+	case RouteTerminationPassthrough:
+		// the router does not see the traffic, certificates and a redirect
+		// of insecure traffic are not supported
+		tlsConfig.Termination = routev1.TLSTerminationPassthrough
+		tlsConfig.Certificate = ""
+		tlsConfig.Key = ""
+		tlsConfig.CACertificate = ""
+		tlsConfig.DestinationCACertificate = ""
+		if tlsConfig.InsecureEdgeTerminationPolicy == routev1.InsecureEdgeTerminationPolicyAllow {
+			tlsConfig.InsecureEdgeTerminationPolicy = routev1.InsecureEdgeTerminationPolicyNone
+		}
+	}
+}
+
+// setRouteTimeoutAnnotations sets HAProxy timeout values of the route
+func (spec *KeystoneAPISpecCore) setRouteTimeoutAnnotations(annotations map[string]string) {
 	const haProxyAnno = "haproxy.router.openshift.io/timeout"
 	// Use a custom annotation to flag when the operator has set the default HAProxy timeout
 	// With the annotation func determines when to overwrite existing HAProxy timeout with the APITimeout
@@ -197,7 +316,42 @@ func (spec *KeystoneAPISpecCore) SetDefaultRouteAnnotations(annotations map[stri
 		delete(annotations, keystoneAnno)
 		return
 	}
-	timeout := fmt.Sprintf("%ds", spec.APITimeout)
+	timeout := fmt.Sprintf("%ds", spec.GetPublicAPITimeout())
 	annotations[keystoneAnno] = timeout
 	annotations[haProxyAnno] = timeout
 }
+
+// setRouteRateLimitAnnotations sets the HAProxy rate limits of the route.
+// Rate limits set manually on the route are kept if no rate limit is
+// configured, the ones set by the operator get removed.
+func (spec *KeystoneAPISpecCore) setRouteRateLimitAnnotations(annotations map[string]string) {
+	const rateLimitAnno = "haproxy.router.openshift.io/rate-limit-connections"
+	const concurrentTCPAnno = rateLimitAnno + ".concurrent-tcp"
+	const rateHTTPAnno = rateLimitAnno + ".rate-http"
+	const rateTCPAnno = rateLimitAnno + ".rate-tcp"
+	// Use a custom annotation to flag when the operator has set the rate limits
+	const keystoneAnno = "api.keystone.openstack.org/rate-limit"
+
+	if spec.RateLimit == nil {
+		if _, ok := annotations[keystoneAnno]; ok {
+			for _, anno := range []string{keystoneAnno, rateLimitAnno, concurrentTCPAnno, rateHTTPAnno, rateTCPAnno} {
+				delete(annotations, anno)
+			}
+		}
+		return
+	}
+
+	annotations[keystoneAnno] = "true"
+	annotations[rateLimitAnno] = "true"
+	for anno, limit := range map[string]*int32{
+		concurrentTCPAnno: spec.RateLimit.ConcurrentConnections,
+		rateHTTPAnno:      spec.RateLimit.HTTPRequestRate,
+		rateTCPAnno:       spec.RateLimit.TCPConnectionRate,
+	} {
+		if limit == nil {
+			delete(annotations, anno)
+			continue
+		}
+		annotations[anno] = fmt.Sprintf("%d", *limit)
+	}
+}
@@ -0,0 +1,268 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
+	"k8s.io/utils/ptr"
+)
+
+func TestSetDefaultRouteAnnotations(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		rateLimit   *RateLimitSpec
+		annotations map[string]string
+		want        map[string]string
+	}{
+		{
+			name:        "No rate limit",
+			rateLimit:   nil,
+			annotations: map[string]string{},
+			want: map[string]string{
+				"api.keystone.openstack.org/timeout":  "60s",
+				"haproxy.router.openshift.io/timeout": "60s",
+			},
+		},
+		{
+			name: "Rate limit",
+			rateLimit: &RateLimitSpec{
+				ConcurrentConnections: ptr.To[int32](20),
+				HTTPRequestRate:       ptr.To[int32](100),
+			},
+			annotations: map[string]string{
+				"haproxy.router.openshift.io/rate-limit-connections.rate-tcp": "10",
+				"api.keystone.openstack.org/rate-limit":                       "true",
+			},
+			want: map[string]string{
+				"api.keystone.openstack.org/timeout":                                "60s",
+				"haproxy.router.openshift.io/timeout":                               "60s",
+				"api.keystone.openstack.org/rate-limit":                             "true",
+				"haproxy.router.openshift.io/rate-limit-connections":                "true",
+				"haproxy.router.openshift.io/rate-limit-connections.concurrent-tcp": "20",
+				"haproxy.router.openshift.io/rate-limit-connections.rate-http":      "100",
+			},
+		},
+		{
+			name:      "Rate limit removed",
+			rateLimit: nil,
+			annotations: map[string]string{
+				"api.keystone.openstack.org/rate-limit":                        "true",
+				"haproxy.router.openshift.io/rate-limit-connections":           "true",
+				"haproxy.router.openshift.io/rate-limit-connections.rate-http": "100",
+			},
+			want: map[string]string{
+				"api.keystone.openstack.org/timeout":  "60s",
+				"haproxy.router.openshift.io/timeout": "60s",
+			},
+		},
+		{
+			name:      "Manual rate limit kept",
+			rateLimit: nil,
+			annotations: map[string]string{
+				"haproxy.router.openshift.io/rate-limit-connections":           "true",
+				"haproxy.router.openshift.io/rate-limit-connections.rate-http": "100",
+			},
+			want: map[string]string{
+				"api.keystone.openstack.org/timeout":                           "60s",
+				"haproxy.router.openshift.io/timeout":                          "60s",
+				"haproxy.router.openshift.io/rate-limit-connections":           "true",
+				"haproxy.router.openshift.io/rate-limit-connections.rate-http": "100",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := KeystoneAPISpecCore{APITimeout: 60, RateLimit: tt.rateLimit}
+			spec.SetDefaultRouteAnnotations(tt.annotations)
+			g.Expect(tt.annotations).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestSetRouteTLSConfig(t *testing.T) {
+
+	edge := func() *routev1.TLSConfig {
+		return &routev1.TLSConfig{
+			Termination:                   routev1.TLSTerminationEdge,
+			Certificate:                   "cert",
+			Key:                           "key",
+			CACertificate:                 "ca",
+			InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyAllow,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		routeTLS map[service.Endpoint]RouteTLSSpec
+		want     *routev1.TLSConfig
+	}{
+		{
+			name: "No routeTLS",
+			want: edge(),
+		},
+		{
+			name: "Reencrypt with destination CA",
+			routeTLS: map[service.Endpoint]RouteTLSSpec{
+				service.EndpointPublic: {Termination: RouteTerminationReencrypt, DestinationCACertificate: "destca"},
+			},
+			want: &routev1.TLSConfig{
+				Termination:                   routev1.TLSTerminationReencrypt,
+				Certificate:                   "cert",
+				Key:                           "key",
+				CACertificate:                 "ca",
+				DestinationCACertificate:      "destca",
+				InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyAllow,
+			},
+		},
+		{
+			name: "Passthrough",
+			routeTLS: map[service.Endpoint]RouteTLSSpec{
+				service.EndpointPublic: {Termination: RouteTerminationPassthrough},
+			},
+			want: &routev1.TLSConfig{
+				Termination:                   routev1.TLSTerminationPassthrough,
+				InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyNone,
+			},
+		},
+		{
+			name: "Other endpoint",
+			routeTLS: map[service.Endpoint]RouteTLSSpec{
+				service.EndpointInternal: {Termination: RouteTerminationPassthrough},
+			},
+			want: edge(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := KeystoneAPISpecCore{RouteTLS: tt.routeTLS}
+			tlsConfig := edge()
+			spec.SetRouteTLSConfig(service.EndpointPublic, tlsConfig)
+			g.Expect(tlsConfig).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestSetRouteDNSAnnotations(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := KeystoneAPISpecCore{PublicDNS: &PublicDNSSpec{
+		Hostname:    "identity.example.com",
+		TTL:         ptr.To[int32](300),
+		Annotations: map[string]string{"external-dns.alpha.kubernetes.io/target": "lb.example.com"},
+	}}
+	annotations := map[string]string{"custom": "value"}
+	spec.setRouteDNSAnnotations(annotations)
+	g.Expect(annotations).To(Equal(map[string]string{
+		"custom": "value",
+		"external-dns.alpha.kubernetes.io/hostname": "identity.example.com",
+		"external-dns.alpha.kubernetes.io/ttl":      "300",
+		"external-dns.alpha.kubernetes.io/target":   "lb.example.com",
+		"api.keystone.openstack.org/external-dns":   "external-dns.alpha.kubernetes.io/hostname,external-dns.alpha.kubernetes.io/target,external-dns.alpha.kubernetes.io/ttl",
+	}))
+
+	// annotations removed from the spec get removed from the route
+	spec.PublicDNS.TTL = nil
+	spec.PublicDNS.Annotations = nil
+	spec.setRouteDNSAnnotations(annotations)
+	g.Expect(annotations).To(Equal(map[string]string{
+		"custom": "value",
+		"external-dns.alpha.kubernetes.io/hostname": "identity.example.com",
+		"api.keystone.openstack.org/external-dns":   "external-dns.alpha.kubernetes.io/hostname",
+	}))
+
+	spec.PublicDNS = nil
+	spec.setRouteDNSAnnotations(annotations)
+	g.Expect(annotations).To(Equal(map[string]string{"custom": "value"}))
+}
+
+func TestValidateDeleteWarnsAboutDependents(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		finalizers []string
+		want       []string
+	}{
+		{
+			name:       "No dependents",
+			finalizers: []string{"openstack.org/keystoneapi"},
+			want:       nil,
+		},
+		{
+			name: "Dependents",
+			finalizers: []string{
+				"openstack.org/keystoneapi",
+				"openstack.org/keystoneservice-nova",
+				"openstack.org/keystoneendpoint-nova-api",
+				"example.com/other",
+			},
+			want: []string{
+				"KeystoneAPI deletion waits for the deletion of example.com/other, keystoneendpoint/nova-api, keystoneservice/nova",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			instance := &KeystoneAPI{}
+			instance.Finalizers = tt.finalizers
+
+			warnings, err := instance.ValidateDelete()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect([]string(warnings)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestValidateUpdateKeepsFernetOptions(t *testing.T) {
+	g := NewWithT(t)
+
+	old := &KeystoneAPI{}
+	old.Name = "keystone"
+	old.Namespace = "openstack"
+	old.Spec.ContainerImage = "quay.io/podified-antelope-centos9/openstack-keystone:current-podified"
+	old.Spec.CustomServiceConfig = "[fernet_tokens]\nmax_active_keys = 5\n"
+	old.Default()
+
+	// an unrelated change of an instance already setting a managed option
+	updated := old.DeepCopy()
+	updated.Spec.Replicas = ptr.To[int32](3)
+	_, err := updated.ValidateUpdate(old)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// adding another managed option gets rejected
+	updated.Spec.CustomServiceConfig += "key_repository = /tmp/keys\n"
+	_, err = updated.ValidateUpdate(old)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("[fernet_tokens] key_repository is managed by the operator"))
+
+	// on create both get rejected
+	_, err = updated.ValidateCreate()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("[fernet_tokens] max_active_keys is managed by the operator"))
+}
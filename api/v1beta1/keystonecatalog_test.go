@@ -0,0 +1,116 @@
+/*
+Copyright 2022 Red Hat
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderCatalog(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		services []KeystoneCatalogService
+		want     map[string]map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "No services",
+			services: []KeystoneCatalogService{},
+			want:     map[string]map[string]string{},
+		},
+		{
+			name: "Renders variables of all services",
+			services: []KeystoneCatalogService{
+				{
+					ServiceName: "placement",
+					Endpoints: map[string]string{
+						"public":   "https://{serviceName}-public.{domain}",
+						"internal": "http://{serviceName}-internal.{namespace}.svc:8778",
+					},
+				},
+				{
+					ServiceName: "glance",
+					Endpoints: map[string]string{
+						"admin": "http://glance.{namespace}.svc:9292/{region}",
+					},
+				},
+			},
+			want: map[string]map[string]string{
+				"placement": {
+					"public":   "https://placement-public.example.com",
+					"internal": "http://placement-internal.openstack.svc:8778",
+				},
+				"glance": {
+					"admin": "http://glance.openstack.svc:9292/regionOne",
+				},
+			},
+		},
+		{
+			name: "Unsupported endpoint type",
+			services: []KeystoneCatalogService{
+				{
+					ServiceName: "placement",
+					Endpoints:   map[string]string{"private": "http://placement"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unknown variable in second service",
+			services: []KeystoneCatalogService{
+				{
+					ServiceName: "placement",
+					Endpoints:   map[string]string{"public": "http://placement"},
+				},
+				{
+					ServiceName: "glance",
+					Endpoints:   map[string]string{"public": "http://{unknown}"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			catalog := KeystoneCatalog{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "catalog",
+					Namespace: "openstack",
+				},
+				Spec: KeystoneCatalogSpec{
+					Services:     tt.services,
+					URLVariables: map[string]string{"domain": "example.com"},
+				},
+			}
+
+			got, err := catalog.RenderCatalog("regionOne")
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeystoneCatalogSpec defines the desired state of KeystoneCatalog
+type KeystoneCatalogSpec struct {
+	// +kubebuilder:validation:Required
+	// +listType=map
+	// +listMapKey=serviceName
+	// Services - services of the catalog with their endpoints. Services must
+	// not be managed by a KeystoneService or KeystoneEndpoint at the same time.
+	Services []KeystoneCatalogService `json:"services"`
+	// +kubebuilder:validation:Optional
+	// URLVariables - additional variables which can be referenced in the
+	// endpoint URLs of all services. They take precedence over the builtin
+	// variables.
+	URLVariables map[string]string `json:"urlVariables,omitempty"`
+}
+
+// KeystoneCatalogService defines a service and its endpoints in the catalog
+type KeystoneCatalogService struct {
+	// +kubebuilder:validation:Required
+	// ServiceName - Name of the service.
+	ServiceName string `json:"serviceName"`
+	// +kubebuilder:validation:Required
+	// ServiceType - Type is the type of the service.
+	ServiceType string `json:"serviceType"`
+	// +kubebuilder:validation:Optional
+	// ServiceDescription - Description for the service.
+	ServiceDescription string `json:"serviceDescription,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=true
+	// Enabled - whether or not the service is enabled.
+	Enabled bool `json:"enabled"`
+	// +kubebuilder:validation:Optional
+	// Endpoints - map with service api endpoint URLs with the endpoint type as
+	// index. The URLs get rendered like the ones of a KeystoneEndpoint, the
+	// builtin variables are {namespace}, {region} and {serviceName}.
+	Endpoints map[string]string `json:"endpoints,omitempty"`
+}
+
+// KeystoneCatalogStatus defines the observed state of KeystoneCatalog
+type KeystoneCatalogStatus struct {
+	// Services - services registered by the catalog
+	Services []KeystoneCatalogServiceStatus `json:"services,omitempty"`
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	//ObservedGeneration - the most recent generation observed for this catalog. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime - time of the last reconcile. While the result of the
+	// reconcile and the applied spec do not change it gets updated at most once
+	// a minute.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastSuccessfulReconcile - time of the last reconcile which finished
+	// without an error and left the instance ready
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// AppliedSpecHash - hash of the spec applied by the last successful reconcile
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+}
+
+// KeystoneCatalogServiceStatus - registered service of the catalog
+type KeystoneCatalogServiceStatus struct {
+	// ServiceName - name of the service
+	ServiceName string `json:"serviceName"`
+	// ServiceID - ID of the service in keystone
+	ServiceID string `json:"serviceID,omitempty"`
+	// Endpoints - registered endpoints of the service
+	Endpoints []Endpoint `json:"endpoints,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneCatalog is the Schema for the keystonecatalogs API
+type KeystoneCatalog struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneCatalogSpec   `json:"spec,omitempty"`
+	Status KeystoneCatalogStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneCatalogList contains a list of KeystoneCatalog
+type KeystoneCatalogList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneCatalog `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneCatalog{}, &KeystoneCatalogList{})
+}
+
+// IsReady - returns true if KeystoneCatalog is reconciled successfully for
+// the current generation of the spec
+func (instance KeystoneCatalog) IsReady() bool {
+	return instance.Generation == instance.Status.ObservedGeneration &&
+		instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
+
+// RenderCatalog - validates the endpoint types of all services and returns
+// their endpoint URLs with all variables rendered, indexed by the service
+// name and the endpoint type. Nothing gets registered unless the whole
+// catalog renders.
+func (instance KeystoneCatalog) RenderCatalog(region string) (map[string]map[string]string, error) {
+	catalog := make(map[string]map[string]string, len(instance.Spec.Services))
+	for _, svc := range instance.Spec.Services {
+		vars := map[string]string{
+			"namespace":   instance.Namespace,
+			"region":      region,
+			"serviceName": svc.ServiceName,
+		}
+		for k, v := range instance.Spec.URLVariables {
+			vars[k] = v
+		}
+
+		endpoints := make(map[string]string, len(svc.Endpoints))
+		for endpointType, endpointURL := range svc.Endpoints {
+			switch endpointType {
+			case string(service.EndpointPublic), string(service.EndpointInternal), string(service.EndpointAdmin):
+			default:
+				return nil, fmt.Errorf("service %s: endpoint type %s not supported, must be one of %s, %s or %s",
+					svc.ServiceName, endpointType, service.EndpointPublic, service.EndpointInternal, service.EndpointAdmin)
+			}
+			rendered, err := RenderEndpointURL(endpointURL, vars)
+			if err != nil {
+				return nil, fmt.Errorf("service %s %s endpoint: %w", svc.ServiceName, endpointType, err)
+			}
+			endpoints[endpointType] = rendered
+		}
+		catalog[svc.ServiceName] = endpoints
+	}
+
+	return catalog, nil
+}
+
+// GetServiceStatus - returns the status of the registered service, nil if the
+// service is not registered by the catalog
+func (instance *KeystoneCatalog) GetServiceStatus(serviceName string) *KeystoneCatalogServiceStatus {
+	for i := range instance.Status.Services {
+		if instance.Status.Services[i].ServiceName == serviceName {
+			return &instance.Status.Services[i]
+		}
+	}
+
+	return nil
+}
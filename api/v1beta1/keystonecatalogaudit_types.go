@@ -0,0 +1,127 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CatalogAuditFindingType - type of a mismatch found by the catalog audit
+type CatalogAuditFindingType string
+
+const (
+	// CatalogAuditUnmanagedService - service registered in keystone without a KeystoneService
+	CatalogAuditUnmanagedService CatalogAuditFindingType = "UnmanagedService"
+	// CatalogAuditMissingService - KeystoneService whose service is not registered in keystone
+	CatalogAuditMissingService CatalogAuditFindingType = "MissingService"
+	// CatalogAuditUnmanagedEndpoint - endpoint registered in keystone without a KeystoneEndpoint
+	CatalogAuditUnmanagedEndpoint CatalogAuditFindingType = "UnmanagedEndpoint"
+	// CatalogAuditMissingEndpoint - KeystoneEndpoint whose endpoint is not registered in keystone
+	CatalogAuditMissingEndpoint CatalogAuditFindingType = "MissingEndpoint"
+	// CatalogAuditMismatchedEndpoint - endpoint registered in keystone with a different URL than its KeystoneEndpoint
+	CatalogAuditMismatchedEndpoint CatalogAuditFindingType = "MismatchedEndpoint"
+	// CatalogAuditOrphanedService - service registered by the operator of this cluster for a CR which no longer exists
+	CatalogAuditOrphanedService CatalogAuditFindingType = "OrphanedService"
+	// CatalogAuditOrphanedEndpoint - endpoint registered by the operator of this cluster for a CR which no longer exists
+	CatalogAuditOrphanedEndpoint CatalogAuditFindingType = "OrphanedEndpoint"
+)
+
+// KeystoneCatalogAuditSpec defines the desired state of KeystoneCatalogAudit
+type KeystoneCatalogAuditSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=600
+	// +kubebuilder:validation:Minimum=60
+	// AuditInterval - interval in seconds the catalog gets audited in
+	AuditInterval int `json:"auditInterval,omitempty"`
+}
+
+// CatalogAuditFinding - a mismatch between the keystone catalog and the
+// KeystoneService/KeystoneEndpoint CRs
+type CatalogAuditFinding struct {
+	// Type - type of the mismatch
+	Type CatalogAuditFindingType `json:"type"`
+	// ID - ID of the service or endpoint in keystone
+	ID string `json:"id,omitempty"`
+	// Name - name of the service or endpoint, or of the CR referencing it
+	Name string `json:"name,omitempty"`
+	// Detail - human readable description of the mismatch
+	Detail string `json:"detail,omitempty"`
+}
+
+// KeystoneCatalogAuditStatus defines the observed state of KeystoneCatalogAudit
+type KeystoneCatalogAuditStatus struct {
+	// LastAuditTime - time of the last successful audit
+	LastAuditTime *metav1.Time `json:"lastAuditTime,omitempty"`
+	// MismatchCount - number of mismatches found by the last audit
+	MismatchCount int `json:"mismatchCount,omitempty"`
+	// Findings - mismatches found by the last audit
+	Findings []CatalogAuditFinding `json:"findings,omitempty"`
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	//ObservedGeneration - the most recent generation observed for this audit. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime - time of the last reconcile. While the result of the
+	// reconcile and the applied spec do not change it gets updated at most once
+	// a minute.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastSuccessfulReconcile - time of the last reconcile which finished
+	// without an error and left the instance ready
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// AppliedSpecHash - hash of the spec applied by the last successful reconcile
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Mismatches",type="integer",JSONPath=".status.mismatchCount",description="Mismatches"
+//+kubebuilder:printcolumn:name="LastAudit",type="date",JSONPath=".status.lastAuditTime",description="LastAudit"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneCatalogAudit is the Schema for the keystonecatalogaudits API
+type KeystoneCatalogAudit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneCatalogAuditSpec   `json:"spec,omitempty"`
+	Status KeystoneCatalogAuditStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneCatalogAuditList contains a list of KeystoneCatalogAudit
+type KeystoneCatalogAuditList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneCatalogAudit `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneCatalogAudit{}, &KeystoneCatalogAuditList{})
+}
+
+// IsReady - returns true if KeystoneCatalogAudit is reconciled successfully for
+// the current generation of the spec
+func (instance KeystoneCatalogAudit) IsReady() bool {
+	return instance.Generation == instance.Status.ObservedGeneration &&
+		instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CredentialType - type of a keystone credential
+type CredentialType string
+
+const (
+	// CredentialTypeCert - X.509 certificate credential
+	CredentialTypeCert CredentialType = "cert"
+	// CredentialTypeTOTP - TOTP secret of a user, used by the totp auth method
+	CredentialTypeTOTP CredentialType = "totp"
+	// CredentialTypeEC2 - EC2 credential, the blob is a JSON document with
+	// access and secret
+	CredentialTypeEC2 CredentialType = "ec2"
+)
+
+// KeystoneCredentialSpec defines the desired state of KeystoneCredential
+type KeystoneCredentialSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=cert;totp;ec2
+	// Type - type of the credential
+	Type CredentialType `json:"type"`
+	// +kubebuilder:validation:Required
+	// UserName - Name of the user the credential belongs to
+	UserName string `json:"userName"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// UserDomain - Name of the domain of the user
+	UserDomain string `json:"userDomain,omitempty"`
+	// +kubebuilder:validation:Optional
+	// ProjectName - Name of the project the credential is scoped to, required
+	// for ec2 credentials
+	ProjectName string `json:"projectName,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// ProjectDomain - Name of the domain of the project
+	ProjectDomain string `json:"projectDomain,omitempty"`
+	// +kubebuilder:validation:Required
+	// BlobSecretRef - key of the Secret holding the blob of the credential,
+	// e.g. the PEM certificate, the base32 TOTP secret or the EC2 JSON document.
+	// Changes of the blob get applied to the credential.
+	BlobSecretRef corev1.SecretKeySelector `json:"blobSecretRef"`
+}
+
+// KeystoneCredentialStatus defines the observed state of KeystoneCredential
+type KeystoneCredentialStatus struct {
+	// CredentialID - ID of the credential in keystone
+	CredentialID string `json:"credentialID,omitempty"`
+	// UserID - ID of the user the credential belongs to
+	UserID string `json:"userID,omitempty"`
+	// ProjectID - ID of the project the credential is scoped to
+	ProjectID string `json:"projectID,omitempty"`
+	// BlobHash - hash of the blob the credential got created or updated with
+	BlobHash string `json:"blobHash,omitempty"`
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	//ObservedGeneration - the most recent generation observed for this credential. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime - time of the last reconcile. While the result of the
+	// reconcile and the applied spec do not change it gets updated at most once
+	// a minute.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastSuccessfulReconcile - time of the last reconcile which finished
+	// without an error and left the instance ready
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// AppliedSpecHash - hash of the spec applied by the last successful reconcile
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type",description="Type"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneCredential is the Schema for the keystonecredentials API
+type KeystoneCredential struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneCredentialSpec   `json:"spec,omitempty"`
+	Status KeystoneCredentialStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneCredentialList contains a list of KeystoneCredential
+type KeystoneCredentialList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneCredential `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneCredential{}, &KeystoneCredentialList{})
+}
+
+// IsReady - returns true if KeystoneCredential is reconciled successfully for
+// the current generation of the spec
+func (instance KeystoneCredential) IsReady() bool {
+	return instance.Generation == instance.Status.ObservedGeneration &&
+		instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// EC2CredentialAccessKey - key of the access in the Secret of a KeystoneEC2Credential
+	EC2CredentialAccessKey = "access"
+	// EC2CredentialSecretKey - key of the secret in the Secret of a KeystoneEC2Credential
+	EC2CredentialSecretKey = "secret"
+)
+
+// KeystoneEC2CredentialSpec defines the desired state of KeystoneEC2Credential
+type KeystoneEC2CredentialSpec struct {
+	// +kubebuilder:validation:Required
+	// UserName - Name of the user the credential gets created for
+	UserName string `json:"userName"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// UserDomain - Name of the domain of the user
+	UserDomain string `json:"userDomain,omitempty"`
+	// +kubebuilder:validation:Required
+	// ProjectName - Name of the project the credential is scoped to
+	ProjectName string `json:"projectName"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// ProjectDomain - Name of the domain of the project
+	ProjectDomain string `json:"projectDomain,omitempty"`
+	// +kubebuilder:validation:Optional
+	// SecretName - Name of the Secret the access and secret of the credential
+	// get stored in. Defaults to the name of the KeystoneEC2Credential.
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// KeystoneEC2CredentialStatus defines the observed state of KeystoneEC2Credential
+type KeystoneEC2CredentialStatus struct {
+	// AccessKey - access of the credential, which is its ID in keystone
+	AccessKey string `json:"accessKey,omitempty"`
+	// UserID - ID of the user the credential belongs to
+	UserID string `json:"userID,omitempty"`
+	// ProjectID - ID of the project the credential is scoped to
+	ProjectID string `json:"projectID,omitempty"`
+	// SecretName - Name of the Secret holding the access and secret
+	SecretName string `json:"secretName,omitempty"`
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	//ObservedGeneration - the most recent generation observed for this credential. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime - time of the last reconcile. While the result of the
+	// reconcile and the applied spec do not change it gets updated at most once
+	// a minute.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastSuccessfulReconcile - time of the last reconcile which finished
+	// without an error and left the instance ready
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// AppliedSpecHash - hash of the spec applied by the last successful reconcile
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneEC2Credential is the Schema for the keystoneec2credentials API
+type KeystoneEC2Credential struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneEC2CredentialSpec   `json:"spec,omitempty"`
+	Status KeystoneEC2CredentialStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneEC2CredentialList contains a list of KeystoneEC2Credential
+type KeystoneEC2CredentialList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneEC2Credential `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneEC2Credential{}, &KeystoneEC2CredentialList{})
+}
+
+// IsReady - returns true if KeystoneEC2Credential is reconciled successfully for
+// the current generation of the spec
+func (instance KeystoneEC2Credential) IsReady() bool {
+	return instance.Generation == instance.Status.ObservedGeneration &&
+		instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
+
+// GetSecretName - returns the name of the Secret holding the credential
+func (instance KeystoneEC2Credential) GetSecretName() string {
+	if instance.Spec.SecretName != "" {
+		return instance.Spec.SecretName
+	}
+	return instance.Name
+}
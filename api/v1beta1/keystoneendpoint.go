@@ -18,13 +18,16 @@ package v1beta1
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"reflect"
+	"regexp"
 	"sort"
 	"time"
 
 	"github.com/openstack-k8s-operators/lib-common/modules/common"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -401,3 +404,148 @@ func getEndpointForVisibility(
 
 	return Endpoint{}, false
 }
+
+// endpointURLVariableRegex matches the {name} variables which can be used in
+// the endpoint URLs
+var endpointURLVariableRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// keystoneSubstitutionRegex matches the %(name)s substitutions keystone
+// renders in the catalog
+var keystoneSubstitutionRegex = regexp.MustCompile(`%\([a-z_]+\)s`)
+
+// RenderEndpointURL - replaces the {name} variables in an endpoint URL with the
+// values from vars and validates the result to be an absolute http(s) URL.
+// Keystone substitutions like %(tenant_id)s are kept as they are.
+func RenderEndpointURL(
+	endpointURL string,
+	vars map[string]string,
+) (string, error) {
+	var missing []string
+	rendered := endpointURLVariableRegex.ReplaceAllStringFunc(endpointURL, func(m string) string {
+		name := m[1 : len(m)-1]
+		val, ok := vars[name]
+		if !ok {
+			missing = append(missing, name)
+			return m
+		}
+		return val
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("endpoint url %s references unknown variables %v", endpointURL, missing)
+	}
+
+	// keystone substitutions are not valid in an URL, replace them with a
+	// placeholder for the validation only
+	u, err := url.Parse(keystoneSubstitutionRegex.ReplaceAllString(rendered, "x"))
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint url %s: %w", rendered, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid endpoint url %s: must be an absolute http or https url", rendered)
+	}
+
+	return rendered, nil
+}
+
+// GetEndpointURLVariables - returns the variables available to render the
+// endpoint URLs of the KeystoneEndpoint in the given region
+func (instance KeystoneEndpoint) GetEndpointURLVariables(region string) map[string]string {
+	vars := map[string]string{
+		"namespace":   instance.Namespace,
+		"region":      region,
+		"serviceName": instance.Spec.ServiceName,
+	}
+	for k, v := range instance.Spec.URLVariables {
+		vars[k] = v
+	}
+
+	return vars
+}
+
+// ValidateEndpoints - validates the endpoint types of the spec and of the
+// regional endpoint sets, which need unique regions. Only public,
+// internal and, if enabled via EnableAdminEndpoint, admin are allowed.
+func (instance KeystoneEndpoint) ValidateEndpoints() error {
+	err := instance.validateEndpointTypes(instance.Spec.Endpoints)
+	if err != nil {
+		return err
+	}
+
+	regions := map[string]bool{}
+	for _, set := range instance.Spec.RegionalEndpoints {
+		if set.Region == "" {
+			return fmt.Errorf("regional endpoints require a region")
+		}
+		if regions[set.Region] {
+			return fmt.Errorf("regional endpoints of region %s defined more than once", set.Region)
+		}
+		regions[set.Region] = true
+
+		err := instance.validateEndpointTypes(set.Endpoints)
+		if err != nil {
+			return fmt.Errorf("region %s: %w", set.Region, err)
+		}
+	}
+
+	return nil
+}
+
+// validateEndpointTypes - validates the endpoint types of an endpoint map
+func (instance KeystoneEndpoint) validateEndpointTypes(endpoints map[string]string) error {
+	for endpointType := range endpoints {
+		switch endpointType {
+		case string(service.EndpointPublic), string(service.EndpointInternal):
+		case string(service.EndpointAdmin):
+			if !instance.Spec.EnableAdminEndpoint {
+				return fmt.Errorf("%s endpoint requires enableAdminEndpoint to be set", endpointType)
+			}
+		default:
+			return fmt.Errorf("endpoint type %s not supported, must be one of %s, %s or %s",
+				endpointType, service.EndpointPublic, service.EndpointInternal, service.EndpointAdmin)
+		}
+	}
+
+	return nil
+}
+
+// RenderEndpoints - returns the endpoint URLs of the spec with all variables
+// rendered, indexed by the endpoint type
+func (instance KeystoneEndpoint) RenderEndpoints(region string) (map[string]string, error) {
+	vars := instance.GetEndpointURLVariables(region)
+	endpoints := make(map[string]string, len(instance.Spec.Endpoints))
+	for endpointType, endpointURL := range instance.Spec.Endpoints {
+		rendered, err := RenderEndpointURL(endpointURL, vars)
+		if err != nil {
+			return nil, fmt.Errorf("%s endpoint: %w", endpointType, err)
+		}
+		endpoints[endpointType] = rendered
+	}
+
+	return endpoints, nil
+}
+
+// RenderRegionalEndpoints - returns the endpoint URLs of the regional endpoint
+// sets with all variables rendered, indexed by the region and the endpoint
+// type. A set for the region of the KeystoneAPI is rejected, its endpoints
+// belong into Spec.Endpoints.
+func (instance KeystoneEndpoint) RenderRegionalEndpoints(region string) (map[string]map[string]string, error) {
+	regional := make(map[string]map[string]string, len(instance.Spec.RegionalEndpoints))
+	for _, set := range instance.Spec.RegionalEndpoints {
+		if set.Region == region {
+			return nil, fmt.Errorf("regional endpoints of region %s conflict with the endpoints of the KeystoneAPI region", set.Region)
+		}
+
+		vars := instance.GetEndpointURLVariables(set.Region)
+		endpoints := make(map[string]string, len(set.Endpoints))
+		for endpointType, endpointURL := range set.Endpoints {
+			rendered, err := RenderEndpointURL(endpointURL, vars)
+			if err != nil {
+				return nil, fmt.Errorf("region %s %s endpoint: %w", set.Region, endpointType, err)
+			}
+			endpoints[endpointType] = rendered
+		}
+		regional[set.Region] = endpoints
+	}
+
+	return regional, nil
+}
@@ -310,3 +310,169 @@ func TestFilterVisibility(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderEndpointURL(t *testing.T) {
+	vars := map[string]string{
+		"namespace":   "openstack",
+		"region":      "regionOne",
+		"serviceName": "cinderv3",
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "No variables",
+			url:  "http://cinder-public.openstack.svc:8776/v3",
+			want: "http://cinder-public.openstack.svc:8776/v3",
+		},
+		{
+			name: "With variables",
+			url:  "https://{serviceName}-public.{namespace}.svc/{region}",
+			want: "https://cinderv3-public.openstack.svc/regionOne",
+		},
+		{
+			name: "Keystone substitution passthrough",
+			url:  "http://cinder-public.{namespace}.svc:8776/v3/%(tenant_id)s",
+			want: "http://cinder-public.openstack.svc:8776/v3/%(tenant_id)s",
+		},
+		{
+			name:    "Unknown variable",
+			url:     "http://{zone}.openstack.svc:8776/v3",
+			wantErr: true,
+		},
+		{
+			name:    "Not an absolute URL",
+			url:     "{namespace}.svc:8776/v3",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rendered, err := RenderEndpointURL(tt.url, vars)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(rendered).To(Equal(tt.want))
+			}
+		})
+	}
+}
+
+func TestValidateEndpoints(t *testing.T) {
+
+	tests := []struct {
+		name                string
+		endpoints           map[string]string
+		regionalEndpoints   []RegionalEndpointSet
+		enableAdminEndpoint bool
+		wantErr             bool
+	}{
+		{
+			name: "public and internal",
+			endpoints: map[string]string{
+				"public":   "https://cinder-public.openstack.svc/v3",
+				"internal": "http://cinder-internal.openstack.svc/v3",
+			},
+		},
+		{
+			name: "admin not enabled",
+			endpoints: map[string]string{
+				"admin": "http://cinder-admin.openstack.svc/v3",
+			},
+			wantErr: true,
+		},
+		{
+			name: "admin enabled",
+			endpoints: map[string]string{
+				"admin": "http://cinder-admin.openstack.svc/v3",
+			},
+			enableAdminEndpoint: true,
+		},
+		{
+			name: "unknown endpoint type",
+			endpoints: map[string]string{
+				"private": "http://cinder-private.openstack.svc/v3",
+			},
+			wantErr: true,
+		},
+		{
+			name: "regional endpoints",
+			endpoints: map[string]string{
+				"public": "https://cinder-public.openstack.svc/v3",
+			},
+			regionalEndpoints: []RegionalEndpointSet{
+				{Region: "dcn1", Endpoints: map[string]string{"internal": "http://cinder.dcn1.svc/v3"}},
+				{Region: "dcn2", Endpoints: map[string]string{"internal": "http://cinder.dcn2.svc/v3"}},
+			},
+		},
+		{
+			name: "regional endpoints with duplicate region",
+			regionalEndpoints: []RegionalEndpointSet{
+				{Region: "dcn1", Endpoints: map[string]string{"internal": "http://cinder.dcn1.svc/v3"}},
+				{Region: "dcn1", Endpoints: map[string]string{"public": "http://cinder.dcn1.svc/v3"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "regional admin endpoint not enabled",
+			regionalEndpoints: []RegionalEndpointSet{
+				{Region: "dcn1", Endpoints: map[string]string{"admin": "http://cinder.dcn1.svc/v3"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			endpt := KeystoneEndpoint{
+				Spec: KeystoneEndpointSpec{
+					Endpoints:           tt.endpoints,
+					RegionalEndpoints:   tt.regionalEndpoints,
+					EnableAdminEndpoint: tt.enableAdminEndpoint,
+				},
+			}
+			err := endpt.ValidateEndpoints()
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestRenderRegionalEndpoints(t *testing.T) {
+	g := NewWithT(t)
+
+	endpt := KeystoneEndpoint{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openstack",
+		},
+		Spec: KeystoneEndpointSpec{
+			ServiceName: "cinder",
+			RegionalEndpoints: []RegionalEndpointSet{
+				{Region: "dcn1", Endpoints: map[string]string{"internal": "http://{serviceName}.{region}.{namespace}.svc/v3"}},
+			},
+		},
+	}
+
+	regional, err := endpt.RenderRegionalEndpoints("regionOne")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(regional).To(Equal(map[string]map[string]string{
+		"dcn1": {"internal": "http://cinder.dcn1.openstack.svc/v3"},
+	}))
+
+	// the endpoints of the KeystoneAPI region belong into Spec.Endpoints
+	_, err = endpt.RenderRegionalEndpoints("dcn1")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -21,28 +21,151 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// RevertEndpointsAnnotation - annotation on the KeystoneEndpoint, setting
+	// it or changing its value restores the previous URL of the endpoints
+	// whose URL changed
+	RevertEndpointsAnnotation = "keystone.openstack.org/revert-endpoints"
+
+	// PruneRegionsAnnotation - annotation on the KeystoneEndpoint with a comma
+	// separated list of regions whose endpoints may get deleted after their
+	// set got removed from Spec.RegionalEndpoints
+	PruneRegionsAnnotation = "keystone.openstack.org/prune-regions"
+)
+
 // KeystoneEndpointSpec defines the desired state of KeystoneEndpoint
 type KeystoneEndpointSpec struct {
 	// +kubebuilder:validation:Required
 	// ServiceName - Name of the service to create the endpoint for
 	ServiceName string `json:"serviceName"`
 	// +kubebuilder:validation:Required
-	// Endpoints - map with service api endpoint URLs with the endpoint type as index
+	// Endpoints - map with service api endpoint URLs with the endpoint type as index.
+	// The URLs can reference variables in the form {name} which get rendered by
+	// the operator. Builtin variables are {namespace}, {region} and {serviceName},
+	// additional ones can be provided via URLVariables. Keystone substitutions
+	// like %(tenant_id)s are passed through unchanged.
 	Endpoints map[string]string `json:"endpoints"`
+	// +kubebuilder:validation:Optional
+	// URLVariables - additional variables which can be referenced in the
+	// endpoint URLs. They take precedence over the builtin variables.
+	URLVariables map[string]string `json:"urlVariables,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// EnableAdminEndpoint - opt-in to register an admin interface endpoint.
+	// An admin entry in Endpoints is rejected unless this is set. A registered
+	// admin endpoint gets deleted when it is removed from Endpoints.
+	EnableAdminEndpoint bool `json:"enableAdminEndpoint,omitempty"`
+	// +kubebuilder:validation:Optional
+	// ReadinessGate - optional check which needs to pass before the endpoints
+	// get registered in the keystone catalog. Use it to avoid publishing
+	// catalog entries for an API which does not yet respond.
+	ReadinessGate *KeystoneEndpointReadinessGate `json:"readinessGate,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// VerifyURLChanges - probe the new URL of a registered endpoint with a GET
+	// request before it replaces the current URL in the catalog. If the new
+	// URL does not respond, returns a status code of 500 or above or its TLS
+	// certificate is not valid, the current URL is kept and the
+	// KeystoneEndpointURLPreflightReady condition reports the failure.
+	VerifyURLChanges bool `json:"verifyURLChanges,omitempty"`
+	// +kubebuilder:validation:Optional
+	// URLRollback - probe the new URL of an endpoint after it replaced the
+	// previous URL in the catalog and restore the previous URL if a probe
+	// fails within the rollback window
+	URLRollback *KeystoneEndpointURLRollback `json:"urlRollback,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=region
+	// RegionalEndpoints - additional endpoint sets registered in other regions
+	// than the one of the KeystoneAPI, e.g. for distributed compute node (edge)
+	// sites whose internal URLs differ per availability zone. The {region}
+	// variable renders the region of the set. Missing regions get created in
+	// keystone. The endpoints of a removed set only get deleted once the
+	// region is listed in the PruneRegionsAnnotation.
+	RegionalEndpoints []RegionalEndpointSet `json:"regionalEndpoints,omitempty"`
+}
+
+// RegionalEndpointSet defines the endpoints of the service in one region
+type RegionalEndpointSet struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// Region - keystone region the endpoints get registered in
+	Region string `json:"region"`
+	// +kubebuilder:validation:Required
+	// Endpoints - map with the endpoint URLs of the region with the endpoint
+	// type as index. The same rules as for Spec.Endpoints apply.
+	Endpoints map[string]string `json:"endpoints"`
+}
+
+// KeystoneEndpointURLRollback defines the automatic rollback of endpoint URL
+// changes
+type KeystoneEndpointURLRollback struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=300
+	// +kubebuilder:validation:Minimum=30
+	// WindowSeconds - time after a URL change the new URL gets probed in
+	WindowSeconds int32 `json:"windowSeconds"`
+}
+
+// KeystoneEndpointReadinessGate defines the checks which need to pass before
+// the endpoints get registered. All configured checks need to pass.
+type KeystoneEndpointReadinessGate struct {
+	// +kubebuilder:validation:Optional
+	// DeploymentName - name of a Deployment in the same namespace which needs
+	// to have at least one ready replica
+	DeploymentName string `json:"deploymentName,omitempty"`
+	// +kubebuilder:validation:Optional
+	// ServiceName - name of a Service in the same namespace which needs to have
+	// at least one ready endpoint address
+	ServiceName string `json:"serviceName,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// URL - http(s) URL which gets probed with a GET request. Any response
+	// with a status code below 500 is considered ready. The certificate of an
+	// https URL gets verified with the CA bundle of the KeystoneAPI.
+	URL string `json:"url,omitempty"`
 }
 
 // KeystoneEndpointStatus defines the observed state of KeystoneEndpoint
 type KeystoneEndpointStatus struct {
 	EndpointIDs map[string]string `json:"endpointIDs,omitempty"`
 	ServiceID   string            `json:"serviceID,omitempty"`
+	// ServiceName - name of the KeystoneService the endpoints are registered
+	// for. When Spec.ServiceName changes the endpoints get moved to the new
+	// service.
+	ServiceName string `json:"serviceName,omitempty"`
 	// Conditions
 	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
 
 	// Endpoints - current status of latest configured endpoints for the service
 	Endpoints []Endpoint `json:"endpoints,omitempty"`
 
+	// RegionalEndpoints - endpoints registered for Spec.RegionalEndpoints,
+	// including the ones of removed regions pending the prune confirmation
+	RegionalEndpoints []Endpoint `json:"regionalEndpoints,omitempty"`
+
+	// PendingPruneRegions - regions removed from Spec.RegionalEndpoints whose
+	// endpoints wait for the confirmation via the PruneRegionsAnnotation
+	PendingPruneRegions []string `json:"pendingPruneRegions,omitempty"`
+
 	//ObservedGeneration - the most recent generation observed for this service. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime - time of the last reconcile. While the result of the
+	// reconcile and the applied spec do not change it gets updated at most once
+	// a minute.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastSuccessfulReconcile - time of the last reconcile which finished
+	// without an error and left the instance ready
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// AppliedSpecHash - hash of the spec applied by the last successful reconcile
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+
+	// LastRevertRequest - value of the revert annotation the endpoint URLs got
+	// reverted for last
+	LastRevertRequest string `json:"lastRevertRequest,omitempty"`
 }
 
 // Endpoint -
@@ -53,6 +176,22 @@ type Endpoint struct {
 	URL string `json:"url"`
 	// ID - endpoint id
 	ID string `json:"id"`
+	// Region - region the endpoint is registered in
+	Region string `json:"region,omitempty"`
+	// Enabled - whether the endpoint is enabled in keystone
+	Enabled *bool `json:"enabled,omitempty"`
+	// LastSyncedURL - URL registered in keystone at the last sync
+	LastSyncedURL string `json:"lastSyncedURL,omitempty"`
+	// LastSyncTime - time the endpoint was last synced with keystone
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// PreviousURL - URL the endpoint was registered with before the last URL
+	// change, restored by a rollback
+	PreviousURL string `json:"previousURL,omitempty"`
+	// URLChangeTime - time of the last URL change or rollback
+	URLChangeTime *metav1.Time `json:"urlChangeTime,omitempty"`
+	// RolledBackURL - URL which got rolled back. It does not get registered
+	// again while the spec requests it.
+	RolledBackURL string `json:"rolledBackURL,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -82,7 +221,9 @@ func init() {
 	SchemeBuilder.Register(&KeystoneEndpoint{}, &KeystoneEndpointList{})
 }
 
-// IsReady - returns true if KeystoneEndpoint is reconciled successfully
+// IsReady - returns true if KeystoneEndpoint is reconciled successfully for
+// the current generation of the spec
 func (instance KeystoneEndpoint) IsReady() bool {
-	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+	return instance.Generation == instance.Status.ObservedGeneration &&
+		instance.Status.Conditions.IsTrue(condition.ReadyCondition)
 }
@@ -0,0 +1,128 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeystoneEndpointGroupSpec defines the desired state of KeystoneEndpointGroup
+type KeystoneEndpointGroupSpec struct {
+	// +kubebuilder:validation:Optional
+	// EndpointGroupName - Name of the endpoint group in keystone. Defaults to
+	// the name of the KeystoneEndpointGroup.
+	EndpointGroupName string `json:"endpointGroupName,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Description - Description for the endpoint group.
+	Description string `json:"description,omitempty"`
+	// +kubebuilder:validation:Required
+	// Filters - Criteria the endpoints of the group get selected by
+	Filters KeystoneEndpointGroupFilters `json:"filters"`
+	// +kubebuilder:validation:Optional
+	// Projects - Names of the projects the endpoint group gets associated
+	// with. With endpoint filtering, a project scoped token only lists the
+	// endpoints of the endpoint groups associated with the project.
+	Projects []string `json:"projects,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// ProjectDomain - Name of the domain of the Projects.
+	ProjectDomain string `json:"projectDomain,omitempty"`
+}
+
+// KeystoneEndpointGroupFilters defines the criteria the endpoints of an
+// endpoint group get selected by. Empty fields do not filter.
+type KeystoneEndpointGroupFilters struct {
+	// +kubebuilder:validation:Optional
+	// ServiceName - Name of the KeystoneService whose endpoints get selected
+	ServiceName string `json:"serviceName,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=public;internal;admin
+	// Interface - Interface of the endpoints which get selected
+	Interface string `json:"interface,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Region - Region of the endpoints which get selected
+	Region string `json:"region,omitempty"`
+}
+
+// KeystoneEndpointGroupStatus defines the observed state of KeystoneEndpointGroup
+type KeystoneEndpointGroupStatus struct {
+	// EndpointGroupID - ID of the endpoint group in keystone
+	EndpointGroupID string `json:"endpointGroupID,omitempty"`
+	// ProjectIDs - IDs of the projects the endpoint group is associated with,
+	// indexed by the project name
+	ProjectIDs map[string]string `json:"projectIDs,omitempty"`
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	//ObservedGeneration - the most recent generation observed for this endpoint group. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime - time of the last reconcile. While the result of the
+	// reconcile and the applied spec do not change it gets updated at most once
+	// a minute.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastSuccessfulReconcile - time of the last reconcile which finished
+	// without an error and left the instance ready
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// AppliedSpecHash - hash of the spec applied by the last successful reconcile
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneEndpointGroup is the Schema for the keystoneendpointgroups API
+type KeystoneEndpointGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneEndpointGroupSpec   `json:"spec,omitempty"`
+	Status KeystoneEndpointGroupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneEndpointGroupList contains a list of KeystoneEndpointGroup
+type KeystoneEndpointGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneEndpointGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneEndpointGroup{}, &KeystoneEndpointGroupList{})
+}
+
+// IsReady - returns true if KeystoneEndpointGroup is reconciled successfully for
+// the current generation of the spec
+func (instance KeystoneEndpointGroup) IsReady() bool {
+	return instance.Generation == instance.Status.ObservedGeneration &&
+		instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
+
+// GetEndpointGroupName - returns the name of the endpoint group in keystone
+func (instance KeystoneEndpointGroup) GetEndpointGroupName() string {
+	if instance.Spec.EndpointGroupName != "" {
+		return instance.Spec.EndpointGroupName
+	}
+	return instance.Name
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/gophercloud/gophercloud"
+)
+
+// keystoneFaultMaxLength - maximum length of the keystone fault message
+// included in a condition message
+const keystoneFaultMaxLength = 256
+
+// keystoneFault - error body returned by keystone
+type keystoneFault struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Title   string `json:"title"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// KeystoneErrorMessage - returns the message of an error to show in a
+// condition. For an unexpected response of the keystone API the fault title
+// and message keystone returned get included instead of the raw response
+// dump, e.g. "keystone POST /v3/projects failed with 409 Conflict: Duplicate
+// entry". Secrets get redacted from the message.
+func KeystoneErrorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	// gophercloud does not unwrap the error of a request it retried after
+	// authenticating again
+	var reauthErr *gophercloud.ErrErrorAfterReauthentication
+	if errors.As(err, &reauthErr) && reauthErr.ErrOriginal != nil {
+		err = reauthErr.ErrOriginal
+	}
+
+	var respErr gophercloud.ErrUnexpectedResponseCode
+	if !errors.As(err, &respErr) {
+		return RedactSecrets(err.Error())
+	}
+
+	// only the path, the host is the known keystone endpoint and the query
+	// might hold names
+	path := respErr.URL
+	if u, parseErr := url.Parse(respErr.URL); parseErr == nil {
+		path = u.Path
+	}
+
+	fault := keystoneFault{}
+	title := http.StatusText(respErr.Actual)
+	message := ""
+	if jsonErr := json.Unmarshal(respErr.Body, &fault); jsonErr == nil {
+		if fault.Error.Title != "" {
+			title = fault.Error.Title
+		}
+		message = fault.Error.Message
+	}
+
+	msg := fmt.Sprintf("keystone %s %s failed with %d %s", respErr.Method, path, respErr.Actual, sanitizeFault(title))
+	if message = sanitizeFault(message); message != "" {
+		msg += ": " + message
+	}
+
+	return RedactSecrets(msg)
+}
+
+// sanitizeFault - replaces control characters and collapses whitespace of a
+// fault message returned by keystone and truncates it
+func sanitizeFault(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, s)
+	s = strings.Join(strings.Fields(s), " ")
+
+	if r := []rune(s); len(r) > keystoneFaultMaxLength {
+		s = string(r[:keystoneFaultMaxLength]) + "..."
+	}
+
+	return s
+}
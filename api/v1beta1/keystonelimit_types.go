@@ -0,0 +1,112 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeystoneLimitSpec defines the desired state of KeystoneLimit
+type KeystoneLimitSpec struct {
+	// +kubebuilder:validation:Required
+	// ServiceName - Name of the KeystoneService the limit is for
+	ServiceName string `json:"serviceName"`
+	// +kubebuilder:validation:Required
+	// ResourceName - Name of the resource the limit applies to, e.g. cores.
+	// A KeystoneRegisteredLimit for the resource needs to exist.
+	ResourceName string `json:"resourceName"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=-1
+	// ResourceLimit - Limit which overrides the default of the registered
+	// limit. -1 means unlimited.
+	ResourceLimit int `json:"resourceLimit"`
+	// +kubebuilder:validation:Optional
+	// Description - Description for the limit.
+	Description string `json:"description,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Region - Region the limit applies to. If not set the limit applies to
+	// all regions.
+	Region string `json:"region,omitempty"`
+	// +kubebuilder:validation:Optional
+	// ProjectName - Name of the project the limit applies to. If not set the
+	// limit applies to the Domain, which requires the strict_two_level
+	// enforcement model.
+	ProjectName string `json:"projectName,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// Domain - Name of the domain of the project, or the domain the limit
+	// applies to if no ProjectName is set.
+	Domain string `json:"domain,omitempty"`
+}
+
+// KeystoneLimitStatus defines the observed state of KeystoneLimit
+type KeystoneLimitStatus struct {
+	// LimitID - ID of the limit in keystone
+	LimitID string `json:"limitID,omitempty"`
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	//ObservedGeneration - the most recent generation observed for this limit. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime - time of the last reconcile. While the result of the
+	// reconcile and the applied spec do not change it gets updated at most once
+	// a minute.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastSuccessfulReconcile - time of the last reconcile which finished
+	// without an error and left the instance ready
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// AppliedSpecHash - hash of the spec applied by the last successful reconcile
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneLimit is the Schema for the keystonelimits API
+type KeystoneLimit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneLimitSpec   `json:"spec,omitempty"`
+	Status KeystoneLimitStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneLimitList contains a list of KeystoneLimit
+type KeystoneLimitList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneLimit `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneLimit{}, &KeystoneLimitList{})
+}
+
+// IsReady - returns true if KeystoneLimit is reconciled successfully for
+// the current generation of the spec
+func (instance KeystoneLimit) IsReady() bool {
+	return instance.Generation == instance.Status.ObservedGeneration &&
+		instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
@@ -0,0 +1,167 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// mappingRule - rule of a keystone mapping, the local entities the users
+// matching the remote conditions get mapped to
+type mappingRule struct {
+	Local  []mappingLocal  `json:"local"`
+	Remote []mappingRemote `json:"remote"`
+}
+
+// mappingLocal - local entity of a mapping rule
+type mappingLocal struct {
+	User     *mappingLocalUser     `json:"user,omitempty"`
+	Group    *mappingLocalGroup    `json:"group,omitempty"`
+	Projects []mappingLocalProject `json:"projects,omitempty"`
+}
+
+// mappingLocalUser - federated user of a mapping rule, {N} gets replaced with
+// the value of the Nth remote condition
+type mappingLocalUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+// mappingLocalGroup - group of a mapping rule, referenced by name and domain
+type mappingLocalGroup struct {
+	Name   string             `json:"name"`
+	Domain mappingLocalDomain `json:"domain"`
+}
+
+// mappingLocalDomain - domain of a group of a mapping rule
+type mappingLocalDomain struct {
+	Name string `json:"name"`
+}
+
+// mappingLocalProject - project of a mapping rule keystone creates on the
+// first login of a user
+type mappingLocalProject struct {
+	Name  string             `json:"name"`
+	Roles []mappingLocalRole `json:"roles"`
+}
+
+// mappingLocalRole - role of a mapping rule
+type mappingLocalRole struct {
+	Name string `json:"name"`
+}
+
+// mappingRemote - remote condition of a mapping rule, the attribute has to be
+// set in the assertion and hold one of AnyOneOf if set
+type mappingRemote struct {
+	Type     string   `json:"type"`
+	AnyOneOf []string `json:"any_one_of,omitempty"`
+	Regex    bool     `json:"regex,omitempty"`
+}
+
+// GetRules - returns the mapping rules as keystone JSON, the Rules followed by
+// the rules rendered from ClaimMappings and AutoProvisioning
+func (spec KeystoneMappingSpec) GetRules() (json.RawMessage, error) {
+	rules := []interface{}{}
+	if spec.Rules != "" {
+		raw := []json.RawMessage{}
+		if err := json.Unmarshal([]byte(spec.Rules), &raw); err != nil {
+			return nil, fmt.Errorf("rules must be a JSON list of mapping rules: %w", err)
+		}
+		for _, rule := range raw {
+			rules = append(rules, rule)
+		}
+	}
+	for i, claim := range spec.ClaimMappings {
+		if len(claim.Groups) == 0 && len(claim.Projects) == 0 {
+			return nil, fmt.Errorf("claimMappings[%d] of claim %s has neither groups nor projects", i, claim.Claim)
+		}
+		rules = append(rules, claim.rule())
+	}
+	if spec.AutoProvisioning != nil {
+		rules = append(rules, spec.AutoProvisioning.rule())
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("neither rules, claimMappings nor autoProvisioning set")
+	}
+
+	return json.Marshal(rules)
+}
+
+// rule - returns the mapping rule mapping the users with the claim to the
+// groups and project roles. It does not name the user, keystone takes the
+// name of the user from the other rules or REMOTE_USER.
+func (c MappingClaim) rule() mappingRule {
+	local := []mappingLocal{}
+	for _, group := range c.Groups {
+		domain := group.Domain
+		if domain == "" {
+			domain = "Default"
+		}
+		local = append(local, mappingLocal{Group: &mappingLocalGroup{
+			Name:   group.Name,
+			Domain: mappingLocalDomain{Name: domain},
+		}})
+	}
+	if len(c.Projects) > 0 {
+		local = append(local, mappingLocal{Projects: mappingProjects(c.Projects)})
+	}
+
+	remote := mappingRemote{Type: c.Claim}
+	if len(c.Values) > 0 {
+		remote.AnyOneOf = c.Values
+		remote.Regex = c.Regex
+	}
+
+	return mappingRule{
+		Local:  local,
+		Remote: []mappingRemote{remote},
+	}
+}
+
+// mappingProjects - returns the projects of a mapping rule with their roles
+func mappingProjects(projects []MappingProject) []mappingLocalProject {
+	local := []mappingLocalProject{}
+	for _, project := range projects {
+		roles := []mappingLocalRole{}
+		for _, role := range project.Roles {
+			roles = append(roles, mappingLocalRole{Name: role})
+		}
+		local = append(local, mappingLocalProject{Name: project.Name, Roles: roles})
+	}
+
+	return local
+}
+
+// rule - returns the mapping rule mapping the users to the projects and roles
+func (p MappingAutoProvisioning) rule() mappingRule {
+	userNameAttribute := p.UserNameAttribute
+	if userNameAttribute == "" {
+		userNameAttribute = MappingDefaultUserNameAttribute
+	}
+	user := &mappingLocalUser{Name: "{0}"}
+	remote := []mappingRemote{{Type: userNameAttribute}}
+	if p.UserEmailAttribute != "" {
+		user.Email = "{1}"
+		remote = append(remote, mappingRemote{Type: p.UserEmailAttribute})
+	}
+
+	return mappingRule{
+		Local:  []mappingLocal{{User: user, Projects: mappingProjects(p.Projects)}},
+		Remote: remote,
+	}
+}
@@ -0,0 +1,140 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestKeystoneMappingGetRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      KeystoneMappingSpec
+		wantRules string
+		wantErr   bool
+	}{
+		{
+			name:    "no rules",
+			spec:    KeystoneMappingSpec{},
+			wantErr: true,
+		},
+		{
+			name:    "invalid rules",
+			spec:    KeystoneMappingSpec{Rules: `{"local": []}`},
+			wantErr: true,
+		},
+		{
+			name:      "raw rules",
+			spec:      KeystoneMappingSpec{Rules: `[{"local": [{"user": {"name": "{0}"}}], "remote": [{"type": "REMOTE_USER"}]}]`},
+			wantRules: `[{"local":[{"user":{"name":"{0}"}}],"remote":[{"type":"REMOTE_USER"}]}]`,
+		},
+		{
+			name: "auto provisioning",
+			spec: KeystoneMappingSpec{
+				AutoProvisioning: &MappingAutoProvisioning{
+					Projects: []MappingProject{
+						{Name: "demo", Roles: []string{"reader"}},
+						{Name: "{0}-sandbox", Roles: []string{"member", "load-balancer_member"}},
+					},
+				},
+			},
+			wantRules: `[{"local":[{"user":{"name":"{0}"},"projects":[` +
+				`{"name":"demo","roles":[{"name":"reader"}]},` +
+				`{"name":"{0}-sandbox","roles":[{"name":"member"},{"name":"load-balancer_member"}]}]}],` +
+				`"remote":[{"type":"REMOTE_USER"}]}]`,
+		},
+		{
+			name: "raw rules before the auto provisioning with email",
+			spec: KeystoneMappingSpec{
+				Rules: `[{"local": [{"group": {"id": "0cd5e9"}}], "remote": [{"type": "OIDC-groups", "any_one_of": ["admins"]}]}]`,
+				AutoProvisioning: &MappingAutoProvisioning{
+					UserNameAttribute:  "OIDC-preferred_username",
+					UserEmailAttribute: "OIDC-email",
+					Projects:           []MappingProject{{Name: "demo", Roles: []string{"member"}}},
+				},
+			},
+			wantRules: `[{"local":[{"group":{"id":"0cd5e9"}}],"remote":[{"type":"OIDC-groups","any_one_of":["admins"]}]},` +
+				`{"local":[{"user":{"name":"{0}","email":"{1}"},"projects":[{"name":"demo","roles":[{"name":"member"}]}]}],` +
+				`"remote":[{"type":"OIDC-preferred_username"},{"type":"OIDC-email"}]}]`,
+		},
+		{
+			name: "claim mapping without groups and projects",
+			spec: KeystoneMappingSpec{
+				ClaimMappings: []MappingClaim{{Claim: "OIDC-groups", Values: []string{"admins"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "claim mappings",
+			spec: KeystoneMappingSpec{
+				ClaimMappings: []MappingClaim{
+					{
+						Claim:  "OIDC-groups",
+						Values: []string{"admins", "operators"},
+						Groups: []MappingGroup{{Name: "cloud-admins"}, {Name: "ops", Domain: "corp"}},
+					},
+					{
+						Claim:    "OIDC-department",
+						Values:   []string{"^dev-.*$"},
+						Regex:    true,
+						Projects: []MappingProject{{Name: "dev", Roles: []string{"member"}}},
+					},
+					{
+						Claim:  "OIDC-email",
+						Groups: []MappingGroup{{Name: "federated", Domain: "Default"}},
+					},
+				},
+			},
+			wantRules: `[{"local":[{"group":{"name":"cloud-admins","domain":{"name":"Default"}}},` +
+				`{"group":{"name":"ops","domain":{"name":"corp"}}}],` +
+				`"remote":[{"type":"OIDC-groups","any_one_of":["admins","operators"]}]},` +
+				`{"local":[{"projects":[{"name":"dev","roles":[{"name":"member"}]}]}],` +
+				`"remote":[{"type":"OIDC-department","any_one_of":["^dev-.*$"],"regex":true}]},` +
+				`{"local":[{"group":{"name":"federated","domain":{"name":"Default"}}}],"remote":[{"type":"OIDC-email"}]}]`,
+		},
+		{
+			name: "raw rules, claim mappings and auto provisioning",
+			spec: KeystoneMappingSpec{
+				Rules: `[{"local": [{"user": {"name": "{0}"}}], "remote": [{"type": "REMOTE_USER"}]}]`,
+				ClaimMappings: []MappingClaim{
+					{Claim: "OIDC-groups", Values: []string{"admins"}, Groups: []MappingGroup{{Name: "admins", Domain: "Default"}}},
+				},
+				AutoProvisioning: &MappingAutoProvisioning{
+					Projects: []MappingProject{{Name: "demo", Roles: []string{"reader"}}},
+				},
+			},
+			wantRules: `[{"local":[{"user":{"name":"{0}"}}],"remote":[{"type":"REMOTE_USER"}]},` +
+				`{"local":[{"group":{"name":"admins","domain":{"name":"Default"}}}],"remote":[{"type":"OIDC-groups","any_one_of":["admins"]}]},` +
+				`{"local":[{"user":{"name":"{0}"},"projects":[{"name":"demo","roles":[{"name":"reader"}]}]}],"remote":[{"type":"REMOTE_USER"}]}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rules, err := tt.spec.GetRules()
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(rules)).To(Equal(tt.wantRules))
+		})
+	}
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MappingDefaultProtocol - federation protocol of the identity provider
+	// of a KeystoneMapping if not set
+	MappingDefaultProtocol = "openid"
+	// MappingDefaultUserNameAttribute - remote attribute holding the name of
+	// the federated user if not set, mod_auth_openidc and mod_auth_mellon set
+	// it to the configured claim or attribute
+	MappingDefaultUserNameAttribute = "REMOTE_USER"
+)
+
+// KeystoneMappingSpec defines the desired state of KeystoneMapping
+type KeystoneMappingSpec struct {
+	// +kubebuilder:validation:Optional
+	// MappingID - ID of the mapping in keystone. Defaults to the name of the
+	// KeystoneMapping.
+	MappingID string `json:"mappingID,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Rules - mapping rules as keystone JSON, a list of rules with local and
+	// remote, for what ClaimMappings and AutoProvisioning do not cover. They
+	// come before the rules rendered from them.
+	Rules string `json:"rules,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=atomic
+	// ClaimMappings - groups and project roles the federated users get by the
+	// values of a claim of their assertion. Each gets rendered into a mapping
+	// rule.
+	ClaimMappings []MappingClaim `json:"claimMappings,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// AutoProvisioning - projects keystone creates on the first login of a
+	// federated user, with the roles the user gets on them. Rendered into a
+	// mapping rule, so basic setups do not need hand written rules.
+	AutoProvisioning *MappingAutoProvisioning `json:"autoProvisioning,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// IdentityProvider - identity provider the mapping gets used for. The
+	// operator creates the identity provider and its protocol using the
+	// mapping.
+	IdentityProvider *MappingIdentityProvider `json:"identityProvider,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ShadowUsers - reports the shadow users keystone created for the
+	// federated users of the identity provider, and purges them by age
+	ShadowUsers *MappingShadowUsers `json:"shadowUsers,omitempty"`
+}
+
+// MappingAutoProvisioning - projects and roles of federated users
+type MappingAutoProvisioning struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=REMOTE_USER
+	// UserNameAttribute - remote attribute holding the name of the user, e.g.
+	// OIDC-preferred_username
+	UserNameAttribute string `json:"userNameAttribute,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// UserEmailAttribute - remote attribute holding the email of the user,
+	// e.g. OIDC-email
+	UserEmailAttribute string `json:"userEmailAttribute,omitempty"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=atomic
+	// Projects - projects the users get roles on. Keystone creates missing
+	// projects in the domain of the identity provider.
+	Projects []MappingProject `json:"projects"`
+}
+
+// MappingProject - project a federated user gets roles on
+type MappingProject struct {
+	// +kubebuilder:validation:Required
+	// Name - name of the project, {0} gets replaced with the name of the
+	// user, e.g. "{0}-sandbox" for a project per user
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=atomic
+	// Roles - names of the roles the user gets on the project, they have to
+	// exist
+	Roles []string `json:"roles"`
+}
+
+// MappingClaim - groups and project roles of the users with a claim
+type MappingClaim struct {
+	// +kubebuilder:validation:Required
+	// Claim - remote attribute of the assertion, e.g. OIDC-groups
+	Claim string `json:"claim"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=atomic
+	// Values - the claim has to hold one of the values, any value if not
+	// set
+	Values []string `json:"values,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Regex - whether the Values are regular expressions
+	Regex bool `json:"regex,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=atomic
+	// Groups - keystone groups the users get, they have to exist
+	Groups []MappingGroup `json:"groups,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=atomic
+	// Projects - projects the users get roles on. Keystone creates missing
+	// projects in the domain of the identity provider.
+	Projects []MappingProject `json:"projects,omitempty"`
+}
+
+// MappingGroup - keystone group of federated users
+type MappingGroup struct {
+	// +kubebuilder:validation:Required
+	// Name - name of the group
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// Domain - name of the domain of the group
+	Domain string `json:"domain,omitempty"`
+}
+
+// MappingIdentityProvider - identity provider using a KeystoneMapping
+type MappingIdentityProvider struct {
+	// +kubebuilder:validation:Required
+	// Name - ID of the identity provider in keystone
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Optional
+	// Description - description of the identity provider
+	Description string `json:"description,omitempty"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=atomic
+	// RemoteIDs - IDs the identity provider identifies with, e.g. the issuer
+	// of an OpenID Connect provider or the entity ID of a SAML2 one
+	RemoteIDs []string `json:"remoteIDs"`
+
+	// +kubebuilder:validation:Optional
+	// Domain - name of the domain of the federated users and their
+	// auto provisioned projects, it has to exist. Keystone creates a domain
+	// for the identity provider if not set. Can not be changed once the
+	// identity provider got created.
+	Domain string `json:"domain,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=openid
+	// Protocol - ID of the federation protocol using the mapping, e.g.
+	// openid or saml2
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// MappingShadowUsers - report and purge of the shadow users of the identity
+// provider
+type MappingShadowUsers struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// PurgeAfterDays - days after the operator first saw a shadow user it gets
+	// deleted, keystone creates it again on the next login of the user. Not
+	// purged if 0.
+	PurgeAfterDays int `json:"purgeAfterDays,omitempty"`
+}
+
+// ShadowUsersStatus - report of the shadow users of the identity provider
+type ShadowUsersStatus struct {
+	// IdentityProvider - ID of the identity provider of the users
+	IdentityProvider string `json:"identityProvider"`
+	// Count - number of shadow users
+	Count int `json:"count"`
+	// DisabledCount - number of disabled shadow users
+	DisabledCount int `json:"disabledCount"`
+	// OldestFirstSeen - time the operator first saw the oldest shadow user
+	OldestFirstSeen *metav1.Time `json:"oldestFirstSeen,omitempty"`
+	// ConfigMap - name of the ConfigMap listing the shadow users with the
+	// time the operator first saw them
+	ConfigMap string `json:"configMap"`
+	// PurgedCount - number of shadow users the last purge deleted
+	PurgedCount int `json:"purgedCount,omitempty"`
+	// LastPurgeTime - time of the last purge which deleted users
+	LastPurgeTime *metav1.Time `json:"lastPurgeTime,omitempty"`
+	// CheckTime - time the shadow users got listed
+	CheckTime metav1.Time `json:"checkTime"`
+}
+
+// KeystoneMappingStatus defines the observed state of KeystoneMapping
+type KeystoneMappingStatus struct {
+	// MappingID - ID of the mapping in keystone
+	MappingID string `json:"mappingID,omitempty"`
+	// RulesHash - hash of the rules set in keystone
+	RulesHash string `json:"rulesHash,omitempty"`
+	// MappingCreated - whether the operator created the mapping. Only
+	// created mappings get deleted with the KeystoneMapping, existing ones
+	// get adopted.
+	MappingCreated bool `json:"mappingCreated,omitempty"`
+	// IdentityProviderID - ID of the identity provider using the mapping
+	IdentityProviderID string `json:"identityProviderID,omitempty"`
+	// IdentityProviderCreated - whether the operator created the identity
+	// provider, only created identity providers get deleted
+	IdentityProviderCreated bool `json:"identityProviderCreated,omitempty"`
+	// DomainID - ID of the domain of the identity provider
+	DomainID string `json:"domainID,omitempty"`
+	// DomainName - name of the domain of the identity provider
+	DomainName string `json:"domainName,omitempty"`
+	// DomainCreated - whether keystone created the domain for the identity
+	// provider the operator created. It gets deleted with the identity
+	// provider, after purging the ID mappings of its users.
+	DomainCreated bool `json:"domainCreated,omitempty"`
+	// MappingPurgeHash - hash of the finished mapping purge job of the domain
+	MappingPurgeHash string `json:"mappingPurgeHash,omitempty"`
+	// ShadowUsers - report of the shadow users of the identity provider
+	ShadowUsers *ShadowUsersStatus `json:"shadowUsers,omitempty"`
+	// Protocol - ID of the federation protocol using the mapping
+	Protocol string `json:"protocol,omitempty"`
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	//ObservedGeneration - the most recent generation observed for this mapping. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime - time of the last reconcile. While the result of the
+	// reconcile and the applied spec do not change it gets updated at most once
+	// a minute.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastSuccessfulReconcile - time of the last reconcile which finished
+	// without an error and left the instance ready
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// AppliedSpecHash - hash of the spec applied by the last successful reconcile
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Mapping",type="string",JSONPath=".status.mappingID",description="Mapping"
+//+kubebuilder:printcolumn:name="IdentityProvider",type="string",JSONPath=".status.identityProviderID",description="IdentityProvider"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneMapping is the Schema for the keystonemappings API
+type KeystoneMapping struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneMappingSpec   `json:"spec,omitempty"`
+	Status KeystoneMappingStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneMappingList contains a list of KeystoneMapping
+type KeystoneMappingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneMapping `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneMapping{}, &KeystoneMappingList{})
+}
+
+// IsReady - returns true if KeystoneMapping is reconciled successfully for
+// the current generation of the spec
+func (instance KeystoneMapping) IsReady() bool {
+	return instance.Generation == instance.Status.ObservedGeneration &&
+		instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
+
+// GetMappingID - returns the ID of the mapping in keystone
+func (instance KeystoneMapping) GetMappingID() string {
+	if instance.Spec.MappingID != "" {
+		return instance.Spec.MappingID
+	}
+	return instance.Name
+}
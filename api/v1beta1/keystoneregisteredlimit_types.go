@@ -0,0 +1,101 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeystoneRegisteredLimitSpec defines the desired state of KeystoneRegisteredLimit
+type KeystoneRegisteredLimitSpec struct {
+	// +kubebuilder:validation:Required
+	// ServiceName - Name of the KeystoneService the limit gets registered for
+	ServiceName string `json:"serviceName"`
+	// +kubebuilder:validation:Required
+	// ResourceName - Name of the resource the limit applies to, e.g. cores
+	ResourceName string `json:"resourceName"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=-1
+	// DefaultLimit - Default limit for all projects which have no project
+	// specific limit. -1 means unlimited.
+	DefaultLimit int `json:"defaultLimit"`
+	// +kubebuilder:validation:Optional
+	// Description - Description for the registered limit.
+	Description string `json:"description,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Region - Region the limit applies to. If not set the limit applies to
+	// all regions.
+	Region string `json:"region,omitempty"`
+}
+
+// KeystoneRegisteredLimitStatus defines the observed state of KeystoneRegisteredLimit
+type KeystoneRegisteredLimitStatus struct {
+	// RegisteredLimitID - ID of the registered limit in keystone
+	RegisteredLimitID string `json:"registeredLimitID,omitempty"`
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	//ObservedGeneration - the most recent generation observed for this limit. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime - time of the last reconcile. While the result of the
+	// reconcile and the applied spec do not change it gets updated at most once
+	// a minute.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastSuccessfulReconcile - time of the last reconcile which finished
+	// without an error and left the instance ready
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// AppliedSpecHash - hash of the spec applied by the last successful reconcile
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneRegisteredLimit is the Schema for the keystoneregisteredlimits API
+type KeystoneRegisteredLimit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneRegisteredLimitSpec   `json:"spec,omitempty"`
+	Status KeystoneRegisteredLimitStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneRegisteredLimitList contains a list of KeystoneRegisteredLimit
+type KeystoneRegisteredLimitList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneRegisteredLimit `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneRegisteredLimit{}, &KeystoneRegisteredLimitList{})
+}
+
+// IsReady - returns true if KeystoneRegisteredLimit is reconciled successfully for
+// the current generation of the spec
+func (instance KeystoneRegisteredLimit) IsReady() bool {
+	return instance.Generation == instance.Status.ObservedGeneration &&
+		instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
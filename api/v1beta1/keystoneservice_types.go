@@ -21,6 +21,14 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// TakeOwnershipAnnotation - annotation on a KeystoneService,
+	// KeystoneEndpoint or KeystoneCatalog. Set to "true" the operator manages
+	// the services and endpoints in keystone even if they carry the ownership
+	// marker of the operator of another cluster.
+	TakeOwnershipAnnotation = "keystone.openstack.org/take-ownership"
+)
+
 // KeystoneServiceSpec defines the desired state of KeystoneService
 type KeystoneServiceSpec struct {
 	// +kubebuilder:validation:Required
@@ -44,6 +52,24 @@ type KeystoneServiceSpec struct {
 	// +kubebuilder:validation:Required
 	// PasswordSelector - Selector to get the ServiceUser password from the Secret, e.g. PlacementPassword
 	PasswordSelector string `json:"passwordSelector"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=service
+	// ServiceProject - Name of the project the ServiceUser gets created in and
+	// gets the admin and service roles assigned on. Defaults to the shared
+	// "service" project, set a dedicated project for tighter isolation.
+	ServiceProject string `json:"serviceProject,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// ServiceDomain - Name of the domain the ServiceUser and ServiceProject
+	// get created in. The domain gets created if it does not exist.
+	ServiceDomain string `json:"serviceDomain,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=set
+	// SystemRoles - roles assigned to the ServiceUser on the system scope,
+	// e.g. reader or admin, required by services checking system scoped
+	// tokens. Roles removed from the list get unassigned.
+	SystemRoles []string `json:"systemRoles,omitempty"`
 }
 
 // KeystoneServiceStatus defines the observed state of KeystoneService
@@ -51,6 +77,34 @@ type KeystoneServiceStatus struct {
 	ServiceID string `json:"serviceID,omitempty"`
 	// Conditions
 	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	//ObservedGeneration - the most recent generation observed for this service. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime - time of the last reconcile. While the result of the
+	// reconcile and the applied spec do not change it gets updated at most once
+	// a minute.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastSuccessfulReconcile - time of the last reconcile which finished
+	// without an error and left the instance ready
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// AppliedSpecHash - hash of the spec applied by the last successful reconcile
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+
+	// SystemRoles - roles assigned to the ServiceUser on the system scope
+	SystemRoles []string `json:"systemRoles,omitempty"`
+
+	// DomainID - ID of the domain the ServiceUser got created in
+	DomainID string `json:"domainID,omitempty"`
+
+	// ProjectID - ID of the project the ServiceUser got the roles assigned on
+	ProjectID string `json:"projectID,omitempty"`
+
+	// UserID - ID of the ServiceUser. A user of a former ServiceUser,
+	// ServiceDomain or ServiceProject gets cleaned up using the IDs.
+	UserID string `json:"userID,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -80,7 +134,9 @@ func init() {
 	SchemeBuilder.Register(&KeystoneService{}, &KeystoneServiceList{})
 }
 
-// IsReady - returns true if KeystoneService is reconciled successfully
+// IsReady - returns true if KeystoneService is reconciled successfully for
+// the current generation of the spec
 func (instance KeystoneService) IsReady() bool {
-	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+	return instance.Generation == instance.Status.ObservedGeneration &&
+		instance.Status.Conditions.IsTrue(condition.ReadyCondition)
 }
@@ -0,0 +1,156 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeystoneUserSpec defines the desired state of KeystoneUser
+type KeystoneUserSpec struct {
+	// +kubebuilder:validation:Required
+	// UserName - Name of the user
+	UserName string `json:"userName"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// Domain - Name of the domain the user gets created in, it has to exist
+	Domain string `json:"domain,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Secret - Secret holding the password of the user. Users without a
+	// password can not authenticate with the password method, e.g. for
+	// federated or locked accounts.
+	Secret string `json:"secret,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Password
+	// PasswordSelector - Selector to get the password of the user from the Secret
+	PasswordSelector string `json:"passwordSelector,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=true
+	// Enabled - whether the user can authenticate. Disabling locks the
+	// account, e.g. after a compromise, until it gets enabled again. Changes
+	// made out of band in keystone get reverted.
+	Enabled bool `json:"enabled"`
+	// +kubebuilder:validation:Optional
+	// Options - resource options of the user in keystone
+	Options KeystoneUserOptions `json:"options,omitempty"`
+}
+
+// KeystoneUserOptions - resource options of a user in keystone. Options left
+// unset are not managed and keep their value in keystone, set options get
+// reverted like the enabled state if they got changed out of band.
+type KeystoneUserOptions struct {
+	// +kubebuilder:validation:Optional
+	// IgnoreChangePasswordUponFirstUse - the user does not have to change
+	// the password set from the Secret on first use, e.g. for service
+	// accounts in a domain with change_password_upon_first_use
+	IgnoreChangePasswordUponFirstUse *bool `json:"ignoreChangePasswordUponFirstUse,omitempty"`
+	// +kubebuilder:validation:Optional
+	// IgnorePasswordExpiry - the password of the user does not expire with
+	// password_expires_days
+	IgnorePasswordExpiry *bool `json:"ignorePasswordExpiry,omitempty"`
+	// +kubebuilder:validation:Optional
+	// IgnoreLockoutFailureAttempts - the user does not get locked out after
+	// lockout_failure_attempts failed authentications
+	IgnoreLockoutFailureAttempts *bool `json:"ignoreLockoutFailureAttempts,omitempty"`
+	// +kubebuilder:validation:Optional
+	// LockPassword - the user can not change its password itself
+	LockPassword *bool `json:"lockPassword,omitempty"`
+	// +kubebuilder:validation:Optional
+	// MultiFactorAuthEnabled - the user has to authenticate with one of the
+	// MultiFactorAuthRules
+	MultiFactorAuthEnabled *bool `json:"multiFactorAuthEnabled,omitempty"`
+	// +kubebuilder:validation:Optional
+	// MultiFactorAuthRules - lists of the auth methods the user has to
+	// authenticate with, e.g. [["password", "totp"]]
+	MultiFactorAuthRules [][]string `json:"multiFactorAuthRules,omitempty"`
+}
+
+// KeystoneUserStatus defines the observed state of KeystoneUser
+type KeystoneUserStatus struct {
+	// UserID - ID of the user in keystone
+	UserID string `json:"userID,omitempty"`
+	// DomainID - ID of the domain of the user
+	DomainID string `json:"domainID,omitempty"`
+	// Created - whether the operator created the user. Only created users
+	// get deleted with the KeystoneUser, existing users get adopted.
+	Created bool `json:"created,omitempty"`
+	// Enabled - whether the user is enabled in keystone
+	Enabled *bool `json:"enabled,omitempty"`
+	// EnabledChangeTime - time the user got enabled or disabled last
+	EnabledChangeTime *metav1.Time `json:"enabledChangeTime,omitempty"`
+	// LastDriftRepairTime - time an enabled state changed out of band in
+	// keystone got reverted last
+	LastDriftRepairTime *metav1.Time `json:"lastDriftRepairTime,omitempty"`
+	// PasswordSecretHash - hash of the name, ResourceVersion and password
+	// selector of the Secret the password of the user got set from. The
+	// password itself does not get hashed, its hash could be brute forced.
+	PasswordSecretHash string `json:"passwordSecretHash,omitempty"`
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	//ObservedGeneration - the most recent generation observed for this user. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime - time of the last reconcile. While the result of the
+	// reconcile and the applied spec do not change it gets updated at most once
+	// a minute.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastSuccessfulReconcile - time of the last reconcile which finished
+	// without an error and left the instance ready
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// AppliedSpecHash - hash of the spec applied by the last successful reconcile
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="User",type="string",JSONPath=".spec.userName",description="User"
+//+kubebuilder:printcolumn:name="Enabled",type="boolean",JSONPath=".status.enabled",description="Enabled"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneUser is the Schema for the keystoneusers API
+type KeystoneUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneUserSpec   `json:"spec,omitempty"`
+	Status KeystoneUserStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneUserList contains a list of KeystoneUser
+type KeystoneUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneUser{}, &KeystoneUserList{})
+}
+
+// IsReady - returns true if KeystoneUser is reconciled successfully for
+// the current generation of the spec
+func (instance KeystoneUser) IsReady() bool {
+	return instance.Generation == instance.Status.ObservedGeneration &&
+		instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
@@ -0,0 +1,135 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// UserBatchEntry - user of the CSV of a KeystoneUserBatch
+type UserBatchEntry struct {
+	// Line - line of the user in the CSV
+	Line     int
+	UserName string
+	Domain   string
+	Enabled  bool
+}
+
+// ParseUserBatch - parses the users CSV of a KeystoneUserBatch. The first line
+// is the header, name is the only required column. Users without a domain
+// get defaultDomain. Lines which can not be parsed and duplicate users get
+// returned as failures, an error only if the CSV itself is invalid.
+func ParseUserBatch(data string, defaultDomain string) ([]UserBatchEntry, []KeystoneUserBatchFailure, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "name", "domain", "enabled":
+		default:
+			return nil, nil, fmt.Errorf("unknown column %q, supported are name, domain and enabled", name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, nil, fmt.Errorf("duplicate column %q", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, nil, fmt.Errorf("missing column name")
+	}
+	column := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var entries []UserBatchEntry
+	var failures []KeystoneUserBatchFailure
+	seen := map[string]int{}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := r.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, err
+			}
+			failures = append(failures, KeystoneUserBatchFailure{
+				Line:    parseErr.Line,
+				Message: parseErr.Err.Error(),
+			})
+			continue
+		}
+
+		entry := UserBatchEntry{
+			Line:     line,
+			UserName: column(record, "name"),
+			Domain:   column(record, "domain"),
+			Enabled:  true,
+		}
+		if entry.Domain == "" {
+			entry.Domain = defaultDomain
+		}
+		failure := KeystoneUserBatchFailure{
+			Line:     line,
+			UserName: entry.UserName,
+			Domain:   entry.Domain,
+		}
+		if entry.UserName == "" {
+			failure.Message = "missing name"
+			failures = append(failures, failure)
+			continue
+		}
+		if enabled := column(record, "enabled"); enabled != "" {
+			entry.Enabled, err = strconv.ParseBool(enabled)
+			if err != nil {
+				failure.Message = fmt.Sprintf("invalid enabled %q", enabled)
+				failures = append(failures, failure)
+				continue
+			}
+		}
+		key := strings.ToLower(entry.Domain) + "/" + entry.UserName
+		if first, ok := seen[key]; ok {
+			failure.Message = fmt.Sprintf("duplicate of line %d", first)
+			failures = append(failures, failure)
+			continue
+		}
+		seen[key] = line
+		entries = append(entries, entry)
+	}
+
+	return entries, failures, nil
+}
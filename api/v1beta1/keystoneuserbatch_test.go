@@ -0,0 +1,90 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseUserBatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantEntries  []UserBatchEntry
+		wantFailures []KeystoneUserBatchFailure
+		wantErr      bool
+	}{
+		{
+			name: "empty",
+			data: "",
+		},
+		{
+			name: "names only",
+			data: "name\nalice\nbob\n",
+			wantEntries: []UserBatchEntry{
+				{Line: 2, UserName: "alice", Domain: "Default", Enabled: true},
+				{Line: 3, UserName: "bob", Domain: "Default", Enabled: true},
+			},
+		},
+		{
+			name: "all columns in any order",
+			data: "enabled, domain, name\nfalse, lab, alice\n,,bob\n",
+			wantEntries: []UserBatchEntry{
+				{Line: 2, UserName: "alice", Domain: "lab", Enabled: false},
+				{Line: 3, UserName: "bob", Domain: "Default", Enabled: true},
+			},
+		},
+		{
+			name: "invalid lines become failures",
+			data: "name,domain,enabled\n,lab,true\nalice,lab,maybe\nbob\nbob,default\n",
+			wantEntries: []UserBatchEntry{
+				{Line: 4, UserName: "bob", Domain: "Default", Enabled: true},
+			},
+			wantFailures: []KeystoneUserBatchFailure{
+				{Line: 2, Domain: "lab", Message: "missing name"},
+				{Line: 3, UserName: "alice", Domain: "lab", Message: `invalid enabled "maybe"`},
+				{Line: 5, UserName: "bob", Domain: "default", Message: "duplicate of line 4"},
+			},
+		},
+		{
+			name:    "missing name column",
+			data:    "domain,enabled\nlab,true\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown column",
+			data:    "name,password\nalice,secret\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			entries, failures, err := ParseUserBatch(tt.data, "Default")
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(entries).To(Equal(tt.wantEntries))
+				g.Expect(failures).To(Equal(tt.wantFailures))
+			}
+		})
+	}
+}
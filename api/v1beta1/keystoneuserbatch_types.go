@@ -0,0 +1,144 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// UserBatchDefaultKey - default key of the CSV in the ConfigMap of a
+	// KeystoneUserBatch
+	UserBatchDefaultKey = "users.csv"
+
+	// UserBatchMaxReportedFailures - maximum number of failed users listed
+	// in the status of a KeystoneUserBatch
+	UserBatchMaxReportedFailures = 50
+)
+
+// KeystoneUserBatchSpec defines the desired state of KeystoneUserBatch
+type KeystoneUserBatchSpec struct {
+	// +kubebuilder:validation:Required
+	// ConfigMap - Name of the ConfigMap holding the users as CSV. The first
+	// line is the header naming the columns: name is required, domain
+	// defaults to Domain and enabled to true.
+	ConfigMap string `json:"configMap"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=users.csv
+	// Key - Key of the CSV in the ConfigMap
+	Key string `json:"key,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// Domain - Name of the domain of the users without a domain column, the
+	// domains have to exist
+	Domain string `json:"domain,omitempty"`
+	// +kubebuilder:validation:Optional
+	// PasswordSecret - Secret holding the passwords of the users by user
+	// name. Users without a password in it get created without a password.
+	PasswordSecret string `json:"passwordSecret,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=5
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=20
+	// Concurrency - number of users created in parallel
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// KeystoneUserBatchFailure - user of a KeystoneUserBatch which could not be
+// provisioned
+type KeystoneUserBatchFailure struct {
+	// Line - line of the user in the CSV
+	Line int `json:"line"`
+	// UserName - name of the user
+	UserName string `json:"userName,omitempty"`
+	// Domain - name of the domain of the user
+	Domain string `json:"domain,omitempty"`
+	// Message - why the user could not be provisioned
+	Message string `json:"message"`
+}
+
+// KeystoneUserBatchStatus defines the observed state of KeystoneUserBatch
+type KeystoneUserBatchStatus struct {
+	// UserCount - number of users in the CSV
+	UserCount int32 `json:"userCount,omitempty"`
+	// ReadyCount - number of users provisioned in keystone
+	ReadyCount int32 `json:"readyCount,omitempty"`
+	// PendingCount - number of users not created yet because of the users
+	// per minute safety limit
+	PendingCount int32 `json:"pendingCount,omitempty"`
+	// FailedCount - number of users which could not be provisioned
+	FailedCount int32 `json:"failedCount,omitempty"`
+	// Failures - the first failed users, at most UserBatchMaxReportedFailures
+	Failures []KeystoneUserBatchFailure `json:"failures,omitempty"`
+	// DomainIDs - IDs of the domains the batch created users in
+	DomainIDs []string `json:"domainIDs,omitempty"`
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	//ObservedGeneration - the most recent generation observed for this batch. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime - time of the last reconcile. While the result of the
+	// reconcile and the applied spec do not change it gets updated at most once
+	// a minute.
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastSuccessfulReconcile - time of the last reconcile which finished
+	// without an error and left the instance ready
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// AppliedSpecHash - hash of the spec applied by the last successful reconcile
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Users",type="integer",JSONPath=".status.userCount",description="Users"
+//+kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyCount",description="Ready"
+//+kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failedCount",description="Failed"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneUserBatch is the Schema for the keystoneuserbatches API
+type KeystoneUserBatch struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneUserBatchSpec   `json:"spec,omitempty"`
+	Status KeystoneUserBatchStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneUserBatchList contains a list of KeystoneUserBatch
+type KeystoneUserBatchList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneUserBatch `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneUserBatch{}, &KeystoneUserBatchList{})
+}
+
+// IsReady - returns true if KeystoneUserBatch is reconciled successfully for
+// the current generation of the spec
+func (instance KeystoneUserBatch) IsReady() bool {
+	return instance.Generation == instance.Status.ObservedGeneration &&
+		instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"regexp"
+)
+
+// Redacted - replacement of redacted secret values
+const Redacted = "***"
+
+// secretKey - names of fields holding secrets, e.g. password, secret, the
+// token of an X-Auth-Token or the blob of a keystone credential
+const secretKey = `(?i:[a-z0-9_-]*(?:password|passwd|passcode|secret|token|blob)[a-z0-9_-]*)`
+
+var (
+	// "password": "value" in JSON bodies, e.g. gophercloud error dumps of
+	// user, credential and application credential requests
+	jsonSecretRegexp = regexp.MustCompile(`("` + secretKey + `"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// password=value, password: value or X-Auth-Token: value in
+	// connection strings, config snippets, headers and keystone validation
+	// errors like "Invalid input for field 'password': 'value'"
+	keyValueSecretRegexp = regexp.MustCompile(`(\b` + secretKey + `['"]?\s*[=:]\s*['"]?)[^\s"',;&}]+`)
+	// user:password@ of URLs, e.g. database and transport URLs
+	urlPasswordRegexp = regexp.MustCompile(`(://[^:/@\s]*:)[^@\s]+@`)
+)
+
+// RedactSecrets - replaces passwords, tokens and other secrets in s, e.g.
+// an error message, with Redacted, so they do not end up in the operator logs
+// or condition messages.
+func RedactSecrets(s string) string {
+	s = jsonSecretRegexp.ReplaceAllString(s, `$1"`+Redacted+`"`)
+	s = keyValueSecretRegexp.ReplaceAllString(s, "${1}"+Redacted)
+	s = urlPasswordRegexp.ReplaceAllString(s, "${1}"+Redacted+"@")
+	return s
+}
+
+// redactedError - error with its message redacted, the wrapped error is kept
+// for errors.Is and errors.As
+type redactedError struct {
+	err error
+}
+
+func (e redactedError) Error() string {
+	return RedactSecrets(e.err.Error())
+}
+
+func (e redactedError) Unwrap() error {
+	return e.err
+}
+
+// RedactError - returns err with secrets redacted from its message
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(redactedError); ok {
+		return err
+	}
+	return redactedError{err: err}
+}
@@ -0,0 +1,244 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"slices"
+	"strings"
+)
+
+// serviceConfigOption - option of a service config section with the values
+// in the order they got set, options like [ldap] url may be set repeatedly
+type serviceConfigOption struct {
+	name   string
+	values []string
+}
+
+// serviceConfigSection - section of a service config, the options in the
+// order they got set first
+type serviceConfigSection struct {
+	name    string
+	options []*serviceConfigOption
+}
+
+// serviceConfig - service config in oslo.config INI format, the sections in
+// the order they got set first. Options before the first section header
+// belong to the section with the empty name.
+type serviceConfig struct {
+	sections []*serviceConfigSection
+}
+
+// section - returns the section with the name, adds it if missing
+func (c *serviceConfig) section(name string) *serviceConfigSection {
+	for _, s := range c.sections {
+		if s.name == name {
+			return s
+		}
+	}
+	s := &serviceConfigSection{name: name}
+	c.sections = append(c.sections, s)
+	return s
+}
+
+// option - returns the option with the name, adds it if missing
+func (s *serviceConfigSection) option(name string) *serviceConfigOption {
+	for _, o := range s.options {
+		if o.name == name {
+			return o
+		}
+	}
+	o := &serviceConfigOption{name: name}
+	s.options = append(s.options, o)
+	return o
+}
+
+// serviceConfigError - line of a service config oslo.config fails to parse
+type serviceConfigError struct {
+	// line - number of the line, starting at 1
+	line    int
+	message string
+}
+
+// parseServiceConfig - parses a service config like the oslo.config INI
+// parser does. Lines starting with # or ; are comments, key and value get
+// separated by the first = or :, indented lines continue the value of the
+// previous option until the next blank line. Lines oslo.config fails to
+// parse get returned as errors, options before the first section header get
+// added to the section with the empty name.
+func parseServiceConfig(data string) (*serviceConfig, []serviceConfigError) {
+	config := &serviceConfig{}
+	errs := []serviceConfigError{}
+	var section *serviceConfigSection
+	var last *serviceConfigOption
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			last = nil
+			continue
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+		if last != nil && indented {
+			last.values[len(last.values)-1] += "\n" + line
+			continue
+		}
+		last = nil
+		fail := func(message string) {
+			errs = append(errs, serviceConfigError{line: n + 1, message: message})
+		}
+		switch {
+		case line[0] == '#' || line[0] == ';':
+		case line[0] == '[':
+			if line[len(line)-1] != ']' {
+				fail("section header is missing the closing ]")
+			} else if line == "[]" {
+				fail("empty section name")
+			} else {
+				section = config.section(line[1 : len(line)-1])
+			}
+		case indented:
+			fail("unexpected continuation line")
+		default:
+			i := strings.IndexAny(line, "=:")
+			if i < 0 {
+				fail("missing = or : between option and value")
+				continue
+			}
+			name := strings.TrimSpace(line[:i])
+			if name == "" {
+				fail("empty option name")
+				continue
+			}
+			if section == nil {
+				fail("option before the first section header")
+				section = config.section("")
+			}
+			last = section.option(name)
+			last.values = append(last.values, strings.TrimSpace(line[i+1:]))
+		}
+	}
+	return config, errs
+}
+
+// merge - merges the other config into the config, the values of an option
+// set in other replace the values of the config
+func (c *serviceConfig) merge(other *serviceConfig) {
+	for _, otherSection := range other.sections {
+		section := c.section(otherSection.name)
+		for _, otherOption := range otherSection.options {
+			section.option(otherOption.name).values = otherOption.values
+		}
+	}
+}
+
+// String - renders the config in oslo.config INI format
+func (c *serviceConfig) String() string {
+	var b strings.Builder
+	// options without section first, they are lost after a section header
+	for _, s := range c.sections {
+		if s.name == "" {
+			s.write(&b)
+		}
+	}
+	for _, s := range c.sections {
+		if s.name == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("[" + s.name + "]\n")
+		s.write(&b)
+	}
+	return b.String()
+}
+
+// write - writes the options of the section
+func (s *serviceConfigSection) write(b *strings.Builder) {
+	for _, o := range s.options {
+		for _, value := range o.values {
+			b.WriteString(o.name + " = " + value + "\n")
+		}
+	}
+}
+
+// MergeServiceConfigs - merges the service configs in order. An option set
+// in a later config replaces all values of the option of the earlier ones,
+// the sections and options keep the order they got set first. Comments do
+// not get preserved.
+func MergeServiceConfigs(configs ...string) string {
+	merged := &serviceConfig{}
+	for _, config := range configs {
+		parsed, _ := parseServiceConfig(config)
+		merged.merge(parsed)
+	}
+	return merged.String()
+}
+
+// DiffServiceConfigs - returns the options whose values differ between the
+// service configs as "[section] option", and a diff of them with the values
+// of the old config on - lines and the values of the new config on + lines
+func DiffServiceConfigs(oldConfig string, newConfig string) ([]string, string) {
+	before, _ := parseServiceConfig(oldConfig)
+	after, _ := parseServiceConfig(newConfig)
+	// sections and options of both configs, in the order of the new config
+	all := &serviceConfig{}
+	all.merge(after)
+	all.merge(before)
+
+	changed := []string{}
+	var b strings.Builder
+	for _, s := range all.sections {
+		header := false
+		for _, o := range s.options {
+			oldValues := before.values(s.name, o.name)
+			newValues := after.values(s.name, o.name)
+			if slices.Equal(oldValues, newValues) {
+				continue
+			}
+			if s.name == "" {
+				changed = append(changed, o.name)
+			} else {
+				changed = append(changed, "["+s.name+"] "+o.name)
+			}
+			if !header && s.name != "" {
+				b.WriteString("[" + s.name + "]\n")
+			}
+			header = true
+			for _, value := range oldValues {
+				b.WriteString("-" + o.name + " = " + value + "\n")
+			}
+			for _, value := range newValues {
+				b.WriteString("+" + o.name + " = " + value + "\n")
+			}
+		}
+	}
+	return changed, b.String()
+}
+
+// values - returns the values of the option of the section, nil if not set
+func (c *serviceConfig) values(section string, option string) []string {
+	for _, s := range c.sections {
+		if s.name != section {
+			continue
+		}
+		for _, o := range s.options {
+			if o.name == option {
+				return o.values
+			}
+		}
+	}
+	return nil
+}
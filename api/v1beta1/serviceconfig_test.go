@@ -0,0 +1,402 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestMergeServiceConfigs(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []string
+		want    string
+	}{
+		{
+			name:    "empty",
+			configs: []string{"", "# comment only\n"},
+			want:    "",
+		},
+		{
+			name: "later layers replace options",
+			configs: []string{
+				"[DEFAULT]\ndebug = false\n[token]\nexpiration = 3600\n",
+				"# environment\n[token]\nexpiration: 7200\ncaching=false\n",
+				"[DEFAULT]\ndebug = true\n",
+			},
+			want: "[DEFAULT]\ndebug = true\n\n[token]\nexpiration = 7200\ncaching = false\n",
+		},
+		{
+			name: "repeated options and sections of a layer",
+			configs: []string{
+				"[ldap]\nurl = ldap://a\n[identity]\ndriver = sql\n[ldap]\nurl = ldap://b\n",
+				"[ldap]\nuser = cn=admin\n",
+			},
+			want: "[ldap]\nurl = ldap://a\nurl = ldap://b\nuser = cn=admin\n\n[identity]\ndriver = sql\n",
+		},
+		{
+			name: "repeated options get replaced as a whole",
+			configs: []string{
+				"[ldap]\nurl = ldap://a\nurl = ldap://b\n",
+				"[ldap]\nurl = ldap://c\n",
+			},
+			want: "[ldap]\nurl = ldap://c\n",
+		},
+		{
+			name: "continuation lines and options without section",
+			configs: []string{
+				"[oslo_policy]\npolicy_dirs = a,\n    b\n",
+				"debug = true\n",
+			},
+			want: "debug = true\n\n[oslo_policy]\npolicy_dirs = a,\n    b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(MergeServiceConfigs(tt.configs...)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestDiffServiceConfigs(t *testing.T) {
+	tests := []struct {
+		name        string
+		oldConfig   string
+		newConfig   string
+		wantChanged []string
+		wantDiff    string
+	}{
+		{
+			name:        "equal configs with different comments",
+			oldConfig:   "[token]\n# default\nexpiration = 3600\n",
+			newConfig:   "[token]\nexpiration=3600\n",
+			wantChanged: []string{},
+			wantDiff:    "",
+		},
+		{
+			name:        "changed, added and removed options",
+			oldConfig:   "[DEFAULT]\ndebug = false\n[token]\nexpiration = 3600\ncaching = true\n",
+			newConfig:   "[DEFAULT]\ndebug = false\n[token]\nexpiration = 7200\n[ldap]\nurl = ldap://a\nurl = ldap://b\n",
+			wantChanged: []string{"[token] expiration", "[token] caching", "[ldap] url"},
+			wantDiff: "[token]\n-expiration = 3600\n+expiration = 7200\n-caching = true\n" +
+				"[ldap]\n+url = ldap://a\n+url = ldap://b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			changed, diff := DiffServiceConfigs(tt.oldConfig, tt.newConfig)
+			g.Expect(changed).To(Equal(tt.wantChanged))
+			g.Expect(diff).To(Equal(tt.wantDiff))
+		})
+	}
+}
+
+func TestValidateCustomServiceConfigLayers(t *testing.T) {
+	secretRef := &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "site"},
+		Key:                  "custom.conf",
+	}
+	configMapRef := &corev1.ConfigMapKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "base"},
+		Key:                  "custom.conf",
+	}
+
+	tests := []struct {
+		name     string
+		layers   []ServiceConfigLayer
+		wantErrs int
+	}{
+		{
+			name:     "No layers",
+			wantErrs: 0,
+		},
+		{
+			name:     "ConfigMap and Secret layers",
+			layers:   []ServiceConfigLayer{{ConfigMapKeyRef: configMapRef}, {SecretKeyRef: secretRef}},
+			wantErrs: 0,
+		},
+		{
+			name:     "Layers without or with both sources",
+			layers:   []ServiceConfigLayer{{}, {ConfigMapKeyRef: configMapRef, SecretKeyRef: secretRef}},
+			wantErrs: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := KeystoneAPISpecCore{CustomServiceConfigLayers: tt.layers}
+			g.Expect(spec.ValidateCustomServiceConfigLayers(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+func TestValidateCustomServiceConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		wantErrs int
+	}{
+		{
+			name:   "Empty",
+			config: "",
+		},
+		{
+			name: "Valid",
+			config: "# site overrides\n[DEFAULT]\ndebug = true\n\n[ldap]\nurl: ldap://a\n" +
+				"user_filter = (memberOf=cn=keystone)\n[oslo_policy]\npolicy_dirs = a,\n    b\n",
+		},
+		{
+			name: "Sections of libraries and plugins",
+			config: "[oslo_concurrency]\nlock_path = /var/lib/keystone/tmp\n" +
+				"[keystone_authtoken]\nmemcached_servers = memcached:11211\n",
+		},
+		{
+			name:   "Sample generated by oslo-config-generator",
+			config: keystoneConfSample,
+		},
+		{
+			name:     "Option before the first section",
+			config:   "debug = true\n[DEFAULT]\n",
+			wantErrs: 1,
+		},
+		{
+			name:     "Invalid section headers",
+			config:   "[token\nexpiration = 7200\n[]\n",
+			wantErrs: 3,
+		},
+		{
+			name:     "Missing separator, empty option name and unexpected continuation",
+			config:   "[ldap]\npassword secret\n= value\n\n  url = ldap://a\n",
+			wantErrs: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := KeystoneAPISpecCore{CustomServiceConfig: tt.config}
+			g.Expect(spec.ValidateCustomServiceConfig(field.NewPath("spec"))).To(HaveLen(tt.wantErrs))
+		})
+	}
+}
+
+// keystoneConfSample - excerpt of the keystone.conf.sample oslo-config-generator
+// generates for keystone, with the options of some sections set
+const keystoneConfSample = `[DEFAULT]
+
+#
+# From keystone
+#
+
+# Using this feature is *NOT* recommended. Instead, use the ` + "`" + `keystone-manage
+# bootstrap` + "`" + ` command. The value of this option is treated as a "shared secret"
+# that can be used to bootstrap Keystone through the API. (string value)
+#admin_token = <None>
+
+# The base public endpoint URL for Keystone that is advertised to clients
+# (NOTE: this does NOT affect how Keystone listens for connections). (string
+# value)
+#public_endpoint = <None>
+
+# Maximum number of entities that will be returned in a collection. (integer
+# value)
+#list_limit = <None>
+
+#
+# From oslo.log
+#
+
+# If set to true, the logging level will be set to DEBUG instead of the default
+# INFO level. (boolean value)
+# Note: This option can be changed without restarting.
+debug = true
+
+# List of package logging levels in logger=LEVEL pairs. (list value)
+#default_log_levels = amqp=WARN,amqplib=WARN,boto=WARN,qpid=WARN,sqlalchemy=WARN,suds=INFO,oslo.messaging=INFO,oslo_messaging=INFO,iso8601=WARN,requests.packages.urllib3.connectionpool=WARN,urllib3.connectionpool=WARN,websocket=WARN,requests.packages.urllib3.util.retry=WARN,urllib3.util.retry=WARN,keystonemiddleware=WARN,routes.middleware=WARN,stevedore=WARN,taskflow=WARN,keystoneauth=WARN,oslo.cache=INFO,oslo_policy=INFO,dogpile.core.dogpile=INFO
+
+
+[application_credential]
+
+#
+# From keystone
+#
+
+# Entry point for the application credential backend driver in the
+# ` + "`" + `keystone.application_credential` + "`" + ` namespace.  Keystone only provides a ` + "`" + `sql` + "`" + `
+# driver, so there is no reason to change this unless you are providing a
+# custom entry point. (string value)
+#driver = sql
+
+# Maximum number of application credentials a user is permitted to create. A
+# value of -1 means unlimited. If a limit is not set, users are permitted to
+# create application credentials at will, which could lead to bloat in the
+# keystone database or open keystone to a DoS attack. (integer value)
+user_limit = 10
+
+
+[cache]
+
+#
+# From oslo.cache
+#
+
+# Prefix for building the configuration dictionary for the cache region. This
+# should not need to be changed unless there is another dogpile.cache region
+# with the same configuration name. (string value)
+#config_prefix = cache.oslo
+
+# Cache backend module. For deployments with multiple API processes or
+# hosts, oslo_cache.memcache_pool and dogpile.cache.memcached are recommended.
+# (string value)
+# Possible values:
+# oslo_cache.memcache_pool - <No description provided>
+# dogpile.cache.memcached - <No description provided>
+# dogpile.cache.null - <No description provided>
+backend = dogpile.cache.memcached
+
+# Memcache servers in the format of "host:port". This is used by backends
+# dependent on Memcached. (list value)
+memcache_servers = memcached-0.memcached:11211,memcached-1.memcached:11211
+
+
+[database]
+
+#
+# From oslo.db
+#
+
+# If True, SQLite uses synchronous mode. (boolean value)
+#sqlite_synchronous = true
+
+# The SQLAlchemy connection string to use to connect to the database. (string
+# value)
+# Deprecated group/name - [DEFAULT]/sql_connection
+# Deprecated group/name - [DATABASE]/sql_connection
+# Deprecated group/name - [sql]/connection
+#connection = <None>
+
+# Maximum number of database connection retries during startup. Set to -1 to
+# specify an infinite retry count. (integer value)
+# Deprecated group/name - [DEFAULT]/sql_max_retries
+# Deprecated group/name - [DATABASE]/sql_max_retries
+max_retries = -1
+
+
+[identity]
+
+#
+# From keystone
+#
+
+# This references the domain to use for all Identity API v2 requests (which
+# are not aware of domains). (string value)
+#default_domain_id = default
+
+# A subset (or all) of domains can have their own identity driver, each with
+# their own partial configuration options, stored in either the resource
+# backend or in a file in a domain configuration directory. (boolean value)
+domain_specific_drivers_enabled = true
+
+# Path for Keystone to locate the domain specific identity configuration files
+# if ` + "`" + `domain_specific_drivers_enabled` + "`" + ` is set to true. (string value)
+domain_config_dir = /etc/keystone/domains
+
+
+[oslo_messaging_notifications]
+
+#
+# From oslo.messaging
+#
+
+# The Drivers(s) to handle sending notifications. Possible values are
+# messaging, messagingv2, routing, log, test, noop (multi valued)
+# Deprecated group/name - [DEFAULT]/notification_driver
+driver = messagingv2
+
+# AMQP topic used for OpenStack notifications. (list value)
+# Deprecated group/name - [rpc_notifier2]/topics
+# Deprecated group/name - [DEFAULT]/notification_topics
+#topics = notifications
+
+
+[oslo_middleware]
+
+#
+# From oslo.middleware
+#
+
+# Whether the application is behind a proxy or not. This determines if the
+# middleware should parse the headers or not. (boolean value)
+enable_proxy_headers_parsing = true
+
+
+[oslo_policy]
+
+#
+# From oslo.policy
+#
+
+# This option controls whether or not to enforce scope when evaluating
+# policies. If ` + "`" + `` + "`" + `True` + "`" + `` + "`" + `, the scope of the token used in the request is compared
+# to the ` + "`" + `` + "`" + `scope_types` + "`" + `` + "`" + ` of the policy being enforced. (boolean value)
+enforce_scope = true
+
+# This option controls whether or not to use old deprecated defaults when
+# evaluating policies. (boolean value)
+enforce_new_defaults = true
+
+
+[security_compliance]
+
+#
+# From keystone
+#
+
+# The maximum number of days a user can go without authenticating before being
+# considered "inactive" and automatically disabled (locked). (integer value)
+# Minimum value: 1
+disable_user_account_days_inactive = 90
+
+# The regular expression used to validate password strength requirements.
+# (string value)
+password_regex = ^(?=.*\d)(?=.*[a-zA-Z]).{7,}$
+
+
+[token]
+
+#
+# From keystone
+#
+
+# The amount of time that a token should remain valid (in seconds). (integer
+# value)
+# Minimum value: 0
+# Maximum value: 9223372036854775807
+expiration = 7200
+`
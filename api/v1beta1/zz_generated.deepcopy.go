@@ -25,6 +25,7 @@ import (
 	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
 	"github.com/openstack-k8s-operators/lib-common/modules/storage"
+	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -50,9 +51,212 @@ func (in *APIOverrideSpec) DeepCopy() *APIOverrideSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdminClientSpec) DeepCopyInto(out *AdminClientSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdminClientSpec.
+func (in *AdminClientSpec) DeepCopy() *AdminClientSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AdminClientSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogShipperSpec) DeepCopyInto(out *AuditLogShipperSpec) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogShipperSpec.
+func (in *AuditLogShipperSpec) DeepCopy() *AuditLogShipperSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogShipperSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogSpec) DeepCopyInto(out *AuditLogSpec) {
+	*out = *in
+	if in.Shipper != nil {
+		in, out := &in.Shipper, &out.Shipper
+		*out = new(AuditLogShipperSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogSpec.
+func (in *AuditLogSpec) DeepCopy() *AuditLogSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalingSpec) DeepCopyInto(out *AutoscalingSpec) {
+	*out = *in
+	if in.CPUUtilization != nil {
+		in, out := &in.CPUUtilization, &out.CPUUtilization
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalingSpec.
+func (in *AutoscalingSpec) DeepCopy() *AutoscalingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreenSpec) DeepCopyInto(out *BlueGreenSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreenSpec.
+func (in *BlueGreenSpec) DeepCopy() *BlueGreenSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreenSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogAuditFinding) DeepCopyInto(out *CatalogAuditFinding) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogAuditFinding.
+func (in *CatalogAuditFinding) DeepCopy() *CatalogAuditFinding {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogAuditFinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudEventsSpec) DeepCopyInto(out *CloudEventsSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudEventsSpec.
+func (in *CloudEventsSpec) DeepCopy() *CloudEventsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudEventsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigPreviewStatus) DeepCopyInto(out *ConfigPreviewStatus) {
+	*out = *in
+	if in.ChangedOptions != nil {
+		in, out := &in.ChangedOptions, &out.ChangedOptions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigPreviewStatus.
+func (in *ConfigPreviewStatus) DeepCopy() *ConfigPreviewStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigPreviewStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseReaderSpec) DeepCopyInto(out *DatabaseReaderSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseReaderSpec.
+func (in *DatabaseReaderSpec) DeepCopy() *DatabaseReaderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseReaderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DomainConfigSource) DeepCopyInto(out *DomainConfigSource) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LDAP != nil {
+		in, out := &in.LDAP, &out.LDAP
+		*out = new(LDAPDomainSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DomainConfigSource.
+func (in *DomainConfigSource) DeepCopy() *DomainConfigSource {
+	if in == nil {
+		return nil
+	}
+	out := new(DomainConfigSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Endpoint) DeepCopyInto(out *Endpoint) {
 	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.URLChangeTime != nil {
+		in, out := &in.URLChangeTime, &out.URLChangeTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Endpoint.
@@ -65,6 +269,45 @@ func (in *Endpoint) DeepCopy() *Endpoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FernetKeysDistributionStatus) DeepCopyInto(out *FernetKeysDistributionStatus) {
+	*out = *in
+	if in.DistributedTime != nil {
+		in, out := &in.DistributedTime, &out.DistributedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FernetKeysDistributionStatus.
+func (in *FernetKeysDistributionStatus) DeepCopy() *FernetKeysDistributionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FernetKeysDistributionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthcheckSpec) DeepCopyInto(out *HealthcheckSpec) {
+	*out = *in
+	if in.Backends != nil {
+		in, out := &in.Backends, &out.Backends
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthcheckSpec.
+func (in *HealthcheckSpec) DeepCopy() *HealthcheckSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthcheckSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HttpdCustomization) DeepCopyInto(out *HttpdCustomization) {
 	*out = *in
@@ -90,6 +333,82 @@ func (in *HttpdCustomization) DeepCopy() *HttpdCustomization {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageVerificationSpec) DeepCopyInto(out *ImageVerificationSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageVerificationSpec.
+func (in *ImageVerificationSpec) DeepCopy() *ImageVerificationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageVerificationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImpliedRole) DeepCopyInto(out *ImpliedRole) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImpliedRole.
+func (in *ImpliedRole) DeepCopy() *ImpliedRole {
+	if in == nil {
+		return nil
+	}
+	out := new(ImpliedRole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobSettings) DeepCopyInto(out *JobSettings) {
+	*out = *in
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobSettings.
+func (in *JobSettings) DeepCopy() *JobSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(JobSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KerberosSpec) DeepCopyInto(out *KerberosSpec) {
+	*out = *in
+	in.KeytabSecretRef.DeepCopyInto(&out.KeytabSecretRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KerberosSpec.
+func (in *KerberosSpec) DeepCopy() *KerberosSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KerberosSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeystoneAPI) DeepCopyInto(out *KeystoneAPI) {
 	*out = *in
@@ -183,11 +502,41 @@ func (in *KeystoneAPISpec) DeepCopy() *KeystoneAPISpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeystoneAPISpecCore) DeepCopyInto(out *KeystoneAPISpecCore) {
 	*out = *in
+	if in.DatabaseReader != nil {
+		in, out := &in.DatabaseReader, &out.DatabaseReader
+		*out = new(DatabaseReaderSpec)
+		**out = **in
+	}
+	if in.PostgreSQL != nil {
+		in, out := &in.PostgreSQL, &out.PostgreSQL
+		*out = new(PostgreSQLSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TokenSmokeTest != nil {
+		in, out := &in.TokenSmokeTest, &out.TokenSmokeTest
+		*out = new(TokenSmokeTestSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdminClient != nil {
+		in, out := &in.AdminClient, &out.AdminClient
+		*out = new(AdminClientSpec)
+		**out = **in
+	}
 	if in.Replicas != nil {
 		in, out := &in.Replicas, &out.Replicas
 		*out = new(int32)
 		**out = **in
 	}
+	if in.TrustFlushSuccessfulJobsHistoryLimit != nil {
+		in, out := &in.TrustFlushSuccessfulJobsHistoryLimit, &out.TrustFlushSuccessfulJobsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TrustFlushFailedJobsHistoryLimit != nil {
+		in, out := &in.TrustFlushFailedJobsHistoryLimit, &out.TrustFlushFailedJobsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
 	if in.FernetRotationDays != nil {
 		in, out := &in.FernetRotationDays, &out.FernetRotationDays
 		*out = new(int32)
@@ -210,6 +559,63 @@ func (in *KeystoneAPISpecCore) DeepCopyInto(out *KeystoneAPISpecCore) {
 			}
 		}
 	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.RuntimeClassName != nil {
+		in, out := &in.RuntimeClassName, &out.RuntimeClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.DNSConfig != nil {
+		in, out := &in.DNSConfig, &out.DNSConfig
+		*out = new(v1.PodDNSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HostAliases != nil {
+		in, out := &in.HostAliases, &out.HostAliases
+		*out = make([]v1.HostAlias, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExtraEnv != nil {
+		in, out := &in.ExtraEnv, &out.ExtraEnv
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.JobSettings.DeepCopyInto(&out.JobSettings)
+	if in.Autoscaling != nil {
+		in, out := &in.Autoscaling, &out.Autoscaling
+		*out = new(AutoscalingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageVerification != nil {
+		in, out := &in.ImageVerification, &out.ImageVerification
+		*out = new(ImageVerificationSpec)
+		**out = **in
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(BlueGreenSpec)
+		**out = **in
+	}
+	if in.PublicDeployment != nil {
+		in, out := &in.PublicDeployment, &out.PublicDeployment
+		*out = new(PublicDeploymentSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CustomServiceConfigLayers != nil {
+		in, out := &in.CustomServiceConfigLayers, &out.CustomServiceConfigLayers
+		*out = make([]ServiceConfigLayer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.DefaultConfigOverwrite != nil {
 		in, out := &in.DefaultConfigOverwrite, &out.DefaultConfigOverwrite
 		*out = make(map[string]string, len(*in))
@@ -218,6 +624,12 @@ func (in *KeystoneAPISpecCore) DeepCopyInto(out *KeystoneAPISpecCore) {
 		}
 	}
 	in.HttpdCustomization.DeepCopyInto(&out.HttpdCustomization)
+	if in.ZoneSpreadMaxSkew != nil {
+		in, out := &in.ZoneSpreadMaxSkew, &out.ZoneSpreadMaxSkew
+		*out = new(int32)
+		**out = **in
+	}
+	in.Healthcheck.DeepCopyInto(&out.Healthcheck)
 	in.Resources.DeepCopyInto(&out.Resources)
 	if in.NetworkAttachments != nil {
 		in, out := &in.NetworkAttachments, &out.NetworkAttachments
@@ -225,7 +637,66 @@ func (in *KeystoneAPISpecCore) DeepCopyInto(out *KeystoneAPISpecCore) {
 		copy(*out, *in)
 	}
 	in.Override.DeepCopyInto(&out.Override)
+	if in.PublishedEndpoints != nil {
+		in, out := &in.PublishedEndpoints, &out.PublishedEndpoints
+		*out = make(map[service.Endpoint]PublishedEndpointSpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	in.TLS.DeepCopyInto(&out.TLS)
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(RateLimitSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RouteTLS != nil {
+		in, out := &in.RouteTLS, &out.RouteTLS
+		*out = make(map[service.Endpoint]RouteTLSSpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PublicDNS != nil {
+		in, out := &in.PublicDNS, &out.PublicDNS
+		*out = new(PublicDNSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PublicSecurity != nil {
+		in, out := &in.PublicSecurity, &out.PublicSecurity
+		*out = new(PublicSecuritySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AuditLog != nil {
+		in, out := &in.AuditLog, &out.AuditLog
+		*out = new(AuditLogSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = new(NotificationsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Kerberos != nil {
+		in, out := &in.Kerberos, &out.Kerberos
+		*out = new(KerberosSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TokenlessAuth != nil {
+		in, out := &in.TokenlessAuth, &out.TokenlessAuth
+		*out = new(TokenlessAuthSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MFA != nil {
+		in, out := &in.MFA, &out.MFA
+		*out = new(MFASpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Token != nil {
+		in, out := &in.Token, &out.Token
+		*out = new(TokenSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.TopologyRef != nil {
 		in, out := &in.TopologyRef, &out.TopologyRef
 		*out = new(topologyv1beta1.TopoRef)
@@ -238,6 +709,33 @@ func (in *KeystoneAPISpecCore) DeepCopyInto(out *KeystoneAPISpecCore) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.FederationTrustedDashboards != nil {
+		in, out := &in.FederationTrustedDashboards, &out.FederationTrustedDashboards
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImpliedRoles != nil {
+		in, out := &in.ImpliedRoles, &out.ImpliedRoles
+		*out = make([]ImpliedRole, len(*in))
+		copy(*out, *in)
+	}
+	if in.DomainConfigs != nil {
+		in, out := &in.DomainConfigs, &out.DomainConfigs
+		*out = make(map[string]DomainConfigSource, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Drivers != nil {
+		in, out := &in.Drivers, &out.Drivers
+		*out = new(KeystoneDrivers)
+		**out = **in
+	}
+	if in.CloudEvents != nil {
+		in, out := &in.CloudEvents, &out.CloudEvents
+		*out = new(CloudEventsSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneAPISpecCore.
@@ -267,6 +765,13 @@ func (in *KeystoneAPIStatus) DeepCopyInto(out *KeystoneAPIStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.ServiceEndpoints != nil {
+		in, out := &in.ServiceEndpoints, &out.ServiceEndpoints
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(condition.Conditions, len(*in))
@@ -290,11 +795,62 @@ func (in *KeystoneAPIStatus) DeepCopyInto(out *KeystoneAPIStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
+	}
 	if in.LastAppliedTopology != nil {
 		in, out := &in.LastAppliedTopology, &out.LastAppliedTopology
 		*out = new(topologyv1beta1.TopoRef)
 		**out = **in
 	}
+	if in.TrustFlushLastSuccessfulTime != nil {
+		in, out := &in.TrustFlushLastSuccessfulTime, &out.TrustFlushLastSuccessfulTime
+		*out = (*in).DeepCopy()
+	}
+	if in.TrustFlushLastScheduleTime != nil {
+		in, out := &in.TrustFlushLastScheduleTime, &out.TrustFlushLastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ImpliedRoles != nil {
+		in, out := &in.ImpliedRoles, &out.ImpliedRoles
+		*out = make([]ImpliedRole, len(*in))
+		copy(*out, *in)
+	}
+	if in.TokenSmokeTest != nil {
+		in, out := &in.TokenSmokeTest, &out.TokenSmokeTest
+		*out = new(TokenSmokeTestStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Children != nil {
+		in, out := &in.Children, &out.Children
+		*out = new(KeystoneChildrenStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WebSSO != nil {
+		in, out := &in.WebSSO, &out.WebSSO
+		*out = new(WebSSOStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TokenRevocation != nil {
+		in, out := &in.TokenRevocation, &out.TokenRevocation
+		*out = new(TokenRevocationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FernetKeysDistribution != nil {
+		in, out := &in.FernetKeysDistribution, &out.FernetKeysDistribution
+		*out = new(FernetKeysDistributionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigPreview != nil {
+		in, out := &in.ConfigPreview, &out.ConfigPreview
+		*out = new(ConfigPreviewStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneAPIStatus.
@@ -308,7 +864,7 @@ func (in *KeystoneAPIStatus) DeepCopy() *KeystoneAPIStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneEndpoint) DeepCopyInto(out *KeystoneEndpoint) {
+func (in *KeystoneCatalog) DeepCopyInto(out *KeystoneCatalog) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -316,18 +872,18 @@ func (in *KeystoneEndpoint) DeepCopyInto(out *KeystoneEndpoint) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpoint.
-func (in *KeystoneEndpoint) DeepCopy() *KeystoneEndpoint {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalog.
+func (in *KeystoneCatalog) DeepCopy() *KeystoneCatalog {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneEndpoint)
+	out := new(KeystoneCatalog)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KeystoneEndpoint) DeepCopyObject() runtime.Object {
+func (in *KeystoneCatalog) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -335,65 +891,58 @@ func (in *KeystoneEndpoint) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneEndpointHelper) DeepCopyInto(out *KeystoneEndpointHelper) {
+func (in *KeystoneCatalogAudit) DeepCopyInto(out *KeystoneCatalogAudit) {
 	*out = *in
-	if in.endpoint != nil {
-		in, out := &in.endpoint, &out.endpoint
-		*out = new(KeystoneEndpoint)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.labels != nil {
-		in, out := &in.labels, &out.labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.id != nil {
-		in, out := &in.id, &out.id
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointHelper.
-func (in *KeystoneEndpointHelper) DeepCopy() *KeystoneEndpointHelper {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalogAudit.
+func (in *KeystoneCatalogAudit) DeepCopy() *KeystoneCatalogAudit {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneEndpointHelper)
+	out := new(KeystoneCatalogAudit)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneCatalogAudit) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneEndpointList) DeepCopyInto(out *KeystoneEndpointList) {
+func (in *KeystoneCatalogAuditList) DeepCopyInto(out *KeystoneCatalogAuditList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]KeystoneEndpoint, len(*in))
+		*out = make([]KeystoneCatalogAudit, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointList.
-func (in *KeystoneEndpointList) DeepCopy() *KeystoneEndpointList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalogAuditList.
+func (in *KeystoneCatalogAuditList) DeepCopy() *KeystoneCatalogAuditList {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneEndpointList)
+	out := new(KeystoneCatalogAuditList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KeystoneEndpointList) DeepCopyObject() runtime.Object {
+func (in *KeystoneCatalogAuditList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -401,36 +950,31 @@ func (in *KeystoneEndpointList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneEndpointSpec) DeepCopyInto(out *KeystoneEndpointSpec) {
+func (in *KeystoneCatalogAuditSpec) DeepCopyInto(out *KeystoneCatalogAuditSpec) {
 	*out = *in
-	if in.Endpoints != nil {
-		in, out := &in.Endpoints, &out.Endpoints
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointSpec.
-func (in *KeystoneEndpointSpec) DeepCopy() *KeystoneEndpointSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalogAuditSpec.
+func (in *KeystoneCatalogAuditSpec) DeepCopy() *KeystoneCatalogAuditSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneEndpointSpec)
+	out := new(KeystoneCatalogAuditSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneEndpointStatus) DeepCopyInto(out *KeystoneEndpointStatus) {
+func (in *KeystoneCatalogAuditStatus) DeepCopyInto(out *KeystoneCatalogAuditStatus) {
 	*out = *in
-	if in.EndpointIDs != nil {
-		in, out := &in.EndpointIDs, &out.EndpointIDs
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.LastAuditTime != nil {
+		in, out := &in.LastAuditTime, &out.LastAuditTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Findings != nil {
+		in, out := &in.Findings, &out.Findings
+		*out = make([]CatalogAuditFinding, len(*in))
+		copy(*out, *in)
 	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
@@ -439,66 +983,52 @@ func (in *KeystoneEndpointStatus) DeepCopyInto(out *KeystoneEndpointStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.Endpoints != nil {
-		in, out := &in.Endpoints, &out.Endpoints
-		*out = make([]Endpoint, len(*in))
-		copy(*out, *in)
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointStatus.
-func (in *KeystoneEndpointStatus) DeepCopy() *KeystoneEndpointStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalogAuditStatus.
+func (in *KeystoneCatalogAuditStatus) DeepCopy() *KeystoneCatalogAuditStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneEndpointStatus)
+	out := new(KeystoneCatalogAuditStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneExtraMounts) DeepCopyInto(out *KeystoneExtraMounts) {
+func (in *KeystoneCatalogList) DeepCopyInto(out *KeystoneCatalogList) {
 	*out = *in
-	if in.VolMounts != nil {
-		in, out := &in.VolMounts, &out.VolMounts
-		*out = make([]storage.VolMounts, len(*in))
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneCatalog, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneExtraMounts.
-func (in *KeystoneExtraMounts) DeepCopy() *KeystoneExtraMounts {
-	if in == nil {
-		return nil
-	}
-	out := new(KeystoneExtraMounts)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneService) DeepCopyInto(out *KeystoneService) {
-	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	in.Status.DeepCopyInto(&out.Status)
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneService.
-func (in *KeystoneService) DeepCopy() *KeystoneService {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalogList.
+func (in *KeystoneCatalogList) DeepCopy() *KeystoneCatalogList {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneService)
+	out := new(KeystoneCatalogList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KeystoneService) DeepCopyObject() runtime.Object {
+func (in *KeystoneCatalogList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -506,15 +1036,10 @@ func (in *KeystoneService) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneServiceHelper) DeepCopyInto(out *KeystoneServiceHelper) {
+func (in *KeystoneCatalogService) DeepCopyInto(out *KeystoneCatalogService) {
 	*out = *in
-	if in.service != nil {
-		in, out := &in.service, &out.service
-		*out = new(KeystoneService)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.labels != nil {
-		in, out := &in.labels, &out.labels
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
 		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
@@ -522,66 +1047,77 @@ func (in *KeystoneServiceHelper) DeepCopyInto(out *KeystoneServiceHelper) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneServiceHelper.
-func (in *KeystoneServiceHelper) DeepCopy() *KeystoneServiceHelper {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalogService.
+func (in *KeystoneCatalogService) DeepCopy() *KeystoneCatalogService {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneServiceHelper)
+	out := new(KeystoneCatalogService)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneServiceList) DeepCopyInto(out *KeystoneServiceList) {
+func (in *KeystoneCatalogServiceStatus) DeepCopyInto(out *KeystoneCatalogServiceStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]KeystoneService, len(*in))
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]Endpoint, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneServiceList.
-func (in *KeystoneServiceList) DeepCopy() *KeystoneServiceList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalogServiceStatus.
+func (in *KeystoneCatalogServiceStatus) DeepCopy() *KeystoneCatalogServiceStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneServiceList)
+	out := new(KeystoneCatalogServiceStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KeystoneServiceList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneServiceSpec) DeepCopyInto(out *KeystoneServiceSpec) {
+func (in *KeystoneCatalogSpec) DeepCopyInto(out *KeystoneCatalogSpec) {
 	*out = *in
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]KeystoneCatalogService, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.URLVariables != nil {
+		in, out := &in.URLVariables, &out.URLVariables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneServiceSpec.
-func (in *KeystoneServiceSpec) DeepCopy() *KeystoneServiceSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalogSpec.
+func (in *KeystoneCatalogSpec) DeepCopy() *KeystoneCatalogSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneServiceSpec)
+	out := new(KeystoneCatalogSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneServiceStatus) DeepCopyInto(out *KeystoneServiceStatus) {
+func (in *KeystoneCatalogStatus) DeepCopyInto(out *KeystoneCatalogStatus) {
 	*out = *in
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]KeystoneCatalogServiceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(condition.Conditions, len(*in))
@@ -589,29 +1125,2187 @@ func (in *KeystoneServiceStatus) DeepCopyInto(out *KeystoneServiceStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneServiceStatus.
-func (in *KeystoneServiceStatus) DeepCopy() *KeystoneServiceStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalogStatus.
+func (in *KeystoneCatalogStatus) DeepCopy() *KeystoneCatalogStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneServiceStatus)
+	out := new(KeystoneCatalogStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PasswordSelector) DeepCopyInto(out *PasswordSelector) {
+func (in *KeystoneChildrenStatus) DeepCopyInto(out *KeystoneChildrenStatus) {
+	*out = *in
+	if in.Failing != nil {
+		in, out := &in.Failing, &out.Failing
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneChildrenStatus.
+func (in *KeystoneChildrenStatus) DeepCopy() *KeystoneChildrenStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneChildrenStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneCredential) DeepCopyInto(out *KeystoneCredential) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PasswordSelector.
-func (in *PasswordSelector) DeepCopy() *PasswordSelector {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCredential.
+func (in *KeystoneCredential) DeepCopy() *KeystoneCredential {
 	if in == nil {
 		return nil
 	}
-	out := new(PasswordSelector)
+	out := new(KeystoneCredential)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneCredential) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneCredentialList) DeepCopyInto(out *KeystoneCredentialList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneCredential, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCredentialList.
+func (in *KeystoneCredentialList) DeepCopy() *KeystoneCredentialList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneCredentialList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneCredentialList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneCredentialSpec) DeepCopyInto(out *KeystoneCredentialSpec) {
+	*out = *in
+	in.BlobSecretRef.DeepCopyInto(&out.BlobSecretRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCredentialSpec.
+func (in *KeystoneCredentialSpec) DeepCopy() *KeystoneCredentialSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneCredentialSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneCredentialStatus) DeepCopyInto(out *KeystoneCredentialStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCredentialStatus.
+func (in *KeystoneCredentialStatus) DeepCopy() *KeystoneCredentialStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneCredentialStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneDrivers) DeepCopyInto(out *KeystoneDrivers) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneDrivers.
+func (in *KeystoneDrivers) DeepCopy() *KeystoneDrivers {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneDrivers)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEC2Credential) DeepCopyInto(out *KeystoneEC2Credential) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEC2Credential.
+func (in *KeystoneEC2Credential) DeepCopy() *KeystoneEC2Credential {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEC2Credential)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneEC2Credential) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEC2CredentialList) DeepCopyInto(out *KeystoneEC2CredentialList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneEC2Credential, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEC2CredentialList.
+func (in *KeystoneEC2CredentialList) DeepCopy() *KeystoneEC2CredentialList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEC2CredentialList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneEC2CredentialList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEC2CredentialSpec) DeepCopyInto(out *KeystoneEC2CredentialSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEC2CredentialSpec.
+func (in *KeystoneEC2CredentialSpec) DeepCopy() *KeystoneEC2CredentialSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEC2CredentialSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEC2CredentialStatus) DeepCopyInto(out *KeystoneEC2CredentialStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEC2CredentialStatus.
+func (in *KeystoneEC2CredentialStatus) DeepCopy() *KeystoneEC2CredentialStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEC2CredentialStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpoint) DeepCopyInto(out *KeystoneEndpoint) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpoint.
+func (in *KeystoneEndpoint) DeepCopy() *KeystoneEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneEndpoint) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointGroup) DeepCopyInto(out *KeystoneEndpointGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointGroup.
+func (in *KeystoneEndpointGroup) DeepCopy() *KeystoneEndpointGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneEndpointGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointGroupFilters) DeepCopyInto(out *KeystoneEndpointGroupFilters) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointGroupFilters.
+func (in *KeystoneEndpointGroupFilters) DeepCopy() *KeystoneEndpointGroupFilters {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointGroupFilters)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointGroupList) DeepCopyInto(out *KeystoneEndpointGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneEndpointGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointGroupList.
+func (in *KeystoneEndpointGroupList) DeepCopy() *KeystoneEndpointGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneEndpointGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointGroupSpec) DeepCopyInto(out *KeystoneEndpointGroupSpec) {
+	*out = *in
+	out.Filters = in.Filters
+	if in.Projects != nil {
+		in, out := &in.Projects, &out.Projects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointGroupSpec.
+func (in *KeystoneEndpointGroupSpec) DeepCopy() *KeystoneEndpointGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointGroupStatus) DeepCopyInto(out *KeystoneEndpointGroupStatus) {
+	*out = *in
+	if in.ProjectIDs != nil {
+		in, out := &in.ProjectIDs, &out.ProjectIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointGroupStatus.
+func (in *KeystoneEndpointGroupStatus) DeepCopy() *KeystoneEndpointGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointHelper) DeepCopyInto(out *KeystoneEndpointHelper) {
+	*out = *in
+	if in.endpoint != nil {
+		in, out := &in.endpoint, &out.endpoint
+		*out = new(KeystoneEndpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.labels != nil {
+		in, out := &in.labels, &out.labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.id != nil {
+		in, out := &in.id, &out.id
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointHelper.
+func (in *KeystoneEndpointHelper) DeepCopy() *KeystoneEndpointHelper {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointHelper)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointList) DeepCopyInto(out *KeystoneEndpointList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneEndpoint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointList.
+func (in *KeystoneEndpointList) DeepCopy() *KeystoneEndpointList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneEndpointList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointReadinessGate) DeepCopyInto(out *KeystoneEndpointReadinessGate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointReadinessGate.
+func (in *KeystoneEndpointReadinessGate) DeepCopy() *KeystoneEndpointReadinessGate {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointReadinessGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointSpec) DeepCopyInto(out *KeystoneEndpointSpec) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.URLVariables != nil {
+		in, out := &in.URLVariables, &out.URLVariables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ReadinessGate != nil {
+		in, out := &in.ReadinessGate, &out.ReadinessGate
+		*out = new(KeystoneEndpointReadinessGate)
+		**out = **in
+	}
+	if in.URLRollback != nil {
+		in, out := &in.URLRollback, &out.URLRollback
+		*out = new(KeystoneEndpointURLRollback)
+		**out = **in
+	}
+	if in.RegionalEndpoints != nil {
+		in, out := &in.RegionalEndpoints, &out.RegionalEndpoints
+		*out = make([]RegionalEndpointSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointSpec.
+func (in *KeystoneEndpointSpec) DeepCopy() *KeystoneEndpointSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointStatus) DeepCopyInto(out *KeystoneEndpointStatus) {
+	*out = *in
+	if in.EndpointIDs != nil {
+		in, out := &in.EndpointIDs, &out.EndpointIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]Endpoint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RegionalEndpoints != nil {
+		in, out := &in.RegionalEndpoints, &out.RegionalEndpoints
+		*out = make([]Endpoint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PendingPruneRegions != nil {
+		in, out := &in.PendingPruneRegions, &out.PendingPruneRegions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointStatus.
+func (in *KeystoneEndpointStatus) DeepCopy() *KeystoneEndpointStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointURLRollback) DeepCopyInto(out *KeystoneEndpointURLRollback) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointURLRollback.
+func (in *KeystoneEndpointURLRollback) DeepCopy() *KeystoneEndpointURLRollback {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointURLRollback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneExtraMounts) DeepCopyInto(out *KeystoneExtraMounts) {
+	*out = *in
+	if in.VolMounts != nil {
+		in, out := &in.VolMounts, &out.VolMounts
+		*out = make([]storage.VolMounts, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneExtraMounts.
+func (in *KeystoneExtraMounts) DeepCopy() *KeystoneExtraMounts {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneExtraMounts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneLimit) DeepCopyInto(out *KeystoneLimit) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneLimit.
+func (in *KeystoneLimit) DeepCopy() *KeystoneLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneLimit) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneLimitList) DeepCopyInto(out *KeystoneLimitList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneLimit, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneLimitList.
+func (in *KeystoneLimitList) DeepCopy() *KeystoneLimitList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneLimitList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneLimitList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneLimitSpec) DeepCopyInto(out *KeystoneLimitSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneLimitSpec.
+func (in *KeystoneLimitSpec) DeepCopy() *KeystoneLimitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneLimitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneLimitStatus) DeepCopyInto(out *KeystoneLimitStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneLimitStatus.
+func (in *KeystoneLimitStatus) DeepCopy() *KeystoneLimitStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneLimitStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneMapping) DeepCopyInto(out *KeystoneMapping) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneMapping.
+func (in *KeystoneMapping) DeepCopy() *KeystoneMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneMapping) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneMappingList) DeepCopyInto(out *KeystoneMappingList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneMapping, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneMappingList.
+func (in *KeystoneMappingList) DeepCopy() *KeystoneMappingList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneMappingList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneMappingList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneMappingSpec) DeepCopyInto(out *KeystoneMappingSpec) {
+	*out = *in
+	if in.ClaimMappings != nil {
+		in, out := &in.ClaimMappings, &out.ClaimMappings
+		*out = make([]MappingClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AutoProvisioning != nil {
+		in, out := &in.AutoProvisioning, &out.AutoProvisioning
+		*out = new(MappingAutoProvisioning)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IdentityProvider != nil {
+		in, out := &in.IdentityProvider, &out.IdentityProvider
+		*out = new(MappingIdentityProvider)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ShadowUsers != nil {
+		in, out := &in.ShadowUsers, &out.ShadowUsers
+		*out = new(MappingShadowUsers)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneMappingSpec.
+func (in *KeystoneMappingSpec) DeepCopy() *KeystoneMappingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneMappingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneMappingStatus) DeepCopyInto(out *KeystoneMappingStatus) {
+	*out = *in
+	if in.ShadowUsers != nil {
+		in, out := &in.ShadowUsers, &out.ShadowUsers
+		*out = new(ShadowUsersStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneMappingStatus.
+func (in *KeystoneMappingStatus) DeepCopy() *KeystoneMappingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneMappingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneRegisteredLimit) DeepCopyInto(out *KeystoneRegisteredLimit) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneRegisteredLimit.
+func (in *KeystoneRegisteredLimit) DeepCopy() *KeystoneRegisteredLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneRegisteredLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneRegisteredLimit) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneRegisteredLimitList) DeepCopyInto(out *KeystoneRegisteredLimitList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneRegisteredLimit, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneRegisteredLimitList.
+func (in *KeystoneRegisteredLimitList) DeepCopy() *KeystoneRegisteredLimitList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneRegisteredLimitList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneRegisteredLimitList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneRegisteredLimitSpec) DeepCopyInto(out *KeystoneRegisteredLimitSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneRegisteredLimitSpec.
+func (in *KeystoneRegisteredLimitSpec) DeepCopy() *KeystoneRegisteredLimitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneRegisteredLimitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneRegisteredLimitStatus) DeepCopyInto(out *KeystoneRegisteredLimitStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneRegisteredLimitStatus.
+func (in *KeystoneRegisteredLimitStatus) DeepCopy() *KeystoneRegisteredLimitStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneRegisteredLimitStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneService) DeepCopyInto(out *KeystoneService) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneService.
+func (in *KeystoneService) DeepCopy() *KeystoneService {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneService) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneServiceHelper) DeepCopyInto(out *KeystoneServiceHelper) {
+	*out = *in
+	if in.service != nil {
+		in, out := &in.service, &out.service
+		*out = new(KeystoneService)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.labels != nil {
+		in, out := &in.labels, &out.labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneServiceHelper.
+func (in *KeystoneServiceHelper) DeepCopy() *KeystoneServiceHelper {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneServiceHelper)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneServiceList) DeepCopyInto(out *KeystoneServiceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneService, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneServiceList.
+func (in *KeystoneServiceList) DeepCopy() *KeystoneServiceList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneServiceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneServiceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneServiceSpec) DeepCopyInto(out *KeystoneServiceSpec) {
+	*out = *in
+	if in.SystemRoles != nil {
+		in, out := &in.SystemRoles, &out.SystemRoles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneServiceSpec.
+func (in *KeystoneServiceSpec) DeepCopy() *KeystoneServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneServiceStatus) DeepCopyInto(out *KeystoneServiceStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
+	}
+	if in.SystemRoles != nil {
+		in, out := &in.SystemRoles, &out.SystemRoles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneServiceStatus.
+func (in *KeystoneServiceStatus) DeepCopy() *KeystoneServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUser) DeepCopyInto(out *KeystoneUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUser.
+func (in *KeystoneUser) DeepCopy() *KeystoneUser {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUserBatch) DeepCopyInto(out *KeystoneUserBatch) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUserBatch.
+func (in *KeystoneUserBatch) DeepCopy() *KeystoneUserBatch {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUserBatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneUserBatch) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUserBatchFailure) DeepCopyInto(out *KeystoneUserBatchFailure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUserBatchFailure.
+func (in *KeystoneUserBatchFailure) DeepCopy() *KeystoneUserBatchFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUserBatchFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUserBatchList) DeepCopyInto(out *KeystoneUserBatchList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneUserBatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUserBatchList.
+func (in *KeystoneUserBatchList) DeepCopy() *KeystoneUserBatchList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUserBatchList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneUserBatchList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUserBatchSpec) DeepCopyInto(out *KeystoneUserBatchSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUserBatchSpec.
+func (in *KeystoneUserBatchSpec) DeepCopy() *KeystoneUserBatchSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUserBatchSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUserBatchStatus) DeepCopyInto(out *KeystoneUserBatchStatus) {
+	*out = *in
+	if in.Failures != nil {
+		in, out := &in.Failures, &out.Failures
+		*out = make([]KeystoneUserBatchFailure, len(*in))
+		copy(*out, *in)
+	}
+	if in.DomainIDs != nil {
+		in, out := &in.DomainIDs, &out.DomainIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUserBatchStatus.
+func (in *KeystoneUserBatchStatus) DeepCopy() *KeystoneUserBatchStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUserBatchStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUserList) DeepCopyInto(out *KeystoneUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUserList.
+func (in *KeystoneUserList) DeepCopy() *KeystoneUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUserOptions) DeepCopyInto(out *KeystoneUserOptions) {
+	*out = *in
+	if in.IgnoreChangePasswordUponFirstUse != nil {
+		in, out := &in.IgnoreChangePasswordUponFirstUse, &out.IgnoreChangePasswordUponFirstUse
+		*out = new(bool)
+		**out = **in
+	}
+	if in.IgnorePasswordExpiry != nil {
+		in, out := &in.IgnorePasswordExpiry, &out.IgnorePasswordExpiry
+		*out = new(bool)
+		**out = **in
+	}
+	if in.IgnoreLockoutFailureAttempts != nil {
+		in, out := &in.IgnoreLockoutFailureAttempts, &out.IgnoreLockoutFailureAttempts
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LockPassword != nil {
+		in, out := &in.LockPassword, &out.LockPassword
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MultiFactorAuthEnabled != nil {
+		in, out := &in.MultiFactorAuthEnabled, &out.MultiFactorAuthEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MultiFactorAuthRules != nil {
+		in, out := &in.MultiFactorAuthRules, &out.MultiFactorAuthRules
+		*out = make([][]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUserOptions.
+func (in *KeystoneUserOptions) DeepCopy() *KeystoneUserOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUserOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUserSpec) DeepCopyInto(out *KeystoneUserSpec) {
+	*out = *in
+	in.Options.DeepCopyInto(&out.Options)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUserSpec.
+func (in *KeystoneUserSpec) DeepCopy() *KeystoneUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUserStatus) DeepCopyInto(out *KeystoneUserStatus) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnabledChangeTime != nil {
+		in, out := &in.EnabledChangeTime, &out.EnabledChangeTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastDriftRepairTime != nil {
+		in, out := &in.LastDriftRepairTime, &out.LastDriftRepairTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUserStatus.
+func (in *KeystoneUserStatus) DeepCopy() *KeystoneUserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LDAPDomainSpec) DeepCopyInto(out *LDAPDomainSpec) {
+	*out = *in
+	if in.UsePool != nil {
+		in, out := &in.UsePool, &out.UsePool
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PoolSize != nil {
+		in, out := &in.PoolSize, &out.PoolSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PoolRetryMax != nil {
+		in, out := &in.PoolRetryMax, &out.PoolRetryMax
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ChaseReferrals != nil {
+		in, out := &in.ChaseReferrals, &out.ChaseReferrals
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CACertSecretRef != nil {
+		in, out := &in.CACertSecretRef, &out.CACertSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LDAPDomainSpec.
+func (in *LDAPDomainSpec) DeepCopy() *LDAPDomainSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LDAPDomainSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MFASpec) DeepCopyInto(out *MFASpec) {
+	*out = *in
+	if in.Methods != nil {
+		in, out := &in.Methods, &out.Methods
+		*out = make([]MFAMethod, len(*in))
+		copy(*out, *in)
+	}
+	if in.Receipt != nil {
+		in, out := &in.Receipt, &out.Receipt
+		*out = new(ReceiptSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MFASpec.
+func (in *MFASpec) DeepCopy() *MFASpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MFASpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MappingAutoProvisioning) DeepCopyInto(out *MappingAutoProvisioning) {
+	*out = *in
+	if in.Projects != nil {
+		in, out := &in.Projects, &out.Projects
+		*out = make([]MappingProject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MappingAutoProvisioning.
+func (in *MappingAutoProvisioning) DeepCopy() *MappingAutoProvisioning {
+	if in == nil {
+		return nil
+	}
+	out := new(MappingAutoProvisioning)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MappingClaim) DeepCopyInto(out *MappingClaim) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]MappingGroup, len(*in))
+		copy(*out, *in)
+	}
+	if in.Projects != nil {
+		in, out := &in.Projects, &out.Projects
+		*out = make([]MappingProject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MappingClaim.
+func (in *MappingClaim) DeepCopy() *MappingClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(MappingClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MappingGroup) DeepCopyInto(out *MappingGroup) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MappingGroup.
+func (in *MappingGroup) DeepCopy() *MappingGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(MappingGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MappingIdentityProvider) DeepCopyInto(out *MappingIdentityProvider) {
+	*out = *in
+	if in.RemoteIDs != nil {
+		in, out := &in.RemoteIDs, &out.RemoteIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MappingIdentityProvider.
+func (in *MappingIdentityProvider) DeepCopy() *MappingIdentityProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(MappingIdentityProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MappingProject) DeepCopyInto(out *MappingProject) {
+	*out = *in
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MappingProject.
+func (in *MappingProject) DeepCopy() *MappingProject {
+	if in == nil {
+		return nil
+	}
+	out := new(MappingProject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MappingShadowUsers) DeepCopyInto(out *MappingShadowUsers) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MappingShadowUsers.
+func (in *MappingShadowUsers) DeepCopy() *MappingShadowUsers {
+	if in == nil {
+		return nil
+	}
+	out := new(MappingShadowUsers)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModSecuritySpec) DeepCopyInto(out *ModSecuritySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModSecuritySpec.
+func (in *ModSecuritySpec) DeepCopy() *ModSecuritySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ModSecuritySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationRoute) DeepCopyInto(out *NotificationRoute) {
+	*out = *in
+	if in.EventTypes != nil {
+		in, out := &in.EventTypes, &out.EventTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationRoute.
+func (in *NotificationRoute) DeepCopy() *NotificationRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationsSpec) DeepCopyInto(out *NotificationsSpec) {
+	*out = *in
+	if in.OptOut != nil {
+		in, out := &in.OptOut, &out.OptOut
+		*out = make([]NotificationEventType, len(*in))
+		copy(*out, *in)
+	}
+	if in.Topics != nil {
+		in, out := &in.Topics, &out.Topics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]NotificationRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationsSpec.
+func (in *NotificationsSpec) DeepCopy() *NotificationsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PasswordSelector) DeepCopyInto(out *PasswordSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PasswordSelector.
+func (in *PasswordSelector) DeepCopy() *PasswordSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(PasswordSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLSpec) DeepCopyInto(out *PostgreSQLSpec) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLSpec.
+func (in *PostgreSQLSpec) DeepCopy() *PostgreSQLSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PublicDNSSpec) DeepCopyInto(out *PublicDNSSpec) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublicDNSSpec.
+func (in *PublicDNSSpec) DeepCopy() *PublicDNSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PublicDNSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PublicDeploymentSpec) DeepCopyInto(out *PublicDeploymentSpec) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.APITimeout != nil {
+		in, out := &in.APITimeout, &out.APITimeout
+		*out = new(int)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublicDeploymentSpec.
+func (in *PublicDeploymentSpec) DeepCopy() *PublicDeploymentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PublicDeploymentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PublicSecuritySpec) DeepCopyInto(out *PublicSecuritySpec) {
+	*out = *in
+	if in.ExtraHeaders != nil {
+		in, out := &in.ExtraHeaders, &out.ExtraHeaders
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ModSecurity != nil {
+		in, out := &in.ModSecurity, &out.ModSecurity
+		*out = new(ModSecuritySpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublicSecuritySpec.
+func (in *PublicSecuritySpec) DeepCopy() *PublicSecuritySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PublicSecuritySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PublishedEndpointSpec) DeepCopyInto(out *PublishedEndpointSpec) {
+	*out = *in
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublishedEndpointSpec.
+func (in *PublishedEndpointSpec) DeepCopy() *PublishedEndpointSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PublishedEndpointSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimitSpec) DeepCopyInto(out *RateLimitSpec) {
+	*out = *in
+	if in.ConcurrentConnections != nil {
+		in, out := &in.ConcurrentConnections, &out.ConcurrentConnections
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HTTPRequestRate != nil {
+		in, out := &in.HTTPRequestRate, &out.HTTPRequestRate
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TCPConnectionRate != nil {
+		in, out := &in.TCPConnectionRate, &out.TCPConnectionRate
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitSpec.
+func (in *RateLimitSpec) DeepCopy() *RateLimitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReceiptSpec) DeepCopyInto(out *ReceiptSpec) {
+	*out = *in
+	if in.Expiration != nil {
+		in, out := &in.Expiration, &out.Expiration
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Caching != nil {
+		in, out := &in.Caching, &out.Caching
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CacheTime != nil {
+		in, out := &in.CacheTime, &out.CacheTime
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CacheOnIssue != nil {
+		in, out := &in.CacheOnIssue, &out.CacheOnIssue
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReceiptSpec.
+func (in *ReceiptSpec) DeepCopy() *ReceiptSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReceiptSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegionalEndpointSet) DeepCopyInto(out *RegionalEndpointSet) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegionalEndpointSet.
+func (in *RegionalEndpointSet) DeepCopy() *RegionalEndpointSet {
+	if in == nil {
+		return nil
+	}
+	out := new(RegionalEndpointSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RevokeSpec) DeepCopyInto(out *RevokeSpec) {
+	*out = *in
+	if in.ExpirationBuffer != nil {
+		in, out := &in.ExpirationBuffer, &out.ExpirationBuffer
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Caching != nil {
+		in, out := &in.Caching, &out.Caching
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CacheTime != nil {
+		in, out := &in.CacheTime, &out.CacheTime
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RevokeSpec.
+func (in *RevokeSpec) DeepCopy() *RevokeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RevokeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteTLSSpec) DeepCopyInto(out *RouteTLSSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteTLSSpec.
+func (in *RouteTLSSpec) DeepCopy() *RouteTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceConfigLayer) DeepCopyInto(out *ServiceConfigLayer) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceConfigLayer.
+func (in *ServiceConfigLayer) DeepCopy() *ServiceConfigLayer {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceConfigLayer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShadowUsersStatus) DeepCopyInto(out *ShadowUsersStatus) {
+	*out = *in
+	if in.OldestFirstSeen != nil {
+		in, out := &in.OldestFirstSeen, &out.OldestFirstSeen
+		*out = (*in).DeepCopy()
+	}
+	if in.LastPurgeTime != nil {
+		in, out := &in.LastPurgeTime, &out.LastPurgeTime
+		*out = (*in).DeepCopy()
+	}
+	in.CheckTime.DeepCopyInto(&out.CheckTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShadowUsersStatus.
+func (in *ShadowUsersStatus) DeepCopy() *ShadowUsersStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ShadowUsersStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenRevocationStatus) DeepCopyInto(out *TokenRevocationStatus) {
+	*out = *in
+	if in.KeysReplacedTime != nil {
+		in, out := &in.KeysReplacedTime, &out.KeysReplacedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.KeysRolledOutTime != nil {
+		in, out := &in.KeysRolledOutTime, &out.KeysRolledOutTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenRevocationStatus.
+func (in *TokenRevocationStatus) DeepCopy() *TokenRevocationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenRevocationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenSmokeTestSpec) DeepCopyInto(out *TokenSmokeTestSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenSmokeTestSpec.
+func (in *TokenSmokeTestSpec) DeepCopy() *TokenSmokeTestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenSmokeTestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenSmokeTestStatus) DeepCopyInto(out *TokenSmokeTestStatus) {
+	*out = *in
+	if in.LastRunTime != nil {
+		in, out := &in.LastRunTime, &out.LastRunTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessTime != nil {
+		in, out := &in.LastSuccessTime, &out.LastSuccessTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenSmokeTestStatus.
+func (in *TokenSmokeTestStatus) DeepCopy() *TokenSmokeTestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenSmokeTestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenSpec) DeepCopyInto(out *TokenSpec) {
+	*out = *in
+	if in.Caching != nil {
+		in, out := &in.Caching, &out.Caching
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CacheTime != nil {
+		in, out := &in.CacheTime, &out.CacheTime
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CacheOnIssue != nil {
+		in, out := &in.CacheOnIssue, &out.CacheOnIssue
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RevokeByID != nil {
+		in, out := &in.RevokeByID, &out.RevokeByID
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Revoke != nil {
+		in, out := &in.Revoke, &out.Revoke
+		*out = new(RevokeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenSpec.
+func (in *TokenSpec) DeepCopy() *TokenSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenlessAuthSpec) DeepCopyInto(out *TokenlessAuthSpec) {
+	*out = *in
+	if in.TrustedIssuers != nil {
+		in, out := &in.TrustedIssuers, &out.TrustedIssuers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.CABundleSecretRef.DeepCopyInto(&out.CABundleSecretRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenlessAuthSpec.
+func (in *TokenlessAuthSpec) DeepCopy() *TokenlessAuthSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenlessAuthSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserBatchEntry) DeepCopyInto(out *UserBatchEntry) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserBatchEntry.
+func (in *UserBatchEntry) DeepCopy() *UserBatchEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(UserBatchEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebSSOOption) DeepCopyInto(out *WebSSOOption) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebSSOOption.
+func (in *WebSSOOption) DeepCopy() *WebSSOOption {
+	if in == nil {
+		return nil
+	}
+	out := new(WebSSOOption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebSSOStatus) DeepCopyInto(out *WebSSOStatus) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make([]WebSSOOption, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebSSOStatus.
+func (in *WebSSOStatus) DeepCopy() *WebSSOStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WebSSOStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,253 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/domains"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// status of a compared entry
+const (
+	statusInSync  = "in-sync"
+	statusMissing = "missing"
+	statusDiffers = "differs"
+)
+
+// result - comparison of one entry of a custom resource with keystone
+type result struct {
+	kind   string
+	name   string
+	entry  string
+	status string
+	detail string
+}
+
+// differ - compares the keystone custom resources of a namespace with the
+// entries in keystone
+type differ struct {
+	ctx       context.Context
+	client    client.Client
+	os        *openstack.OpenStack
+	log       logr.Logger
+	namespace string
+	region    string
+}
+
+// diff - returns the comparison of all KeystoneServices, KeystoneEndpoints
+// and KeystoneCatalogs with keystone
+func (d *differ) diff() ([]result, error) {
+	results := []result{}
+
+	services := &keystonev1.KeystoneServiceList{}
+	err := d.client.List(d.ctx, services, client.InNamespace(d.namespace))
+	if err != nil {
+		return nil, err
+	}
+	for _, cr := range services.Items {
+		r, err := d.diffService("KeystoneService", cr.Name, cr.Spec.ServiceName, cr.Spec.ServiceType,
+			cr.Spec.ServiceDescription, cr.Spec.Enabled, cr.Status.ServiceID)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+
+		r, err = d.diffUser(cr)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	endpoints := &keystonev1.KeystoneEndpointList{}
+	err = d.client.List(d.ctx, endpoints, client.InNamespace(d.namespace))
+	if err != nil {
+		return nil, err
+	}
+	for _, cr := range endpoints.Items {
+		rendered, err := cr.RenderEndpoints(d.region)
+		if err != nil {
+			results = append(results, result{"KeystoneEndpoint", cr.Name, "", statusDiffers, err.Error()})
+			continue
+		}
+		r, err := d.diffEndpoints("KeystoneEndpoint", cr.Name, cr.Status.ServiceID, rendered)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r...)
+	}
+
+	catalogs := &keystonev1.KeystoneCatalogList{}
+	err = d.client.List(d.ctx, catalogs, client.InNamespace(d.namespace))
+	if err != nil {
+		return nil, err
+	}
+	for _, cr := range catalogs.Items {
+		catalog, err := cr.RenderCatalog(d.region)
+		if err != nil {
+			results = append(results, result{"KeystoneCatalog", cr.Name, "", statusDiffers, err.Error()})
+			continue
+		}
+		for _, svc := range cr.Spec.Services {
+			serviceID := ""
+			if svcStatus := cr.GetServiceStatus(svc.ServiceName); svcStatus != nil {
+				serviceID = svcStatus.ServiceID
+			}
+			r, err := d.diffService("KeystoneCatalog", cr.Name, svc.ServiceName, svc.ServiceType,
+				svc.ServiceDescription, svc.Enabled, serviceID)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, r)
+
+			endpointResults, err := d.diffEndpoints("KeystoneCatalog", cr.Name, serviceID, catalog[svc.ServiceName])
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, endpointResults...)
+		}
+	}
+
+	return results, nil
+}
+
+// diffService - compares a service with the service of the same type and
+// name in keystone
+func (d *differ) diffService(
+	kind string,
+	name string,
+	serviceName string,
+	serviceType string,
+	description string,
+	enabled bool,
+	serviceID string,
+) (result, error) {
+	r := result{kind: kind, name: name, entry: "service " + serviceName, status: statusInSync}
+
+	svc, err := d.os.GetService(d.log, serviceType, serviceName)
+	if err != nil {
+		if strings.Contains(err.Error(), openstack.ServiceNotFound) {
+			r.status = statusMissing
+			return r, nil
+		}
+		return r, err
+	}
+
+	diffs := []string{}
+	if serviceID != "" && svc.ID != serviceID {
+		diffs = append(diffs, fmt.Sprintf("id %s, status %s", svc.ID, serviceID))
+	}
+	if svc.Enabled != enabled {
+		diffs = append(diffs, fmt.Sprintf("enabled %t, spec %t", svc.Enabled, enabled))
+	}
+	if svc.Extra["description"] != description {
+		diffs = append(diffs, fmt.Sprintf("description %q, spec %q", svc.Extra["description"], description))
+	}
+	if len(diffs) > 0 {
+		r.status = statusDiffers
+		r.detail = strings.Join(diffs, "; ")
+	}
+
+	return r, nil
+}
+
+// diffUser - checks the service user of a KeystoneService exists
+func (d *differ) diffUser(cr keystonev1.KeystoneService) (result, error) {
+	r := result{kind: "KeystoneService", name: cr.Name, entry: "user " + cr.Spec.ServiceUser, status: statusInSync}
+
+	domainID := "default"
+	if cr.Spec.ServiceDomain != "" && !strings.EqualFold(cr.Spec.ServiceDomain, "default") {
+		allPages, err := domains.List(d.os.GetOSClient(), domains.ListOpts{Name: cr.Spec.ServiceDomain}).AllPages()
+		if err != nil {
+			return r, err
+		}
+		allDomains, err := domains.ExtractDomains(allPages)
+		if err != nil {
+			return r, err
+		}
+		if len(allDomains) == 0 {
+			r.status = statusMissing
+			r.detail = fmt.Sprintf("domain %s not found", cr.Spec.ServiceDomain)
+			return r, nil
+		}
+		domainID = allDomains[0].ID
+	}
+
+	_, err := d.os.GetUser(d.log, cr.Spec.ServiceUser, domainID)
+	if err != nil {
+		if strings.Contains(err.Error(), openstack.UserNotFound) {
+			r.status = statusMissing
+			return r, nil
+		}
+		return r, err
+	}
+
+	return r, nil
+}
+
+// diffEndpoints - compares the rendered endpoint URLs with the endpoints
+// registered for the service in keystone
+func (d *differ) diffEndpoints(
+	kind string,
+	name string,
+	serviceID string,
+	endpoints map[string]string,
+) ([]result, error) {
+	endpointTypes := make([]string, 0, len(endpoints))
+	for endpointType := range endpoints {
+		endpointTypes = append(endpointTypes, endpointType)
+	}
+	sort.Strings(endpointTypes)
+
+	results := []result{}
+	for _, endpointType := range endpointTypes {
+		r := result{kind: kind, name: name, entry: "endpoint " + endpointType, status: statusInSync}
+		if serviceID == "" {
+			r.status = statusMissing
+			r.detail = "service not registered"
+			results = append(results, r)
+			continue
+		}
+
+		registered, err := d.os.GetEndpoints(d.log, serviceID, endpointType)
+		if err != nil {
+			return nil, err
+		}
+		switch len(registered) {
+		case 0:
+			r.status = statusMissing
+		case 1:
+			if registered[0].URL != endpoints[endpointType] {
+				r.status = statusDiffers
+				r.detail = fmt.Sprintf("url %s, spec %s", registered[0].URL, endpoints[endpointType])
+			}
+		default:
+			r.status = statusDiffers
+			r.detail = fmt.Sprintf("%d endpoints registered", len(registered))
+		}
+		results = append(results, r)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,138 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kstat prints the differences between the keystone custom resources of a
+// namespace and the services, endpoints and users registered in keystone.
+// It authenticates like the operator, using the admin credentials of the
+// KeystoneAPI.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
+)
+
+func main() {
+	var namespace string
+	var authURL string
+	flag.StringVar(&namespace, "namespace", "openstack", "Namespace of the KeystoneAPI and the keystone custom resources.")
+	flag.StringVar(&authURL, "auth-url", "", "Keystone URL to authenticate against instead of the internal endpoint "+
+		"of the KeystoneAPI, e.g. the public endpoint or a port-forward when running outside of the cluster.")
+	opts := zap.Options{}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts), zap.WriteTo(os.Stderr)))
+	log := ctrl.Log.WithName("kstat")
+
+	drift, err := run(context.Background(), namespace, authURL)
+	if err != nil {
+		log.Error(keystonev1.RedactError(err), "unable to compare the keystone custom resources")
+		os.Exit(2)
+	}
+	if drift {
+		os.Exit(1)
+	}
+}
+
+// run - prints the comparison of the keystone custom resources with
+// keystone, returns true if there is a difference
+func run(ctx context.Context, namespace string, authURL string) (bool, error) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(keystonev1.AddToScheme(scheme))
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return false, err
+	}
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return false, err
+	}
+	kclient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return false, err
+	}
+
+	keystoneAPIs := &keystonev1.KeystoneAPIList{}
+	err = c.List(ctx, keystoneAPIs, client.InNamespace(namespace))
+	if err != nil {
+		return false, err
+	}
+	if len(keystoneAPIs.Items) != 1 {
+		return false, fmt.Errorf("expected one KeystoneAPI in namespace %s, found %d", namespace, len(keystoneAPIs.Items))
+	}
+	keystoneAPI := &keystoneAPIs.Items[0]
+
+	h, err := helper.NewHelper(keystoneAPI, c, kclient, scheme, ctrl.Log.WithName("kstat"))
+	if err != nil {
+		return false, err
+	}
+
+	// the admin client authenticates against the internal endpoint, the
+	// spec and status only get changed in memory
+	if authURL != "" {
+		keystoneAPI.Spec.AdminClient = nil
+		if keystoneAPI.Status.APIEndpoints == nil {
+			keystoneAPI.Status.APIEndpoints = map[string]string{}
+		}
+		keystoneAPI.Status.APIEndpoints[string(service.EndpointInternal)] = authURL
+	}
+
+	osclient, _, err := keystonev1.GetAdminServiceClient(ctx, h, keystoneAPI)
+	if err != nil {
+		return false, err
+	}
+
+	d := &differ{
+		ctx:       ctx,
+		client:    c,
+		os:        osclient,
+		log:       h.GetLogger(),
+		namespace: namespace,
+		region:    keystoneAPI.Spec.Region,
+	}
+	results, err := d.diff()
+	if err != nil {
+		return false, err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tENTRY\tSTATUS\tDETAIL")
+	drift := false
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.kind, r.name, r.entry, r.status, r.detail)
+		drift = drift || r.status != statusInSync
+	}
+
+	return drift, w.Flush()
+}
@@ -0,0 +1,39 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// getAdminClient - returns the admin client of the keystone API from the
+// factory of the reconciler, by default the client of the running keystone
+func getAdminClient(
+	ctx context.Context,
+	h *helper.Helper,
+	keystoneAPI *keystonev1.KeystoneAPI,
+	factory openstackclient.Factory,
+) (openstackclient.OpenStackClient, ctrl.Result, error) {
+	if factory == nil {
+		factory = openstackclient.NewAdminClient
+	}
+	return factory(ctx, h, keystoneAPI)
+}
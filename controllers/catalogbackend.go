@@ -0,0 +1,66 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/endpoints"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/services"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
+	"github.com/openstack-k8s-operators/lib-common/modules/openstack"
+)
+
+// catalogBackend - the service and endpoint operations the reconcilers of
+// the catalog use. The default backend registers them in the catalog of the
+// keystone API via gophercloud. Another backend, e.g. a templated catalog or
+// a later keystone API version, only needs to implement the interface and get
+// returned by newCatalogBackend.
+type catalogBackend interface {
+	// GetRegion - returns the region the endpoints get registered in
+	GetRegion() string
+
+	CreateService(log logr.Logger, s openstack.Service) (string, error)
+	GetService(log logr.Logger, serviceType string, serviceName string) (*services.Service, error)
+	UpdateService(log logr.Logger, s openstack.Service, serviceID string) error
+	DeleteService(log logr.Logger, serviceID string) error
+	// UpdateServiceExtra - sets extra attributes of the service
+	UpdateServiceExtra(serviceID string, extra map[string]interface{}) error
+
+	CreateEndpoint(log logr.Logger, e openstack.Endpoint) (string, error)
+	GetEndpoints(log logr.Logger, serviceID string, endpointInterface string) ([]endpoints.Endpoint, error)
+	UpdateEndpoint(log logr.Logger, e openstack.Endpoint, endpointID string) (string, error)
+	DeleteEndpoint(log logr.Logger, e openstack.Endpoint) error
+	// ListEndpoints - returns the endpoints matching opts, including their
+	// extra attributes
+	ListEndpoints(opts endpoints.ListOpts) ([]openstackclient.Endpoint, error)
+	// UpdateEndpointExtra - sets extra attributes of the endpoint
+	UpdateEndpointExtra(endpointID string, extra map[string]interface{}) error
+
+	// EnsureRegion - creates the region unless it exists
+	EnsureRegion(regionID string) error
+	// CreateRegionalEndpoint - creates the endpoint in region
+	CreateRegionalEndpoint(e openstack.Endpoint, region string) (string, error)
+	// UpdateEndpointURL - sets the URL of the endpoint
+	UpdateEndpointURL(endpointID string, url string) error
+	// DeleteEndpointByID - deletes the endpoint if it exists
+	DeleteEndpointByID(endpointID string) error
+}
+
+// newCatalogBackend - returns the catalog backend of the reconcilers, the
+// catalog of the keystone API the admin client talks to
+func newCatalogBackend(os openstackclient.OpenStackClient) catalogBackend {
+	return os
+}
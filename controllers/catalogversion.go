@@ -0,0 +1,141 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	keystone "github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// catalogEntries - returns the services and endpoints registered in keystone
+// by a KeystoneService, KeystoneEndpoint or KeystoneCatalog
+func catalogEntries(o client.Object) []string {
+	entries := []string{}
+	switch cr := o.(type) {
+	case *keystonev1.KeystoneService:
+		if cr.Status.ServiceID != "" {
+			entries = append(entries, fmt.Sprintf("service/%s/%s/%s/%t/%s",
+				cr.Spec.ServiceName, cr.Spec.ServiceType, cr.Status.ServiceID,
+				cr.Spec.Enabled, cr.Spec.ServiceDescription))
+		}
+	case *keystonev1.KeystoneEndpoint:
+		for _, endpt := range cr.Status.Endpoints {
+			entries = append(entries, fmt.Sprintf("endpoint/%s/%s/%s/%s",
+				cr.Spec.ServiceName, endpt.Interface, endpt.ID, endpt.URL))
+		}
+		for _, endpt := range cr.Status.RegionalEndpoints {
+			entries = append(entries, fmt.Sprintf("endpoint/%s/%s/%s/%s/%s",
+				cr.Spec.ServiceName, endpt.Region, endpt.Interface, endpt.ID, endpt.URL))
+		}
+	case *keystonev1.KeystoneCatalog:
+		for _, svc := range cr.Status.Services {
+			entries = append(entries, fmt.Sprintf("service/%s/%s", svc.ServiceName, svc.ServiceID))
+			for _, endpt := range svc.Endpoints {
+				entries = append(entries, fmt.Sprintf("endpoint/%s/%s/%s/%s",
+					svc.ServiceName, endpt.Interface, endpt.ID, endpt.URL))
+			}
+		}
+	}
+	sort.Strings(entries)
+
+	return entries
+}
+
+// catalogChangedPredicate - only passes updates which change the services or
+// endpoints registered by the object
+var catalogChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !slices.Equal(catalogEntries(e.ObjectOld), catalogEntries(e.ObjectNew))
+	},
+}
+
+// findKeystoneAPIsForCatalog - returns the KeystoneAPIs in the namespace of
+// the object registering services or endpoints
+func (r *KeystoneAPIReconciler) findKeystoneAPIsForCatalog(ctx context.Context, o client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	keystoneAPIs := &keystonev1.KeystoneAPIList{}
+	if err := r.Client.List(ctx, keystoneAPIs, client.InNamespace(o.GetNamespace())); err != nil {
+		r.GetLogger(ctx).Error(err, "Unable to retrieve KeystoneAPI CRs")
+		return requests
+	}
+	for _, cr := range keystoneAPIs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&cr)})
+	}
+
+	return requests
+}
+
+// reconcileCatalogVersion - increments Status.CatalogVersion when the services
+// and endpoints registered in the namespace changed since the last bump
+func (r *KeystoneAPIReconciler) reconcileCatalogVersion(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+) error {
+	entries := []string{}
+	for endpointType, url := range instance.Status.APIEndpoints {
+		entries = append(entries, fmt.Sprintf("endpoint/%s/%s/%s", keystone.ServiceName, endpointType, url))
+	}
+
+	services := &keystonev1.KeystoneServiceList{}
+	err := r.Client.List(ctx, services, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return err
+	}
+	for i := range services.Items {
+		entries = append(entries, catalogEntries(&services.Items[i])...)
+	}
+
+	endpoints := &keystonev1.KeystoneEndpointList{}
+	err = r.Client.List(ctx, endpoints, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return err
+	}
+	for i := range endpoints.Items {
+		entries = append(entries, catalogEntries(&endpoints.Items[i])...)
+	}
+
+	catalogs := &keystonev1.KeystoneCatalogList{}
+	err = r.Client.List(ctx, catalogs, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return err
+	}
+	for i := range catalogs.Items {
+		entries = append(entries, catalogEntries(&catalogs.Items[i])...)
+	}
+	sort.Strings(entries)
+
+	catalogHash, err := util.ObjectHash(entries)
+	if err != nil {
+		return err
+	}
+	if instance.Status.Hash[keystonev1.CatalogHash] != catalogHash {
+		instance.Status.CatalogVersion++
+		instance.Status.Hash[keystonev1.CatalogHash] = catalogHash
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// readyObject - keystone CR which reports its readiness
+type readyObject interface {
+	client.Object
+	IsReady() bool
+}
+
+// keystoneChildLists - returns the lists of the keystone CRs summarized in
+// the KeystoneAPI status by their lowercase kind
+func keystoneChildLists() map[string]client.ObjectList {
+	return map[string]client.ObjectList{
+		"keystonecatalog":         &keystonev1.KeystoneCatalogList{},
+		"keystonecatalogaudit":    &keystonev1.KeystoneCatalogAuditList{},
+		"keystonecredential":      &keystonev1.KeystoneCredentialList{},
+		"keystoneec2credential":   &keystonev1.KeystoneEC2CredentialList{},
+		"keystoneendpoint":        &keystonev1.KeystoneEndpointList{},
+		"keystoneendpointgroup":   &keystonev1.KeystoneEndpointGroupList{},
+		"keystonelimit":           &keystonev1.KeystoneLimitList{},
+		"keystonemapping":         &keystonev1.KeystoneMappingList{},
+		"keystoneregisteredlimit": &keystonev1.KeystoneRegisteredLimitList{},
+		"keystoneservice":         &keystonev1.KeystoneServiceList{},
+		"keystoneuser":            &keystonev1.KeystoneUserList{},
+		"keystoneuserbatch":       &keystonev1.KeystoneUserBatchList{},
+	}
+}
+
+// setKeystoneAPILabel - labels the keystone CR with the name of the
+// KeystoneAPI it belongs to, the deferred PatchInstance persists it
+func setKeystoneAPILabel(instance client.Object, keystoneAPI *keystonev1.KeystoneAPI) {
+	labels := instance.GetLabels()
+	if labels[keystonev1.KeystoneAPILabel] == keystoneAPI.Name {
+		return
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[keystonev1.KeystoneAPILabel] = keystoneAPI.Name
+	instance.SetLabels(labels)
+}
+
+// readinessChangedPredicate - only passes updates which change whether the
+// keystone CR is ready
+var readinessChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldObj, okOld := e.ObjectOld.(readyObject)
+		newObj, okNew := e.ObjectNew.(readyObject)
+		if !okOld || !okNew {
+			return false
+		}
+		return oldObj.IsReady() != newObj.IsReady()
+	},
+}
+
+// reconcileChildren - summarizes the readiness of the keystone CRs of the
+// namespace in Status.Children
+func (r *KeystoneAPIReconciler) reconcileChildren(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+) error {
+	children := &keystonev1.KeystoneChildrenStatus{}
+	for kind, list := range keystoneChildLists() {
+		err := r.Client.List(ctx, list, client.InNamespace(instance.Namespace))
+		if err != nil {
+			return err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			cr, ok := item.(readyObject)
+			if !ok {
+				continue
+			}
+			children.Count++
+			if cr.IsReady() {
+				children.ReadyCount++
+			} else {
+				children.Failing = append(children.Failing, fmt.Sprintf("%s/%s", kind, cr.GetName()))
+			}
+		}
+	}
+	sort.Strings(children.Failing)
+	instance.Status.Children = children
+
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CloudEvent types of the catalog changes
+const (
+	cloudEventServiceCreated  = "org.openstack.keystone.service.created"
+	cloudEventServiceUpdated  = "org.openstack.keystone.service.updated"
+	cloudEventServiceDeleted  = "org.openstack.keystone.service.deleted"
+	cloudEventEndpointCreated = "org.openstack.keystone.endpoint.created"
+	cloudEventEndpointUpdated = "org.openstack.keystone.endpoint.updated"
+	cloudEventEndpointDeleted = "org.openstack.keystone.endpoint.deleted"
+	cloudEventUserCreated     = "org.openstack.keystone.user.created"
+	cloudEventUserUpdated     = "org.openstack.keystone.user.updated"
+	cloudEventUserDeleted     = "org.openstack.keystone.user.deleted"
+)
+
+// cloudEventTimeout - timeout of the request posting an event to the sink
+const cloudEventTimeout = 5 * time.Second
+
+// catalogEvents - sends CloudEvents about the catalog changes made on behalf
+// of an object to the sink configured on the KeystoneAPI. A nil catalogEvents
+// drops all events.
+type catalogEvents struct {
+	log    logr.Logger
+	sink   string
+	source string
+}
+
+// newCatalogEvents - returns the catalogEvents of the object, nil if the
+// KeystoneAPI has no CloudEvents sink configured
+func newCatalogEvents(
+	log logr.Logger,
+	keystoneAPI *keystonev1.KeystoneAPI,
+	obj client.Object,
+	kind string,
+) *catalogEvents {
+	if keystoneAPI == nil || keystoneAPI.Spec.CloudEvents == nil {
+		return nil
+	}
+
+	return &catalogEvents{
+		log:    log,
+		sink:   keystoneAPI.Spec.CloudEvents.SinkURL,
+		source: fmt.Sprintf("/apis/%s/namespaces/%s/%s/%s", keystonev1.GroupVersion.String(), obj.GetNamespace(), kind, obj.GetName()),
+	}
+}
+
+// emit - posts the event to the sink. Delivery is best effort, a failure
+// gets logged but does not fail the reconcile as the change is already
+// applied in keystone.
+func (e *catalogEvents) emit(ctx context.Context, eventType string, subject string, data map[string]string) {
+	if e == nil {
+		return
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		e.log.Error(err, "Unable to marshal CloudEvent", "type", eventType)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cloudEventTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.sink, bytes.NewReader(body))
+	if err != nil {
+		e.log.Error(err, "Unable to create CloudEvent request", "type", eventType)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("ce-specversion", "1.0")
+	req.Header.Set("ce-id", string(uuid.NewUUID()))
+	req.Header.Set("ce-type", eventType)
+	req.Header.Set("ce-source", e.source)
+	req.Header.Set("ce-subject", subject)
+	req.Header.Set("ce-time", time.Now().UTC().Format(time.RFC3339))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		e.log.Error(keystonev1.RedactError(err), "Unable to send CloudEvent", "type", eventType, "sink", keystonev1.RedactSecrets(e.sink))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		e.log.Info(fmt.Sprintf("CloudEvents sink %s rejected event %s with status %d", keystonev1.RedactSecrets(e.sink), eventType, resp.StatusCode))
+	}
+}
@@ -0,0 +1,126 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	common "github.com/openstack-k8s-operators/lib-common/modules/common"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+var (
+	// configHashAnnotation - annotation of the config preview Secret with
+	// the hash of the rendered keystone config
+	configHashAnnotation = labels.GetGroupLabel(keystone.ServiceName) + "/config-hash"
+	// appliedConfigHashAnnotation - annotation of the config preview Secret
+	// with the hash of the keystone config the pods last got rolled out with
+	appliedConfigHashAnnotation = labels.GetGroupLabel(keystone.ServiceName) + "/applied-config-hash"
+)
+
+// reconcileConfigPreview - writes the rendered keystone config, keystone.conf
+// with custom.conf merged on top, next to the one the keystone pods last got
+// rolled out with into the config preview Secret, and reports the options
+// which differ in Status.ConfigPreview. The config holds credentials, only
+// the option names get into the status. With rolledOut the rendered config
+// becomes the applied one.
+func (r *KeystoneAPIReconciler) reconcileConfigPreview(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+	rolledOut bool,
+) error {
+	Log := r.GetLogger(ctx)
+
+	configData, _, err := oko_secret.GetSecret(ctx, h, fmt.Sprintf("%s-config-data", instance.Name), instance.Namespace)
+	if err != nil {
+		return err
+	}
+	rendered := keystonev1.MergeServiceConfigs(
+		string(configData.Data[keystone.ConfigFileName]),
+		string(configData.Data[common.CustomServiceConfigFileName]))
+	hash, err := util.ObjectHash(rendered)
+	if err != nil {
+		return err
+	}
+
+	preview := &corev1.Secret{}
+	name := keystone.ConfigPreviewSecretName(instance.Name)
+	err = r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.Namespace}, preview)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return err
+	}
+	applied := string(preview.Data[keystone.ConfigPreviewAppliedKey])
+	appliedHash := preview.Annotations[appliedConfigHashAnnotation]
+	// without a previous preview the pods run the rendered config or get
+	// created with it
+	if rolledOut || appliedHash == "" {
+		applied = rendered
+		appliedHash = hash
+	}
+	changed, diff := keystonev1.DiffServiceConfigs(applied, rendered)
+
+	preview = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: instance.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrPatch(ctx, r.Client, preview, func() error {
+		preview.Labels = util.MergeStringMaps(preview.Labels,
+			labels.GetLabels(instance, labels.GetGroupLabel(keystone.ServiceName), map[string]string{}))
+		if preview.Annotations == nil {
+			preview.Annotations = map[string]string{}
+		}
+		preview.Annotations[configHashAnnotation] = hash
+		preview.Annotations[appliedConfigHashAnnotation] = appliedHash
+		preview.Data = map[string][]byte{
+			keystone.ConfigPreviewRenderedKey: []byte(rendered),
+			keystone.ConfigPreviewAppliedKey:  []byte(applied),
+		}
+		if instance.Spec.ConfigDiff {
+			preview.Data[keystone.ConfigPreviewDiffKey] = []byte(diff)
+		}
+		return controllerutil.SetControllerReference(h.GetBeforeObject(), preview, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		Log.Info(fmt.Sprintf("Config preview Secret %s - %s", name, op), "changedOptions", changed)
+	}
+
+	instance.Status.ConfigPreview = &keystonev1.ConfigPreviewStatus{
+		SecretName:  name,
+		Hash:        hash,
+		AppliedHash: appliedHash,
+	}
+	if len(changed) > 0 {
+		instance.Status.ConfigPreview.ChangedOptions = changed
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	keystone "github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// configReloadScript - verifies the kubelet synced the expected config
+	// data into the pod, copies it into place and gracefully reloads the WSGI
+	// server with the reload command passed as second argument.
+	// The checksum is calculated the same way as by configDataChecksum.
+	configReloadScript = `set -e
+cd /var/lib/config-data/default
+actual=$(for f in $(ls | LC_ALL=C sort); do cat "$f"; done | sha256sum | cut -d' ' -f1)
+if [ "$actual" != "$1" ]; then
+    echo "config data not yet synced" >&2
+    exit 75
+fi
+/usr/local/bin/kolla_set_configs
+/bin/bash -c "$2"
+`
+	// configReloadNotSynced - exit code of configReloadScript if the config
+	// data in the pod is not yet updated
+	configReloadNotSynced = 75
+	// configReloadRequeue - requeue interval while waiting for the config data
+	// to get synced into the pods
+	configReloadRequeue = 10 * time.Second
+)
+
+// configDataChecksum - checksum of the config data secret as seen by the pods
+func configDataChecksum(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write(data[k])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// reconcileConfigReload - with the GracefulReload strategy the config data is
+// not part of the deployment hash. Once it changes the config gets reloaded in
+// the running pods instead.
+func (r *KeystoneAPIReconciler) reconcileConfigReload(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+	serviceLabels map[string]string,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	if instance.Spec.ConfigReloadStrategy != keystonev1.ConfigReloadGraceful {
+		// config changes restart the pods
+		delete(instance.Status.Hash, keystonev1.ConfigReloadHash)
+		return ctrl.Result{}, nil
+	}
+
+	configData, _, err := oko_secret.GetSecret(ctx, h, fmt.Sprintf("%s-config-data", instance.Name), instance.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	checksum := configDataChecksum(configData.Data)
+
+	reloadedHash, ok := instance.Status.Hash[keystonev1.ConfigReloadHash]
+	if !ok {
+		// switching to GracefulReload restarted the pods, they use the
+		// current config data
+		instance.Status.Hash[keystonev1.ConfigReloadHash] = checksum
+		return ctrl.Result{}, nil
+	}
+	if reloadedHash == checksum {
+		return ctrl.Result{}, nil
+	}
+
+	if r.Config == nil {
+		return ctrl.Result{}, errors.New("graceful config reload requires a rest config")
+	}
+
+	pods := &corev1.PodList{}
+	err = r.Client.List(ctx, pods,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(serviceLabels))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		err = r.execInPod(ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace},
+			keystone.ServiceName+"-api",
+			[]string{"/bin/bash", "-c", configReloadScript, "--", checksum, keystone.ReloadCommand(instance)})
+		var exitErr exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitStatus() == configReloadNotSynced {
+			Log.Info(fmt.Sprintf("Config data of pod %s not yet synced, requeueing", pod.Name))
+			return ctrl.Result{RequeueAfter: configReloadRequeue}, nil
+		}
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("config reload of pod %s failed: %w", pod.Name, err)
+		}
+		Log.Info(fmt.Sprintf("Reloaded config of pod %s", pod.Name))
+	}
+
+	instance.Status.Hash[keystonev1.ConfigReloadHash] = checksum
+	return ctrl.Result{}, nil
+}
+
+// execInPod - runs the command in the container of the pod
+func (r *KeystoneAPIReconciler) execInPod(
+	ctx context.Context,
+	pod types.NamespacedName,
+	container string,
+	command []string,
+) error {
+	req := r.Kclient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod.Name).
+		Namespace(pod.Namespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(r.Config, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdout: &bytes.Buffer{},
+		Stderr: &stderr,
+	})
+	if err != nil && stderr.Len() > 0 {
+		return fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return err
+}
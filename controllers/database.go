@@ -0,0 +1,334 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	keystone "github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/job"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	mariadbv1 "github.com/openstack-k8s-operators/mariadb-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// databaseConfig - connection settings of the keystone database
+type databaseConfig struct {
+	// connection - SQLAlchemy URL of the database
+	connection string
+	// slaveConnection - SQLAlchemy URL of the read replica, empty without
+	// one
+	slaveConnection string
+	// clientConfig - my.cnf of the MariaDB client, empty for PostgreSQL
+	clientConfig string
+	// syncConnection - SQLAlchemy URL of the database with the
+	// DatabaseSyncAccount, empty without one
+	syncConnection string
+}
+
+// mariaDBConfig - returns the connection settings of the MariaDB database,
+// syncDB is the database of the DatabaseSyncAccount or nil
+func mariaDBConfig(instance *keystonev1.KeystoneAPI, db *mariadbv1.Database, syncDB *mariadbv1.Database) *databaseConfig {
+	var tlsCfg *tls.Service
+	if instance.Spec.TLS.Ca.CaBundleSecretName != "" {
+		tlsCfg = &tls.Service{}
+	}
+
+	mariaDBURL := func(db *mariadbv1.Database, host string) string {
+		return fmt.Sprintf("mysql+pymysql://%s:%s@%s/%s?read_default_file=/etc/my.cnf",
+			db.GetAccount().Spec.UserName,
+			string(db.GetSecret().Data[mariadbv1.DatabasePasswordSelector]),
+			host,
+			keystone.DatabaseName,
+		)
+	}
+
+	cfg := &databaseConfig{
+		connection:   mariaDBURL(db, instance.Status.DatabaseHostname),
+		clientConfig: db.GetDatabaseClientConfig(tlsCfg), //(mschuppert) for now just get the default my.cnf
+	}
+	if instance.Spec.DatabaseReader != nil {
+		cfg.slaveConnection = mariaDBURL(db, instance.Spec.DatabaseReader.Host)
+	}
+	if syncDB != nil {
+		cfg.syncConnection = mariaDBURL(syncDB, instance.Status.DatabaseHostname)
+	}
+	return cfg
+}
+
+// ensureDBSyncConfig - creates the Secret with the connection of the
+// DatabaseSyncAccount, read by the db sync job after keystone.conf. It is not
+// part of the config-data Secret mounted into the keystone API pods, which
+// must not change the schema. The Secret gets deleted without a
+// DatabaseSyncAccount.
+func (r *KeystoneAPIReconciler) ensureDBSyncConfig(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+	dbConfig *databaseConfig,
+) error {
+	if dbConfig.syncConnection == "" {
+		syncConfig := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keystone.DBSyncConfigSecretName(instance),
+				Namespace: instance.Namespace,
+			},
+		}
+		return client.IgnoreNotFound(r.Client.Delete(ctx, syncConfig))
+	}
+
+	cmLabels := labels.GetLabels(instance, labels.GetGroupLabel(keystone.ServiceName), map[string]string{})
+	tmpl := []util.Template{
+		{
+			Name:         keystone.DBSyncConfigSecretName(instance),
+			Namespace:    instance.Namespace,
+			Type:         util.TemplateTypeNone,
+			InstanceType: instance.Kind,
+			CustomData: map[string]string{
+				keystone.DBSyncConfigFileName: fmt.Sprintf("[database]\nconnection = %s\n", dbConfig.syncConnection),
+			},
+			Labels: cmLabels,
+		},
+	}
+	return oko_secret.EnsureSecrets(ctx, h, instance, tmpl, nil)
+}
+
+// reconcileDBGrants - runs the job setting the privileges of the
+// DatabaseAccount, restricted to DML with a DatabaseSyncAccount. The
+// mariadb-operator grants all privileges to the account, the job runs again
+// when it recreated the account. Once the DatabaseSyncAccount got removed the
+// job grants all privileges again, db sync runs with the DatabaseAccount
+// afterwards.
+func (r *KeystoneAPIReconciler) reconcileDBGrants(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	h *helper.Helper,
+	serviceLabels map[string]string,
+	serviceAnnotations map[string]string,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	dbGrantsHash, restricted := instance.Status.Hash[keystonev1.DbGrantsHash]
+	if !keystone.UsesDBSyncAccount(instance) && (!restricted || instance.Spec.DevMode || instance.Spec.PostgreSQL != nil) {
+		delete(instance.Status.Hash, keystonev1.DbGrantsHash)
+		return ctrl.Result{}, nil
+	}
+
+	account, err := mariadbv1.GetAccount(ctx, h, instance.Spec.DatabaseAccount, instance.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	galera := &mariadbv1.Galera{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: instance.Spec.DatabaseInstance, Namespace: instance.Namespace}, galera)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	jobDef := keystone.DBGrantsJob(
+		instance, galera, account.Spec.UserName, account.Status.Hash[mariadbv1.AccountCreateHash],
+		serviceLabels, serviceAnnotations)
+	dbGrantsJob := job.NewJob(
+		jobDef,
+		keystonev1.DbGrantsHash,
+		instance.Spec.PreserveJobs,
+		5*time.Second,
+		dbGrantsHash,
+	)
+	ctrlResult, err := dbGrantsJob.DoJob(ctx, h)
+	if err != nil || (ctrlResult != ctrl.Result{}) {
+		return ctrlResult, err
+	}
+
+	if !keystone.UsesDBSyncAccount(instance) {
+		delete(instance.Status.Hash, keystonev1.DbGrantsHash)
+		Log.Info(fmt.Sprintf("Job %s granted all privileges to %s", jobDef.Name, account.Spec.UserName))
+	} else if dbGrantsJob.HasChanged() {
+		instance.Status.Hash[keystonev1.DbGrantsHash] = dbGrantsJob.GetHash()
+		Log.Info(fmt.Sprintf("Job %s hash added - %s", jobDef.Name, instance.Status.Hash[keystonev1.DbGrantsHash]))
+	}
+	return ctrl.Result{}, nil
+}
+
+// deleteUnusedMariaDBAccountFinalizers - removes the finalizer from the
+// MariaDBAccounts of the keystone database and their Secrets, except for the
+// DatabaseAccount and the DatabaseSyncAccount. Like
+// mariadbv1.DeleteUnusedMariaDBAccountFinalizers, which keeps one account.
+func deleteUnusedMariaDBAccountFinalizers(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) error {
+	accountList := &mariadbv1.MariaDBAccountList{}
+	err := h.GetClient().List(ctx, accountList,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels{"mariaDBDatabaseName": keystone.DatabaseName},
+	)
+	if err != nil {
+		return err
+	}
+
+	for i := range accountList.Items {
+		account := &accountList.Items[i]
+		if account.Name == instance.Spec.DatabaseAccount ||
+			(keystone.UsesDBSyncAccount(instance) && account.Name == instance.Spec.DatabaseSyncAccount) {
+			continue
+		}
+
+		if account.Spec.Secret != "" {
+			dbSecret, _, err := oko_secret.GetSecret(ctx, h, account.Spec.Secret, instance.Namespace)
+			if err != nil && !k8s_errors.IsNotFound(err) {
+				return err
+			}
+			if dbSecret != nil && controllerutil.RemoveFinalizer(dbSecret, h.GetFinalizer()) {
+				if err := h.GetClient().Update(ctx, dbSecret); err != nil && !k8s_errors.IsNotFound(err) {
+					return err
+				}
+			}
+		}
+
+		if controllerutil.RemoveFinalizer(account, h.GetFinalizer()) {
+			if err := h.GetClient().Update(ctx, account); err != nil && !k8s_errors.IsNotFound(err) {
+				return err
+			}
+			h.GetLogger().Info(fmt.Sprintf("Removed finalizer %s from MariaDBAccount %s", h.GetFinalizer(), account.Name))
+		}
+	}
+	return nil
+}
+
+// ensurePostgreSQL - waits for the Secret with the credentials of the
+// PostgreSQL database and returns its connection settings. The database
+// itself is managed outside of the operator, e.g. by a PostgreSQL operator.
+func (r *KeystoneAPIReconciler) ensurePostgreSQL(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) (*databaseConfig, ctrl.Result, error) {
+	pg := instance.Spec.PostgreSQL
+
+	// no MariaDBAccount gets used with PostgreSQL
+	instance.Status.Conditions.Remove(mariadbv1.MariaDBAccountReadyCondition)
+
+	// NOTE: VerifySecret handles the "not found" error and returns
+	// RequeueAfter ctrl.Result if so, the PostgreSQL operator might not have
+	// created it yet
+	_, result, err := oko_secret.VerifySecret(
+		ctx,
+		types.NamespacedName{Name: pg.SecretName, Namespace: instance.Namespace},
+		[]string{pg.UsernameKey, pg.PasswordKey},
+		h.GetClient(),
+		time.Second*10)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.DBReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.DBReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return nil, ctrl.Result{}, err
+	} else if (result != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.DBReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			condition.DBReadyRunningMessage))
+		return nil, result, nil
+	}
+
+	dbSecret, _, err := oko_secret.GetSecret(ctx, h, pg.SecretName, instance.Namespace)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.DBReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.DBReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return nil, ctrl.Result{}, err
+	}
+	username := string(dbSecret.Data[pg.UsernameKey])
+	password := string(dbSecret.Data[pg.PasswordKey])
+
+	cfg := &databaseConfig{
+		connection: postgreSQLURL(pg, instance.Spec.TLS.CaBundleSecretName != "", pg.Host, username, password),
+	}
+	if instance.Spec.DatabaseReader != nil {
+		cfg.slaveConnection = postgreSQLURL(pg, instance.Spec.TLS.CaBundleSecretName != "",
+			instance.Spec.DatabaseReader.Host, username, password)
+	}
+
+	// update Status.DatabaseHostname, used to config the service
+	instance.Status.DatabaseHostname = pg.Host
+	instance.Status.Conditions.MarkTrue(condition.DBReadyCondition, condition.DBReadyMessage)
+
+	return cfg, ctrl.Result{}, nil
+}
+
+// postgreSQLURL - returns the SQLAlchemy URL of the PostgreSQL database on
+// the host, which may include a port. The server certificate gets verified
+// with the CA bundle mounted into the keystone containers.
+func postgreSQLURL(
+	pg *keystonev1.PostgreSQLSpec,
+	caBundle bool,
+	host string,
+	username string,
+	password string,
+) string {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		port := pg.Port
+		if port == 0 {
+			port = 5432
+		}
+		host = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+
+	query := url.Values{}
+	for key, value := range pg.Options {
+		query.Set(key, value)
+	}
+	if pg.SSLMode != "" {
+		query.Set("sslmode", string(pg.SSLMode))
+	}
+	if caBundle && (pg.SSLMode == keystonev1.PostgreSQLSSLModeVerifyCA ||
+		pg.SSLMode == keystonev1.PostgreSQLSSLModeVerifyFull) {
+		query.Set("sslrootcert", tls.DownstreamTLSCABundlePath)
+	}
+
+	u := url.URL{
+		Scheme:   "postgresql+psycopg2",
+		User:     url.UserPassword(username, password),
+		Host:     host,
+		Path:     "/" + pg.Database,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
@@ -0,0 +1,156 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// degradedRetryBudget - number of reconciles of an instance which can fail
+	// in a row before it gets reported as degraded
+	degradedRetryBudget = 5
+	// degradedRequeueAfter - requeue interval of degraded instances
+	degradedRequeueAfter = 2 * time.Minute
+	// degradedMaxErrors - number of distinct errors kept for the Degraded
+	// condition message
+	degradedMaxErrors = 3
+)
+
+// degradedTracker - tracks the consecutive failed reconciles of the instances
+// of a controller. The zero value is ready to use.
+type degradedTracker struct {
+	mu       sync.Mutex
+	failures map[types.NamespacedName]*reconcileFailures
+}
+
+// reconcileFailures - failed reconciles of an instance in a row
+type reconcileFailures struct {
+	count  int
+	errors []string
+	// message of the Degraded condition and when it got set, it only
+	// changes every degradedRequeueAfter as each status change requeues the
+	// instance right away
+	message    string
+	reportedAt time.Time
+}
+
+// forget - removes the failures of a deleted instance
+func (t *degradedTracker) forget(name types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.failures, name)
+}
+
+// handleResult - records the result of a reconcile of the instance. Once the
+// reconcile failed degradedRetryBudget times in a row the Degraded condition
+// gets set with the aggregated errors, Ready gets set to False and the
+// instance gets requeued after degradedRequeueAfter instead of the error
+// backoff. The condition messages change at most every degradedRequeueAfter,
+// a change of the status requeues the instance right away. A safety limit
+// error or an ownership conflict degrades the instance right away, the
+// conflict also sets the OwnershipConflict condition. On success the Degraded
+// and OwnershipConflict conditions get removed. Secrets get redacted from the
+// error, it ends up in the condition and the operator log.
+func (t *degradedTracker) handleResult(
+	log logr.Logger,
+	name types.NamespacedName,
+	conditions *condition.Conditions,
+	result *ctrl.Result,
+	err *error,
+) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if *err == nil {
+		delete(t.failures, name)
+		conditions.Remove(keystonev1.DegradedCondition)
+		conditions.Remove(keystonev1.OwnershipConflictCondition)
+		return
+	}
+
+	*err = keystonev1.RedactError(*err)
+
+	if t.failures == nil {
+		t.failures = map[types.NamespacedName]*reconcileFailures{}
+	}
+	f, ok := t.failures[name]
+	if !ok {
+		f = &reconcileFailures{}
+		t.failures[name] = f
+	}
+	f.count++
+	if msg := (*err).Error(); !slices.Contains(f.errors, msg) {
+		f.errors = append(f.errors, msg)
+		if len(f.errors) > degradedMaxErrors {
+			f.errors = f.errors[1:]
+		}
+	}
+
+	var conflictErr *ownershipConflictError
+	if errors.As(*err, &conflictErr) {
+		conditions.Set(&condition.Condition{
+			Type:     keystonev1.OwnershipConflictCondition,
+			Status:   corev1.ConditionTrue,
+			Reason:   condition.ErrorReason,
+			Severity: condition.SeverityError,
+			Message: fmt.Sprintf(keystonev1.OwnershipConflictMessage,
+				conflictErr.Error(), keystonev1.TakeOwnershipAnnotation),
+		})
+	}
+
+	// retrying does not help while a safety limit is exceeded or another
+	// operator manages the object
+	var limitErr *safetyLimitError
+	if f.count < degradedRetryBudget && !errors.As(*err, &limitErr) && conflictErr == nil {
+		return
+	}
+
+	if now := time.Now(); f.message == "" || now.Sub(f.reportedAt) >= degradedRequeueAfter {
+		f.message = fmt.Sprintf(keystonev1.DegradedMessage, strings.Join(f.errors, "; "))
+		f.reportedAt = now
+	}
+	conditions.Set(&condition.Condition{
+		Type:     keystonev1.DegradedCondition,
+		Status:   corev1.ConditionTrue,
+		Reason:   condition.ErrorReason,
+		Severity: condition.SeverityError,
+		Message:  f.message,
+	})
+	conditions.MarkFalse(
+		condition.ReadyCondition,
+		condition.ErrorReason,
+		condition.SeverityError,
+		keystonev1.DegradedReadyMessage)
+
+	// the error is reported in the condition, requeue at a slower rate
+	// instead of the error backoff
+	log.Error(*err, "Reconcile degraded", "failures", f.count)
+	*result = ctrl.Result{RequeueAfter: degradedRequeueAfter}
+	*err = nil
+}
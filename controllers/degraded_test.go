@@ -0,0 +1,100 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestDegradedTrackerMessageIsStable(t *testing.T) {
+	g := NewWithT(t)
+
+	tracker := degradedTracker{}
+	name := types.NamespacedName{Namespace: "openstack", Name: "keystone"}
+	conditions := condition.Conditions{}
+
+	fail := func(msg string) (ctrl.Result, error) {
+		result := ctrl.Result{}
+		err := errors.New(msg)
+		tracker.handleResult(logr.Discard(), name, &conditions, &result, &err)
+		return result, err
+	}
+
+	for i := 1; i < degradedRetryBudget; i++ {
+		_, err := fail("connection refused")
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(conditions.Has(keystonev1.DegradedCondition)).To(BeFalse())
+	}
+
+	result, err := fail("connection refused")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(Equal(degradedRequeueAfter))
+	degraded := conditions.Get(keystonev1.DegradedCondition)
+	g.Expect(degraded.Message).To(Equal(fmt.Sprintf(keystonev1.DegradedMessage, "connection refused")))
+	ready := conditions.Get(condition.ReadyCondition)
+	g.Expect(ready.Message).To(Equal(keystonev1.DegradedReadyMessage))
+
+	// further failures, even with other errors, do not change the status
+	// until degradedRequeueAfter passed
+	before := conditions.DeepCopy()
+	for i := 0; i < 3; i++ {
+		result, err = fail(fmt.Sprintf("request %d timed out", i))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.RequeueAfter).To(Equal(degradedRequeueAfter))
+	}
+	g.Expect(conditions).To(Equal(before))
+
+	tracker.failures[name].reportedAt = time.Now().Add(-degradedRequeueAfter)
+	_, err = fail("connection refused")
+	g.Expect(err).ToNot(HaveOccurred())
+	degraded = conditions.Get(keystonev1.DegradedCondition)
+	g.Expect(degraded.Message).To(Equal(fmt.Sprintf(keystonev1.DegradedMessage,
+		"request 1 timed out; request 2 timed out; connection refused")))
+}
+
+func TestDegradedTrackerForget(t *testing.T) {
+	g := NewWithT(t)
+
+	tracker := degradedTracker{}
+	conditions := condition.Conditions{}
+	failed := types.NamespacedName{Namespace: "openstack", Name: "failed"}
+	succeeded := types.NamespacedName{Namespace: "openstack", Name: "succeeded"}
+
+	for _, name := range []types.NamespacedName{failed, succeeded} {
+		result := ctrl.Result{}
+		err := errors.New("connection refused")
+		tracker.handleResult(logr.Discard(), name, &conditions, &result, &err)
+	}
+	g.Expect(tracker.failures).To(HaveLen(2))
+
+	result := ctrl.Result{}
+	var err error
+	tracker.handleResult(logr.Discard(), succeeded, &conditions, &result, &err)
+	g.Expect(tracker.failures).To(HaveLen(1))
+
+	tracker.forget(failed)
+	g.Expect(tracker.failures).To(BeEmpty())
+}
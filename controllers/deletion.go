@@ -0,0 +1,71 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// namespaceDeleting - returns true if the namespace is being deleted or is
+// already gone
+func namespaceDeleting(ctx context.Context, kclient kubernetes.Interface, namespace string) (bool, error) {
+	ns, err := kclient.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return !ns.DeletionTimestamp.IsZero(), nil
+}
+
+// forceCleanup - returns true if the ForceCleanupAnnotation is set on the
+// object
+func forceCleanup(obj client.Object) bool {
+	return obj.GetAnnotations()[keystonev1.ForceCleanupAnnotation] == "true"
+}
+
+// skipKeystoneCleanup - returns true if a CR being deleted can skip the
+// cleanup of its resources on the keystone side and only remove its
+// finalizers. This is the case if the KeystoneAPI is being deleted, as its
+// database goes away as well, or if the whole namespace is being deleted,
+// which deletes the KeystoneAPI in any order with the other CRs. Talking to a
+// keystone API being deleted would only block the deletion. The
+// ForceCleanupAnnotation on the CR or the KeystoneAPI skips the cleanup as
+// well.
+func skipKeystoneCleanup(
+	ctx context.Context,
+	log logr.Logger,
+	kclient kubernetes.Interface,
+	instance client.Object,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (bool, error) {
+	if !keystoneAPI.DeletionTimestamp.IsZero() {
+		return true, nil
+	}
+	if forceCleanup(instance) || forceCleanup(keystoneAPI) {
+		log.Info("Skipping the cleanup in keystone", "annotation", keystonev1.ForceCleanupAnnotation)
+		return true, nil
+	}
+	return namespaceDeleting(ctx, kclient, keystoneAPI.Namespace)
+}
@@ -0,0 +1,76 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	keystone "github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	mariadbv1 "github.com/openstack-k8s-operators/mariadb-operator/api/v1beta1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ensureDevModeSecret - creates the Secret with the admin password in dev
+// mode unless it exists. The Secret is owned by the KeystoneAPI and gets
+// deleted with it.
+func (r *KeystoneAPIReconciler) ensureDevModeSecret(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) error {
+	_, _, err := oko_secret.GetSecret(ctx, h, instance.Spec.Secret, instance.Namespace)
+	if !k8s_errors.IsNotFound(err) {
+		return err
+	}
+
+	tmpl := []util.Template{
+		{
+			Name:      instance.Spec.Secret,
+			Namespace: instance.Namespace,
+			Type:      util.TemplateTypeNone,
+			CustomData: map[string]string{
+				"AdminPassword": keystone.GenerateFernetKey(r.GetLogger(ctx)),
+			},
+			Labels: labels.GetLabels(instance, labels.GetGroupLabel(keystone.ServiceName), map[string]string{}),
+		},
+	}
+	err = oko_secret.EnsureSecrets(ctx, h, instance, tmpl, nil)
+	if err != nil {
+		return err
+	}
+	r.GetLogger(ctx).Info("Generated the admin password Secret for dev mode", "secret", instance.Spec.Secret)
+
+	return nil
+}
+
+// devModeDatabaseConfig - returns the connection settings of the SQLite
+// database of dev mode, the keystone API pod creates it on startup
+func devModeDatabaseConfig(instance *keystonev1.KeystoneAPI) *databaseConfig {
+	// no MariaDBAccount gets used in dev mode
+	instance.Status.Conditions.Remove(mariadbv1.MariaDBAccountReadyCondition)
+	instance.Status.DatabaseHostname = ""
+	instance.Status.Conditions.MarkTrue(condition.DBReadyCondition, keystonev1.KeystoneDevModeDBMessage)
+
+	return &databaseConfig{
+		connection: keystone.DevModeDatabaseConnection,
+	}
+}
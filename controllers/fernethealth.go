@@ -0,0 +1,168 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	keystone "github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileFernetKeysHealth - updates the fernet key metrics and reports in
+// the FernetKeysReady condition if the rotation is overdue, the fernet keys
+// secret does not hold the expected number of keys or not all keystone pods
+// use the current keys. Tokens issued with a key a pod does not have fail to
+// validate on that pod. Once all pods use the current keys the keys get
+// recorded as distributed, see fernetKeysDistributed.
+func (r *KeystoneAPIReconciler) reconcileFernetKeysHealth(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	fernetKeysSecret *corev1.Secret,
+	fernetHash string,
+	serviceLabels map[string]string,
+) error {
+	rotationPeriod := time.Duration(keystone.DefaultFernetRotationDays) * 24 * time.Hour
+	if instance.Spec.FernetRotationDays != nil {
+		rotationPeriod = time.Duration(*instance.Spec.FernetRotationDays) * 24 * time.Hour
+	}
+	expectedKeys := keystone.DefaultFernetMaxActiveKeys
+	if instance.Spec.FernetMaxActiveKeys != nil {
+		expectedKeys = int(*instance.Spec.FernetMaxActiveKeys)
+	}
+
+	outdated, running, err := r.countOutdatedFernetPods(ctx, instance, fernetHash, serviceLabels)
+	if err != nil {
+		return err
+	}
+	distribution := instance.Status.FernetKeysDistribution
+	if outdated == 0 && running > 0 && (distribution == nil || distribution.Hash != fernetHash) {
+		instance.Status.FernetKeysDistribution = &keystonev1.FernetKeysDistributionStatus{
+			Hash:            fernetHash,
+			DistributedTime: ptr.To(metav1.Now()),
+		}
+		r.GetLogger(ctx).Info("Fernet keys distributed to all keystone pods", "pods", running)
+	}
+
+	name := types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}
+	rotatedAt, err := time.Parse(time.RFC3339, fernetKeysSecret.Annotations[fernetRotatedAtAnnotation])
+	if err != nil {
+		// the annotation gets added on the next rotation
+		fernetKeys.delete(name)
+	} else {
+		fernetKeys.set(name, rotatedAt, rotationPeriod)
+		if time.Since(rotatedAt) > rotationPeriod {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneFernetKeysReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneFernetKeysRotationOverdueMessage,
+				rotatedAt.Format(time.RFC3339)))
+			return nil
+		}
+	}
+
+	keys := 0
+	for key := range fernetKeysSecret.Data {
+		if strings.HasPrefix(key, "FernetKeys") {
+			keys++
+		}
+	}
+	if keys != expectedKeys {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneFernetKeysReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneFernetKeysCountMismatchMessage,
+			keys,
+			expectedKeys))
+		return nil
+	}
+
+	if outdated > 0 {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneFernetKeysReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneFernetKeysPodsMismatchMessage,
+			outdated,
+			running))
+		return nil
+	}
+
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneFernetKeysReadyCondition, keystonev1.KeystoneFernetKeysReadyMessage)
+	return nil
+}
+
+// countOutdatedFernetPods - returns the number of running keystone pods which
+// do not use the fernet keys with the hash and the number of running pods.
+// The keys get loaded on container start, a pod created with the keys counts
+// as outdated until it is ready.
+func (r *KeystoneAPIReconciler) countOutdatedFernetPods(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	fernetHash string,
+	serviceLabels map[string]string,
+) (int, int, error) {
+	pods := &corev1.PodList{}
+	err := r.Client.List(ctx, pods,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(serviceLabels))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	running := 0
+	outdated := 0
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		running++
+		if pod.Annotations[keystone.FernetKeysHashAnnotation] != fernetHash || !isPodReady(&pod) {
+			outdated++
+		}
+	}
+	return outdated, running, nil
+}
+
+// isPodReady - returns true if the Ready condition of the pod is true
+func isPodReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// fernetKeysDistributed - returns true if all keystone pods used the fernet
+// keys with the hash. The staged key gets promoted to the primary key on a
+// rotation, rotating again before all pods got the staged key lets pods issue
+// tokens the pods with the previous keys fail to validate. Without a recorded
+// distribution, e.g. after an operator update, the keys count as distributed.
+func fernetKeysDistributed(instance *keystonev1.KeystoneAPI, fernetHash string) bool {
+	distribution := instance.Status.FernetKeysDistribution
+	return distribution == nil || distribution.Hash == fernetHash
+}
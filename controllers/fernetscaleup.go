@@ -0,0 +1,85 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	keystone "github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fernetScaleUpRequeue - requeue interval while a scale up waits for all pods
+// to use the current fernet keys
+const fernetScaleUpRequeue = 10 * time.Second
+
+// guardFernetScaleUp - pods added while the fernet keys roll out get keys the
+// pods of the previous revision do not have yet, tokens issued by the new pods
+// fail to validate on the old ones. If the deployment gets scaled up while not
+// all existing pods carry the current fernet keys hash annotation, the
+// replicas of the deployment definition are kept at the current ones.
+// Returns true if the scale up got held back.
+func (r *KeystoneAPIReconciler) guardFernetScaleUp(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	deplDef *appsv1.Deployment,
+	serviceLabels map[string]string,
+	fernetHash string,
+) (bool, error) {
+	Log := r.GetLogger(ctx)
+
+	current := &appsv1.Deployment{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: deplDef.Name, Namespace: deplDef.Namespace}, current)
+	if k8s_errors.IsNotFound(err) {
+		// initial deployment, all pods get the current keys
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if current.Spec.Replicas == nil || deplDef.Spec.Replicas == nil ||
+		*deplDef.Spec.Replicas <= *current.Spec.Replicas {
+		return false, nil
+	}
+
+	pods := &corev1.PodList{}
+	err = r.Client.List(ctx, pods,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(serviceLabels))
+	if err != nil {
+		return false, err
+	}
+
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if pod.Annotations[keystone.FernetKeysHashAnnotation] != fernetHash {
+			Log.Info(fmt.Sprintf("Pod %s does not use the current fernet keys, holding back scale up to %d replicas",
+				pod.Name, *deplDef.Spec.Replicas))
+			deplDef.Spec.Replicas = current.Spec.Replicas
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
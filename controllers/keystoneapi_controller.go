@@ -21,14 +21,18 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	networkv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	routev1 "github.com/openshift/api/route/v1"
 	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
 	rabbitmqv1 "github.com/openstack-k8s-operators/infra-operator/apis/rabbitmq/v1beta1"
 	topologyv1 "github.com/openstack-k8s-operators/infra-operator/apis/topology/v1beta1"
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/identity"
 	keystone "github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
 	"github.com/openstack-k8s-operators/lib-common/modules/common"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	configmap "github.com/openstack-k8s-operators/lib-common/modules/common/configmap"
@@ -45,7 +49,9 @@ import (
 	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
 	util "github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
 	mariadbv1 "github.com/openstack-k8s-operators/mariadb-operator/api/v1beta1"
+	"golang.org/x/exp/slices"
 
 	"gopkg.in/yaml.v3"
 	appsv1 "k8s.io/api/apps/v1"
@@ -53,14 +59,18 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/ptr"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -96,11 +106,22 @@ type KeystoneAPIReconciler struct {
 	client.Client
 	Kclient kubernetes.Interface
 	Scheme  *runtime.Scheme
+	// Config - rest config used to exec into the pods for graceful config
+	// reloads
+	Config *rest.Config
+	// OpenStackClient - returns the keystone admin client, the client of
+	// the KeystoneAPI if not set. Tests set the factory of a fake.
+	OpenStackClient openstackclient.Factory
+
+	degraded degradedTracker
+	// routeAPI - the cluster serves the OpenShift Route API
+	routeAPI bool
 }
 
 // +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneservices;keystoneendpoints;keystonecatalogs;keystonecatalogaudits;keystonecredentials;keystoneec2credentials;keystoneendpointgroups;keystonelimits;keystonemappings;keystoneregisteredlimits;keystoneusers;keystoneuserbatches,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete;
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete;
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete;
@@ -111,6 +132,7 @@ type KeystoneAPIReconciler struct {
 // +kubebuilder:rbac:groups=mariadb.openstack.org,resources=mariadbdatabases,verbs=get;list;watch;create;update;patch;delete;
 // +kubebuilder:rbac:groups=mariadb.openstack.org,resources=mariadbaccounts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=mariadb.openstack.org,resources=mariadbaccounts/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=mariadb.openstack.org,resources=galeras,verbs=get;list;watch
 // +kubebuilder:rbac:groups=memcached.openstack.org,resources=memcacheds,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=memcached.openstack.org,resources=memcacheds/finalizers,verbs=update;patch
 // +kubebuilder:rbac:groups=k8s.cni.cncf.io,resources=network-attachment-definitions,verbs=get;list;watch
@@ -124,6 +146,8 @@ type KeystoneAPIReconciler struct {
 // keystone service account permissions that are needed to grant permission to the above
 // +kubebuilder:rbac:groups="security.openshift.io",resourceNames=anyuid,resources=securitycontextconstraints,verbs=use
 // +kubebuilder:rbac:groups="",resources=pods,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups="",resources=pods/exec,verbs=create
+// +kubebuilder:rbac:groups=keda.sh,resources=scaledobjects,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile reconcile keystone API requests
 func (r *KeystoneAPIReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
@@ -136,6 +160,7 @@ func (r *KeystoneAPIReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers. Return and don't requeue.
+			r.degraded.forget(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -173,17 +198,14 @@ func (r *KeystoneAPIReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			panic(r)
 		}
 		// update the Ready condition based on the sub conditions
-		if instance.Status.Conditions.AllSubConditionIsTrue() {
-			instance.Status.Conditions.MarkTrue(
-				condition.ReadyCondition, condition.ReadyMessage)
-		} else {
-			// something is not ready so reset the Ready condition
-			instance.Status.Conditions.MarkUnknown(
-				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
-			// and recalculate it based on the state of the rest of the conditions
-			instance.Status.Conditions.Set(
-				instance.Status.Conditions.Mirror(condition.ReadyCondition))
-		}
+		updateReadyCondition(&instance.Status.Conditions,
+			instance.Generation, instance.Status.ObservedGeneration)
+		recordReconcile(instance.Spec, _err,
+			instance.Status.Conditions.IsTrue(condition.ReadyCondition),
+			&instance.Status.LastReconcileTime,
+			&instance.Status.LastSuccessfulReconcile,
+			&instance.Status.AppliedSpecHash)
+		r.degraded.handleResult(Log, req.NamespacedName, &instance.Status.Conditions, &result, &_err)
 		condition.RestoreLastTransitionTimes(&instance.Status.Conditions, savedConditions)
 		err := helper.PatchInstance(ctx, instance)
 		if err != nil {
@@ -208,11 +230,33 @@ func (r *KeystoneAPIReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		condition.UnknownCondition(condition.NetworkAttachmentsReadyCondition, condition.InitReason, condition.NetworkAttachmentsReadyInitMessage),
 		condition.UnknownCondition(condition.CronJobReadyCondition, condition.InitReason, condition.CronJobReadyInitMessage),
 		condition.UnknownCondition(condition.TLSInputReadyCondition, condition.InitReason, condition.InputReadyInitMessage),
+		condition.UnknownCondition(keystonev1.KeystoneBootstrapRolesReadyCondition, condition.InitReason, keystonev1.KeystoneBootstrapRolesReadyInitMessage),
+		condition.UnknownCondition(keystonev1.KeystoneImpliedRolesReadyCondition, condition.InitReason, keystonev1.KeystoneImpliedRolesReadyInitMessage),
+		condition.UnknownCondition(keystonev1.KeystoneGreenDeploymentReadyCondition, condition.InitReason, keystonev1.KeystoneGreenDeploymentReadyInitMessage),
+		condition.UnknownCondition(keystonev1.KeystoneFernetKeysReadyCondition, condition.InitReason, keystonev1.KeystoneFernetKeysReadyInitMessage),
 		// service account, role, rolebinding conditions
 		condition.UnknownCondition(condition.ServiceAccountReadyCondition, condition.InitReason, condition.ServiceAccountReadyInitMessage),
 		condition.UnknownCondition(condition.RoleReadyCondition, condition.InitReason, condition.RoleReadyInitMessage),
 		condition.UnknownCondition(condition.RoleBindingReadyCondition, condition.InitReason, condition.RoleBindingReadyInitMessage),
 	)
+	if instance.Spec.ImageVerification != nil {
+		cl.Set(condition.UnknownCondition(keystonev1.KeystoneImageVerifiedCondition, condition.InitReason, keystonev1.KeystoneImageVerifiedInitMessage))
+	}
+	if instance.Spec.Autoscaling != nil {
+		cl.Set(condition.UnknownCondition(keystonev1.KeystoneAutoscalingReadyCondition, condition.InitReason, keystonev1.KeystoneAutoscalingReadyInitMessage))
+	}
+	if instance.Spec.PublicDeployment != nil {
+		cl.Set(condition.UnknownCondition(keystonev1.KeystonePublicDeploymentReadyCondition, condition.InitReason, keystonev1.KeystonePublicDeploymentReadyInitMessage))
+	}
+	if instance.Spec.HasLDAPDomains() {
+		cl.Set(condition.UnknownCondition(keystonev1.KeystoneLDAPReadyCondition, condition.InitReason, keystonev1.KeystoneLDAPReadyInitMessage))
+	}
+	if instance.Spec.PublicDNS != nil {
+		cl.Set(condition.UnknownCondition(keystonev1.KeystonePublicDNSReadyCondition, condition.InitReason, keystonev1.KeystonePublicDNSReadyInitMessage))
+	}
+	if instance.Spec.TokenSmokeTest != nil {
+		cl.Set(condition.UnknownCondition(keystonev1.KeystoneTokenSmokeTestReadyCondition, condition.InitReason, keystonev1.KeystoneTokenSmokeTestReadyInitMessage))
+	}
 
 	instance.Status.Conditions.Init(&cl)
 	instance.Status.ObservedGeneration = instance.Generation
@@ -254,6 +298,11 @@ const (
 	tlsAPIPublicField                   = ".spec.tls.api.public.secretName"
 	topologyField                       = ".spec.topologyRef.Name"
 	httpdCustomServiceConfigSecretField = ".spec.httpdCustomization.customServiceConfigSecret" // #nosec G101
+	domainConfigSourceField             = ".spec.domainConfigs"
+	kerberosKeytabSecretField           = ".spec.kerberos.keytabSecretRef" // #nosec G101
+	tokenlessCABundleSecretField        = ".spec.tokenlessAuth.caBundleSecretRef"
+	postgreSQLSecretField               = ".spec.postgreSQL.secretName" // #nosec G101
+	customServiceConfigLayersField      = ".spec.customServiceConfigLayers"
 )
 
 var allWatchFields = []string{
@@ -263,6 +312,11 @@ var allWatchFields = []string{
 	tlsAPIPublicField,
 	httpdCustomServiceConfigSecretField,
 	topologyField,
+	domainConfigSourceField,
+	kerberosKeytabSecretField,
+	tokenlessCABundleSecretField,
+	postgreSQLSecretField,
+	customServiceConfigLayersField,
 }
 
 // SetupWithManager -
@@ -329,6 +383,81 @@ func (r *KeystoneAPIReconciler) SetupWithManager(ctx context.Context, mgr ctrl.M
 		return err
 	}
 
+	// index domainConfigSourceField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneAPI{}, domainConfigSourceField, func(rawObj client.Object) []string {
+		// Extract the Secret and ConfigMap names from the spec, if provided
+		cr := rawObj.(*keystonev1.KeystoneAPI)
+		names := []string{}
+		for _, src := range cr.Spec.DomainConfigs {
+			if src.SecretKeyRef != nil {
+				names = append(names, src.SecretKeyRef.Name)
+			}
+			if src.ConfigMapKeyRef != nil {
+				names = append(names, src.ConfigMapKeyRef.Name)
+			}
+			if src.LDAP != nil && src.LDAP.CACertSecretRef != nil {
+				names = append(names, src.LDAP.CACertSecretRef.Name)
+			}
+		}
+		return names
+	}); err != nil {
+		return err
+	}
+
+	// index kerberosKeytabSecretField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneAPI{}, kerberosKeytabSecretField, func(rawObj client.Object) []string {
+		// Extract the secret name from the spec, if one is provided
+		cr := rawObj.(*keystonev1.KeystoneAPI)
+		if cr.Spec.Kerberos == nil {
+			return nil
+		}
+		return []string{cr.Spec.Kerberos.KeytabSecretRef.Name}
+	}); err != nil {
+		return err
+	}
+
+	// index tokenlessCABundleSecretField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneAPI{}, tokenlessCABundleSecretField, func(rawObj client.Object) []string {
+		// Extract the secret name from the spec, if one is provided
+		cr := rawObj.(*keystonev1.KeystoneAPI)
+		if cr.Spec.TokenlessAuth == nil {
+			return nil
+		}
+		return []string{cr.Spec.TokenlessAuth.CABundleSecretRef.Name}
+	}); err != nil {
+		return err
+	}
+
+	// index postgreSQLSecretField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneAPI{}, postgreSQLSecretField, func(rawObj client.Object) []string {
+		// Extract the secret name from the spec, if one is provided
+		cr := rawObj.(*keystonev1.KeystoneAPI)
+		if cr.Spec.PostgreSQL == nil {
+			return nil
+		}
+		return []string{cr.Spec.PostgreSQL.SecretName}
+	}); err != nil {
+		return err
+	}
+
+	// index customServiceConfigLayersField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneAPI{}, customServiceConfigLayersField, func(rawObj client.Object) []string {
+		// Extract the Secret and ConfigMap names from the spec, if provided
+		cr := rawObj.(*keystonev1.KeystoneAPI)
+		names := []string{}
+		for _, layer := range cr.Spec.CustomServiceConfigLayers {
+			if layer.SecretKeyRef != nil {
+				names = append(names, layer.SecretKeyRef.Name)
+			}
+			if layer.ConfigMapKeyRef != nil {
+				names = append(names, layer.ConfigMapKeyRef.Name)
+			}
+		}
+		return names
+	}); err != nil {
+		return err
+	}
+
 	// index topologyField
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneAPI{}, topologyField, func(rawObj client.Object) []string {
 		// Extract the topology name from the spec, if one is provided
@@ -370,7 +499,45 @@ func (r *KeystoneAPIReconciler) SetupWithManager(ctx context.Context, mgr ctrl.M
 		return nil
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	routeFn := func(ctx context.Context, o client.Object) []reconcile.Request {
+		result := []reconcile.Request{}
+		route := o.(*routev1.Route)
+
+		// get all KeystoneAPI CRs
+		keystoneAPIs := &keystonev1.KeystoneAPIList{}
+		listOpts := []client.ListOption{
+			client.InNamespace(o.GetNamespace()),
+		}
+		if err := r.Client.List(ctx, keystoneAPIs, listOpts...); err != nil {
+			Log.Error(err, "Unable to retrieve KeystoneAPI CRs %w")
+			return nil
+		}
+
+		for _, cr := range keystoneAPIs.Items {
+			if route.Spec.To.Name == cr.Name+"-"+string(service.EndpointPublic) {
+				name := client.ObjectKey{
+					Namespace: o.GetNamespace(),
+					Name:      cr.Name,
+				}
+				Log.Info(fmt.Sprintf("Route %s exposes KeystoneAPI CR %s", o.GetName(), cr.Name))
+				result = append(result, reconcile.Request{NamespacedName: name})
+			}
+		}
+		if len(result) > 0 {
+			return result
+		}
+		return nil
+	}
+
+	// the Route API is only served by OpenShift clusters
+	_, err := mgr.GetRESTMapper().RESTMapping(
+		schema.GroupKind{Group: routev1.GroupName, Kind: "Route"}, routev1.GroupVersion.Version)
+	if err != nil && !meta.IsNoMatchError(err) {
+		return err
+	}
+	r.routeAPI = err == nil
+
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&keystonev1.KeystoneAPI{}).
 		Owns(&mariadbv1.MariaDBDatabase{}).
 		Owns(&mariadbv1.MariaDBAccount{}).
@@ -390,10 +557,48 @@ func (r *KeystoneAPIReconciler) SetupWithManager(ctx context.Context, mgr ctrl.M
 			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSrc),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
 		).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSrc),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
 		Watches(&topologyv1.Topology{},
 			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSrc),
 			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
-		Complete(r)
+		Watches(&keystonev1.KeystoneService{},
+			handler.EnqueueRequestsFromMapFunc(r.findKeystoneAPIsForCatalog),
+			builder.WithPredicates(predicate.Or(catalogChangedPredicate, readinessChangedPredicate))).
+		Watches(&keystonev1.KeystoneEndpoint{},
+			handler.EnqueueRequestsFromMapFunc(r.findKeystoneAPIsForCatalog),
+			builder.WithPredicates(predicate.Or(catalogChangedPredicate, readinessChangedPredicate))).
+		Watches(&keystonev1.KeystoneCatalog{},
+			handler.EnqueueRequestsFromMapFunc(r.findKeystoneAPIsForCatalog),
+			builder.WithPredicates(predicate.Or(catalogChangedPredicate, readinessChangedPredicate)))
+	// update the readiness summary of the other keystone CRs
+	for _, obj := range []client.Object{
+		&keystonev1.KeystoneCatalogAudit{},
+		&keystonev1.KeystoneCredential{},
+		&keystonev1.KeystoneEC2Credential{},
+		&keystonev1.KeystoneEndpointGroup{},
+		&keystonev1.KeystoneLimit{},
+		&keystonev1.KeystoneMapping{},
+		&keystonev1.KeystoneRegisteredLimit{},
+		&keystonev1.KeystoneUser{},
+		&keystonev1.KeystoneUserBatch{},
+	} {
+		b = b.Watches(obj,
+			handler.EnqueueRequestsFromMapFunc(r.findKeystoneAPIsForCatalog),
+			builder.WithPredicates(readinessChangedPredicate))
+	}
+	if r.routeAPI {
+		// re-register the identity endpoints when the hostname or the TLS
+		// mode of the route changes
+		b = b.Watches(&routev1.Route{},
+			handler.EnqueueRequestsFromMapFunc(routeFn),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	}
+
+	return b.Complete(r)
 }
 
 func (r *KeystoneAPIReconciler) findObjectsForSrc(ctx context.Context, src client.Object) []reconcile.Request {
@@ -439,15 +644,21 @@ func (r *KeystoneAPIReconciler) reconcileDelete(ctx context.Context, instance *k
 	// case of the Memcached dependency, if Memcached is deleted before all Keystone
 	// cleanup has finished, then the Keystone logic will likely hit a 500 error and
 	// thus its deletion will hang indefinitely.
-	for _, finalizer := range instance.Finalizers {
-		// If this finalizer is not our KeystoneAPI finalizer, then it is either
-		// a KeystoneService or KeystoneEndpointer finalizer, which indicates that
-		// there is more Keystone processing that needs to finish before we can
-		// allow our DB and Memcached dependencies to be potentially deleted
-		// themselves
-		if finalizer != helper.GetFinalizer() {
-			return ctrl.Result{}, nil
-		}
+	// Any finalizer other than our KeystoneAPI finalizer is either a
+	// KeystoneService, KeystoneEndpoint or other CR finalizer, which indicates
+	// that there is more Keystone processing that needs to finish before we
+	// can allow our DB and Memcached dependencies to be potentially deleted
+	// themselves. The DeletionBlocked condition names the CRs.
+	if dependents := instance.GetDependents(); len(dependents) > 0 {
+		Log.Info("KeystoneAPI deletion waits for dependent CRs", "dependents", dependents)
+		instance.Status.Conditions.Set(&condition.Condition{
+			Type:     keystonev1.KeystoneDeletionBlockedCondition,
+			Status:   corev1.ConditionTrue,
+			Reason:   condition.RequestedReason,
+			Severity: condition.SeverityInfo,
+			Message:  fmt.Sprintf(keystonev1.KeystoneDeletionBlockedMessage, strings.Join(dependents, ", ")),
+		})
+		return ctrl.Result{}, nil
 	}
 
 	// Remove finalizer on the Topology CR
@@ -475,18 +686,26 @@ func (r *KeystoneAPIReconciler) reconcileDelete(ctx context.Context, instance *k
 		}
 	}
 
-	// remove db finalizer before the keystone one
-	db, err := mariadbv1.GetDatabaseByNameAndAccount(ctx, helper, keystone.DatabaseCRName, instance.Spec.DatabaseAccount, instance.Namespace)
-	if err != nil && !k8s_errors.IsNotFound(err) {
-		return ctrl.Result{}, err
-	}
-
-	if !k8s_errors.IsNotFound(err) {
-		if err := db.DeleteFinalizer(ctx, helper); err != nil {
+	// remove db finalizers before the keystone one
+	for _, account := range []string{instance.Spec.DatabaseAccount, instance.Spec.DatabaseSyncAccount} {
+		if account == "" {
+			continue
+		}
+		db, err := mariadbv1.GetDatabaseByNameAndAccount(ctx, helper, keystone.DatabaseCRName, account, instance.Namespace)
+		if err != nil && !k8s_errors.IsNotFound(err) {
 			return ctrl.Result{}, err
 		}
+
+		if !k8s_errors.IsNotFound(err) {
+			if err := db.DeleteFinalizer(ctx, helper); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
 	}
 
+	fernetKeys.delete(types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace})
+	deleteTokenSmokeTestMetrics(types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace})
+
 	// Service is deleted so remove the finalizer.
 	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
 	Log.Info("Reconciled Service delete successfully")
@@ -494,18 +713,13 @@ func (r *KeystoneAPIReconciler) reconcileDelete(ctx context.Context, instance *k
 	return ctrl.Result{}, nil
 }
 
-func (r *KeystoneAPIReconciler) reconcileInit(
+// reconcileRbac - creates the service account, role and binding the keystone
+// pods and jobs run with
+func (r *KeystoneAPIReconciler) reconcileRbac(
 	ctx context.Context,
 	instance *keystonev1.KeystoneAPI,
-	helper *helper.Helper,
-	serviceLabels map[string]string,
-	serviceAnnotations map[string]string,
+	h *helper.Helper,
 ) (ctrl.Result, error) {
-	Log := r.GetLogger(ctx)
-	Log.Info("Reconciling Service init")
-	//
-	// Service account, role, binding
-	//
 	rbacRules := []rbacv1.PolicyRule{
 		{
 			APIGroups:     []string{"security.openshift.io"},
@@ -519,51 +733,83 @@ func (r *KeystoneAPIReconciler) reconcileInit(
 			Verbs:     []string{"create", "get", "list", "watch", "update", "patch", "delete"},
 		},
 	}
-	rbacResult, err := common_rbac.ReconcileRbac(ctx, helper, instance, rbacRules)
-	if err != nil {
-		return rbacResult, err
-	} else if (rbacResult != ctrl.Result{}) {
-		return rbacResult, nil
-	}
+	return common_rbac.ReconcileRbac(ctx, h, instance, rbacRules)
+}
+
+func (r *KeystoneAPIReconciler) reconcileInit(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+	serviceLabels map[string]string,
+	serviceAnnotations map[string]string,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Service init")
 
 	//
 	// run keystone db sync
 	//
-	dbSyncHash := instance.Status.Hash[keystonev1.DbSyncHash]
-	jobDef := keystone.DbSyncJob(instance, serviceLabels, serviceAnnotations)
-	dbSyncjob := job.NewJob(
-		jobDef,
-		keystonev1.DbSyncHash,
-		instance.Spec.PreserveJobs,
-		5*time.Second,
-		dbSyncHash,
-	)
-	ctrlResult, err := dbSyncjob.DoJob(
-		ctx,
-		helper,
-	)
-	if (ctrlResult != ctrl.Result{}) {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.DBSyncReadyCondition,
-			condition.RequestedReason,
-			condition.SeverityInfo,
-			condition.DBSyncReadyRunningMessage))
-		return ctrlResult, nil
-	}
-	if err != nil {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.DBSyncReadyCondition,
-			condition.ErrorReason,
-			condition.SeverityWarning,
-			condition.DBSyncReadyErrorMessage,
-			err.Error()))
-		return ctrl.Result{}, err
-	}
-	if dbSyncjob.HasChanged() {
-		instance.Status.Hash[keystonev1.DbSyncHash] = dbSyncjob.GetHash()
-		Log.Info(fmt.Sprintf("Job %s hash added - %s", jobDef.Name, instance.Status.Hash[keystonev1.DbSyncHash]))
+	// the keystone API pod runs db sync and bootstrap in dev mode
+	if instance.Spec.DevMode {
+		instance.Status.Conditions.MarkTrue(condition.DBSyncReadyCondition, keystonev1.KeystoneDevModeDBMessage)
+	} else {
+		// the DatabaseAccount may only read and write rows before db sync
+		// runs with the DatabaseSyncAccount
+		ctrlResult, err := r.reconcileDBGrants(ctx, instance, helper, serviceLabels, serviceAnnotations)
+		if (ctrlResult != ctrl.Result{}) {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.DBSyncReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				condition.DBSyncReadyRunningMessage))
+			return ctrlResult, nil
+		}
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.DBSyncReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.DBSyncReadyErrorMessage,
+				keystonev1.KeystoneErrorMessage(err)))
+			return ctrl.Result{}, err
+		}
+
+		dbSyncHash := instance.Status.Hash[keystonev1.DbSyncHash]
+		jobDef := keystone.DbSyncJob(instance, serviceLabels, serviceAnnotations)
+		dbSyncjob := job.NewJob(
+			jobDef,
+			keystonev1.DbSyncHash,
+			instance.Spec.PreserveJobs,
+			5*time.Second,
+			dbSyncHash,
+		)
+		ctrlResult, err = dbSyncjob.DoJob(
+			ctx,
+			helper,
+		)
+		if (ctrlResult != ctrl.Result{}) {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.DBSyncReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				condition.DBSyncReadyRunningMessage))
+			return ctrlResult, nil
+		}
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.DBSyncReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.DBSyncReadyErrorMessage,
+				keystonev1.KeystoneErrorMessage(err)))
+			return ctrl.Result{}, err
+		}
+		if dbSyncjob.HasChanged() {
+			instance.Status.Hash[keystonev1.DbSyncHash] = dbSyncjob.GetHash()
+			Log.Info(fmt.Sprintf("Job %s hash added - %s", jobDef.Name, instance.Status.Hash[keystonev1.DbSyncHash]))
+		}
+		instance.Status.Conditions.MarkTrue(condition.DBSyncReadyCondition, condition.DBSyncReadyMessage)
 	}
-	instance.Status.Conditions.MarkTrue(condition.DBSyncReadyCondition, condition.DBSyncReadyMessage)
 
 	// run keystone db sync - end
 
@@ -580,6 +826,7 @@ func (r *KeystoneAPIReconciler) reconcileInit(
 	}
 
 	apiEndpoints := make(map[string]string)
+	serviceEndpoints := make(map[string]string)
 	for endpointType, data := range keystoneEndpoints {
 		endpointTypeStr := string(endpointType)
 		endpointName := instance.Name + "-" + endpointTypeStr
@@ -597,18 +844,27 @@ func (r *KeystoneAPIReconciler) reconcileInit(
 		)
 
 		// Create the service
+		svcDef := service.GenericService(&service.GenericServiceDetails{
+			Name:      endpointName,
+			Namespace: instance.Namespace,
+			Labels:    exportLabels,
+			Selector:  keystone.ServiceSelector(instance, serviceLabels, endpointType),
+			Port: service.GenericServicePort{
+				Name:     endpointName,
+				Port:     data.Port,
+				Protocol: corev1.ProtocolTCP,
+			},
+		})
+		// the tokenless vhost serves the certificate of the internal endpoint
+		if endpointType == service.EndpointInternal && instance.Spec.TokenlessAuth != nil {
+			svcDef.Spec.Ports = append(svcDef.Spec.Ports, corev1.ServicePort{
+				Name:     instance.Name + "-tokenless",
+				Port:     keystone.KeystoneTokenlessPort,
+				Protocol: corev1.ProtocolTCP,
+			})
+		}
 		svc, err := service.NewService(
-			service.GenericService(&service.GenericServiceDetails{
-				Name:      endpointName,
-				Namespace: instance.Namespace,
-				Labels:    exportLabels,
-				Selector:  serviceLabels,
-				Port: service.GenericServicePort{
-					Name:     endpointName,
-					Port:     data.Port,
-					Protocol: corev1.ProtocolTCP,
-				},
-			}),
+			svcDef,
 			5,
 			&svcOverride.OverrideSpec,
 		)
@@ -618,7 +874,7 @@ func (r *KeystoneAPIReconciler) reconcileInit(
 				condition.ErrorReason,
 				condition.SeverityWarning,
 				condition.CreateServiceReadyErrorMessage,
-				err.Error()))
+				keystonev1.KeystoneErrorMessage(err)))
 
 			return ctrl.Result{}, err
 		}
@@ -627,6 +883,12 @@ func (r *KeystoneAPIReconciler) reconcileInit(
 			service.AnnotationEndpointKey: endpointTypeStr,
 		})
 
+		// external-dns publishes the record from the Service, the route gets
+		// the annotations from SetDefaultRouteAnnotations
+		if endpointType == service.EndpointPublic && instance.Spec.PublicDNS != nil {
+			svc.AddAnnotation(instance.Spec.PublicDNS.GetAnnotations())
+		}
+
 		// add Annotation to whether creating an ingress is required or not
 		if endpointType == service.EndpointPublic && svc.GetServiceType() == corev1.ServiceTypeClusterIP {
 			svc.AddAnnotation(map[string]string{
@@ -650,7 +912,7 @@ func (r *KeystoneAPIReconciler) reconcileInit(
 				condition.ErrorReason,
 				condition.SeverityWarning,
 				condition.CreateServiceReadyErrorMessage,
-				err.Error()))
+				keystonev1.KeystoneErrorMessage(err)))
 
 			return ctrlResult, err
 		} else if (ctrlResult != ctrl.Result{}) {
@@ -669,11 +931,43 @@ func (r *KeystoneAPIReconciler) reconcileInit(
 			data.Protocol = ptr.To(service.ProtocolHTTPS)
 		}
 
+		// the route created for the service is the source of truth for the
+		// exposed hostname and TLS mode
+		endpointURL := svcOverride.EndpointURL
+		if svc.GetAnnotations()[service.AnnotationIngressCreateKey] == "true" {
+			routeURL, err := r.getRouteURL(ctx, instance.Namespace, endpointName)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if routeURL != "" {
+				endpointURL = &routeURL
+			}
+		}
+
 		apiEndpoints[string(endpointType)], err = svc.GetAPIEndpoint(
-			svcOverride.EndpointURL, data.Protocol, data.Path)
+			endpointURL, data.Protocol, data.Path)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		// the URL of the Service itself, e.g. for the admin client when the
+		// endpoint URL of the catalog is not reachable from the operator
+		serviceEndpoints[string(endpointType)], err = svc.GetAPIEndpoint(
+			nil, data.Protocol, data.Path)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
+		if published, ok := instance.Spec.PublishedEndpoints[endpointType]; ok {
+			apiEndpoints[string(endpointType)], err = published.Apply(apiEndpoints[string(endpointType)])
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		if endpointType == service.EndpointPublic && instance.Spec.PublicDNS != nil {
+			apiEndpoints[string(endpointType)], err = r.reconcilePublicDNS(ctx, instance, apiEndpoints[string(endpointType)])
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
 	}
 
 	instance.Status.Conditions.MarkTrue(condition.CreateServiceReadyCondition, condition.CreateServiceReadyMessage)
@@ -682,46 +976,66 @@ func (r *KeystoneAPIReconciler) reconcileInit(
 	// Update instance status with service endpoint url from route host information
 	//
 	instance.Status.APIEndpoints = apiEndpoints
+	instance.Status.ServiceEndpoints = serviceEndpoints
 
 	// expose service - end
 
 	//
 	// BootStrap Job
 	//
-	jobDef = keystone.BootstrapJob(instance, serviceLabels, serviceAnnotations, instance.Status.APIEndpoints)
-	bootstrapjob := job.NewJob(
-		jobDef,
-		keystonev1.BootstrapHash,
-		instance.Spec.PreserveJobs,
-		5*time.Second,
-		instance.Status.Hash[keystonev1.BootstrapHash],
-	)
-	ctrlResult, err = bootstrapjob.DoJob(
-		ctx,
-		helper,
-	)
-	if (ctrlResult != ctrl.Result{}) {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.BootstrapReadyCondition,
-			condition.RequestedReason,
-			condition.SeverityInfo,
-			condition.BootstrapReadyRunningMessage))
-		return ctrlResult, nil
-	}
-	if err != nil {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.BootstrapReadyCondition,
-			condition.ErrorReason,
-			condition.SeverityWarning,
-			condition.BootstrapReadyErrorMessage,
-			err.Error()))
-		return ctrl.Result{}, err
-	}
-	if bootstrapjob.HasChanged() {
-		instance.Status.Hash[keystonev1.BootstrapHash] = bootstrapjob.GetHash()
-		Log.Info(fmt.Sprintf("Job %s hash added - %s", jobDef.Name, instance.Status.Hash[keystonev1.BootstrapHash]))
+	if instance.Spec.DevMode {
+		instance.Status.Conditions.MarkTrue(condition.BootstrapReadyCondition, keystonev1.KeystoneDevModeDBMessage)
+	} else {
+		jobDef := keystone.BootstrapJob(instance, serviceLabels, serviceAnnotations, instance.Status.APIEndpoints)
+		bootstrapjob := job.NewJob(
+			jobDef,
+			keystonev1.BootstrapHash,
+			instance.Spec.PreserveJobs,
+			5*time.Second,
+			instance.Status.Hash[keystonev1.BootstrapHash],
+		)
+		ctrlResult, err := bootstrapjob.DoJob(
+			ctx,
+			helper,
+		)
+		rebootstrapRequest := instance.Annotations[keystonev1.RebootstrapAnnotation]
+		if (ctrlResult != ctrl.Result{}) {
+			if rebootstrapRequest != "" && rebootstrapRequest != instance.Status.LastRebootstrapRequest {
+				instance.Status.Conditions.Set(condition.FalseCondition(
+					condition.BootstrapReadyCondition,
+					condition.RequestedReason,
+					condition.SeverityInfo,
+					keystonev1.KeystoneRebootstrapRunningMessage,
+					keystonev1.RebootstrapAnnotation,
+					rebootstrapRequest))
+				return ctrlResult, nil
+			}
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.BootstrapReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				condition.BootstrapReadyRunningMessage))
+			return ctrlResult, nil
+		}
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.BootstrapReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.BootstrapReadyErrorMessage,
+				keystonev1.KeystoneErrorMessage(err)))
+			return ctrl.Result{}, err
+		}
+		if bootstrapjob.HasChanged() {
+			instance.Status.Hash[keystonev1.BootstrapHash] = bootstrapjob.GetHash()
+			Log.Info(fmt.Sprintf("Job %s hash added - %s", jobDef.Name, instance.Status.Hash[keystonev1.BootstrapHash]))
+		}
+		if rebootstrapRequest != instance.Status.LastRebootstrapRequest {
+			instance.Status.LastRebootstrapRequest = rebootstrapRequest
+			Log.Info(fmt.Sprintf("Bootstrap job re-run for %s=%s completed", keystonev1.RebootstrapAnnotation, rebootstrapRequest))
+		}
+		instance.Status.Conditions.MarkTrue(condition.BootstrapReadyCondition, condition.BootstrapReadyMessage)
 	}
-	instance.Status.Conditions.MarkTrue(condition.BootstrapReadyCondition, condition.BootstrapReadyMessage)
 
 	// run keystone bootstrap - end
 
@@ -767,6 +1081,13 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 	// ConfigMap
 	configMapVars := make(map[string]env.Setter)
 
+	if instance.Spec.DevMode {
+		err := r.ensureDevModeSecret(ctx, helper, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	//
 	// check for required OpenStack secret holding passwords for service/admin user and add hash to the vars map
 	// NOTE: VerifySecret handles the "not found" error and returns RequeueAfter ctrl.Result if so, so we don't
@@ -779,7 +1100,7 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			condition.InputReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrl.Result{}, err
 	} else if (result != ctrl.Result{}) {
 		// This case is "secret not found".  VerifySecret already logs a message for it
@@ -797,9 +1118,23 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 	// run check OpenStack secret - end
 
 	//
-	// create service DB instance
+	// create service DB instance, or use the existing PostgreSQL database
 	//
-	db, result, err := r.ensureDB(ctx, helper, instance)
+	var dbConfig *databaseConfig
+	if instance.Spec.DevMode {
+		dbConfig = devModeDatabaseConfig(instance)
+	} else if instance.Spec.PostgreSQL != nil {
+		dbConfig, result, err = r.ensurePostgreSQL(ctx, helper, instance)
+	} else {
+		var db, syncDB *mariadbv1.Database
+		db, result, err = r.ensureDB(ctx, helper, instance, instance.Spec.DatabaseAccount)
+		if err == nil && (result == ctrl.Result{}) && keystone.UsesDBSyncAccount(instance) {
+			syncDB, result, err = r.ensureDB(ctx, helper, instance, instance.Spec.DatabaseSyncAccount)
+		}
+		if err == nil && (result == ctrl.Result{}) {
+			dbConfig = mariaDBConfig(instance, db, syncDB)
+		}
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	} else if (result != ctrl.Result{}) {
@@ -810,33 +1145,38 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 	//
 	// create RabbitMQ transportURL CR and get the actual URL from the associated secret that is created
 	//
-	transportURL, op, err := r.transportURLCreateOrUpdate(ctx, instance, serviceLabels)
-	if err != nil {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.RabbitMqTransportURLReadyCondition,
-			condition.ErrorReason,
-			condition.SeverityWarning,
-			condition.RabbitMqTransportURLReadyErrorMessage,
-			err.Error()))
-		return ctrl.Result{}, err
-	}
-	if op != controllerutil.OperationResultNone {
-		Log.Info(fmt.Sprintf("TransportURL %s successfully reconciled - operation: %s", transportURL.Name, string(op)))
-	}
-
-	instance.Status.TransportURLSecret = transportURL.Status.SecretName
-
-	if instance.Status.TransportURLSecret == "" {
-		Log.Info(fmt.Sprintf("Waiting for TransportURL %s secret to be created", transportURL.Name))
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.RabbitMqTransportURLReadyCondition,
-			condition.RequestedReason,
-			condition.SeverityInfo,
-			condition.RabbitMqTransportURLReadyRunningMessage))
-		return ctrl.Result{RequeueAfter: time.Duration(10) * time.Second}, nil
+	if instance.Spec.DevMode {
+		instance.Status.TransportURLSecret = ""
+		instance.Status.Conditions.MarkTrue(condition.RabbitMqTransportURLReadyCondition, keystonev1.KeystoneDevModeTransportURLMessage)
+	} else {
+		transportURL, op, err := r.transportURLCreateOrUpdate(ctx, instance, serviceLabels)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.RabbitMqTransportURLReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.RabbitMqTransportURLReadyErrorMessage,
+				keystonev1.KeystoneErrorMessage(err)))
+			return ctrl.Result{}, err
+		}
+		if op != controllerutil.OperationResultNone {
+			Log.Info(fmt.Sprintf("TransportURL %s successfully reconciled - operation: %s", transportURL.Name, string(op)))
+		}
+
+		instance.Status.TransportURLSecret = transportURL.Status.SecretName
+
+		if instance.Status.TransportURLSecret == "" {
+			Log.Info(fmt.Sprintf("Waiting for TransportURL %s secret to be created", transportURL.Name))
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.RabbitMqTransportURLReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				condition.RabbitMqTransportURLReadyRunningMessage))
+			return ctrl.Result{RequeueAfter: time.Duration(10) * time.Second}, nil
+		}
+		Log.Info(fmt.Sprintf("TransportURL secret name %s", transportURL.Status.SecretName))
+		instance.Status.Conditions.MarkTrue(condition.RabbitMqTransportURLReadyCondition, condition.RabbitMqTransportURLReadyMessage)
 	}
-	Log.Info(fmt.Sprintf("TransportURL secret name %s", transportURL.Status.SecretName))
-	instance.Status.Conditions.MarkTrue(condition.RabbitMqTransportURLReadyCondition, condition.RabbitMqTransportURLReadyMessage)
 	// run check rabbitmq - end
 
 	//
@@ -858,7 +1198,7 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			condition.MemcachedReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrl.Result{}, err
 	}
 
@@ -871,7 +1211,7 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 				condition.ErrorReason,
 				condition.SeverityWarning,
 				condition.MemcachedReadyErrorMessage,
-				err.Error()))
+				keystonev1.KeystoneErrorMessage(err)))
 			return ctrl.Result{}, err
 		}
 	}
@@ -900,14 +1240,42 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 	// - %-config configmap holding minimal keystone config required to get the service up, user can add additional files to be added to the service
 	// - parameters which has passwords gets added from the OpenStack secret via the init container
 	//
-	err = r.generateServiceConfigMaps(ctx, instance, helper, &configMapVars, memcached, db)
+	err = r.generateServiceConfigMaps(ctx, instance, helper, &configMapVars, memcached, dbConfig)
+	if err == nil {
+		err = r.ensureDBSyncConfig(ctx, helper, instance, dbConfig)
+	}
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.ServiceConfigReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.ServiceConfigReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	err = r.reconcileConfigPreview(ctx, helper, instance, false)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.ServiceConfigReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.ServiceConfigReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	//
+	// Replace the fernet token keys when a token revocation got requested
+	//
+	err = r.replaceFernetTokenKeys(ctx, helper, instance)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			condition.ServiceConfigReadyCondition,
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			condition.ServiceConfigReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrl.Result{}, err
 	}
 
@@ -921,7 +1289,7 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			condition.ServiceConfigReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrl.Result{}, err
 	}
 
@@ -939,7 +1307,7 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			condition.ServiceConfigReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrl.Result{}, err
 	}
 
@@ -971,7 +1339,7 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 				condition.ErrorReason,
 				condition.SeverityWarning,
 				condition.TLSInputErrorMessage,
-				err.Error()))
+				keystonev1.KeystoneErrorMessage(err)))
 			return ctrl.Result{}, err
 		}
 
@@ -989,7 +1357,7 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 				condition.RequestedReason,
 				condition.SeverityInfo,
 				condition.TLSInputReadyWaitingMessage,
-				err.Error()))
+				keystonev1.KeystoneErrorMessage(err)))
 			return ctrl.Result{}, nil
 		}
 		instance.Status.Conditions.Set(condition.FalseCondition(
@@ -997,7 +1365,7 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			condition.TLSInputErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrl.Result{}, err
 	}
 	configMapVars[tls.TLSHashName] = env.SetValue(certsHash)
@@ -1005,6 +1373,12 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 	// all cert input checks out so report InputReady
 	instance.Status.Conditions.MarkTrue(condition.TLSInputReadyCondition, condition.InputReadyMessage)
 
+	if instance.Spec.ConfigReloadStrategy == keystonev1.ConfigReloadGraceful {
+		// config data changes get reloaded in the running pods instead of
+		// restarting them, see reconcileConfigReload
+		delete(configMapVars, fmt.Sprintf("%s-config-data", instance.Name))
+	}
+
 	// create hash over all the different input resources to identify if any those changed
 	// and a restart/recreate is required.
 	inputHash, hashChanged, err := r.createHashOfInputHashes(ctx, instance, configMapVars)
@@ -1043,7 +1417,7 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 				condition.ErrorReason,
 				condition.SeverityWarning,
 				condition.NetworkAttachmentsReadyErrorMessage,
-				err.Error()))
+				keystonev1.KeystoneErrorMessage(err)))
 			return ctrl.Result{}, err
 		}
 
@@ -1058,8 +1432,28 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 			instance.Spec.NetworkAttachments, err)
 	}
 
+	//
+	// Service account, role, binding
+	//
+	ctrlResult, err := r.reconcileRbac(ctx, instance, helper)
+	if err != nil {
+		return ctrlResult, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		return ctrlResult, nil
+	}
+
+	// verify the container images before the jobs and pods run them, db sync
+	// and bootstrap run keystone-manage with the database and admin
+	// credentials
+	ctrlResult, err = r.reconcileImageVerification(ctx, helper, instance, serviceLabels, serviceAnnotations)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		return ctrlResult, nil
+	}
+
 	// Handle service init
-	ctrlResult, err := r.reconcileInit(ctx, instance, helper, serviceLabels, serviceAnnotations)
+	ctrlResult, err = r.reconcileInit(ctx, instance, helper, serviceLabels, serviceAnnotations)
 	if err != nil {
 		return ctrlResult, err
 	} else if (ctrlResult != ctrl.Result{}) {
@@ -1100,7 +1494,7 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			condition.TopologyReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrl.Result{}, fmt.Errorf("waiting for Topology requirements: %w", err)
 	}
 
@@ -1121,17 +1515,45 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 	// normal reconcile tasks
 	//
 
+	// annotate the pods with the fernet keys they get created with
+	fernetSecret, fernetHash, err := oko_secret.GetSecret(ctx, helper, keystone.ServiceName, instance.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	podAnnotations := util.MergeStringMaps(serviceAnnotations, map[string]string{
+		keystone.FernetKeysHashAnnotation: fernetHash,
+	})
+
 	// Define a new Deployment object
-	deplDef, err := keystone.Deployment(instance, inputHash, serviceLabels, serviceAnnotations, topology, federationFilenames)
+	deplDef, err := keystone.Deployment(instance, inputHash, serviceLabels, podAnnotations, topology, federationFilenames)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			condition.DeploymentReadyCondition,
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			condition.DeploymentReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if instance.Spec.Autoscaling != nil {
+		// the replicas are managed by the ScaledObject, keep the current ones
+		current := &appsv1.Deployment{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: deplDef.Name, Namespace: deplDef.Namespace}, current)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		if err == nil && current.Spec.Replicas != nil {
+			deplDef.Spec.Replicas = current.Spec.Replicas
+		} else {
+			deplDef.Spec.Replicas = ptr.To(instance.Spec.Autoscaling.MinReplicas)
+		}
+	}
+
+	scaleUpBlocked, err := r.guardFernetScaleUp(ctx, instance, deplDef, serviceLabels, fernetHash)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
+
 	depl := deployment.NewDeployment(
 		deplDef,
 		5*time.Second,
@@ -1144,7 +1566,7 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			condition.DeploymentReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrlResult, err
 	} else if (ctrlResult != ctrl.Result{}) {
 		instance.Status.Conditions.Set(condition.FalseCondition(
@@ -1160,6 +1582,16 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 		instance.Status.ReadyCount = deploy.Status.ReadyReplicas
 	}
 
+	if scaleUpBlocked {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.DeploymentReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneFernetScaleUpWaitingMessage,
+			*instance.Spec.Replicas))
+		return ctrl.Result{RequeueAfter: fernetScaleUpRequeue}, nil
+	}
+
 	// verify if network attachment matches expectations
 	networkReady, networkAttachmentStatus, err := nad.VerifyNetworkStatusFromAnnotation(
 		ctx, helper, instance.Spec.NetworkAttachments, serviceLabels, instance.Status.ReadyCount,
@@ -1191,6 +1623,28 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 	// In addition, make sure the controller sees the last Generation
 	// by comparing it with the ObservedGeneration.
 	if deployment.IsReady(deploy) {
+		ctrlResult, err = r.reconcileConfigReload(ctx, helper, instance, serviceLabels)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.DeploymentReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.DeploymentReadyErrorMessage,
+				keystonev1.KeystoneErrorMessage(err)))
+			return ctrlResult, err
+		} else if (ctrlResult != ctrl.Result{}) {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.DeploymentReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				condition.DeploymentReadyRunningMessage))
+			return ctrlResult, nil
+		}
+		// all pods use the rendered config now
+		err = r.reconcileConfigPreview(ctx, helper, instance, true)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
 		instance.Status.Conditions.MarkTrue(condition.DeploymentReadyCondition, condition.DeploymentReadyMessage)
 	} else {
 		instance.Status.Conditions.Set(condition.FalseCondition(
@@ -1201,9 +1655,56 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 	}
 	// create Deployment - end
 
+	err = r.reconcileFernetKeysHealth(ctx, instance, fernetSecret, fernetHash, serviceLabels)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = r.reconcileTokenRevocation(ctx, instance, fernetHash, serviceLabels)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = r.reconcileAutoscaling(ctx, helper, instance)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAutoscalingReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAutoscalingReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	ctrlResult, err = r.reconcileGreenDeployment(ctx, helper, instance, deplDef, serviceLabels)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneGreenDeploymentReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneGreenDeploymentReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrlResult, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		return ctrlResult, nil
+	}
+
+	ctrlResult, err = r.reconcilePublicDeployment(ctx, helper, instance, deplDef, serviceLabels)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystonePublicDeploymentReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystonePublicDeploymentReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrlResult, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		return ctrlResult, nil
+	}
+
 	if instance.Status.ReadyCount == *instance.Spec.Replicas {
 		// remove finalizers from unused MariaDBAccount records
-		err = mariadbv1.DeleteUnusedMariaDBAccountFinalizers(ctx, helper, keystone.DatabaseName, instance.Spec.DatabaseAccount, instance.Namespace)
+		err = deleteUnusedMariaDBAccountFinalizers(ctx, helper, instance)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
@@ -1223,10 +1724,19 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			condition.CronJobReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrlResult, err
 	}
 
+	// record the last scheduled and successful trust flush
+	cj := &batchv1.CronJob{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cronjobDef.Name, Namespace: cronjobDef.Namespace}, cj)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	instance.Status.TrustFlushLastSuccessfulTime = cj.Status.LastSuccessfulTime
+	instance.Status.TrustFlushLastScheduleTime = cj.Status.LastScheduleTime
+
 	instance.Status.Conditions.MarkTrue(condition.CronJobReadyCondition, condition.CronJobReadyMessage)
 	// create CronJob - end
 
@@ -1238,128 +1748,1114 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 		return ctrl.Result{}, err
 	}
 
-	Log.Info("Reconciled Service successfully")
-	return ctrl.Result{}, nil
-}
-
-func (r *KeystoneAPIReconciler) transportURLCreateOrUpdate(
-	ctx context.Context,
-	instance *keystonev1.KeystoneAPI,
-	serviceLabels map[string]string,
-) (*rabbitmqv1.TransportURL, controllerutil.OperationResult, error) {
-	transportURL := &rabbitmqv1.TransportURL{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-keystone-transport", instance.Name),
-			Namespace: instance.Namespace,
-			Labels:    serviceLabels,
-		},
+	//
+	// verify the default roles of the bootstrap
+	//
+	ctrlResult, err = r.reconcileBootstrapRoles(ctx, helper, instance)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneBootstrapRolesReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneBootstrapRolesReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneBootstrapRolesReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneBootstrapRolesReadyWaitingMessage))
+		return ctrlResult, nil
 	}
-	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, transportURL, func() error {
-		transportURL.Spec.RabbitmqClusterName = instance.Spec.RabbitMqClusterName
-		err := controllerutil.SetControllerReference(instance, transportURL, r.Scheme)
-		return err
-	})
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneBootstrapRolesReadyCondition, keystonev1.KeystoneBootstrapRolesReadyMessage)
 
-	return transportURL, op, err
-}
-
-// generateServiceConfigMaps - create create configmaps which hold scripts and service configuration
-// TODO add DefaultConfigOverwrite
-func (r *KeystoneAPIReconciler) generateServiceConfigMaps(
-	ctx context.Context,
-	instance *keystonev1.KeystoneAPI,
-	h *helper.Helper,
-	envVars *map[string]env.Setter,
-	mc *memcachedv1.Memcached,
-	db *mariadbv1.Database,
-) error {
 	//
-	// create Configmap/Secret required for keystone input
-	// - %-scripts configmap holding scripts to e.g. bootstrap the service
-	// - %-config configmap holding minimal keystone config required to get the service up, user can add additional files to be added to the service
-	// - parameters which has passwords gets added from the ospSecret via the init container
+	// update the identity endpoints in the catalog
 	//
-
-	cmLabels := labels.GetLabels(instance, labels.GetGroupLabel(keystone.ServiceName), map[string]string{})
-
-	var tlsCfg *tls.Service
-	if instance.Spec.TLS.Ca.CaBundleSecretName != "" {
-		tlsCfg = &tls.Service{}
+	ctrlResult, err = r.reconcileIdentityEndpoints(ctx, helper, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		return ctrlResult, nil
 	}
 
-	// customData hold any customization for the service.
-	// custom.conf is going to /etc/<service>/<service>.conf.d
-	// all other files get placed into /etc/<service> to allow overwrite of e.g. policy.json
-	// TODO: make sure custom.conf can not be overwritten
-	customData := map[string]string{
-		common.CustomServiceConfigFileName: instance.Spec.CustomServiceConfig,
-		"my.cnf":                           db.GetDatabaseClientConfig(tlsCfg), //(mschuppert) for now just get the default my.cnf
+	//
+	// bump the catalog version if services or endpoints changed
+	//
+	err = r.reconcileCatalogVersion(ctx, instance)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
-	for key, data := range instance.Spec.DefaultConfigOverwrite {
-		customData[key] = data
+
+	//
+	// summarize the readiness of the keystone CRs of the namespace
+	//
+	err = r.reconcileChildren(ctx, instance)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
-	transportURLSecret, _, err := oko_secret.GetSecret(ctx, h, instance.Status.TransportURLSecret, instance.Namespace)
+	//
+	// ensure the implied roles
+	//
+	ctrlResult, err = r.reconcileImpliedRoles(ctx, helper, instance)
 	if err != nil {
-		return err
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneImpliedRolesReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneImpliedRolesReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneImpliedRolesReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneImpliedRolesReadyWaitingMessage))
+		return ctrlResult, nil
 	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneImpliedRolesReadyCondition, keystonev1.KeystoneImpliedRolesReadyMessage)
 
-	databaseAccount := db.GetAccount()
-	dbSecret := db.GetSecret()
+	//
+	// check the connectivity to the LDAP servers
+	//
+	err = r.reconcileLDAPConnectivity(ctx, helper, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 
-	templateParameters := map[string]interface{}{
-		"MemcachedServers":         mc.GetMemcachedServerListString(),
-		"MemcachedServersWithInet": mc.GetMemcachedServerListWithInetString(),
-		"MemcachedTLS":             mc.GetMemcachedTLSSupport(),
-		"TransportURL":             string(transportURLSecret.Data["transport_url"]),
-		"DatabaseConnection": fmt.Sprintf("mysql+pymysql://%s:%s@%s/%s?read_default_file=/etc/my.cnf",
-			databaseAccount.Spec.UserName,
-			string(dbSecret.Data[mariadbv1.DatabasePasswordSelector]),
-			instance.Status.DatabaseHostname,
-			keystone.DatabaseName,
-		),
-		"ProcessNumber":       instance.Spec.HttpdCustomization.ProcessNumber,
-		"EnableSecureRBAC":    instance.Spec.EnableSecureRBAC,
-		"FernetMaxActiveKeys": instance.Spec.FernetMaxActiveKeys,
+	//
+	// issue and validate a token with the smoke test user
+	//
+	err = r.reconcileTokenSmokeTest(ctx, helper, instance)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
-	templateParameters["KeystoneEndpointPublic"], _ = instance.GetEndpoint(endpoint.EndpointPublic)
-	templateParameters["KeystoneEndpointInternal"], _ = instance.GetEndpoint(endpoint.EndpointInternal)
+	//
+	// publish the WebSSO login options of the identity providers
+	//
+	err = r.reconcileWebSSO(ctx, helper, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 
-	httpdOverrideSecret := &corev1.Secret{}
-	if instance.Spec.HttpdCustomization.CustomConfigSecret != nil && *instance.Spec.HttpdCustomization.CustomConfigSecret != "" {
-		httpdOverrideSecret, _, err = oko_secret.GetSecret(ctx, h, *instance.Spec.HttpdCustomization.CustomConfigSecret, instance.Namespace)
-		if err != nil {
-			return err
+	Log.Info("Reconciled Service successfully")
+	if instance.Spec.PublicDNS != nil &&
+		!instance.Status.Conditions.IsTrue(keystonev1.KeystonePublicDNSReadyCondition) {
+		// look up the public hostname again until it resolves
+		return ctrl.Result{RequeueAfter: keystone.PublicDNSCheckInterval}, nil
+	}
+	requeue := ctrl.Result{}
+	if instance.Spec.HasLDAPDomains() {
+		// re-check the LDAP servers periodically
+		requeue.RequeueAfter = keystone.LDAPCheckInterval
+	}
+	if instance.Spec.TokenSmokeTest != nil {
+		interval := keystone.TokenSmokeTestInterval(instance.Spec.TokenSmokeTest)
+		if requeue.RequeueAfter == 0 || interval < requeue.RequeueAfter {
+			requeue.RequeueAfter = interval
 		}
 	}
-
-	// create httpd  vhost template parameters
-	customTemplates := map[string]string{}
-	httpdVhostConfig := map[string]interface{}{}
-	for _, endpt := range []service.Endpoint{service.EndpointInternal, service.EndpointPublic} {
-		endptConfig := map[string]interface{}{}
-		endptConfig["ServerName"] = fmt.Sprintf("%s-%s.%s.svc", instance.Name, endpt.String(), instance.Namespace)
-		endptConfig["TLS"] = false // default TLS to false, and set it bellow to true if enabled
-		if instance.Spec.TLS.API.Enabled(endpt) {
-			endptConfig["TLS"] = true
-			endptConfig["SSLCertificateFile"] = fmt.Sprintf("/etc/pki/tls/certs/%s.crt", endpt.String())
-			endptConfig["SSLCertificateKeyFile"] = fmt.Sprintf("/etc/pki/tls/private/%s.key", endpt.String())
+	if instance.Status.WebSSO != nil {
+		// pick up added or removed identity providers
+		if requeue.RequeueAfter == 0 || keystone.WebSSORefreshInterval < requeue.RequeueAfter {
+			requeue.RequeueAfter = keystone.WebSSORefreshInterval
 		}
-
-		endptConfig["Override"] = false
-		if len(httpdOverrideSecret.Data) > 0 {
-			endptConfig["Override"] = true
-			for key, data := range httpdOverrideSecret.Data {
-				if len(data) > 0 {
-					customTemplates["httpd_custom_"+endpt.String()+"_"+key] = string(data)
-				}
-			}
+	}
+	if instance.Status.TokenRevocation != nil && instance.Status.TokenRevocation.CompletionTime == nil {
+		// report when the token revocation completed
+		if requeue.RequeueAfter == 0 || tokenRevocationRequeue < requeue.RequeueAfter {
+			requeue.RequeueAfter = tokenRevocationRequeue
 		}
-		httpdVhostConfig[endpt.String()] = endptConfig
 	}
-	templateParameters["VHosts"] = httpdVhostConfig
-	templateParameters["TimeOut"] = instance.Spec.APITimeout
+	return requeue, nil
+}
+
+// reconcilePublicDNS - returns the public endpoint URL with the hostname of
+// the public DNS record once it resolves. Until then the public endpoint
+// registered before is kept, or the URL of the Service or route on the first
+// deployment, so clients do not get a catalog entry they can not resolve.
+func (r *KeystoneAPIReconciler) reconcilePublicDNS(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	publicURL string,
+) (string, error) {
+	dnsURL, err := keystonev1.PublishedEndpointSpec{Host: instance.Spec.PublicDNS.Hostname}.Apply(publicURL)
+	if err != nil {
+		return "", err
+	}
+
+	if keystone.HostnameResolvable(ctx, instance.Spec.PublicDNS.Hostname) {
+		instance.Status.Conditions.MarkTrue(keystonev1.KeystonePublicDNSReadyCondition, keystonev1.KeystonePublicDNSReadyMessage)
+		return dnsURL, nil
+	}
+
+	// the record resolved before, a failed lookup does not change the catalog
+	current := instance.Status.APIEndpoints[string(service.EndpointPublic)]
+	if current == dnsURL {
+		return dnsURL, nil
+	}
+	if current == "" {
+		current = publicURL
+	}
+	instance.Status.Conditions.Set(condition.FalseCondition(
+		keystonev1.KeystonePublicDNSReadyCondition,
+		condition.RequestedReason,
+		condition.SeverityInfo,
+		keystonev1.KeystonePublicDNSReadyWaitingMessage,
+		instance.Spec.PublicDNS.Hostname,
+		current))
+	return current, nil
+}
+
+// getDomainConfigs - returns the content of the domain configs keyed by the
+// name of their file in domain_config_dir
+func (r *KeystoneAPIReconciler) getDomainConfigs(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) (map[string]string, error) {
+	domainConfigs := map[string]string{}
+
+	for domain, src := range instance.Spec.DomainConfigs {
+		var data string
+		switch {
+		case src.SecretKeyRef != nil:
+			value, err := getSecretKey(ctx, h, src.SecretKeyRef, instance.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			data = value
+		case src.ConfigMapKeyRef != nil:
+			cm, _, err := configmap.GetConfigMapAndHashWithName(ctx, h, src.ConfigMapKeyRef.Name, instance.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			value, ok := cm.Data[src.ConfigMapKeyRef.Key]
+			if !ok {
+				return nil, fmt.Errorf("%w: key %s not found in ConfigMap %s", util.ErrNotFound, src.ConfigMapKeyRef.Key, src.ConfigMapKeyRef.Name)
+			}
+			data = value
+		default:
+			continue
+		}
+		if domain == keystonev1.DefaultDomainName && instance.Spec.GetIdentityDriver() == keystonev1.KeystoneDriverLDAP {
+			data = keystone.IdentityDriverConfig(keystonev1.KeystoneDriverLDAP) + data
+		}
+		if src.LDAP != nil {
+			data += keystone.LDAPDomainConfig(domain, src.LDAP)
+			if src.LDAP.CACertSecretRef != nil {
+				caCert, err := getSecretKey(ctx, h, src.LDAP.CACertSecretRef, instance.Namespace)
+				if err != nil {
+					return nil, err
+				}
+				domainConfigs[keystone.LDAPCACertFileName(domain)] = caCert
+			}
+		}
+		domainConfigs[keystonev1.GetDomainConfigFileName(domain)] = data
+	}
+
+	return domainConfigs, nil
+}
+
+// getCustomServiceConfig - returns the custom service config, the service
+// config layers merged in order with CustomServiceConfig merged last. Without
+// layers CustomServiceConfig gets used as is.
+func (r *KeystoneAPIReconciler) getCustomServiceConfig(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) (string, error) {
+	if len(instance.Spec.CustomServiceConfigLayers) == 0 {
+		return instance.Spec.CustomServiceConfig, nil
+	}
+
+	layers := []string{}
+	for _, layer := range instance.Spec.CustomServiceConfigLayers {
+		switch {
+		case layer.SecretKeyRef != nil:
+			value, err := getSecretKey(ctx, h, layer.SecretKeyRef, instance.Namespace)
+			if err != nil {
+				return "", err
+			}
+			layers = append(layers, value)
+		case layer.ConfigMapKeyRef != nil:
+			cm, _, err := configmap.GetConfigMapAndHashWithName(ctx, h, layer.ConfigMapKeyRef.Name, instance.Namespace)
+			if err != nil {
+				return "", err
+			}
+			value, ok := cm.Data[layer.ConfigMapKeyRef.Key]
+			if !ok {
+				return "", fmt.Errorf("%w: key %s not found in ConfigMap %s", util.ErrNotFound, layer.ConfigMapKeyRef.Key, layer.ConfigMapKeyRef.Name)
+			}
+			layers = append(layers, value)
+		}
+	}
+	layers = append(layers, instance.Spec.CustomServiceConfig)
+
+	return keystonev1.MergeServiceConfigs(layers...), nil
+}
+
+// getSecretKey - returns the value of the key of the Secret
+func getSecretKey(
+	ctx context.Context,
+	h *helper.Helper,
+	ref *corev1.SecretKeySelector,
+	namespace string,
+) (string, error) {
+	scrt, _, err := oko_secret.GetSecret(ctx, h, ref.Name, namespace)
+	if err != nil {
+		return "", err
+	}
+	value, ok := scrt.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("%w: key %s not found in Secret %s", util.ErrNotFound, ref.Key, ref.Name)
+	}
+	return string(value), nil
+}
+
+// reconcileLDAPConnectivity - checks the LDAP servers of the domains with LDAP
+// settings are reachable from the operator. Unreachable servers do not fail
+// the reconcile, they get reported in the LDAPReady condition.
+func (r *KeystoneAPIReconciler) reconcileLDAPConnectivity(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) error {
+	if !instance.Spec.HasLDAPDomains() {
+		return nil
+	}
+
+	domainConfigs, err := r.getDomainConfigs(ctx, h, instance)
+	if err != nil {
+		return err
+	}
+
+	domains := []string{}
+	for domain, src := range instance.Spec.DomainConfigs {
+		if src.LDAP != nil {
+			domains = append(domains, domain)
+		}
+	}
+	sort.Strings(domains)
+
+	failures := []string{}
+	for _, domain := range domains {
+		caCert := []byte(domainConfigs[keystone.LDAPCACertFileName(domain)])
+		for _, ldapURL := range keystone.LDAPURLs(domainConfigs[keystonev1.GetDomainConfigFileName(domain)]) {
+			if err := keystone.CheckLDAPConnectivity(ctx, ldapURL, caCert); err != nil {
+				failures = append(failures, fmt.Sprintf("domain %s: %s: %s", domain, ldapURL, err))
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneLDAPReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneLDAPReadyErrorMessage,
+			strings.Join(failures, ", ")))
+		return nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneLDAPReadyCondition, keystonev1.KeystoneLDAPReadyMessage)
+	return nil
+}
+
+// reconcileImageVerification - verifies the keystone container images are
+// referenced by digest and runs the cosign signature verification job if
+// configured. No job or pod may run the images before it passed.
+func (r *KeystoneAPIReconciler) reconcileImageVerification(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+	serviceLabels map[string]string,
+	serviceAnnotations map[string]string,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	v := instance.Spec.ImageVerification
+	if v == nil {
+		delete(instance.Status.Hash, keystonev1.ImageVerifyHash)
+		return ctrl.Result{}, nil
+	}
+
+	if v.RequireDigest {
+		images := []string{instance.Spec.ContainerImage}
+		if instance.Spec.BlueGreen != nil {
+			images = append(images, instance.Spec.BlueGreen.ContainerImage)
+		}
+		for _, image := range images {
+			if !keystonev1.IsImageDigest(image) {
+				instance.Status.Conditions.Set(condition.FalseCondition(
+					keystonev1.KeystoneImageVerifiedCondition,
+					condition.ErrorReason,
+					condition.SeverityError,
+					keystonev1.KeystoneImageVerifiedDigestMessage,
+					image))
+				return ctrl.Result{}, fmt.Errorf("image %s is not referenced by digest", image)
+			}
+		}
+	}
+
+	if !v.VerifySignature() {
+		delete(instance.Status.Hash, keystonev1.ImageVerifyHash)
+		instance.Status.Conditions.MarkTrue(keystonev1.KeystoneImageVerifiedCondition, keystonev1.KeystoneImageVerifiedMessage)
+		return ctrl.Result{}, nil
+	}
+
+	jobDef := keystone.ImageVerifyJob(instance, serviceLabels, serviceAnnotations)
+	verifyJob := job.NewJob(
+		jobDef,
+		keystonev1.ImageVerifyHash,
+		instance.Spec.PreserveJobs,
+		5*time.Second,
+		instance.Status.Hash[keystonev1.ImageVerifyHash],
+	)
+	ctrlResult, err := verifyJob.DoJob(ctx, h)
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneImageVerifiedCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneImageVerifiedRunningMessage))
+		return ctrlResult, nil
+	}
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneImageVerifiedCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			keystonev1.KeystoneImageVerifiedErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if verifyJob.HasChanged() {
+		instance.Status.Hash[keystonev1.ImageVerifyHash] = verifyJob.GetHash()
+		Log.Info(fmt.Sprintf("Job %s hash added - %s", jobDef.Name, instance.Status.Hash[keystonev1.ImageVerifyHash]))
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneImageVerifiedCondition, keystonev1.KeystoneImageVerifiedMessage)
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileAutoscaling - creates the KEDA ScaledObject of the keystone API
+// deployment, or deletes it if autoscaling is disabled
+func (r *KeystoneAPIReconciler) reconcileAutoscaling(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) error {
+	Log := r.GetLogger(ctx)
+
+	so := keystone.NewScaledObject(instance)
+	if instance.Spec.Autoscaling == nil {
+		if _, ok := instance.Status.Hash[keystonev1.AutoscalingHash]; !ok {
+			// no ScaledObject got created, avoid querying KEDA objects on
+			// clusters without KEDA
+			return nil
+		}
+		delete(instance.Status.Hash, keystonev1.AutoscalingHash)
+		err := r.Client.Get(ctx, types.NamespacedName{Name: so.GetName(), Namespace: so.GetNamespace()}, so)
+		if k8s_errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			// nothing to delete, or KEDA is not installed
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if metav1.IsControlledBy(so, instance) {
+			Log.Info(fmt.Sprintf("Deleting ScaledObject %s", so.GetName()))
+			err = r.Client.Delete(ctx, so)
+			if err != nil && !k8s_errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	spec := keystone.ScaledObjectSpec(instance)
+	hash, err := util.ObjectHash(spec)
+	if err != nil {
+		return err
+	}
+	op, err := controllerutil.CreateOrPatch(ctx, r.Client, so, func() error {
+		err := unstructured.SetNestedField(so.Object, spec, "spec")
+		if err != nil {
+			return err
+		}
+		return controllerutil.SetControllerReference(h.GetBeforeObject(), so, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		Log.Info(fmt.Sprintf("ScaledObject %s - %s", so.GetName(), op))
+	}
+	instance.Status.Hash[keystonev1.AutoscalingHash] = hash
+
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAutoscalingReadyCondition, keystonev1.KeystoneAutoscalingReadyMessage)
+	return nil
+}
+
+// reconcileGreenDeployment - creates the green deployment of a blue/green
+// setup, or deletes it if blue/green is disabled
+func (r *KeystoneAPIReconciler) reconcileGreenDeployment(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+	blue *appsv1.Deployment,
+	serviceLabels map[string]string,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	if instance.Spec.BlueGreen == nil {
+		green := &appsv1.Deployment{}
+		err := r.Client.Get(ctx, types.NamespacedName{
+			Name:      keystone.ServiceName + "-" + string(keystonev1.BlueGreenGreen),
+			Namespace: instance.Namespace,
+		}, green)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		if err == nil && metav1.IsControlledBy(green, instance) {
+			Log.Info(fmt.Sprintf("Deleting green deployment %s", green.Name))
+			err = r.Client.Delete(ctx, green)
+			if err != nil && !k8s_errors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		}
+		instance.Status.GreenReadyCount = 0
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneGreenDeploymentReadyCondition,
+			keystonev1.KeystoneGreenDeploymentDisabledMessage)
+		return ctrl.Result{}, nil
+	}
+
+	depl := deployment.NewDeployment(
+		keystone.GreenDeployment(instance, blue, serviceLabels),
+		5*time.Second,
+	)
+	ctrlResult, err := depl.CreateOrPatch(ctx, h)
+	if err != nil {
+		return ctrlResult, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneGreenDeploymentReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneGreenDeploymentReadyRunningMessage))
+		return ctrlResult, nil
+	}
+
+	green := depl.GetDeployment()
+	if green.Generation == green.Status.ObservedGeneration {
+		instance.Status.GreenReadyCount = green.Status.ReadyReplicas
+	}
+	if deployment.IsReady(green) {
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneGreenDeploymentReadyCondition,
+			keystonev1.KeystoneGreenDeploymentReadyMessage,
+			instance.Spec.BlueGreen.Active)
+	} else {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneGreenDeploymentReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneGreenDeploymentReadyRunningMessage))
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reconcilePublicDeployment - creates the separate deployment serving the
+// public endpoint, or deletes it if the public endpoint is served by the
+// default deployment
+func (r *KeystoneAPIReconciler) reconcilePublicDeployment(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+	internal *appsv1.Deployment,
+	serviceLabels map[string]string,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	if instance.Spec.PublicDeployment == nil {
+		public := &appsv1.Deployment{}
+		err := r.Client.Get(ctx, types.NamespacedName{
+			Name:      keystone.ServiceName + "-" + string(service.EndpointPublic),
+			Namespace: instance.Namespace,
+		}, public)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		if err == nil && metav1.IsControlledBy(public, instance) {
+			Log.Info(fmt.Sprintf("Deleting public deployment %s", public.Name))
+			err = r.Client.Delete(ctx, public)
+			if err != nil && !k8s_errors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		}
+		instance.Status.PublicReadyCount = 0
+		return ctrl.Result{}, nil
+	}
+
+	depl := deployment.NewDeployment(
+		keystone.PublicDeployment(instance, internal, serviceLabels),
+		5*time.Second,
+	)
+	ctrlResult, err := depl.CreateOrPatch(ctx, h)
+	if err != nil {
+		return ctrlResult, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystonePublicDeploymentReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystonePublicDeploymentReadyRunningMessage))
+		return ctrlResult, nil
+	}
+
+	public := depl.GetDeployment()
+	if public.Generation == public.Status.ObservedGeneration {
+		instance.Status.PublicReadyCount = public.Status.ReadyReplicas
+	}
+	if deployment.IsReady(public) {
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystonePublicDeploymentReadyCondition,
+			keystonev1.KeystonePublicDeploymentReadyMessage)
+	} else {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystonePublicDeploymentReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystonePublicDeploymentReadyRunningMessage))
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// getRouteURL - returns the URL of the route exposing the service or an
+// empty string if there is none
+func (r *KeystoneAPIReconciler) getRouteURL(
+	ctx context.Context,
+	namespace string,
+	serviceName string,
+) (string, error) {
+	if !r.routeAPI {
+		return "", nil
+	}
+
+	routes := &routev1.RouteList{}
+	err := r.Client.List(ctx, routes, client.InNamespace(namespace))
+	if err != nil {
+		return "", err
+	}
+
+	for _, route := range routes.Items {
+		if route.Spec.To.Kind != "Service" || route.Spec.To.Name != serviceName || route.Spec.Host == "" {
+			continue
+		}
+		if route.Spec.TLS != nil {
+			return "https://" + route.Spec.Host, nil
+		}
+		return "http://" + route.Spec.Host, nil
+	}
+
+	return "", nil
+}
+
+// reconcileIdentityEndpoints - updates the URLs of the identity endpoints in
+// the catalog to the exposed API endpoints, e.g. after the hostname or the TLS
+// mode of the route changed. The update runs once per set of API endpoints.
+func (r *KeystoneAPIReconciler) reconcileIdentityEndpoints(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	endpointsHash, err := util.ObjectHash(instance.Status.APIEndpoints)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if instance.Status.Hash[keystonev1.IdentityEndpointsHash] == endpointsHash {
+		return ctrl.Result{}, nil
+	}
+
+	// the admin client needs a running keystone API
+	if !instance.Status.Conditions.IsTrue(condition.DeploymentReadyCondition) {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	os, ctrlResult, err := getAdminClient(ctx, h, instance, r.OpenStackClient)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		return ctrlResult, nil
+	}
+	catalog := newCatalogBackend(os)
+
+	svc, err := catalog.GetService(Log, keystone.ServiceType, keystone.ServiceName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for endpointType, url := range instance.Status.APIEndpoints {
+		endpts, err := catalog.GetEndpoints(Log, svc.ID, endpointType)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		for _, endpt := range endpts {
+			if endpt.URL == url {
+				continue
+			}
+			_, err = catalog.UpdateEndpoint(Log, openstack.Endpoint{
+				Name:         endpt.Name,
+				ServiceID:    svc.ID,
+				Availability: endpt.Availability,
+				URL:          url,
+			}, endpt.ID)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			Log.Info(fmt.Sprintf("Identity %s endpoint updated from %s to %s", endpointType, endpt.URL, url))
+		}
+	}
+
+	instance.Status.Hash[keystonev1.IdentityEndpointsHash] = endpointsHash
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileBootstrapRoles - verifies the default roles, implied roles and role
+// assignments of the admin user created by keystone-manage bootstrap exist and
+// recreates the missing ones. This protects against partially run bootstrap
+// jobs. The verification runs once per bootstrap run.
+func (r *KeystoneAPIReconciler) reconcileBootstrapRoles(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	bootstrapHash := instance.Status.Hash[keystonev1.BootstrapHash]
+	if instance.Status.Hash[keystonev1.BootstrapRolesHash] == bootstrapHash {
+		return ctrl.Result{}, nil
+	}
+
+	// the admin client needs a running keystone API
+	if !instance.Status.Conditions.IsTrue(condition.DeploymentReadyCondition) {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	os, ctrlResult, err := getAdminClient(ctx, h, instance, r.OpenStackClient)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		return ctrlResult, nil
+	}
+
+	roleIDs := map[string]string{}
+	for _, role := range []string{keystone.ReaderRole, keystone.MemberRole, keystone.AdminRole} {
+		roleIDs[role], err = os.CreateRole(Log, role)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	err = identity.EnsureImpliedRole(Log, os, roleIDs[keystone.AdminRole], roleIDs[keystone.MemberRole])
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = identity.EnsureImpliedRole(Log, os, roleIDs[keystone.MemberRole], roleIDs[keystone.ReaderRole])
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// the admin user and project can not be recreated without the bootstrap
+	user, err := os.GetUser(Log, instance.Spec.AdminUser, "default")
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	project, err := os.GetProject(Log, instance.Spec.AdminProject, "default")
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = os.AssignUserRole(Log, keystone.AdminRole, user.ID, project.ID)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = identity.EnsureSystemUserRole(Log, os, user.ID, roleIDs[keystone.AdminRole])
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	instance.Status.Hash[keystonev1.BootstrapRolesHash] = bootstrapHash
+	Log.Info("Bootstrap roles verified")
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileImpliedRoles - ensures the implied role relationships of the spec
+// exist and deletes the ones which got removed from the spec
+func (r *KeystoneAPIReconciler) reconcileImpliedRoles(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	if len(instance.Spec.ImpliedRoles) == 0 && len(instance.Status.ImpliedRoles) == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	// the admin client needs a running keystone API
+	if !instance.Status.Conditions.IsTrue(condition.DeploymentReadyCondition) {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	os, ctrlResult, err := getAdminClient(ctx, h, instance, r.OpenStackClient)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		return ctrlResult, nil
+	}
+
+	for _, ir := range instance.Status.ImpliedRoles {
+		if slices.Contains(instance.Spec.ImpliedRoles, ir) {
+			continue
+		}
+		priorRole, err := os.GetRole(Log, ir.PriorRole)
+		if err != nil {
+			if strings.Contains(err.Error(), openstack.RoleNotFound) {
+				continue
+			}
+			return ctrl.Result{}, err
+		}
+		impliedRole, err := os.GetRole(Log, ir.ImpliedRole)
+		if err != nil {
+			if strings.Contains(err.Error(), openstack.RoleNotFound) {
+				continue
+			}
+			return ctrl.Result{}, err
+		}
+		err = identity.DeleteImpliedRole(Log, os, priorRole.ID, impliedRole.ID)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	for _, ir := range instance.Spec.ImpliedRoles {
+		priorRoleID, err := os.CreateRole(Log, ir.PriorRole)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		impliedRoleID, err := os.CreateRole(Log, ir.ImpliedRole)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		err = identity.EnsureImpliedRole(Log, os, priorRoleID, impliedRoleID)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	instance.Status.ImpliedRoles = slices.Clone(instance.Spec.ImpliedRoles)
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneAPIReconciler) transportURLCreateOrUpdate(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	serviceLabels map[string]string,
+) (*rabbitmqv1.TransportURL, controllerutil.OperationResult, error) {
+	transportURL := &rabbitmqv1.TransportURL{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-keystone-transport", instance.Name),
+			Namespace: instance.Namespace,
+			Labels:    serviceLabels,
+		},
+	}
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, transportURL, func() error {
+		transportURL.Spec.RabbitmqClusterName = instance.Spec.RabbitMqClusterName
+		err := controllerutil.SetControllerReference(instance, transportURL, r.Scheme)
+		return err
+	})
+
+	return transportURL, op, err
+}
+
+// generateServiceConfigMaps - create create configmaps which hold scripts and service configuration
+// TODO add DefaultConfigOverwrite
+func (r *KeystoneAPIReconciler) generateServiceConfigMaps(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	h *helper.Helper,
+	envVars *map[string]env.Setter,
+	mc *memcachedv1.Memcached,
+	dbConfig *databaseConfig,
+) error {
+	//
+	// create Configmap/Secret required for keystone input
+	// - %-scripts configmap holding scripts to e.g. bootstrap the service
+	// - %-config configmap holding minimal keystone config required to get the service up, user can add additional files to be added to the service
+	// - parameters which has passwords gets added from the ospSecret via the init container
+	//
+
+	cmLabels := labels.GetLabels(instance, labels.GetGroupLabel(keystone.ServiceName), map[string]string{})
+
+	// customData hold any customization for the service.
+	// custom.conf is going to /etc/<service>/<service>.conf.d
+	// all other files get placed into /etc/<service> to allow overwrite of e.g. policy.json
+	// TODO: make sure custom.conf can not be overwritten
+	customServiceConfig, err := r.getCustomServiceConfig(ctx, h, instance)
+	if err != nil {
+		return err
+	}
+	customData := map[string]string{
+		common.CustomServiceConfigFileName: customServiceConfig,
+		"my.cnf":                           dbConfig.clientConfig,
+	}
+	for key, data := range instance.Spec.DefaultConfigOverwrite {
+		customData[key] = data
+	}
+
+	domainConfigs, err := r.getDomainConfigs(ctx, h, instance)
+	if err != nil {
+		return err
+	}
+	for key, data := range domainConfigs {
+		customData[key] = data
+	}
+	if instance.Spec.FederationSSOCallbackTemplate != "" {
+		customData[keystone.SSOCallbackTemplateFileName] = instance.Spec.FederationSSOCallbackTemplate
+	}
+	if instance.Spec.Kerberos != nil {
+		keytab, err := getSecretKey(ctx, h, &instance.Spec.Kerberos.KeytabSecretRef, instance.Namespace)
+		if err != nil {
+			return err
+		}
+		customData[keystone.KerberosKeytabFileName] = keytab
+		if instance.Spec.Kerberos.Krb5Conf != "" {
+			customData[keystone.Krb5ConfFileName] = instance.Spec.Kerberos.Krb5Conf
+		}
+	}
+	if instance.Spec.TokenlessAuth != nil {
+		caBundle, err := getSecretKey(ctx, h, &instance.Spec.TokenlessAuth.CABundleSecretRef, instance.Namespace)
+		if err != nil {
+			return err
+		}
+		customData[keystone.TokenlessCAFileName] = caBundle
+	}
+
+	// notifications are disabled without a transport URL
+	transportURL := ""
+	if instance.Status.TransportURLSecret != "" {
+		transportURLSecret, _, err := oko_secret.GetSecret(ctx, h, instance.Status.TransportURLSecret, instance.Namespace)
+		if err != nil {
+			return err
+		}
+		transportURL = string(transportURLSecret.Data["transport_url"])
+	}
+
+	templateParameters := map[string]interface{}{
+		"MemcachedServers":              mc.GetMemcachedServerListString(),
+		"MemcachedServersWithInet":      mc.GetMemcachedServerListWithInetString(),
+		"MemcachedTLS":                  mc.GetMemcachedTLSSupport(),
+		"TransportURL":                  transportURL,
+		"DatabaseConnection":            dbConfig.connection,
+		"DatabaseSlaveConnection":       dbConfig.slaveConnection,
+		"ProcessNumber":                 instance.Spec.HttpdCustomization.ProcessNumber,
+		"WSGIServer":                    string(instance.Spec.WSGIServer),
+		"EnableSecureRBAC":              instance.Spec.EnableSecureRBAC,
+		"FernetMaxActiveKeys":           instance.Spec.FernetMaxActiveKeys,
+		"LimitEnforcementModel":         instance.Spec.LimitEnforcementModel,
+		"DomainSpecificDrivers":         len(domainConfigs) > 0,
+		"HealthcheckBackends":           strings.Join(instance.Spec.Healthcheck.Backends, ","),
+		"HealthcheckDisableByFilePath":  instance.Spec.Healthcheck.DisableByFilePath,
+		"HealthcheckDetailed":           instance.Spec.Healthcheck.Detailed,
+		"FederationTrustedDashboards":   instance.Spec.FederationTrustedDashboards,
+		"FederationSSOCallbackTemplate": instance.Spec.FederationSSOCallbackTemplate != "",
+		"AuthMethods":                   "",
+		"AssignmentDriver":              "",
+		"ResourceDriver":                "",
+	}
+
+	if instance.Spec.Drivers != nil {
+		templateParameters["AssignmentDriver"] = string(instance.Spec.Drivers.Assignment)
+		templateParameters["ResourceDriver"] = string(instance.Spec.Drivers.Resource)
+	}
+
+	// the auth methods only get set if features extend the keystone defaults
+	if authMethods := instance.Spec.GetAuthMethods(); len(authMethods) > len(keystonev1.DefaultAuthMethods) {
+		templateParameters["AuthMethods"] = strings.Join(authMethods, ",")
+	}
+
+	templateParameters["KeystoneEndpointPublic"], _ = instance.GetEndpoint(endpoint.EndpointPublic)
+	templateParameters["KeystoneEndpointInternal"], _ = instance.GetEndpoint(endpoint.EndpointInternal)
+
+	httpdOverrideSecret := &corev1.Secret{}
+	if instance.Spec.HttpdCustomization.CustomConfigSecret != nil && *instance.Spec.HttpdCustomization.CustomConfigSecret != "" {
+		httpdOverrideSecret, _, err = oko_secret.GetSecret(ctx, h, *instance.Spec.HttpdCustomization.CustomConfigSecret, instance.Namespace)
+		if err != nil {
+			return err
+		}
+	}
+
+	// security headers of the public endpoint, uWSGI can not limit them to
+	// the public endpoint and sends them on all responses
+	securityHeaders := map[string]string{}
+	httpdSecurityHeaders := map[string]string{}
+	if instance.Spec.PublicSecurity != nil {
+		securityHeaders = instance.Spec.PublicSecurity.GetHeaders()
+		for name, value := range securityHeaders {
+			httpdSecurityHeaders[name] = strings.ReplaceAll(value, `"`, `\"`)
+		}
+		if instance.Spec.PublicSecurity.ModSecurity != nil && instance.Spec.PublicSecurity.ModSecurity.CustomRules != "" {
+			customData[keystone.ModSecurityRulesFileName] = instance.Spec.PublicSecurity.ModSecurity.CustomRules
+		}
+	}
+	templateParameters["SecurityHeaders"] = securityHeaders
+
+	templateParameters["AuditLog"] = instance.Spec.AuditLog != nil
+	if instance.Spec.AuditLog != nil {
+		templateParameters["AuditLogVerbosity"] = string(instance.Spec.AuditLog.Verbosity)
+		templateParameters["AuditLogFile"] = keystone.AuditLogFile
+		templateParameters["AuditLogMaxBytes"] = int64(instance.Spec.AuditLog.MaxFileSizeMB) * 1024 * 1024
+		templateParameters["AuditLogMaxFiles"] = instance.Spec.AuditLog.MaxFiles
+	}
+
+	// notification options set in the spec, the others keep the keystone
+	// defaults or the audit log settings
+	notificationFormat := ""
+	notificationTopics := []string{keystone.NotificationTopic}
+	notificationOptOut := []string{}
+	optOutSet := instance.Spec.AuditLog != nil && instance.Spec.AuditLog.Verbosity == keystonev1.AuditLogFull
+	notificationRoutes := []map[string]interface{}{}
+	if notifications := instance.Spec.Notifications; notifications != nil {
+		notificationFormat = string(notifications.Format)
+		if len(notifications.Topics) > 0 {
+			notificationTopics = notifications.Topics
+		}
+		if len(notifications.OptOut) > 0 {
+			for _, eventType := range notifications.OptOut {
+				notificationOptOut = append(notificationOptOut, string(eventType))
+			}
+			optOutSet = true
+		}
+		for _, route := range notifications.Routes {
+			driver := "log"
+			if route.Destination == keystonev1.NotificationDestinationMessaging {
+				driver = "messagingv2"
+			}
+			notificationRoutes = append(notificationRoutes, map[string]interface{}{
+				"Driver":     driver,
+				"EventTypes": route.EventTypes,
+			})
+		}
+	}
+	templateParameters["NotificationFormat"] = notificationFormat
+	templateParameters["NotificationTopics"] = strings.Join(notificationTopics, ",")
+	templateParameters["NotificationOptOutSet"] = optOutSet
+	templateParameters["NotificationOptOut"] = notificationOptOut
+	templateParameters["NotificationRoutes"] = notificationRoutes
+	templateParameters["NotificationRoutingConfig"] = keystone.NotificationRoutingConfig
+
+	// receipt options set in the spec, the others keep the keystone defaults
+	receiptOptions := map[string]interface{}{}
+	if instance.Spec.MFA != nil && instance.Spec.MFA.Receipt != nil {
+		receipt := instance.Spec.MFA.Receipt
+		if receipt.Expiration != nil {
+			receiptOptions["expiration"] = *receipt.Expiration
+		}
+		if receipt.Provider != "" {
+			receiptOptions["provider"] = receipt.Provider
+		}
+		if receipt.Caching != nil {
+			receiptOptions["caching"] = *receipt.Caching
+		}
+		if receipt.CacheTime != nil {
+			receiptOptions["cache_time"] = *receipt.CacheTime
+		}
+		if receipt.CacheOnIssue != nil {
+			receiptOptions["cache_on_issue"] = *receipt.CacheOnIssue
+		}
+	}
+	templateParameters["ReceiptOptions"] = receiptOptions
+
+	// token caching and revocation options set in the spec
+	tokenOptions := instance.Spec.GetTokenOptions()
+	templateParameters["TokenOptions"] = tokenOptions["token"]
+	templateParameters["RevokeOptions"] = tokenOptions["revoke"]
+
+	templateParameters["Kerberos"] = instance.Spec.Kerberos != nil
+	if instance.Spec.Kerberos != nil {
+		templateParameters["KerberosDomain"] = instance.Spec.Kerberos.Domain
+	}
+
+	templateParameters["TokenlessAuth"] = instance.Spec.TokenlessAuth != nil
+	if instance.Spec.TokenlessAuth != nil {
+		templateParameters["TokenlessPort"] = keystone.KeystoneTokenlessPort
+		templateParameters["TokenlessTrustedIssuers"] = instance.Spec.TokenlessAuth.TrustedIssuers
+		templateParameters["TokenlessProtocol"] = instance.Spec.TokenlessAuth.Protocol
+		templateParameters["TokenlessIssuerAttribute"] = instance.Spec.TokenlessAuth.IssuerAttribute
+	}
+
+	// create httpd  vhost template parameters
+	customTemplates := map[string]string{}
+	httpdVhostConfig := map[string]interface{}{}
+	for _, endpt := range []service.Endpoint{service.EndpointInternal, service.EndpointPublic} {
+		endptConfig := map[string]interface{}{}
+		endptConfig["ServerName"] = fmt.Sprintf("%s-%s.%s.svc", instance.Name, endpt.String(), instance.Namespace)
+		endptConfig["TimeOut"] = instance.Spec.APITimeout
+		if endpt == service.EndpointPublic {
+			endptConfig["TimeOut"] = instance.Spec.GetPublicAPITimeout()
+		}
+		endptConfig["TLS"] = false // default TLS to false, and set it bellow to true if enabled
+		if instance.Spec.TLS.API.Enabled(endpt) {
+			endptConfig["TLS"] = true
+			endptConfig["SSLCertificateFile"] = fmt.Sprintf("/etc/pki/tls/certs/%s.crt", endpt.String())
+			endptConfig["SSLCertificateKeyFile"] = fmt.Sprintf("/etc/pki/tls/private/%s.key", endpt.String())
+		}
+
+		endptConfig["SecurityHeaders"] = map[string]string{}
+		endptConfig["ModSecurity"] = ""
+		endptConfig["ModSecurityCustomRules"] = false
+		if instance.Spec.PublicSecurity != nil && instance.Spec.PublicSecurity.ModSecurity != nil {
+			// the rules of the container image get loaded globally, only
+			// the public endpoint filters the requests
+			endptConfig["ModSecurity"] = "Off"
+		}
+		if endpt == service.EndpointPublic && instance.Spec.PublicSecurity != nil {
+			endptConfig["SecurityHeaders"] = httpdSecurityHeaders
+			if instance.Spec.PublicSecurity.ModSecurity != nil {
+				endptConfig["ModSecurity"] = string(instance.Spec.PublicSecurity.ModSecurity.RuleEngine)
+				endptConfig["ModSecurityCustomRules"] = instance.Spec.PublicSecurity.ModSecurity.CustomRules != ""
+			}
+		}
+
+		endptConfig["Override"] = false
+		if len(httpdOverrideSecret.Data) > 0 {
+			endptConfig["Override"] = true
+			for key, data := range httpdOverrideSecret.Data {
+				if len(data) > 0 {
+					customTemplates["httpd_custom_"+endpt.String()+"_"+key] = string(data)
+				}
+			}
+		}
+		httpdVhostConfig[endpt.String()] = endptConfig
+	}
+	templateParameters["VHosts"] = httpdVhostConfig
+
+	accessLogFormat := instance.Spec.HttpdCustomization.AccessLogFormat
+	if accessLogFormat == "" {
+		accessLogFormat = keystone.DefaultAccessLogFormat
+	}
+	templateParameters["AccessLogFormat"] = strings.ReplaceAll(accessLogFormat, `"`, `\"`)
+	templateParameters["TimeOut"] = instance.Spec.APITimeout
 
 	// Marshal the templateParameters map to YAML
 	yamlData, err := yaml.Marshal(templateParameters)
@@ -1482,6 +2978,10 @@ func (r *KeystoneAPIReconciler) reconcileCloudConfig(
 	return oko_secret.EnsureSecrets(ctx, h, instance, secrets, nil)
 }
 
+// fernetRotatedAtAnnotation - annotation of the fernet keys secret with the
+// time the keys got rotated last
+var fernetRotatedAtAnnotation = labels.GetGroupLabel(keystone.ServiceName) + "/rotatedat"
+
 // ensureFernetKeys - creates secret with fernet keys, rotates the keys
 func (r *KeystoneAPIReconciler) ensureFernetKeys(
 	ctx context.Context,
@@ -1490,7 +2990,6 @@ func (r *KeystoneAPIReconciler) ensureFernetKeys(
 	envVars *map[string]env.Setter,
 ) error {
 	logger := r.GetLogger(ctx)
-	fernetAnnotation := labels.GetGroupLabel(keystone.ServiceName) + "/rotatedat"
 	labels := labels.GetLabels(instance, labels.GetGroupLabel(keystone.ServiceName), map[string]string{})
 	now := time.Now().UTC()
 
@@ -1520,7 +3019,7 @@ func (r *KeystoneAPIReconciler) ensureFernetKeys(
 		}
 
 		annotations := map[string]string{
-			fernetAnnotation: now.Format(time.RFC3339)}
+			fernetRotatedAtAnnotation: now.Format(time.RFC3339)}
 
 		tmpl := []util.Template{
 			{
@@ -1550,7 +3049,7 @@ func (r *KeystoneAPIReconciler) ensureFernetKeys(
 		if secret.Annotations == nil {
 			secret.Annotations = map[string]string{}
 		}
-		rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[fernetAnnotation])
+		rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[fernetRotatedAtAnnotation])
 
 		var duration int
 		if instance.Spec.FernetRotationDays == nil {
@@ -1561,6 +3060,8 @@ func (r *KeystoneAPIReconciler) ensureFernetKeys(
 
 		if err != nil {
 			changedKeys = true
+		} else if rotatedAt.AddDate(0, 0, duration).Before(now) && !fernetKeysDistributed(instance, hash) {
+			logger.Info("Fernet keys rotation due, waiting for all keystone pods to use the current keys")
 		} else if rotatedAt.AddDate(0, 0, duration).Before(now) {
 			secret.Data[extraKey] = secret.Data["FernetKeys0"]
 			secret.Data["FernetKeys0"] = []byte(keystone.GenerateFernetKey(logger))
@@ -1617,7 +3118,7 @@ func (r *KeystoneAPIReconciler) ensureFernetKeys(
 			return nil
 		}
 
-		secret.Annotations[fernetAnnotation] = now.Format(time.RFC3339)
+		secret.Annotations[fernetRotatedAtAnnotation] = now.Format(time.RFC3339)
 
 		// use update to apply changes to the secret, since EnsureSecrets
 		// does not handle annotation updates, also CreateOrPatchSecret would
@@ -1742,10 +3243,13 @@ func (r *KeystoneAPIReconciler) createHashOfInputHashes(
 	return hash, changed, nil
 }
 
+// ensureDB - ensures the keystone database with the MariaDBAccount
+// accountName, the DatabaseAccount or the DatabaseSyncAccount
 func (r *KeystoneAPIReconciler) ensureDB(
 	ctx context.Context,
 	h *helper.Helper,
 	instance *keystonev1.KeystoneAPI,
+	accountName string,
 ) (*mariadbv1.Database, ctrl.Result, error) {
 
 	// ensure MariaDBAccount exists.  This account record may be created by
@@ -1755,7 +3259,7 @@ func (r *KeystoneAPIReconciler) ensureDB(
 	// generated password.   The MariaDBAccount is created without being
 	// yet associated with any MariaDBDatabase.
 	_, _, err := mariadbv1.EnsureMariaDBAccount(
-		ctx, h, instance.Spec.DatabaseAccount,
+		ctx, h, accountName,
 		instance.Namespace, false, keystone.DatabaseUsernamePrefix,
 	)
 
@@ -1765,7 +3269,7 @@ func (r *KeystoneAPIReconciler) ensureDB(
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			mariadbv1.MariaDBAccountNotReadyMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 
 		return nil, ctrl.Result{}, err
 	}
@@ -1780,7 +3284,7 @@ func (r *KeystoneAPIReconciler) ensureDB(
 		instance.Spec.DatabaseInstance, // mariadb/galera service to target
 		keystone.DatabaseName,          // name used in CREATE DATABASE in mariadb
 		keystone.DatabaseCRName,        // CR name for MariaDBDatabase
-		instance.Spec.DatabaseAccount,  // CR name for MariaDBAccount
+		accountName,                    // CR name for MariaDBAccount
 		instance.Namespace,             // namespace
 	)
 
@@ -1793,7 +3297,7 @@ func (r *KeystoneAPIReconciler) ensureDB(
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			condition.DBReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return db, ctrl.Result{}, err
 	}
 	if (ctrlResult != ctrl.Result{}) {
@@ -1813,7 +3317,7 @@ func (r *KeystoneAPIReconciler) ensureDB(
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			condition.DBReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return db, ctrlResult, err
 	}
 	if (ctrlResult != ctrl.Result{}) {
@@ -0,0 +1,629 @@
+/*
+   Copyright 2022.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/services"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+)
+
+// KeystoneCatalogReconciler reconciles a KeystoneCatalog object
+type KeystoneCatalogReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+	// Requeue - requeue intervals while waiting for the KeystoneAPI and
+	// KeystoneServices
+	Requeue RequeueIntervals
+	// Limits - safety limits of the keystone objects the controller creates
+	Limits SafetyLimits
+	// OpenStackClient - returns the keystone admin client, the client of
+	// the KeystoneAPI if not set. Tests set the factory of a fake.
+	OpenStackClient openstackclient.Factory
+
+	degraded degradedTracker
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneCatalogReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneCatalog")
+}
+
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonecatalogs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonecatalogs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonecatalogs/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
+
+// Reconcile keystone catalog requests
+func (r *KeystoneCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	Log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneCatalog instance
+	instance := &keystonev1.KeystoneCatalog{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			r.degraded.forget(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		Log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			Log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		updateReadyCondition(&instance.Status.Conditions,
+			instance.Generation, instance.Status.ObservedGeneration)
+		recordReconcile(instance.Spec, _err,
+			instance.Status.Conditions.IsTrue(condition.ReadyCondition),
+			&instance.Status.LastReconcileTime,
+			&instance.Status.LastSuccessfulReconcile,
+			&instance.Status.AppliedSpecHash)
+		r.degraded.handleResult(Log, req.NamespacedName, &instance.Status.Conditions, &result, &_err)
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneCatalogReadyCondition, condition.InitReason, keystonev1.KeystoneCatalogReadyInitMessage),
+		)
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, nil
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPI(ctx, helper, instance.Namespace, map[string]string{})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// If this KeystoneCatalog CR is being deleted and the KeystoneAPI is
+			// gone, its database went away with it and there is nothing to
+			// clean up. Waiting for a KeystoneAPI to appear would block the
+			// deletion, e.g. of the namespace.
+			if !instance.DeletionTimestamp.IsZero() {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			Log.Info("KeystoneAPI not found!")
+
+			return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	// If both the catalog and the KeystoneAPI is deleted then we can skip
+	// the cleanup on the OpenStack side as the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() {
+		skip, err := skipKeystoneCleanup(ctx, Log, r.Kclient, instance, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if skip {
+			return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+		}
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && len(instance.Status.Services) == 0 {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		Log.Info("KeystoneAPI not yet ready!")
+
+		return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack, the whole catalog gets reconciled
+	// with this single session
+	//
+	os, ctrlResult, err := getAdminClient(ctx, helper, keystoneAPI, r.OpenStackClient)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal catalog delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, newCatalogBackend(os), keystoneAPI)
+	}
+
+	// Handle non-deleted clusters
+	return r.reconcileNormal(ctx, instance, helper, newCatalogBackend(os), keystoneAPI)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KeystoneCatalogReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneCatalog{}).
+		Complete(r)
+}
+
+func (r *KeystoneCatalogReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneCatalog,
+	helper *helper.Helper,
+	os catalogBackend,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Catalog delete")
+	events := newCatalogEvents(Log, keystoneAPI, instance, "keystonecatalogs")
+
+	// We might not have an OpenStack backend to use in certain situations.
+	// Keystone deletes the endpoints of a service together with the service.
+	if os != nil {
+		for len(instance.Status.Services) > 0 {
+			svc := instance.Status.Services[0]
+			if svc.ServiceID != "" {
+				err := os.DeleteService(Log, svc.ServiceID)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				events.emit(ctx, cloudEventServiceDeleted, svc.ServiceName, map[string]string{
+					"serviceName": svc.ServiceName,
+					"serviceID":   svc.ServiceID,
+				})
+			}
+			instance.Status.Services = instance.Status.Services[1:]
+		}
+	}
+	instance.Status.Services = nil
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this catalog from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Catalog is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	Log.Info("Reconciled Catalog delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneCatalogReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneCatalog,
+	helper *helper.Helper,
+	os catalogBackend,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Catalog normal")
+
+	// label the CR with the KeystoneAPI it belongs to
+	setKeystoneAPILabel(instance, keystoneAPI)
+
+	//
+	// Add a finalizer to the KeystoneAPI for this catalog, as we do not want the
+	// KeystoneAPI to disappear before this catalog in the case where it is deleted
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// render the whole catalog before anything gets registered, an invalid
+	// entry must not leave a partially applied catalog behind
+	catalog, err := instance.RenderCatalog(keystoneAPI.Spec.Region)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneCatalogReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			keystonev1.KeystoneCatalogReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, nil
+	}
+
+	err = r.reconcileCatalog(ctx, instance, os, catalog,
+		newCatalogEvents(Log, keystoneAPI, instance, "keystonecatalogs"))
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneCatalogReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneCatalogReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneCatalogReadyCondition,
+		keystonev1.KeystoneCatalogReadyMessage,
+		len(instance.Status.Services),
+	)
+
+	Log.Info("Reconciled Catalog normal successfully")
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileCatalog - deletes the services which got removed from the spec and
+// creates or updates the services of the spec with their endpoints
+func (r *KeystoneCatalogReconciler) reconcileCatalog(
+	ctx context.Context,
+	instance *keystonev1.KeystoneCatalog,
+	os catalogBackend,
+	catalog map[string]map[string]string,
+	events *catalogEvents,
+) error {
+	Log := r.GetLogger(ctx)
+
+	owner, err := newOwnership(ctx, r.Kclient, instance)
+	if err != nil {
+		return err
+	}
+
+	// delete services which are no longer in the spec, keystone deletes
+	// their endpoints as well
+	services := []keystonev1.KeystoneCatalogServiceStatus{}
+	for i, svc := range instance.Status.Services {
+		if _, ok := catalog[svc.ServiceName]; ok {
+			services = append(services, svc)
+			continue
+		}
+		if svc.ServiceID != "" {
+			err := os.DeleteService(Log, svc.ServiceID)
+			if err != nil {
+				instance.Status.Services = append(services, instance.Status.Services[i:]...)
+				return err
+			}
+			events.emit(ctx, cloudEventServiceDeleted, svc.ServiceName, map[string]string{
+				"serviceName": svc.ServiceName,
+				"serviceID":   svc.ServiceID,
+			})
+		}
+	}
+	instance.Status.Services = services
+
+	for _, svc := range instance.Spec.Services {
+		svcStatus := instance.GetServiceStatus(svc.ServiceName)
+		if svcStatus == nil {
+			instance.Status.Services = append(instance.Status.Services,
+				keystonev1.KeystoneCatalogServiceStatus{ServiceName: svc.ServiceName})
+			svcStatus = &instance.Status.Services[len(instance.Status.Services)-1]
+		}
+
+		serviceID, err := r.reconcileService(ctx, os, svc, owner, events)
+		if err != nil {
+			return err
+		}
+		svcStatus.ServiceID = serviceID
+
+		err = r.reconcileEndpoints(ctx, os, svc.ServiceName, svcStatus, catalog[svc.ServiceName], owner, events)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reconcileService - creates the service or updates its description and
+// enabled flag, returns the ID of the service
+func (r *KeystoneCatalogReconciler) reconcileService(
+	ctx context.Context,
+	os catalogBackend,
+	svc keystonev1.KeystoneCatalogService,
+	owner *ownership,
+	events *catalogEvents,
+) (string, error) {
+	Log := r.GetLogger(ctx)
+
+	// verify if there is already a service in keystone for the type and name
+	service, err := os.GetService(
+		Log,
+		svc.ServiceType,
+		svc.ServiceName,
+	)
+	// If the service is not found, don't count that as an error here,
+	// it gets created bellow
+	if err != nil && !strings.Contains(err.Error(), openstack.ServiceNotFound) {
+		return "", err
+	}
+
+	osService := openstack.Service{
+		Name:        svc.ServiceName,
+		Type:        svc.ServiceType,
+		Description: svc.ServiceDescription,
+		Enabled:     svc.Enabled,
+	}
+	if service == nil {
+		serviceID, err := os.CreateService(Log, osService)
+		if err != nil {
+			return "", err
+		}
+		events.emit(ctx, cloudEventServiceCreated, svc.ServiceName, map[string]string{
+			"serviceName": svc.ServiceName,
+			"serviceType": svc.ServiceType,
+			"serviceID":   serviceID,
+		})
+		return serviceID, owner.tagService(Log, os, services.Service{ID: serviceID})
+	}
+
+	err = owner.checkService(*service)
+	if err != nil {
+		return "", err
+	}
+
+	if service.Enabled != svc.Enabled ||
+		service.Extra["description"] != svc.ServiceDescription {
+		err := os.UpdateService(Log, osService, service.ID)
+		if err != nil {
+			return "", err
+		}
+		events.emit(ctx, cloudEventServiceUpdated, svc.ServiceName, map[string]string{
+			"serviceName": svc.ServiceName,
+			"serviceType": svc.ServiceType,
+			"serviceID":   service.ID,
+			"enabled":     strconv.FormatBool(svc.Enabled),
+			"description": svc.ServiceDescription,
+		})
+	}
+
+	return service.ID, owner.tagService(Log, os, *service)
+}
+
+// reconcileEndpoints - deletes the endpoints of the service which got removed
+// from the spec and creates or updates the endpoints of the spec
+func (r *KeystoneCatalogReconciler) reconcileEndpoints(
+	ctx context.Context,
+	os catalogBackend,
+	serviceName string,
+	svcStatus *keystonev1.KeystoneCatalogServiceStatus,
+	endpoints map[string]string,
+	owner *ownership,
+	events *catalogEvents,
+) error {
+	Log := r.GetLogger(ctx)
+
+	registered := []keystonev1.Endpoint{}
+	for i, endpoint := range svcStatus.Endpoints {
+		if _, ok := endpoints[endpoint.Interface]; ok {
+			registered = append(registered, endpoint)
+			continue
+		}
+		availability, err := openstack.GetAvailability(endpoint.Interface)
+		if err != nil {
+			return err
+		}
+		err = os.DeleteEndpoint(
+			Log,
+			openstack.Endpoint{
+				Name:         serviceName,
+				ServiceID:    svcStatus.ServiceID,
+				Availability: availability,
+			},
+		)
+		if err != nil {
+			svcStatus.Endpoints = append(registered, svcStatus.Endpoints[i:]...)
+			return err
+		}
+		events.emit(ctx, cloudEventEndpointDeleted, serviceName, map[string]string{
+			"serviceName": serviceName,
+			"serviceID":   svcStatus.ServiceID,
+			"interface":   endpoint.Interface,
+			"endpointID":  endpoint.ID,
+		})
+	}
+	svcStatus.Endpoints = registered
+
+	owners, err := listEndpointOwners(os, svcStatus.ServiceID)
+	if err != nil {
+		return err
+	}
+
+	for endpointType, endpointURL := range endpoints {
+		// get the gopher availability mapping for the endpointType
+		availability, err := openstack.GetAvailability(endpointType)
+		if err != nil {
+			return err
+		}
+
+		// get registered endpoints for the service and endpointType
+		allEndpoints, err := os.GetEndpoints(
+			Log,
+			svcStatus.ServiceID,
+			endpointType)
+		if err != nil {
+			return err
+		}
+
+		endpointID := ""
+		synced := keystonev1.Endpoint{
+			Interface: endpointType,
+			URL:       endpointURL,
+			Region:    os.GetRegion(),
+			Enabled:   ptr.To(true),
+		}
+		switch len(allEndpoints) {
+		case 0:
+			err = r.Limits.checkEndpointLimit(Log, os, svcStatus.ServiceID, serviceName)
+			if err != nil {
+				return err
+			}
+			endpointID, err = os.CreateEndpoint(
+				Log,
+				openstack.Endpoint{
+					Name:         serviceName,
+					ServiceID:    svcStatus.ServiceID,
+					Availability: availability,
+					URL:          endpointURL,
+				},
+			)
+			if err != nil {
+				return err
+			}
+			events.emit(ctx, cloudEventEndpointCreated, serviceName, map[string]string{
+				"serviceName": serviceName,
+				"serviceID":   svcStatus.ServiceID,
+				"interface":   endpointType,
+				"endpointID":  endpointID,
+				"url":         endpointURL,
+			})
+		case 1:
+			err = owner.checkEndpoint(allEndpoints[0].ID, owners)
+			if err != nil {
+				return err
+			}
+			// Update the endpoint if URL changed
+			endpointID = allEndpoints[0].ID
+			synced.Region = allEndpoints[0].Region
+			synced.Enabled = ptr.To(allEndpoints[0].Enabled)
+			if endpointURL != allEndpoints[0].URL {
+				endpointID, err = os.UpdateEndpoint(
+					Log,
+					openstack.Endpoint{
+						Name:         allEndpoints[0].Name,
+						ServiceID:    allEndpoints[0].ServiceID,
+						Availability: availability,
+						URL:          endpointURL,
+					},
+					allEndpoints[0].ID,
+				)
+				if err != nil {
+					return err
+				}
+				events.emit(ctx, cloudEventEndpointUpdated, serviceName, map[string]string{
+					"serviceName": serviceName,
+					"serviceID":   svcStatus.ServiceID,
+					"interface":   endpointType,
+					"endpointID":  endpointID,
+					"url":         endpointURL,
+				})
+			}
+		default:
+			// a manual check is required
+			return fmt.Errorf("multiple endpoints registered for service:%s type: %s",
+				serviceName, endpointType)
+		}
+
+		err = owner.tagEndpoint(Log, os, endpointID, owners)
+		if err != nil {
+			return err
+		}
+		synced.ID = endpointID
+		svcStatus.Endpoints = setEndpointStatus(svcStatus.Endpoints, synced)
+	}
+
+	return nil
+}
@@ -0,0 +1,493 @@
+/*
+   Copyright 2022.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/endpoints"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	keystone "github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// KeystoneCatalogAuditReconciler reconciles a KeystoneCatalogAudit object
+type KeystoneCatalogAuditReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+	// Requeue - requeue intervals while waiting for the KeystoneAPI and
+	// KeystoneServices
+	Requeue RequeueIntervals
+	// OpenStackClient - returns the keystone admin client, the client of
+	// the KeystoneAPI if not set. Tests set the factory of a fake.
+	OpenStackClient openstackclient.Factory
+
+	degraded degradedTracker
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneCatalogAuditReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneCatalogAudit")
+}
+
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonecatalogaudits,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonecatalogaudits/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneservices,verbs=get;list
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneendpoints,verbs=get;list
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonecatalogs,verbs=get;list
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
+
+// Reconcile keystone catalog audit requests
+func (r *KeystoneCatalogAuditReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	Log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneCatalogAudit instance
+	instance := &keystonev1.KeystoneCatalogAudit{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Remove the metrics of the audit and don't requeue.
+			catalogAuditMismatches.DeletePartialMatch(map[string]string{
+				"namespace": req.Namespace,
+				"name":      req.Name,
+			})
+			r.degraded.forget(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		Log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			Log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		updateReadyCondition(&instance.Status.Conditions,
+			instance.Generation, instance.Status.ObservedGeneration)
+		recordReconcile(instance.Spec, _err,
+			instance.Status.Conditions.IsTrue(condition.ReadyCondition),
+			&instance.Status.LastReconcileTime,
+			&instance.Status.LastSuccessfulReconcile,
+			&instance.Status.AppliedSpecHash)
+		r.degraded.handleResult(Log, req.NamespacedName, &instance.Status.Conditions, &result, &_err)
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneCatalogAuditReadyCondition, condition.InitReason, keystonev1.KeystoneCatalogAuditReadyInitMessage),
+		)
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+
+	instance.Status.ObservedGeneration = instance.Generation
+
+	if !instance.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPI(ctx, helper, instance.Namespace, map[string]string{})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			Log.Info("KeystoneAPI not found!")
+
+			return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	// label the CR with the KeystoneAPI it belongs to
+	setKeystoneAPILabel(instance, keystoneAPI)
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		Log.Info("KeystoneAPI not yet ready!")
+
+		return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := getAdminClient(ctx, helper, keystoneAPI, r.OpenStackClient)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	return r.reconcileNormal(ctx, instance, helper, os)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KeystoneCatalogAuditReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneCatalogAudit{}).
+		Complete(r)
+}
+
+func (r *KeystoneCatalogAuditReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneCatalogAudit,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	interval := time.Duration(instance.Spec.AuditInterval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+
+	// only audit once per interval, a reconcile can get triggered earlier
+	// e.g. by a status update
+	if instance.Status.LastAuditTime != nil &&
+		instance.Status.Conditions.IsTrue(keystonev1.KeystoneCatalogAuditReadyCondition) {
+		next := instance.Status.LastAuditTime.Add(interval)
+		if wait := time.Until(next); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	Log.Info("Auditing catalog")
+
+	findings, err := r.auditCatalog(ctx, instance, helper, os)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneCatalogAuditReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneCatalogAuditReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	now := metav1.Now()
+	instance.Status.LastAuditTime = &now
+	instance.Status.Findings = findings
+	instance.Status.MismatchCount = len(findings)
+
+	// publish the number of mismatches per type
+	counts := map[keystonev1.CatalogAuditFindingType]int{
+		keystonev1.CatalogAuditUnmanagedService:   0,
+		keystonev1.CatalogAuditMissingService:     0,
+		keystonev1.CatalogAuditUnmanagedEndpoint:  0,
+		keystonev1.CatalogAuditMissingEndpoint:    0,
+		keystonev1.CatalogAuditMismatchedEndpoint: 0,
+		keystonev1.CatalogAuditOrphanedService:    0,
+		keystonev1.CatalogAuditOrphanedEndpoint:   0,
+	}
+	for _, f := range findings {
+		counts[f.Type]++
+	}
+	for t, c := range counts {
+		catalogAuditMismatches.WithLabelValues(instance.Namespace, instance.Name, string(t)).Set(float64(c))
+	}
+
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneCatalogAuditReadyCondition,
+		keystonev1.KeystoneCatalogAuditReadyMessage,
+		instance.Status.MismatchCount)
+
+	Log.Info("Audited catalog successfully", "mismatches", instance.Status.MismatchCount)
+
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// auditCatalog - compares the services and endpoints registered in keystone
+// with the KeystoneService and KeystoneEndpoint CRs of the namespace. The
+// identity service is managed by the KeystoneAPI and therefore skipped.
+// Services and endpoints not referenced by a CR of the namespace are only
+// reported as unmanaged if they do not carry the ownership marker of a CR of
+// this cluster, e.g. of a KeystoneCatalog. If that CR no longer exists they
+// are reported as orphaned.
+func (r *KeystoneCatalogAuditReconciler) auditCatalog(
+	ctx context.Context,
+	instance *keystonev1.KeystoneCatalogAudit,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+) ([]keystonev1.CatalogAuditFinding, error) {
+	findings := []keystonev1.CatalogAuditFinding{}
+
+	osServices, err := os.ListServices()
+	if err != nil {
+		return nil, err
+	}
+	osEndpoints, err := os.ListEndpoints(endpoints.ListOpts{})
+	if err != nil {
+		return nil, err
+	}
+
+	clusterID, err := getClusterID(ctx, r.Kclient)
+	if err != nil {
+		return nil, err
+	}
+	liveOwners, err := r.listOwnerUIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ksSvcs := &keystonev1.KeystoneServiceList{}
+	err = r.Client.List(ctx, ksSvcs, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	ksEndpts, err := keystonev1.GetKeystoneEndpointList(ctx, helper, instance.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// services
+	identityServiceIDs := map[string]bool{}
+	osServiceIDs := map[string]bool{}
+	for _, svc := range osServices {
+		osServiceIDs[svc.ID] = true
+		if svc.Type == keystone.ServiceType {
+			identityServiceIDs[svc.ID] = true
+		}
+	}
+
+	managedServiceIDs := map[string]bool{}
+	for _, ksSvc := range ksSvcs.Items {
+		if ksSvc.Status.ServiceID == "" {
+			continue
+		}
+		managedServiceIDs[ksSvc.Status.ServiceID] = true
+		if !osServiceIDs[ksSvc.Status.ServiceID] {
+			findings = append(findings, keystonev1.CatalogAuditFinding{
+				Type:   keystonev1.CatalogAuditMissingService,
+				ID:     ksSvc.Status.ServiceID,
+				Name:   ksSvc.Name,
+				Detail: fmt.Sprintf("service %s of KeystoneService %s not registered in keystone", ksSvc.Spec.ServiceName, ksSvc.Name),
+			})
+		}
+	}
+
+	for _, svc := range osServices {
+		if managedServiceIDs[svc.ID] || identityServiceIDs[svc.ID] {
+			continue
+		}
+		name, _ := svc.Extra["name"].(string)
+		owner := serviceOwner(svc)
+		switch {
+		case owner.ClusterID == clusterID && liveOwners[owner.OwnerUID]:
+			continue
+		case owner.ClusterID == clusterID:
+			findings = append(findings, keystonev1.CatalogAuditFinding{
+				Type:   keystonev1.CatalogAuditOrphanedService,
+				ID:     svc.ID,
+				Name:   name,
+				Detail: fmt.Sprintf("service %s of type %s registered for CR %s which no longer exists", name, svc.Type, owner.OwnerUID),
+			})
+		default:
+			findings = append(findings, keystonev1.CatalogAuditFinding{
+				Type:   keystonev1.CatalogAuditUnmanagedService,
+				ID:     svc.ID,
+				Name:   name,
+				Detail: fmt.Sprintf("service %s of type %s has no KeystoneService%s", name, svc.Type, foreignOwnerDetail(owner)),
+			})
+		}
+	}
+
+	// endpoints
+	osEndpointURLs := map[string]string{}
+	for _, endpt := range osEndpoints {
+		osEndpointURLs[endpt.ID] = endpt.URL
+	}
+
+	managedEndpointIDs := map[string]bool{}
+	for _, ksEndpt := range ksEndpts.Items {
+		for _, endpt := range append(ksEndpt.Status.Endpoints, ksEndpt.Status.RegionalEndpoints...) {
+			managedEndpointIDs[endpt.ID] = true
+			url, ok := osEndpointURLs[endpt.ID]
+			if !ok {
+				findings = append(findings, keystonev1.CatalogAuditFinding{
+					Type:   keystonev1.CatalogAuditMissingEndpoint,
+					ID:     endpt.ID,
+					Name:   ksEndpt.Name,
+					Detail: fmt.Sprintf("%s endpoint of KeystoneEndpoint %s not registered in keystone", endpt.Interface, ksEndpt.Name),
+				})
+			} else if url != endpt.URL {
+				findings = append(findings, keystonev1.CatalogAuditFinding{
+					Type:   keystonev1.CatalogAuditMismatchedEndpoint,
+					ID:     endpt.ID,
+					Name:   ksEndpt.Name,
+					Detail: fmt.Sprintf("%s endpoint of KeystoneEndpoint %s registered with url %s instead of %s", endpt.Interface, ksEndpt.Name, url, endpt.URL),
+				})
+			}
+		}
+	}
+
+	for _, endpt := range osEndpoints {
+		if managedEndpointIDs[endpt.ID] || identityServiceIDs[endpt.ServiceID] {
+			continue
+		}
+		owner := endpointOwner(endpt)
+		switch {
+		case owner.ClusterID == clusterID && liveOwners[owner.OwnerUID]:
+			continue
+		case owner.ClusterID == clusterID:
+			findings = append(findings, keystonev1.CatalogAuditFinding{
+				Type:   keystonev1.CatalogAuditOrphanedEndpoint,
+				ID:     endpt.ID,
+				Name:   endpt.Name,
+				Detail: fmt.Sprintf("%s endpoint %s of service %s registered for CR %s which no longer exists", endpt.Availability, endpt.URL, endpt.ServiceID, owner.OwnerUID),
+			})
+		default:
+			findings = append(findings, keystonev1.CatalogAuditFinding{
+				Type:   keystonev1.CatalogAuditUnmanagedEndpoint,
+				ID:     endpt.ID,
+				Name:   endpt.Name,
+				Detail: fmt.Sprintf("%s endpoint %s of service %s has no KeystoneEndpoint%s", endpt.Availability, endpt.URL, endpt.ServiceID, foreignOwnerDetail(owner)),
+			})
+		}
+	}
+
+	// keep the report stable between audits
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Type != findings[j].Type {
+			return findings[i].Type < findings[j].Type
+		}
+		return findings[i].ID < findings[j].ID
+	})
+
+	return findings, nil
+}
+
+// listOwnerUIDs - returns the UIDs of the KeystoneServices, KeystoneEndpoints
+// and KeystoneCatalogs of all watched namespaces, which register services and
+// endpoints in keystone
+func (r *KeystoneCatalogAuditReconciler) listOwnerUIDs(ctx context.Context) (map[string]bool, error) {
+	uids := map[string]bool{}
+
+	ksSvcs := &keystonev1.KeystoneServiceList{}
+	err := r.Client.List(ctx, ksSvcs)
+	if err != nil {
+		return nil, err
+	}
+	for _, cr := range ksSvcs.Items {
+		uids[string(cr.UID)] = true
+	}
+
+	ksEndpts := &keystonev1.KeystoneEndpointList{}
+	err = r.Client.List(ctx, ksEndpts)
+	if err != nil {
+		return nil, err
+	}
+	for _, cr := range ksEndpts.Items {
+		uids[string(cr.UID)] = true
+	}
+
+	ksCatalogs := &keystonev1.KeystoneCatalogList{}
+	err = r.Client.List(ctx, ksCatalogs)
+	if err != nil {
+		return nil, err
+	}
+	for _, cr := range ksCatalogs.Items {
+		uids[string(cr.UID)] = true
+	}
+
+	return uids, nil
+}
+
+// foreignOwnerDetail - returns the detail of a finding about the ownership
+// marker of another cluster, empty if there is none
+func foreignOwnerDetail(owner keystoneOwner) string {
+	if owner.ClusterID == "" {
+		return ""
+	}
+	return fmt.Sprintf(", registered by the operator of cluster %s", owner.ClusterID)
+}
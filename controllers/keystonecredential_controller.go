@@ -0,0 +1,562 @@
+/*
+   Copyright 2022.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/go-logr/logr"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/identity"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// KeystoneCredentialReconciler reconciles a KeystoneCredential object
+type KeystoneCredentialReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+	// Requeue - requeue intervals while waiting for the KeystoneAPI and
+	// KeystoneServices
+	Requeue RequeueIntervals
+	// OpenStackClient - returns the keystone admin client, the client of
+	// the KeystoneAPI if not set. Tests set the factory of a fake.
+	OpenStackClient openstackclient.Factory
+
+	degraded degradedTracker
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneCredentialReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneCredential")
+}
+
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonecredentials,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonecredentials/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonecredentials/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
+
+// Reconcile keystone credential requests
+func (r *KeystoneCredentialReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	Log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneCredential instance
+	instance := &keystonev1.KeystoneCredential{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			r.degraded.forget(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		Log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			Log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		updateReadyCondition(&instance.Status.Conditions,
+			instance.Generation, instance.Status.ObservedGeneration)
+		recordReconcile(instance.Spec, _err,
+			instance.Status.Conditions.IsTrue(condition.ReadyCondition),
+			&instance.Status.LastReconcileTime,
+			&instance.Status.LastSuccessfulReconcile,
+			&instance.Status.AppliedSpecHash)
+		r.degraded.handleResult(Log, req.NamespacedName, &instance.Status.Conditions, &result, &_err)
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneCredentialReadyCondition, condition.InitReason, keystonev1.KeystoneCredentialReadyInitMessage),
+		)
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, nil
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPI(ctx, helper, instance.Namespace, map[string]string{})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// If this KeystoneCredential CR is being deleted and the KeystoneAPI is
+			// gone, its database went away with it and there is nothing to
+			// clean up. Waiting for a KeystoneAPI to appear would block the
+			// deletion, e.g. of the namespace.
+			if !instance.DeletionTimestamp.IsZero() {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			Log.Info("KeystoneAPI not found!")
+
+			return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	// If both the credential and the KeystoneAPI is deleted then we can
+	// skip the cleanup on the OpenStack side as the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() {
+		skip, err := skipKeystoneCleanup(ctx, Log, r.Kclient, instance, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if skip {
+			return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+		}
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && instance.Status.CredentialID == "" {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		Log.Info("KeystoneAPI not yet ready!")
+
+		return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := getAdminClient(ctx, helper, keystoneAPI, r.OpenStackClient)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal credential delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+	}
+
+	// Handle non-deleted clusters
+	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+}
+
+// fields to index to reconcile when change
+const (
+	credentialBlobSecretField = ".spec.blobSecretRef.name" // #nosec G101
+)
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KeystoneCredentialReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	// index credentialBlobSecretField
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &keystonev1.KeystoneCredential{}, credentialBlobSecretField, func(rawObj client.Object) []string {
+		// Extract the secret name from the spec
+		cr := rawObj.(*keystonev1.KeystoneCredential)
+		if cr.Spec.BlobSecretRef.Name == "" {
+			return nil
+		}
+		return []string{cr.Spec.BlobSecretRef.Name}
+	}); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneCredential{}).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSrc),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Complete(r)
+}
+
+func (r *KeystoneCredentialReconciler) findObjectsForSrc(ctx context.Context, src client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	Log := r.GetLogger(context.Background())
+
+	crList := &keystonev1.KeystoneCredentialList{}
+	listOps := &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(credentialBlobSecretField, src.GetName()),
+		Namespace:     src.GetNamespace(),
+	}
+	err := r.List(ctx, crList, listOps)
+	if err != nil {
+		Log.Error(err, fmt.Sprintf("listing %s for field: %s - %s", crList.GroupVersionKind().Kind, credentialBlobSecretField, src.GetNamespace()))
+		return requests
+	}
+
+	for _, item := range crList.Items {
+		Log.Info(fmt.Sprintf("input source %s changed, reconcile: %s - %s", src.GetName(), item.GetName(), item.GetNamespace()))
+
+		requests = append(requests,
+			reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      item.GetName(),
+					Namespace: item.GetNamespace(),
+				},
+			},
+		)
+	}
+
+	return requests
+}
+
+func (r *KeystoneCredentialReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneCredential,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Credential delete")
+
+	// We might not have an OpenStack backend to use in certain situations.
+	if os != nil && instance.Status.CredentialID != "" {
+		err := identity.DeleteCredential(Log, os, instance.Status.CredentialID)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	instance.Status.CredentialID = ""
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this credential from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Credential is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	Log.Info("Reconciled Credential delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneCredentialReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneCredential,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Credential normal")
+
+	// label the CR with the KeystoneAPI it belongs to
+	setKeystoneAPILabel(instance, keystoneAPI)
+
+	//
+	// Add a finalizer to the KeystoneAPI for this credential, as we do not want the
+	// KeystoneAPI to disappear before this credential in the case where it is deleted
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// keystone rejects ec2 credentials without a project, retrying does not help
+	if instance.Spec.Type == keystonev1.CredentialTypeEC2 && instance.Spec.ProjectName == "" {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneCredentialReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			keystonev1.KeystoneCredentialReadyErrorMessage,
+			"projectName is required for ec2 credentials"))
+		return ctrl.Result{}, nil
+	}
+
+	//
+	// resolve the user and project of the credential
+	//
+	userID, projectID, waitingFor, err := r.getUserAndProject(ctx, instance, os)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneCredentialReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneCredentialReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if waitingFor != "" {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneCredentialReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneCredentialReadyWaitingMessage,
+			waitingFor))
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	//
+	// get the blob of the credential from the Secret
+	//
+	blob, err := getSecretKey(ctx, helper, &instance.Spec.BlobSecretRef, instance.Namespace)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) || errors.Is(err, util.ErrNotFound) {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneCredentialReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				keystonev1.KeystoneCredentialReadyWaitingMessage,
+				fmt.Sprintf("secret %s", instance.Spec.BlobSecretRef.Name)))
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneCredentialReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneCredentialReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	blobHash, err := util.ObjectHash(blob)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = r.reconcileCredential(ctx, instance, os, userID, projectID, blob, blobHash)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneCredentialReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneCredentialReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneCredentialReadyCondition,
+		keystonev1.KeystoneCredentialReadyMessage,
+		instance.Status.CredentialID,
+	)
+
+	Log.Info("Reconciled Credential normal successfully")
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileCredential - creates the credential or updates its blob. The
+// credential gets recreated if its user, project or type changed, or the blob
+// of an ec2 credential changed as keystone derives its ID from the access.
+func (r *KeystoneCredentialReconciler) reconcileCredential(
+	ctx context.Context,
+	instance *keystonev1.KeystoneCredential,
+	os openstackclient.OpenStackClient,
+	userID string,
+	projectID string,
+	blob string,
+	blobHash string,
+) error {
+	Log := r.GetLogger(ctx)
+
+	recreate := instance.Status.CredentialID == "" ||
+		instance.Status.UserID != userID ||
+		instance.Status.ProjectID != projectID
+	if !recreate {
+		cred, err := identity.GetCredential(Log, os, instance.Status.CredentialID)
+		if err != nil {
+			return err
+		}
+		recreate = cred == nil || cred.Type != string(instance.Spec.Type) ||
+			(instance.Spec.Type == keystonev1.CredentialTypeEC2 && instance.Status.BlobHash != blobHash)
+	}
+
+	if !recreate {
+		if instance.Status.BlobHash != blobHash {
+			err := identity.UpdateCredential(Log, os, instance.Status.CredentialID, blob)
+			if err != nil {
+				return err
+			}
+			instance.Status.BlobHash = blobHash
+		}
+		return nil
+	}
+
+	if instance.Status.CredentialID != "" {
+		err := identity.DeleteCredential(Log, os, instance.Status.CredentialID)
+		if err != nil {
+			return err
+		}
+		instance.Status.CredentialID = ""
+	}
+
+	credentialID, err := identity.CreateCredential(Log, os, identity.Credential{
+		Type:      string(instance.Spec.Type),
+		UserID:    userID,
+		ProjectID: projectID,
+		Blob:      blob,
+	})
+	if err != nil {
+		return err
+	}
+	instance.Status.CredentialID = credentialID
+	instance.Status.UserID = userID
+	instance.Status.ProjectID = projectID
+	instance.Status.BlobHash = blobHash
+
+	return nil
+}
+
+// getUserAndProject - returns the IDs of the user and the optional project of
+// the credential. If one of them does not exist, a non empty description of
+// what is missing gets returned.
+func (r *KeystoneCredentialReconciler) getUserAndProject(
+	ctx context.Context,
+	instance *keystonev1.KeystoneCredential,
+	os openstackclient.OpenStackClient,
+) (string, string, string, error) {
+	Log := r.GetLogger(ctx)
+
+	userDomainID, err := getDomainID(Log, os, instance.Spec.UserDomain, false)
+	if err != nil {
+		return "", "", "", err
+	}
+	if userDomainID == "" {
+		return "", "", fmt.Sprintf("domain %s", instance.Spec.UserDomain), nil
+	}
+	user, err := os.GetUser(Log, instance.Spec.UserName, userDomainID)
+	if err != nil {
+		if strings.Contains(err.Error(), openstack.UserNotFound) {
+			return "", "", fmt.Sprintf("user %s", instance.Spec.UserName), nil
+		}
+		return "", "", "", err
+	}
+
+	if instance.Spec.ProjectName == "" {
+		return user.ID, "", "", nil
+	}
+
+	projectDomainID, err := getDomainID(Log, os, instance.Spec.ProjectDomain, false)
+	if err != nil {
+		return "", "", "", err
+	}
+	if projectDomainID == "" {
+		return "", "", fmt.Sprintf("domain %s", instance.Spec.ProjectDomain), nil
+	}
+	project, err := os.GetProject(Log, instance.Spec.ProjectName, projectDomainID)
+	if err != nil {
+		if strings.Contains(err.Error(), openstack.ProjectNotFound) {
+			return "", "", fmt.Sprintf("project %s", instance.Spec.ProjectName), nil
+		}
+		return "", "", "", err
+	}
+
+	return user.ID, project.ID, "", nil
+}
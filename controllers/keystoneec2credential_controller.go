@@ -0,0 +1,479 @@
+/*
+   Copyright 2022.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/go-logr/logr"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/identity"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// KeystoneEC2CredentialReconciler reconciles a KeystoneEC2Credential object
+type KeystoneEC2CredentialReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+	// Requeue - requeue intervals while waiting for the KeystoneAPI and
+	// KeystoneServices
+	Requeue RequeueIntervals
+	// OpenStackClient - returns the keystone admin client, the client of
+	// the KeystoneAPI if not set. Tests set the factory of a fake.
+	OpenStackClient openstackclient.Factory
+
+	degraded degradedTracker
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneEC2CredentialReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneEC2Credential")
+}
+
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneec2credentials,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneec2credentials/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneec2credentials/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
+
+// Reconcile keystone EC2 credential requests
+func (r *KeystoneEC2CredentialReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	Log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneEC2Credential instance
+	instance := &keystonev1.KeystoneEC2Credential{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			r.degraded.forget(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		Log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			Log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		updateReadyCondition(&instance.Status.Conditions,
+			instance.Generation, instance.Status.ObservedGeneration)
+		recordReconcile(instance.Spec, _err,
+			instance.Status.Conditions.IsTrue(condition.ReadyCondition),
+			&instance.Status.LastReconcileTime,
+			&instance.Status.LastSuccessfulReconcile,
+			&instance.Status.AppliedSpecHash)
+		r.degraded.handleResult(Log, req.NamespacedName, &instance.Status.Conditions, &result, &_err)
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneEC2CredentialReadyCondition, condition.InitReason, keystonev1.KeystoneEC2CredentialReadyInitMessage),
+		)
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, nil
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPI(ctx, helper, instance.Namespace, map[string]string{})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// If this KeystoneEC2Credential CR is being deleted and the KeystoneAPI is
+			// gone, its database went away with it and there is nothing to
+			// clean up. Waiting for a KeystoneAPI to appear would block the
+			// deletion, e.g. of the namespace.
+			if !instance.DeletionTimestamp.IsZero() {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			Log.Info("KeystoneAPI not found!")
+
+			return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	// If both the credential and the KeystoneAPI is deleted then we can
+	// skip the cleanup on the OpenStack side as the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() {
+		skip, err := skipKeystoneCleanup(ctx, Log, r.Kclient, instance, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if skip {
+			return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+		}
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && instance.Status.AccessKey == "" {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		Log.Info("KeystoneAPI not yet ready!")
+
+		return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := getAdminClient(ctx, helper, keystoneAPI, r.OpenStackClient)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal credential delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+	}
+
+	// Handle non-deleted clusters
+	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KeystoneEC2CredentialReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneEC2Credential{}).
+		Owns(&corev1.Secret{}).
+		Complete(r)
+}
+
+func (r *KeystoneEC2CredentialReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneEC2Credential,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling EC2 Credential delete")
+
+	// We might not have an OpenStack backend to use in certain situations.
+	// The Secret is owned by the instance and gets garbage collected.
+	if os != nil && instance.Status.AccessKey != "" {
+		err := identity.DeleteEC2Credential(Log, os, instance.Status.UserID, instance.Status.AccessKey)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	instance.Status.AccessKey = ""
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this credential from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Credential is revoked so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	Log.Info("Reconciled EC2 Credential delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneEC2CredentialReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneEC2Credential,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling EC2 Credential normal")
+
+	// label the CR with the KeystoneAPI it belongs to
+	setKeystoneAPILabel(instance, keystoneAPI)
+
+	//
+	// Add a finalizer to the KeystoneAPI for this credential, as we do not want the
+	// KeystoneAPI to disappear before this credential in the case where it is deleted
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	//
+	// resolve the user and project of the credential
+	//
+	userID, projectID, waitingFor, err := r.getUserAndProject(ctx, instance, os)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneEC2CredentialReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneEC2CredentialReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if waitingFor != "" {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneEC2CredentialReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneEC2CredentialReadyWaitingMessage,
+			waitingFor))
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	//
+	// verify the current credential is still valid and stored in the Secret
+	//
+	secretName := instance.GetSecretName()
+	valid := instance.Status.AccessKey != "" &&
+		instance.Status.UserID == userID &&
+		instance.Status.ProjectID == projectID &&
+		instance.Status.SecretName == secretName
+	if valid {
+		scrt, _, err := oko_secret.GetSecret(ctx, helper, secretName, instance.Namespace)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		valid = err == nil && string(scrt.Data[keystonev1.EC2CredentialAccessKey]) == instance.Status.AccessKey
+	}
+	if valid {
+		cred, err := identity.GetEC2Credential(Log, os, userID, instance.Status.AccessKey)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneEC2CredentialReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneEC2CredentialReadyErrorMessage,
+				keystonev1.KeystoneErrorMessage(err)))
+			return ctrl.Result{}, err
+		}
+		valid = cred != nil
+	}
+
+	if !valid {
+		// revoke the previous credential, its secret is not known anymore
+		if instance.Status.AccessKey != "" {
+			err := identity.DeleteEC2Credential(Log, os, instance.Status.UserID, instance.Status.AccessKey)
+			if err != nil {
+				instance.Status.Conditions.Set(condition.FalseCondition(
+					keystonev1.KeystoneEC2CredentialReadyCondition,
+					condition.ErrorReason,
+					condition.SeverityWarning,
+					keystonev1.KeystoneEC2CredentialReadyErrorMessage,
+					keystonev1.KeystoneErrorMessage(err)))
+				return ctrl.Result{}, err
+			}
+			instance.Status.AccessKey = ""
+		}
+		if instance.Status.SecretName != "" && instance.Status.SecretName != secretName {
+			err := oko_secret.DeleteSecretsWithName(ctx, helper, instance.Status.SecretName, instance.Namespace)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		cred, err := identity.CreateEC2Credential(Log, os, userID, projectID)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneEC2CredentialReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneEC2CredentialReadyErrorMessage,
+				keystonev1.KeystoneErrorMessage(err)))
+			return ctrl.Result{}, err
+		}
+		// record the credential before storing it, so that it gets revoked
+		// if storing the Secret fails
+		instance.Status.AccessKey = cred.Access
+		instance.Status.UserID = userID
+		instance.Status.ProjectID = projectID
+		instance.Status.SecretName = secretName
+
+		_, _, err = oko_secret.CreateOrPatchSecret(ctx, helper, instance, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: instance.Namespace,
+			},
+			Data: map[string][]byte{
+				keystonev1.EC2CredentialAccessKey: []byte(cred.Access),
+				keystonev1.EC2CredentialSecretKey: []byte(cred.Secret),
+			},
+		})
+		if err != nil {
+			instance.Status.SecretName = ""
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneEC2CredentialReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneEC2CredentialReadyErrorMessage,
+				keystonev1.KeystoneErrorMessage(err)))
+			return ctrl.Result{}, err
+		}
+	}
+
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneEC2CredentialReadyCondition,
+		keystonev1.KeystoneEC2CredentialReadyMessage,
+		secretName,
+	)
+
+	Log.Info("Reconciled EC2 Credential normal successfully")
+
+	return ctrl.Result{}, nil
+}
+
+// getUserAndProject - returns the IDs of the user and project of the
+// credential. If one of them does not exist, a non empty description of what
+// is missing gets returned.
+func (r *KeystoneEC2CredentialReconciler) getUserAndProject(
+	ctx context.Context,
+	instance *keystonev1.KeystoneEC2Credential,
+	os openstackclient.OpenStackClient,
+) (string, string, string, error) {
+	Log := r.GetLogger(ctx)
+
+	userDomainID, err := getDomainID(Log, os, instance.Spec.UserDomain, false)
+	if err != nil {
+		return "", "", "", err
+	}
+	if userDomainID == "" {
+		return "", "", fmt.Sprintf("domain %s", instance.Spec.UserDomain), nil
+	}
+	user, err := os.GetUser(Log, instance.Spec.UserName, userDomainID)
+	if err != nil {
+		if strings.Contains(err.Error(), openstack.UserNotFound) {
+			return "", "", fmt.Sprintf("user %s", instance.Spec.UserName), nil
+		}
+		return "", "", "", err
+	}
+
+	projectDomainID, err := getDomainID(Log, os, instance.Spec.ProjectDomain, false)
+	if err != nil {
+		return "", "", "", err
+	}
+	if projectDomainID == "" {
+		return "", "", fmt.Sprintf("domain %s", instance.Spec.ProjectDomain), nil
+	}
+	project, err := os.GetProject(Log, instance.Spec.ProjectName, projectDomainID)
+	if err != nil {
+		if strings.Contains(err.Error(), openstack.ProjectNotFound) {
+			return "", "", fmt.Sprintf("project %s", instance.Spec.ProjectName), nil
+		}
+		return "", "", "", err
+	}
+
+	return user.ID, project.ID, "", nil
+}
@@ -19,23 +19,38 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/go-logr/logr"
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
 	util "github.com/openstack-k8s-operators/lib-common/modules/common/util"
 	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
 	"golang.org/x/exp/slices"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
 )
 
 // KeystoneEndpointReconciler reconciles a KeystoneEndpoint object
@@ -43,6 +58,18 @@ type KeystoneEndpointReconciler struct {
 	client.Client
 	Kclient kubernetes.Interface
 	Scheme  *runtime.Scheme
+	// Requeue - requeue intervals while waiting for the KeystoneAPI and
+	// KeystoneServices
+	Requeue RequeueIntervals
+	// Limits - safety limits of the keystone objects the controller creates
+	Limits SafetyLimits
+	// OpenStackClient - returns the keystone admin client, the client of
+	// the KeystoneAPI if not set. Tests set the factory of a fake.
+	OpenStackClient openstackclient.Factory
+
+	degraded degradedTracker
+	// probeClients - HTTP clients of the readiness gate and the URL checks
+	probeClients probeClients
 }
 
 // GetLog returns a logger object with a logging prefix of "controller.name" and additional controller context fields
@@ -55,8 +82,11 @@ func (r *KeystoneEndpointReconciler) GetLogger(ctx context.Context) logr.Logger
 //+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneendpoints/finalizers,verbs=update;patch
 //+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
 //+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
-//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneservices,verbs=get;list;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneservices,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneservices/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=endpoints,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
 
 // Reconcile keystone endpoint requests
 func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
@@ -70,6 +100,7 @@ func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			r.degraded.forget(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -96,17 +127,14 @@ func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			panic(r)
 		}
 		// update the Ready condition based on the sub conditions
-		if instance.Status.Conditions.AllSubConditionIsTrue() {
-			instance.Status.Conditions.MarkTrue(
-				condition.ReadyCondition, condition.ReadyMessage)
-		} else {
-			// something is not ready so reset the Ready condition
-			instance.Status.Conditions.MarkUnknown(
-				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
-			// and recalculate it based on the state of the rest of the conditions
-			instance.Status.Conditions.Set(
-				instance.Status.Conditions.Mirror(condition.ReadyCondition))
-		}
+		updateReadyCondition(&instance.Status.Conditions,
+			instance.Generation, instance.Status.ObservedGeneration)
+		recordReconcile(instance.Spec, _err,
+			instance.Status.Conditions.IsTrue(condition.ReadyCondition),
+			&instance.Status.LastReconcileTime,
+			&instance.Status.LastSuccessfulReconcile,
+			&instance.Status.AppliedSpecHash)
+		r.degraded.handleResult(Log, req.NamespacedName, &instance.Status.Conditions, &result, &_err)
 		err := helper.PatchInstance(ctx, instance)
 		if err != nil {
 			_err = err
@@ -125,6 +153,7 @@ func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			condition.UnknownCondition(keystonev1.KeystoneServiceOSEndpointsReadyCondition, condition.InitReason, keystonev1.KeystoneServiceOSEndpointsReadyInitMessage),
 			// right now we have no dedicated KeystoneServiceReadyInitMessage
 			condition.UnknownCondition(condition.KeystoneServiceReadyCondition, condition.InitReason, ""),
+			condition.UnknownCondition(keystonev1.KeystoneEndpointReadinessGateReadyCondition, condition.InitReason, keystonev1.KeystoneEndpointReadinessGateReadyInitMessage),
 		)
 		instance.Status.Conditions.Init(&cl)
 
@@ -152,11 +181,11 @@ func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	keystoneAPI, err := keystonev1.GetKeystoneAPI(ctx, helper, instance.Namespace, map[string]string{})
 	if err != nil {
 		if k8s_errors.IsNotFound(err) {
-			// If this KeystoneEndpoint CR is being deleted and it has not registered any actual
-			// endpoints on the OpenStack side, just redirect execution to the "reconcileDelete()"
-			// logic to avoid potentially hanging on waiting for a KeystoneAPI to appear (which
-			// is not needed anyhow, since there is nothing to clean-up on the OpenStack side)
-			if !instance.DeletionTimestamp.IsZero() && len(instance.Status.EndpointIDs) == 0 {
+			// If this KeystoneEndpoint CR is being deleted and the KeystoneAPI is
+			// gone, its database went away with it and there is nothing to
+			// clean up. Waiting for a KeystoneAPI to appear would block the
+			// deletion, e.g. of the namespace.
+			if !instance.DeletionTimestamp.IsZero() {
 				return r.reconcileDelete(ctx, instance, helper, nil, nil)
 			}
 
@@ -168,14 +197,14 @@ func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			))
 			Log.Info("KeystoneAPI not found!")
 
-			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+			return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
 		}
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			keystonev1.KeystoneAPIReadyCondition,
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			keystonev1.KeystoneAPIReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrl.Result{}, err
 	}
 
@@ -184,8 +213,14 @@ func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	// Moreover if KeystoneAPI is being deleted then we cannot talk to the
 	// keystone REST API any more. This happens for example during namespace
 	// deletion.
-	if !instance.DeletionTimestamp.IsZero() && !keystoneAPI.DeletionTimestamp.IsZero() {
-		return r.reconcileDeleteFinalizersOnly(ctx, instance, helper, keystoneAPI)
+	if !instance.DeletionTimestamp.IsZero() {
+		skip, err := skipKeystoneCleanup(ctx, Log, r.Kclient, instance, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if skip {
+			return r.reconcileDeleteFinalizersOnly(ctx, instance, helper, keystoneAPI)
+		}
 	}
 
 	// If this KeystoneEndpoint CR is being deleted and it has not registered any actual
@@ -205,25 +240,21 @@ func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			keystonev1.KeystoneAPIReadyWaitingMessage))
 		Log.Info("KeystoneAPI not yet ready!")
 
-		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
 	}
 	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
 
 	//
 	// get admin authentication OpenStack
 	//
-	os, ctrlResult, err := keystonev1.GetAdminServiceClient(
-		ctx,
-		helper,
-		keystoneAPI,
-	)
+	os, ctrlResult, err := getAdminClient(ctx, helper, keystoneAPI, r.OpenStackClient)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			keystonev1.AdminServiceClientReadyCondition,
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			keystonev1.AdminServiceClientReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrl.Result{}, err
 	}
 	if (ctrlResult != ctrl.Result{}) {
@@ -238,36 +269,102 @@ func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	// Handle normal endpoint delete
 	if !instance.DeletionTimestamp.IsZero() {
-		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+		return r.reconcileDelete(ctx, instance, helper, newCatalogBackend(os), keystoneAPI)
 	}
 
 	// Handle non-deleted clusters
-	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+	return r.reconcileNormal(ctx, instance, helper, newCatalogBackend(os), keystoneAPI)
 }
 
+// fields to index to reconcile when change
+const (
+	endpointServiceNameField = ".spec.serviceName"
+)
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *KeystoneEndpointReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// index endpointServiceNameField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneEndpoint{}, endpointServiceNameField, func(rawObj client.Object) []string {
+		// Extract the service name from the spec
+		cr := rawObj.(*keystonev1.KeystoneEndpoint)
+		return []string{cr.Spec.ServiceName}
+	}); err != nil {
+		return err
+	}
+
+	// re-register the endpoints when the KeystoneService got recreated
+	// with a new service ID
+	serviceIDChanged := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.(*keystonev1.KeystoneService).Status.ServiceID !=
+				e.ObjectNew.(*keystonev1.KeystoneService).Status.ServiceID
+		},
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&keystonev1.KeystoneEndpoint{}).
+		Watches(&keystonev1.KeystoneService{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForService),
+			builder.WithPredicates(serviceIDChanged)).
 		Complete(r)
 }
 
+// findObjectsForService - returns the KeystoneEndpoints of the KeystoneService
+func (r *KeystoneEndpointReconciler) findObjectsForService(ctx context.Context, src client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	Log := r.GetLogger(ctx)
+
+	crList := &keystonev1.KeystoneEndpointList{}
+	listOps := &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(endpointServiceNameField, src.(*keystonev1.KeystoneService).Spec.ServiceName),
+		Namespace:     src.GetNamespace(),
+	}
+	err := r.List(ctx, crList, listOps)
+	if err != nil {
+		Log.Error(err, fmt.Sprintf("listing %s for field: %s - %s", crList.GroupVersionKind().Kind, endpointServiceNameField, src.GetNamespace()))
+		return requests
+	}
+
+	for _, item := range crList.Items {
+		requests = append(requests,
+			reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      item.GetName(),
+					Namespace: item.GetNamespace(),
+				},
+			},
+		)
+	}
+
+	return requests
+}
+
 func (r *KeystoneEndpointReconciler) reconcileDelete(
 	ctx context.Context,
 	instance *keystonev1.KeystoneEndpoint,
 	helper *helper.Helper,
-	os *openstack.OpenStack,
+	os catalogBackend,
 	keystoneAPI *keystonev1.KeystoneAPI,
 ) (ctrl.Result, error) {
 	Log := r.GetLogger(ctx)
 
 	Log.Info("Reconciling Endpoint delete")
+	events := newCatalogEvents(Log, keystoneAPI, instance, "keystoneendpoints")
 
 	// We might not have an OpenStack backend to use in certain situations
 	if os != nil {
 		// Delete Endpoints -  it is ok to call delete on non existing Endpoints
-		// therefore always call delete for the spec.
+		// therefore always call delete for the spec and for all endpoints
+		// still referenced in the status.
+		endpointTypes := map[string]bool{}
 		for endpointType := range instance.Spec.Endpoints {
+			endpointTypes[endpointType] = true
+		}
+		for endpointType := range instance.Status.EndpointIDs {
+			endpointTypes[endpointType] = true
+		}
+		for endpointType := range endpointTypes {
 			// get the gopher availability mapping for the endpointInterface
 			availability, err := openstack.GetAvailability(endpointType)
 			if err != nil {
@@ -285,6 +382,19 @@ func (r *KeystoneEndpointReconciler) reconcileDelete(
 			if err != nil {
 				return ctrl.Result{}, err
 			}
+			if endpointID, ok := instance.Status.EndpointIDs[endpointType]; ok {
+				events.emit(ctx, cloudEventEndpointDeleted, instance.Spec.ServiceName, map[string]string{
+					"serviceName": instance.Spec.ServiceName,
+					"serviceID":   instance.Status.ServiceID,
+					"interface":   endpointType,
+					"endpointID":  endpointID,
+				})
+			}
+		}
+
+		err := r.deleteRegionalEndpoints(ctx, instance, os, instance.Spec.ServiceName, events)
+		if err != nil {
+			return ctrl.Result{}, err
 		}
 	}
 
@@ -365,7 +475,7 @@ func (r *KeystoneEndpointReconciler) reconcileNormal(
 	ctx context.Context,
 	instance *keystonev1.KeystoneEndpoint,
 	helper *helper.Helper,
-	os *openstack.OpenStack,
+	os catalogBackend,
 	keystoneAPI *keystonev1.KeystoneAPI,
 ) (ctrl.Result, error) {
 	Log := r.GetLogger(ctx)
@@ -378,7 +488,7 @@ func (r *KeystoneEndpointReconciler) reconcileNormal(
 	if err != nil {
 		if k8s_errors.IsNotFound(err) {
 			Log.Info("KeystoneService not found", "KeystoneService", instance.Spec.ServiceName)
-			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+			return ctrl.Result{RequeueAfter: r.Requeue.keystoneService()}, nil
 		}
 
 		return ctrl.Result{}, err
@@ -389,14 +499,47 @@ func (r *KeystoneEndpointReconciler) reconcileNormal(
 	if c != nil {
 		instance.Status.Conditions.Set(c)
 	}
+	// a Ready condition of a previous generation must not be mirrored as ready
+	if ksSvc.Status.ObservedGeneration != ksSvc.Generation {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.KeystoneServiceReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.ObservedGenerationWaitingMessage,
+			ksSvc.Generation,
+			ksSvc.Status.ObservedGeneration))
+	}
 
 	if !ksSvc.IsReady() {
 		Log.Info("KeystoneService not ready, waiting to create endpoints", "KeystoneService", instance.Spec.ServiceName)
 
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: r.Requeue.keystoneService()}, nil
+	}
+
+	//
+	// move the endpoints registered for a previous service name, or for a
+	// KeystoneService which got recreated with a new service ID, to the
+	// current service, otherwise they are left behind under the old service ID
+	//
+	if (instance.Status.ServiceName != "" && instance.Status.ServiceName != instance.Spec.ServiceName) ||
+		(instance.Status.ServiceID != "" && instance.Status.ServiceID != ksSvc.Status.ServiceID) {
+		err = r.migrateEndpoints(ctx, instance, helper, os, keystoneAPI)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneServiceOSEndpointsReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneServiceOSEndpointsReadyErrorMessage,
+				keystonev1.KeystoneErrorMessage(err)))
+			return ctrl.Result{}, err
+		}
 	}
 
 	instance.Status.ServiceID = ksSvc.Status.ServiceID
+	instance.Status.ServiceName = instance.Spec.ServiceName
+
+	// label the CR with the KeystoneAPI it belongs to
+	setKeystoneAPILabel(instance, keystoneAPI)
 
 	//
 	// Add a finalizer to the KeystoneAPI for this endpoint instance, as we do not want the
@@ -424,37 +567,227 @@ func (r *KeystoneEndpointReconciler) reconcileNormal(
 		}
 	}
 
+	//
+	// only register the endpoints when the backing API is ready
+	//
+	var probeClient *http.Client
+	gate := instance.Spec.ReadinessGate
+	if (gate != nil && gate.URL != "") || instance.Spec.VerifyURLChanges || instance.Spec.URLRollback != nil {
+		probeClient, err = r.probeClients.get(ctx, helper, keystoneAPI)
+	}
+	var notReadyReason string
+	if err == nil {
+		notReadyReason, err = r.checkReadinessGate(ctx, instance, probeClient)
+	}
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneEndpointReadinessGateReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneEndpointReadinessGateReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if notReadyReason != "" {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneEndpointReadinessGateReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneEndpointReadinessGateReadyWaitingMessage,
+			notReadyReason))
+		Log.Info("Readiness gate not passed, waiting to register endpoints", "reason", notReadyReason)
+
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneEndpointReadinessGateReadyCondition,
+		keystonev1.KeystoneEndpointReadinessGateReadyMessage)
+
+	//
+	// validate the endpoint types and render the variables in the endpoint URLs
+	//
+	err = instance.ValidateEndpoints()
+	var endpoints map[string]string
+	var regionalEndpoints map[string]map[string]string
+	if err == nil {
+		endpoints, err = instance.RenderEndpoints(keystoneAPI.Spec.Region)
+	}
+	if err == nil {
+		regionalEndpoints, err = instance.RenderRegionalEndpoints(keystoneAPI.Spec.Region)
+	}
+	if err != nil {
+		// the spec needs to be fixed, no need to requeue
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneServiceOSEndpointsReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneServiceOSEndpointsReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, nil
+	}
+
 	//
 	// create/update endpoints
 	//
+	preflight := newURLPreflight(instance, probeClient)
+	rollback := newURLRollback(instance, probeClient)
+	events := newCatalogEvents(Log, keystoneAPI, instance, "keystoneendpoints")
 	err = r.reconcileEndpoints(
 		ctx,
 		instance,
-		os)
+		os,
+		endpoints,
+		events,
+		preflight,
+		rollback)
+	if err == nil {
+		err = r.reconcileRegionalEndpoints(ctx, instance, os, regionalEndpoints, events)
+	}
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			keystonev1.KeystoneServiceOSEndpointsReadyCondition,
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			keystonev1.KeystoneServiceOSEndpointsReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrl.Result{}, err
 	}
 	instance.Status.Conditions.MarkTrue(
 		keystonev1.KeystoneServiceOSEndpointsReadyCondition,
 		keystonev1.KeystoneServiceOSEndpointsReadyMessage,
-		instance.Spec.Endpoints,
+		endpoints,
 	)
 
+	if revertRequest := instance.Annotations[keystonev1.RevertEndpointsAnnotation]; revertRequest != instance.Status.LastRevertRequest {
+		instance.Status.LastRevertRequest = revertRequest
+		Log.Info(fmt.Sprintf("Endpoint URLs reverted for %s=%s", keystonev1.RevertEndpointsAnnotation, revertRequest))
+	}
+
+	switch {
+	case len(rollback.rolledBack) > 0:
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneEndpointURLRollbackReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneEndpointURLRollbackReadyErrorMessage,
+			strings.Join(rollback.rolledBack, ", ")))
+	case instance.Spec.URLRollback != nil:
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneEndpointURLRollbackReadyCondition,
+			keystonev1.KeystoneEndpointURLRollbackReadyMessage)
+	default:
+		instance.Status.Conditions.Remove(keystonev1.KeystoneEndpointURLRollbackReadyCondition)
+	}
+
+	switch {
+	case len(instance.Status.PendingPruneRegions) > 0:
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneEndpointRegionPruneReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneEndpointRegionPruneReadyPendingMessage,
+			strings.Join(instance.Status.PendingPruneRegions, ", "),
+			keystonev1.PruneRegionsAnnotation))
+	case len(instance.Status.RegionalEndpoints) > 0:
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneEndpointRegionPruneReadyCondition,
+			keystonev1.KeystoneEndpointRegionPruneReadyMessage)
+	default:
+		instance.Status.Conditions.Remove(keystonev1.KeystoneEndpointRegionPruneReadyCondition)
+	}
+
+	switch {
+	case preflight == nil:
+		instance.Status.Conditions.Remove(keystonev1.KeystoneEndpointURLPreflightReadyCondition)
+	case len(preflight.failures) > 0:
+		// keep probing the new URLs, e.g. until the new API got deployed
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneEndpointURLPreflightReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneEndpointURLPreflightReadyErrorMessage,
+			strings.Join(preflight.failures, ", ")))
+		Log.Info("Endpoint URL changes failed the probe, keeping the registered URLs", "failures", preflight.failures)
+
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	default:
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneEndpointURLPreflightReadyCondition,
+			keystonev1.KeystoneEndpointURLPreflightReadyMessage)
+	}
+
 	Log.Info("Reconciled Endpoint normal successfully")
 
+	if rollback.inWindow(instance.Status.Endpoints) {
+		// probe the changed URLs until the rollback window passed
+		return ctrl.Result{RequeueAfter: 15 * time.Second}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
+// checkReadinessGate - runs the checks of the readiness gate, if configured.
+// The URL gets probed with the probe client of the KeystoneAPI. Returns a
+// non empty reason if one of the checks did not yet pass.
+func (r *KeystoneEndpointReconciler) checkReadinessGate(
+	ctx context.Context,
+	instance *keystonev1.KeystoneEndpoint,
+	probeClient *http.Client,
+) (string, error) {
+	gate := instance.Spec.ReadinessGate
+	if gate == nil {
+		return "", nil
+	}
+
+	if gate.DeploymentName != "" {
+		depl := &appsv1.Deployment{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: gate.DeploymentName, Namespace: instance.Namespace}, depl)
+		if err != nil {
+			if k8s_errors.IsNotFound(err) {
+				return fmt.Sprintf("deployment %s not found", gate.DeploymentName), nil
+			}
+			return "", err
+		}
+		if depl.Status.ReadyReplicas < 1 {
+			return fmt.Sprintf("deployment %s has no ready replicas", gate.DeploymentName), nil
+		}
+	}
+
+	if gate.ServiceName != "" {
+		eps := &corev1.Endpoints{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: gate.ServiceName, Namespace: instance.Namespace}, eps)
+		if err != nil {
+			if k8s_errors.IsNotFound(err) {
+				return fmt.Sprintf("service %s has no endpoints", gate.ServiceName), nil
+			}
+			return "", err
+		}
+		ready := false
+		for _, subset := range eps.Subsets {
+			if len(subset.Addresses) > 0 {
+				ready = true
+				break
+			}
+		}
+		if !ready {
+			return fmt.Sprintf("service %s has no ready endpoint addresses", gate.ServiceName), nil
+		}
+	}
+
+	if gate.URL != "" {
+		return probeURL(ctx, probeClient, gate.URL)
+	}
+
+	return "", nil
+}
+
 func (r *KeystoneEndpointReconciler) reconcileEndpoints(
 	ctx context.Context,
 	instance *keystonev1.KeystoneEndpoint,
-	os *openstack.OpenStack,
+	os catalogBackend,
+	endpoints map[string]string,
+	events *catalogEvents,
+	preflight *urlPreflight,
+	rollback *urlRollback,
 ) error {
 	Log := r.GetLogger(ctx)
 	Log.Info("Reconciling Endpoints")
@@ -463,7 +796,7 @@ func (r *KeystoneEndpointReconciler) reconcileEndpoints(
 	// but has a reference in Status.EndpointIDs
 	if instance.Status.EndpointIDs != nil {
 		for endpointType := range instance.Status.EndpointIDs {
-			if _, ok := instance.Spec.Endpoints[endpointType]; !ok {
+			if _, ok := endpoints[endpointType]; !ok {
 				// get the gopher availability mapping for the endpointInterface
 				availability, err := openstack.GetAvailability(endpointType)
 				if err != nil {
@@ -481,6 +814,12 @@ func (r *KeystoneEndpointReconciler) reconcileEndpoints(
 				if err != nil {
 					return err
 				}
+				events.emit(ctx, cloudEventEndpointDeleted, instance.Spec.ServiceName, map[string]string{
+					"serviceName": instance.Spec.ServiceName,
+					"serviceID":   instance.Status.ServiceID,
+					"interface":   endpointType,
+					"endpointID":  instance.Status.EndpointIDs[endpointType],
+				})
 
 				// remove endpoint reference from status
 				delete(instance.Status.EndpointIDs, endpointType)
@@ -493,8 +832,17 @@ func (r *KeystoneEndpointReconciler) reconcileEndpoints(
 		}
 	}
 
+	owner, err := newOwnership(ctx, r.Kclient, instance)
+	if err != nil {
+		return err
+	}
+	owners, err := listEndpointOwners(os, instance.Status.ServiceID)
+	if err != nil {
+		return err
+	}
+
 	// create / update endpoints
-	for endpointType, endpointURL := range instance.Spec.Endpoints {
+	for endpointType, endpointURL := range endpoints {
 
 		// get the gopher availability mapping for the endpointType
 		availability, err := openstack.GetAvailability(endpointType)
@@ -512,7 +860,17 @@ func (r *KeystoneEndpointReconciler) reconcileEndpoints(
 		}
 
 		endpointID := ""
+		synced := keystonev1.Endpoint{
+			Interface: endpointType,
+			URL:       endpointURL,
+			Region:    os.GetRegion(),
+			Enabled:   ptr.To(true),
+		}
 		if len(allEndpoints) == 0 {
+			err = r.Limits.checkEndpointLimit(Log, os, instance.Status.ServiceID, instance.Spec.ServiceName)
+			if err != nil {
+				return err
+			}
 			// Create the endpoint
 			endpointID, err = os.CreateEndpoint(
 				Log,
@@ -526,24 +884,79 @@ func (r *KeystoneEndpointReconciler) reconcileEndpoints(
 			if err != nil {
 				return err
 			}
+			events.emit(ctx, cloudEventEndpointCreated, instance.Spec.ServiceName, map[string]string{
+				"serviceName": instance.Spec.ServiceName,
+				"serviceID":   instance.Status.ServiceID,
+				"interface":   endpointType,
+				"endpointID":  endpointID,
+				"url":         endpointURL,
+			})
 		} else if len(allEndpoints) == 1 {
-			// Update the endpoint if URL changed
 			endpoint := allEndpoints[0]
+			err = owner.checkEndpoint(endpoint.ID, owners)
+			if err != nil {
+				return err
+			}
 			endpointID = endpoint.ID
-			if endpointURL != endpoint.URL {
+			synced.URL = endpoint.URL
+			synced.Region = endpoint.Region
+			synced.Enabled = ptr.To(endpoint.Enabled)
+			if idx := getEndpointIdx(endpointType, instance.Status.Endpoints); idx >= 0 {
+				synced.PreviousURL = instance.Status.Endpoints[idx].PreviousURL
+				synced.URLChangeTime = instance.Status.Endpoints[idx].URLChangeTime
+				synced.RolledBackURL = instance.Status.Endpoints[idx].RolledBackURL
+			}
+
+			targetURL, isRollback, err := rollback.targetURL(ctx, endpointType, &synced, endpoint.URL, endpointURL)
+			if err != nil {
+				return err
+			}
+			// a dead new URL, e.g. because of a typo, would break all
+			// clients of the service, keep the registered one
+			if targetURL != endpoint.URL && !isRollback {
+				urlOK, err := preflight.check(ctx, endpointType, targetURL)
+				if err != nil {
+					return err
+				}
+				if !urlOK {
+					targetURL = endpoint.URL
+				}
+			}
+
+			// Update the endpoint if URL changed
+			if targetURL != endpoint.URL {
 				endpointID, err = os.UpdateEndpoint(
 					Log,
 					openstack.Endpoint{
 						Name:         endpoint.Name,
 						ServiceID:    endpoint.ServiceID,
 						Availability: availability,
-						URL:          endpointURL,
+						URL:          targetURL,
 					},
 					endpoint.ID,
 				)
 				if err != nil {
 					return err
 				}
+				events.emit(ctx, cloudEventEndpointUpdated, instance.Spec.ServiceName, map[string]string{
+					"serviceName": instance.Spec.ServiceName,
+					"serviceID":   instance.Status.ServiceID,
+					"interface":   endpointType,
+					"endpointID":  endpointID,
+					"url":         targetURL,
+				})
+
+				// record the previous URL for a rollback, a rolled back URL
+				// does not get rolled back again
+				if isRollback {
+					Log.Info(fmt.Sprintf("Rolled back %s endpoint from %s to %s", endpointType, endpoint.URL, targetURL))
+					synced.RolledBackURL = endpoint.URL
+					synced.PreviousURL = ""
+				} else {
+					synced.PreviousURL = endpoint.URL
+				}
+				synced.URL = targetURL
+				synced.URLChangeTime = ptr.To(metav1.Now())
 			}
 		} else {
 			// If there are multiple endpoints for the service and endpoint type log it as an error
@@ -555,22 +968,15 @@ func (r *KeystoneEndpointReconciler) reconcileEndpoints(
 		}
 
 		if endpointID != "" {
+			err = owner.tagEndpoint(Log, os, endpointID, owners)
+			if err != nil {
+				return err
+			}
 			if _, ok := instance.Spec.Endpoints[endpointType]; ok {
 				instance.Status.EndpointIDs[endpointType] = endpointID
 			}
-			// validate if endpoint is already in the endpoint status list
-			idx := getEndpointIdx(endpointType, instance.Status.Endpoints)
-			if idx >= 0 {
-				instance.Status.Endpoints[idx].ID = endpointID
-				instance.Status.Endpoints[idx].URL = endpointURL
-			} else {
-				instance.Status.Endpoints = append(instance.Status.Endpoints,
-					keystonev1.Endpoint{
-						Interface: endpointType,
-						URL:       endpointURL,
-						ID:        endpointID,
-					})
-			}
+			synced.ID = endpointID
+			instance.Status.Endpoints = setEndpointStatus(instance.Status.Endpoints, synced)
 		}
 	}
 
@@ -579,6 +985,101 @@ func (r *KeystoneEndpointReconciler) reconcileEndpoints(
 	return nil
 }
 
+// migrateEndpoints - deletes the endpoints registered under the previous
+// service ID. If Spec.ServiceName changed the finalizer gets removed from the
+// previous KeystoneService. The endpoints get created for the current service
+// afterwards.
+func (r *KeystoneEndpointReconciler) migrateEndpoints(
+	ctx context.Context,
+	instance *keystonev1.KeystoneEndpoint,
+	helper *helper.Helper,
+	os catalogBackend,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) error {
+	Log := r.GetLogger(ctx)
+	Log.Info(fmt.Sprintf("Service %s (%s) changed to %s, moving endpoints",
+		instance.Status.ServiceName, instance.Status.ServiceID, instance.Spec.ServiceName))
+	events := newCatalogEvents(Log, keystoneAPI, instance, "keystoneendpoints")
+
+	if instance.Status.ServiceID != "" {
+		for endpointType, endpointID := range instance.Status.EndpointIDs {
+			availability, err := openstack.GetAvailability(endpointType)
+			if err != nil {
+				return err
+			}
+			err = os.DeleteEndpoint(
+				Log,
+				openstack.Endpoint{
+					Name:         instance.Status.ServiceName,
+					ServiceID:    instance.Status.ServiceID,
+					Availability: availability,
+				},
+			)
+			if err != nil {
+				return err
+			}
+			events.emit(ctx, cloudEventEndpointDeleted, instance.Status.ServiceName, map[string]string{
+				"serviceName": instance.Status.ServiceName,
+				"serviceID":   instance.Status.ServiceID,
+				"interface":   endpointType,
+				"endpointID":  endpointID,
+			})
+			delete(instance.Status.EndpointIDs, endpointType)
+		}
+
+		err := r.deleteRegionalEndpoints(ctx, instance, os, instance.Status.ServiceName, events)
+		if err != nil {
+			return err
+		}
+	}
+	instance.Status.Endpoints = nil
+	instance.Status.RegionalEndpoints = nil
+
+	if instance.Status.ServiceName != "" && instance.Status.ServiceName != instance.Spec.ServiceName {
+		oldSvc, err := keystonev1.GetKeystoneServiceWithName(ctx, helper, instance.Status.ServiceName, instance.Namespace)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return err
+		}
+		if err == nil && controllerutil.RemoveFinalizer(oldSvc, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err = r.Update(ctx, oldSvc)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	instance.Status.ServiceID = ""
+	instance.Status.ServiceName = ""
+
+	return nil
+}
+
+// setEndpointStatus - records the endpoint synced with keystone in the
+// endpoint status list, replacing the entry of the same interface. Like the
+// LastReconcileTime the LastSyncTime of an unchanged endpoint gets updated at
+// most once a minute.
+func setEndpointStatus(endpoints []keystonev1.Endpoint, synced keystonev1.Endpoint) []keystonev1.Endpoint {
+	now := metav1.Now()
+	synced.LastSyncedURL = synced.URL
+	synced.LastSyncTime = &now
+
+	// validate if endpoint is already in the endpoint status list
+	idx := getEndpointIdx(synced.Interface, endpoints)
+	if idx < 0 {
+		return append(endpoints, synced)
+	}
+
+	prev := endpoints[idx]
+	if prev.ID == synced.ID && prev.URL == synced.URL && prev.Region == synced.Region &&
+		prev.LastSyncedURL == synced.LastSyncedURL && ptr.Equal(prev.Enabled, synced.Enabled) &&
+		prev.LastSyncTime != nil && now.Sub(prev.LastSyncTime.Time) < reconcileTimeResolution {
+		synced.LastSyncTime = prev.LastSyncTime
+	}
+	endpoints[idx] = synced
+
+	return endpoints
+}
+
 // getEndpointIdx - returns the index of the endpointType from a list of Endpoints
 // if not found -1 is returnd
 func getEndpointIdx(endpointType string, endpoints []keystonev1.Endpoint) int {
@@ -0,0 +1,203 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	libtls "github.com/openstack-k8s-operators/lib-common/modules/common/tls"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newReadinessGateTestReconciler(g *WithT, objects ...client.Object) *KeystoneEndpointReconciler {
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	g.Expect(keystonev1.AddToScheme(scheme)).To(Succeed())
+	return &KeystoneEndpointReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Scheme: scheme,
+	}
+}
+
+func newReadinessGateTestEndpoint(gate keystonev1.KeystoneEndpointReadinessGate) *keystonev1.KeystoneEndpoint {
+	return &keystonev1.KeystoneEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Name: "placement", Namespace: "openstack"},
+		Spec: keystonev1.KeystoneEndpointSpec{
+			ReadinessGate: &gate,
+		},
+	}
+}
+
+func TestCheckReadinessGateDeployment(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	instance := newReadinessGateTestEndpoint(keystonev1.KeystoneEndpointReadinessGate{
+		DeploymentName: "placement",
+	})
+
+	r := newReadinessGateTestReconciler(g)
+	reason, err := r.checkReadinessGate(ctx, instance, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reason).To(Equal("deployment placement not found"))
+
+	depl := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "placement", Namespace: "openstack"},
+	}
+	r = newReadinessGateTestReconciler(g, depl)
+	reason, err = r.checkReadinessGate(ctx, instance, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reason).To(Equal("deployment placement has no ready replicas"))
+
+	depl.Status.ReadyReplicas = 1
+	r = newReadinessGateTestReconciler(g, depl)
+	reason, err = r.checkReadinessGate(ctx, instance, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reason).To(BeEmpty())
+}
+
+func TestCheckReadinessGateEndpoints(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	instance := newReadinessGateTestEndpoint(keystonev1.KeystoneEndpointReadinessGate{
+		ServiceName: "placement-internal",
+	})
+
+	r := newReadinessGateTestReconciler(g)
+	reason, err := r.checkReadinessGate(ctx, instance, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reason).To(Equal("service placement-internal has no endpoints"))
+
+	eps := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "placement-internal", Namespace: "openstack"},
+		Subsets: []corev1.EndpointSubset{
+			{NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}},
+		},
+	}
+	r = newReadinessGateTestReconciler(g, eps)
+	reason, err = r.checkReadinessGate(ctx, instance, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reason).To(Equal("service placement-internal has no ready endpoint addresses"))
+
+	eps.Subsets = append(eps.Subsets, corev1.EndpointSubset{
+		Addresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}},
+	})
+	r = newReadinessGateTestReconciler(g, eps)
+	reason, err = r.checkReadinessGate(ctx, instance, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reason).To(BeEmpty())
+}
+
+func TestCheckReadinessGateURL(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	status := http.StatusOK
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	caBundle := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}))
+
+	keystoneAPI := &keystonev1.KeystoneAPI{
+		ObjectMeta: metav1.ObjectMeta{Name: "keystone", Namespace: "openstack"},
+	}
+	keystoneAPI.Spec.TLS.CaBundleSecretName = "combined-ca-bundle"
+	caSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "combined-ca-bundle", Namespace: "openstack"},
+		Data: map[string][]byte{
+			libtls.InternalCABundleKey: []byte(caBundle),
+		},
+	}
+	r := newReadinessGateTestReconciler(g, keystoneAPI, caSecret)
+	h, err := helper.NewHelper(keystoneAPI, r.Client, nil, r.Scheme, logr.Discard())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	instance := newReadinessGateTestEndpoint(keystonev1.KeystoneEndpointReadinessGate{
+		URL: server.URL,
+	})
+
+	// the certificate of the server is only trusted with the CA bundle
+	untrusted, err := newProbeClient("")
+	g.Expect(err).NotTo(HaveOccurred())
+	reason, err := r.checkReadinessGate(ctx, instance, untrusted)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reason).To(ContainSubstring("certificate"))
+
+	probeClient, err := r.probeClients.get(ctx, h, keystoneAPI)
+	g.Expect(err).NotTo(HaveOccurred())
+	reason, err = r.checkReadinessGate(ctx, instance, probeClient)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reason).To(BeEmpty())
+
+	status = http.StatusServiceUnavailable
+	reason, err = r.checkReadinessGate(ctx, instance, probeClient)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reason).To(Equal("probe of " + server.URL + " returned 503"))
+}
+
+func TestProbeClientsReuseClient(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	keystoneAPI := &keystonev1.KeystoneAPI{
+		ObjectMeta: metav1.ObjectMeta{Name: "keystone", Namespace: "openstack"},
+	}
+	r := newReadinessGateTestReconciler(g, keystoneAPI)
+	h, err := helper.NewHelper(keystoneAPI, r.Client, nil, r.Scheme, logr.Discard())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	first, err := r.probeClients.get(ctx, h, keystoneAPI)
+	g.Expect(err).NotTo(HaveOccurred())
+	second, err := r.probeClients.get(ctx, h, keystoneAPI)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(second).To(BeIdenticalTo(first))
+
+	// a changed CA bundle replaces the client
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	caSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "combined-ca-bundle", Namespace: "openstack"},
+		Data: map[string][]byte{
+			libtls.InternalCABundleKey: pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: server.Certificate().Raw,
+			}),
+		},
+	}
+	g.Expect(r.Client.Create(ctx, caSecret)).To(Succeed())
+	keystoneAPI.Spec.TLS.CaBundleSecretName = caSecret.Name
+
+	third, err := r.probeClients.get(ctx, h, keystoneAPI)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(third).NotTo(BeIdenticalTo(first))
+}
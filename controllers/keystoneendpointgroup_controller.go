@@ -0,0 +1,451 @@
+/*
+   Copyright 2022.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/go-logr/logr"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/identity"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	"golang.org/x/exp/slices"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// KeystoneEndpointGroupReconciler reconciles a KeystoneEndpointGroup object
+type KeystoneEndpointGroupReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+	// Requeue - requeue intervals while waiting for the KeystoneAPI and
+	// KeystoneServices
+	Requeue RequeueIntervals
+	// OpenStackClient - returns the keystone admin client, the client of
+	// the KeystoneAPI if not set. Tests set the factory of a fake.
+	OpenStackClient openstackclient.Factory
+
+	degraded degradedTracker
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneEndpointGroupReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneEndpointGroup")
+}
+
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneendpointgroups,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneendpointgroups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneendpointgroups/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneservices,verbs=get;list
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
+
+// Reconcile keystone endpoint group requests
+func (r *KeystoneEndpointGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	Log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneEndpointGroup instance
+	instance := &keystonev1.KeystoneEndpointGroup{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			r.degraded.forget(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		Log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			Log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		updateReadyCondition(&instance.Status.Conditions,
+			instance.Generation, instance.Status.ObservedGeneration)
+		recordReconcile(instance.Spec, _err,
+			instance.Status.Conditions.IsTrue(condition.ReadyCondition),
+			&instance.Status.LastReconcileTime,
+			&instance.Status.LastSuccessfulReconcile,
+			&instance.Status.AppliedSpecHash)
+		r.degraded.handleResult(Log, req.NamespacedName, &instance.Status.Conditions, &result, &_err)
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneEndpointGroupReadyCondition, condition.InitReason, keystonev1.KeystoneEndpointGroupReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneEndpointGroupProjectsReadyCondition, condition.InitReason, keystonev1.KeystoneEndpointGroupProjectsReadyInitMessage),
+		)
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+
+	if instance.Status.ProjectIDs == nil {
+		instance.Status.ProjectIDs = map[string]string{}
+	}
+
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, nil
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPI(ctx, helper, instance.Namespace, map[string]string{})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// If this KeystoneEndpointGroup CR is being deleted and the KeystoneAPI is
+			// gone, its database went away with it and there is nothing to
+			// clean up. Waiting for a KeystoneAPI to appear would block the
+			// deletion, e.g. of the namespace.
+			if !instance.DeletionTimestamp.IsZero() {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			Log.Info("KeystoneAPI not found!")
+
+			return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	// If both the endpoint group and the KeystoneAPI is deleted then we can
+	// skip the cleanup on the OpenStack side as the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() {
+		skip, err := skipKeystoneCleanup(ctx, Log, r.Kclient, instance, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if skip {
+			return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+		}
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && instance.Status.EndpointGroupID == "" {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		Log.Info("KeystoneAPI not yet ready!")
+
+		return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := getAdminClient(ctx, helper, keystoneAPI, r.OpenStackClient)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal endpoint group delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+	}
+
+	// Handle non-deleted clusters
+	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KeystoneEndpointGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneEndpointGroup{}).
+		Complete(r)
+}
+
+func (r *KeystoneEndpointGroupReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneEndpointGroup,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Endpoint Group delete")
+
+	// We might not have an OpenStack backend to use in certain situations.
+	// Deleting the endpoint group also removes its project associations.
+	if os != nil && instance.Status.EndpointGroupID != "" {
+		err := identity.DeleteEndpointGroup(Log, os, instance.Status.EndpointGroupID)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	instance.Status.EndpointGroupID = ""
+	instance.Status.ProjectIDs = map[string]string{}
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this endpoint group from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Endpoint group is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	Log.Info("Reconciled Endpoint Group delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneEndpointGroupReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneEndpointGroup,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Endpoint Group normal")
+
+	// label the CR with the KeystoneAPI it belongs to
+	setKeystoneAPILabel(instance, keystoneAPI)
+
+	//
+	// Add a finalizer to the KeystoneAPI for this endpoint group, as we do not want the
+	// KeystoneAPI to disappear before this endpoint group in the case where it is deleted
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	//
+	// build the filters of the endpoint group
+	//
+	filters := map[string]string{}
+	if instance.Spec.Filters.ServiceName != "" {
+		ksSvc, err := keystonev1.GetKeystoneServiceWithName(ctx, helper, instance.Spec.Filters.ServiceName, instance.Namespace)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneEndpointGroupReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneEndpointGroupReadyErrorMessage,
+				keystonev1.KeystoneErrorMessage(err)))
+			return ctrl.Result{}, err
+		}
+		if err != nil || ksSvc.Status.ServiceID == "" {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneEndpointGroupReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				keystonev1.KeystoneEndpointGroupReadyWaitingMessage,
+				instance.Spec.Filters.ServiceName))
+			Log.Info("KeystoneService not ready, waiting to create endpoint group", "KeystoneService", instance.Spec.Filters.ServiceName)
+
+			return ctrl.Result{RequeueAfter: r.Requeue.keystoneService()}, nil
+		}
+		filters["service_id"] = ksSvc.Status.ServiceID
+	}
+	if instance.Spec.Filters.Interface != "" {
+		filters["interface"] = instance.Spec.Filters.Interface
+	}
+	if instance.Spec.Filters.Region != "" {
+		filters["region_id"] = instance.Spec.Filters.Region
+	}
+
+	//
+	// create/update the endpoint group
+	//
+	endpointGroupID, err := identity.CreateOrUpdateEndpointGroup(
+		Log,
+		os,
+		identity.EndpointGroup{
+			Name:        instance.GetEndpointGroupName(),
+			Description: instance.Spec.Description,
+			Filters:     filters,
+		})
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneEndpointGroupReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneEndpointGroupReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	instance.Status.EndpointGroupID = endpointGroupID
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneEndpointGroupReadyCondition,
+		keystonev1.KeystoneEndpointGroupReadyMessage,
+		instance.GetEndpointGroupName(),
+		instance.Status.EndpointGroupID,
+	)
+
+	//
+	// associate the endpoint group with the projects
+	//
+	err = r.reconcileProjects(ctx, instance, os)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneEndpointGroupProjectsReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneEndpointGroupProjectsReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneEndpointGroupProjectsReadyCondition,
+		keystonev1.KeystoneEndpointGroupProjectsReadyMessage,
+		instance.Spec.Projects,
+	)
+
+	Log.Info("Reconciled Endpoint Group normal successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneEndpointGroupReconciler) reconcileProjects(
+	ctx context.Context,
+	instance *keystonev1.KeystoneEndpointGroup,
+	os openstackclient.OpenStackClient,
+) error {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Endpoint Group projects")
+
+	// remove associations to projects which are no longer in the spec
+	for projectName, projectID := range instance.Status.ProjectIDs {
+		if !slices.Contains(instance.Spec.Projects, projectName) {
+			err := identity.RemoveEndpointGroupFromProject(Log, os, instance.Status.EndpointGroupID, projectID)
+			if err != nil {
+				return err
+			}
+			delete(instance.Status.ProjectIDs, projectName)
+		}
+	}
+
+	if len(instance.Spec.Projects) == 0 {
+		return nil
+	}
+
+	domainID, err := getDomainID(Log, os, instance.Spec.ProjectDomain, false)
+	if err != nil {
+		return err
+	}
+	if domainID == "" {
+		return fmt.Errorf("domain %s not found", instance.Spec.ProjectDomain)
+	}
+
+	for _, projectName := range instance.Spec.Projects {
+		project, err := os.GetProject(Log, projectName, domainID)
+		if err != nil {
+			if strings.Contains(err.Error(), openstack.ProjectNotFound) {
+				return fmt.Errorf("project %s not found in domain %s", projectName, instance.Spec.ProjectDomain)
+			}
+			return err
+		}
+
+		err = identity.AddEndpointGroupToProject(Log, os, instance.Status.EndpointGroupID, project.ID)
+		if err != nil {
+			return err
+		}
+		instance.Status.ProjectIDs[projectName] = project.ID
+	}
+
+	return nil
+}
@@ -0,0 +1,678 @@
+/*
+   Copyright 2022.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/go-logr/logr"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/identity"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
+	"github.com/openstack-k8s-operators/lib-common/modules/common"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/job"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// KeystoneMappingReconciler reconciles a KeystoneMapping object
+type KeystoneMappingReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+	// Requeue - requeue intervals while waiting for the KeystoneAPI
+	Requeue RequeueIntervals
+	// OpenStackClient - returns the keystone admin client, the client of
+	// the KeystoneAPI if not set. Tests set the factory of a fake.
+	OpenStackClient openstackclient.Factory
+
+	degraded degradedTracker
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneMappingReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneMapping")
+}
+
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonemappings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonemappings/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonemappings/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile keystone mapping requests
+func (r *KeystoneMappingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	Log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneMapping instance
+	instance := &keystonev1.KeystoneMapping{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			r.degraded.forget(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		Log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			Log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		updateReadyCondition(&instance.Status.Conditions,
+			instance.Generation, instance.Status.ObservedGeneration)
+		recordReconcile(instance.Spec, _err,
+			instance.Status.Conditions.IsTrue(condition.ReadyCondition),
+			&instance.Status.LastReconcileTime,
+			&instance.Status.LastSuccessfulReconcile,
+			&instance.Status.AppliedSpecHash)
+		r.degraded.handleResult(Log, req.NamespacedName, &instance.Status.Conditions, &result, &_err)
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneMappingReadyCondition, condition.InitReason, keystonev1.KeystoneMappingReadyInitMessage),
+		)
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, nil
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPI(ctx, helper, instance.Namespace, map[string]string{})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// If this KeystoneMapping CR is being deleted and the KeystoneAPI
+			// is gone, its database went away with it and there is nothing
+			// to clean up.
+			if !instance.DeletionTimestamp.IsZero() {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			Log.Info("KeystoneAPI not found!")
+
+			return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	// If both the mapping and the KeystoneAPI is deleted then we can
+	// skip the cleanup on the OpenStack side as the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() {
+		skip, err := skipKeystoneCleanup(ctx, Log, r.Kclient, instance, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if skip {
+			return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+		}
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && !instance.Status.MappingCreated &&
+		instance.Status.IdentityProviderID == "" && !instance.Status.DomainCreated {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		Log.Info("KeystoneAPI not yet ready!")
+
+		return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := getAdminClient(ctx, helper, keystoneAPI, r.OpenStackClient)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal mapping delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+	}
+
+	// Handle non-deleted clusters
+	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KeystoneMappingReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneMapping{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
+
+func (r *KeystoneMappingReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneMapping,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Mapping delete")
+
+	// We might not have an OpenStack backend to use in certain situations.
+	// Adopted mappings and identity providers existed before and are kept.
+	if os != nil {
+		purging, err := r.cleanupIdentityProvider(ctx, helper, os, instance, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if purging {
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		}
+		if instance.Status.MappingCreated {
+			err = identity.DeleteMapping(Log, os, instance.Status.MappingID)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+	instance.Status.MappingCreated = false
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this mapping from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Mapping is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	Log.Info("Reconciled Mapping delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneMappingReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneMapping,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Mapping normal")
+
+	// label the CR with the KeystoneAPI it belongs to
+	setKeystoneAPILabel(instance, keystoneAPI)
+
+	//
+	// Add a finalizer to the KeystoneAPI for this mapping, as we do not want
+	// the KeystoneAPI to disappear before this mapping in the case where it
+	// is deleted
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	waitingFor, err := r.reconcileMapping(ctx, instance, helper, os, keystoneAPI)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneMappingReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneMappingReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if waitingFor != "" {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneMappingReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneMappingReadyWaitingMessage,
+			waitingFor))
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	err = r.reconcileShadowUsers(ctx, helper, instance, os)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneMappingReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneMappingReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneMappingReadyCondition,
+		keystonev1.KeystoneMappingReadyMessage,
+		instance.Status.MappingID,
+	)
+
+	Log.Info("Reconciled Mapping normal successfully")
+
+	if instance.Status.ShadowUsers != nil {
+		// list the shadow users again
+		return ctrl.Result{RequeueAfter: keystone.ShadowUsersCheckInterval}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// reconcileMapping - creates or adopts the mapping and sets its rules, then
+// creates or adopts the identity provider and points its protocol to the
+// mapping. If something the identity provider depends on does not exist, a
+// non empty description of what is missing gets returned.
+func (r *KeystoneMappingReconciler) reconcileMapping(
+	ctx context.Context,
+	instance *keystonev1.KeystoneMapping,
+	h *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (string, error) {
+	Log := r.GetLogger(ctx)
+
+	rules, err := instance.Spec.GetRules()
+	if err != nil {
+		return "", err
+	}
+	rulesHash, err := util.ObjectHash(string(rules))
+	if err != nil {
+		return "", err
+	}
+
+	//
+	// create the mapping if it does not exist, adopt it otherwise
+	//
+	mappingID := instance.GetMappingID()
+	previousMappingID := instance.Status.MappingID
+	previousMappingCreated := instance.Status.MappingCreated
+	mapping, err := identity.GetMapping(Log, os, mappingID)
+	if err != nil {
+		return "", err
+	}
+	if mapping == nil {
+		err = identity.CreateMapping(Log, os, mappingID, rules)
+		if err != nil {
+			return "", err
+		}
+		instance.Status.MappingCreated = true
+		instance.Status.RulesHash = rulesHash
+	} else if previousMappingID != mappingID {
+		// the mapping existed before, e.g. created out of band
+		instance.Status.MappingCreated = false
+		instance.Status.RulesHash = ""
+	}
+	instance.Status.MappingID = mappingID
+
+	if instance.Status.RulesHash != rulesHash {
+		err = identity.UpdateMapping(Log, os, mappingID, rules)
+		if err != nil {
+			return "", err
+		}
+		instance.Status.RulesHash = rulesHash
+	}
+
+	waitingFor, err := r.reconcileIdentityProvider(ctx, instance, h, os, keystoneAPI)
+	if err != nil || waitingFor != "" {
+		return waitingFor, err
+	}
+
+	// the protocol uses the new mapping, the old one can go
+	if previousMappingCreated && previousMappingID != "" && previousMappingID != mappingID {
+		err = identity.DeleteMapping(Log, os, previousMappingID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+// reconcileIdentityProvider - creates or adopts the identity provider of the
+// mapping and creates or updates its protocol to use the mapping. Cleans up
+// the identity provider if it got removed from the spec.
+func (r *KeystoneMappingReconciler) reconcileIdentityProvider(
+	ctx context.Context,
+	instance *keystonev1.KeystoneMapping,
+	h *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (string, error) {
+	Log := r.GetLogger(ctx)
+
+	spec := instance.Spec.IdentityProvider
+	if spec == nil || (instance.Status.IdentityProviderID != "" && instance.Status.IdentityProviderID != spec.Name) ||
+		(instance.Status.IdentityProviderID == "" && instance.Status.DomainCreated) {
+		purging, err := r.cleanupIdentityProvider(ctx, h, os, instance, keystoneAPI)
+		if err != nil {
+			return "", err
+		}
+		if purging {
+			return "mapping purge job", nil
+		}
+		if spec == nil {
+			return "", nil
+		}
+	}
+
+	domainID := ""
+	if spec.Domain != "" {
+		var err error
+		domainID, err = getDomainID(Log, os, spec.Domain, false)
+		if err != nil {
+			return "", err
+		}
+		if domainID == "" {
+			return fmt.Sprintf("domain %s", spec.Domain), nil
+		}
+	}
+
+	//
+	// create the identity provider if it does not exist, adopt it otherwise
+	//
+	desired := identity.IdentityProvider{
+		ID:          spec.Name,
+		Description: spec.Description,
+		DomainID:    domainID,
+		Enabled:     true,
+		RemoteIDs:   spec.RemoteIDs,
+	}
+	idp, err := identity.GetIdentityProvider(Log, os, spec.Name)
+	if err != nil {
+		return "", err
+	}
+	if idp == nil {
+		idp, err = identity.CreateIdentityProvider(Log, os, desired)
+		if err != nil {
+			return "", err
+		}
+		instance.Status.IdentityProviderCreated = true
+		// keystone created a domain for the identity provider
+		instance.Status.DomainCreated = domainID == ""
+	} else {
+		if instance.Status.IdentityProviderID != idp.ID {
+			// the identity provider existed before, e.g. created out of band
+			instance.Status.IdentityProviderCreated = false
+			instance.Status.DomainCreated = false
+			instance.Status.Protocol = ""
+		}
+		if domainID != "" && idp.DomainID != domainID {
+			return "", fmt.Errorf("identity provider %s uses domain %s, not domain %s", idp.ID, idp.DomainID, spec.Domain)
+		}
+		if idp.Description != desired.Description || !idp.Enabled || !equalRemoteIDs(idp.RemoteIDs, desired.RemoteIDs) {
+			err = identity.UpdateIdentityProvider(Log, os, desired)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	if spec.Domain != "" {
+		instance.Status.DomainName = spec.Domain
+	} else if instance.Status.DomainID != idp.DomainID || instance.Status.DomainName == "" {
+		domain, err := identity.GetDomainByID(Log, os, idp.DomainID)
+		if err != nil {
+			return "", err
+		}
+		if domain != nil {
+			instance.Status.DomainName = domain.Name
+		}
+	}
+	instance.Status.IdentityProviderID = idp.ID
+	instance.Status.DomainID = idp.DomainID
+
+	//
+	// point the protocol of the identity provider to the mapping
+	//
+	protocol := spec.Protocol
+	if protocol == "" {
+		protocol = keystonev1.MappingDefaultProtocol
+	}
+	if instance.Status.Protocol != "" && instance.Status.Protocol != protocol {
+		err = identity.DeleteFederationProtocol(Log, os, idp.ID, instance.Status.Protocol)
+		if err != nil {
+			return "", err
+		}
+	}
+	err = identity.CreateOrUpdateFederationProtocol(Log, os, idp.ID, protocol, instance.Status.MappingID)
+	if err != nil {
+		return "", err
+	}
+	instance.Status.Protocol = protocol
+
+	return "", nil
+}
+
+// cleanupIdentityProvider - deletes the identity provider in the status if
+// the operator created it, with its protocol and federated users. If keystone
+// created the domain of the identity provider, the ID mappings of its users
+// get purged by a job and the domain gets deleted with its auto provisioned
+// projects. Only the protocol gets deleted of adopted identity providers, if
+// it still uses the mapping. Returns true while the mapping purge job runs.
+func (r *KeystoneMappingReconciler) cleanupIdentityProvider(
+	ctx context.Context,
+	h *helper.Helper,
+	os openstackclient.OpenStackClient,
+	instance *keystonev1.KeystoneMapping,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (bool, error) {
+	Log := r.GetLogger(ctx)
+
+	idpID := instance.Status.IdentityProviderID
+	if idpID != "" {
+		if instance.Status.Protocol != "" {
+			protocol, err := identity.GetFederationProtocol(Log, os, idpID, instance.Status.Protocol)
+			if err != nil {
+				return false, err
+			}
+			if protocol != nil && (instance.Status.IdentityProviderCreated || protocol.MappingID == instance.Status.MappingID) {
+				err = identity.DeleteFederationProtocol(Log, os, idpID, instance.Status.Protocol)
+				if err != nil {
+					return false, err
+				}
+			}
+		}
+
+		if instance.Status.IdentityProviderCreated {
+			// delete the shadow users explicitly, not every keystone
+			// release deletes them with the identity provider
+			users, err := identity.ListFederatedUsers(Log, os, idpID)
+			if err != nil {
+				return false, err
+			}
+			for _, user := range users {
+				err = identity.DeleteUserByID(Log, os, user.ID)
+				if err != nil {
+					return false, err
+				}
+			}
+			err = identity.DeleteIdentityProvider(Log, os, idpID)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		instance.Status.IdentityProviderID = ""
+		instance.Status.IdentityProviderCreated = false
+		instance.Status.Protocol = ""
+	}
+
+	if instance.Status.DomainCreated {
+		purging, err := r.purgeDomainMappings(ctx, h, instance, keystoneAPI)
+		if err != nil || purging {
+			return purging, err
+		}
+		err = identity.DeleteDomain(Log, os, instance.Status.DomainID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	instance.Status.DomainID = ""
+	instance.Status.DomainName = ""
+	instance.Status.DomainCreated = false
+	instance.Status.MappingPurgeHash = ""
+
+	return false, nil
+}
+
+// purgeDomainMappings - runs keystone-manage mapping_purge for the domain of
+// the identity provider, keystone keeps the ID mappings of the federated users
+// otherwise. The jobs have no access to the SQLite database of dev mode,
+// keystone goes away with the pod there. Returns true while the job runs.
+func (r *KeystoneMappingReconciler) purgeDomainMappings(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneMapping,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (bool, error) {
+	if keystoneAPI.Spec.DevMode || instance.Status.DomainName == "" {
+		return false, nil
+	}
+
+	jobDef := keystone.MappingPurgeJob(keystoneAPI, instance.Name, instance.Status.DomainName, map[string]string{
+		common.AppSelector:   keystone.ServiceName,
+		common.OwnerSelector: instance.Name,
+	})
+	purgeJob := job.NewJob(
+		jobDef,
+		keystone.MappingPurgeHash,
+		keystoneAPI.Spec.PreserveJobs,
+		10*time.Second,
+		instance.Status.MappingPurgeHash,
+	)
+	ctrlResult, err := purgeJob.DoJob(ctx, h)
+	if err != nil {
+		return false, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		return true, nil
+	}
+	if purgeJob.HasChanged() {
+		instance.Status.MappingPurgeHash = purgeJob.GetHash()
+	}
+
+	return false, nil
+}
+
+// equalRemoteIDs - returns true if both lists hold the same remote IDs,
+// keystone does not keep their order
+func equalRemoteIDs(a []string, b []string) bool {
+	a = slices.Clone(a)
+	b = slices.Clone(b)
+	sort.Strings(a)
+	sort.Strings(b)
+
+	return slices.Equal(a, b)
+}
@@ -19,11 +19,16 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/services"
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/identity"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
 	secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
@@ -59,6 +64,18 @@ type KeystoneServiceReconciler struct {
 	client.Client
 	Kclient kubernetes.Interface
 	Scheme  *runtime.Scheme
+	// Requeue - requeue intervals while waiting for the KeystoneAPI and
+	// KeystoneServices
+	Requeue RequeueIntervals
+	// Limits - safety limits of the keystone objects the controller creates
+	Limits SafetyLimits
+	// OpenStackClient - returns the keystone admin client, the client of
+	// the KeystoneAPI if not set. Tests set the factory of a fake.
+	OpenStackClient openstackclient.Factory
+
+	degraded degradedTracker
+	// userCreations - users created within the last minute
+	userCreations rateWindow
 }
 
 // GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
@@ -71,6 +88,7 @@ func (r *KeystoneServiceReconciler) GetLogger(ctx context.Context) logr.Logger {
 // +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneservices/finalizers,verbs=update;patch
 // +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
 // +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
 
 // Reconcile keystone service requests
 func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
@@ -85,6 +103,7 @@ func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			r.degraded.forget(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -111,17 +130,14 @@ func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			panic(r)
 		}
 		// update the Ready condition based on the sub conditions
-		if instance.Status.Conditions.AllSubConditionIsTrue() {
-			instance.Status.Conditions.MarkTrue(
-				condition.ReadyCondition, condition.ReadyMessage)
-		} else {
-			// something is not ready so reset the Ready condition
-			instance.Status.Conditions.MarkUnknown(
-				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
-			// and recalculate it based on the state of the rest of the conditions
-			instance.Status.Conditions.Set(
-				instance.Status.Conditions.Mirror(condition.ReadyCondition))
-		}
+		updateReadyCondition(&instance.Status.Conditions,
+			instance.Generation, instance.Status.ObservedGeneration)
+		recordReconcile(instance.Spec, _err,
+			instance.Status.Conditions.IsTrue(condition.ReadyCondition),
+			&instance.Status.LastReconcileTime,
+			&instance.Status.LastSuccessfulReconcile,
+			&instance.Status.AppliedSpecHash)
+		r.degraded.handleResult(log, req.NamespacedName, &instance.Status.Conditions, &result, &_err)
 		err := helper.PatchInstance(ctx, instance)
 		if err != nil {
 			_err = err
@@ -145,6 +161,8 @@ func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, nil
 	}
 
+	instance.Status.ObservedGeneration = instance.Generation
+
 	// If we're not deleting this and the service object doesn't have our finalizer, add it.
 	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
 		return ctrl.Result{}, err
@@ -156,11 +174,11 @@ func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	keystoneAPI, err := keystonev1.GetKeystoneAPI(ctx, helper, instance.Namespace, map[string]string{})
 	if err != nil {
 		if k8s_errors.IsNotFound(err) {
-			// If this KeystoneService CR is being deleted and it has not registered any actual
-			// service on the OpenStack side, just redirect execution to the "reconcileDelete()"
-			// logic to avoid potentially hanging on waiting for a KeystoneAPI to appear (which
-			// is not needed anyhow, since there is nothing to clean-up on the OpenStack side)
-			if !instance.DeletionTimestamp.IsZero() && instance.Status.ServiceID == "" {
+			// If this KeystoneService CR is being deleted and the KeystoneAPI is
+			// gone, its database went away with it and there is nothing to
+			// clean up. Waiting for a KeystoneAPI to appear would block the
+			// deletion, e.g. of the namespace.
+			if !instance.DeletionTimestamp.IsZero() {
 				return r.reconcileDelete(ctx, instance, helper, nil, nil)
 			}
 
@@ -171,14 +189,14 @@ func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 				keystonev1.KeystoneAPIReadyNotFoundMessage,
 			))
 			log.Info("KeystoneAPI not found!")
-			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+			return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
 		}
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			keystonev1.KeystoneAPIReadyCondition,
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			keystonev1.KeystoneAPIReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrl.Result{}, err
 	}
 
@@ -187,8 +205,14 @@ func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	// Moreover if KeystoneAPI is being deleted then we cannot talk to the
 	// keystone REST API any more. This happens for example during namespace
 	// deletion.
-	if !instance.DeletionTimestamp.IsZero() && !keystoneAPI.DeletionTimestamp.IsZero() {
-		return r.reconcileDeleteFinalizersOnly(ctx, instance, helper, keystoneAPI)
+	if !instance.DeletionTimestamp.IsZero() {
+		skip, err := skipKeystoneCleanup(ctx, log, r.Kclient, instance, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if skip {
+			return r.reconcileDeleteFinalizersOnly(ctx, instance, helper, keystoneAPI)
+		}
 	}
 
 	// If this KeystoneService CR is being deleted and it has not registered any actual
@@ -207,25 +231,21 @@ func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			condition.SeverityInfo,
 			keystonev1.KeystoneAPIReadyWaitingMessage))
 		log.Info("KeystoneAPI not yet ready")
-		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
 	}
 	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
 
 	//
 	// get admin authentication OpenStack
 	//
-	os, ctrlResult, err := keystonev1.GetAdminServiceClient(
-		ctx,
-		helper,
-		keystoneAPI,
-	)
+	os, ctrlResult, err := getAdminClient(ctx, helper, keystoneAPI, r.OpenStackClient)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			keystonev1.AdminServiceClientReadyCondition,
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			keystonev1.AdminServiceClientReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrl.Result{}, err
 	}
 	if (ctrlResult != ctrl.Result{}) {
@@ -259,38 +279,71 @@ func (r *KeystoneServiceReconciler) reconcileDelete(
 	ctx context.Context,
 	instance *keystonev1.KeystoneService,
 	helper *helper.Helper,
-	os *openstack.OpenStack,
+	os openstackclient.OpenStackClient,
 	keystoneAPI *keystonev1.KeystoneAPI,
 ) (ctrl.Result, error) {
 	log := r.GetLogger(ctx)
 	log.Info("Reconciling Service delete")
+	events := newCatalogEvents(log, keystoneAPI, instance, "keystoneservices")
 
 	// only cleanup the service if there is the ServiceID reference in the
 	// object status and if we have an OpenStack backend to use
 	if instance.Status.ServiceID != "" && os != nil {
-		// Delete User
-		err := os.DeleteUser(
-			log,
-			instance.Spec.ServiceUser,
-			"default")
-		if err != nil {
-			return ctrl.Result{}, err
+		// Delete User, by the recorded ID as the spec might have changed
+		// since it got created. Services created before the ID got recorded
+		// look up the user by its name.
+		if instance.Status.UserID != "" {
+			err := identity.DeleteUserByID(log, os, instance.Status.UserID)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			events.emit(ctx, cloudEventUserDeleted, instance.Spec.ServiceUser, map[string]string{
+				"userName": instance.Spec.ServiceUser,
+				"userID":   instance.Status.UserID,
+				"domainID": instance.Status.DomainID,
+			})
+			instance.Status.UserID = ""
+		} else {
+			domainID, err := getDomainID(log, os, instance.Spec.ServiceDomain, false)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if domainID != "" {
+				err = os.DeleteUser(
+					log,
+					instance.Spec.ServiceUser,
+					domainID)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				events.emit(ctx, cloudEventUserDeleted, instance.Spec.ServiceUser, map[string]string{
+					"userName": instance.Spec.ServiceUser,
+					"domainID": domainID,
+				})
+			}
 		}
 
 		// Delete Service
-		err = os.DeleteService(
+		err := newCatalogBackend(os).DeleteService(
 			log,
 			instance.Status.ServiceID)
 		if err != nil {
-			log.Info(err.Error())
+			log.Info(keystonev1.RedactSecrets(err.Error()))
 			return ctrl.Result{}, err
 		}
+		events.emit(ctx, cloudEventServiceDeleted, instance.Spec.ServiceName, map[string]string{
+			"serviceName": instance.Spec.ServiceName,
+			"serviceType": instance.Spec.ServiceType,
+			"serviceID":   instance.Status.ServiceID,
+		})
 
 		// Clear the service ID so that any potential requeues after this reconcile
 		// will know that there is no need to worry about cleaning up the OpenStack
 		// side of things anymore (deferred PatchInstance call will persist this to
 		// etcd)
 		instance.Status.ServiceID = ""
+		instance.Status.DomainID = ""
+		instance.Status.ProjectID = ""
 	} else {
 		log.Info("Not deleting service as there is no stores service ID", "KeystoneService", instance.Spec.ServiceName)
 	}
@@ -341,11 +394,15 @@ func (r *KeystoneServiceReconciler) reconcileNormal(
 	ctx context.Context,
 	instance *keystonev1.KeystoneService,
 	helper *helper.Helper,
-	os *openstack.OpenStack,
+	os openstackclient.OpenStackClient,
 	keystoneAPI *keystonev1.KeystoneAPI,
 ) (ctrl.Result, error) {
 	log := r.GetLogger(ctx)
 	log.Info("Reconciling Service")
+	events := newCatalogEvents(log, keystoneAPI, instance, "keystoneservices")
+
+	// label the CR with the KeystoneAPI it belongs to
+	setKeystoneAPILabel(instance, keystoneAPI)
 
 	//
 	// Add a finalizer to the KeystoneAPI for this service instance, as we do not want the
@@ -364,14 +421,14 @@ func (r *KeystoneServiceReconciler) reconcileNormal(
 	//
 	// Create new service if ServiceID is not already set
 	//
-	err := r.reconcileService(ctx, instance, os)
+	err := r.reconcileService(ctx, instance, newCatalogBackend(os), events)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			keystonev1.KeystoneServiceOSServiceReadyCondition,
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			keystonev1.KeystoneServiceOSServiceReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrl.Result{}, err
 	}
 	instance.Status.Conditions.MarkTrue(
@@ -388,14 +445,15 @@ func (r *KeystoneServiceReconciler) reconcileNormal(
 		ctx,
 		helper,
 		instance,
-		os)
+		os,
+		events)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			keystonev1.KeystoneServiceOSUserReadyCondition,
 			condition.ErrorReason,
 			condition.SeverityWarning,
 			keystonev1.KeystoneServiceOSUserReadyErrorMessage,
-			err.Error()))
+			keystonev1.KeystoneErrorMessage(err)))
 		return ctrlResult, err
 	} else if (ctrlResult != ctrl.Result{}) {
 		instance.Status.Conditions.Set(condition.FalseCondition(
@@ -418,11 +476,17 @@ func (r *KeystoneServiceReconciler) reconcileNormal(
 func (r *KeystoneServiceReconciler) reconcileService(
 	ctx context.Context,
 	instance *keystonev1.KeystoneService,
-	os *openstack.OpenStack,
+	os catalogBackend,
+	events *catalogEvents,
 ) error {
 	log := r.GetLogger(ctx)
 	log.Info("Reconciling Service ", "KeystoneService", instance.Spec.ServiceName)
 
+	owner, err := newOwnership(ctx, r.Kclient, instance)
+	if err != nil {
+		return err
+	}
+
 	// verify if there is already a service in keystone for the type and name
 	service, err := os.GetService(
 		log,
@@ -448,7 +512,23 @@ func (r *KeystoneServiceReconciler) reconcileService(
 		if err != nil {
 			return err
 		}
+		events.emit(ctx, cloudEventServiceCreated, instance.Spec.ServiceName, map[string]string{
+			"serviceName": instance.Spec.ServiceName,
+			"serviceType": instance.Spec.ServiceType,
+			"serviceID":   instance.Status.ServiceID,
+		})
+		err = owner.tagService(log, os, services.Service{ID: instance.Status.ServiceID})
+		if err != nil {
+			return err
+		}
 	} else {
+		// a service of another cluster must not get recorded, deleting the
+		// KeystoneService would delete it
+		err = owner.checkService(*service)
+		if err != nil {
+			return err
+		}
+
 		// During adoption there are services in the keystone DB but the
 		// KeystoneService CR is fresh so we have to propagate the service ID
 		// from the DB to the KeystoneService CR.
@@ -469,6 +549,18 @@ func (r *KeystoneServiceReconciler) reconcileService(
 			if err != nil {
 				return err
 			}
+			events.emit(ctx, cloudEventServiceUpdated, instance.Spec.ServiceName, map[string]string{
+				"serviceName": instance.Spec.ServiceName,
+				"serviceType": instance.Spec.ServiceType,
+				"serviceID":   service.ID,
+				"enabled":     strconv.FormatBool(instance.Spec.Enabled),
+				"description": instance.Spec.ServiceDescription,
+			})
+		}
+
+		err = owner.tagService(log, os, *service)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -480,7 +572,8 @@ func (r *KeystoneServiceReconciler) reconcileUser(
 	ctx context.Context,
 	h *helper.Helper,
 	instance *keystonev1.KeystoneService,
-	os *openstack.OpenStack,
+	os openstackclient.OpenStackClient,
+	events *catalogEvents,
 ) (reconcile.Result, error) {
 	log := r.GetLogger(ctx)
 	log.Info("Reconciling User", "User", instance.Spec.ServiceUser)
@@ -500,20 +593,64 @@ func (r *KeystoneServiceReconciler) reconcileUser(
 		return ctrlResult, nil
 	}
 
+	//
+	// create service domain if it does not exist
+	//
+	domainID, err := getDomainID(log, os, instance.Spec.ServiceDomain, true)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	//
 	// create service project if it does not exist
 	//
+	serviceProjectName := instance.Spec.ServiceProject
+	if serviceProjectName == "" {
+		serviceProjectName = "service"
+	}
+	if r.Limits.maxProjectsPerDomain(instance.Spec.ServiceDomain) > 0 {
+		_, err = os.GetProject(log, serviceProjectName, domainID)
+		if err != nil {
+			if !strings.Contains(err.Error(), openstack.ProjectNotFound) {
+				return ctrl.Result{}, err
+			}
+			err = r.Limits.checkDomainProjectLimit(log, os, domainID, instance.Spec.ServiceDomain)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
 	serviceProjectID, err := os.CreateProject(
 		log,
 		openstack.Project{
-			Name:        "service",
-			Description: "service",
-			DomainID:    "default",
+			Name:        serviceProjectName,
+			Description: serviceProjectName,
+			DomainID:    domainID,
 		})
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// only look up the user if the creation gets reported or limited
+	userExists := true
+	if events != nil || r.Limits.maxUsersPerMinute() > 0 || r.Limits.maxUsersPerDomain(instance.Spec.ServiceDomain) > 0 {
+		_, err = os.GetUser(log, instance.Spec.ServiceUser, domainID)
+		if err != nil && !strings.Contains(err.Error(), openstack.UserNotFound) {
+			return ctrl.Result{}, err
+		}
+		userExists = err == nil
+	}
+	if !userExists {
+		err = r.Limits.checkDomainUserLimit(log, os, domainID, instance.Spec.ServiceDomain)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		err = r.userCreations.allow(r.Limits.maxUsersPerMinute(), "users")
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	//
 	// create user if it does not exist
 	//
@@ -523,11 +660,50 @@ func (r *KeystoneServiceReconciler) reconcileUser(
 			Name:      instance.Spec.ServiceUser,
 			Password:  password,
 			ProjectID: serviceProjectID,
-			DomainID:  "default",
+			DomainID:  domainID,
 		})
 	if err != nil {
 		return ctrl.Result{}, err
 	}
+	if !userExists {
+		events.emit(ctx, cloudEventUserCreated, instance.Spec.ServiceUser, map[string]string{
+			"userName": instance.Spec.ServiceUser,
+			"userID":   userID,
+			"domainID": domainID,
+		})
+	}
+
+	//
+	// cleanup the user or the role assignments of a former ServiceUser,
+	// ServiceDomain or ServiceProject
+	//
+	if instance.Status.UserID != "" && instance.Status.UserID != userID {
+		err = identity.DeleteUserByID(log, os, instance.Status.UserID)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		events.emit(ctx, cloudEventUserDeleted, instance.Spec.ServiceUser, map[string]string{
+			"userID":   instance.Status.UserID,
+			"domainID": instance.Status.DomainID,
+		})
+	} else if instance.Status.ProjectID != "" && instance.Status.ProjectID != serviceProjectID {
+		for _, roleName := range roleNames {
+			role, err := os.GetRole(log, roleName)
+			if err != nil {
+				if strings.Contains(err.Error(), openstack.RoleNotFound) {
+					continue
+				}
+				return ctrl.Result{}, err
+			}
+			err = identity.DeleteProjectUserRole(log, os, userID, instance.Status.ProjectID, role.ID)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+	instance.Status.DomainID = domainID
+	instance.Status.ProjectID = serviceProjectID
+	instance.Status.UserID = userID
 
 	for _, roleName := range roleNames {
 		//
@@ -553,6 +729,74 @@ func (r *KeystoneServiceReconciler) reconcileUser(
 		}
 	}
 
+	//
+	// assign the system scope roles to the user, unassign the removed ones
+	//
+	for _, roleName := range instance.Status.SystemRoles {
+		if slices.Contains(instance.Spec.SystemRoles, roleName) {
+			continue
+		}
+		role, err := os.GetRole(log, roleName)
+		if err != nil {
+			if strings.Contains(err.Error(), openstack.RoleNotFound) {
+				continue
+			}
+			return ctrl.Result{}, err
+		}
+		err = identity.DeleteSystemUserRole(log, os, userID, role.ID)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	for _, roleName := range instance.Spec.SystemRoles {
+		roleID, err := os.CreateRole(
+			log,
+			roleName)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		err = identity.EnsureSystemUserRole(log, os, userID, roleID)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	instance.Status.SystemRoles = slices.Clone(instance.Spec.SystemRoles)
+
 	log.Info("Reconciled User successfully")
 	return ctrl.Result{}, nil
 }
+
+// getDomainID - returns the ID of the domain with the name. The default
+// domain is resolved without an API call. With create set the domain gets
+// created if it does not exist, otherwise an empty ID is returned for a
+// missing domain.
+func getDomainID(
+	log logr.Logger,
+	os openstackclient.OpenStackClient,
+	domainName string,
+	create bool,
+) (string, error) {
+	if domainName == "" || strings.EqualFold(domainName, "default") {
+		return "default", nil
+	}
+
+	if create {
+		return os.CreateDomain(
+			log,
+			openstack.Domain{
+				Name:        domainName,
+				Description: domainName,
+			})
+	}
+
+	domain, err := os.GetDomain(domainName)
+	if err != nil {
+		return "", err
+	}
+	if domain == nil {
+		log.Info(fmt.Sprintf("Domain %s not found", domainName))
+		return "", nil
+	}
+
+	return domain.ID, nil
+}
@@ -0,0 +1,614 @@
+/*
+   Copyright 2022.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/users"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/identity"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+)
+
+// userDriftCheckInterval - interval the enabled state of the users in
+// keystone gets checked in, to revert changes made out of band
+const userDriftCheckInterval = 5 * time.Minute
+
+// KeystoneUserReconciler reconciles a KeystoneUser object
+type KeystoneUserReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+	// Requeue - requeue intervals while waiting for the KeystoneAPI and
+	// KeystoneServices
+	Requeue RequeueIntervals
+	// OpenStackClient - returns the keystone admin client, the client of
+	// the KeystoneAPI if not set. Tests set the factory of a fake.
+	OpenStackClient openstackclient.Factory
+	// Limits - safety limits of the keystone objects the controller creates
+	Limits SafetyLimits
+
+	degraded degradedTracker
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneUserReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneUser")
+}
+
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneusers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneusers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneusers/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
+
+// Reconcile keystone user requests
+func (r *KeystoneUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	Log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneUser instance
+	instance := &keystonev1.KeystoneUser{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			r.degraded.forget(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		Log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			Log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		updateReadyCondition(&instance.Status.Conditions,
+			instance.Generation, instance.Status.ObservedGeneration)
+		recordReconcile(instance.Spec, _err,
+			instance.Status.Conditions.IsTrue(condition.ReadyCondition),
+			&instance.Status.LastReconcileTime,
+			&instance.Status.LastSuccessfulReconcile,
+			&instance.Status.AppliedSpecHash)
+		r.degraded.handleResult(Log, req.NamespacedName, &instance.Status.Conditions, &result, &_err)
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneUserReadyCondition, condition.InitReason, keystonev1.KeystoneUserReadyInitMessage),
+		)
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, nil
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPI(ctx, helper, instance.Namespace, map[string]string{})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// If this KeystoneUser CR is being deleted and the KeystoneAPI is
+			// gone, its database went away with it and there is nothing to
+			// clean up. Waiting for a KeystoneAPI to appear would block the
+			// deletion, e.g. of the namespace.
+			if !instance.DeletionTimestamp.IsZero() {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			Log.Info("KeystoneAPI not found!")
+
+			return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	// If both the user and the KeystoneAPI is deleted then we can
+	// skip the cleanup on the OpenStack side as the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() {
+		skip, err := skipKeystoneCleanup(ctx, Log, r.Kclient, instance, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if skip {
+			return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+		}
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && !instance.Status.Created {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		Log.Info("KeystoneAPI not yet ready!")
+
+		return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := getAdminClient(ctx, helper, keystoneAPI, r.OpenStackClient)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal user delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+	}
+
+	// Handle non-deleted clusters
+	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KeystoneUserReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneUser{}).
+		Complete(r)
+}
+
+func (r *KeystoneUserReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneUser,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling User delete")
+
+	// We might not have an OpenStack backend to use in certain situations.
+	// Adopted users existed before and are kept. The user gets deleted by
+	// its ID, another user might have the name of the spec by now.
+	if os != nil && instance.Status.Created && instance.Status.UserID != "" {
+		err := identity.DeleteUserByID(Log, os, instance.Status.UserID)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		newCatalogEvents(Log, keystoneAPI, instance, "keystoneusers").emit(ctx, cloudEventUserDeleted, instance.Spec.UserName, map[string]string{
+			"userName": instance.Spec.UserName,
+			"userID":   instance.Status.UserID,
+			"domainID": instance.Status.DomainID,
+		})
+	}
+	instance.Status.Created = false
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this user from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// User is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	Log.Info("Reconciled User delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneUserReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneUser,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling User normal")
+	events := newCatalogEvents(Log, keystoneAPI, instance, "keystoneusers")
+
+	// label the CR with the KeystoneAPI it belongs to
+	setKeystoneAPILabel(instance, keystoneAPI)
+
+	//
+	// Add a finalizer to the KeystoneAPI for this user, as we do not want the
+	// KeystoneAPI to disappear before this user in the case where it is deleted
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	waitingFor, err := r.reconcileUser(ctx, instance, helper, os, events)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneUserReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneUserReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if waitingFor != "" {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneUserReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneUserReadyWaitingMessage,
+			waitingFor))
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	state := "disabled"
+	if instance.Spec.Enabled {
+		state = "enabled"
+	}
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneUserReadyCondition,
+		keystonev1.KeystoneUserReadyMessage,
+		instance.Status.UserID,
+		state,
+	)
+
+	Log.Info("Reconciled User normal successfully")
+
+	// check the enabled state in keystone again to revert changes made out
+	// of band
+	return ctrl.Result{RequeueAfter: userDriftCheckInterval}, nil
+}
+
+// reconcileUser - creates or adopts the user, sets its password and enabled
+// state. If something the user depends on does not exist, a non empty
+// description of what is missing gets returned.
+func (r *KeystoneUserReconciler) reconcileUser(
+	ctx context.Context,
+	instance *keystonev1.KeystoneUser,
+	h *helper.Helper,
+	os openstackclient.OpenStackClient,
+	events *catalogEvents,
+) (string, error) {
+	Log := r.GetLogger(ctx)
+
+	// get the password of the user from the secret
+	password := ""
+	passwordSecretHash := ""
+	if instance.Spec.Secret != "" {
+		scrt, _, err := oko_secret.GetSecret(ctx, h, instance.Spec.Secret, instance.Namespace)
+		if err != nil {
+			if k8s_errors.IsNotFound(err) {
+				return fmt.Sprintf("secret %s", instance.Spec.Secret), nil
+			}
+			return "", err
+		}
+		value, ok := scrt.Data[instance.Spec.PasswordSelector]
+		if !ok {
+			return "", fmt.Errorf("%s not found in secret %s", instance.Spec.PasswordSelector, instance.Spec.Secret)
+		}
+		password = strings.TrimSuffix(string(value), "\n")
+		// a hash of the password itself could be brute forced by anybody
+		// able to read the status
+		passwordSecretHash, err = util.ObjectHash(map[string]string{
+			"secret":          scrt.Name,
+			"resourceVersion": scrt.ResourceVersion,
+			"selector":        instance.Spec.PasswordSelector,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	domainID, err := getDomainID(Log, os, instance.Spec.Domain, false)
+	if err != nil {
+		return "", err
+	}
+	if domainID == "" {
+		return fmt.Sprintf("domain %s", instance.Spec.Domain), nil
+	}
+
+	//
+	// delete the user created for a previous name or domain of the spec
+	//
+	if instance.Status.Created && instance.Status.UserID != "" {
+		previous, err := identity.GetUserByID(Log, os, instance.Status.UserID)
+		if err != nil {
+			return "", err
+		}
+		if previous != nil && (previous.Name != instance.Spec.UserName || previous.DomainID != domainID) {
+			err = identity.DeleteUserByID(Log, os, previous.ID)
+			if err != nil {
+				return "", err
+			}
+			events.emit(ctx, cloudEventUserDeleted, previous.Name, map[string]string{
+				"userName": previous.Name,
+				"userID":   previous.ID,
+				"domainID": previous.DomainID,
+			})
+			instance.Status.Created = false
+			instance.Status.UserID = ""
+			instance.Status.Enabled = nil
+			instance.Status.PasswordSecretHash = ""
+		}
+	}
+
+	//
+	// create the user if it does not exist, adopt it otherwise
+	//
+	var userID string
+	var enabled bool
+	var currentOptions map[string]interface{}
+	user, err := os.GetUser(Log, instance.Spec.UserName, domainID)
+	if err != nil {
+		if !strings.Contains(err.Error(), openstack.UserNotFound) {
+			return "", err
+		}
+		err = r.Limits.checkDomainUserLimit(Log, os, domainID, instance.Spec.Domain)
+		if err != nil {
+			return "", err
+		}
+		// the marker tells the user apart from adopted ones, also if the
+		// status of the instance got lost
+		owner, err := newOwnership(ctx, r.Kclient, instance)
+		if err != nil {
+			return "", err
+		}
+		userID, err = identity.CreateUserWithExtra(Log, os, users.CreateOpts{
+			Name:     instance.Spec.UserName,
+			Password: password,
+			DomainID: domainID,
+			Extra:    owner.attributes(),
+			Options:  userOptions(instance.Spec.Options),
+		})
+		if err != nil {
+			return "", err
+		}
+		events.emit(ctx, cloudEventUserCreated, instance.Spec.UserName, map[string]string{
+			"userName": instance.Spec.UserName,
+			"userID":   userID,
+			"domainID": domainID,
+		})
+		instance.Status.Created = true
+		instance.Status.PasswordSecretHash = passwordSecretHash
+		// users get created enabled
+		enabled = true
+	} else {
+		userID = user.ID
+		enabled = user.Enabled
+		currentOptions = user.Options
+		if userID != instance.Status.UserID {
+			// the user existed before, e.g. created out of band, unless it
+			// carries the marker of this instance
+			instance.Status.Created = userOwner(*user).OwnerUID == string(instance.UID)
+			instance.Status.Enabled = nil
+			instance.Status.PasswordSecretHash = ""
+		}
+	}
+	instance.Status.UserID = userID
+	instance.Status.DomainID = domainID
+
+	//
+	// set the password if it changed, an existing user keeps its password
+	// otherwise
+	//
+	if password == "" {
+		instance.Status.PasswordSecretHash = ""
+	} else if instance.Status.PasswordSecretHash != passwordSecretHash {
+		err = identity.UpdateUserPassword(Log, os, userID, password)
+		if err != nil {
+			return "", err
+		}
+		instance.Status.PasswordSecretHash = passwordSecretHash
+	}
+
+	//
+	// enable or disable the user
+	//
+	if enabled != instance.Spec.Enabled {
+		// the state got applied before, so it got changed in keystone
+		drift := instance.Status.Enabled != nil && *instance.Status.Enabled == instance.Spec.Enabled
+		err = identity.SetUserEnabled(Log, os, userID, instance.Spec.Enabled)
+		if err != nil {
+			return "", err
+		}
+		now := metav1.Now()
+		instance.Status.EnabledChangeTime = &now
+		if drift {
+			Log.Info(fmt.Sprintf("Reverted the out of band change of enabled of user %s to %t", instance.Spec.UserName, enabled))
+			instance.Status.LastDriftRepairTime = &now
+		}
+		events.emit(ctx, cloudEventUserUpdated, instance.Spec.UserName, map[string]string{
+			"userName": instance.Spec.UserName,
+			"userID":   userID,
+			"domainID": domainID,
+			"enabled":  fmt.Sprintf("%t", instance.Spec.Enabled),
+		})
+	}
+	instance.Status.Enabled = ptr.To(instance.Spec.Enabled)
+
+	//
+	// set the options of the spec which differ in keystone, a created user
+	// got them on create
+	//
+	if user != nil {
+		changed, err := changedUserOptions(userOptions(instance.Spec.Options), currentOptions)
+		if err != nil {
+			return "", err
+		}
+		if len(changed) > 0 {
+			err = identity.SetUserOptions(Log, os, userID, changed)
+			if err != nil {
+				return "", err
+			}
+			names := []string{}
+			for option := range changed {
+				names = append(names, string(option))
+			}
+			sort.Strings(names)
+			Log.Info(fmt.Sprintf("Set the options %s of user %s", strings.Join(names, ", "), instance.Spec.UserName))
+			events.emit(ctx, cloudEventUserUpdated, instance.Spec.UserName, map[string]string{
+				"userName": instance.Spec.UserName,
+				"userID":   userID,
+				"domainID": domainID,
+				"options":  strings.Join(names, ","),
+			})
+		}
+	}
+
+	return "", nil
+}
+
+// userOptionLockPassword - the lock_password resource option of keystone,
+// gophercloud does not define it
+const userOptionLockPassword users.Option = "lock_password"
+
+// userOptions - returns the resource options of the spec which are set
+func userOptions(spec keystonev1.KeystoneUserOptions) map[users.Option]interface{} {
+	options := map[users.Option]interface{}{}
+	for option, value := range map[users.Option]*bool{
+		users.IgnoreChangePasswordUponFirstUse: spec.IgnoreChangePasswordUponFirstUse,
+		users.IgnorePasswordExpiry:             spec.IgnorePasswordExpiry,
+		users.IgnoreLockoutFailureAttempts:     spec.IgnoreLockoutFailureAttempts,
+		userOptionLockPassword:                 spec.LockPassword,
+		users.MultiFactorAuthEnabled:           spec.MultiFactorAuthEnabled,
+	} {
+		if value != nil {
+			options[option] = *value
+		}
+	}
+	if spec.MultiFactorAuthRules != nil {
+		options[users.MultiFactorAuthRules] = spec.MultiFactorAuthRules
+	}
+	return options
+}
+
+// changedUserOptions - returns the options which differ from the current
+// options of the user. The values get compared in their JSON form, keystone
+// returns the rules as lists of interfaces.
+func changedUserOptions(
+	options map[users.Option]interface{},
+	current map[string]interface{},
+) (map[users.Option]interface{}, error) {
+	changed := map[users.Option]interface{}{}
+	for option, value := range options {
+		want, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		got, err := json.Marshal(current[string(option)])
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(want, got) {
+			changed[option] = value
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,732 @@
+/*
+   Copyright 2022.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/users"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/go-logr/logr"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/identity"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// userBatchPendingRequeue - requeue interval while users of a batch are
+// pending because of the users per minute safety limit
+const userBatchPendingRequeue = time.Minute
+
+// KeystoneUserBatchReconciler reconciles a KeystoneUserBatch object
+type KeystoneUserBatchReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+	// Requeue - requeue intervals while waiting for the KeystoneAPI and
+	// KeystoneServices
+	Requeue RequeueIntervals
+	// OpenStackClient - returns the keystone admin client, the client of
+	// the KeystoneAPI if not set. Tests set the factory of a fake.
+	OpenStackClient openstackclient.Factory
+	// Limits - safety limits of the keystone objects the controller creates
+	Limits SafetyLimits
+
+	degraded      degradedTracker
+	userCreations rateWindow
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneUserBatchReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneUserBatch")
+}
+
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneuserbatches,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneuserbatches/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneuserbatches/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+//+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
+
+// Reconcile keystone user batch requests
+func (r *KeystoneUserBatchReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	Log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneUserBatch instance
+	instance := &keystonev1.KeystoneUserBatch{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			r.degraded.forget(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		Log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			Log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		updateReadyCondition(&instance.Status.Conditions,
+			instance.Generation, instance.Status.ObservedGeneration)
+		recordReconcile(instance.Spec, _err,
+			instance.Status.Conditions.IsTrue(condition.ReadyCondition),
+			&instance.Status.LastReconcileTime,
+			&instance.Status.LastSuccessfulReconcile,
+			&instance.Status.AppliedSpecHash)
+		r.degraded.handleResult(Log, req.NamespacedName, &instance.Status.Conditions, &result, &_err)
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneUserBatchReadyCondition, condition.InitReason, keystonev1.KeystoneUserBatchReadyInitMessage),
+		)
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, nil
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPI(ctx, helper, instance.Namespace, map[string]string{})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// If this KeystoneUserBatch CR is being deleted and the KeystoneAPI is
+			// gone, its database went away with it and there is nothing to
+			// clean up. Waiting for a KeystoneAPI to appear would block the
+			// deletion, e.g. of the namespace.
+			if !instance.DeletionTimestamp.IsZero() {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			Log.Info("KeystoneAPI not found!")
+
+			return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+
+	// If both the batch and the KeystoneAPI is deleted then we can
+	// skip the cleanup on the OpenStack side as the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() {
+		skip, err := skipKeystoneCleanup(ctx, Log, r.Kclient, instance, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if skip {
+			return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+		}
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && len(instance.Status.DomainIDs) == 0 {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		Log.Info("KeystoneAPI not yet ready!")
+
+		return ctrl.Result{RequeueAfter: r.Requeue.keystoneAPI()}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := getAdminClient(ctx, helper, keystoneAPI, r.OpenStackClient)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal batch delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+	}
+
+	// Handle non-deleted clusters
+	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+}
+
+// fields to index to reconcile when change
+const (
+	userBatchConfigMapField      = ".spec.configMap"
+	userBatchPasswordSecretField = ".spec.passwordSecret" // #nosec G101
+)
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KeystoneUserBatchReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	// index userBatchConfigMapField
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &keystonev1.KeystoneUserBatch{}, userBatchConfigMapField, func(rawObj client.Object) []string {
+		// Extract the ConfigMap name from the spec
+		cr := rawObj.(*keystonev1.KeystoneUserBatch)
+		return []string{cr.Spec.ConfigMap}
+	}); err != nil {
+		return err
+	}
+
+	// index userBatchPasswordSecretField
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &keystonev1.KeystoneUserBatch{}, userBatchPasswordSecretField, func(rawObj client.Object) []string {
+		// Extract the secret name from the spec
+		cr := rawObj.(*keystonev1.KeystoneUserBatch)
+		if cr.Spec.PasswordSecret == "" {
+			return nil
+		}
+		return []string{cr.Spec.PasswordSecret}
+	}); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneUserBatch{}).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSrc(userBatchConfigMapField)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSrc(userBatchPasswordSecretField)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Complete(r)
+}
+
+// findObjectsForSrc - returns the map function enqueuing the batches which
+// reference the changed input source by the indexed field
+func (r *KeystoneUserBatchReconciler) findObjectsForSrc(field string) handler.MapFunc {
+	return func(ctx context.Context, src client.Object) []reconcile.Request {
+		requests := []reconcile.Request{}
+
+		Log := r.GetLogger(context.Background())
+
+		crList := &keystonev1.KeystoneUserBatchList{}
+		listOps := &client.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector(field, src.GetName()),
+			Namespace:     src.GetNamespace(),
+		}
+		err := r.List(ctx, crList, listOps)
+		if err != nil {
+			Log.Error(err, fmt.Sprintf("listing %s for field: %s - %s", crList.GroupVersionKind().Kind, field, src.GetNamespace()))
+			return requests
+		}
+
+		for _, item := range crList.Items {
+			Log.Info(fmt.Sprintf("input source %s changed, reconcile: %s - %s", src.GetName(), item.GetName(), item.GetNamespace()))
+
+			requests = append(requests,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      item.GetName(),
+						Namespace: item.GetNamespace(),
+					},
+				},
+			)
+		}
+
+		return requests
+	}
+}
+
+func (r *KeystoneUserBatchReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneUserBatch,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling User Batch delete")
+
+	// We might not have an OpenStack backend to use in certain situations.
+	// Only the users created by the batch get deleted, adopted users are kept.
+	if os != nil && len(instance.Status.DomainIDs) > 0 {
+		owner, err := newOwnership(ctx, r.Kclient, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		events := newCatalogEvents(Log, keystoneAPI, instance, "keystoneuserbatches")
+		for _, domainID := range instance.Status.DomainIDs {
+			domainUsers, err := identity.ListUsers(Log, os, domainID)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			for _, user := range domainUsers {
+				if userOwner(user) != owner.owner {
+					continue
+				}
+				err = identity.DeleteUserByID(Log, os, user.ID)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				events.emit(ctx, cloudEventUserDeleted, user.Name, map[string]string{
+					"userName": user.Name,
+					"userID":   user.ID,
+					"domainID": domainID,
+				})
+			}
+		}
+	}
+	instance.Status.DomainIDs = nil
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this batch from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Users are deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	Log.Info("Reconciled User Batch delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneUserBatchReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneUserBatch,
+	helper *helper.Helper,
+	os openstackclient.OpenStackClient,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling User Batch normal")
+	events := newCatalogEvents(Log, keystoneAPI, instance, "keystoneuserbatches")
+
+	// label the CR with the KeystoneAPI it belongs to
+	setKeystoneAPILabel(instance, keystoneAPI)
+
+	//
+	// Add a finalizer to the KeystoneAPI for this batch, as we do not want the
+	// KeystoneAPI to disappear before this batch in the case where it is deleted
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	waitingFor, err := r.reconcileBatch(ctx, instance, helper, os, events)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneUserBatchReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneUserBatchReadyErrorMessage,
+			keystonev1.KeystoneErrorMessage(err)))
+		return ctrl.Result{}, err
+	}
+	if waitingFor != "" {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneUserBatchReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneUserBatchReadyWaitingMessage,
+			waitingFor))
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	if instance.Status.PendingCount > 0 {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneUserBatchReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneUserBatchReadyPendingMessage,
+			instance.Status.PendingCount,
+			instance.Status.UserCount))
+		return ctrl.Result{RequeueAfter: userBatchPendingRequeue}, nil
+	}
+	if instance.Status.FailedCount > 0 {
+		// the failures get retried with the drift check
+		failure := instance.Status.Failures[0]
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneUserBatchReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneUserBatchReadyFailedMessage,
+			instance.Status.FailedCount,
+			instance.Status.UserCount,
+			failure.Line,
+			failure.Message))
+		return ctrl.Result{RequeueAfter: userDriftCheckInterval}, nil
+	}
+
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneUserBatchReadyCondition,
+		keystonev1.KeystoneUserBatchReadyMessage,
+		instance.Status.UserCount,
+	)
+
+	Log.Info("Reconciled User Batch normal successfully")
+
+	// check the enabled state in keystone again to revert changes made out
+	// of band
+	return ctrl.Result{RequeueAfter: userDriftCheckInterval}, nil
+}
+
+// userBatchCreate - user of a batch to create in the domain
+type userBatchCreate struct {
+	entry    keystonev1.UserBatchEntry
+	domainID string
+}
+
+// reconcileBatch - provisions the users of the CSV. The users of each domain
+// get listed once, the missing ones get created in parallel and marked with
+// the ownership marker of the batch, existing users get adopted. Users
+// created by the batch which got removed from the CSV get deleted. If an
+// input of the batch does not exist, a non empty description of what is
+// missing gets returned.
+func (r *KeystoneUserBatchReconciler) reconcileBatch(
+	ctx context.Context,
+	instance *keystonev1.KeystoneUserBatch,
+	h *helper.Helper,
+	os openstackclient.OpenStackClient,
+	events *catalogEvents,
+) (string, error) {
+	Log := r.GetLogger(ctx)
+
+	cm := &corev1.ConfigMap{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: instance.Spec.ConfigMap, Namespace: instance.Namespace}, cm)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return fmt.Sprintf("configmap %s", instance.Spec.ConfigMap), nil
+		}
+		return "", err
+	}
+	data, ok := cm.Data[instance.Spec.Key]
+	if !ok {
+		return fmt.Sprintf("key %s in configmap %s", instance.Spec.Key, instance.Spec.ConfigMap), nil
+	}
+
+	passwords := map[string][]byte{}
+	if instance.Spec.PasswordSecret != "" {
+		scrt, _, err := oko_secret.GetSecret(ctx, h, instance.Spec.PasswordSecret, instance.Namespace)
+		if err != nil {
+			if k8s_errors.IsNotFound(err) {
+				return fmt.Sprintf("secret %s", instance.Spec.PasswordSecret), nil
+			}
+			return "", err
+		}
+		passwords = scrt.Data
+	}
+
+	entries, failures, err := keystonev1.ParseUserBatch(data, instance.Spec.Domain)
+	if err != nil {
+		return "", fmt.Errorf("invalid users CSV in key %s of configmap %s: %w", instance.Spec.Key, instance.Spec.ConfigMap, err)
+	}
+	// lines which can not be parsed count as users
+	userCount := int32(len(entries) + len(failures))
+
+	owner, err := newOwnership(ctx, r.Kclient, instance)
+	if err != nil {
+		return "", err
+	}
+
+	//
+	// resolve the domains and list their users once
+	//
+	domainIDs := map[string]string{}
+	domainUsers := map[string]map[string]users.User{}
+	listUsers := func(domainID string) error {
+		if _, ok := domainUsers[domainID]; ok {
+			return nil
+		}
+		list, err := identity.ListUsers(Log, os, domainID)
+		if err != nil {
+			return err
+		}
+		domainUsers[domainID] = map[string]users.User{}
+		for _, user := range list {
+			domainUsers[domainID][user.Name] = user
+		}
+		return nil
+	}
+
+	var ready int32
+	wanted := map[string]bool{}
+	var toCreate []userBatchCreate
+	for _, entry := range entries {
+		domainID, ok := domainIDs[strings.ToLower(entry.Domain)]
+		if !ok {
+			domainID, err = getDomainID(Log, os, entry.Domain, false)
+			if err != nil {
+				return "", err
+			}
+			domainIDs[strings.ToLower(entry.Domain)] = domainID
+		}
+		if domainID == "" {
+			failures = append(failures, userBatchFailure(entry, fmt.Sprintf("domain %s not found", entry.Domain)))
+			continue
+		}
+		err = listUsers(domainID)
+		if err != nil {
+			return "", err
+		}
+		wanted[domainID+"/"+entry.UserName] = true
+
+		user, ok := domainUsers[domainID][entry.UserName]
+		if !ok {
+			toCreate = append(toCreate, userBatchCreate{entry: entry, domainID: domainID})
+			continue
+		}
+		if user.Enabled != entry.Enabled {
+			err = identity.SetUserEnabled(Log, os, user.ID, entry.Enabled)
+			if err != nil {
+				failures = append(failures, userBatchFailure(entry, keystonev1.KeystoneErrorMessage(err)))
+				continue
+			}
+			events.emit(ctx, cloudEventUserUpdated, user.Name, map[string]string{
+				"userName": user.Name,
+				"userID":   user.ID,
+				"domainID": domainID,
+				"enabled":  fmt.Sprintf("%t", entry.Enabled),
+			})
+		}
+		ready++
+	}
+
+	//
+	// delete the users created by the batch which got removed from the CSV
+	//
+	ownedDomains := map[string]bool{}
+	deleted := map[string]int{}
+	for _, domainID := range instance.Status.DomainIDs {
+		err = listUsers(domainID)
+		if err != nil {
+			return "", err
+		}
+	}
+	for domainID, byName := range domainUsers {
+		for _, user := range byName {
+			if userOwner(user) != owner.owner {
+				continue
+			}
+			if wanted[domainID+"/"+user.Name] {
+				ownedDomains[domainID] = true
+				continue
+			}
+			err = identity.DeleteUserByID(Log, os, user.ID)
+			if err != nil {
+				return "", err
+			}
+			deleted[domainID]++
+			events.emit(ctx, cloudEventUserDeleted, user.Name, map[string]string{
+				"userName": user.Name,
+				"userID":   user.ID,
+				"domainID": domainID,
+			})
+		}
+	}
+
+	//
+	// create the missing users in parallel
+	//
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan userBatchCreate)
+	concurrency := instance.Spec.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				userID, err := identity.CreateUserWithExtra(Log, os, users.CreateOpts{
+					Name:     job.entry.UserName,
+					DomainID: job.domainID,
+					Password: string(passwords[job.entry.UserName]),
+					Enabled:  &job.entry.Enabled,
+					Extra: map[string]interface{}{
+						OwnerClusterIDAttribute: owner.owner.ClusterID,
+						OwnerUIDAttribute:       owner.owner.OwnerUID,
+					},
+				})
+				mu.Lock()
+				if err != nil {
+					failures = append(failures, userBatchFailure(job.entry, keystonev1.KeystoneErrorMessage(err)))
+				} else {
+					ready++
+					ownedDomains[job.domainID] = true
+				}
+				mu.Unlock()
+				if err == nil {
+					events.emit(ctx, cloudEventUserCreated, job.entry.UserName, map[string]string{
+						"userName": job.entry.UserName,
+						"userID":   userID,
+						"domainID": job.domainID,
+					})
+				}
+			}
+		}()
+	}
+	domainCounts := map[string]int{}
+	for domainID, byName := range domainUsers {
+		domainCounts[domainID] = len(byName) - deleted[domainID]
+	}
+	var pendingCount int32
+	for i, job := range toCreate {
+		err = domainUserLimitError(job.entry.Domain, domainCounts[job.domainID], r.Limits.maxUsersPerDomain(job.entry.Domain))
+		if err != nil {
+			mu.Lock()
+			failures = append(failures, userBatchFailure(job.entry, err.Error()))
+			mu.Unlock()
+			continue
+		}
+		err = r.userCreations.allow(r.Limits.maxUsersPerMinute(), "users")
+		if err != nil {
+			pendingCount = int32(len(toCreate) - i)
+			Log.Info(fmt.Sprintf("Creating %d users of the batch later: %s", pendingCount, err.Error()))
+			break
+		}
+		domainCounts[job.domainID]++
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+
+	instance.Status.DomainIDs = nil
+	for domainID := range ownedDomains {
+		instance.Status.DomainIDs = append(instance.Status.DomainIDs, domainID)
+	}
+	sort.Strings(instance.Status.DomainIDs)
+
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].Line < failures[j].Line
+	})
+	instance.Status.UserCount = userCount
+	instance.Status.ReadyCount = ready
+	instance.Status.PendingCount = pendingCount
+	instance.Status.FailedCount = int32(len(failures))
+	if len(failures) > keystonev1.UserBatchMaxReportedFailures {
+		failures = failures[:keystonev1.UserBatchMaxReportedFailures]
+	}
+	instance.Status.Failures = failures
+
+	return "", nil
+}
+
+// userBatchFailure - returns the failure of the user of the batch
+func userBatchFailure(entry keystonev1.UserBatchEntry, message string) keystonev1.KeystoneUserBatchFailure {
+	return keystonev1.KeystoneUserBatchFailure{
+		Line:     entry.Line,
+		UserName: entry.UserName,
+		Domain:   entry.Domain,
+		Message:  message,
+	}
+}
@@ -0,0 +1,168 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// catalogAuditMismatches - number of mismatches found by the last catalog
+	// audit per finding type
+	catalogAuditMismatches = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "keystone_catalog_audit_mismatches",
+			Help: "Number of mismatches between the keystone catalog and the KeystoneService/KeystoneEndpoint CRs found by the last audit",
+		},
+		[]string{"namespace", "name", "type"},
+	)
+
+	// fernetKeys - age and rotation state of the fernet keys per KeystoneAPI
+	fernetKeys = newFernetKeysCollector()
+
+	// tokenSmokeTestSuccess - result of the last token smoke test
+	tokenSmokeTestSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "keystone_token_smoke_test_success",
+			Help: "1 if the last token smoke test of the KeystoneAPI issued and validated a token, 0 otherwise",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	// tokenSmokeTestDuration - time the successful token smoke tests took
+	tokenSmokeTestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "keystone_token_smoke_test_duration_seconds",
+			Help:    "Seconds the successful token smoke tests of the KeystoneAPI took to issue and validate a token",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"namespace", "name"},
+	)
+
+	// tokenSmokeTestFailures - number of failed token smoke tests
+	tokenSmokeTestFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "keystone_token_smoke_test_failures_total",
+			Help: "Number of token smoke tests of the KeystoneAPI which failed to issue or validate a token",
+		},
+		[]string{"namespace", "name"},
+	)
+)
+
+// observeTokenSmokeTest - records the result of a token smoke test of the
+// KeystoneAPI
+func observeTokenSmokeTest(name types.NamespacedName, duration time.Duration, err error) {
+	if err != nil {
+		tokenSmokeTestSuccess.WithLabelValues(name.Namespace, name.Name).Set(0)
+		tokenSmokeTestFailures.WithLabelValues(name.Namespace, name.Name).Inc()
+		return
+	}
+	tokenSmokeTestSuccess.WithLabelValues(name.Namespace, name.Name).Set(1)
+	tokenSmokeTestDuration.WithLabelValues(name.Namespace, name.Name).Observe(duration.Seconds())
+}
+
+// deleteTokenSmokeTestMetrics - removes the token smoke test metrics of the
+// KeystoneAPI
+func deleteTokenSmokeTestMetrics(name types.NamespacedName) {
+	tokenSmokeTestSuccess.DeleteLabelValues(name.Namespace, name.Name)
+	tokenSmokeTestDuration.DeleteLabelValues(name.Namespace, name.Name)
+	tokenSmokeTestFailures.DeleteLabelValues(name.Namespace, name.Name)
+}
+
+// fernetKeysState - last rotation and rotation period of the fernet keys
+type fernetKeysState struct {
+	rotatedAt      time.Time
+	rotationPeriod time.Duration
+}
+
+// fernetKeysCollector - calculates the age of the fernet keys at scrape time,
+// so the metrics keep increasing while no reconcile runs and a stuck rotation
+// can be alerted on
+type fernetKeysCollector struct {
+	mu     sync.Mutex
+	states map[types.NamespacedName]fernetKeysState
+
+	keyAge  *prometheus.Desc
+	overdue *prometheus.Desc
+}
+
+func newFernetKeysCollector() *fernetKeysCollector {
+	return &fernetKeysCollector{
+		states: map[types.NamespacedName]fernetKeysState{},
+		keyAge: prometheus.NewDesc(
+			"keystone_fernet_key_age_seconds",
+			"Seconds since the fernet keys of the KeystoneAPI got rotated last",
+			[]string{"namespace", "name"}, nil,
+		),
+		overdue: prometheus.NewDesc(
+			"keystone_fernet_rotation_overdue",
+			"1 if the fernet keys of the KeystoneAPI are older than the rotation period, 0 otherwise",
+			[]string{"namespace", "name"}, nil,
+		),
+	}
+}
+
+// set - records the last rotation of the fernet keys of the KeystoneAPI
+func (c *fernetKeysCollector) set(name types.NamespacedName, rotatedAt time.Time, rotationPeriod time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states[name] = fernetKeysState{rotatedAt: rotatedAt, rotationPeriod: rotationPeriod}
+}
+
+// delete - removes the metrics of the KeystoneAPI
+func (c *fernetKeysCollector) delete(name types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.states, name)
+}
+
+// Describe - implements prometheus.Collector
+func (c *fernetKeysCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.keyAge
+	ch <- c.overdue
+}
+
+// Collect - implements prometheus.Collector
+func (c *fernetKeysCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for name, state := range c.states {
+		age := now.Sub(state.rotatedAt)
+		overdue := 0.0
+		if age > state.rotationPeriod {
+			overdue = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.keyAge, prometheus.GaugeValue, age.Seconds(), name.Namespace, name.Name)
+		ch <- prometheus.MustNewConstMetric(c.overdue, prometheus.GaugeValue, overdue, name.Namespace, name.Name)
+	}
+}
+
+func init() {
+	// Register custom metrics with the global prometheus registry
+	metrics.Registry.MustRegister(
+		catalogAuditMismatches,
+		fernetKeys,
+		tokenSmokeTestSuccess,
+		tokenSmokeTestDuration,
+		tokenSmokeTestFailures,
+	)
+}
@@ -0,0 +1,226 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/endpoints"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/services"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/users"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// OwnerClusterIDAttribute - extra attribute of the services and endpoints
+	// the operator registers in keystone, holding the ID of the cluster the
+	// operator runs in
+	OwnerClusterIDAttribute = "keystone_operator_cluster_id"
+
+	// OwnerUIDAttribute - extra attribute of the services and endpoints the
+	// operator registers in keystone, holding the UID of the CR they got
+	// registered for
+	OwnerUIDAttribute = "keystone_operator_owner_uid"
+)
+
+var (
+	clusterIDLock sync.Mutex
+	clusterID     string
+)
+
+// getClusterID - returns the UID of the kube-system namespace, which
+// identifies the cluster. It does not change for the lifetime of a cluster
+// and gets cached.
+func getClusterID(ctx context.Context, kclient kubernetes.Interface) (string, error) {
+	clusterIDLock.Lock()
+	defer clusterIDLock.Unlock()
+
+	if clusterID != "" {
+		return clusterID, nil
+	}
+	ns, err := kclient.CoreV1().Namespaces().Get(ctx, "kube-system", metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	clusterID = string(ns.UID)
+
+	return clusterID, nil
+}
+
+// keystoneOwner - ownership marker of a service or endpoint in keystone. Both
+// fields are empty for objects not registered by the operator.
+type keystoneOwner struct {
+	ClusterID string `json:"keystone_operator_cluster_id"`
+	OwnerUID  string `json:"keystone_operator_owner_uid"`
+}
+
+// serviceOwner - returns the ownership marker of the service
+func serviceOwner(svc services.Service) keystoneOwner {
+	owner := keystoneOwner{}
+	owner.ClusterID, _ = svc.Extra[OwnerClusterIDAttribute].(string)
+	owner.OwnerUID, _ = svc.Extra[OwnerUIDAttribute].(string)
+	return owner
+}
+
+// endpointOwner - returns the ownership marker of the endpoint
+func endpointOwner(endpt openstackclient.Endpoint) keystoneOwner {
+	owner := keystoneOwner{}
+	owner.ClusterID, _ = endpt.Extra[OwnerClusterIDAttribute].(string)
+	owner.OwnerUID, _ = endpt.Extra[OwnerUIDAttribute].(string)
+	return owner
+}
+
+// userOwner - returns the ownership marker of the user
+func userOwner(user users.User) keystoneOwner {
+	owner := keystoneOwner{}
+	owner.ClusterID, _ = user.Extra[OwnerClusterIDAttribute].(string)
+	owner.OwnerUID, _ = user.Extra[OwnerUIDAttribute].(string)
+	return owner
+}
+
+// listEndpointOwners - returns the ownership markers of the endpoints of the
+// service by endpoint ID
+func listEndpointOwners(os catalogBackend, serviceID string) (map[string]keystoneOwner, error) {
+	allEndpoints, err := os.ListEndpoints(endpoints.ListOpts{
+		ServiceID: serviceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	owners := map[string]keystoneOwner{}
+	for _, endpt := range allEndpoints {
+		owners[endpt.ID] = endpointOwner(endpt)
+	}
+	return owners, nil
+}
+
+// ownershipConflictError - a service or endpoint carries the ownership
+// marker of the operator of another cluster sharing the keystone. The
+// instance gets reported as degraded right away, both operators would
+// otherwise keep overwriting each other.
+type ownershipConflictError struct {
+	kind      string
+	id        string
+	clusterID string
+}
+
+func (e *ownershipConflictError) Error() string {
+	return fmt.Sprintf("%s %s is managed by the keystone-operator of cluster %s", e.kind, e.id, e.clusterID)
+}
+
+// ownership - tags the services and endpoints registered for a CR with the
+// cluster ID and the UID of the CR. The catalog audit uses the marker to tell
+// apart objects registered by the operator from manually created ones.
+type ownership struct {
+	owner keystoneOwner
+	// takeOver - replace the marker of another cluster instead of failing
+	takeOver bool
+}
+
+// newOwnership - returns the ownership marker of the keystone objects
+// registered for the CR
+func newOwnership(ctx context.Context, kclient kubernetes.Interface, cr client.Object) (*ownership, error) {
+	id, err := getClusterID(ctx, kclient)
+	if err != nil {
+		return nil, err
+	}
+	return &ownership{
+		owner: keystoneOwner{
+			ClusterID: id,
+			OwnerUID:  string(cr.GetUID()),
+		},
+		takeOver: cr.GetAnnotations()[keystonev1.TakeOwnershipAnnotation] == "true",
+	}, nil
+}
+
+// check - returns an ownershipConflictError if the object carries the
+// ownership marker of another cluster, unless the ownership gets taken over
+func (o *ownership) check(kind string, id string, current keystoneOwner) error {
+	if current.ClusterID == "" || current.ClusterID == o.owner.ClusterID || o.takeOver {
+		return nil
+	}
+	return &ownershipConflictError{kind: kind, id: id, clusterID: current.ClusterID}
+}
+
+// checkService - returns an ownershipConflictError if the service is managed
+// by the operator of another cluster
+func (o *ownership) checkService(svc services.Service) error {
+	return o.check("service", svc.ID, serviceOwner(svc))
+}
+
+// checkEndpoint - returns an ownershipConflictError if the endpoint is
+// managed by the operator of another cluster
+func (o *ownership) checkEndpoint(endpointID string, owners map[string]keystoneOwner) error {
+	return o.check("endpoint", endpointID, owners[endpointID])
+}
+
+// attributes - returns the extra attributes of the ownership marker
+func (o *ownership) attributes() map[string]interface{} {
+	return map[string]interface{}{
+		OwnerClusterIDAttribute: o.owner.ClusterID,
+		OwnerUIDAttribute:       o.owner.OwnerUID,
+	}
+}
+
+// tagService - sets the ownership marker on the service unless it is already
+// set. Keystone keeps the other extra attributes of the service.
+func (o *ownership) tagService(log logr.Logger, os catalogBackend, svc services.Service) error {
+	if serviceOwner(svc) == o.owner {
+		return nil
+	}
+	err := o.checkService(svc)
+	if err != nil {
+		return err
+	}
+	err = os.UpdateServiceExtra(svc.ID, o.attributes())
+	if err != nil {
+		return err
+	}
+	log.Info("Tagged service with ownership marker", "serviceID", svc.ID)
+	return nil
+}
+
+// tagEndpoint - sets the ownership marker on the endpoint unless owners
+// already has it for the endpoint. Keystone keeps the other extra attributes
+// of the endpoint.
+func (o *ownership) tagEndpoint(
+	log logr.Logger,
+	os catalogBackend,
+	endpointID string,
+	owners map[string]keystoneOwner,
+) error {
+	if owners[endpointID] == o.owner {
+		return nil
+	}
+	err := o.checkEndpoint(endpointID, owners)
+	if err != nil {
+		return err
+	}
+	err = os.UpdateEndpointExtra(endpointID, o.attributes())
+	if err != nil {
+		return err
+	}
+	log.Info("Tagged endpoint with ownership marker", "endpointID", endpointID)
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reconcileTimeResolution - minimum interval the last reconcile time gets
+// updated in while the reconcile result and applied spec do not change.
+// Every status patch triggers another reconcile, updating the time on every
+// reconcile would keep the instances reconciling in a loop.
+const reconcileTimeResolution = time.Minute
+
+// recordReconcile - records the time and result of a reconcile in the status
+// fields. A reconcile only succeeded if it returned no error and the instance
+// is ready, a reconcile which waits e.g. for a job or a dependency returns no
+// error either. On success the hash of the spec gets stored as applied spec
+// hash.
+func recordReconcile(
+	spec interface{},
+	err error,
+	ready bool,
+	lastReconcileTime **metav1.Time,
+	lastSuccessfulReconcile **metav1.Time,
+	appliedSpecHash *string,
+) {
+	now := metav1.Now()
+	succeeded := err == nil && ready
+
+	hash := *appliedSpecHash
+	if succeeded {
+		// the spec got applied, a hash error only means the hash can not be
+		// recorded, which must not fail the reconcile
+		if h, hashErr := util.ObjectHash(spec); hashErr == nil {
+			hash = h
+		}
+	}
+
+	// the previous reconcile was successful if both times match
+	lastSucceeded := *lastReconcileTime != nil && *lastSuccessfulReconcile != nil &&
+		(*lastReconcileTime).Equal(*lastSuccessfulReconcile)
+
+	if *lastReconcileTime != nil &&
+		now.Sub((*lastReconcileTime).Time) < reconcileTimeResolution &&
+		lastSucceeded == succeeded &&
+		hash == *appliedSpecHash {
+		return
+	}
+
+	*lastReconcileTime = &now
+	if succeeded {
+		*lastSuccessfulReconcile = &now
+		*appliedSpecHash = hash
+	}
+}
+
+// updateReadyCondition - updates the Ready condition based on the sub
+// conditions. Ready is only reported True if the status reflects the current
+// generation of the spec, otherwise e.g. kubectl wait returns on the stale
+// status of the previous generation after a spec edit.
+func updateReadyCondition(
+	conditions *condition.Conditions,
+	generation int64,
+	observedGeneration int64,
+) {
+	if conditions.AllSubConditionIsTrue() && generation == observedGeneration {
+		conditions.MarkTrue(
+			condition.ReadyCondition, condition.ReadyMessage)
+		return
+	}
+
+	// something is not ready so reset the Ready condition
+	conditions.MarkUnknown(
+		condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
+	// and recalculate it based on the state of the rest of the conditions
+	conditions.Set(
+		conditions.Mirror(condition.ReadyCondition))
+
+	if generation != observedGeneration && conditions.IsTrue(condition.ReadyCondition) {
+		conditions.Set(condition.FalseCondition(
+			condition.ReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.ObservedGenerationWaitingMessage,
+			generation,
+			observedGeneration))
+	}
+}
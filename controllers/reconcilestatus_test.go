@@ -0,0 +1,89 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type reconcileStatus struct {
+	lastReconcileTime       *metav1.Time
+	lastSuccessfulReconcile *metav1.Time
+	appliedSpecHash         string
+}
+
+func (s *reconcileStatus) record(spec interface{}, err error, ready bool) {
+	recordReconcile(spec, err, ready,
+		&s.lastReconcileTime, &s.lastSuccessfulReconcile, &s.appliedSpecHash)
+}
+
+func TestRecordReconcileWaiting(t *testing.T) {
+	g := NewWithT(t)
+	status := reconcileStatus{}
+
+	// a reconcile waiting e.g. for a job returns no error, but the spec did
+	// not get applied yet
+	status.record(map[string]string{"replicas": "1"}, nil, false)
+	g.Expect(status.lastReconcileTime).NotTo(BeNil())
+	g.Expect(status.lastSuccessfulReconcile).To(BeNil())
+	g.Expect(status.appliedSpecHash).To(BeEmpty())
+
+	status.record(map[string]string{"replicas": "1"}, nil, true)
+	g.Expect(status.lastSuccessfulReconcile).NotTo(BeNil())
+	g.Expect(status.lastSuccessfulReconcile.Equal(status.lastReconcileTime)).To(BeTrue())
+	g.Expect(status.appliedSpecHash).NotTo(BeEmpty())
+	appliedHash := status.appliedSpecHash
+	lastSuccessful := status.lastSuccessfulReconcile
+
+	// waiting on a changed spec keeps the previously applied spec
+	status.record(map[string]string{"replicas": "3"}, nil, false)
+	g.Expect(status.appliedSpecHash).To(Equal(appliedHash))
+	g.Expect(status.lastSuccessfulReconcile).To(Equal(lastSuccessful))
+	g.Expect(status.lastReconcileTime.Equal(status.lastSuccessfulReconcile)).To(BeFalse())
+}
+
+func TestRecordReconcileError(t *testing.T) {
+	g := NewWithT(t)
+	status := reconcileStatus{}
+
+	status.record(map[string]string{"replicas": "1"}, errors.New("connection refused"), true)
+	g.Expect(status.lastReconcileTime).NotTo(BeNil())
+	g.Expect(status.lastSuccessfulReconcile).To(BeNil())
+	g.Expect(status.appliedSpecHash).To(BeEmpty())
+}
+
+func TestRecordReconcileTimeResolution(t *testing.T) {
+	g := NewWithT(t)
+	status := reconcileStatus{}
+
+	status.record(map[string]string{"replicas": "1"}, nil, true)
+	first := status.lastReconcileTime
+
+	// the same result within the resolution does not update the time
+	status.record(map[string]string{"replicas": "1"}, nil, true)
+	g.Expect(status.lastReconcileTime).To(BeIdenticalTo(first))
+
+	past := metav1.NewTime(first.Add(-2 * reconcileTimeResolution))
+	status.lastReconcileTime = &past
+	status.lastSuccessfulReconcile = &past
+	status.record(map[string]string{"replicas": "1"}, nil, true)
+	g.Expect(status.lastReconcileTime.After(past.Add(time.Minute))).To(BeTrue())
+}
@@ -0,0 +1,247 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/endpoints"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	"github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	"k8s.io/utils/ptr"
+)
+
+// reconcileRegionalEndpoints - registers the endpoint sets of
+// Spec.RegionalEndpoints in their regions and deletes the regional endpoints
+// which got removed from the spec. The endpoints of a region whose whole set
+// got removed, e.g. by a bad merge, are kept and reported in
+// Status.PendingPruneRegions until the region is listed in the
+// PruneRegionsAnnotation. The URL preflight and rollback only cover the
+// endpoints of the KeystoneAPI region.
+func (r *KeystoneEndpointReconciler) reconcileRegionalEndpoints(
+	ctx context.Context,
+	instance *keystonev1.KeystoneEndpoint,
+	os catalogBackend,
+	regional map[string]map[string]string,
+	events *catalogEvents,
+) error {
+	Log := r.GetLogger(ctx)
+
+	// delete the endpoints of regions or interfaces no longer in the spec
+	confirmed := pruneRegions(instance)
+	previous := map[string]keystonev1.Endpoint{}
+	var kept []keystonev1.Endpoint
+	pending := map[string]bool{}
+	for _, endpt := range instance.Status.RegionalEndpoints {
+		if _, ok := regional[endpt.Region][endpt.Interface]; ok {
+			previous[endpt.Region+"/"+endpt.Interface] = endpt
+			continue
+		}
+		if _, ok := regional[endpt.Region]; !ok && !confirmed[endpt.Region] {
+			kept = append(kept, endpt)
+			pending[endpt.Region] = true
+			continue
+		}
+		err := os.DeleteEndpointByID(endpt.ID)
+		if err != nil {
+			return err
+		}
+		Log.Info(fmt.Sprintf("Deleted %s endpoint %s in region %s", endpt.Interface, endpt.ID, endpt.Region))
+		events.emit(ctx, cloudEventEndpointDeleted, instance.Spec.ServiceName, map[string]string{
+			"serviceName": instance.Spec.ServiceName,
+			"serviceID":   instance.Status.ServiceID,
+			"interface":   endpt.Interface,
+			"endpointID":  endpt.ID,
+			"region":      endpt.Region,
+		})
+	}
+
+	owner, err := newOwnership(ctx, r.Kclient, instance)
+	if err != nil {
+		return err
+	}
+	owners, err := listEndpointOwners(os, instance.Status.ServiceID)
+	if err != nil {
+		return err
+	}
+
+	regions := make([]string, 0, len(regional))
+	for region := range regional {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	var status []keystonev1.Endpoint
+	for _, region := range regions {
+		endpointTypes := make([]string, 0, len(regional[region]))
+		for endpointType := range regional[region] {
+			endpointTypes = append(endpointTypes, endpointType)
+		}
+		sort.Strings(endpointTypes)
+
+		for _, endpointType := range endpointTypes {
+			endpointURL := regional[region][endpointType]
+			availability, err := openstack.GetAvailability(endpointType)
+			if err != nil {
+				return err
+			}
+
+			registered, err := os.ListEndpoints(endpoints.ListOpts{
+				Availability: availability,
+				ServiceID:    instance.Status.ServiceID,
+				RegionID:     region,
+			})
+			if err != nil {
+				return err
+			}
+
+			synced := keystonev1.Endpoint{
+				Interface: endpointType,
+				URL:       endpointURL,
+				Region:    region,
+				Enabled:   ptr.To(true),
+			}
+			switch len(registered) {
+			case 0:
+				err = os.EnsureRegion(region)
+				if err != nil {
+					return err
+				}
+				synced.ID, err = os.CreateRegionalEndpoint(
+					openstack.Endpoint{
+						Name:         instance.Spec.ServiceName,
+						ServiceID:    instance.Status.ServiceID,
+						Availability: availability,
+						URL:          endpointURL,
+					},
+					region,
+				)
+				if err != nil {
+					return err
+				}
+				Log.Info(fmt.Sprintf("Created %s endpoint %s in region %s", endpointType, synced.ID, region))
+				events.emit(ctx, cloudEventEndpointCreated, instance.Spec.ServiceName, map[string]string{
+					"serviceName": instance.Spec.ServiceName,
+					"serviceID":   instance.Status.ServiceID,
+					"interface":   endpointType,
+					"endpointID":  synced.ID,
+					"url":         endpointURL,
+					"region":      region,
+				})
+			case 1:
+				endpoint := registered[0]
+				err = owner.checkEndpoint(endpoint.ID, owners)
+				if err != nil {
+					return err
+				}
+				synced.ID = endpoint.ID
+				synced.Enabled = ptr.To(endpoint.Enabled)
+				if endpoint.URL != endpointURL {
+					err = os.UpdateEndpointURL(endpoint.ID, endpointURL)
+					if err != nil {
+						return err
+					}
+					Log.Info(fmt.Sprintf("Updated %s endpoint %s in region %s", endpointType, endpoint.ID, region))
+					events.emit(ctx, cloudEventEndpointUpdated, instance.Spec.ServiceName, map[string]string{
+						"serviceName": instance.Spec.ServiceName,
+						"serviceID":   instance.Status.ServiceID,
+						"interface":   endpointType,
+						"endpointID":  endpoint.ID,
+						"url":         endpointURL,
+						"region":      region,
+					})
+				}
+			default:
+				// a manual check is required which one to keep
+				return util.WrapErrorForObject(
+					fmt.Sprintf("multiple endpoints registered for service:%s type: %s region: %s",
+						instance.Spec.ServiceName, endpointType, region),
+					instance, err)
+			}
+
+			err = owner.tagEndpoint(Log, os, synced.ID, owners)
+			if err != nil {
+				return err
+			}
+
+			var prev []keystonev1.Endpoint
+			if endpt, ok := previous[region+"/"+endpointType]; ok {
+				prev = []keystonev1.Endpoint{endpt}
+			}
+			status = append(status, setEndpointStatus(prev, synced)...)
+		}
+	}
+	instance.Status.RegionalEndpoints = append(status, kept...)
+
+	instance.Status.PendingPruneRegions = nil
+	for region := range pending {
+		instance.Status.PendingPruneRegions = append(instance.Status.PendingPruneRegions, region)
+	}
+	sort.Strings(instance.Status.PendingPruneRegions)
+	if len(pending) > 0 {
+		Log.Info("Keeping the endpoints of removed regions until the prune got confirmed",
+			"regions", instance.Status.PendingPruneRegions, "annotation", keystonev1.PruneRegionsAnnotation)
+	}
+
+	return nil
+}
+
+// pruneRegions - returns the regions listed in the PruneRegionsAnnotation
+func pruneRegions(instance *keystonev1.KeystoneEndpoint) map[string]bool {
+	regions := map[string]bool{}
+	for _, region := range strings.Split(instance.Annotations[keystonev1.PruneRegionsAnnotation], ",") {
+		if region = strings.TrimSpace(region); region != "" {
+			regions[region] = true
+		}
+	}
+	return regions
+}
+
+// deleteRegionalEndpoints - deletes the endpoints registered for
+// Spec.RegionalEndpoints, e.g. when the KeystoneEndpoint gets deleted or
+// moved to another service
+func (r *KeystoneEndpointReconciler) deleteRegionalEndpoints(
+	ctx context.Context,
+	instance *keystonev1.KeystoneEndpoint,
+	os catalogBackend,
+	serviceName string,
+	events *catalogEvents,
+) error {
+	Log := r.GetLogger(ctx)
+
+	for _, endpt := range instance.Status.RegionalEndpoints {
+		err := os.DeleteEndpointByID(endpt.ID)
+		if err != nil {
+			return err
+		}
+		Log.Info(fmt.Sprintf("Deleted %s endpoint %s in region %s", endpt.Interface, endpt.ID, endpt.Region))
+		events.emit(ctx, cloudEventEndpointDeleted, serviceName, map[string]string{
+			"serviceName": serviceName,
+			"serviceID":   instance.Status.ServiceID,
+			"interface":   endpt.Interface,
+			"endpointID":  endpt.ID,
+			"region":      endpt.Region,
+		})
+	}
+	instance.Status.RegionalEndpoints = nil
+	instance.Status.PendingPruneRegions = nil
+
+	return nil
+}
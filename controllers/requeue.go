@@ -0,0 +1,60 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+)
+
+const (
+	// DefaultKeystoneAPIRequeue - default requeue interval while waiting for
+	// the KeystoneAPI
+	DefaultKeystoneAPIRequeue = 5 * time.Second
+
+	// DefaultKeystoneServiceRequeue - default requeue interval while waiting
+	// for a KeystoneService
+	DefaultKeystoneServiceRequeue = 10 * time.Second
+)
+
+// RequeueIntervals - requeue intervals of the controllers while they wait for
+// the CRs they depend on. Busy clusters can slow down the polling, CI can
+// speed it up. Zero values use the defaults.
+type RequeueIntervals struct {
+	// KeystoneAPI - requeue interval while waiting for the KeystoneAPI to
+	// exist and get ready
+	KeystoneAPI time.Duration
+
+	// KeystoneService - requeue interval while waiting for a KeystoneService
+	// to exist and get ready
+	KeystoneService time.Duration
+}
+
+// keystoneAPI - returns the requeue interval while waiting for the KeystoneAPI
+func (i RequeueIntervals) keystoneAPI() time.Duration {
+	if i.KeystoneAPI <= 0 {
+		return DefaultKeystoneAPIRequeue
+	}
+	return i.KeystoneAPI
+}
+
+// keystoneService - returns the requeue interval while waiting for a
+// KeystoneService
+func (i RequeueIntervals) keystoneService() time.Duration {
+	if i.KeystoneService <= 0 {
+		return DefaultKeystoneServiceRequeue
+	}
+	return i.KeystoneService
+}
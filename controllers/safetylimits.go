@@ -0,0 +1,299 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/identity"
+)
+
+const (
+	// DefaultMaxEndpointsPerService - default maximum number of endpoints of a
+	// service in a region
+	DefaultMaxEndpointsPerService = 30
+
+	// DefaultMaxUsersPerMinute - default maximum number of users the operator
+	// creates per minute
+	DefaultMaxUsersPerMinute = 30
+
+	// DefaultMaxUsersPerDomain - default maximum number of users of a domain
+	// the operator creates users up to
+	DefaultMaxUsersPerDomain = 5000
+
+	// DefaultMaxProjectsPerDomain - default maximum number of projects of a
+	// domain the operator creates projects up to
+	DefaultMaxProjectsPerDomain = 1000
+)
+
+// DomainLimit - limits of a single domain, overriding MaxUsersPerDomain and
+// MaxProjectsPerDomain. Zero values use the limits of all domains, negative
+// values disable the limit.
+type DomainLimit struct {
+	MaxUsers    int
+	MaxProjects int
+}
+
+// SafetyLimits - limits of the keystone objects the controllers create,
+// protecting keystone from runaway CR creation, e.g. by misbehaving tooling.
+// Zero values use the defaults, negative values disable the limit.
+type SafetyLimits struct {
+	// MaxEndpointsPerService - maximum number of endpoints of a service in a
+	// region
+	MaxEndpointsPerService int
+
+	// MaxUsersPerMinute - maximum number of users created per minute by all
+	// instances of the controller
+	MaxUsersPerMinute int
+
+	// MaxUsersPerDomain - maximum number of users of a domain the
+	// controllers create users up to
+	MaxUsersPerDomain int
+
+	// MaxProjectsPerDomain - maximum number of projects of a domain the
+	// controllers create projects up to
+	MaxProjectsPerDomain int
+
+	// DomainLimits - limits of single domains by domain name, e.g. to give a
+	// tenant of a multi-tenant cluster a smaller share of keystone
+	DomainLimits map[string]DomainLimit
+}
+
+// ParseDomainLimits - parses the comma separated domain=maxUsers/maxProjects
+// list of the domain limits, e.g. tenant-a=500/50,tenant-b=100/
+func ParseDomainLimits(value string) (map[string]DomainLimit, error) {
+	limits := map[string]DomainLimit{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		domain, values, ok := strings.Cut(item, "=")
+		users, projects, okValues := strings.Cut(values, "/")
+		if !ok || !okValues || strings.TrimSpace(domain) == "" {
+			return nil, fmt.Errorf("invalid domain limit %q, expected domain=maxUsers/maxProjects", item)
+		}
+		limit := DomainLimit{}
+		var err error
+		if users = strings.TrimSpace(users); users != "" {
+			limit.MaxUsers, err = strconv.Atoi(users)
+			if err != nil {
+				return nil, fmt.Errorf("invalid maximum number of users in domain limit %q: %w", item, err)
+			}
+		}
+		if projects = strings.TrimSpace(projects); projects != "" {
+			limit.MaxProjects, err = strconv.Atoi(projects)
+			if err != nil {
+				return nil, fmt.Errorf("invalid maximum number of projects in domain limit %q: %w", item, err)
+			}
+		}
+		limits[strings.TrimSpace(domain)] = limit
+	}
+	return limits, nil
+}
+
+// maxEndpointsPerService - returns the maximum number of endpoints of a
+// service, 0 if not limited
+func (l SafetyLimits) maxEndpointsPerService() int {
+	switch {
+	case l.MaxEndpointsPerService == 0:
+		return DefaultMaxEndpointsPerService
+	case l.MaxEndpointsPerService < 0:
+		return 0
+	}
+	return l.MaxEndpointsPerService
+}
+
+// maxUsersPerMinute - returns the maximum number of users created per minute,
+// 0 if not limited
+func (l SafetyLimits) maxUsersPerMinute() int {
+	switch {
+	case l.MaxUsersPerMinute == 0:
+		return DefaultMaxUsersPerMinute
+	case l.MaxUsersPerMinute < 0:
+		return 0
+	}
+	return l.MaxUsersPerMinute
+}
+
+// domainLimit - returns the limits of the domain, domain names are case
+// insensitive
+func (l SafetyLimits) domainLimit(domain string) DomainLimit {
+	for name, limit := range l.DomainLimits {
+		if strings.EqualFold(name, domain) {
+			return limit
+		}
+	}
+	return DomainLimit{}
+}
+
+// maxUsersPerDomain - returns the maximum number of users of the domain, 0
+// if not limited
+func (l SafetyLimits) maxUsersPerDomain(domain string) int {
+	limit := l.MaxUsersPerDomain
+	if override := l.domainLimit(domain).MaxUsers; override != 0 {
+		limit = override
+	}
+	switch {
+	case limit == 0:
+		return DefaultMaxUsersPerDomain
+	case limit < 0:
+		return 0
+	}
+	return limit
+}
+
+// maxProjectsPerDomain - returns the maximum number of projects of the
+// domain, 0 if not limited
+func (l SafetyLimits) maxProjectsPerDomain(domain string) int {
+	limit := l.MaxProjectsPerDomain
+	if override := l.domainLimit(domain).MaxProjects; override != 0 {
+		limit = override
+	}
+	switch {
+	case limit == 0:
+		return DefaultMaxProjectsPerDomain
+	case limit < 0:
+		return 0
+	}
+	return limit
+}
+
+// safetyLimitError - a safety limit prevented the creation of a keystone
+// object. The instance gets reported as degraded right away, retrying does
+// not help until the limit is no longer exceeded.
+type safetyLimitError struct {
+	msg string
+}
+
+func (e *safetyLimitError) Error() string {
+	return "safety limit exceeded: " + e.msg
+}
+
+// checkEndpointLimit - returns a safetyLimitError if the service already has
+// the maximum number of endpoints in the region
+func (l SafetyLimits) checkEndpointLimit(
+	log logr.Logger,
+	os catalogBackend,
+	serviceID string,
+	serviceName string,
+) error {
+	limit := l.maxEndpointsPerService()
+	if limit == 0 {
+		return nil
+	}
+	allEndpoints, err := os.GetEndpoints(log, serviceID, "")
+	if err != nil {
+		return err
+	}
+	if len(allEndpoints) >= limit {
+		return &safetyLimitError{msg: fmt.Sprintf(
+			"service %s has %d endpoints in region %s, the maximum is %d",
+			serviceName, len(allEndpoints), os.GetRegion(), limit)}
+	}
+	return nil
+}
+
+// checkDomainUserLimit - returns a safetyLimitError if the domain already
+// has the maximum number of users
+func (l SafetyLimits) checkDomainUserLimit(
+	log logr.Logger,
+	os identity.Client,
+	domainID string,
+	domain string,
+) error {
+	limit := l.maxUsersPerDomain(domain)
+	if limit == 0 {
+		return nil
+	}
+	domainUsers, err := identity.ListUsers(log, os, domainID)
+	if err != nil {
+		return err
+	}
+	return domainUserLimitError(domain, len(domainUsers), limit)
+}
+
+// domainUserLimitError - returns a safetyLimitError if count users reach the
+// limit of the domain
+func domainUserLimitError(domain string, count int, limit int) error {
+	if limit > 0 && count >= limit {
+		return &safetyLimitError{msg: fmt.Sprintf(
+			"domain %s has %d users, the maximum is %d", domain, count, limit)}
+	}
+	return nil
+}
+
+// checkDomainProjectLimit - returns a safetyLimitError if the domain already
+// has the maximum number of projects
+func (l SafetyLimits) checkDomainProjectLimit(
+	log logr.Logger,
+	os identity.Client,
+	domainID string,
+	domain string,
+) error {
+	limit := l.maxProjectsPerDomain(domain)
+	if limit == 0 {
+		return nil
+	}
+	domainProjects, err := identity.ListProjects(log, os, domainID)
+	if err != nil {
+		return err
+	}
+	if len(domainProjects) >= limit {
+		return &safetyLimitError{msg: fmt.Sprintf(
+			"domain %s has %d projects, the maximum is %d", domain, len(domainProjects), limit)}
+	}
+	return nil
+}
+
+// rateWindow - counts the events of the last minute. The zero value is ready
+// to use.
+type rateWindow struct {
+	mu     sync.Mutex
+	events []time.Time
+}
+
+// allow - records an event and returns nil if less than limit events got
+// recorded within the last minute, otherwise a safetyLimitError. A limit of 0
+// allows all events.
+func (w *rateWindow) allow(limit int, what string) error {
+	if limit == 0 {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	recent := w.events[:0]
+	for _, t := range w.events {
+		if now.Sub(t) < time.Minute {
+			recent = append(recent, t)
+		}
+	}
+	w.events = recent
+	if len(w.events) >= limit {
+		return &safetyLimitError{msg: fmt.Sprintf(
+			"%d %s created within the last minute, the maximum is %d", len(w.events), what, limit)}
+	}
+	w.events = append(w.events, now)
+	return nil
+}
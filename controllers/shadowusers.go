@@ -0,0 +1,195 @@
+/*
+   Copyright 2022.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/identity"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
+	configmap "github.com/openstack-k8s-operators/lib-common/modules/common/configmap"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// shadowUser - shadow user in the shadow users ConfigMap
+type shadowUser struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Enabled   bool        `json:"enabled"`
+	FirstSeen metav1.Time `json:"firstSeen"`
+}
+
+// reconcileShadowUsers - lists the shadow users of the identity provider in
+// Status.ShadowUsers and in a ConfigMap, with the time the operator first saw
+// them. Deletes the enabled users first seen more than PurgeAfterDays ago.
+// Disabled users are kept, keystone would create them enabled again on the
+// next login.
+func (r *KeystoneMappingReconciler) reconcileShadowUsers(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneMapping,
+	os openstackclient.OpenStackClient,
+) error {
+	Log := r.GetLogger(ctx)
+	cmName := keystone.ShadowUsersConfigMapName(instance.Name)
+	idpID := instance.Status.IdentityProviderID
+
+	if instance.Spec.ShadowUsers == nil || idpID == "" {
+		if instance.Status.ShadowUsers == nil {
+			return nil
+		}
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      cmName,
+				Namespace: instance.Namespace,
+			},
+		}
+		err := r.Client.Delete(ctx, cm)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return err
+		}
+		instance.Status.ShadowUsers = nil
+		return nil
+	}
+
+	now := metav1.Now()
+	report := instance.Status.ShadowUsers
+	if report != nil && report.IdentityProvider == idpID &&
+		now.Sub(report.CheckTime.Time) < keystone.ShadowUsersCheckInterval {
+		return nil
+	}
+
+	//
+	// get the times the users got first seen from the last report
+	//
+	firstSeen := map[string]metav1.Time{}
+	if report != nil && report.IdentityProvider == idpID {
+		cm := &corev1.ConfigMap{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: cmName, Namespace: instance.Namespace}, cm)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return err
+		}
+		if err == nil {
+			previous := []shadowUser{}
+			err = json.Unmarshal([]byte(cm.Data[keystone.ShadowUsersConfigMapKey]), &previous)
+			if err != nil {
+				// start over, the users count as first seen now
+				Log.Info(fmt.Sprintf("Ignoring the shadow users of ConfigMap %s: %s", cmName, err))
+			}
+			for _, user := range previous {
+				firstSeen[user.ID] = user.FirstSeen
+			}
+		}
+	}
+
+	users, err := identity.ListFederatedUsers(Log, os, idpID)
+	if err != nil {
+		return err
+	}
+
+	purgeAfter := time.Duration(instance.Spec.ShadowUsers.PurgeAfterDays) * 24 * time.Hour
+	purged := 0
+	entries := []shadowUser{}
+	for _, user := range users {
+		seen, ok := firstSeen[user.ID]
+		if !ok {
+			seen = now
+		}
+		if purgeAfter > 0 && user.Enabled && now.Sub(seen.Time) >= purgeAfter {
+			err = identity.DeleteUserByID(Log, os, user.ID)
+			if err != nil {
+				return err
+			}
+			purged++
+			continue
+		}
+		entries = append(entries, shadowUser{
+			ID:        user.ID,
+			Name:      user.Name,
+			Enabled:   user.Enabled,
+			FirstSeen: seen,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	if purged > 0 {
+		Log.Info(fmt.Sprintf("Purged %d shadow users of identity provider %s first seen more than %d days ago",
+			purged, idpID, instance.Spec.ShadowUsers.PurgeAfterDays))
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	cms := []util.Template{
+		{
+			Name:         cmName,
+			Namespace:    instance.Namespace,
+			Type:         util.TemplateTypeNone,
+			InstanceType: instance.Kind,
+			CustomData: map[string]string{
+				keystone.ShadowUsersConfigMapKey: string(data),
+			},
+			Labels: labels.GetLabels(instance, labels.GetGroupLabel(keystone.ServiceName), map[string]string{}),
+		},
+	}
+	err = configmap.EnsureConfigMaps(ctx, h, instance, cms, nil)
+	if err != nil {
+		return err
+	}
+
+	status := &keystonev1.ShadowUsersStatus{
+		IdentityProvider: idpID,
+		Count:            len(entries),
+		ConfigMap:        cmName,
+		CheckTime:        now,
+	}
+	for _, entry := range entries {
+		if !entry.Enabled {
+			status.DisabledCount++
+		}
+		if status.OldestFirstSeen == nil || entry.FirstSeen.Before(status.OldestFirstSeen) {
+			status.OldestFirstSeen = entry.FirstSeen.DeepCopy()
+		}
+	}
+	if purged > 0 {
+		status.PurgedCount = purged
+		status.LastPurgeTime = &now
+	} else if report != nil && report.IdentityProvider == idpID {
+		status.PurgedCount = report.PurgedCount
+		status.LastPurgeTime = report.LastPurgeTime
+	}
+	instance.Status.ShadowUsers = status
+
+	return nil
+}
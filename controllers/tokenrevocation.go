@@ -0,0 +1,148 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	keystone "github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// tokenRevocationRequeue - requeue interval while a token revocation waits
+// for the cached token validations to expire
+const tokenRevocationRequeue = 30 * time.Second
+
+// replaceFernetTokenKeys - replaces all fernet token keys when the
+// RevokeTokensAnnotation requests a new token revocation. Unlike a rotation
+// no previous key is kept, so keystone rejects all tokens issued before once
+// the pods use the new keys. The credential keys are kept, the credentials
+// encrypted with them would be lost otherwise.
+func (r *KeystoneAPIReconciler) replaceFernetTokenKeys(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) error {
+	Log := r.GetLogger(ctx)
+
+	request := instance.Annotations[keystonev1.RevokeTokensAnnotation]
+	if request == "" ||
+		(instance.Status.TokenRevocation != nil && instance.Status.TokenRevocation.Request == request) {
+		return nil
+	}
+
+	now := metav1.Now()
+	secret, _, err := oko_secret.GetSecret(ctx, h, keystone.ServiceName, instance.Namespace)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return err
+	}
+	// without the secret no tokens got issued yet, the keys get created
+	if err == nil {
+		for key := range secret.Data {
+			if strings.HasPrefix(key, "FernetKeys") {
+				secret.Data[key] = []byte(keystone.GenerateFernetKey(Log))
+			}
+		}
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[fernetRotatedAtAnnotation] = now.UTC().Format(time.RFC3339)
+		err = r.Client.Update(ctx, secret)
+		if err != nil {
+			return err
+		}
+	}
+
+	Log.Info("Replaced the fernet token keys to revoke all tokens", "annotation", keystonev1.RevokeTokensAnnotation, "request", request)
+	instance.Status.TokenRevocation = &keystonev1.TokenRevocationStatus{
+		Request:          request,
+		KeysReplacedTime: &now,
+	}
+
+	return nil
+}
+
+// reconcileTokenRevocation - reports the progress of the last token
+// revocation in the TokenRevocationReady condition. The revocation completed
+// once all keystone pods use the new keys and the token validations they
+// cached before expired.
+func (r *KeystoneAPIReconciler) reconcileTokenRevocation(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	fernetHash string,
+	serviceLabels map[string]string,
+) error {
+	revocation := instance.Status.TokenRevocation
+	if revocation == nil {
+		return nil
+	}
+
+	if revocation.CompletionTime == nil {
+		if revocation.KeysRolledOutTime == nil {
+			outdated, running, err := r.countOutdatedFernetPods(ctx, instance, fernetHash, serviceLabels)
+			if err != nil {
+				return err
+			}
+			if outdated > 0 || running == 0 {
+				instance.Status.Conditions.Set(condition.FalseCondition(
+					keystonev1.KeystoneTokenRevocationReadyCondition,
+					condition.RequestedReason,
+					condition.SeverityInfo,
+					keystonev1.KeystoneTokenRevocationPodsMessage,
+					keystonev1.RevokeTokensAnnotation,
+					revocation.Request,
+					outdated,
+					running))
+				return nil
+			}
+			revocation.KeysRolledOutTime = ptr.To(metav1.Now())
+		}
+
+		cacheTime := keystone.TokenCacheExpiration
+		if configured, ok := instance.Spec.GetTokenCacheTime(); ok {
+			cacheTime = configured
+		}
+		cacheExpiry := revocation.KeysRolledOutTime.Add(cacheTime)
+		if time.Now().Before(cacheExpiry) {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneTokenRevocationReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				keystonev1.KeystoneTokenRevocationCacheMessage,
+				keystonev1.RevokeTokensAnnotation,
+				revocation.Request,
+				cacheExpiry.UTC().Format(time.RFC3339)))
+			return nil
+		}
+		revocation.CompletionTime = ptr.To(metav1.Now())
+		r.GetLogger(ctx).Info("Token revocation completed", "request", revocation.Request)
+	}
+
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneTokenRevocationReadyCondition,
+		keystonev1.KeystoneTokenRevocationReadyMessage,
+		revocation.KeysReplacedTime.UTC().Format(time.RFC3339))
+
+	return nil
+}
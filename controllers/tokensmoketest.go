@@ -0,0 +1,182 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/identity"
+	keystone "github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	"github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// reconcileTokenSmokeTest - issues and validates a token with the smoke test
+// user once per interval and records the result in the status and the
+// metrics. Failed smoke tests do not fail the reconcile, the
+// TokenSmokeTestReady condition gets False once FailureThreshold of them
+// failed in a row.
+func (r *KeystoneAPIReconciler) reconcileTokenSmokeTest(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) error {
+	Log := r.GetLogger(ctx)
+	name := types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}
+
+	spec := instance.Spec.TokenSmokeTest
+	if spec == nil {
+		instance.Status.TokenSmokeTest = nil
+		delete(instance.Status.Hash, keystonev1.TokenSmokeTestHash)
+		deleteTokenSmokeTestMetrics(name)
+		return nil
+	}
+	if instance.Status.TokenSmokeTest == nil {
+		instance.Status.TokenSmokeTest = &keystonev1.TokenSmokeTestStatus{}
+	}
+	status := instance.Status.TokenSmokeTest
+
+	if status.LastRunTime == nil || time.Since(status.LastRunTime.Time) >= keystone.TokenSmokeTestInterval(spec) {
+		password, ctrlResult, err := r.ensureTokenSmokeTestUser(ctx, h, instance)
+		if err != nil {
+			return err
+		} else if (ctrlResult != ctrl.Result{}) {
+			// the admin client is not available yet, test on the next reconcile
+			return nil
+		}
+		authURL, tlsConfig, err := keystonev1.GetAdminAuthConfig(ctx, h, instance)
+		if err != nil {
+			return err
+		}
+
+		duration, err := identity.IssueAndValidateToken(openstack.AuthOpts{
+			AuthURL:    authURL,
+			Username:   keystone.TokenSmokeTestUser,
+			Password:   password,
+			DomainName: "Default",
+			TLS:        tlsConfig,
+		})
+		observeTokenSmokeTest(name, duration, err)
+		now := metav1.Now()
+		status.LastRunTime = &now
+		if err != nil {
+			status.ConsecutiveFailures++
+			status.LastError = keystonev1.KeystoneErrorMessage(err)
+			Log.Info("Token smoke test failed", "failures", status.ConsecutiveFailures, "error", status.LastError)
+		} else {
+			status.LastSuccessTime = &now
+			status.LastLatencyMilliseconds = duration.Milliseconds()
+			status.ConsecutiveFailures = 0
+			status.LastError = ""
+		}
+	}
+
+	// the conditions get initialized on every reconcile, set the condition
+	// from the recorded results
+	if status.ConsecutiveFailures >= keystone.TokenSmokeTestFailureThreshold(spec) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneTokenSmokeTestReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneTokenSmokeTestReadyErrorMessage,
+			status.ConsecutiveFailures,
+			status.LastError))
+	} else if status.LastSuccessTime != nil {
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneTokenSmokeTestReadyCondition,
+			keystonev1.KeystoneTokenSmokeTestReadyMessage,
+			status.LastLatencyMilliseconds)
+	}
+
+	return nil
+}
+
+// ensureTokenSmokeTestUser - creates the Secret with the password of the
+// smoke test user unless it exists and the user in the default domain.
+// The password of the user gets updated whenever the password in the Secret
+// changes. Returns the password.
+func (r *KeystoneAPIReconciler) ensureTokenSmokeTestUser(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) (string, ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	secretName := keystone.TokenSmokeTestSecretName(instance)
+
+	smokeTestSecret, _, err := oko_secret.GetSecret(ctx, h, secretName, instance.Namespace)
+	if k8s_errors.IsNotFound(err) {
+		tmpl := []util.Template{
+			{
+				Name:      secretName,
+				Namespace: instance.Namespace,
+				Type:      util.TemplateTypeNone,
+				CustomData: map[string]string{
+					"Password": keystone.GenerateFernetKey(Log),
+				},
+				Labels: labels.GetLabels(instance, labels.GetGroupLabel(keystone.ServiceName), map[string]string{}),
+			},
+		}
+		err = oko_secret.EnsureSecrets(ctx, h, instance, tmpl, nil)
+		if err != nil {
+			return "", ctrl.Result{}, err
+		}
+		smokeTestSecret, _, err = oko_secret.GetSecret(ctx, h, secretName, instance.Namespace)
+	}
+	if err != nil {
+		return "", ctrl.Result{}, err
+	}
+	password := string(smokeTestSecret.Data["Password"])
+	passwordHash, err := util.ObjectHash(password)
+	if err != nil {
+		return "", ctrl.Result{}, err
+	}
+
+	os, ctrlResult, err := getAdminClient(ctx, h, instance, r.OpenStackClient)
+	if err != nil || (ctrlResult != ctrl.Result{}) {
+		return "", ctrlResult, err
+	}
+	userID, err := os.CreateUser(
+		Log,
+		openstack.User{
+			Name:     keystone.TokenSmokeTestUser,
+			Password: password,
+			DomainID: "default",
+		})
+	if err != nil {
+		return "", ctrl.Result{}, err
+	}
+
+	// an existing user keeps its password, e.g. if the Secret got recreated
+	if instance.Status.Hash[keystonev1.TokenSmokeTestHash] != passwordHash {
+		err = identity.UpdateUserPassword(Log, os, userID, password)
+		if err != nil {
+			return "", ctrl.Result{}, err
+		}
+		instance.Status.Hash[keystonev1.TokenSmokeTestHash] = passwordHash
+	}
+
+	return password, ctrl.Result{}, nil
+}
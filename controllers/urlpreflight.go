@@ -0,0 +1,257 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	libtls "github.com/openstack-k8s-operators/lib-common/modules/common/tls"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// urlProbeTimeout - timeout of the GET request probing an endpoint URL
+const urlProbeTimeout = 5 * time.Second
+
+// probeURL - probes the URL with a GET request. Returns a non empty reason
+// if the URL does not respond or returns a server error. Any response with a
+// status code below 500 is considered ready.
+func probeURL(ctx context.Context, client *http.Client, url string) (string, error) {
+	probeCtx, cancel := context.WithTimeout(ctx, urlProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(probeCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Sprintf("probe of %s failed: %s", url, err.Error()), nil
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Sprintf("probe of %s returned %d", url, resp.StatusCode), nil
+	}
+	return "", nil
+}
+
+// urlPreflight - probes the new URL of a registered endpoint before it
+// replaces the current one in the catalog. A nil urlPreflight passes all
+// URLs, so callers do not need to check if the preflight is enabled.
+type urlPreflight struct {
+	client   *http.Client
+	failures []string
+}
+
+// probeClients - the HTTP clients probing the endpoint URLs, one per
+// KeystoneAPI. They get reused across reconciles to keep their connections,
+// a client gets replaced once the CA bundle of its KeystoneAPI changes. The
+// zero value is ready to use.
+type probeClients struct {
+	mu      sync.Mutex
+	clients map[types.NamespacedName]*probeClient
+}
+
+// probeClient - a probe client and the CA bundle it verifies with
+type probeClient struct {
+	caBundle string
+	client   *http.Client
+}
+
+// get - returns the probe client of the KeystoneAPI. The certificates of
+// https URLs get verified with the system CAs and the CA bundle of the
+// KeystoneAPI.
+func (c *probeClients) get(
+	ctx context.Context,
+	h *helper.Helper,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (*http.Client, error) {
+	caBundle := ""
+	if keystoneAPI.Spec.TLS.CaBundleSecretName != "" {
+		caCert, ctrlResult, err := secret.GetDataFromSecret(
+			ctx,
+			h,
+			keystoneAPI.Spec.TLS.CaBundleSecretName,
+			10*time.Second,
+			libtls.InternalCABundleKey)
+		if err != nil {
+			return nil, err
+		}
+		if (ctrlResult != ctrl.Result{}) {
+			return nil, fmt.Errorf("the CABundleSecret %s not found", keystoneAPI.Spec.TLS.CaBundleSecretName)
+		}
+		caBundle = caCert
+	}
+
+	name := types.NamespacedName{Name: keystoneAPI.Name, Namespace: keystoneAPI.Namespace}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.clients[name]
+	if ok && cached.caBundle == caBundle {
+		return cached.client, nil
+	}
+
+	client, err := newProbeClient(caBundle)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		cached.client.CloseIdleConnections()
+	}
+	if c.clients == nil {
+		c.clients = map[types.NamespacedName]*probeClient{}
+	}
+	c.clients[name] = &probeClient{caBundle: caBundle, client: client}
+	return client, nil
+}
+
+// newProbeClient - returns an HTTP client verifying the certificates with
+// the system CAs and the PEM encoded caBundle, if not empty
+func newProbeClient(caBundle string) (*http.Client, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if caBundle != "" && !pool.AppendCertsFromPEM([]byte(caBundle)) {
+		return nil, errors.New("invalid CA bundle")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// newURLPreflight - returns the preflight of the endpoint, nil if the
+// KeystoneEndpoint does not verify URL changes
+func newURLPreflight(
+	instance *keystonev1.KeystoneEndpoint,
+	client *http.Client,
+) *urlPreflight {
+	if !instance.Spec.VerifyURLChanges {
+		return nil
+	}
+	return &urlPreflight{client: client}
+}
+
+// check - returns true if the URL of the endpoint type can replace the
+// registered URL, otherwise records the failure
+func (p *urlPreflight) check(ctx context.Context, endpointType string, url string) (bool, error) {
+	if p == nil {
+		return true, nil
+	}
+	reason, err := probeURL(ctx, p.client, url)
+	if err != nil {
+		return false, err
+	}
+	if reason != "" {
+		p.failures = append(p.failures, fmt.Sprintf("%s: %s", endpointType, reason))
+		return false, nil
+	}
+	return true, nil
+}
+
+// urlRollback - restores the previous URL of endpoints whose new URL failed
+// the probe within the rollback window, or on request by the revert
+// annotation. The zero value does not roll back.
+type urlRollback struct {
+	// client - probes the new URLs, nil if the automatic rollback is disabled
+	client *http.Client
+	window time.Duration
+	// revert - restore the previous URLs requested by the revert annotation
+	revert bool
+	// rolledBack - the rolled back URLs
+	rolledBack []string
+}
+
+// newURLRollback - returns the rollback of the endpoint URL changes
+func newURLRollback(
+	instance *keystonev1.KeystoneEndpoint,
+	client *http.Client,
+) *urlRollback {
+	revertRequest := instance.Annotations[keystonev1.RevertEndpointsAnnotation]
+	rb := &urlRollback{
+		revert: revertRequest != "" && revertRequest != instance.Status.LastRevertRequest,
+	}
+	if instance.Spec.URLRollback != nil {
+		rb.client = client
+		rb.window = time.Duration(instance.Spec.URLRollback.WindowSeconds) * time.Second
+	}
+	return rb
+}
+
+// targetURL - returns the URL the endpoint should be registered with and
+// true if registering it rolls back the last URL change. The rollback state
+// of the endpoint status gets updated.
+func (rb *urlRollback) targetURL(
+	ctx context.Context,
+	endpointType string,
+	status *keystonev1.Endpoint,
+	registeredURL string,
+	specURL string,
+) (string, bool, error) {
+	if status.RolledBackURL != "" {
+		// do not register the rolled back URL again until the spec changes
+		if status.RolledBackURL == specURL {
+			rb.rolledBack = append(rb.rolledBack, fmt.Sprintf("%s: %s", endpointType, specURL))
+			return registeredURL, false, nil
+		}
+		status.RolledBackURL = ""
+	}
+	if status.PreviousURL == "" || status.PreviousURL == registeredURL {
+		return specURL, false, nil
+	}
+
+	rollback := rb.revert
+	if !rollback && rb.client != nil && status.URLChangeTime != nil &&
+		time.Since(status.URLChangeTime.Time) < rb.window {
+		reason, err := probeURL(ctx, rb.client, registeredURL)
+		if err != nil {
+			return "", false, err
+		}
+		rollback = reason != ""
+	}
+	if !rollback {
+		return specURL, false, nil
+	}
+	rb.rolledBack = append(rb.rolledBack, fmt.Sprintf("%s: %s", endpointType, registeredURL))
+	return status.PreviousURL, true, nil
+}
+
+// inWindow - returns true if the URL of one of the endpoints changed within
+// the rollback window and needs to be probed again
+func (rb *urlRollback) inWindow(endpoints []keystonev1.Endpoint) bool {
+	if rb.client == nil {
+		return false
+	}
+	for _, e := range endpoints {
+		if e.PreviousURL != "" && e.URLChangeTime != nil && time.Since(e.URLChangeTime.Time) < rb.window {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/identity"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	configmap "github.com/openstack-k8s-operators/lib-common/modules/common/configmap"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/endpoint"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// reconcileWebSSO - publishes the WebSSO login options of the enabled
+// identity providers in Status.WebSSO and in a ConfigMap, so dashboard
+// operators can configure their SSO logins. Only done if trusted dashboards
+// are configured, keystone does not redirect to other dashboards.
+func (r *KeystoneAPIReconciler) reconcileWebSSO(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *keystonev1.KeystoneAPI,
+) error {
+	Log := r.GetLogger(ctx)
+
+	if len(instance.Spec.FederationTrustedDashboards) == 0 {
+		if instance.Status.WebSSO == nil {
+			return nil
+		}
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      keystone.WebSSOConfigMapName(instance.Name),
+				Namespace: instance.Namespace,
+			},
+		}
+		err := r.Client.Delete(ctx, cm)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return err
+		}
+		instance.Status.WebSSO = nil
+		return nil
+	}
+
+	// the admin client needs a running keystone API
+	if !instance.Status.Conditions.IsTrue(condition.DeploymentReadyCondition) {
+		return nil
+	}
+
+	os, ctrlResult, err := getAdminClient(ctx, h, instance, r.OpenStackClient)
+	if err != nil || (ctrlResult != ctrl.Result{}) {
+		return err
+	}
+	publicURL, err := instance.GetEndpoint(endpoint.EndpointPublic)
+	if err != nil {
+		return err
+	}
+
+	idps, err := identity.ListIdentityProviders(Log, os)
+	if err != nil {
+		return err
+	}
+	sort.Slice(idps, func(i, j int) bool { return idps[i].ID < idps[j].ID })
+
+	options := []keystonev1.WebSSOOption{}
+	for _, idp := range idps {
+		if !idp.Enabled {
+			continue
+		}
+		protocols, err := identity.ListFederationProtocols(Log, os, idp.ID)
+		if err != nil {
+			return err
+		}
+		sort.Slice(protocols, func(i, j int) bool { return protocols[i].ID < protocols[j].ID })
+		for _, protocol := range protocols {
+			options = append(options, keystonev1.WebSSOOption{
+				IdentityProvider: idp.ID,
+				Protocol:         protocol.ID,
+				Description:      idp.Description,
+				URL:              keystone.WebSSOURL(publicURL, idp.ID, protocol.ID),
+			})
+		}
+	}
+
+	data, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	cms := []util.Template{
+		{
+			Name:         keystone.WebSSOConfigMapName(instance.Name),
+			Namespace:    instance.Namespace,
+			Type:         util.TemplateTypeNone,
+			InstanceType: instance.Kind,
+			CustomData: map[string]string{
+				keystone.WebSSOConfigMapKey: string(data),
+			},
+			Labels: labels.GetLabels(instance, labels.GetGroupLabel(keystone.ServiceName), map[string]string{}),
+		},
+	}
+	err = configmap.EnsureConfigMaps(ctx, h, instance, cms, nil)
+	if err != nil {
+		return err
+	}
+
+	instance.Status.WebSSO = &keystonev1.WebSSOStatus{
+		Options:   options,
+		ConfigMap: keystone.WebSSOConfigMapName(instance.Name),
+	}
+
+	return nil
+}
@@ -19,9 +19,16 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"expvar"
 	"flag"
+	"net/http"
 	"os"
 	"strings"
+	"time"
+
+	// Embed the time zone database, the webhook validates the time zone of
+	// the KeystoneAPI in images without one.
+	_ "time/tzdata"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -32,6 +39,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -40,6 +48,7 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	networkv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	routev1 "github.com/openshift/api/route/v1"
 	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
 	rabbitmqv1 "github.com/openstack-k8s-operators/infra-operator/apis/rabbitmq/v1beta1"
 	topologyv1 "github.com/openstack-k8s-operators/infra-operator/apis/topology/v1beta1"
@@ -64,22 +73,66 @@ func init() {
 	utilruntime.Must(memcachedv1.AddToScheme(scheme))
 	utilruntime.Must(networkv1.AddToScheme(scheme))
 	utilruntime.Must(topologyv1.AddToScheme(scheme))
+	utilruntime.Must(routev1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var leaderElectionNamespace string
+	var leaseDuration time.Duration
+	var renewDeadline time.Duration
+	var retryPeriod time.Duration
+	var gracefulShutdownTimeout time.Duration
+	var watchNamespaces string
 	var probeAddr string
 	var pprofBindAddress string
+	var enableExpvar bool
 	var enableHTTP2 bool
+	var requeue controllers.RequeueIntervals
+	var limits controllers.SafetyLimits
+	var domainLimits string
 	flag.BoolVar(&enableHTTP2, "enable-http2", enableHTTP2, "If HTTP/2 should be enabled for the metrics and webhook servers.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.StringVar(&pprofBindAddress, "pprof-bind-address", "", "The address the pprof endpoint binds to. Set to empty to disable pprof.")
+	flag.BoolVar(&enableExpvar, "enable-expvar", false,
+		"If the expvar variables, e.g. memstats, should be exposed at /debug/vars of the metrics endpoint.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&leaderElectionNamespace, "leader-elect-namespace", "",
+		"Namespace of the leader election lease. Defaults to the namespace the manager runs in.")
+	flag.DurationVar(&leaseDuration, "leader-elect-lease-duration", 0,
+		"Duration non-leader replicas wait before taking over the leadership. Overrides LEASE_DURATION.")
+	flag.DurationVar(&renewDeadline, "leader-elect-renew-deadline", 0,
+		"Duration the leader retries refreshing the leadership before giving it up. Overrides RENEW_DEADLINE.")
+	flag.DurationVar(&retryPeriod, "leader-elect-retry-period", 0,
+		"Duration the replicas wait between leader election attempts. Overrides RETRY_PERIOD.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", os.Getenv("WATCH_NAMESPACE"),
+		"Comma separated list of namespaces the operator watches. Defaults to WATCH_NAMESPACE, "+
+			"all namespaces are watched if empty.")
+	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", 8*time.Second,
+		"Duration in-flight reconciles get to finish on shutdown before the leadership is released. "+
+			"Keep it below the terminationGracePeriodSeconds of the manager pod.")
+	flag.DurationVar(&requeue.KeystoneAPI, "keystoneapi-requeue-interval", controllers.DefaultKeystoneAPIRequeue,
+		"Interval the controllers requeue with while waiting for the KeystoneAPI to exist and get ready.")
+	flag.DurationVar(&requeue.KeystoneService, "keystoneservice-requeue-interval", controllers.DefaultKeystoneServiceRequeue,
+		"Interval the controllers requeue with while waiting for a KeystoneService to exist and get ready.")
+	flag.IntVar(&limits.MaxEndpointsPerService, "max-endpoints-per-service", controllers.DefaultMaxEndpointsPerService,
+		"Maximum number of endpoints of a service in a region the operator creates endpoints up to. "+
+			"A negative value disables the limit.")
+	flag.IntVar(&limits.MaxUsersPerMinute, "max-users-per-minute", controllers.DefaultMaxUsersPerMinute,
+		"Maximum number of users the KeystoneService and KeystoneUserBatch controllers each create per minute. "+
+			"A negative value disables the limit.")
+	flag.IntVar(&limits.MaxUsersPerDomain, "max-users-per-domain", controllers.DefaultMaxUsersPerDomain,
+		"Maximum number of users of a domain the operator creates users up to. A negative value disables the limit.")
+	flag.IntVar(&limits.MaxProjectsPerDomain, "max-projects-per-domain", controllers.DefaultMaxProjectsPerDomain,
+		"Maximum number of projects of a domain the operator creates projects up to. A negative value disables the limit.")
+	flag.StringVar(&domainLimits, "domain-limits", "",
+		"Comma separated domain=maxUsers/maxProjects list overriding the limits of single domains, "+
+			"e.g. tenant-a=500/50,tenant-b=100/.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -88,6 +141,13 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	var err error
+	limits.DomainLimits, err = controllers.ParseDomainLimits(domainLimits)
+	if err != nil {
+		setupLog.Error(err, "invalid domain limits")
+		os.Exit(1)
+	}
+
 	disableHTTP2 := func(c *tls.Config) {
 		if enableHTTP2 {
 			return
@@ -100,10 +160,15 @@ func main() {
 		Metrics: metricsserver.Options{
 			BindAddress: metricsAddr,
 		},
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "6012128b.openstack.org",
-		PprofBindAddress:       pprofBindAddress,
+		HealthProbeBindAddress:  probeAddr,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionID:        "6012128b.openstack.org",
+		LeaderElectionNamespace: leaderElectionNamespace,
+		// release the lease on shutdown, after in-flight reconciles finished,
+		// so that another replica takes over without waiting for it to expire
+		LeaderElectionReleaseOnCancel: true,
+		GracefulShutdownTimeout:       &gracefulShutdownTimeout,
+		PprofBindAddress:              pprofBindAddress,
 		WebhookServer: webhook.NewServer(
 			webhook.Options{
 				Port:    9443,
@@ -111,11 +176,33 @@ func main() {
 			}),
 	}
 
-	err := operator.SetManagerOptions(&options, setupLog)
+	if enableExpvar {
+		options.Metrics.ExtraHandlers = map[string]http.Handler{
+			"/debug/vars": expvar.Handler(),
+		}
+	}
+
+	err = operator.SetManagerOptions(&options, setupLog)
 	if err != nil {
 		setupLog.Error(err, "unable to set manager options")
 		os.Exit(1)
 	}
+	if namespaces := getWatchNamespaces(watchNamespaces); len(namespaces) > 0 {
+		setupLog.Info("manager configured to watch namespaces", "namespaces", namespaces)
+		options.Cache.DefaultNamespaces = map[string]cache.Config{}
+		for _, ns := range namespaces {
+			options.Cache.DefaultNamespaces[ns] = cache.Config{}
+		}
+	}
+	if leaseDuration != 0 {
+		options.LeaseDuration = &leaseDuration
+	}
+	if renewDeadline != 0 {
+		options.RenewDeadline = &renewDeadline
+	}
+	if retryPeriod != 0 {
+		options.RetryPeriod = &retryPeriod
+	}
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
 	if err != nil {
@@ -138,6 +225,7 @@ func main() {
 		Client:  mgr.GetClient(),
 		Scheme:  mgr.GetScheme(),
 		Kclient: kclient,
+		Config:  cfg,
 	}).SetupWithManager(context.Background(), mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KeystoneAPI")
 		os.Exit(1)
@@ -147,6 +235,8 @@ func main() {
 		Client:  mgr.GetClient(),
 		Scheme:  mgr.GetScheme(),
 		Kclient: kclient,
+		Requeue: requeue,
+		Limits:  limits,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KeystoneService")
 		os.Exit(1)
@@ -156,11 +246,111 @@ func main() {
 		Client:  mgr.GetClient(),
 		Scheme:  mgr.GetScheme(),
 		Kclient: kclient,
+		Requeue: requeue,
+		Limits:  limits,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KeystoneEndpoint")
 		os.Exit(1)
 	}
 
+	if err = (&controllers.KeystoneEndpointGroupReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+		Requeue: requeue,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneEndpointGroup")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.KeystoneCatalogAuditReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+		Requeue: requeue,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneCatalogAudit")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.KeystoneRegisteredLimitReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+		Requeue: requeue,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneRegisteredLimit")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.KeystoneLimitReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+		Requeue: requeue,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneLimit")
+		os.Exit(1)
+	}
+	if err = (&controllers.KeystoneEC2CredentialReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+		Requeue: requeue,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneEC2Credential")
+		os.Exit(1)
+	}
+	if err = (&controllers.KeystoneUserReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+		Requeue: requeue,
+		Limits:  limits,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneUser")
+		os.Exit(1)
+	}
+	if err = (&controllers.KeystoneMappingReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+		Requeue: requeue,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneMapping")
+		os.Exit(1)
+	}
+	if err = (&controllers.KeystoneUserBatchReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+		Requeue: requeue,
+		Limits:  limits,
+	}).SetupWithManager(context.Background(), mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneUserBatch")
+		os.Exit(1)
+	}
+	if err = (&controllers.KeystoneCredentialReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+		Requeue: requeue,
+	}).SetupWithManager(context.Background(), mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneCredential")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.KeystoneCatalogReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+		Requeue: requeue,
+		Limits:  limits,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneCatalog")
+		os.Exit(1)
+	}
+
 	// Acquire environmental defaults and initialize operator defaults with them
 	keystonev1.SetupDefaults()
 
@@ -192,3 +382,16 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// getWatchNamespaces - returns the namespaces of the comma separated list,
+// an empty list means all namespaces get watched
+func getWatchNamespaces(watchNamespaces string) []string {
+	namespaces := []string{}
+	for _, ns := range strings.Split(watchNamespaces, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
@@ -0,0 +1,102 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/credentials"
+)
+
+// Credential - generic keystone credential
+type Credential struct {
+	Type      string
+	UserID    string
+	ProjectID string
+	Blob      string
+}
+
+// CreateCredential - creates the credential and returns its ID
+func CreateCredential(
+	log logr.Logger,
+	os Client,
+	c Credential,
+) (string, error) {
+	log.Info(fmt.Sprintf("Creating %s credential for user %s", c.Type, c.UserID))
+
+	cred, err := credentials.Create(os.GetOSClient(), credentials.CreateOpts{
+		Type:      c.Type,
+		UserID:    c.UserID,
+		ProjectID: c.ProjectID,
+		Blob:      c.Blob,
+	}).Extract()
+	if err != nil {
+		return "", err
+	}
+
+	return cred.ID, nil
+}
+
+// GetCredential - returns the credential with the ID or nil if it does not
+// exist
+func GetCredential(
+	log logr.Logger,
+	os Client,
+	id string,
+) (*credentials.Credential, error) {
+	cred, err := credentials.Get(os.GetOSClient(), id).Extract()
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return cred, nil
+}
+
+// UpdateCredential - updates the blob of the credential
+func UpdateCredential(
+	log logr.Logger,
+	os Client,
+	id string,
+	blob string,
+) error {
+	log.Info(fmt.Sprintf("Updating credential %s", id))
+
+	_, err := credentials.Update(os.GetOSClient(), id, credentials.UpdateOpts{
+		Blob: blob,
+	}).Extract()
+
+	return err
+}
+
+// DeleteCredential - deletes the credential with the ID, it is ok to call
+// delete on a non existing credential
+func DeleteCredential(
+	log logr.Logger,
+	os Client,
+	id string,
+) error {
+	log.Info(fmt.Sprintf("Deleting credential %s", id))
+	err := credentials.Delete(os.GetOSClient(), id).ExtractErr()
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
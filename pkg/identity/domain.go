@@ -0,0 +1,65 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/domains"
+)
+
+// GetDomainByID - returns the domain with the ID or nil if it does not exist
+func GetDomainByID(
+	log logr.Logger,
+	os Client,
+	id string,
+) (*domains.Domain, error) {
+	domain, err := domains.Get(os.GetOSClient(), id).Extract()
+	if IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return domain, nil
+}
+
+// DeleteDomain - disables and deletes the domain, keystone deletes its
+// projects, users and groups with it. It is ok to call delete on a non
+// existing domain.
+func DeleteDomain(
+	log logr.Logger,
+	os Client,
+	id string,
+) error {
+	log.Info(fmt.Sprintf("Deleting domain %s", id))
+	enabled := false
+	_, err := domains.Update(os.GetOSClient(), id, domains.UpdateOpts{
+		Enabled: &enabled,
+	}).Extract()
+	if IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	err = domains.Delete(os.GetOSClient(), id).ExtractErr()
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/extensions/ec2credentials"
+)
+
+// CreateEC2Credential - creates an EC2 credential for the user scoped to the
+// project
+func CreateEC2Credential(
+	log logr.Logger,
+	os Client,
+	userID string,
+	projectID string,
+) (*ec2credentials.Credential, error) {
+	log.Info(fmt.Sprintf("Creating EC2 credential for user %s in project %s", userID, projectID))
+
+	return ec2credentials.Create(os.GetOSClient(), userID, ec2credentials.CreateOpts{
+		TenantID: projectID,
+	}).Extract()
+}
+
+// GetEC2Credential - returns the EC2 credential of the user with the access
+// or nil if it does not exist
+func GetEC2Credential(
+	log logr.Logger,
+	os Client,
+	userID string,
+	access string,
+) (*ec2credentials.Credential, error) {
+	cred, err := ec2credentials.Get(os.GetOSClient(), userID, access).Extract()
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return cred, nil
+}
+
+// DeleteEC2Credential - deletes the EC2 credential of the user with the
+// access, it is ok to call delete on a non existing credential
+func DeleteEC2Credential(
+	log logr.Logger,
+	os Client,
+	userID string,
+	access string,
+) error {
+	log.Info(fmt.Sprintf("Deleting EC2 credential %s of user %s", access, userID))
+	err := ec2credentials.Delete(os.GetOSClient(), userID, access).ExtractErr()
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package identity contains calls to the keystone identity API which are not
+// provided by lib-common/modules/openstack
+package identity
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud"
+)
+
+// EndpointGroup - Holds the parameters of an OS-EP-FILTER endpoint group
+type EndpointGroup struct {
+	ID          string            `json:"id,omitempty"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Filters     map[string]string `json:"filters"`
+}
+
+// Client - provides the gophercloud client of the identity API, e.g. the
+// openstack.OpenStack helper
+type Client interface {
+	GetOSClient() *gophercloud.ServiceClient
+}
+
+// IsNotFound - returns true if err is a 404 response of the identity API
+func IsNotFound(err error) bool {
+	var notFound gophercloud.ErrDefault404
+	return errors.As(err, &notFound)
+}
+
+// GetEndpointGroup - returns the endpoint group with the name or nil if it
+// does not exist
+func GetEndpointGroup(
+	log logr.Logger,
+	os Client,
+	name string,
+) (*EndpointGroup, error) {
+	var resp struct {
+		EndpointGroups []EndpointGroup `json:"endpoint_groups"`
+	}
+	u := os.GetOSClient().ServiceURL("OS-EP-FILTER", "endpoint_groups") + "?name=" + url.QueryEscape(name)
+	_, err := os.GetOSClient().Get(u, &resp, &gophercloud.RequestOpts{OkCodes: []int{200}})
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(resp.EndpointGroups) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &resp.EndpointGroups[0], nil
+	default:
+		return nil, fmt.Errorf("multiple endpoint groups named \"%s\" found", name)
+	}
+}
+
+// CreateOrUpdateEndpointGroup - creates the endpoint group if it does not
+// exist, otherwise updates its description and filters. Returns the ID of the
+// endpoint group.
+func CreateOrUpdateEndpointGroup(
+	log logr.Logger,
+	os Client,
+	g EndpointGroup,
+) (string, error) {
+	current, err := GetEndpointGroup(log, os, g.Name)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		EndpointGroup EndpointGroup `json:"endpoint_group"`
+	}
+	body := map[string]interface{}{
+		"endpoint_group": map[string]interface{}{
+			"name":        g.Name,
+			"description": g.Description,
+			"filters":     g.Filters,
+		},
+	}
+
+	if current == nil {
+		log.Info(fmt.Sprintf("Creating endpoint group %s", g.Name))
+		_, err = os.GetOSClient().Post(
+			os.GetOSClient().ServiceURL("OS-EP-FILTER", "endpoint_groups"),
+			body, &resp, &gophercloud.RequestOpts{OkCodes: []int{201}})
+		if err != nil {
+			return "", err
+		}
+		return resp.EndpointGroup.ID, nil
+	}
+
+	if current.Description == g.Description && equalFilters(current.Filters, g.Filters) {
+		return current.ID, nil
+	}
+
+	log.Info(fmt.Sprintf("Updating endpoint group %s", g.Name))
+	_, err = os.GetOSClient().Patch(
+		os.GetOSClient().ServiceURL("OS-EP-FILTER", "endpoint_groups", current.ID),
+		body, &resp, &gophercloud.RequestOpts{OkCodes: []int{200}})
+	if err != nil {
+		return "", err
+	}
+
+	return current.ID, nil
+}
+
+// DeleteEndpointGroup - deletes the endpoint group with the ID, it is ok to
+// call delete on a non existing endpoint group
+func DeleteEndpointGroup(
+	log logr.Logger,
+	os Client,
+	id string,
+) error {
+	log.Info(fmt.Sprintf("Deleting endpoint group %s", id))
+	_, err := os.GetOSClient().Delete(
+		os.GetOSClient().ServiceURL("OS-EP-FILTER", "endpoint_groups", id),
+		&gophercloud.RequestOpts{OkCodes: []int{204}})
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// AddEndpointGroupToProject - associates the endpoint group with the project
+func AddEndpointGroupToProject(
+	log logr.Logger,
+	os Client,
+	endpointGroupID string,
+	projectID string,
+) error {
+	_, err := os.GetOSClient().Put(
+		os.GetOSClient().ServiceURL("OS-EP-FILTER", "endpoint_groups", endpointGroupID, "projects", projectID),
+		nil, nil, &gophercloud.RequestOpts{OkCodes: []int{204}})
+
+	return err
+}
+
+// RemoveEndpointGroupFromProject - removes the association of the endpoint
+// group with the project, it is ok to call it for a non existing association
+func RemoveEndpointGroupFromProject(
+	log logr.Logger,
+	os Client,
+	endpointGroupID string,
+	projectID string,
+) error {
+	_, err := os.GetOSClient().Delete(
+		os.GetOSClient().ServiceURL("OS-EP-FILTER", "endpoint_groups", endpointGroupID, "projects", projectID),
+		&gophercloud.RequestOpts{OkCodes: []int{204}})
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+func equalFilters(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,319 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud"
+)
+
+// IdentityProvider - federated identity provider of keystone
+type IdentityProvider struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	DomainID    string   `json:"domain_id"`
+	Enabled     bool     `json:"enabled"`
+	RemoteIDs   []string `json:"remote_ids"`
+}
+
+// FederationProtocol - protocol of an identity provider, e.g. openid or
+// saml2, with the mapping of its assertions
+type FederationProtocol struct {
+	ID        string `json:"id"`
+	MappingID string `json:"mapping_id"`
+}
+
+// Mapping - mapping of the assertions of an identity provider to local users,
+// groups, projects and roles
+type Mapping struct {
+	ID    string          `json:"id"`
+	Rules json.RawMessage `json:"rules"`
+}
+
+// GetMapping - returns the mapping with the ID or nil if it does not exist
+func GetMapping(
+	log logr.Logger,
+	os Client,
+	id string,
+) (*Mapping, error) {
+	var resp struct {
+		Mapping Mapping `json:"mapping"`
+	}
+	_, err := os.GetOSClient().Get(
+		os.GetOSClient().ServiceURL("OS-FEDERATION", "mappings", id),
+		&resp, &gophercloud.RequestOpts{OkCodes: []int{200}})
+	if IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &resp.Mapping, nil
+}
+
+// CreateMapping - creates the mapping with the ID and rules
+func CreateMapping(
+	log logr.Logger,
+	os Client,
+	id string,
+	rules json.RawMessage,
+) error {
+	log.Info(fmt.Sprintf("Creating mapping %s", id))
+	_, err := os.GetOSClient().Put(
+		os.GetOSClient().ServiceURL("OS-FEDERATION", "mappings", id),
+		map[string]interface{}{"mapping": map[string]interface{}{"rules": rules}},
+		nil, &gophercloud.RequestOpts{OkCodes: []int{201}})
+
+	return err
+}
+
+// UpdateMapping - replaces the rules of the mapping with the ID
+func UpdateMapping(
+	log logr.Logger,
+	os Client,
+	id string,
+	rules json.RawMessage,
+) error {
+	log.Info(fmt.Sprintf("Updating mapping %s", id))
+	_, err := os.GetOSClient().Patch(
+		os.GetOSClient().ServiceURL("OS-FEDERATION", "mappings", id),
+		map[string]interface{}{"mapping": map[string]interface{}{"rules": rules}},
+		nil, &gophercloud.RequestOpts{OkCodes: []int{200}})
+
+	return err
+}
+
+// DeleteMapping - deletes the mapping with the ID, it is ok to call delete on
+// a non existing mapping
+func DeleteMapping(
+	log logr.Logger,
+	os Client,
+	id string,
+) error {
+	log.Info(fmt.Sprintf("Deleting mapping %s", id))
+	_, err := os.GetOSClient().Delete(
+		os.GetOSClient().ServiceURL("OS-FEDERATION", "mappings", id),
+		&gophercloud.RequestOpts{OkCodes: []int{204}})
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// GetIdentityProvider - returns the identity provider with the ID or nil if
+// it does not exist
+func GetIdentityProvider(
+	log logr.Logger,
+	os Client,
+	id string,
+) (*IdentityProvider, error) {
+	var resp struct {
+		IdentityProvider IdentityProvider `json:"identity_provider"`
+	}
+	_, err := os.GetOSClient().Get(
+		os.GetOSClient().ServiceURL("OS-FEDERATION", "identity_providers", id),
+		&resp, &gophercloud.RequestOpts{OkCodes: []int{200}})
+	if IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &resp.IdentityProvider, nil
+}
+
+// CreateIdentityProvider - creates the identity provider, keystone creates a
+// domain for its users if the DomainID is empty. Returns the created identity
+// provider.
+func CreateIdentityProvider(
+	log logr.Logger,
+	os Client,
+	idp IdentityProvider,
+) (*IdentityProvider, error) {
+	log.Info(fmt.Sprintf("Creating identity provider %s", idp.ID))
+	body := map[string]interface{}{
+		"description": idp.Description,
+		"enabled":     idp.Enabled,
+		"remote_ids":  idp.RemoteIDs,
+	}
+	if idp.DomainID != "" {
+		body["domain_id"] = idp.DomainID
+	}
+	var resp struct {
+		IdentityProvider IdentityProvider `json:"identity_provider"`
+	}
+	_, err := os.GetOSClient().Put(
+		os.GetOSClient().ServiceURL("OS-FEDERATION", "identity_providers", idp.ID),
+		map[string]interface{}{"identity_provider": body},
+		&resp, &gophercloud.RequestOpts{OkCodes: []int{201}})
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.IdentityProvider, nil
+}
+
+// UpdateIdentityProvider - updates the description, enabled state and remote
+// IDs of the identity provider, the domain can not be changed
+func UpdateIdentityProvider(
+	log logr.Logger,
+	os Client,
+	idp IdentityProvider,
+) error {
+	log.Info(fmt.Sprintf("Updating identity provider %s", idp.ID))
+	_, err := os.GetOSClient().Patch(
+		os.GetOSClient().ServiceURL("OS-FEDERATION", "identity_providers", idp.ID),
+		map[string]interface{}{"identity_provider": map[string]interface{}{
+			"description": idp.Description,
+			"enabled":     idp.Enabled,
+			"remote_ids":  idp.RemoteIDs,
+		}},
+		nil, &gophercloud.RequestOpts{OkCodes: []int{200}})
+
+	return err
+}
+
+// DeleteIdentityProvider - deletes the identity provider with the ID, keystone
+// deletes its protocols and federated users with it. It is ok to call delete
+// on a non existing identity provider.
+func DeleteIdentityProvider(
+	log logr.Logger,
+	os Client,
+	id string,
+) error {
+	log.Info(fmt.Sprintf("Deleting identity provider %s", id))
+	_, err := os.GetOSClient().Delete(
+		os.GetOSClient().ServiceURL("OS-FEDERATION", "identity_providers", id),
+		&gophercloud.RequestOpts{OkCodes: []int{204}})
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// GetFederationProtocol - returns the protocol of the identity provider or
+// nil if it does not exist
+func GetFederationProtocol(
+	log logr.Logger,
+	os Client,
+	idpID string,
+	protocolID string,
+) (*FederationProtocol, error) {
+	var resp struct {
+		Protocol FederationProtocol `json:"protocol"`
+	}
+	_, err := os.GetOSClient().Get(
+		os.GetOSClient().ServiceURL("OS-FEDERATION", "identity_providers", idpID, "protocols", protocolID),
+		&resp, &gophercloud.RequestOpts{OkCodes: []int{200}})
+	if IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &resp.Protocol, nil
+}
+
+// CreateOrUpdateFederationProtocol - creates the protocol of the identity
+// provider using the mapping, or updates the mapping of an existing protocol
+func CreateOrUpdateFederationProtocol(
+	log logr.Logger,
+	os Client,
+	idpID string,
+	protocolID string,
+	mappingID string,
+) error {
+	current, err := GetFederationProtocol(log, os, idpID, protocolID)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{"protocol": map[string]interface{}{"mapping_id": mappingID}}
+	url := os.GetOSClient().ServiceURL("OS-FEDERATION", "identity_providers", idpID, "protocols", protocolID)
+
+	if current == nil {
+		log.Info(fmt.Sprintf("Creating protocol %s of identity provider %s", protocolID, idpID))
+		_, err = os.GetOSClient().Put(url, body, nil, &gophercloud.RequestOpts{OkCodes: []int{201}})
+		return err
+	}
+	if current.MappingID == mappingID {
+		return nil
+	}
+	log.Info(fmt.Sprintf("Updating protocol %s of identity provider %s", protocolID, idpID))
+	_, err = os.GetOSClient().Patch(url, body, nil, &gophercloud.RequestOpts{OkCodes: []int{200}})
+
+	return err
+}
+
+// DeleteFederationProtocol - deletes the protocol of the identity provider,
+// it is ok to call delete on a non existing protocol
+func DeleteFederationProtocol(
+	log logr.Logger,
+	os Client,
+	idpID string,
+	protocolID string,
+) error {
+	log.Info(fmt.Sprintf("Deleting protocol %s of identity provider %s", protocolID, idpID))
+	_, err := os.GetOSClient().Delete(
+		os.GetOSClient().ServiceURL("OS-FEDERATION", "identity_providers", idpID, "protocols", protocolID),
+		&gophercloud.RequestOpts{OkCodes: []int{204}})
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// ListIdentityProviders - returns the federated identity providers
+func ListIdentityProviders(
+	log logr.Logger,
+	os Client,
+) ([]IdentityProvider, error) {
+	var resp struct {
+		IdentityProviders []IdentityProvider `json:"identity_providers"`
+	}
+	_, err := os.GetOSClient().Get(
+		os.GetOSClient().ServiceURL("OS-FEDERATION", "identity_providers"),
+		&resp, &gophercloud.RequestOpts{OkCodes: []int{200}})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.IdentityProviders, nil
+}
+
+// ListFederationProtocols - returns the protocols of the identity provider
+func ListFederationProtocols(
+	log logr.Logger,
+	os Client,
+	idpID string,
+) ([]FederationProtocol, error) {
+	var resp struct {
+		Protocols []FederationProtocol `json:"protocols"`
+	}
+	_, err := os.GetOSClient().Get(
+		os.GetOSClient().ServiceURL("OS-FEDERATION", "identity_providers", idpID, "protocols"),
+		&resp, &gophercloud.RequestOpts{OkCodes: []int{200}})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Protocols, nil
+}
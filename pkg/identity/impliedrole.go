@@ -0,0 +1,67 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud"
+)
+
+// EnsureImpliedRole - creates the implied role relationship between the
+// prior role and the implied role if it does not exist
+func EnsureImpliedRole(
+	log logr.Logger,
+	os Client,
+	priorRoleID string,
+	impliedRoleID string,
+) error {
+	u := os.GetOSClient().ServiceURL("roles", priorRoleID, "implies", impliedRoleID)
+
+	_, err := os.GetOSClient().Get(u, nil, &gophercloud.RequestOpts{OkCodes: []int{200}})
+	if err == nil {
+		return nil
+	}
+	if !IsNotFound(err) {
+		return err
+	}
+
+	log.Info(fmt.Sprintf("Creating implied role %s -> %s", priorRoleID, impliedRoleID))
+	_, err = os.GetOSClient().Put(u, nil, nil, &gophercloud.RequestOpts{OkCodes: []int{201}})
+
+	return err
+}
+
+// DeleteImpliedRole - deletes the implied role relationship between the prior
+// role and the implied role, it is ok to call delete on a non existing
+// relationship
+func DeleteImpliedRole(
+	log logr.Logger,
+	os Client,
+	priorRoleID string,
+	impliedRoleID string,
+) error {
+	log.Info(fmt.Sprintf("Deleting implied role %s -> %s", priorRoleID, impliedRoleID))
+	_, err := os.GetOSClient().Delete(
+		os.GetOSClient().ServiceURL("roles", priorRoleID, "implies", impliedRoleID),
+		&gophercloud.RequestOpts{OkCodes: []int{204}})
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/limits"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/registeredlimits"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+)
+
+// CreateOrUpdateRegisteredLimit - creates the registered limit for the
+// service, region and resource if it does not exist, otherwise updates its
+// default limit and description. Returns the ID of the registered limit.
+func CreateOrUpdateRegisteredLimit(
+	log logr.Logger,
+	os Client,
+	l openstack.RegisteredLimit,
+) (string, error) {
+	allPages, err := registeredlimits.List(os.GetOSClient(), registeredlimits.ListOpts{
+		ServiceID:    l.ServiceID,
+		ResourceName: l.ResourceName,
+		RegionID:     l.RegionID,
+	}).AllPages()
+	if err != nil {
+		return "", err
+	}
+	allLimits, err := registeredlimits.ExtractRegisteredLimits(allPages)
+	if err != nil {
+		return "", err
+	}
+
+	// the list filters match a registered limit without region for any region
+	var current *registeredlimits.RegisteredLimit
+	for i := range allLimits {
+		if allLimits[i].RegionID == l.RegionID {
+			current = &allLimits[i]
+			break
+		}
+	}
+
+	if current == nil {
+		log.Info(fmt.Sprintf("Creating registered limit %s", l.ResourceName))
+		created, err := registeredlimits.BatchCreate(os.GetOSClient(), registeredlimits.BatchCreateOpts{
+			registeredlimits.CreateOpts{
+				ResourceName: l.ResourceName,
+				Description:  l.Description,
+				DefaultLimit: l.DefaultLimit,
+				ServiceID:    l.ServiceID,
+				RegionID:     l.RegionID,
+			},
+		}).Extract()
+		if err != nil {
+			return "", err
+		}
+		return created[0].ID, nil
+	}
+
+	if current.DefaultLimit != l.DefaultLimit || current.Description != l.Description {
+		log.Info(fmt.Sprintf("Updating registered limit %s", l.ResourceName))
+		_, err = registeredlimits.Update(os.GetOSClient(), current.ID, registeredlimits.UpdateOpts{
+			DefaultLimit: &l.DefaultLimit,
+			Description:  &l.Description,
+		}).Extract()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return current.ID, nil
+}
+
+// DeleteRegisteredLimit - deletes the registered limit with the ID, it is ok
+// to call delete on a non existing registered limit
+func DeleteRegisteredLimit(
+	log logr.Logger,
+	os Client,
+	id string,
+) error {
+	log.Info(fmt.Sprintf("Deleting registered limit %s", id))
+	err := registeredlimits.Delete(os.GetOSClient(), id).ExtractErr()
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// CreateOrUpdateLimit - creates the limit for the service, region, resource
+// and project or domain if it does not exist, otherwise updates its resource
+// limit and description. Returns the ID of the limit.
+func CreateOrUpdateLimit(
+	log logr.Logger,
+	os Client,
+	l openstack.Limit,
+) (string, error) {
+	allPages, err := limits.List(os.GetOSClient(), limits.ListOpts{
+		ServiceID:    l.ServiceID,
+		ResourceName: l.ResourceName,
+		RegionID:     l.RegionID,
+		ProjectID:    l.ProjectID,
+		DomainID:     l.DomainID,
+	}).AllPages()
+	if err != nil {
+		return "", err
+	}
+	allLimits, err := limits.ExtractLimits(allPages)
+	if err != nil {
+		return "", err
+	}
+
+	var current *limits.Limit
+	for i := range allLimits {
+		if allLimits[i].RegionID == l.RegionID &&
+			allLimits[i].ProjectID == l.ProjectID &&
+			allLimits[i].DomainID == l.DomainID {
+			current = &allLimits[i]
+			break
+		}
+	}
+
+	if current == nil {
+		log.Info(fmt.Sprintf("Creating limit %s", l.ResourceName))
+		created, err := limits.BatchCreate(os.GetOSClient(), limits.BatchCreateOpts{
+			limits.CreateOpts{
+				ResourceName:  l.ResourceName,
+				Description:   l.Description,
+				ResourceLimit: l.ResourceLimit,
+				ServiceID:     l.ServiceID,
+				ProjectID:     l.ProjectID,
+				DomainID:      l.DomainID,
+				RegionID:      l.RegionID,
+			},
+		}).Extract()
+		if err != nil {
+			return "", err
+		}
+		return created[0].ID, nil
+	}
+
+	if current.ResourceLimit != l.ResourceLimit || current.Description != l.Description {
+		log.Info(fmt.Sprintf("Updating limit %s", l.ResourceName))
+		_, err = limits.Update(os.GetOSClient(), current.ID, limits.UpdateOpts{
+			ResourceLimit: &l.ResourceLimit,
+			Description:   &l.Description,
+		}).Extract()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return current.ID, nil
+}
+
+// DeleteLimit - deletes the limit with the ID, it is ok to call delete on a
+// non existing limit
+func DeleteLimit(
+	log logr.Logger,
+	os Client,
+	id string,
+) error {
+	log.Info(fmt.Sprintf("Deleting limit %s", id))
+	err := limits.Delete(os.GetOSClient(), id).ExtractErr()
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
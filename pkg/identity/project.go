@@ -0,0 +1,60 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/projects"
+)
+
+// ListProjects - returns the projects of the domain
+func ListProjects(
+	log logr.Logger,
+	os Client,
+	domainID string,
+) ([]projects.Project, error) {
+	allPages, err := projects.List(os.GetOSClient(), projects.ListOpts{
+		DomainID: domainID,
+	}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+
+	return projects.ExtractProjects(allPages)
+}
+
+// DeleteProjectUserRole - unassigns the role of the user on the project, it
+// is ok to call delete on a non existing assignment
+func DeleteProjectUserRole(
+	log logr.Logger,
+	os Client,
+	userID string,
+	projectID string,
+	roleID string,
+) error {
+	log.Info(fmt.Sprintf("Unassigning userID %s from role %s on project %s", userID, roleID, projectID))
+	_, err := os.GetOSClient().Delete(
+		os.GetOSClient().ServiceURL("projects", projectID, "users", userID, "roles", roleID),
+		&gophercloud.RequestOpts{OkCodes: []int{204}})
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
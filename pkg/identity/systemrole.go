@@ -0,0 +1,66 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud"
+)
+
+// EnsureSystemUserRole - assigns the role to the user on the system scope if
+// it is not assigned yet
+func EnsureSystemUserRole(
+	log logr.Logger,
+	os Client,
+	userID string,
+	roleID string,
+) error {
+	u := os.GetOSClient().ServiceURL("system", "users", userID, "roles", roleID)
+
+	_, err := os.GetOSClient().Head(u, &gophercloud.RequestOpts{OkCodes: []int{204}})
+	if err == nil {
+		return nil
+	}
+	if !IsNotFound(err) {
+		return err
+	}
+
+	log.Info(fmt.Sprintf("Assigning userID %s to system role %s", userID, roleID))
+	_, err = os.GetOSClient().Put(u, nil, nil, &gophercloud.RequestOpts{OkCodes: []int{204}})
+
+	return err
+}
+
+// DeleteSystemUserRole - unassigns the role of the user on the system scope,
+// it is ok to call delete on a non existing assignment
+func DeleteSystemUserRole(
+	log logr.Logger,
+	os Client,
+	userID string,
+	roleID string,
+) error {
+	log.Info(fmt.Sprintf("Unassigning userID %s from system role %s", userID, roleID))
+	_, err := os.GetOSClient().Delete(
+		os.GetOSClient().ServiceURL("system", "users", userID, "roles", roleID),
+		&gophercloud.RequestOpts{OkCodes: []int{204}})
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud"
+	gophercloud_openstack "github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/tokens"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/users"
+	"github.com/openstack-k8s-operators/lib-common/modules/openstack"
+)
+
+// IssueAndValidateToken - issues a token with the credentials of authOpts
+// and validates it with the token itself, returns the time both took. The
+// token is unscoped if authOpts has no project or scope.
+func IssueAndValidateToken(authOpts openstack.AuthOpts) (time.Duration, error) {
+	start := time.Now()
+	provider, err := openstack.GetOpenStackProvider(authOpts)
+	if err != nil {
+		return 0, err
+	}
+
+	// an unscoped token has no catalog, the identity API is the auth URL
+	identityClient, err := gophercloud_openstack.NewIdentityV3(provider, gophercloud.EndpointOpts{})
+	if err != nil {
+		return 0, err
+	}
+	valid, err := tokens.Validate(identityClient, provider.Token())
+	if err != nil {
+		return 0, err
+	}
+	if !valid {
+		return 0, fmt.Errorf("token issued for user %s is not valid", authOpts.Username)
+	}
+
+	return time.Since(start), nil
+}
+
+// UpdateUserPassword - sets the password of the user
+func UpdateUserPassword(
+	log logr.Logger,
+	os Client,
+	userID string,
+	password string,
+) error {
+	log.Info(fmt.Sprintf("Updating the password of user %s", userID))
+	_, err := users.Update(os.GetOSClient(), userID, users.UpdateOpts{
+		Password: password,
+	}).Extract()
+
+	return err
+}
@@ -0,0 +1,137 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/users"
+)
+
+// SetUserEnabled - enables or disables the user, a disabled user can not
+// authenticate and its tokens fail validation
+func SetUserEnabled(
+	log logr.Logger,
+	os Client,
+	userID string,
+	enabled bool,
+) error {
+	log.Info(fmt.Sprintf("Setting enabled of user %s to %t", userID, enabled))
+	_, err := users.Update(os.GetOSClient(), userID, users.UpdateOpts{
+		Enabled: &enabled,
+	}).Extract()
+
+	return err
+}
+
+// SetUserOptions - sets the resource options of the user, options not
+// passed keep their value
+func SetUserOptions(
+	log logr.Logger,
+	os Client,
+	userID string,
+	options map[users.Option]interface{},
+) error {
+	log.Info(fmt.Sprintf("Setting options of user %s", userID))
+	_, err := users.Update(os.GetOSClient(), userID, users.UpdateOpts{
+		Options: options,
+	}).Extract()
+
+	return err
+}
+
+// ListUsers - returns the users of the domain
+func ListUsers(
+	log logr.Logger,
+	os Client,
+	domainID string,
+) ([]users.User, error) {
+	allPages, err := users.List(os.GetOSClient(), users.ListOpts{
+		DomainID: domainID,
+	}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+
+	return users.ExtractUsers(allPages)
+}
+
+// ListFederatedUsers - returns the shadow users keystone created for the
+// federated users of the identity provider
+func ListFederatedUsers(
+	log logr.Logger,
+	os Client,
+	idpID string,
+) ([]users.User, error) {
+	allPages, err := users.List(os.GetOSClient(), users.ListOpts{
+		IdPID: idpID,
+	}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+
+	return users.ExtractUsers(allPages)
+}
+
+// CreateUserWithExtra - creates the user with the extra attributes, e.g. the
+// ownership markers of the operator. Returns the ID of the user.
+func CreateUserWithExtra(
+	log logr.Logger,
+	os Client,
+	opts users.CreateOpts,
+) (string, error) {
+	log.Info(fmt.Sprintf("Creating user %s in domain %s", opts.Name, opts.DomainID))
+	user, err := users.Create(os.GetOSClient(), opts).Extract()
+	if err != nil {
+		return "", err
+	}
+
+	return user.ID, nil
+}
+
+// DeleteUserByID - deletes the user, it is ok to call delete on a non
+// existing user
+func DeleteUserByID(
+	log logr.Logger,
+	os Client,
+	userID string,
+) error {
+	log.Info(fmt.Sprintf("Deleting user %s", userID))
+	err := users.Delete(os.GetOSClient(), userID).ExtractErr()
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// GetUserByID - returns the user with the ID or nil if it does not exist
+func GetUserByID(
+	log logr.Logger,
+	os Client,
+	userID string,
+) (*users.User, error) {
+	user, err := users.Get(os.GetOSClient(), userID).Extract()
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
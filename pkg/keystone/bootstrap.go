@@ -48,6 +48,12 @@ func BootstrapJob(
 	envVars["OS_BOOTSTRAP_PROJECT_NAME"] = env.SetValue(instance.Spec.AdminProject)
 	envVars["OS_BOOTSTRAP_SERVICE_NAME"] = env.SetValue(ServiceName)
 	envVars["OS_BOOTSTRAP_REGION_ID"] = env.SetValue(instance.Spec.Region)
+	setTimeZone(envVars, instance)
+
+	// changes the job hash, which re-runs the job for every new request
+	if request, ok := instance.Annotations[keystonev1.RebootstrapAnnotation]; ok {
+		envVars["KEYSTONE_REBOOTSTRAP_REQUEST"] = env.SetValue(request)
+	}
 
 	if _, ok := endpoints["admin"]; ok {
 		envVars["OS_BOOTSTRAP_ADMIN_URL"] = env.SetValue(endpoints["admin"])
@@ -64,6 +70,12 @@ func BootstrapJob(
 	volumes := getVolumes(instance, bootstrapExtraMounts, BootstrapPropagation)
 	volumeMounts := getVolumeMounts(bootstrapExtraMounts, BootstrapPropagation)
 
+	// the bootstrap loads the audit log config of the keystone API
+	if instance.Spec.AuditLog != nil {
+		volumes = append(volumes, getAuditLogVolumes(instance)...)
+		volumeMounts = append(volumeMounts, getAuditLogVolumeMounts()...)
+	}
+
 	// add CA cert if defined
 	if instance.Spec.TLS.CaBundleSecretName != "" {
 		volumes = append(volumes, instance.Spec.TLS.CreateVolume())
@@ -84,6 +96,7 @@ func BootstrapJob(
 				Spec: corev1.PodSpec{
 					RestartPolicy:      corev1.RestartPolicyOnFailure,
 					ServiceAccountName: instance.RbacResourceName(),
+					ImagePullSecrets:   instance.Spec.ImagePullSecrets,
 					Containers: []corev1.Container{
 						{
 							Name:  ServiceName + "-bootstrap",
@@ -116,11 +129,15 @@ func BootstrapJob(
 			},
 		},
 	}
-	job.Spec.Template.Spec.Containers[0].Env = env.MergeEnvs(job.Spec.Template.Spec.Containers[0].Env, envVars)
+	job.Spec.Template.Spec.Containers[0].Env = withExtraEnv(
+		env.MergeEnvs(job.Spec.Template.Spec.Containers[0].Env, envVars), instance)
 
 	if instance.Spec.NodeSelector != nil {
 		job.Spec.Template.Spec.NodeSelector = *instance.Spec.NodeSelector
 	}
 
+	applyPodSettings(&job.Spec.Template.Spec, instance)
+	applyJobSettings(&job.Spec, instance.Spec.JobSettings)
+
 	return job
 }
@@ -0,0 +1,41 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	"fmt"
+)
+
+const (
+	// ConfigFileName - key of the rendered keystone config in the config
+	// data Secret
+	ConfigFileName = "keystone.conf"
+	// ConfigPreviewRenderedKey - key of the rendered keystone config in the
+	// config preview Secret
+	ConfigPreviewRenderedKey = "keystone.conf"
+	// ConfigPreviewAppliedKey - key of the keystone config the pods last got
+	// rolled out with in the config preview Secret
+	ConfigPreviewAppliedKey = "keystone.conf.applied"
+	// ConfigPreviewDiffKey - key of the diff of the applied and the rendered
+	// keystone config in the config preview Secret
+	ConfigPreviewDiffKey = "keystone.conf.diff"
+)
+
+// ConfigPreviewSecretName - returns the name of the Secret with the rendered
+// and the applied keystone config of the KeystoneAPI
+func ConfigPreviewSecretName(instanceName string) string {
+	return fmt.Sprintf("%s-config-preview", instanceName)
+}
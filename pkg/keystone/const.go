@@ -16,12 +16,16 @@ limitations under the License.
 package keystone
 
 import (
+	"time"
+
 	"github.com/openstack-k8s-operators/lib-common/modules/storage"
 )
 
 const (
 	// ServiceName -
 	ServiceName = "keystone"
+	// ServiceType - type of the keystone service in the catalog
+	ServiceType = "identity"
 	// DatabaseName -
 	DatabaseName = "keystone"
 	// DatabaseCRName -
@@ -32,15 +36,43 @@ const (
 	KeystonePublicPort int32 = 5000
 	// KeystoneInternalPort -
 	KeystoneInternalPort int32 = 5000
+	// KeystoneTokenlessPort - port of the vhost verifying client certificates
+	// for tokenless authorization
+	KeystoneTokenlessPort int32 = 5001
 	// KeystoneUID is based on kolla
 	// https://github.com/openstack/kolla/blob/master/kolla/common/users.py
 	KeystoneUID int64 = 42425
+	// HealthcheckPath - path of the oslo.middleware healthcheck of the keystone API
+	HealthcheckPath = "/healthcheck"
 	// DefaultFernetMaxActiveKeys -
 	DefaultFernetMaxActiveKeys = 5
 	// DefaultFernetRotationDays -
 	DefaultFernetRotationDays = 1
+	// TokenCacheExpiration - default [cache] expiration_time of keystone,
+	// cached token validations are valid until it passed
+	TokenCacheExpiration = 600 * time.Second
+	// AdminRole - role keystone-manage bootstrap assigns to the admin user
+	AdminRole = "admin"
+	// MemberRole - role implied by AdminRole
+	MemberRole = "member"
+	// ReaderRole - role implied by MemberRole
+	ReaderRole = "reader"
 	// DBSyncCommand -
 	DBSyncCommand = "keystone-manage db_sync"
+	// DBSyncConfigFileName - config of db sync with the connection of the
+	// DatabaseSyncAccount, read after keystone.conf
+	DBSyncConfigFileName = "db-sync.conf"
+	// DBSyncAccountCommand - db sync with the DatabaseSyncAccount
+	DBSyncAccountCommand = "keystone-manage --config-file /etc/keystone/keystone.conf --config-file /etc/keystone/" +
+		DBSyncConfigFileName + " db_sync"
+	// DBGrantsCommand - sets the privileges of the DatabaseAccount as the
+	// root user of the galera cluster
+	DBGrantsCommand = `mysql -h "${DATABASE_HOST}" -u root -e "` +
+		`REVOKE ALL PRIVILEGES ON ` + DatabaseName + `.* FROM '${DATABASE_USER}'@'%'; ` +
+		`GRANT ${DATABASE_PRIVILEGES} ON ` + DatabaseName + `.* TO '${DATABASE_USER}'@'%';"`
+	// DBPrivileges - privileges of the DatabaseAccount with a
+	// DatabaseSyncAccount, keystone does not change the schema itself
+	DBPrivileges = "SELECT, INSERT, UPDATE, DELETE"
 	// Keystone is the global ServiceType
 	Keystone storage.PropagationType = "Keystone"
 	// KeystoneCronJob is the CronJob ServiceType
@@ -51,6 +83,40 @@ const (
 	FederationConfigKey = "federation-config.json"
 	// FederationMultiRealmSecret - secret to store processed multirealm data
 	FederationMultiRealmSecret = "keystone-multirealm-federation-secret"
+	// DefaultAccessLogFormat - httpd access log format following the client
+	// address, includes the global and the local request ID
+	DefaultAccessLogFormat = `%l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i" global_request_id=%{X-OpenStack-Request-ID}i request_id=%{X-OpenStack-Request-ID}o`
+	// SSOCallbackTemplateFileName - file name of the WebSSO callback template
+	SSOCallbackTemplateFileName = "sso_callback_template.html"
+	// ModSecurityRulesFileName - file name of the custom mod_security rules
+	ModSecurityRulesFileName = "modsecurity_rules.conf"
+	// KerberosKeytabFileName - file name of the keytab of the HTTP service
+	// principals
+	KerberosKeytabFileName = "keystone.keytab"
+	// Krb5ConfFileName - file name of the Kerberos client configuration
+	Krb5ConfFileName = "krb5.conf"
+	// TokenlessCAFileName - file name of the CA bundle verifying the client
+	// certificates of tokenless authorization
+	TokenlessCAFileName = "tokenless-ca.pem"
+	// AuditLogDir - directory of the audit log in the keystone API pods
+	AuditLogDir = "/var/log/keystone-audit"
+	// AuditLogFile - audit log the CADF events get written to
+	AuditLogFile = AuditLogDir + "/audit.log"
+	// NotificationTopic - default topic of the notifications on RabbitMQ
+	NotificationTopic = "barbican_notifications"
+	// NotificationRoutingConfig - routing config of the notifications
+	NotificationRoutingConfig = "/etc/keystone/notification_routing.yaml"
+	// BlueGreenLabel - pod label identifying the deployment of a blue/green setup
+	BlueGreenLabel = "keystone.openstack.org/color"
+	// FernetKeysHashAnnotation - pod annotation with the hash of the fernet
+	// keys secret the pod got created with
+	FernetKeysHashAnnotation = "keystone.openstack.org/fernet-keys-hash"
+	// UWSGIMasterFifo - master FIFO of uWSGI, used to gracefully reload and
+	// stop it
+	UWSGIMasterFifo = "/var/lib/keystone/uwsgi.fifo"
+	// EndpointLabel - pod label identifying the endpoint the pods serve if the
+	// public endpoint runs in a separate deployment
+	EndpointLabel = "keystone.openstack.org/endpoint"
 	// FederationDefaultMountPath - if user doesn't specify otherwise, this location is used
 	FederationDefaultMountPath = "/etc/httpd/conf"
 )
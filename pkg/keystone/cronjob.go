@@ -16,6 +16,8 @@ limitations under the License.
 package keystone
 
 import (
+	"fmt"
+
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/env"
 
@@ -27,6 +29,9 @@ import (
 const (
 	// TrustFlushCommand -
 	TrustFlushCommand = "keystone-manage trust_flush"
+	// TrustFlushDateFormat - format of the trust_flush --date argument for
+	// the date command
+	TrustFlushDateFormat = "+%d-%m-%Y %H:%M:%S"
 )
 
 // CronJob func
@@ -36,10 +41,17 @@ func CronJob(
 	annotations map[string]string,
 ) *batchv1.CronJob {
 
-	args := []string{"-c", TrustFlushCommand + instance.Spec.TrustFlushArgs}
+	command := TrustFlushCommand
+	if instance.Spec.TrustFlushAge > 0 {
+		command += fmt.Sprintf(
+			" --date \"$(date -u -d '-%d days' '%s')\"",
+			instance.Spec.TrustFlushAge, TrustFlushDateFormat)
+	}
+	args := []string{"-c", command + instance.Spec.TrustFlushArgs}
 
 	envVars := map[string]env.Setter{}
 	envVars["KOLLA_CONFIG_STRATEGY"] = env.SetValue("COPY_ALWAYS")
+	setTimeZone(envVars, instance)
 
 	parallelism := int32(1)
 	completions := int32(1)
@@ -55,15 +67,20 @@ func CronJob(
 		volumeMounts = append(getCronJobVolumeMounts(), instance.Spec.TLS.CreateVolumeMounts(nil)...)
 	}
 
+	// the jobs have no access to the SQLite database of dev mode
+	suspend := instance.Spec.TrustFlushSuspend || instance.Spec.DevMode
+
 	cronjob := &batchv1.CronJob{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      ServiceName + "-cron",
 			Namespace: instance.Namespace,
 		},
 		Spec: batchv1.CronJobSpec{
-			Schedule:          instance.Spec.TrustFlushSchedule,
-			Suspend:           &instance.Spec.TrustFlushSuspend,
-			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			Schedule:                   instance.Spec.TrustFlushSchedule,
+			Suspend:                    &suspend,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: instance.Spec.TrustFlushSuccessfulJobsHistoryLimit,
+			FailedJobsHistoryLimit:     instance.Spec.TrustFlushFailedJobsHistoryLimit,
 			JobTemplate: batchv1.JobTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Annotations: annotations,
@@ -82,7 +99,7 @@ func CronJob(
 										"/bin/bash",
 									},
 									Args:            args,
-									Env:             env.MergeEnvs([]corev1.EnvVar{}, envVars),
+									Env:             withExtraEnv(env.MergeEnvs([]corev1.EnvVar{}, envVars), instance),
 									VolumeMounts:    volumeMounts,
 									SecurityContext: baseSecurityContext(),
 								},
@@ -90,14 +107,21 @@ func CronJob(
 							Volumes:            volumes,
 							RestartPolicy:      corev1.RestartPolicyNever,
 							ServiceAccountName: instance.RbacResourceName(),
+							ImagePullSecrets:   instance.Spec.ImagePullSecrets,
 						},
 					},
 				},
 			},
 		},
 	}
+	if instance.Spec.TimeZone != "" {
+		cronjob.Spec.TimeZone = &instance.Spec.TimeZone
+	}
 	if instance.Spec.NodeSelector != nil {
 		cronjob.Spec.JobTemplate.Spec.Template.Spec.NodeSelector = *instance.Spec.NodeSelector
 	}
+
+	applyPodSettings(&cronjob.Spec.JobTemplate.Spec.Template.Spec, instance)
+	applyJobSettings(&cronjob.Spec.JobTemplate.Spec, instance.Spec.JobSettings)
 	return cronjob
 }
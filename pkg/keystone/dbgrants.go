@@ -0,0 +1,151 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+
+	"github.com/openstack-k8s-operators/lib-common/modules/common/env"
+	mariadbv1 "github.com/openstack-k8s-operators/mariadb-operator/api/v1beta1"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// DBGrantsJob - job setting the privileges of the user of the
+// DatabaseAccount on the keystone database, as the root user of the galera
+// cluster with its client. With a DatabaseSyncAccount the user may only read
+// and write rows, otherwise it gets all privileges back. The accountHash of
+// the MariaDBAccount makes the job run again once the mariadb-operator
+// granted all privileges again.
+func DBGrantsJob(
+	instance *keystonev1.KeystoneAPI,
+	galera *mariadbv1.Galera,
+	userName string,
+	accountHash string,
+	labels map[string]string,
+	annotations map[string]string,
+) *batchv1.Job {
+
+	args := []string{"-c", DBGrantsCommand}
+
+	privileges := "ALL PRIVILEGES"
+	if UsesDBSyncAccount(instance) {
+		privileges = DBPrivileges
+	}
+
+	envVars := map[string]env.Setter{}
+	envVars["DATABASE_HOST"] = env.SetValue(instance.Status.DatabaseHostname)
+	envVars["DATABASE_USER"] = env.SetValue(userName)
+	envVars["DATABASE_PRIVILEGES"] = env.SetValue(privileges)
+	envVars["DATABASE_ACCOUNT_HASH"] = env.SetValue(accountHash)
+	envs := env.MergeEnvs([]corev1.EnvVar{}, envVars)
+	envs = append(envs, corev1.EnvVar{
+		Name: "MYSQL_PWD",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: galera.Spec.Secret,
+				},
+				Key: mariadbv1.DbRootPasswordSelector,
+			},
+		},
+	})
+
+	// the client reads the TLS settings of the connection from my.cnf
+	volumes := []corev1.Volume{
+		{
+			Name: "config-data",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: instance.Name + "-config-data",
+					Items: []corev1.KeyToPath{
+						{
+							Key:  "my.cnf",
+							Path: "my.cnf",
+						},
+					},
+				},
+			},
+		},
+	}
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      "config-data",
+			MountPath: "/etc/my.cnf",
+			SubPath:   "my.cnf",
+			ReadOnly:  true,
+		},
+	}
+
+	// add CA cert if defined
+	if instance.Spec.TLS.CaBundleSecretName != "" {
+		volumes = append(volumes, instance.Spec.TLS.CreateVolume())
+		volumeMounts = append(volumeMounts, instance.Spec.TLS.CreateVolumeMounts(nil)...)
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ServiceName + "-db-grants",
+			Namespace: instance.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyOnFailure,
+					ServiceAccountName: instance.RbacResourceName(),
+					ImagePullSecrets:   instance.Spec.ImagePullSecrets,
+					Containers: []corev1.Container{
+						{
+							Name: ServiceName + "-db-grants",
+							Command: []string{
+								"/bin/bash",
+							},
+							Args:  args,
+							Image: galera.Spec.ContainerImage,
+							SecurityContext: &corev1.SecurityContext{
+								AllowPrivilegeEscalation: ptr.To(false),
+								Capabilities: &corev1.Capabilities{
+									Drop: []corev1.Capability{
+										"ALL",
+									},
+								},
+							},
+							Env:          envs,
+							VolumeMounts: volumeMounts,
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+
+	if instance.Spec.NodeSelector != nil {
+		job.Spec.Template.Spec.NodeSelector = *instance.Spec.NodeSelector
+	}
+
+	applyPodSettings(&job.Spec.Template.Spec, instance)
+	applyJobSettings(&job.Spec, instance.Spec.JobSettings)
+
+	return job
+}
@@ -37,12 +37,35 @@ func DbSyncJob(
 	envVars := map[string]env.Setter{}
 	envVars["KOLLA_CONFIG_STRATEGY"] = env.SetValue("COPY_ALWAYS")
 	envVars["KOLLA_BOOTSTRAP"] = env.SetValue("true")
+	setTimeZone(envVars, instance)
 
 	// create Volume and VolumeMounts
 	dbSyncExtraMounts := []keystonev1.KeystoneExtraMounts{}
 	volumes := getVolumes(instance, dbSyncExtraMounts, DBSyncPropagation)
 	volumeMounts := getDBSyncVolumeMounts()
 
+	// db sync changes the schema with the DatabaseSyncAccount, its
+	// connection is only readable by the db sync job
+	if UsesDBSyncAccount(instance) {
+		var config0640AccessMode int32 = 0644
+		args = []string{"-c", DBSyncAccountCommand}
+		volumes = append(volumes, corev1.Volume{
+			Name: "db-sync-config",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					DefaultMode: &config0640AccessMode,
+					SecretName:  DBSyncConfigSecretName(instance),
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "db-sync-config",
+			MountPath: "/etc/keystone/" + DBSyncConfigFileName,
+			SubPath:   DBSyncConfigFileName,
+			ReadOnly:  true,
+		})
+	}
+
 	// add CA cert if defined
 	if instance.Spec.TLS.CaBundleSecretName != "" {
 		volumes = append(volumes, instance.Spec.TLS.CreateVolume())
@@ -63,6 +86,7 @@ func DbSyncJob(
 				Spec: corev1.PodSpec{
 					RestartPolicy:      corev1.RestartPolicyOnFailure,
 					ServiceAccountName: instance.RbacResourceName(),
+					ImagePullSecrets:   instance.Spec.ImagePullSecrets,
 					Containers: []corev1.Container{
 						{
 							Name: ServiceName + "-db-sync",
@@ -72,7 +96,7 @@ func DbSyncJob(
 							Args:            args,
 							Image:           instance.Spec.ContainerImage,
 							SecurityContext: dbSyncSecurityContext(),
-							Env:             env.MergeEnvs([]corev1.EnvVar{}, envVars),
+							Env:             withExtraEnv(env.MergeEnvs([]corev1.EnvVar{}, envVars), instance),
 							VolumeMounts:    volumeMounts,
 						},
 					},
@@ -86,5 +110,19 @@ func DbSyncJob(
 		job.Spec.Template.Spec.NodeSelector = *instance.Spec.NodeSelector
 	}
 
+	applyPodSettings(&job.Spec.Template.Spec, instance)
+	applyJobSettings(&job.Spec, instance.Spec.JobSettings)
+
 	return job
 }
+
+// UsesDBSyncAccount - returns true if db sync uses the DatabaseSyncAccount,
+// it is only supported with MariaDB
+func UsesDBSyncAccount(instance *keystonev1.KeystoneAPI) bool {
+	return instance.Spec.DatabaseSyncAccount != "" && !instance.Spec.DevMode && instance.Spec.PostgreSQL == nil
+}
+
+// DBSyncConfigSecretName - name of the Secret with the db sync config
+func DBSyncConfigSecretName(instance *keystonev1.KeystoneAPI) string {
+	return instance.Name + "-db-sync-config"
+}
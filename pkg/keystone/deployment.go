@@ -16,6 +16,8 @@ limitations under the License.
 package keystone
 
 import (
+	"fmt"
+
 	topologyv1 "github.com/openstack-k8s-operators/infra-operator/apis/topology/v1beta1"
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
 	common "github.com/openstack-k8s-operators/lib-common/modules/common"
@@ -23,6 +25,8 @@ import (
 	"github.com/openstack-k8s-operators/lib-common/modules/common/env"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	"golang.org/x/exp/slices"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -63,12 +67,14 @@ func Deployment(
 	//
 	// https://kubernetes.io/docs/tasks/configure-pod-container/configure-liveness-readiness-startup-probes/
 	//
+	// liveness stays on /v3, with the disable_by_file healthcheck backend
+	// a disabled pod has to go out of the service, but not get restarted
 	livenessProbe.HTTPGet = &corev1.HTTPGetAction{
 		Path: "/v3",
 		Port: intstr.IntOrString{Type: intstr.Int, IntVal: int32(KeystonePublicPort)},
 	}
 	readinessProbe.HTTPGet = &corev1.HTTPGetAction{
-		Path: "/v3",
+		Path: HealthcheckPath,
 		Port: intstr.IntOrString{Type: intstr.Int, IntVal: int32(KeystonePublicPort)},
 	}
 
@@ -80,6 +86,7 @@ func Deployment(
 	envVars := map[string]env.Setter{}
 	envVars["KOLLA_CONFIG_STRATEGY"] = env.SetValue("COPY_ALWAYS")
 	envVars["CONFIG_HASH"] = env.SetValue(configHash)
+	setTimeZone(envVars, instance)
 
 	// create Volume and VolumeMounts
 	volumes := getVolumes(instance, instance.Spec.ExtraMounts, KeystonePropagation)
@@ -97,6 +104,16 @@ func Deployment(
 		volumeMounts = append(volumeMounts, getFederationVolumeMounts(instance.Spec.FederationMountPath, federationFilenames)...)
 	}
 
+	if instance.Spec.AuditLog != nil {
+		volumes = append(volumes, getAuditLogVolumes(instance)...)
+		volumeMounts = append(volumeMounts, getAuditLogVolumeMounts()...)
+	}
+
+	if instance.Spec.DevMode {
+		volumes = append(volumes, getDevModeVolume())
+		volumeMounts = append(volumeMounts, getDevModeVolumeMount())
+	}
+
 	for _, endpt := range []service.Endpoint{service.EndpointInternal, service.EndpointPublic} {
 		if instance.Spec.TLS.API.Enabled(endpt) {
 			var tlsEndptCfg tls.GenericService
@@ -129,10 +146,11 @@ func Deployment(
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Annotations: annotations,
-					Labels:      labels,
+					Labels:      podLabels(instance, labels, keystonev1.BlueGreenBlue),
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: instance.RbacResourceName(),
+					ImagePullSecrets:   instance.Spec.ImagePullSecrets,
 					Volumes:            volumes,
 					Containers: []corev1.Container{
 						{
@@ -143,7 +161,7 @@ func Deployment(
 							Args:            args,
 							Image:           instance.Spec.ContainerImage,
 							SecurityContext: httpdSecurityContext(),
-							Env:             env.MergeEnvs([]corev1.EnvVar{}, envVars),
+							Env:             withExtraEnv(env.MergeEnvs([]corev1.EnvVar{}, envVars), instance),
 							VolumeMounts:    volumeMounts,
 							Resources:       instance.Spec.Resources,
 							ReadinessProbe:  readinessProbe,
@@ -155,13 +173,53 @@ func Deployment(
 		},
 	}
 
+	if instance.Spec.AuditLog != nil {
+		deployment.Spec.Template.Spec.Containers = append(
+			deployment.Spec.Template.Spec.Containers, auditLogContainer(instance))
+	}
+
+	// every keystone API pod initializes its own database in dev mode
+	if instance.Spec.DevMode {
+		deployment.Spec.Template.Spec.InitContainers = devModeInitContainers(instance)
+	}
+
 	if instance.Spec.NodeSelector != nil {
 		deployment.Spec.Template.Spec.NodeSelector = *instance.Spec.NodeSelector
 	}
 
+	applyPodSettings(&deployment.Spec.Template.Spec, instance)
+
+	if instance.Spec.TerminationGracePeriodSeconds > 0 {
+		deployment.Spec.Template.Spec.TerminationGracePeriodSeconds = &instance.Spec.TerminationGracePeriodSeconds
+	}
+	if instance.Spec.PreStopDrainSeconds > 0 {
+		deployment.Spec.Template.Spec.Containers[0].Lifecycle = &corev1.Lifecycle{
+			PreStop: &corev1.LifecycleHandler{
+				Exec: &corev1.ExecAction{
+					Command: []string{"/bin/bash", "-c", preStopCommand(instance)},
+				},
+			},
+		}
+	}
+
 	if topology != nil {
 		topology.ApplyTo(&deployment.Spec.Template)
 	} else {
+		if instance.Spec.ZoneSpreadMaxSkew != nil {
+			// pod-template-hash excludes the pods of the previous
+			// revision during a rollout from the skew calculation
+			deployment.Spec.Template.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{
+				{
+					MaxSkew:           *instance.Spec.ZoneSpreadMaxSkew,
+					TopologyKey:       corev1.LabelTopologyZone,
+					WhenUnsatisfiable: corev1.ScheduleAnyway,
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: labels,
+					},
+					MatchLabelKeys: []string{appsv1.DefaultDeploymentUniqueLabelKey},
+				},
+			}
+		}
 		// If possible two pods of the same service should not
 		// run on the same worker node. If this is not possible
 		// the get still created on the same worker node.
@@ -176,3 +234,137 @@ func Deployment(
 
 	return deployment, nil
 }
+
+// auditLogContainer - sidecar container streaming the audit log to its
+// output, or the shipper container configured for the audit log
+func auditLogContainer(instance *keystonev1.KeystoneAPI) corev1.Container {
+	container := corev1.Container{
+		Name:            ServiceName + "-audit",
+		Image:           instance.Spec.ContainerImage,
+		Command:         []string{"/usr/bin/tail", "-n+1", "-F", AuditLogFile},
+		SecurityContext: baseSecurityContext(),
+		Env: []corev1.EnvVar{
+			{
+				Name:  "AUDIT_LOG_FILE",
+				Value: AuditLogFile,
+			},
+		},
+		VolumeMounts: getAuditLogVolumeMounts(),
+	}
+	if shipper := instance.Spec.AuditLog.Shipper; shipper != nil {
+		container.Image = shipper.ContainerImage
+		container.Command = shipper.Command
+		container.Args = shipper.Args
+	}
+	return container
+}
+
+// podLabels - labels of the keystone API pods. With blue/green enabled the
+// pods get labeled with the color of their deployment, with a separate public
+// deployment the pods of the default deployment get labeled as internal.
+func podLabels(
+	instance *keystonev1.KeystoneAPI,
+	labels map[string]string,
+	color keystonev1.BlueGreenColor,
+) map[string]string {
+	if instance.Spec.PublicDeployment != nil {
+		return util.MergeStringMaps(labels, map[string]string{EndpointLabel: string(service.EndpointInternal)})
+	}
+	if instance.Spec.BlueGreen == nil {
+		return labels
+	}
+	return util.MergeStringMaps(labels, map[string]string{BlueGreenLabel: string(color)})
+}
+
+// ServiceSelector - selector of the keystone service of the endpoint. With
+// blue/green enabled only the pods of the active deployment get selected, with
+// a separate public deployment only the pods serving the endpoint.
+func ServiceSelector(
+	instance *keystonev1.KeystoneAPI,
+	labels map[string]string,
+	endpt service.Endpoint,
+) map[string]string {
+	if instance.Spec.PublicDeployment != nil {
+		return util.MergeStringMaps(labels, map[string]string{EndpointLabel: endpt.String()})
+	}
+	if instance.Spec.BlueGreen == nil {
+		return labels
+	}
+	return podLabels(instance, labels, instance.Spec.BlueGreen.Active)
+}
+
+// PublicDeployment - the deployment serving the public endpoint, a copy of
+// the default deployment with its own replicas and resources
+func PublicDeployment(
+	instance *keystonev1.KeystoneAPI,
+	internal *appsv1.Deployment,
+	labels map[string]string,
+) *appsv1.Deployment {
+	public := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ServiceName + "-" + string(service.EndpointPublic),
+			Namespace: instance.Namespace,
+		},
+		Spec: *internal.Spec.DeepCopy(),
+	}
+	publicLabels := util.MergeStringMaps(labels, map[string]string{EndpointLabel: string(service.EndpointPublic)})
+	public.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: publicLabels,
+	}
+	public.Spec.Template.Labels = publicLabels
+	public.Spec.Replicas = instance.Spec.PublicDeployment.Replicas
+	if instance.Spec.PublicDeployment.Resources != nil {
+		public.Spec.Template.Spec.Containers[0].Resources = *instance.Spec.PublicDeployment.Resources
+	}
+
+	return public
+}
+
+// GreenDeployment - the green deployment of a blue/green setup, a copy of the
+// blue deployment running the green container image
+func GreenDeployment(
+	instance *keystonev1.KeystoneAPI,
+	blue *appsv1.Deployment,
+	labels map[string]string,
+) *appsv1.Deployment {
+	green := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ServiceName + "-" + string(keystonev1.BlueGreenGreen),
+			Namespace: instance.Namespace,
+		},
+		Spec: *blue.Spec.DeepCopy(),
+	}
+	greenLabels := podLabels(instance, labels, keystonev1.BlueGreenGreen)
+	green.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: greenLabels,
+	}
+	green.Spec.Template.Labels = greenLabels
+	green.Spec.Template.Spec.Containers[0].Image = instance.Spec.BlueGreen.ContainerImage
+
+	return green
+}
+
+// preStopCommand - drains the connections of a stopping keystone API pod.
+// With the disable_by_file healthcheck backend the pod first reports itself
+// unhealthy, keeps serving requests for the drain period and then stops httpd
+// gracefully, which finishes the requests in flight.
+func preStopCommand(instance *keystonev1.KeystoneAPI) string {
+	cmd := ""
+	if slices.Contains(instance.Spec.Healthcheck.Backends, "disable_by_file") {
+		cmd = fmt.Sprintf("touch %s; ", instance.Spec.Healthcheck.DisableByFilePath)
+	}
+	stop := "/usr/sbin/httpd -k graceful-stop"
+	if instance.Spec.WSGIServer == keystonev1.WSGIServerUWSGI {
+		stop = fmt.Sprintf("echo q > %s", UWSGIMasterFifo)
+	}
+	return cmd + fmt.Sprintf("sleep %d; %s", instance.Spec.PreStopDrainSeconds, stop)
+}
+
+// ReloadCommand - command gracefully reloading the WSGI server of the
+// keystone API after the config got updated
+func ReloadCommand(instance *keystonev1.KeystoneAPI) string {
+	if instance.Spec.WSGIServer == keystonev1.WSGIServerUWSGI {
+		return fmt.Sprintf("echo r > %s", UWSGIMasterFifo)
+	}
+	return "/usr/sbin/httpd -k graceful"
+}
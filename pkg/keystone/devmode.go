@@ -0,0 +1,63 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DevModeDatabaseDir - directory of the SQLite database in dev mode
+	DevModeDatabaseDir = "/var/lib/keystone/dev"
+	// DevModeDatabaseConnection - SQLAlchemy URL of the SQLite database in
+	// dev mode
+	DevModeDatabaseConnection = "sqlite:///" + DevModeDatabaseDir + "/keystone.db"
+)
+
+// getDevModeVolume - volume holding the SQLite database in dev mode, it
+// lives as long as the keystone API pod
+func getDevModeVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: "dev-db",
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}
+}
+
+// getDevModeVolumeMount - mounts the SQLite database of dev mode
+func getDevModeVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      "dev-db",
+		MountPath: DevModeDatabaseDir,
+	}
+}
+
+// devModeInitContainers - returns the containers of the db-sync and bootstrap
+// jobs, which initialize the SQLite database of the keystone API pod in dev
+// mode. The volumes of the jobs are also volumes of the deployment.
+func devModeInitContainers(instance *keystonev1.KeystoneAPI) []corev1.Container {
+	initContainers := []corev1.Container{
+		DbSyncJob(instance, nil, nil).Spec.Template.Spec.Containers[0],
+		BootstrapJob(instance, nil, nil, instance.Status.APIEndpoints).Spec.Template.Spec.Containers[0],
+	}
+	for i := range initContainers {
+		initContainers[i].VolumeMounts = append(initContainers[i].VolumeMounts, getDevModeVolumeMount())
+	}
+	return initContainers
+}
@@ -0,0 +1,40 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+const (
+	// PublicDNSCheckTimeout - timeout of the lookup of the public hostname
+	PublicDNSCheckTimeout = 5 * time.Second
+	// PublicDNSCheckInterval - interval the public hostname gets looked up in
+	// until it resolves
+	PublicDNSCheckInterval = time.Minute
+)
+
+// HostnameResolvable - returns true if the hostname resolves to at least one
+// address
+func HostnameResolvable(ctx context.Context, hostname string) bool {
+	ctx, cancel := context.WithTimeout(ctx, PublicDNSCheckTimeout)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, hostname)
+	return err == nil && len(addrs) > 0
+}
@@ -1,6 +1,11 @@
 package keystone
 
 import (
+	"slices"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/env"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
 )
@@ -47,3 +52,40 @@ func httpdSecurityContext() *corev1.SecurityContext {
 		RunAsGroup: ptr.To(KeystoneUID),
 	}
 }
+
+// applyJobSettings - sets the backoff and retention of the job, unset
+// settings keep the cluster defaults
+func applyJobSettings(spec *batchv1.JobSpec, settings keystonev1.JobSettings) {
+	spec.BackoffLimit = settings.BackoffLimit
+	spec.ActiveDeadlineSeconds = settings.ActiveDeadlineSeconds
+	spec.TTLSecondsAfterFinished = settings.TTLSecondsAfterFinished
+}
+
+// applyPodSettings - sets the scheduling, runtime and DNS settings of the pods
+func applyPodSettings(spec *corev1.PodSpec, instance *keystonev1.KeystoneAPI) {
+	spec.PriorityClassName = instance.Spec.PriorityClassName
+	spec.RuntimeClassName = instance.Spec.RuntimeClassName
+	spec.SchedulerName = instance.Spec.SchedulerName
+	spec.DNSPolicy = instance.Spec.DNSPolicy
+	spec.DNSConfig = instance.Spec.DNSConfig
+	spec.HostAliases = instance.Spec.HostAliases
+}
+
+// withExtraEnv - appends the extra environment variables of the instance to
+// the ones set by the operator, which take precedence
+func withExtraEnv(envs []corev1.EnvVar, instance *keystonev1.KeystoneAPI) []corev1.EnvVar {
+	for _, extra := range instance.Spec.ExtraEnv {
+		if !slices.ContainsFunc(envs, func(e corev1.EnvVar) bool { return e.Name == extra.Name }) {
+			envs = append(envs, extra)
+		}
+	}
+	return envs
+}
+
+// setTimeZone - sets the TZ environment variable to the time zone of the
+// instance, the containers log their timestamps in
+func setTimeZone(envVars map[string]env.Setter, instance *keystonev1.KeystoneAPI) {
+	if instance.Spec.TimeZone != "" {
+		envVars["TZ"] = env.SetValue(instance.Spec.TimeZone)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// CosignKeyMountPath - mount path of the cosign public key secret
+	CosignKeyMountPath = "/etc/cosign"
+	// CosignKeyFileName - key of the cosign public key in its secret
+	CosignKeyFileName = "cosign.pub"
+)
+
+// ImageVerifyJob - job verifying the cosign signatures of the keystone
+// container images
+func ImageVerifyJob(
+	instance *keystonev1.KeystoneAPI,
+	labels map[string]string,
+	annotations map[string]string,
+) *batchv1.Job {
+	v := instance.Spec.ImageVerification
+
+	args := []string{"verify"}
+	volumes := []corev1.Volume{}
+	volumeMounts := []corev1.VolumeMount{}
+	if v.CosignKeySecret != "" {
+		args = append(args, "--key", CosignKeyMountPath+"/"+CosignKeyFileName)
+		volumes = append(volumes, corev1.Volume{
+			Name: "cosign-key",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: v.CosignKeySecret,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "cosign-key",
+			MountPath: CosignKeyMountPath,
+			ReadOnly:  true,
+		})
+	} else {
+		args = append(args,
+			"--certificate-identity", v.CertificateIdentity,
+			"--certificate-oidc-issuer", v.CertificateOIDCIssuer)
+	}
+	args = append(args, instance.Spec.ContainerImage)
+	if instance.Spec.BlueGreen != nil {
+		args = append(args, instance.Spec.BlueGreen.ContainerImage)
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ServiceName + "-image-verify",
+			Namespace: instance.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: instance.RbacResourceName(),
+					ImagePullSecrets:   instance.Spec.ImagePullSecrets,
+					Containers: []corev1.Container{
+						{
+							Name:            ServiceName + "-image-verify",
+							Image:           v.CosignImage,
+							Args:            args,
+							SecurityContext: baseSecurityContext(),
+							VolumeMounts:    volumeMounts,
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+
+	if instance.Spec.NodeSelector != nil {
+		job.Spec.Template.Spec.NodeSelector = *instance.Spec.NodeSelector
+	}
+
+	applyPodSettings(&job.Spec.Template.Spec, instance)
+	applyJobSettings(&job.Spec, instance.Spec.JobSettings)
+
+	return job
+}
@@ -0,0 +1,148 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+)
+
+const (
+	// LDAPCACertDir - directory of the CA certificates of the LDAP domains
+	LDAPCACertDir = "/etc/keystone/ldap-ca"
+	// LDAPCheckTimeout - timeout of the connectivity check of an LDAP server
+	LDAPCheckTimeout = 5 * time.Second
+	// LDAPCheckInterval - interval the LDAP servers get checked in
+	LDAPCheckInterval = 5 * time.Minute
+)
+
+// LDAPCACertFileName - name of the CA certificate file of the LDAP domain
+func LDAPCACertFileName(domain string) string {
+	return fmt.Sprintf("ldap-ca-%s.pem", domain)
+}
+
+// LDAPDomainConfig - [ldap] section with the connection settings of the
+// domain, appended to the domain configuration
+func LDAPDomainConfig(domain string, ldap *keystonev1.LDAPDomainSpec) string {
+	var b strings.Builder
+	b.WriteString("\n[ldap]\n")
+	if ldap.UsePool != nil {
+		fmt.Fprintf(&b, "use_pool = %t\n", *ldap.UsePool)
+	}
+	if ldap.PoolSize != nil {
+		fmt.Fprintf(&b, "pool_size = %d\n", *ldap.PoolSize)
+	}
+	if ldap.PoolRetryMax != nil {
+		fmt.Fprintf(&b, "pool_retry_max = %d\n", *ldap.PoolRetryMax)
+	}
+	if ldap.ChaseReferrals != nil {
+		fmt.Fprintf(&b, "chase_referrals = %t\n", *ldap.ChaseReferrals)
+	}
+	if ldap.CACertSecretRef != nil {
+		fmt.Fprintf(&b, "tls_cacertfile = %s/%s\n", LDAPCACertDir, LDAPCACertFileName(domain))
+	}
+	return b.String()
+}
+
+// IdentityDriverConfig - returns the [identity] section of a domain
+// configuration selecting the identity driver of the domain
+func IdentityDriverConfig(driver keystonev1.KeystoneDriver) string {
+	return fmt.Sprintf("[identity]\ndriver = %s\n\n", driver)
+}
+
+// LDAPURLs - the LDAP server URLs of the url option of the [ldap] section of
+// the domain configuration
+func LDAPURLs(config string) []string {
+	urls := []string{}
+	section := ""
+	for _, line := range strings.Split(config, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if section != "ldap" || !found || strings.TrimSpace(key) != "url" {
+			continue
+		}
+		// a later url option overrides the previous one
+		urls = []string{}
+		for _, u := range strings.Split(value, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				urls = append(urls, u)
+			}
+		}
+	}
+	return urls
+}
+
+// CheckLDAPConnectivity - connects to the LDAP server, ldaps servers get
+// their certificate verified with the CA certificate if one is given
+func CheckLDAPConnectivity(ctx context.Context, ldapURL string, caCert []byte) error {
+	u, err := url.Parse(ldapURL)
+	if err != nil {
+		return err
+	}
+
+	port := u.Port()
+	switch {
+	case port != "":
+	case u.Scheme == "ldaps":
+		port = "636"
+	case u.Scheme == "ldap":
+		port = "389"
+	default:
+		return fmt.Errorf("unsupported LDAP URL scheme %s", u.Scheme)
+	}
+	address := net.JoinHostPort(u.Hostname(), port)
+
+	ctx, cancel := context.WithTimeout(ctx, LDAPCheckTimeout)
+	defer cancel()
+
+	if u.Scheme != "ldaps" {
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName: u.Hostname(),
+		MinVersion: tls.VersionTLS12,
+	}
+	if len(caCert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return errors.New("invalid LDAP CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	conn, err := (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
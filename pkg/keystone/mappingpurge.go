@@ -0,0 +1,109 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	"fmt"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/env"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MappingPurgeCommand - purges the ID mappings of the domain in the
+	// DOMAIN_NAME environment variable, passed as variable to not quote it
+	MappingPurgeCommand = "keystone-manage mapping_purge --domain-name \"$DOMAIN_NAME\""
+	// MappingPurgeHash - key of the hash of the mapping purge job
+	MappingPurgeHash = "mappingpurge"
+)
+
+// MappingPurgeJobName - returns the name of the mapping purge job of the
+// KeystoneMapping
+func MappingPurgeJobName(mappingName string) string {
+	return fmt.Sprintf("%s-mapping-purge-%s", ServiceName, mappingName)
+}
+
+// MappingPurgeJob - returns the job purging the ID mappings keystone keeps for
+// the federated users of the domain, after their identity provider got deleted
+func MappingPurgeJob(
+	instance *keystonev1.KeystoneAPI,
+	mappingName string,
+	domainName string,
+	labels map[string]string,
+) *batchv1.Job {
+
+	args := []string{"-c", MappingPurgeCommand}
+
+	envVars := map[string]env.Setter{}
+	envVars["KOLLA_CONFIG_STRATEGY"] = env.SetValue("COPY_ALWAYS")
+	envVars["DOMAIN_NAME"] = env.SetValue(domainName)
+	setTimeZone(envVars, instance)
+
+	// create Volume and VolumeMounts, keystone-manage only needs the
+	// database connection
+	volumes := getVolumes(instance, []keystonev1.KeystoneExtraMounts{}, DBSyncPropagation)
+	volumeMounts := getDBSyncVolumeMounts()
+
+	// add CA cert if defined
+	if instance.Spec.TLS.CaBundleSecretName != "" {
+		volumes = append(volumes, instance.Spec.TLS.CreateVolume())
+		volumeMounts = append(volumeMounts, instance.Spec.TLS.CreateVolumeMounts(nil)...)
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MappingPurgeJobName(mappingName),
+			Namespace: instance.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyOnFailure,
+					ServiceAccountName: instance.RbacResourceName(),
+					ImagePullSecrets:   instance.Spec.ImagePullSecrets,
+					Containers: []corev1.Container{
+						{
+							Name: ServiceName + "-mapping-purge",
+							Command: []string{
+								"/bin/bash",
+							},
+							Args:            args,
+							Image:           instance.Spec.ContainerImage,
+							SecurityContext: baseSecurityContext(),
+							Env:             withExtraEnv(env.MergeEnvs([]corev1.EnvVar{}, envVars), instance),
+							VolumeMounts:    volumeMounts,
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+
+	if instance.Spec.NodeSelector != nil {
+		job.Spec.Template.Spec.NodeSelector = *instance.Spec.NodeSelector
+	}
+
+	applyPodSettings(&job.Spec.Template.Spec, instance)
+	applyJobSettings(&job.Spec, instance.Spec.JobSettings)
+
+	return job
+}
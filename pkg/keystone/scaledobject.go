@@ -0,0 +1,93 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	"fmt"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ScaledObjectGVK - KEDA ScaledObject, KEDA is an optional dependency so its
+// objects are handled as unstructured
+var ScaledObjectGVK = schema.GroupVersionKind{
+	Group:   "keda.sh",
+	Version: "v1alpha1",
+	Kind:    "ScaledObject",
+}
+
+// DefaultAutoscalingQuery - requests per second of the keystone public route
+// reported by the OpenShift HAProxy router
+const DefaultAutoscalingQuery = `sum(rate(haproxy_backend_http_responses_total{exported_namespace="%s",route="%s-public"}[2m]))`
+
+// ScaledObjectSpec - spec of the KEDA ScaledObject scaling the keystone API
+// deployment
+func ScaledObjectSpec(instance *keystonev1.KeystoneAPI) map[string]interface{} {
+	a := instance.Spec.Autoscaling
+
+	query := a.Query
+	if query == "" {
+		query = fmt.Sprintf(DefaultAutoscalingQuery, instance.Namespace, instance.Name)
+	}
+
+	prometheus := map[string]interface{}{
+		"type": "prometheus",
+		"metadata": map[string]interface{}{
+			"serverAddress": a.PrometheusServerAddress,
+			"query":         query,
+			"threshold":     a.RequestsPerSecondThreshold,
+		},
+	}
+	if a.TriggerAuthenticationName != "" {
+		prometheus["authenticationRef"] = map[string]interface{}{
+			"name": a.TriggerAuthenticationName,
+		}
+	}
+	triggers := []interface{}{prometheus}
+
+	if a.CPUUtilization != nil {
+		triggers = append(triggers, map[string]interface{}{
+			"type":       "cpu",
+			"metricType": "Utilization",
+			"metadata": map[string]interface{}{
+				"value": fmt.Sprintf("%d", *a.CPUUtilization),
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"scaleTargetRef": map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"name":       ServiceName,
+		},
+		"minReplicaCount": int64(a.MinReplicas),
+		"maxReplicaCount": int64(a.MaxReplicas),
+		"triggers":        triggers,
+	}
+}
+
+// NewScaledObject - empty unstructured KEDA ScaledObject of the keystone API
+func NewScaledObject(instance *keystonev1.KeystoneAPI) *unstructured.Unstructured {
+	so := &unstructured.Unstructured{}
+	so.SetGroupVersionKind(ScaledObjectGVK)
+	so.SetName(instance.Name)
+	so.SetNamespace(instance.Namespace)
+	return so
+}
@@ -0,0 +1,35 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// ShadowUsersCheckInterval - interval the shadow users of the identity
+	// provider of a KeystoneMapping get listed in
+	ShadowUsersCheckInterval = 10 * time.Minute
+	// ShadowUsersConfigMapKey - key of the shadow users in the ConfigMap
+	ShadowUsersConfigMapKey = "shadow-users.json"
+)
+
+// ShadowUsersConfigMapName - returns the name of the ConfigMap listing the
+// shadow users of the identity provider of the KeystoneMapping
+func ShadowUsersConfigMapName(mappingName string) string {
+	return fmt.Sprintf("%s-shadow-users", mappingName)
+}
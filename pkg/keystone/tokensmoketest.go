@@ -0,0 +1,59 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	"time"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+)
+
+const (
+	// TokenSmokeTestUser - user of the default domain the token smoke test
+	// issues tokens for
+	TokenSmokeTestUser = "keystone-smoke-test"
+	// DefaultTokenSmokeTestInterval - default seconds between the token
+	// smoke tests
+	DefaultTokenSmokeTestInterval = 300
+	// DefaultTokenSmokeTestFailureThreshold - default number of failed token
+	// smoke tests in a row which set the TokenSmokeTestReady condition to
+	// False
+	DefaultTokenSmokeTestFailureThreshold = 3
+)
+
+// TokenSmokeTestSecretName - name of the Secret with the password of the
+// token smoke test user
+func TokenSmokeTestSecretName(instance *keystonev1.KeystoneAPI) string {
+	return instance.Name + "-smoke-test"
+}
+
+// TokenSmokeTestInterval - returns the interval of the token smoke tests
+func TokenSmokeTestInterval(spec *keystonev1.TokenSmokeTestSpec) time.Duration {
+	interval := int32(DefaultTokenSmokeTestInterval)
+	if spec.Interval != nil {
+		interval = *spec.Interval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// TokenSmokeTestFailureThreshold - returns the number of failed token smoke
+// tests in a row which set the TokenSmokeTestReady condition to False
+func TokenSmokeTestFailureThreshold(spec *keystonev1.TokenSmokeTestSpec) int32 {
+	if spec.FailureThreshold != nil {
+		return *spec.FailureThreshold
+	}
+	return DefaultTokenSmokeTestFailureThreshold
+}
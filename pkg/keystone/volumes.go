@@ -20,6 +20,7 @@ import (
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
 	"github.com/openstack-k8s-operators/lib-common/modules/storage"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // getVolumes - service volumes
@@ -188,3 +189,29 @@ func getDBSyncVolumeMounts() []corev1.VolumeMount {
 		},
 	}
 }
+
+// getAuditLogVolumes - volume of the audit log, sized to hold the rotated logs
+func getAuditLogVolumes(instance *keystonev1.KeystoneAPI) []corev1.Volume {
+	a := instance.Spec.AuditLog
+	sizeLimit := resource.MustParse(fmt.Sprintf("%dMi", int64(a.MaxFileSizeMB)*int64(a.MaxFiles+1)))
+	return []corev1.Volume{
+		{
+			Name: "audit-log",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{
+					SizeLimit: &sizeLimit,
+				},
+			},
+		},
+	}
+}
+
+// getAuditLogVolumeMounts - volumeMounts of the audit log
+func getAuditLogVolumeMounts() []corev1.VolumeMount {
+	return []corev1.VolumeMount{
+		{
+			Name:      "audit-log",
+			MountPath: AuditLogDir,
+		},
+	}
+}
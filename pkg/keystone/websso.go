@@ -0,0 +1,43 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// WebSSORefreshInterval - interval the WebSSO login options get read from
+	// keystone in, to pick up added or removed identity providers
+	WebSSORefreshInterval = 5 * time.Minute
+	// WebSSOConfigMapKey - key of the WebSSO login options in the ConfigMap
+	WebSSOConfigMapKey = "websso.json"
+)
+
+// WebSSOConfigMapName - returns the name of the ConfigMap with the WebSSO
+// login options of the KeystoneAPI
+func WebSSOConfigMapName(instanceName string) string {
+	return fmt.Sprintf("%s-websso", instanceName)
+}
+
+// WebSSOURL - returns the WebSSO URL of the protocol of the identity
+// provider, the dashboards redirect the browser to it for the login
+func WebSSOURL(publicURL string, idpID string, protocolID string) string {
+	return fmt.Sprintf("%s/v3/auth/OS-FEDERATION/identity_providers/%s/protocols/%s/websso",
+		strings.TrimSuffix(publicURL, "/"), idpID, protocolID)
+}
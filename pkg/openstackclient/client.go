@@ -0,0 +1,303 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package openstackclient provides the interface the controllers use to talk
+// to keystone, so tests can replace keystone with the in-memory fake of the
+// fake package.
+package openstackclient
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/domains"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/endpoints"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/regions"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/roles"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/services"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/users"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// OpenStackClient - the keystone API calls of the controllers. The methods
+// shared with openstack.OpenStack behave the same, including the not found
+// errors.
+type OpenStackClient interface {
+	// GetOSClient - returns the gophercloud client of the identity API, used
+	// by the helpers of the identity package
+	GetOSClient() *gophercloud.ServiceClient
+	// GetRegion - returns the region the endpoints get registered in
+	GetRegion() string
+
+	CreateService(log logr.Logger, s openstack.Service) (string, error)
+	GetService(log logr.Logger, serviceType string, serviceName string) (*services.Service, error)
+	UpdateService(log logr.Logger, s openstack.Service, serviceID string) error
+	DeleteService(log logr.Logger, serviceID string) error
+	// ListServices - returns all services of the catalog
+	ListServices() ([]services.Service, error)
+	// UpdateServiceExtra - sets extra attributes of the service, keystone
+	// keeps the other attributes
+	UpdateServiceExtra(serviceID string, extra map[string]interface{}) error
+
+	CreateEndpoint(log logr.Logger, e openstack.Endpoint) (string, error)
+	GetEndpoints(log logr.Logger, serviceID string, endpointInterface string) ([]endpoints.Endpoint, error)
+	UpdateEndpoint(log logr.Logger, e openstack.Endpoint, endpointID string) (string, error)
+	DeleteEndpoint(log logr.Logger, e openstack.Endpoint) error
+	// ListEndpoints - returns the endpoints of all regions matching opts,
+	// including their extra attributes
+	ListEndpoints(opts endpoints.ListOpts) ([]Endpoint, error)
+	// UpdateEndpointExtra - sets extra attributes of the endpoint, keystone
+	// keeps the other attributes
+	UpdateEndpointExtra(endpointID string, extra map[string]interface{}) error
+	// EnsureRegion - creates the region unless it exists
+	EnsureRegion(regionID string) error
+	// CreateRegionalEndpoint - creates the endpoint in region instead of the
+	// region of the client
+	CreateRegionalEndpoint(e openstack.Endpoint, region string) (string, error)
+	// UpdateEndpointURL - sets the URL of the endpoint, keystone keeps the
+	// other attributes
+	UpdateEndpointURL(endpointID string, url string) error
+	// DeleteEndpointByID - deletes the endpoint, a missing endpoint is not an
+	// error
+	DeleteEndpointByID(endpointID string) error
+
+	CreateDomain(log logr.Logger, d openstack.Domain) (string, error)
+	// GetDomain - returns the domain with the name, nil if it does not exist
+	GetDomain(name string) (*domains.Domain, error)
+
+	CreateProject(log logr.Logger, p openstack.Project) (string, error)
+	GetProject(log logr.Logger, projectName string, domainID string) (*projects.Project, error)
+
+	CreateUser(log logr.Logger, u openstack.User) (string, error)
+	GetUser(log logr.Logger, userName string, domainID string) (*users.User, error)
+	DeleteUser(log logr.Logger, userName string, domainID string) error
+
+	CreateRole(log logr.Logger, roleName string) (string, error)
+	GetRole(log logr.Logger, roleName string) (*roles.Role, error)
+	AssignUserRole(log logr.Logger, roleName string, userID string, projectID string) error
+}
+
+// Endpoint - keystone endpoint with the extra attributes gophercloud drops
+type Endpoint struct {
+	endpoints.Endpoint
+	// Extra - attributes of the endpoint unknown to keystone
+	Extra map[string]interface{}
+}
+
+// Factory - returns the admin client of the keystone API. The result is
+// non-empty while the client can not be created yet.
+type Factory func(
+	ctx context.Context,
+	h *helper.Helper,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (OpenStackClient, ctrl.Result, error)
+
+// NewAdminClient - Factory returning the system scoped admin client of the
+// keystone API
+func NewAdminClient(
+	ctx context.Context,
+	h *helper.Helper,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (OpenStackClient, ctrl.Result, error) {
+	os, ctrlResult, err := keystonev1.GetAdminServiceClient(ctx, h, keystoneAPI)
+	if err != nil || (ctrlResult != ctrl.Result{}) {
+		return nil, ctrlResult, err
+	}
+	setReauth(ctx, h, keystoneAPI, os)
+	return New(os), ctrl.Result{}, nil
+}
+
+// setReauth - makes gophercloud authenticate the admin client again and
+// retry the request once when keystone rejects the token with 401, e.g.
+// after the fernet keys got rotated, instead of failing the reconcile
+func setReauth(
+	ctx context.Context,
+	h *helper.Helper,
+	keystoneAPI *keystonev1.KeystoneAPI,
+	os *openstack.OpenStack,
+) {
+	reauthWith(h.GetLogger(), os.GetOSClient().ProviderClient, func() (*gophercloud.ProviderClient, error) {
+		newOS, _, err := keystonev1.GetAdminServiceClient(ctx, h, keystoneAPI)
+		if err != nil {
+			return nil, err
+		}
+		return newOS.GetOSClient().ProviderClient, nil
+	})
+}
+
+// reauthWith - sets the reauth of the provider to take over the token of the
+// provider authenticate returns. gophercloud retries the rejected request
+// once, a second 401 gets returned as ErrErrorAfterReauthentication.
+func reauthWith(
+	log logr.Logger,
+	provider *gophercloud.ProviderClient,
+	authenticate func() (*gophercloud.ProviderClient, error),
+) {
+	provider.ReauthFunc = func() error {
+		log.Info("Keystone rejected the admin token, authenticating again")
+		newProvider, err := authenticate()
+		if err != nil {
+			return err
+		}
+		provider.CopyTokenFrom(newProvider)
+		return nil
+	}
+}
+
+// New - returns the OpenStackClient of the openstack.OpenStack helper
+func New(os *openstack.OpenStack) OpenStackClient {
+	return &client{OpenStack: os}
+}
+
+// client - adds the calls the openstack.OpenStack helper does not cover
+type client struct {
+	*openstack.OpenStack
+}
+
+// ListServices - implements OpenStackClient
+func (c *client) ListServices() ([]services.Service, error) {
+	allPages, err := services.List(c.GetOSClient(), services.ListOpts{}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	return services.ExtractServices(allPages)
+}
+
+// UpdateServiceExtra - implements OpenStackClient
+func (c *client) UpdateServiceExtra(serviceID string, extra map[string]interface{}) error {
+	_, err := services.Update(c.GetOSClient(), serviceID, services.UpdateOpts{
+		Extra: extra,
+	}).Extract()
+	return err
+}
+
+// endpointAttributes - attributes of the endpoint API, all others are extra
+// attributes
+var endpointAttributes = []string{
+	"id", "interface", "name", "region", "region_id", "service_id", "url", "enabled", "links",
+}
+
+// ListEndpoints - implements OpenStackClient. gophercloud drops the extra
+// attributes of endpoints, they get extracted from the response body.
+func (c *client) ListEndpoints(opts endpoints.ListOpts) ([]Endpoint, error) {
+	allPages, err := endpoints.List(c.GetOSClient(), opts).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	allEndpoints, err := endpoints.ExtractEndpoints(allPages)
+	if err != nil {
+		return nil, err
+	}
+	var raw []map[string]interface{}
+	err = allPages.(endpoints.EndpointPage).ExtractIntoSlicePtr(&raw, "endpoints")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Endpoint, len(allEndpoints))
+	for i, endpt := range allEndpoints {
+		extra := raw[i]
+		for _, attr := range endpointAttributes {
+			delete(extra, attr)
+		}
+		result[i] = Endpoint{Endpoint: endpt, Extra: extra}
+	}
+	return result, nil
+}
+
+// endpointExtraUpdateOpts - updates extra attributes of an endpoint, which
+// endpoints.UpdateOpts does not support
+type endpointExtraUpdateOpts map[string]interface{}
+
+// ToEndpointUpdateMap - implements endpoints.UpdateOptsBuilder
+func (opts endpointExtraUpdateOpts) ToEndpointUpdateMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"endpoint": map[string]interface{}(opts)}, nil
+}
+
+// UpdateEndpointExtra - implements OpenStackClient
+func (c *client) UpdateEndpointExtra(endpointID string, extra map[string]interface{}) error {
+	_, err := endpoints.Update(c.GetOSClient(), endpointID, endpointExtraUpdateOpts(extra)).Extract()
+	return err
+}
+
+// EnsureRegion - implements OpenStackClient
+func (c *client) EnsureRegion(regionID string) error {
+	_, err := regions.Get(c.GetOSClient(), regionID).Extract()
+	if !isNotFound(err) {
+		return err
+	}
+	_, err = regions.Create(c.GetOSClient(), regions.CreateOpts{ID: regionID}).Extract()
+	return err
+}
+
+// CreateRegionalEndpoint - implements OpenStackClient
+func (c *client) CreateRegionalEndpoint(e openstack.Endpoint, region string) (string, error) {
+	endpt, err := endpoints.Create(c.GetOSClient(), endpoints.CreateOpts{
+		Availability: e.Availability,
+		Name:         e.Name,
+		Region:       region,
+		ServiceID:    e.ServiceID,
+		URL:          e.URL,
+	}).Extract()
+	if err != nil {
+		return "", err
+	}
+	return endpt.ID, nil
+}
+
+// UpdateEndpointURL - implements OpenStackClient
+func (c *client) UpdateEndpointURL(endpointID string, url string) error {
+	_, err := endpoints.Update(c.GetOSClient(), endpointID, endpoints.UpdateOpts{
+		URL: url,
+	}).Extract()
+	return err
+}
+
+// DeleteEndpointByID - implements OpenStackClient
+func (c *client) DeleteEndpointByID(endpointID string) error {
+	err := endpoints.Delete(c.GetOSClient(), endpointID).ExtractErr()
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// isNotFound - returns true if err is a 404 response of the identity API
+func isNotFound(err error) bool {
+	var notFound gophercloud.ErrDefault404
+	return errors.As(err, &notFound)
+}
+
+// GetDomain - implements OpenStackClient
+func (c *client) GetDomain(name string) (*domains.Domain, error) {
+	allPages, err := domains.List(c.GetOSClient(), domains.ListOpts{Name: name}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	allDomains, err := domains.ExtractDomains(allPages)
+	if err != nil {
+		return nil, err
+	}
+	if len(allDomains) == 0 {
+		return nil, nil
+	}
+	return &allDomains[0], nil
+}
@@ -0,0 +1,113 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstackclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/services"
+	. "github.com/onsi/gomega"
+)
+
+// newReauthServer - returns a keystone which lists the services only for the
+// token and counts the requests
+func newReauthServer(t *testing.T, validToken string, requests *int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("X-Auth-Token") != validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": {"code": 401, "title": "Unauthorized"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"services": [], "links": {}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newReauthClient - returns the identity client of the server authenticated
+// with an expired token, reauthenticating with the token
+func newReauthClient(server *httptest.Server, token string, reauths *int32) *gophercloud.ServiceClient {
+	provider := &gophercloud.ProviderClient{}
+	provider.SetToken("expired")
+	reauthWith(logr.Discard(), provider, func() (*gophercloud.ProviderClient, error) {
+		atomic.AddInt32(reauths, 1)
+		newProvider := &gophercloud.ProviderClient{}
+		newProvider.SetToken(token)
+		return newProvider, nil
+	})
+	return &gophercloud.ServiceClient{
+		ProviderClient: provider,
+		Endpoint:       server.URL + "/v3/",
+	}
+}
+
+func TestReauthRetriesOnce(t *testing.T) {
+	g := NewWithT(t)
+
+	var requests, reauths int32
+	server := newReauthServer(t, "valid", &requests)
+	client := newReauthClient(server, "valid", &reauths)
+
+	_, err := services.List(client, services.ListOpts{}).AllPages()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(atomic.LoadInt32(&reauths)).To(Equal(int32(1)))
+	g.Expect(atomic.LoadInt32(&requests)).To(Equal(int32(2)))
+	g.Expect(client.ProviderClient.Token()).To(Equal("valid"))
+}
+
+func TestReauthReturnsSecondUnauthorized(t *testing.T) {
+	g := NewWithT(t)
+
+	var requests, reauths int32
+	server := newReauthServer(t, "valid", &requests)
+	// keystone rejects the new token as well
+	client := newReauthClient(server, "rejected", &reauths)
+
+	_, err := services.List(client, services.ListOpts{}).AllPages()
+	g.Expect(err).To(HaveOccurred())
+	var reauthErr *gophercloud.ErrErrorAfterReauthentication
+	g.Expect(errors.As(err, &reauthErr)).To(BeTrue())
+	g.Expect(atomic.LoadInt32(&reauths)).To(Equal(int32(1)))
+	g.Expect(atomic.LoadInt32(&requests)).To(Equal(int32(2)))
+}
+
+func TestReauthReturnsAuthenticationError(t *testing.T) {
+	g := NewWithT(t)
+
+	var requests int32
+	server := newReauthServer(t, "valid", &requests)
+	provider := &gophercloud.ProviderClient{}
+	provider.SetToken("expired")
+	reauthWith(logr.Discard(), provider, func() (*gophercloud.ProviderClient, error) {
+		return nil, errors.New("keystone unreachable")
+	})
+	client := &gophercloud.ServiceClient{
+		ProviderClient: provider,
+		Endpoint:       server.URL + "/v3/",
+	}
+
+	_, err := services.List(client, services.ListOpts{}).AllPages()
+	g.Expect(err).To(MatchError(ContainSubstring("keystone unreachable")))
+	g.Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+}
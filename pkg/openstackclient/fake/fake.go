@@ -0,0 +1,794 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake provides an in-memory keystone for the tests of the
+// controllers, e.g.:
+//
+//	os := fake.NewOpenStackClient("regionOne")
+//	err = (&controllers.KeystoneServiceReconciler{
+//	    Client:          k8sManager.GetClient(),
+//	    Scheme:          k8sManager.GetScheme(),
+//	    Kclient:         kclient,
+//	    OpenStackClient: os.Factory(),
+//	}).SetupWithManager(k8sManager)
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/domains"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/endpoints"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/roles"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/services"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/users"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	"github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// roleAssignment - role of a user on a project
+type roleAssignment struct {
+	roleID    string
+	userID    string
+	projectID string
+}
+
+// systemScope - project of the role assignments on the system
+const systemScope = "system"
+
+// impliedRole - inference rule of a prior role
+type impliedRole struct {
+	priorRoleID   string
+	impliedRoleID string
+}
+
+// OpenStackClient - in-memory keystone implementing
+// openstackclient.OpenStackClient. The client of GetOSClient serves the
+// calls of the identity package from the same state.
+type OpenStackClient struct {
+	mu sync.Mutex
+
+	region       string
+	regions      map[string]bool
+	services     map[string]services.Service
+	endpoints    map[string]openstackclient.Endpoint
+	domains      map[string]domains.Domain
+	projects     map[string]projects.Project
+	users        map[string]users.User
+	passwords    map[string]string
+	roles        map[string]roles.Role
+	assignments  map[roleAssignment]bool
+	impliedRoles map[impliedRole]bool
+	// resources - the other resources of the identity API by their path,
+	// nil for relationships
+	resources map[string]map[string]interface{}
+	errors    map[string]error
+}
+
+var _ openstackclient.OpenStackClient = &OpenStackClient{}
+
+// NewOpenStackClient - returns an empty keystone, the endpoints get
+// registered in region
+func NewOpenStackClient(region string) *OpenStackClient {
+	return &OpenStackClient{
+		region:       region,
+		regions:      map[string]bool{region: true},
+		services:     map[string]services.Service{},
+		endpoints:    map[string]openstackclient.Endpoint{},
+		domains:      map[string]domains.Domain{},
+		projects:     map[string]projects.Project{},
+		users:        map[string]users.User{},
+		passwords:    map[string]string{},
+		roles:        map[string]roles.Role{},
+		assignments:  map[roleAssignment]bool{},
+		impliedRoles: map[impliedRole]bool{},
+		resources:    map[string]map[string]interface{}{},
+		errors:       map[string]error{},
+	}
+}
+
+// Factory - returns an openstackclient.Factory returning the fake for every
+// KeystoneAPI
+func (f *OpenStackClient) Factory() openstackclient.Factory {
+	return func(
+		_ context.Context,
+		_ *helper.Helper,
+		_ *keystonev1.KeystoneAPI,
+	) (openstackclient.OpenStackClient, ctrl.Result, error) {
+		return f, ctrl.Result{}, nil
+	}
+}
+
+// InjectError - makes all calls of the method, e.g. "CreateService", or all
+// requests of a collection of the identity API, e.g. "endpoint_groups", fail
+// with err. A nil err removes the failure.
+func (f *OpenStackClient) InjectError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		delete(f.errors, method)
+		return
+	}
+	f.errors[method] = err
+}
+
+// HasRoleAssignment - returns true if the user has the role on the project
+func (f *OpenStackClient) HasRoleAssignment(roleName string, userID string, projectID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	role := f.findRole(roleName)
+	return role != nil && f.assignments[roleAssignment{roleID: role.ID, userID: userID, projectID: projectID}]
+}
+
+// HasImpliedRole - returns true if the prior role implies the other role
+func (f *OpenStackClient) HasImpliedRole(priorRoleName string, impliedRoleName string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	prior := f.findRole(priorRoleName)
+	implied := f.findRole(impliedRoleName)
+	return prior != nil && implied != nil &&
+		f.impliedRoles[impliedRole{priorRoleID: prior.ID, impliedRoleID: implied.ID}]
+}
+
+// HasSystemRoleAssignment - returns true if the user has the role on the
+// system
+func (f *OpenStackClient) HasSystemRoleAssignment(roleName string, userID string) bool {
+	return f.HasRoleAssignment(roleName, userID, systemScope)
+}
+
+// GetPassword - returns the password the user got created or updated with
+func (f *OpenStackClient) GetPassword(userID string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.passwords[userID]
+}
+
+// GetUserByID - returns a copy of the user, nil if it does not exist
+func (f *OpenStackClient) GetUserByID(userID string) *users.User {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[userID]
+	if !ok {
+		return nil
+	}
+	user.Extra = copyExtra(user.Extra)
+	return &user
+}
+
+// ListResources - returns copies of the resources of the collection of the
+// identity API, e.g. "OS-EP-FILTER/endpoint_groups"
+func (f *OpenStackClient) ListResources(collectionPath string) []map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := []map[string]interface{}{}
+	for key, object := range f.resources {
+		if object != nil && path.Dir(key) == collectionPath {
+			result = append(result, copyExtra(object))
+		}
+	}
+	return result
+}
+
+// HasResource - returns true if the resource or relationship of the identity
+// API exists, e.g. "OS-EP-FILTER/endpoint_groups/{id}/projects/{id}"
+func (f *OpenStackClient) HasResource(resourcePath string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, ok := f.resources[resourcePath]
+	return ok
+}
+
+// GetOSClient - implements openstackclient.OpenStackClient. The returned
+// client talks to the in-memory identity API of the fake.
+func (f *OpenStackClient) GetOSClient() *gophercloud.ServiceClient {
+	return &gophercloud.ServiceClient{
+		ProviderClient: &gophercloud.ProviderClient{
+			HTTPClient: http.Client{Transport: restTransport{f: f}},
+		},
+		Endpoint: "http://keystone.fake" + apiPrefix,
+	}
+}
+
+// GetRegion - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) GetRegion() string {
+	return f.region
+}
+
+// CreateService - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) CreateService(_ logr.Logger, s openstack.Service) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["CreateService"]; err != nil {
+		return "", err
+	}
+	if svc := f.findService(s.Type, s.Name); svc != nil {
+		return svc.ID, nil
+	}
+	svc := services.Service{
+		ID:      uuid.NewString(),
+		Type:    s.Type,
+		Enabled: s.Enabled,
+		Extra: map[string]interface{}{
+			"name":        s.Name,
+			"description": s.Description,
+		},
+	}
+	f.services[svc.ID] = svc
+	return svc.ID, nil
+}
+
+// GetService - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) GetService(_ logr.Logger, serviceType string, serviceName string) (*services.Service, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["GetService"]; err != nil {
+		return nil, err
+	}
+	svc := f.findService(serviceType, serviceName)
+	if svc == nil {
+		return nil, fmt.Errorf("%s %s", serviceName, openstack.ServiceNotFound) // nolint:err113
+	}
+	return svc, nil
+}
+
+// findService - returns a copy of the service with the type and name
+func (f *OpenStackClient) findService(serviceType string, serviceName string) *services.Service {
+	for _, svc := range f.services {
+		if svc.Type == serviceType && svc.Extra["name"] == serviceName {
+			svc.Extra = copyExtra(svc.Extra)
+			return &svc
+		}
+	}
+	return nil
+}
+
+// UpdateService - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) UpdateService(_ logr.Logger, s openstack.Service, serviceID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["UpdateService"]; err != nil {
+		return err
+	}
+	svc, ok := f.services[serviceID]
+	if !ok {
+		return gophercloud.ErrDefault404{}
+	}
+	svc.Type = s.Type
+	svc.Enabled = s.Enabled
+	svc.Extra = copyExtra(svc.Extra)
+	svc.Extra["name"] = s.Name
+	svc.Extra["description"] = s.Description
+	f.services[serviceID] = svc
+	return nil
+}
+
+// DeleteService - implements openstackclient.OpenStackClient, keystone
+// deletes the endpoints of the service with it
+func (f *OpenStackClient) DeleteService(_ logr.Logger, serviceID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["DeleteService"]; err != nil {
+		return err
+	}
+	delete(f.services, serviceID)
+	for id, endpt := range f.endpoints {
+		if endpt.ServiceID == serviceID {
+			delete(f.endpoints, id)
+		}
+	}
+	return nil
+}
+
+// ListServices - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) ListServices() ([]services.Service, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["ListServices"]; err != nil {
+		return nil, err
+	}
+	result := []services.Service{}
+	for _, svc := range f.services {
+		svc.Extra = copyExtra(svc.Extra)
+		result = append(result, svc)
+	}
+	return result, nil
+}
+
+// UpdateServiceExtra - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) UpdateServiceExtra(serviceID string, extra map[string]interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["UpdateServiceExtra"]; err != nil {
+		return err
+	}
+	svc, ok := f.services[serviceID]
+	if !ok {
+		return gophercloud.ErrDefault404{}
+	}
+	svc.Extra = copyExtra(svc.Extra)
+	for key, value := range extra {
+		svc.Extra[key] = value
+	}
+	f.services[serviceID] = svc
+	return nil
+}
+
+// CreateEndpoint - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) CreateEndpoint(_ logr.Logger, e openstack.Endpoint) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["CreateEndpoint"]; err != nil {
+		return "", err
+	}
+	if existing := f.findEndpoints(e.ServiceID, e.Availability); len(existing) > 0 {
+		return existing[0].ID, nil
+	}
+	endpt := openstackclient.Endpoint{
+		Endpoint: endpoints.Endpoint{
+			ID:           uuid.NewString(),
+			Availability: e.Availability,
+			Name:         e.Name,
+			Region:       f.region,
+			ServiceID:    e.ServiceID,
+			URL:          e.URL,
+		},
+		Extra: map[string]interface{}{},
+	}
+	f.endpoints[endpt.ID] = endpt
+	return endpt.ID, nil
+}
+
+// GetEndpoints - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) GetEndpoints(
+	_ logr.Logger,
+	serviceID string,
+	endpointInterface string,
+) ([]endpoints.Endpoint, error) {
+	var availability gophercloud.Availability
+	if endpointInterface != "" {
+		var err error
+		availability, err = openstack.GetAvailability(endpointInterface)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["GetEndpoints"]; err != nil {
+		return nil, err
+	}
+	result := []endpoints.Endpoint{}
+	for _, endpt := range f.findEndpoints(serviceID, availability) {
+		result = append(result, endpt.Endpoint)
+	}
+	return result, nil
+}
+
+// findEndpoints - returns the endpoints of the service in the region, of
+// all interfaces if availability is empty
+func (f *OpenStackClient) findEndpoints(serviceID string, availability gophercloud.Availability) []openstackclient.Endpoint {
+	result := []openstackclient.Endpoint{}
+	for _, endpt := range f.endpoints {
+		if endpt.ServiceID == serviceID && endpt.Region == f.region &&
+			(availability == "" || endpt.Availability == availability) {
+			endpt.Extra = copyExtra(endpt.Extra)
+			result = append(result, endpt)
+		}
+	}
+	return result
+}
+
+// UpdateEndpoint - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) UpdateEndpoint(_ logr.Logger, e openstack.Endpoint, endpointID string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["UpdateEndpoint"]; err != nil {
+		return "", err
+	}
+	endpt, ok := f.endpoints[endpointID]
+	if !ok {
+		return "", gophercloud.ErrDefault404{}
+	}
+	endpt.Availability = e.Availability
+	endpt.Name = e.Name
+	endpt.Region = f.region
+	endpt.ServiceID = e.ServiceID
+	endpt.URL = e.URL
+	f.endpoints[endpointID] = endpt
+	return endpointID, nil
+}
+
+// DeleteEndpoint - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) DeleteEndpoint(_ logr.Logger, e openstack.Endpoint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["DeleteEndpoint"]; err != nil {
+		return err
+	}
+	for _, endpt := range f.findEndpoints(e.ServiceID, e.Availability) {
+		delete(f.endpoints, endpt.ID)
+	}
+	return nil
+}
+
+// ListEndpoints - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) ListEndpoints(opts endpoints.ListOpts) ([]openstackclient.Endpoint, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["ListEndpoints"]; err != nil {
+		return nil, err
+	}
+	result := []openstackclient.Endpoint{}
+	for _, endpt := range f.endpoints {
+		if (opts.ServiceID == "" || endpt.ServiceID == opts.ServiceID) &&
+			(opts.RegionID == "" || endpt.Region == opts.RegionID) &&
+			(opts.Availability == "" || endpt.Availability == opts.Availability) {
+			endpt.Extra = copyExtra(endpt.Extra)
+			result = append(result, endpt)
+		}
+	}
+	return result, nil
+}
+
+// UpdateEndpointExtra - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) UpdateEndpointExtra(endpointID string, extra map[string]interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["UpdateEndpointExtra"]; err != nil {
+		return err
+	}
+	endpt, ok := f.endpoints[endpointID]
+	if !ok {
+		return gophercloud.ErrDefault404{}
+	}
+	endpt.Extra = copyExtra(endpt.Extra)
+	for key, value := range extra {
+		endpt.Extra[key] = value
+	}
+	f.endpoints[endpointID] = endpt
+	return nil
+}
+
+// EnsureRegion - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) EnsureRegion(regionID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["EnsureRegion"]; err != nil {
+		return err
+	}
+	f.regions[regionID] = true
+	return nil
+}
+
+// HasRegion - returns true if the region exists
+func (f *OpenStackClient) HasRegion(regionID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.regions[regionID]
+}
+
+// CreateRegionalEndpoint - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) CreateRegionalEndpoint(e openstack.Endpoint, region string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["CreateRegionalEndpoint"]; err != nil {
+		return "", err
+	}
+	if !f.regions[region] {
+		return "", gophercloud.ErrDefault400{}
+	}
+	endpt := openstackclient.Endpoint{
+		Endpoint: endpoints.Endpoint{
+			ID:           uuid.NewString(),
+			Availability: e.Availability,
+			Name:         e.Name,
+			Region:       region,
+			ServiceID:    e.ServiceID,
+			URL:          e.URL,
+		},
+		Extra: map[string]interface{}{},
+	}
+	f.endpoints[endpt.ID] = endpt
+	return endpt.ID, nil
+}
+
+// UpdateEndpointURL - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) UpdateEndpointURL(endpointID string, url string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["UpdateEndpointURL"]; err != nil {
+		return err
+	}
+	endpt, ok := f.endpoints[endpointID]
+	if !ok {
+		return gophercloud.ErrDefault404{}
+	}
+	endpt.URL = url
+	f.endpoints[endpointID] = endpt
+	return nil
+}
+
+// DeleteEndpointByID - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) DeleteEndpointByID(endpointID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["DeleteEndpointByID"]; err != nil {
+		return err
+	}
+	delete(f.endpoints, endpointID)
+	return nil
+}
+
+// CreateDomain - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) CreateDomain(_ logr.Logger, d openstack.Domain) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["CreateDomain"]; err != nil {
+		return "", err
+	}
+	if domain := f.findDomain(d.Name); domain != nil {
+		return domain.ID, nil
+	}
+	domain := domains.Domain{
+		ID:          uuid.NewString(),
+		Name:        d.Name,
+		Description: d.Description,
+		Enabled:     true,
+	}
+	f.domains[domain.ID] = domain
+	return domain.ID, nil
+}
+
+// GetDomain - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) GetDomain(name string) (*domains.Domain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["GetDomain"]; err != nil {
+		return nil, err
+	}
+	return f.findDomain(name), nil
+}
+
+// findDomain - returns a copy of the domain with the name
+func (f *OpenStackClient) findDomain(name string) *domains.Domain {
+	for _, domain := range f.domains {
+		if domain.Name == name {
+			return &domain
+		}
+	}
+	return nil
+}
+
+// CreateProject - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) CreateProject(_ logr.Logger, p openstack.Project) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["CreateProject"]; err != nil {
+		return "", err
+	}
+	if project := f.findProject(p.Name, p.DomainID); project != nil {
+		return project.ID, nil
+	}
+	project := projects.Project{
+		ID:          uuid.NewString(),
+		Name:        p.Name,
+		Description: p.Description,
+		DomainID:    p.DomainID,
+		Enabled:     true,
+	}
+	f.projects[project.ID] = project
+	return project.ID, nil
+}
+
+// GetProject - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) GetProject(_ logr.Logger, projectName string, domainID string) (*projects.Project, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["GetProject"]; err != nil {
+		return nil, err
+	}
+	project := f.findProject(projectName, domainID)
+	if project == nil {
+		return nil, fmt.Errorf("%s %s", projectName, openstack.ProjectNotFound) // nolint:err113
+	}
+	return project, nil
+}
+
+// findProject - returns a copy of the project with the name in the domain,
+// of any domain if domainID is empty
+func (f *OpenStackClient) findProject(name string, domainID string) *projects.Project {
+	for _, project := range f.projects {
+		if project.Name == name && (domainID == "" || project.DomainID == domainID) {
+			return &project
+		}
+	}
+	return nil
+}
+
+// CreateUser - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) CreateUser(_ logr.Logger, u openstack.User) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["CreateUser"]; err != nil {
+		return "", err
+	}
+	if user := f.findUser(u.Name, u.DomainID); user != nil {
+		return user.ID, nil
+	}
+	user := users.User{
+		ID:               uuid.NewString(),
+		Name:             u.Name,
+		DomainID:         u.DomainID,
+		DefaultProjectID: u.ProjectID,
+		Enabled:          true,
+	}
+	f.users[user.ID] = user
+	f.passwords[user.ID] = u.Password
+	return user.ID, nil
+}
+
+// GetUser - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) GetUser(_ logr.Logger, userName string, domainID string) (*users.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["GetUser"]; err != nil {
+		return nil, err
+	}
+	user := f.findUser(userName, domainID)
+	if user == nil {
+		return nil, fmt.Errorf("%s %s", userName, openstack.UserNotFound) // nolint:err113
+	}
+	return user, nil
+}
+
+// findUser - returns a copy of the user with the name in the domain, of any
+// domain if domainID is empty
+func (f *OpenStackClient) findUser(name string, domainID string) *users.User {
+	for _, user := range f.users {
+		if user.Name == name && (domainID == "" || user.DomainID == domainID) {
+			return &user
+		}
+	}
+	return nil
+}
+
+// DeleteUser - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) DeleteUser(_ logr.Logger, userName string, domainID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["DeleteUser"]; err != nil {
+		return err
+	}
+	user := f.findUser(userName, domainID)
+	if user == nil {
+		return nil
+	}
+	f.deleteUser(user.ID)
+	return nil
+}
+
+// CreateRole - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) CreateRole(_ logr.Logger, roleName string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["CreateRole"]; err != nil {
+		return "", err
+	}
+	if role := f.findRole(roleName); role != nil {
+		return role.ID, nil
+	}
+	role := roles.Role{
+		ID:   uuid.NewString(),
+		Name: roleName,
+	}
+	f.roles[role.ID] = role
+	return role.ID, nil
+}
+
+// GetRole - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) GetRole(_ logr.Logger, roleName string) (*roles.Role, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["GetRole"]; err != nil {
+		return nil, err
+	}
+	role := f.findRole(roleName)
+	if role == nil {
+		return nil, fmt.Errorf("%s %s", roleName, openstack.RoleNotFound) // nolint:err113
+	}
+	return role, nil
+}
+
+// findRole - returns a copy of the role with the name, keystone role names
+// are case insensitive
+func (f *OpenStackClient) findRole(name string) *roles.Role {
+	for _, role := range f.roles {
+		if strings.EqualFold(role.Name, name) {
+			return &role
+		}
+	}
+	return nil
+}
+
+// AssignUserRole - implements openstackclient.OpenStackClient
+func (f *OpenStackClient) AssignUserRole(_ logr.Logger, roleName string, userID string, projectID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.errors["AssignUserRole"]; err != nil {
+		return err
+	}
+	role := f.findRole(roleName)
+	if role == nil {
+		return fmt.Errorf("%s %s", roleName, openstack.RoleNotFound) // nolint:err113
+	}
+	f.assignments[roleAssignment{roleID: role.ID, userID: userID, projectID: projectID}] = true
+	return nil
+}
+
+// copyExtra - returns a copy of the extra attributes, the callers must not
+// modify the state of the fake
+func copyExtra(extra map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(extra))
+	for key, value := range extra {
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,611 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/domains"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/users"
+)
+
+// apiPrefix - path of the identity API of the client returned by
+// GetOSClient
+const apiPrefix = "/v3/"
+
+// collection - resource collection of the identity API kept in the generic
+// store of the fake
+type collection struct {
+	// singular - key of a single resource in the request and response bodies
+	singular string
+	// plural - key of the resource list in the response bodies
+	plural string
+	// idField - attribute identifying the resource
+	idField string
+}
+
+// collections - the resource collections of the identity package besides
+// users, projects, domains and roles, by their last path segment
+var collections = map[string]collection{
+	"credentials":        {singular: "credential", plural: "credentials", idField: "id"},
+	"OS-EC2":             {singular: "credential", plural: "credentials", idField: "access"},
+	"endpoint_groups":    {singular: "endpoint_group", plural: "endpoint_groups", idField: "id"},
+	"projects":           {singular: "project", plural: "projects", idField: "id"},
+	"identity_providers": {singular: "identity_provider", plural: "identity_providers", idField: "id"},
+	"protocols":          {singular: "protocol", plural: "protocols", idField: "id"},
+	"mappings":           {singular: "mapping", plural: "mappings", idField: "id"},
+	"limits":             {singular: "limit", plural: "limits", idField: "id"},
+	"registered_limits":  {singular: "registered_limit", plural: "registered_limits", idField: "id"},
+}
+
+// restTransport - serves the requests of the client returned by GetOSClient
+// from the state of the fake
+type restTransport struct {
+	f *OpenStackClient
+}
+
+// RoundTrip - implements http.RoundTripper
+func (t restTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.f.serveHTTP(rec, r)
+	resp := rec.Result()
+	resp.Request = r
+	return resp, nil
+}
+
+// restError - failed request of the identity API
+type restError struct {
+	code    int
+	message string
+}
+
+// Error - implements error
+func (e restError) Error() string {
+	return e.message
+}
+
+// notFound - returns the 404 error of the resource at p
+func notFound(p string) restError {
+	return restError{code: http.StatusNotFound, message: fmt.Sprintf("could not find %s", p)}
+}
+
+// serveHTTP - handles a request of the identity API. Users, projects,
+// domains and role relationships share the state of the typed calls, all
+// other resources live in a generic store keyed by their path.
+func (f *OpenStackClient) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	if r.Body != nil {
+		data, err := io.ReadAll(r.Body)
+		if err == nil && len(data) > 0 {
+			err = json.Unmarshal(data, &body)
+		}
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorBody(http.StatusBadRequest, err.Error()))
+			return
+		}
+	}
+	p := strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	code, result, err := f.route(r.Method, p, r.URL.Query(), body)
+	if err != nil {
+		restErr, ok := err.(restError)
+		if !ok {
+			restErr = restError{code: http.StatusInternalServerError, message: err.Error()}
+		}
+		writeJSON(w, restErr.code, errorBody(restErr.code, restErr.message))
+		return
+	}
+	writeJSON(w, code, result)
+}
+
+// route - dispatches the request to the handler of the resource
+func (f *OpenStackClient) route(
+	method string,
+	p string,
+	query map[string][]string,
+	body map[string]interface{},
+) (int, interface{}, error) {
+	segments := strings.Split(p, "/")
+	switch {
+	case len(segments) <= 2 && segments[0] == "users":
+		if err := f.errors["users"]; err != nil {
+			return 0, nil, err
+		}
+		return f.serveUsers(method, segments[1:], query, body)
+	case len(segments) == 1 && segments[0] == "projects":
+		if err := f.errors["projects"]; err != nil {
+			return 0, nil, err
+		}
+		return f.serveProjects(method, query)
+	case len(segments) == 2 && segments[0] == "domains":
+		if err := f.errors["domains"]; err != nil {
+			return 0, nil, err
+		}
+		return f.serveDomain(method, segments[1], body)
+	case len(segments) == 4 && segments[0] == "roles" && segments[2] == "implies":
+		if err := f.errors["implies"]; err != nil {
+			return 0, nil, err
+		}
+		return f.serveImpliedRole(method, segments[1], segments[3])
+	case len(segments) == 5 && segments[0] == "system" && segments[1] == "users" && segments[3] == "roles":
+		if err := f.errors["system"]; err != nil {
+			return 0, nil, err
+		}
+		return f.serveRoleAssignment(method, segments[2], segments[4], systemScope)
+	case len(segments) == 6 && segments[0] == "projects" && segments[2] == "users" && segments[4] == "roles":
+		if err := f.errors["projects"]; err != nil {
+			return 0, nil, err
+		}
+		if _, ok := f.projects[segments[1]]; !ok {
+			return 0, nil, notFound("project role assignment")
+		}
+		return f.serveRoleAssignment(method, segments[3], segments[5], segments[1])
+	}
+	return f.serveGeneric(method, p, query, body)
+}
+
+// serveUsers - handles /users and /users/{id}
+func (f *OpenStackClient) serveUsers(
+	method string,
+	ids []string,
+	query map[string][]string,
+	body map[string]interface{},
+) (int, interface{}, error) {
+	if len(ids) == 0 {
+		switch method {
+		case http.MethodGet:
+			result := []interface{}{}
+			for _, user := range f.users {
+				object := userObject(user)
+				if matches(object, query) {
+					result = append(result, object)
+				}
+			}
+			return http.StatusOK, listBody("users", result), nil
+		case http.MethodPost:
+			object, err := objectOf(body, "user")
+			if err != nil {
+				return 0, nil, err
+			}
+			if f.findUser(fmt.Sprint(object["name"]), fmt.Sprint(object["domain_id"])) != nil {
+				return 0, nil, restError{code: http.StatusConflict, message: "duplicate user"}
+			}
+			object["id"] = uuid.NewString()
+			if _, ok := object["enabled"]; !ok {
+				object["enabled"] = true
+			}
+			user, err := f.storeUser(object)
+			if err != nil {
+				return 0, nil, err
+			}
+			return http.StatusCreated, map[string]interface{}{"user": userObject(user)}, nil
+		}
+		return 0, nil, restError{code: http.StatusMethodNotAllowed, message: method}
+	}
+
+	user, ok := f.users[ids[0]]
+	if !ok {
+		return 0, nil, notFound("user " + ids[0])
+	}
+	switch method {
+	case http.MethodGet:
+		return http.StatusOK, map[string]interface{}{"user": userObject(user)}, nil
+	case http.MethodPatch:
+		update, err := objectOf(body, "user")
+		if err != nil {
+			return 0, nil, err
+		}
+		object := userObject(user)
+		for key, value := range update {
+			object[key] = value
+		}
+		// keystone merges the options, null removes one
+		if options, ok := update["options"].(map[string]interface{}); ok {
+			merged := map[string]interface{}{}
+			for key, value := range user.Options {
+				merged[key] = value
+			}
+			for key, value := range options {
+				if value == nil {
+					delete(merged, key)
+				} else {
+					merged[key] = value
+				}
+			}
+			object["options"] = merged
+		}
+		user, err = f.storeUser(object)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, map[string]interface{}{"user": userObject(user)}, nil
+	case http.MethodDelete:
+		f.deleteUser(user.ID)
+		return http.StatusNoContent, nil, nil
+	}
+	return 0, nil, restError{code: http.StatusMethodNotAllowed, message: method}
+}
+
+// storeUser - saves the user of the API object, the password gets kept
+// separately like keystone does
+func (f *OpenStackClient) storeUser(object map[string]interface{}) (users.User, error) {
+	var user users.User
+	if password, ok := object["password"]; ok {
+		delete(object, "password")
+		f.passwords[fmt.Sprint(object["id"])] = fmt.Sprint(password)
+	}
+	data, err := json.Marshal(object)
+	if err != nil {
+		return user, err
+	}
+	err = json.Unmarshal(data, &user)
+	if err != nil {
+		return user, err
+	}
+	f.users[user.ID] = user
+	return user, nil
+}
+
+// userObject - returns the API object of the user
+func userObject(user users.User) map[string]interface{} {
+	object := toObject(user)
+	delete(object, "links")
+	for key, value := range user.Extra {
+		object[key] = value
+	}
+	return object
+}
+
+// serveProjects - handles /projects
+func (f *OpenStackClient) serveProjects(method string, query map[string][]string) (int, interface{}, error) {
+	if method != http.MethodGet {
+		return 0, nil, restError{code: http.StatusMethodNotAllowed, message: method}
+	}
+	result := []interface{}{}
+	for _, project := range f.projects {
+		object := projectObject(project)
+		if matches(object, query) {
+			result = append(result, object)
+		}
+	}
+	return http.StatusOK, listBody("projects", result), nil
+}
+
+// projectObject - returns the API object of the project
+func projectObject(project projects.Project) map[string]interface{} {
+	object := toObject(project)
+	for key, value := range project.Extra {
+		object[key] = value
+	}
+	return object
+}
+
+// serveDomain - handles /domains/{id}, keystone only deletes disabled
+// domains and deletes their projects and users with them
+func (f *OpenStackClient) serveDomain(method string, id string, body map[string]interface{}) (int, interface{}, error) {
+	domain, ok := f.domains[id]
+	if !ok {
+		return 0, nil, notFound("domain " + id)
+	}
+	switch method {
+	case http.MethodGet:
+		return http.StatusOK, map[string]interface{}{"domain": toObject(domain)}, nil
+	case http.MethodPatch:
+		update, err := objectOf(body, "domain")
+		if err != nil {
+			return 0, nil, err
+		}
+		object := toObject(domain)
+		for key, value := range update {
+			object[key] = value
+		}
+		data, err := json.Marshal(object)
+		if err != nil {
+			return 0, nil, err
+		}
+		domain = domains.Domain{}
+		if err := json.Unmarshal(data, &domain); err != nil {
+			return 0, nil, err
+		}
+		f.domains[id] = domain
+		return http.StatusOK, map[string]interface{}{"domain": toObject(domain)}, nil
+	case http.MethodDelete:
+		if domain.Enabled {
+			return 0, nil, restError{code: http.StatusForbidden, message: "cannot delete an enabled domain"}
+		}
+		delete(f.domains, id)
+		for projectID, project := range f.projects {
+			if project.DomainID == id {
+				delete(f.projects, projectID)
+			}
+		}
+		for userID, user := range f.users {
+			if user.DomainID == id {
+				f.deleteUser(userID)
+			}
+		}
+		return http.StatusNoContent, nil, nil
+	}
+	return 0, nil, restError{code: http.StatusMethodNotAllowed, message: method}
+}
+
+// serveImpliedRole - handles /roles/{prior}/implies/{implied}
+func (f *OpenStackClient) serveImpliedRole(method string, priorRoleID string, impliedRoleID string) (int, interface{}, error) {
+	_, priorOK := f.roles[priorRoleID]
+	_, impliedOK := f.roles[impliedRoleID]
+	if !priorOK || !impliedOK {
+		return 0, nil, notFound("role")
+	}
+	key := impliedRole{priorRoleID: priorRoleID, impliedRoleID: impliedRoleID}
+	switch method {
+	case http.MethodPut:
+		f.impliedRoles[key] = true
+		return http.StatusCreated, nil, nil
+	case http.MethodGet, http.MethodHead:
+		if !f.impliedRoles[key] {
+			return 0, nil, notFound("implied role")
+		}
+		return http.StatusOK, nil, nil
+	case http.MethodDelete:
+		if !f.impliedRoles[key] {
+			return 0, nil, notFound("implied role")
+		}
+		delete(f.impliedRoles, key)
+		return http.StatusNoContent, nil, nil
+	}
+	return 0, nil, restError{code: http.StatusMethodNotAllowed, message: method}
+}
+
+// serveRoleAssignment - handles /system/users/{user}/roles/{role} and
+// /projects/{project}/users/{user}/roles/{role}
+func (f *OpenStackClient) serveRoleAssignment(method string, userID string, roleID string, projectID string) (int, interface{}, error) {
+	_, userOK := f.users[userID]
+	_, roleOK := f.roles[roleID]
+	if !userOK || !roleOK {
+		return 0, nil, notFound("role assignment")
+	}
+	key := roleAssignment{roleID: roleID, userID: userID, projectID: projectID}
+	switch method {
+	case http.MethodPut:
+		f.assignments[key] = true
+		return http.StatusNoContent, nil, nil
+	case http.MethodGet, http.MethodHead:
+		if !f.assignments[key] {
+			return 0, nil, notFound("role assignment")
+		}
+		return http.StatusNoContent, nil, nil
+	case http.MethodDelete:
+		delete(f.assignments, key)
+		return http.StatusNoContent, nil, nil
+	}
+	return 0, nil, restError{code: http.StatusMethodNotAllowed, message: method}
+}
+
+// serveGeneric - handles the resources of the collections, kept as API
+// objects keyed by their path. A PUT without body creates a relationship,
+// e.g. of an endpoint group and a project.
+func (f *OpenStackClient) serveGeneric(
+	method string,
+	p string,
+	query map[string][]string,
+	body map[string]interface{},
+) (int, interface{}, error) {
+	base := path.Base(p)
+	if c, ok := collections[base]; ok {
+		if err := f.errors[base]; err != nil {
+			return 0, nil, err
+		}
+		return f.serveCollection(method, p, c, query, body)
+	}
+
+	parent := path.Dir(p)
+	c, ok := collections[path.Base(parent)]
+	if !ok {
+		return 0, nil, notFound(p)
+	}
+	if err := f.errors[path.Base(parent)]; err != nil {
+		return 0, nil, err
+	}
+	object, exists := f.resources[p]
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		if !exists {
+			return 0, nil, notFound(p)
+		}
+		if object == nil {
+			return http.StatusNoContent, nil, nil
+		}
+		return http.StatusOK, map[string]interface{}{c.singular: copyExtra(object)}, nil
+	case http.MethodPut:
+		if body == nil {
+			f.resources[p] = nil
+			return http.StatusNoContent, nil, nil
+		}
+		if exists {
+			return 0, nil, restError{code: http.StatusConflict, message: fmt.Sprintf("%s exists", p)}
+		}
+		object, err := objectOf(body, c.singular)
+		if err != nil {
+			return 0, nil, err
+		}
+		object[c.idField] = base
+		f.resources[p] = object
+		return http.StatusCreated, map[string]interface{}{c.singular: copyExtra(object)}, nil
+	case http.MethodPatch:
+		if !exists || object == nil {
+			return 0, nil, notFound(p)
+		}
+		update, err := objectOf(body, c.singular)
+		if err != nil {
+			return 0, nil, err
+		}
+		object = copyExtra(object)
+		for key, value := range update {
+			object[key] = value
+		}
+		f.resources[p] = object
+		return http.StatusOK, map[string]interface{}{c.singular: copyExtra(object)}, nil
+	case http.MethodDelete:
+		if !exists {
+			return 0, nil, notFound(p)
+		}
+		for key := range f.resources {
+			if key == p || strings.HasPrefix(key, p+"/") {
+				delete(f.resources, key)
+			}
+		}
+		return http.StatusNoContent, nil, nil
+	}
+	return 0, nil, restError{code: http.StatusMethodNotAllowed, message: method}
+}
+
+// serveCollection - lists the resources of the collection at p or creates
+// them, one or a batch
+func (f *OpenStackClient) serveCollection(
+	method string,
+	p string,
+	c collection,
+	query map[string][]string,
+	body map[string]interface{},
+) (int, interface{}, error) {
+	switch method {
+	case http.MethodGet:
+		result := []interface{}{}
+		for key, object := range f.resources {
+			if object != nil && path.Dir(key) == p && matches(object, query) {
+				result = append(result, copyExtra(object))
+			}
+		}
+		return http.StatusOK, listBody(c.plural, result), nil
+	case http.MethodPost:
+		if batch, ok := body[c.plural].([]interface{}); ok {
+			result := []interface{}{}
+			for _, item := range batch {
+				object, ok := item.(map[string]interface{})
+				if !ok {
+					return 0, nil, restError{code: http.StatusBadRequest, message: "invalid " + c.plural}
+				}
+				result = append(result, f.createResource(p, c, object))
+			}
+			return http.StatusCreated, map[string]interface{}{c.plural: result}, nil
+		}
+		object, ok := body[c.singular].(map[string]interface{})
+		if !ok {
+			// the EC2 credentials are not wrapped
+			object = body
+		}
+		if object == nil {
+			return 0, nil, restError{code: http.StatusBadRequest, message: "missing " + c.singular}
+		}
+		return http.StatusCreated, map[string]interface{}{c.singular: f.createResource(p, c, object)}, nil
+	}
+	return 0, nil, restError{code: http.StatusMethodNotAllowed, message: method}
+}
+
+// createResource - stores the new resource of the collection at p and
+// returns a copy of it
+func (f *OpenStackClient) createResource(p string, c collection, object map[string]interface{}) map[string]interface{} {
+	object = copyExtra(object)
+	id := uuid.NewString()
+	if c.idField == "access" {
+		// users/{user}/credentials/OS-EC2
+		object["user_id"] = path.Base(path.Dir(path.Dir(p)))
+		object["secret"] = uuid.NewString()
+		id = strings.ReplaceAll(id, "-", "")
+	}
+	object[c.idField] = id
+	f.resources[p+"/"+id] = object
+	return copyExtra(object)
+}
+
+// deleteUser - deletes the user with its role assignments and password
+func (f *OpenStackClient) deleteUser(userID string) {
+	delete(f.users, userID)
+	delete(f.passwords, userID)
+	for assignment := range f.assignments {
+		if assignment.userID == userID {
+			delete(f.assignments, assignment)
+		}
+	}
+}
+
+// objectOf - returns the resource of the request body
+func objectOf(body map[string]interface{}, key string) (map[string]interface{}, error) {
+	object, ok := body[key].(map[string]interface{})
+	if !ok {
+		return nil, restError{code: http.StatusBadRequest, message: "missing " + key}
+	}
+	return copyExtra(object), nil
+}
+
+// toObject - returns the API object of a gophercloud resource
+func toObject(v interface{}) map[string]interface{} {
+	object := map[string]interface{}{}
+	data, err := json.Marshal(v)
+	if err == nil {
+		_ = json.Unmarshal(data, &object)
+	}
+	return object
+}
+
+// matches - returns true if the attributes of the object match the filters
+// of the query
+func matches(object map[string]interface{}, query map[string][]string) bool {
+	for key, values := range query {
+		value, ok := object[key]
+		if !ok || len(values) == 0 || fmt.Sprint(value) != values[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// listBody - returns the response body of a list without further pages
+func listBody(key string, items []interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		key:     items,
+		"links": map[string]interface{}{"next": nil},
+	}
+}
+
+// errorBody - returns the response body of a failed request
+func errorBody(code int, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"error": map[string]interface{}{"code": code, "message": message},
+	}
+}
+
+// writeJSON - writes the response, a nil body stays empty
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	if body == nil {
+		w.WriteHeader(code)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -19,7 +19,10 @@ package functional_test
 import (
 	"fmt"
 
-	. "github.com/onsi/gomega" //revive:disable:dot-imports
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -29,6 +32,7 @@ import (
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
 	keystone_base "github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	mariadbv1 "github.com/openstack-k8s-operators/mariadb-operator/api/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -80,6 +84,54 @@ func CreateKeystoneAPI(name types.NamespacedName, spec map[string]interface{}) c
 	return th.CreateUnstructured(raw)
 }
 
+// CreateReadyKeystoneAPI - deploys a KeystoneAPI with the services it
+// depends on simulated and waits for it to be Ready. The controllers of the
+// other CRs need it to reach the keystone API.
+func CreateReadyKeystoneAPI(name types.NamespacedName) {
+	DeferCleanup(
+		k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(name.Namespace, "rabbitmq-secret"))
+	DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(name, GetDefaultKeystoneAPISpec()))
+	DeferCleanup(
+		k8sClient.Delete, ctx, CreateKeystoneAPISecret(name.Namespace, SecretName))
+	DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(name.Namespace, "memcached", infra.GetDefaultMemcachedSpec()))
+	DeferCleanup(
+		mariadb.DeleteDBService,
+		mariadb.CreateDBService(
+			name.Namespace,
+			GetKeystoneAPI(name).Spec.DatabaseInstance,
+			corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Port: 3306}},
+			},
+		),
+	)
+	databaseName := types.NamespacedName{Namespace: name.Namespace, Name: DatabaseCRName}
+	// the first reconcile of the KeystoneAPI after the start of the manager
+	// waits for the caches of the objects it creates, wait longer for the
+	// database than the helpers do
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, databaseName, &mariadbv1.MariaDBDatabase{})).Should(Succeed())
+	}, 5*timeout, interval).Should(Succeed())
+	mariadb.SimulateMariaDBAccountCompleted(types.NamespacedName{Namespace: name.Namespace, Name: AccountName})
+	mariadb.SimulateMariaDBDatabaseCompleted(databaseName)
+	infra.SimulateTransportURLReady(types.NamespacedName{
+		Name:      fmt.Sprintf("%s-keystone-transport", name.Name),
+		Namespace: name.Namespace,
+	})
+	infra.SimulateMemcachedReady(types.NamespacedName{
+		Name:      "memcached",
+		Namespace: name.Namespace,
+	})
+	th.SimulateJobSuccess(types.NamespacedName{Namespace: name.Namespace, Name: name.Name + "-db-sync"})
+	th.SimulateJobSuccess(types.NamespacedName{Namespace: name.Namespace, Name: name.Name + "-bootstrap"})
+	th.SimulateDeploymentReplicaReady(types.NamespacedName{Namespace: name.Namespace, Name: name.Name})
+	th.ExpectCondition(
+		name,
+		ConditionGetterFunc(KeystoneConditionGetter),
+		condition.ReadyCondition,
+		corev1.ConditionTrue,
+	)
+}
+
 func GetKeystoneAPI(name types.NamespacedName) *keystonev1.KeystoneAPI {
 	instance := &keystonev1.KeystoneAPI{}
 	Eventually(func(g Gomega) {
@@ -34,10 +34,13 @@ import (
 
 	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
 	topologyv1 "github.com/openstack-k8s-operators/infra-operator/apis/topology/v1beta1"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	mariadb_test "github.com/openstack-k8s-operators/mariadb-operator/api/test/helpers"
 	mariadbv1 "github.com/openstack-k8s-operators/mariadb-operator/api/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -425,12 +428,18 @@ var _ = Describe("Keystone controller", func() {
 			Expect(configData).To(
 				ContainSubstring(fmt.Sprintf("connection=mysql+pymysql://%s:%s@hostname-for-openstack.%s.svc/keystone?read_default_file=/etc/my.cnf",
 					mariadbAccount.Spec.UserName, mariadbSecret.Data[mariadbv1.DatabasePasswordSelector], namespace)))
+			Expect(configData).To(
+				ContainSubstring("[unified_limit]\nenforcement_model=flat"))
+			Expect(configData).To(
+				ContainSubstring("[healthcheck]\nbackends=disable_by_file\ndisable_by_file_path=/etc/keystone/healthcheck_disable\ndetailed=false"))
 			configData = string(scrt.Data["my.cnf"])
 			Expect(configData).To(
 				ContainSubstring("[client]\nssl=0"))
 			httpdConfData := string(scrt.Data["httpd.conf"])
 			Expect(httpdConfData).To(
 				ContainSubstring("TimeOut 60"))
+			Expect(httpdConfData).To(
+				ContainSubstring(`LogFormat "%h %l %u %t \"%r\" %>s %b \"%{Referer}i\" \"%{User-Agent}i\" global_request_id=%{X-OpenStack-Request-ID}i request_id=%{X-OpenStack-Request-ID}o" combined`))
 		})
 		It("should create a Secret for fernet keys", func() {
 			th.GetSecret(types.NamespacedName{
@@ -570,6 +579,48 @@ var _ = Describe("Keystone controller", func() {
 				corev1.ConditionTrue,
 			)
 		})
+
+		It("re-runs the bootstrap job when the rebootstrap annotation is set", func() {
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				condition.BootstrapReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				keystone.Annotations = map[string]string{
+					keystonev1.RebootstrapAnnotation: "restore-1",
+				}
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				j := th.GetJob(bootstrapJobName)
+				g.Expect(GetEnvVarValue(
+					j.Spec.Template.Spec.Containers[0].Env, "KEYSTONE_REBOOTSTRAP_REQUEST", "")).To(Equal("restore-1"))
+			}, timeout, interval).Should(Succeed())
+			th.ExpectConditionWithDetails(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				condition.BootstrapReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				fmt.Sprintf(keystonev1.KeystoneRebootstrapRunningMessage, keystonev1.RebootstrapAnnotation, "restore-1"),
+			)
+
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				condition.BootstrapReadyCondition,
+				corev1.ConditionTrue,
+			)
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Status.LastRebootstrapRequest).To(Equal("restore-1"))
+			}, timeout, interval).Should(Succeed())
+		})
 	})
 
 	When("Deployment is completed", func() {
@@ -605,6 +656,20 @@ var _ = Describe("Keystone controller", func() {
 			th.SimulateDeploymentReplicaReady(deploymentName)
 		})
 
+		It("verifies the default roles of the bootstrap", func() {
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneBootstrapRolesReadyCondition,
+				corev1.ConditionTrue,
+			)
+			adminUser, err := osClient.GetUser(logger, "admin", "default")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(osClient.HasImpliedRole("admin", "member")).To(BeTrue())
+			Expect(osClient.HasImpliedRole("member", "reader")).To(BeTrue())
+			Expect(osClient.HasSystemRoleAssignment("admin", adminUser.ID)).To(BeTrue())
+		})
+
 		It("should have deployment ready condition and cronjob ready condition", func() {
 			th.ExpectCondition(
 				keystoneAPIName,
@@ -631,8 +696,19 @@ var _ = Describe("Keystone controller", func() {
 			Expect(*(deployment.Spec.Replicas)).Should(Equal(int32(1)))
 		})
 
+		It("should drain the connections of stopping pods", func() {
+			deployment := th.GetDeployment(deploymentName)
+			Expect(deployment.Spec.Template.Spec.TerminationGracePeriodSeconds).To(Equal(ptr.To[int64](60)))
+			Expect(deployment.Spec.Template.Spec.Containers[0].Lifecycle.PreStop.Exec.Command).To(Equal([]string{
+				"/bin/bash", "-c",
+				"touch /etc/keystone/healthcheck_disable; sleep 5; /usr/sbin/httpd -k graceful-stop",
+			}))
+		})
+
 		It("should create a CronJob for trust flush", func() {
-			GetCronJob(cronJobName)
+			cronJob := GetCronJob(cronJobName)
+			Expect(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Args).To(
+				Equal([]string{"-c", "keystone-manage trust_flush"}))
 		})
 
 		It("should create a ConfigMap and Secret for client config", func() {
@@ -1109,6 +1185,10 @@ var _ = Describe("Keystone controller", func() {
 
 			Expect(container.ReadinessProbe.HTTPGet.Scheme).To(Equal(corev1.URISchemeHTTPS))
 			Expect(container.LivenessProbe.HTTPGet.Scheme).To(Equal(corev1.URISchemeHTTPS))
+			Expect(container.ReadinessProbe.HTTPGet.Path).To(Equal("/healthcheck"))
+			Expect(container.LivenessProbe.HTTPGet.Path).To(Equal("/v3"))
+			// a pod disabled by the healthcheck must not fail liveness
+			Expect(container.LivenessProbe.HTTPGet.Path).NotTo(Equal(container.ReadinessProbe.HTTPGet.Path))
 
 			scrt := th.GetSecret(keystoneAPIConfigDataName)
 			Expect(scrt).ShouldNot(BeNil())
@@ -1551,6 +1631,52 @@ var _ = Describe("Keystone controller", func() {
 			}, timeout, interval).Should(Succeed())
 
 		})
+
+		It("reports the fernet keys ready", func() {
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneFernetKeysReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+
+		It("reports pods which do not use the current fernet keys", func() {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "keystone-stale",
+					Namespace: namespace,
+					Labels: map[string]string{
+						common.AppSelector:   "keystone",
+						common.OwnerSelector: keystoneAPIName.Name,
+					},
+					Annotations: map[string]string{
+						"keystone.openstack.org/fernet-keys-hash": "stale",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "keystone-api", Image: "keystone"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, pod)
+
+			// trigger a reconcile
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				keystone.Spec.APITimeout = 120
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectConditionWithDetails(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneFernetKeysReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				fmt.Sprintf(keystonev1.KeystoneFernetKeysPodsMismatchMessage, 1, 1),
+			)
+		})
 	})
 
 	When("Topology is referenced", func() {
@@ -1821,6 +1947,1134 @@ var _ = Describe("Keystone controller", func() {
 		})
 	})
 
+	When("A KeystoneAPI is created with jobSettings", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["jobSettings"] = map[string]interface{}{
+				"backoffLimit":            2,
+				"activeDeadlineSeconds":   600,
+				"ttlSecondsAfterFinished": 3600,
+			}
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("sets the job settings on the jobs", func() {
+			Eventually(func(g Gomega) {
+				for _, spec := range []batchv1.JobSpec{
+					th.GetJob(dbSyncJobName).Spec,
+					th.GetJob(bootstrapJobName).Spec,
+					GetCronJob(cronJobName).Spec.JobTemplate.Spec,
+				} {
+					g.Expect(spec.BackoffLimit).To(Equal(ptr.To[int32](2)))
+					g.Expect(spec.ActiveDeadlineSeconds).To(Equal(ptr.To[int64](600)))
+					g.Expect(spec.TTLSecondsAfterFinished).To(Equal(ptr.To[int32](3600)))
+				}
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A KeystoneAPI is created with a databaseSyncAccount", func() {
+		var syncAccountName types.NamespacedName
+		var dbGrantsJobName types.NamespacedName
+		var dbSyncConfigName types.NamespacedName
+
+		BeforeEach(func() {
+			syncAccountName = types.NamespacedName{
+				Name:      AccountName + "-sync",
+				Namespace: namespace,
+			}
+			dbGrantsJobName = types.NamespacedName{
+				Name:      "keystone-db-grants",
+				Namespace: namespace,
+			}
+			dbSyncConfigName = types.NamespacedName{
+				Name:      "keystone-db-sync-config",
+				Namespace: namespace,
+			}
+			spec := GetDefaultKeystoneAPISpec()
+			spec["databaseSyncAccount"] = syncAccountName.Name
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(mariadb.DeleteGalera, mariadb.CreateGalera(namespace, "openstack", mariadbv1.GaleraSpec{
+				GaleraSpecCore: mariadbv1.GaleraSpecCore{
+					Secret:         "osp-secret",
+					StorageRequest: "1G",
+					Replicas:       ptr.To[int32](1),
+				},
+				ContainerImage: "mariadb-image",
+			}))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			mariadb.SimulateMariaDBAccountCompleted(syncAccountName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+		})
+
+		It("restricts the databaseAccount before db sync runs with the databaseSyncAccount", func() {
+			job := th.GetJob(dbGrantsJobName)
+			container := job.Spec.Template.Spec.Containers[0]
+			Expect(container.Image).To(Equal("mariadb-image"))
+			Expect(container.Env).To(ContainElement(corev1.EnvVar{
+				Name: "DATABASE_USER", Value: mariadb.GetMariaDBAccount(keystoneAccountName).Spec.UserName}))
+			Expect(container.Env).To(ContainElement(corev1.EnvVar{
+				Name: "DATABASE_PRIVILEGES", Value: "SELECT, INSERT, UPDATE, DELETE"}))
+			Expect(container.Env).To(ContainElement(HaveField("ValueFrom.SecretKeyRef.Name", "osp-secret")))
+			// db sync waits for the privileges
+			Consistently(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, dbSyncJobName, &batchv1.Job{})).NotTo(Succeed())
+			}, "2s", interval).Should(Succeed())
+
+			th.SimulateJobSuccess(dbGrantsJobName)
+			container = th.GetJob(dbSyncJobName).Spec.Template.Spec.Containers[0]
+			Expect(container.Args[1]).To(ContainSubstring("--config-file /etc/keystone/db-sync.conf db_sync"))
+			Expect(container.VolumeMounts).To(ContainElement(HaveField("MountPath", "/etc/keystone/db-sync.conf")))
+
+			syncUser := mariadb.GetMariaDBAccount(syncAccountName).Spec.UserName
+			Expect(string(th.GetSecret(dbSyncConfigName).Data["db-sync.conf"])).To(
+				ContainSubstring("connection = mysql+pymysql://" + syncUser + ":"))
+			Expect(string(th.GetSecret(keystoneAPIConfigDataName).Data["keystone.conf"])).NotTo(
+				ContainSubstring(syncUser))
+		})
+
+		It("keeps the finalizers of both accounts", func() {
+			th.SimulateJobSuccess(dbGrantsJobName)
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			finalizer := "openstack.org/keystoneapi"
+			Expect(mariadb.GetMariaDBAccount(keystoneAccountName).Finalizers).To(ContainElement(finalizer))
+			Expect(mariadb.GetMariaDBAccount(syncAccountName).Finalizers).To(ContainElement(finalizer))
+		})
+
+		It("grants all privileges again once the databaseSyncAccount got removed", func() {
+			th.SimulateJobSuccess(dbGrantsJobName)
+			th.SimulateJobSuccess(dbSyncJobName)
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Status.Hash).To(HaveKey(keystonev1.DbGrantsHash))
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				keystoneAPI := GetKeystoneAPI(keystoneAPIName)
+				keystoneAPI.Spec.DatabaseSyncAccount = ""
+				g.Expect(k8sClient.Update(ctx, keystoneAPI)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				job := th.GetJob(dbGrantsJobName)
+				g.Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{
+					Name: "DATABASE_PRIVILEGES", Value: "ALL PRIVILEGES"}))
+			}, timeout, interval).Should(Succeed())
+			th.SimulateJobSuccess(dbGrantsJobName)
+
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Status.Hash).NotTo(HaveKey(keystonev1.DbGrantsHash))
+				g.Expect(k8sClient.Get(ctx, dbSyncConfigName, &corev1.Secret{})).NotTo(Succeed())
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A KeystoneAPI is created with imageVerification", func() {
+		var imageVerifyJobName types.NamespacedName
+
+		BeforeEach(func() {
+			imageVerifyJobName = types.NamespacedName{
+				Name:      "keystone-image-verify",
+				Namespace: namespace,
+			}
+			spec := GetDefaultKeystoneAPISpec()
+			spec["imageVerification"] = map[string]interface{}{
+				"cosignKeySecret": "cosign-key",
+			}
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+		})
+
+		It("does not run db sync and bootstrap before the images got verified", func() {
+			th.GetJob(imageVerifyJobName)
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneImageVerifiedCondition,
+				corev1.ConditionFalse,
+			)
+			Consistently(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, dbSyncJobName, &batchv1.Job{})).NotTo(Succeed())
+				g.Expect(k8sClient.Get(ctx, bootstrapJobName, &batchv1.Job{})).NotTo(Succeed())
+			}, "2s", interval).Should(Succeed())
+
+			th.SimulateJobSuccess(imageVerifyJobName)
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneImageVerifiedCondition,
+				corev1.ConditionTrue,
+			)
+			th.GetJob(dbSyncJobName)
+		})
+	})
+
+	When("A KeystoneAPI is created with imagePullSecrets", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["imagePullSecrets"] = []map[string]interface{}{
+				{"name": "registry-secret"},
+			}
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("sets the imagePullSecrets on the deployment and jobs", func() {
+			pullSecrets := []corev1.LocalObjectReference{{Name: "registry-secret"}}
+			Eventually(func(g Gomega) {
+				g.Expect(th.GetDeployment(deploymentName).Spec.Template.Spec.ImagePullSecrets).To(Equal(pullSecrets))
+				g.Expect(th.GetJob(bootstrapJobName).Spec.Template.Spec.ImagePullSecrets).To(Equal(pullSecrets))
+				g.Expect(th.GetJob(dbSyncJobName).Spec.Template.Spec.ImagePullSecrets).To(Equal(pullSecrets))
+				g.Expect(GetCronJob(cronJobName).Spec.JobTemplate.Spec.Template.Spec.ImagePullSecrets).To(Equal(pullSecrets))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A KeystoneAPI is created with pod scheduling settings", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["priorityClassName"] = "system-cluster-critical"
+			spec["runtimeClassName"] = "kata"
+			spec["schedulerName"] = "custom-scheduler"
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("sets the scheduling settings on the deployment and jobs", func() {
+			Eventually(func(g Gomega) {
+				for _, podSpec := range []corev1.PodSpec{
+					th.GetDeployment(deploymentName).Spec.Template.Spec,
+					th.GetJob(bootstrapJobName).Spec.Template.Spec,
+					th.GetJob(dbSyncJobName).Spec.Template.Spec,
+					GetCronJob(cronJobName).Spec.JobTemplate.Spec.Template.Spec,
+				} {
+					g.Expect(podSpec.PriorityClassName).To(Equal("system-cluster-critical"))
+					g.Expect(podSpec.RuntimeClassName).To(Equal(ptr.To("kata")))
+					g.Expect(podSpec.SchedulerName).To(Equal("custom-scheduler"))
+				}
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A KeystoneAPI is created with DNS settings and hostAliases", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["dnsPolicy"] = "None"
+			spec["dnsConfig"] = map[string]interface{}{
+				"nameservers": []string{"192.0.2.53"},
+			}
+			spec["hostAliases"] = []map[string]interface{}{
+				{"ip": "192.0.2.10", "hostnames": []string{"keystone.example.com"}},
+			}
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("sets the DNS settings and hostAliases on the deployment and jobs", func() {
+			hostAliases := []corev1.HostAlias{{IP: "192.0.2.10", Hostnames: []string{"keystone.example.com"}}}
+			Eventually(func(g Gomega) {
+				for _, podSpec := range []corev1.PodSpec{
+					th.GetDeployment(deploymentName).Spec.Template.Spec,
+					th.GetJob(bootstrapJobName).Spec.Template.Spec,
+					th.GetJob(dbSyncJobName).Spec.Template.Spec,
+					GetCronJob(cronJobName).Spec.JobTemplate.Spec.Template.Spec,
+				} {
+					g.Expect(podSpec.DNSPolicy).To(Equal(corev1.DNSNone))
+					g.Expect(podSpec.DNSConfig.Nameservers).To(Equal([]string{"192.0.2.53"}))
+					g.Expect(podSpec.HostAliases).To(Equal(hostAliases))
+				}
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A KeystoneAPI is created with the GracefulReload config reload strategy", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["configReloadStrategy"] = "GracefulReload"
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("reloads config changes without restarting the pods", func() {
+			var reloadHash string
+			Eventually(func(g Gomega) {
+				reloadHash = GetKeystoneAPI(keystoneAPIName).Status.Hash[keystonev1.ConfigReloadHash]
+				g.Expect(reloadHash).NotTo(BeEmpty())
+			}, timeout, interval).Should(Succeed())
+			configHash := GetEnvVarValue(
+				th.GetDeployment(deploymentName).Spec.Template.Spec.Containers[0].Env, "CONFIG_HASH", "")
+			Expect(configHash).NotTo(BeEmpty())
+
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				keystone.Spec.CustomServiceConfig = "[DEFAULT]\ndebug = true"
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Status.Hash[keystonev1.ConfigReloadHash]).NotTo(Equal(reloadHash))
+			}, timeout, interval).Should(Succeed())
+			Expect(GetEnvVarValue(
+				th.GetDeployment(deploymentName).Spec.Template.Spec.Containers[0].Env, "CONFIG_HASH", "")).To(Equal(configHash))
+		})
+	})
+
+	When("A KeystoneAPI is created with blueGreen", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["blueGreen"] = map[string]interface{}{
+				"containerImage": "keystone-green-image",
+			}
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("creates the green deployment and switches the traffic", func() {
+			greenDeploymentName := types.NamespacedName{
+				Namespace: namespace,
+				Name:      "keystone-green",
+			}
+			publicServiceName := types.NamespacedName{
+				Namespace: namespace,
+				Name:      "keystone-public",
+			}
+
+			Eventually(func(g Gomega) {
+				green := th.GetDeployment(greenDeploymentName)
+				g.Expect(green.Spec.Template.Spec.Containers[0].Image).To(Equal("keystone-green-image"))
+				g.Expect(green.Spec.Template.Labels).To(HaveKeyWithValue("keystone.openstack.org/color", "green"))
+				g.Expect(th.GetDeployment(deploymentName).Spec.Template.Labels).To(
+					HaveKeyWithValue("keystone.openstack.org/color", "blue"))
+				g.Expect(th.GetService(publicServiceName).Spec.Selector).To(
+					HaveKeyWithValue("keystone.openstack.org/color", "blue"))
+			}, timeout, interval).Should(Succeed())
+
+			th.SimulateDeploymentReplicaReady(greenDeploymentName)
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneGreenDeploymentReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				keystone.Spec.BlueGreen.Active = keystonev1.BlueGreenGreen
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(th.GetService(publicServiceName).Spec.Selector).To(
+					HaveKeyWithValue("keystone.openstack.org/color", "green"))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A KeystoneAPI is created with zoneSpreadMaxSkew", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["zoneSpreadMaxSkew"] = 1
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("spreads the pods across zones", func() {
+			Eventually(func(g Gomega) {
+				podSpec := th.GetDeployment(deploymentName).Spec.Template.Spec
+				g.Expect(podSpec.TopologySpreadConstraints).To(HaveLen(1))
+				g.Expect(podSpec.TopologySpreadConstraints[0].TopologyKey).To(Equal(corev1.LabelTopologyZone))
+				g.Expect(podSpec.TopologySpreadConstraints[0].MaxSkew).To(Equal(int32(1)))
+				g.Expect(podSpec.TopologySpreadConstraints[0].WhenUnsatisfiable).To(Equal(corev1.ScheduleAnyway))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("holds back scale up while a pod uses old fernet keys", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(th.GetDeployment(deploymentName).Spec.Template.Annotations).To(
+					HaveKey("keystone.openstack.org/fernet-keys-hash"))
+			}, timeout, interval).Should(Succeed())
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "keystone-stale",
+					Namespace: namespace,
+					Labels: map[string]string{
+						common.AppSelector:   "keystone",
+						common.OwnerSelector: keystoneAPIName.Name,
+					},
+					Annotations: map[string]string{
+						"keystone.openstack.org/fernet-keys-hash": "stale",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "keystone-api", Image: "keystone"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, pod)
+
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				keystone.Spec.Replicas = ptr.To[int32](3)
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectConditionWithDetails(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				condition.DeploymentReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				fmt.Sprintf(keystonev1.KeystoneFernetScaleUpWaitingMessage, 3),
+			)
+			Expect(*th.GetDeployment(deploymentName).Spec.Replicas).To(Equal(int32(1)))
+		})
+	})
+
+	When("A KeystoneAPI is created with the uwsgi WSGI server", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["wsgiServer"] = "uwsgi"
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("runs the keystone API with uWSGI", func() {
+			Eventually(func(g Gomega) {
+				scrt := th.GetSecret(keystoneAPIConfigDataName)
+				g.Expect(string(scrt.Data["keystone-api-config.json"])).To(
+					ContainSubstring(`"command": "/usr/bin/uwsgi --ini /etc/keystone/uwsgi.ini"`))
+				uwsgiConfig := string(scrt.Data["uwsgi.ini"])
+				g.Expect(uwsgiConfig).To(ContainSubstring("http-socket = :5000"))
+				g.Expect(uwsgiConfig).To(ContainSubstring("processes = 3"))
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				lifecycle := th.GetDeployment(deploymentName).Spec.Template.Spec.Containers[0].Lifecycle
+				g.Expect(lifecycle.PreStop.Exec.Command[2]).To(HaveSuffix("echo q > /var/lib/keystone/uwsgi.fifo"))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A KeystoneAPI is created with a publicDeployment", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["publicDeployment"] = map[string]interface{}{
+				"replicas":   2,
+				"apiTimeout": 30,
+			}
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("serves the public endpoint from a separate deployment", func() {
+			publicDeploymentName := types.NamespacedName{
+				Namespace: namespace,
+				Name:      "keystone-public",
+			}
+
+			Eventually(func(g Gomega) {
+				public := th.GetDeployment(publicDeploymentName)
+				g.Expect(*public.Spec.Replicas).To(Equal(int32(2)))
+				g.Expect(public.Spec.Template.Labels).To(HaveKeyWithValue("keystone.openstack.org/endpoint", "public"))
+				g.Expect(th.GetDeployment(deploymentName).Spec.Template.Labels).To(
+					HaveKeyWithValue("keystone.openstack.org/endpoint", "internal"))
+				g.Expect(th.GetService(types.NamespacedName{Namespace: namespace, Name: "keystone-public"}).Spec.Selector).To(
+					HaveKeyWithValue("keystone.openstack.org/endpoint", "public"))
+				g.Expect(th.GetService(types.NamespacedName{Namespace: namespace, Name: "keystone-internal"}).Spec.Selector).To(
+					HaveKeyWithValue("keystone.openstack.org/endpoint", "internal"))
+				g.Expect(string(th.GetSecret(keystoneAPIConfigDataName).Data["httpd.conf"])).To(
+					ContainSubstring("TimeOut 30"))
+			}, timeout, interval).Should(Succeed())
+
+			th.SimulateDeploymentReplicaReady(publicDeploymentName)
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystonePublicDeploymentReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("A KeystoneAPI is created with publicSecurity", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["publicSecurity"] = map[string]interface{}{
+				"hstsMaxAge": 31536000,
+				"modSecurity": map[string]interface{}{
+					"ruleEngine":  "DetectionOnly",
+					"customRules": "SecRule REQUEST_URI \"@contains /admin\" \"id:1000,deny\"",
+				},
+			}
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("configures the security headers and mod_security of the public vhost", func() {
+			Eventually(func(g Gomega) {
+				scrt := th.GetSecret(keystoneAPIConfigDataName)
+				httpdConfig := string(scrt.Data["httpd.conf"])
+				g.Expect(httpdConfig).To(ContainSubstring(`Header always set Strict-Transport-Security "max-age=31536000"`))
+				g.Expect(httpdConfig).To(ContainSubstring(`Header always set X-Content-Type-Options "nosniff"`))
+				g.Expect(httpdConfig).To(ContainSubstring("SecRuleEngine DetectionOnly"))
+				g.Expect(httpdConfig).To(ContainSubstring("SecRuleEngine Off"))
+				g.Expect(httpdConfig).To(ContainSubstring("Include conf/modsecurity_rules.conf"))
+				g.Expect(string(scrt.Data["modsecurity_rules.conf"])).To(ContainSubstring("id:1000,deny"))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A KeystoneAPI is created with auditLog", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["auditLog"] = map[string]interface{}{
+				"verbosity":     "Full",
+				"maxFileSizeMB": 10,
+				"maxFiles":      2,
+			}
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("writes the audit events to a separate audit log", func() {
+			Eventually(func(g Gomega) {
+				scrt := th.GetSecret(keystoneAPIConfigDataName)
+				auditConfig := string(scrt.Data["audit.conf"])
+				g.Expect(auditConfig).To(ContainSubstring("notification_format=cadf"))
+				g.Expect(auditConfig).To(ContainSubstring("notification_opt_out="))
+				g.Expect(auditConfig).To(ContainSubstring("driver=log"))
+				g.Expect(string(scrt.Data["logging.conf"])).To(
+					ContainSubstring("args=('/var/log/keystone-audit/audit.log', 'a', 10485760, 2, None, True)"))
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				podSpec := th.GetDeployment(deploymentName).Spec.Template.Spec
+				g.Expect(podSpec.Containers).To(HaveLen(2))
+				g.Expect(podSpec.Containers[1].Name).To(Equal("keystone-audit"))
+				g.Expect(podSpec.Containers[1].Command).To(ContainElement("/var/log/keystone-audit/audit.log"))
+				for _, v := range podSpec.Volumes {
+					if v.Name == "audit-log" {
+						g.Expect(v.EmptyDir.SizeLimit.String()).To(Equal("30Mi"))
+					}
+				}
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A KeystoneAPI is created with an LDAP domain", func() {
+		BeforeEach(func() {
+			th.CreateSecret(
+				types.NamespacedName{Name: "ldap-domain", Namespace: namespace},
+				map[string][]byte{
+					"config": []byte("[identity]\ndriver = ldap\n\n[ldap]\nurl = ldap://127.0.0.1:1\n"),
+					"ca.pem": []byte("ldap-ca"),
+				},
+			)
+
+			spec := GetDefaultKeystoneAPISpec()
+			spec["domainConfigs"] = map[string]interface{}{
+				"corp": map[string]interface{}{
+					"secretKeyRef": map[string]interface{}{
+						"name": "ldap-domain",
+						"key":  "config",
+					},
+					"ldap": map[string]interface{}{
+						"usePool":  true,
+						"poolSize": 20,
+						"caCertSecretRef": map[string]interface{}{
+							"name": "ldap-domain",
+							"key":  "ca.pem",
+						},
+					},
+				},
+			}
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("adds the LDAP settings and CA certificate to the domain config", func() {
+			Eventually(func(g Gomega) {
+				scrt := th.GetSecret(keystoneAPIConfigDataName)
+				domainConfig := string(scrt.Data["keystone.corp.conf"])
+				g.Expect(domainConfig).To(ContainSubstring("use_pool = true"))
+				g.Expect(domainConfig).To(ContainSubstring("pool_size = 20"))
+				g.Expect(domainConfig).To(ContainSubstring("tls_cacertfile = /etc/keystone/ldap-ca/ldap-ca-corp.pem"))
+				g.Expect(string(scrt.Data["ldap-ca-corp.pem"])).To(Equal("ldap-ca"))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("reports the unreachable LDAP server", func() {
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneLDAPReadyCondition,
+				corev1.ConditionFalse,
+			)
+		})
+	})
+
+	When("A KeystoneAPI is created with kerberos", func() {
+		BeforeEach(func() {
+			th.CreateSecret(
+				types.NamespacedName{Name: "keystone-keytab", Namespace: namespace},
+				map[string][]byte{
+					"keytab": []byte("keytab"),
+				},
+			)
+
+			spec := GetDefaultKeystoneAPISpec()
+			spec["kerberos"] = map[string]interface{}{
+				"keytabSecretRef": map[string]interface{}{
+					"name": "keystone-keytab",
+					"key":  "keytab",
+				},
+				"krb5Conf": "[libdefaults]\n  default_realm = EXAMPLE.COM\n",
+				"domain":   "corp",
+			}
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("configures GSSAPI authentication and the kerberos auth method", func() {
+			Eventually(func(g Gomega) {
+				scrt := th.GetSecret(keystoneAPIConfigDataName)
+				httpdConfig := string(scrt.Data["httpd.conf"])
+				g.Expect(httpdConfig).To(ContainSubstring(`WSGIScriptAlias /krb "/usr/bin/keystone-wsgi-public"`))
+				g.Expect(httpdConfig).To(ContainSubstring(`<Location "/krb/v3/auth/tokens">`))
+				g.Expect(httpdConfig).To(ContainSubstring("GssapiCredStore keytab:/etc/httpd/keystone.keytab"))
+				g.Expect(httpdConfig).To(ContainSubstring(`SetEnv REMOTE_DOMAIN "corp"`))
+				g.Expect(string(scrt.Data["keystone.conf"])).To(ContainSubstring("application_credential,kerberos"))
+				g.Expect(string(scrt.Data["keystone.keytab"])).To(Equal("keytab"))
+				g.Expect(string(scrt.Data["krb5.conf"])).To(ContainSubstring("default_realm = EXAMPLE.COM"))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A KeystoneAPI is created with tokenlessAuth", func() {
+		BeforeEach(func() {
+			th.CreateSecret(
+				types.NamespacedName{Name: "tokenless-ca", Namespace: namespace},
+				map[string][]byte{
+					"ca.pem": []byte("tokenless-ca"),
+				},
+			)
+
+			spec := GetTLSKeystoneAPISpec()
+			spec["tokenlessAuth"] = map[string]interface{}{
+				"trustedIssuers": []string{"CN=Keystone CA,O=OpenStack"},
+				"caBundleSecretRef": map[string]interface{}{
+					"name": "tokenless-ca",
+					"key":  "ca.pem",
+				},
+			}
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBTLSDatabaseCompleted(keystoneAPIName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateTLSMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+		})
+
+		It("configures the tokenless vhost and the trusted issuers", func() {
+			Eventually(func(g Gomega) {
+				scrt := th.GetSecret(keystoneAPIConfigDataName)
+				httpdConfig := string(scrt.Data["httpd.conf"])
+				g.Expect(httpdConfig).To(ContainSubstring("Listen 5001"))
+				g.Expect(httpdConfig).To(ContainSubstring("<VirtualHost *:5001>"))
+				g.Expect(httpdConfig).To(ContainSubstring("SSLVerifyClient require"))
+				g.Expect(httpdConfig).To(ContainSubstring(`SSLCACertificateFile    "/etc/httpd/conf/tokenless-ca.pem"`))
+				keystoneConfig := string(scrt.Data["keystone.conf"])
+				g.Expect(keystoneConfig).To(ContainSubstring("trusted_issuer=CN=Keystone CA,O=OpenStack"))
+				g.Expect(keystoneConfig).To(ContainSubstring("protocol=x509"))
+				g.Expect(keystoneConfig).To(ContainSubstring("issuer_attribute=SSL_CLIENT_I_DN"))
+				g.Expect(string(scrt.Data["tokenless-ca.pem"])).To(Equal("tokenless-ca"))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A KeystoneAPI is created with mfa", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["mfa"] = map[string]interface{}{
+				"receipt": map[string]interface{}{
+					"expiration": 600,
+					"caching":    false,
+				},
+			}
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+		})
+
+		It("enables the totp auth method", func() {
+			Eventually(func(g Gomega) {
+				scrt := th.GetSecret(keystoneAPIConfigDataName)
+				g.Expect(string(scrt.Data["keystone.conf"])).To(
+					ContainSubstring("methods=external,password,token,oauth1,mapped,application_credential,totp"))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("configures the receipts", func() {
+			Eventually(func(g Gomega) {
+				scrt := th.GetSecret(keystoneAPIConfigDataName)
+				g.Expect(string(scrt.Data["keystone.conf"])).To(
+					ContainSubstring("[receipt]\ncaching=false\nexpiration=600\n"))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
 	When("A KeystoneAPI is created with HttpdCustomization.OverrideSecret", func() {
 		BeforeEach(func() {
 			customServiceConfigSecretName := types.NamespacedName{Name: "foo", Namespace: namespace}
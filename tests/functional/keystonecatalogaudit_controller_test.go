@@ -0,0 +1,370 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/gophercloud/gophercloud"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/controllers"
+	keystone_base "github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func CreateKeystoneCatalogAudit(name types.NamespacedName, spec map[string]interface{}) client.Object {
+	raw := map[string]interface{}{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneCatalogAudit",
+		"metadata": map[string]interface{}{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneCatalogAudit(name types.NamespacedName) *keystonev1.KeystoneCatalogAudit {
+	instance := &keystonev1.KeystoneCatalogAudit{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+func KeystoneCatalogAuditConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetKeystoneCatalogAudit(name)
+	return instance.Status.Conditions
+}
+
+// CreateKeystoneServiceWithID - creates a KeystoneService which registered
+// the service with the ID. No controller reconciles it in the tests.
+func CreateKeystoneServiceWithID(name types.NamespacedName, serviceID string) client.Object {
+	raw := map[string]interface{}{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneService",
+		"metadata": map[string]interface{}{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": map[string]interface{}{
+			"serviceType":      name.Name,
+			"serviceName":      name.Name,
+			"enabled":          true,
+			"serviceUser":      name.Name,
+			"secret":           SecretName,
+			"passwordSelector": "ServicePassword",
+		},
+	}
+	object := th.CreateUnstructured(raw)
+
+	Eventually(func(g Gomega) {
+		service := keystone.GetKeystoneService(name)
+		service.Status.ServiceID = serviceID
+		g.Expect(k8sClient.Status().Update(ctx, service)).To(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return object
+}
+
+// findingsOf - returns the findings of the audit about the IDs, the shared
+// keystone of the tests holds the services and endpoints of other tests
+func findingsOf(name types.NamespacedName, ids ...string) []keystonev1.CatalogAuditFinding {
+	findings := []keystonev1.CatalogAuditFinding{}
+	for _, finding := range GetKeystoneCatalogAudit(name).Status.Findings {
+		for _, id := range ids {
+			if finding.ID == id {
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings
+}
+
+var _ = Describe("KeystoneCatalogAudit controller", func() {
+
+	var keystoneAPIName types.NamespacedName
+	var catalogAuditName types.NamespacedName
+
+	BeforeEach(func() {
+		keystoneAPIName = types.NamespacedName{
+			Name:      "keystone",
+			Namespace: namespace,
+		}
+		catalogAuditName = types.NamespacedName{
+			Name:      "audit",
+			Namespace: namespace,
+		}
+	})
+
+	When("the KeystoneAPI does not exist", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneCatalogAudit(catalogAuditName, map[string]interface{}{}))
+		})
+
+		It("waits for the KeystoneAPI", func() {
+			th.ExpectConditionWithDetails(
+				catalogAuditName,
+				ConditionGetterFunc(KeystoneCatalogAuditConditionGetter),
+				keystonev1.KeystoneAPIReadyCondition,
+				corev1.ConditionFalse,
+				condition.ErrorReason,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			)
+			Expect(GetKeystoneCatalogAudit(catalogAuditName).Status.LastAuditTime).To(BeNil())
+		})
+	})
+
+	When("the catalog does not match the CRs", func() {
+		var unmanagedServiceID string
+		var unmanagedEndpointID string
+		var foreignServiceID string
+		var orphanedServiceID string
+		var managedServiceID string
+		var missingServiceID string
+		var managedEndpointID string
+		var mismatchedEndpointID string
+		var missingEndpointID string
+
+		BeforeEach(func() {
+			CreateReadyKeystoneAPI(keystoneAPIName)
+
+			kubeSystem := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-system"}, kubeSystem)).To(Succeed())
+
+			// the names need to be unique in the shared keystone
+			prefix := uuid.New().String()[:8]
+			var err error
+			unmanagedServiceID, err = osClient.CreateService(logger, openstack.Service{
+				Type: prefix + "-unmanaged", Name: prefix + "-unmanaged", Enabled: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			unmanagedEndpointID, err = osClient.CreateEndpoint(logger, openstack.Endpoint{
+				Name:         prefix + "-unmanaged",
+				ServiceID:    unmanagedServiceID,
+				Availability: gophercloud.AvailabilityPublic,
+				URL:          "http://unmanaged",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			foreignServiceID, err = osClient.CreateService(logger, openstack.Service{
+				Type: prefix + "-foreign", Name: prefix + "-foreign", Enabled: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(osClient.UpdateServiceExtra(foreignServiceID, map[string]interface{}{
+				controllers.OwnerClusterIDAttribute: "other-cluster",
+				controllers.OwnerUIDAttribute:       uuid.NewString(),
+			})).To(Succeed())
+
+			orphanedServiceID, err = osClient.CreateService(logger, openstack.Service{
+				Type: prefix + "-orphaned", Name: prefix + "-orphaned", Enabled: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(osClient.UpdateServiceExtra(orphanedServiceID, map[string]interface{}{
+				controllers.OwnerClusterIDAttribute: string(kubeSystem.UID),
+				controllers.OwnerUIDAttribute:       uuid.NewString(),
+			})).To(Succeed())
+
+			managedServiceID, err = osClient.CreateService(logger, openstack.Service{
+				Type: prefix + "-managed", Name: prefix + "-managed", Enabled: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			managedEndpointID, err = osClient.CreateEndpoint(logger, openstack.Endpoint{
+				Name:         prefix + "-managed",
+				ServiceID:    managedServiceID,
+				Availability: gophercloud.AvailabilityPublic,
+				URL:          "http://managed-public",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			mismatchedEndpointID, err = osClient.CreateEndpoint(logger, openstack.Endpoint{
+				Name:         prefix + "-managed",
+				ServiceID:    managedServiceID,
+				Availability: gophercloud.AvailabilityInternal,
+				URL:          "http://changed-internal",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			missingServiceID = uuid.NewString()
+			missingEndpointID = uuid.NewString()
+
+			DeferCleanup(th.DeleteInstance, CreateKeystoneServiceWithID(
+				types.NamespacedName{Namespace: namespace, Name: "managed"}, managedServiceID))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneServiceWithID(
+				types.NamespacedName{Namespace: namespace, Name: "missing"}, missingServiceID))
+
+			endpointName := keystone.CreateKeystoneEndpoint(types.NamespacedName{Namespace: namespace, Name: "managed"})
+			DeferCleanup(keystone.DeleteKeystoneEndpoint, endpointName)
+			Eventually(func(g Gomega) {
+				endpoint := keystone.GetKeystoneEndpoint(endpointName)
+				endpoint.Status.Endpoints = []keystonev1.Endpoint{
+					{Interface: "public", URL: "http://managed-public", ID: managedEndpointID},
+					{Interface: "internal", URL: "http://managed-internal", ID: mismatchedEndpointID},
+					{Interface: "admin", URL: "http://managed-admin", ID: missingEndpointID},
+				}
+				g.Expect(k8sClient.Status().Update(ctx, endpoint)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			DeferCleanup(th.DeleteInstance, CreateKeystoneCatalogAudit(catalogAuditName, map[string]interface{}{}))
+		})
+
+		It("reports the mismatches", func() {
+			th.ExpectCondition(
+				catalogAuditName,
+				ConditionGetterFunc(KeystoneCatalogAuditConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			th.ExpectCondition(
+				catalogAuditName,
+				ConditionGetterFunc(KeystoneCatalogAuditConditionGetter),
+				keystonev1.KeystoneCatalogAuditReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			findings := findingsOf(catalogAuditName,
+				unmanagedServiceID, unmanagedEndpointID, foreignServiceID, orphanedServiceID,
+				managedServiceID, missingServiceID, managedEndpointID, mismatchedEndpointID, missingEndpointID)
+			findingTypes := map[string]keystonev1.CatalogAuditFindingType{}
+			for _, finding := range findings {
+				findingTypes[finding.ID] = finding.Type
+			}
+			Expect(findingTypes).To(Equal(map[string]keystonev1.CatalogAuditFindingType{
+				unmanagedServiceID:   keystonev1.CatalogAuditUnmanagedService,
+				unmanagedEndpointID:  keystonev1.CatalogAuditUnmanagedEndpoint,
+				foreignServiceID:     keystonev1.CatalogAuditUnmanagedService,
+				orphanedServiceID:    keystonev1.CatalogAuditOrphanedService,
+				missingServiceID:     keystonev1.CatalogAuditMissingService,
+				mismatchedEndpointID: keystonev1.CatalogAuditMismatchedEndpoint,
+				missingEndpointID:    keystonev1.CatalogAuditMissingEndpoint,
+			}))
+			for _, finding := range findings {
+				switch finding.ID {
+				case foreignServiceID:
+					Expect(finding.Detail).To(ContainSubstring("registered by the operator of cluster other-cluster"))
+				case mismatchedEndpointID:
+					Expect(finding.Detail).To(ContainSubstring(
+						"registered with url http://changed-internal instead of http://managed-internal"))
+				}
+			}
+
+			instance := GetKeystoneCatalogAudit(catalogAuditName)
+			Expect(instance.Status.LastAuditTime).NotTo(BeNil())
+			Expect(instance.Status.MismatchCount).To(Equal(len(instance.Status.Findings)))
+			Expect(instance.Labels).To(HaveKeyWithValue(keystonev1.KeystoneAPILabel, keystoneAPIName.Name))
+		})
+
+		It("does not report the identity service", func() {
+			th.ExpectCondition(
+				catalogAuditName,
+				ConditionGetterFunc(KeystoneCatalogAuditConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			services, err := osClient.ListServices()
+			Expect(err).NotTo(HaveOccurred())
+			identityServiceIDs := []string{}
+			for _, service := range services {
+				if service.Type == keystone_base.ServiceType {
+					identityServiceIDs = append(identityServiceIDs, service.ID)
+				}
+			}
+			Expect(identityServiceIDs).NotTo(BeEmpty())
+			Expect(findingsOf(catalogAuditName, identityServiceIDs...)).To(BeEmpty())
+		})
+
+		It("audits only once per interval", func() {
+			th.ExpectCondition(
+				catalogAuditName,
+				ConditionGetterFunc(KeystoneCatalogAuditConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			lastAuditTime := GetKeystoneCatalogAudit(catalogAuditName).Status.LastAuditTime
+
+			Expect(osClient.DeleteService(logger, unmanagedServiceID)).To(Succeed())
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneCatalogAudit(catalogAuditName)
+				instance.Spec.AuditInterval = 120
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Consistently(func(g Gomega) {
+				instance := GetKeystoneCatalogAudit(catalogAuditName)
+				g.Expect(instance.Status.LastAuditTime).To(Equal(lastAuditTime))
+				g.Expect(findingsOf(catalogAuditName, unmanagedServiceID)).To(HaveLen(1))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("the keystone API fails", func() {
+		BeforeEach(func() {
+			osClient.InjectError("ListServices", errors.New("keystone is down"))
+			DeferCleanup(func() {
+				osClient.InjectError("ListServices", nil)
+			})
+
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneCatalogAudit(catalogAuditName, map[string]interface{}{}))
+		})
+
+		It("reports the error and recovers", func() {
+			th.ExpectConditionWithDetails(
+				catalogAuditName,
+				ConditionGetterFunc(KeystoneCatalogAuditConditionGetter),
+				keystonev1.KeystoneCatalogAuditReadyCondition,
+				corev1.ConditionFalse,
+				condition.ErrorReason,
+				fmt.Sprintf(keystonev1.KeystoneCatalogAuditReadyErrorMessage, "keystone is down"),
+			)
+			th.ExpectCondition(
+				catalogAuditName,
+				ConditionGetterFunc(KeystoneCatalogAuditConditionGetter),
+				keystonev1.DegradedCondition,
+				corev1.ConditionTrue,
+			)
+			Expect(GetKeystoneCatalogAudit(catalogAuditName).Status.LastAuditTime).To(BeNil())
+
+			// a degraded instance gets requeued slowly, the spec change
+			// triggers the next reconcile
+			osClient.InjectError("ListServices", nil)
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneCatalogAudit(catalogAuditName)
+				instance.Spec.AuditInterval = 120
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectCondition(
+				catalogAuditName,
+				ConditionGetterFunc(KeystoneCatalogAuditConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			Expect(GetKeystoneCatalogAudit(catalogAuditName).Status.LastAuditTime).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,453 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const credentialFinalizer = "openstack.org/keystonecredential"
+
+func CreateKeystoneCredential(name types.NamespacedName, spec map[string]interface{}) client.Object {
+	raw := map[string]interface{}{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneCredential",
+		"metadata": map[string]interface{}{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneCredential(name types.NamespacedName) *keystonev1.KeystoneCredential {
+	instance := &keystonev1.KeystoneCredential{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+func KeystoneCredentialConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetKeystoneCredential(name)
+	return instance.Status.Conditions
+}
+
+// GetFakeCredential - returns the credential with the ID from the keystone
+// API of the controllers, nil if it does not exist
+func GetFakeCredential(id string) map[string]interface{} {
+	for _, cred := range osClient.ListResources("credentials") {
+		if cred["id"] == id {
+			return cred
+		}
+	}
+	return nil
+}
+
+// UpdateBlob - sets the blob of the credential in its Secret
+func UpdateBlob(name types.NamespacedName, blob string) {
+	Eventually(func(g Gomega) {
+		secret := th.GetSecret(name)
+		secret.Data["blob"] = []byte(blob)
+		g.Expect(k8sClient.Update(ctx, &secret)).To(Succeed())
+	}, timeout, interval).Should(Succeed())
+}
+
+var _ = Describe("KeystoneCredential controller", func() {
+
+	var keystoneAPIName types.NamespacedName
+	var credentialName types.NamespacedName
+	var blobSecretName types.NamespacedName
+	var userName string
+	var userID string
+	var projectName string
+	var projectID string
+	var spec map[string]interface{}
+
+	BeforeEach(func() {
+		keystoneAPIName = types.NamespacedName{
+			Name:      "keystone",
+			Namespace: namespace,
+		}
+		// the keystone API is shared by all tests, the names of the user
+		// and project need to be unique
+		credentialName = types.NamespacedName{
+			Name:      "cred-" + uuid.New().String()[:8],
+			Namespace: namespace,
+		}
+		blobSecretName = types.NamespacedName{
+			Name:      credentialName.Name + "-blob",
+			Namespace: namespace,
+		}
+		projectName = credentialName.Name + "-project"
+		var err error
+		projectID, err = osClient.CreateProject(logger, openstack.Project{
+			Name:     projectName,
+			DomainID: "default",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		userName = credentialName.Name + "-user"
+		userID, err = osClient.CreateUser(logger, openstack.User{
+			Name:      userName,
+			DomainID:  "default",
+			ProjectID: projectID,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		spec = map[string]interface{}{
+			"type":     "totp",
+			"userName": userName,
+			"blobSecretRef": map[string]interface{}{
+				"name": blobSecretName.Name,
+				"key":  "blob",
+			},
+		}
+	})
+
+	When("the KeystoneAPI does not exist", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneCredential(credentialName, spec))
+		})
+
+		It("waits for the KeystoneAPI", func() {
+			th.ExpectConditionWithDetails(
+				credentialName,
+				ConditionGetterFunc(KeystoneCredentialConditionGetter),
+				keystonev1.KeystoneAPIReadyCondition,
+				corev1.ConditionFalse,
+				condition.ErrorReason,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			)
+			th.ExpectCondition(
+				credentialName,
+				ConditionGetterFunc(KeystoneCredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+			)
+		})
+
+		It("can be deleted", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneCredential(credentialName).Finalizers).To(ContainElement(credentialFinalizer))
+			}, timeout, interval).Should(Succeed())
+
+			th.DeleteInstance(GetKeystoneCredential(credentialName))
+		})
+	})
+
+	When("the Secret of the blob does not exist", func() {
+		BeforeEach(func() {
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneCredential(credentialName, spec))
+		})
+
+		It("waits for the Secret and creates the credential once it exists", func() {
+			th.ExpectConditionWithDetails(
+				credentialName,
+				ConditionGetterFunc(KeystoneCredentialConditionGetter),
+				keystonev1.KeystoneCredentialReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				fmt.Sprintf(keystonev1.KeystoneCredentialReadyWaitingMessage,
+					fmt.Sprintf("secret %s", blobSecretName.Name)),
+			)
+
+			DeferCleanup(k8sClient.Delete, ctx, th.CreateSecret(blobSecretName, map[string][]byte{
+				"blob": []byte("GEZDGNBVGY3TQOJQ"),
+			}))
+
+			th.ExpectCondition(
+				credentialName,
+				ConditionGetterFunc(KeystoneCredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("a credential is created", func() {
+		BeforeEach(func() {
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(k8sClient.Delete, ctx, th.CreateSecret(blobSecretName, map[string][]byte{
+				"blob": []byte("GEZDGNBVGY3TQOJQ"),
+			}))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneCredential(credentialName, spec))
+		})
+
+		It("creates the credential", func() {
+			th.ExpectCondition(
+				credentialName,
+				ConditionGetterFunc(KeystoneCredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			for _, conditionType := range []condition.Type{
+				keystonev1.KeystoneAPIReadyCondition,
+				keystonev1.AdminServiceClientReadyCondition,
+				keystonev1.KeystoneCredentialReadyCondition,
+			} {
+				th.ExpectCondition(
+					credentialName,
+					ConditionGetterFunc(KeystoneCredentialConditionGetter),
+					conditionType,
+					corev1.ConditionTrue,
+				)
+			}
+
+			instance := GetKeystoneCredential(credentialName)
+			Expect(instance.Status.CredentialID).NotTo(BeEmpty())
+			Expect(instance.Status.UserID).To(Equal(userID))
+			Expect(instance.Status.ProjectID).To(BeEmpty())
+			Expect(instance.Status.BlobHash).NotTo(BeEmpty())
+
+			cred := GetFakeCredential(instance.Status.CredentialID)
+			Expect(cred).NotTo(BeNil())
+			Expect(cred["type"]).To(Equal("totp"))
+			Expect(cred["user_id"]).To(Equal(userID))
+			Expect(cred["blob"]).To(Equal("GEZDGNBVGY3TQOJQ"))
+		})
+
+		It("adds the finalizers to itself and the KeystoneAPI", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneCredential(credentialName).Finalizers).To(ContainElement(credentialFinalizer))
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Finalizers).To(
+					ContainElement(fmt.Sprintf("%s-%s", credentialFinalizer, credentialName.Name)))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("labels itself with the KeystoneAPI", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneCredential(credentialName).Labels).To(
+					HaveKeyWithValue(keystonev1.KeystoneAPILabel, keystoneAPIName.Name))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("updates the blob of the credential when the Secret changes", func() {
+			th.ExpectCondition(
+				credentialName,
+				ConditionGetterFunc(KeystoneCredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			instance := GetKeystoneCredential(credentialName)
+			credentialID := instance.Status.CredentialID
+			blobHash := instance.Status.BlobHash
+
+			UpdateBlob(blobSecretName, "MFRGGZDFMZTWQ2LK")
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneCredential(credentialName)
+				g.Expect(instance.Status.BlobHash).NotTo(Equal(blobHash))
+				g.Expect(instance.Status.CredentialID).To(Equal(credentialID))
+
+				cred := GetFakeCredential(credentialID)
+				g.Expect(cred).NotTo(BeNil())
+				g.Expect(cred["blob"]).To(Equal("MFRGGZDFMZTWQ2LK"))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("recreates the credential when its type changes", func() {
+			th.ExpectCondition(
+				credentialName,
+				ConditionGetterFunc(KeystoneCredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			credentialID := GetKeystoneCredential(credentialName).Status.CredentialID
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneCredential(credentialName)
+				instance.Spec.Type = keystonev1.CredentialTypeCert
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneCredential(credentialName)
+				g.Expect(instance.Status.CredentialID).NotTo(BeEmpty())
+				g.Expect(instance.Status.CredentialID).NotTo(Equal(credentialID))
+
+				cred := GetFakeCredential(instance.Status.CredentialID)
+				g.Expect(cred).NotTo(BeNil())
+				g.Expect(cred["type"]).To(Equal("cert"))
+			}, timeout, interval).Should(Succeed())
+			Expect(GetFakeCredential(credentialID)).To(BeNil())
+		})
+
+		It("deletes the credential and removes the finalizers", func() {
+			th.ExpectCondition(
+				credentialName,
+				ConditionGetterFunc(KeystoneCredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			credentialID := GetKeystoneCredential(credentialName).Status.CredentialID
+
+			th.DeleteInstance(GetKeystoneCredential(credentialName))
+
+			Expect(GetFakeCredential(credentialID)).To(BeNil())
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Finalizers).NotTo(
+					ContainElement(fmt.Sprintf("%s-%s", credentialFinalizer, credentialName.Name)))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("an ec2 credential is created", func() {
+		BeforeEach(func() {
+			spec["type"] = "ec2"
+			spec["projectName"] = projectName
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(k8sClient.Delete, ctx, th.CreateSecret(blobSecretName, map[string][]byte{
+				"blob": []byte(`{"access": "access1", "secret": "secret1"}`),
+			}))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneCredential(credentialName, spec))
+		})
+
+		It("scopes the credential to the project and recreates it when the blob changes", func() {
+			th.ExpectCondition(
+				credentialName,
+				ConditionGetterFunc(KeystoneCredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			instance := GetKeystoneCredential(credentialName)
+			Expect(instance.Status.ProjectID).To(Equal(projectID))
+			credentialID := instance.Status.CredentialID
+			cred := GetFakeCredential(credentialID)
+			Expect(cred).NotTo(BeNil())
+			Expect(cred["project_id"]).To(Equal(projectID))
+
+			UpdateBlob(blobSecretName, `{"access": "access2", "secret": "secret2"}`)
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneCredential(credentialName)
+				g.Expect(instance.Status.CredentialID).NotTo(BeEmpty())
+				g.Expect(instance.Status.CredentialID).NotTo(Equal(credentialID))
+
+				cred := GetFakeCredential(instance.Status.CredentialID)
+				g.Expect(cred).NotTo(BeNil())
+				g.Expect(cred["blob"]).To(Equal(`{"access": "access2", "secret": "secret2"}`))
+			}, timeout, interval).Should(Succeed())
+			Expect(GetFakeCredential(credentialID)).To(BeNil())
+		})
+	})
+
+	When("an ec2 credential has no project", func() {
+		BeforeEach(func() {
+			spec["type"] = "ec2"
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneCredential(credentialName, spec))
+		})
+
+		It("reports the missing project", func() {
+			th.ExpectConditionWithDetails(
+				credentialName,
+				ConditionGetterFunc(KeystoneCredentialConditionGetter),
+				keystonev1.KeystoneCredentialReadyCondition,
+				corev1.ConditionFalse,
+				condition.ErrorReason,
+				fmt.Sprintf(keystonev1.KeystoneCredentialReadyErrorMessage,
+					"projectName is required for ec2 credentials"),
+			)
+			Expect(GetKeystoneCredential(credentialName).Status.CredentialID).To(BeEmpty())
+		})
+	})
+
+	When("the user of the credential does not exist", func() {
+		BeforeEach(func() {
+			spec["userName"] = "missing"
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneCredential(credentialName, spec))
+		})
+
+		It("waits for the user", func() {
+			th.ExpectConditionWithDetails(
+				credentialName,
+				ConditionGetterFunc(KeystoneCredentialConditionGetter),
+				keystonev1.KeystoneCredentialReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				fmt.Sprintf(keystonev1.KeystoneCredentialReadyWaitingMessage, "user missing"),
+			)
+		})
+	})
+
+	When("the keystone API fails", func() {
+		BeforeEach(func() {
+			osClient.InjectError("credentials", errors.New("keystone is down"))
+			DeferCleanup(func() {
+				osClient.InjectError("credentials", nil)
+			})
+
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(k8sClient.Delete, ctx, th.CreateSecret(blobSecretName, map[string][]byte{
+				"blob": []byte("GEZDGNBVGY3TQOJQ"),
+			}))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneCredential(credentialName, spec))
+		})
+
+		It("reports the error and recovers", func() {
+			Eventually(func(g Gomega) {
+				conditions := GetKeystoneCredential(credentialName).Status.Conditions
+				g.Expect(conditions.IsFalse(keystonev1.KeystoneCredentialReadyCondition)).To(BeTrue())
+				readyCondition := conditions.Get(keystonev1.KeystoneCredentialReadyCondition)
+				g.Expect(readyCondition.Reason).To(BeEquivalentTo(condition.ErrorReason))
+				g.Expect(readyCondition.Message).To(ContainSubstring("keystone is down"))
+			}, timeout, interval).Should(Succeed())
+			th.ExpectCondition(
+				credentialName,
+				ConditionGetterFunc(KeystoneCredentialConditionGetter),
+				keystonev1.DegradedCondition,
+				corev1.ConditionTrue,
+			)
+
+			// a degraded instance gets requeued slowly, the change of the
+			// blob triggers the next reconcile
+			osClient.InjectError("credentials", nil)
+			UpdateBlob(blobSecretName, "MFRGGZDFMZTWQ2LK")
+
+			th.ExpectCondition(
+				credentialName,
+				ConditionGetterFunc(KeystoneCredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			cred := GetFakeCredential(GetKeystoneCredential(credentialName).Status.CredentialID)
+			Expect(cred).NotTo(BeNil())
+			Expect(cred["blob"]).To(Equal("MFRGGZDFMZTWQ2LK"))
+			Eventually(func(g Gomega) {
+				conditions := GetKeystoneCredential(credentialName).Status.Conditions
+				g.Expect(conditions.Has(keystonev1.DegradedCondition)).To(BeFalse())
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,361 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const ec2CredentialFinalizer = "openstack.org/keystoneec2credential"
+
+func CreateKeystoneEC2Credential(name types.NamespacedName, spec map[string]interface{}) client.Object {
+	raw := map[string]interface{}{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneEC2Credential",
+		"metadata": map[string]interface{}{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneEC2Credential(name types.NamespacedName) *keystonev1.KeystoneEC2Credential {
+	instance := &keystonev1.KeystoneEC2Credential{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+func KeystoneEC2CredentialConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetKeystoneEC2Credential(name)
+	return instance.Status.Conditions
+}
+
+// GetFakeEC2Credential - returns the EC2 credential of the user with the
+// access from the keystone API of the controllers, nil if it does not exist
+func GetFakeEC2Credential(userID string, access string) map[string]interface{} {
+	for _, cred := range osClient.ListResources(fmt.Sprintf("users/%s/credentials/OS-EC2", userID)) {
+		if cred["access"] == access {
+			return cred
+		}
+	}
+	return nil
+}
+
+var _ = Describe("KeystoneEC2Credential controller", func() {
+
+	var keystoneAPIName types.NamespacedName
+	var ec2CredentialName types.NamespacedName
+	var userName string
+	var userID string
+	var projectName string
+	var projectID string
+	var spec map[string]interface{}
+
+	BeforeEach(func() {
+		keystoneAPIName = types.NamespacedName{
+			Name:      "keystone",
+			Namespace: namespace,
+		}
+		// the keystone API is shared by all tests, the names of the user
+		// and project need to be unique
+		ec2CredentialName = types.NamespacedName{
+			Name:      "ec2-" + uuid.New().String()[:8],
+			Namespace: namespace,
+		}
+		projectName = ec2CredentialName.Name + "-project"
+		var err error
+		projectID, err = osClient.CreateProject(logger, openstack.Project{
+			Name:     projectName,
+			DomainID: "default",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		userName = ec2CredentialName.Name + "-user"
+		userID, err = osClient.CreateUser(logger, openstack.User{
+			Name:      userName,
+			DomainID:  "default",
+			ProjectID: projectID,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		spec = map[string]interface{}{
+			"userName":    userName,
+			"projectName": projectName,
+		}
+	})
+
+	When("the KeystoneAPI does not exist", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneEC2Credential(ec2CredentialName, spec))
+		})
+
+		It("waits for the KeystoneAPI", func() {
+			th.ExpectConditionWithDetails(
+				ec2CredentialName,
+				ConditionGetterFunc(KeystoneEC2CredentialConditionGetter),
+				keystonev1.KeystoneAPIReadyCondition,
+				corev1.ConditionFalse,
+				condition.ErrorReason,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			)
+			th.ExpectCondition(
+				ec2CredentialName,
+				ConditionGetterFunc(KeystoneEC2CredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+			)
+		})
+
+		It("can be deleted", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneEC2Credential(ec2CredentialName).Finalizers).To(ContainElement(ec2CredentialFinalizer))
+			}, timeout, interval).Should(Succeed())
+
+			th.DeleteInstance(GetKeystoneEC2Credential(ec2CredentialName))
+		})
+	})
+
+	When("an EC2 credential is created", func() {
+		BeforeEach(func() {
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneEC2Credential(ec2CredentialName, spec))
+		})
+
+		It("creates the credential and stores it in the Secret", func() {
+			th.ExpectCondition(
+				ec2CredentialName,
+				ConditionGetterFunc(KeystoneEC2CredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			for _, conditionType := range []condition.Type{
+				keystonev1.KeystoneAPIReadyCondition,
+				keystonev1.AdminServiceClientReadyCondition,
+				keystonev1.KeystoneEC2CredentialReadyCondition,
+			} {
+				th.ExpectCondition(
+					ec2CredentialName,
+					ConditionGetterFunc(KeystoneEC2CredentialConditionGetter),
+					conditionType,
+					corev1.ConditionTrue,
+				)
+			}
+
+			instance := GetKeystoneEC2Credential(ec2CredentialName)
+			Expect(instance.Status.UserID).To(Equal(userID))
+			Expect(instance.Status.ProjectID).To(Equal(projectID))
+			Expect(instance.Status.SecretName).To(Equal(ec2CredentialName.Name))
+			Expect(instance.Status.AccessKey).NotTo(BeEmpty())
+
+			cred := GetFakeEC2Credential(userID, instance.Status.AccessKey)
+			Expect(cred).NotTo(BeNil())
+			Expect(cred["tenant_id"]).To(Equal(projectID))
+
+			secret := th.GetSecret(ec2CredentialName)
+			Expect(secret.Data).To(HaveKeyWithValue(keystonev1.EC2CredentialAccessKey, []byte(instance.Status.AccessKey)))
+			Expect(secret.Data).To(HaveKeyWithValue(keystonev1.EC2CredentialSecretKey, []byte(cred["secret"].(string))))
+			Expect(secret.OwnerReferences).To(HaveLen(1))
+			Expect(secret.OwnerReferences[0].UID).To(Equal(instance.UID))
+		})
+
+		It("adds the finalizers to itself and the KeystoneAPI", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneEC2Credential(ec2CredentialName).Finalizers).To(ContainElement(ec2CredentialFinalizer))
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Finalizers).To(
+					ContainElement(fmt.Sprintf("%s-%s", ec2CredentialFinalizer, ec2CredentialName.Name)))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("labels itself with the KeystoneAPI", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneEC2Credential(ec2CredentialName).Labels).To(
+					HaveKeyWithValue(keystonev1.KeystoneAPILabel, keystoneAPIName.Name))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("replaces the credential when its Secret gets deleted", func() {
+			th.ExpectCondition(
+				ec2CredentialName,
+				ConditionGetterFunc(KeystoneEC2CredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			access := GetKeystoneEC2Credential(ec2CredentialName).Status.AccessKey
+
+			// the controller recreates the Secret right away, waiting for it
+			// to be gone like th.DeleteSecret does would race with it
+			secret := th.GetSecret(ec2CredentialName)
+			Expect(k8sClient.Delete(ctx, &secret)).To(Succeed())
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneEC2Credential(ec2CredentialName)
+				g.Expect(instance.Status.AccessKey).NotTo(BeEmpty())
+				g.Expect(instance.Status.AccessKey).NotTo(Equal(access))
+				g.Expect(GetFakeEC2Credential(userID, instance.Status.AccessKey)).NotTo(BeNil())
+
+				secret := &corev1.Secret{}
+				g.Expect(k8sClient.Get(ctx, ec2CredentialName, secret)).To(Succeed())
+				g.Expect(secret.Data).To(HaveKeyWithValue(keystonev1.EC2CredentialAccessKey, []byte(instance.Status.AccessKey)))
+			}, timeout, interval).Should(Succeed())
+			Expect(GetFakeEC2Credential(userID, access)).To(BeNil())
+		})
+
+		It("moves the credential to the new Secret", func() {
+			th.ExpectCondition(
+				ec2CredentialName,
+				ConditionGetterFunc(KeystoneEC2CredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			access := GetKeystoneEC2Credential(ec2CredentialName).Status.AccessKey
+			secretName := types.NamespacedName{
+				Name:      ec2CredentialName.Name + "-secret",
+				Namespace: namespace,
+			}
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneEC2Credential(ec2CredentialName)
+				instance.Spec.SecretName = secretName.Name
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneEC2Credential(ec2CredentialName)
+				g.Expect(instance.Status.SecretName).To(Equal(secretName.Name))
+				g.Expect(instance.Status.AccessKey).NotTo(Equal(access))
+
+				secret := &corev1.Secret{}
+				g.Expect(k8sClient.Get(ctx, secretName, secret)).To(Succeed())
+				g.Expect(secret.Data).To(HaveKeyWithValue(keystonev1.EC2CredentialAccessKey, []byte(instance.Status.AccessKey)))
+			}, timeout, interval).Should(Succeed())
+			th.AssertSecretDoesNotExist(ec2CredentialName)
+			Expect(GetFakeEC2Credential(userID, access)).To(BeNil())
+		})
+
+		It("revokes the credential and removes the finalizers", func() {
+			th.ExpectCondition(
+				ec2CredentialName,
+				ConditionGetterFunc(KeystoneEC2CredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			access := GetKeystoneEC2Credential(ec2CredentialName).Status.AccessKey
+
+			th.DeleteInstance(GetKeystoneEC2Credential(ec2CredentialName))
+
+			Expect(GetFakeEC2Credential(userID, access)).To(BeNil())
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Finalizers).NotTo(
+					ContainElement(fmt.Sprintf("%s-%s", ec2CredentialFinalizer, ec2CredentialName.Name)))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("the user of the credential does not exist", func() {
+		BeforeEach(func() {
+			spec["userName"] = "missing"
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneEC2Credential(ec2CredentialName, spec))
+		})
+
+		It("waits for the user", func() {
+			th.ExpectConditionWithDetails(
+				ec2CredentialName,
+				ConditionGetterFunc(KeystoneEC2CredentialConditionGetter),
+				keystonev1.KeystoneEC2CredentialReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				fmt.Sprintf(keystonev1.KeystoneEC2CredentialReadyWaitingMessage, "user missing"),
+			)
+			th.ExpectCondition(
+				ec2CredentialName,
+				ConditionGetterFunc(KeystoneEC2CredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+			)
+			th.AssertSecretDoesNotExist(ec2CredentialName)
+		})
+	})
+
+	When("the keystone API fails", func() {
+		BeforeEach(func() {
+			osClient.InjectError("OS-EC2", errors.New("keystone is down"))
+			DeferCleanup(func() {
+				osClient.InjectError("OS-EC2", nil)
+			})
+
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneEC2Credential(ec2CredentialName, spec))
+		})
+
+		It("reports the error and recovers", func() {
+			Eventually(func(g Gomega) {
+				conditions := GetKeystoneEC2Credential(ec2CredentialName).Status.Conditions
+				g.Expect(conditions.IsFalse(keystonev1.KeystoneEC2CredentialReadyCondition)).To(BeTrue())
+				readyCondition := conditions.Get(keystonev1.KeystoneEC2CredentialReadyCondition)
+				g.Expect(readyCondition.Reason).To(BeEquivalentTo(condition.ErrorReason))
+				g.Expect(readyCondition.Message).To(ContainSubstring("keystone is down"))
+			}, timeout, interval).Should(Succeed())
+			th.AssertSecretDoesNotExist(ec2CredentialName)
+			th.ExpectCondition(
+				ec2CredentialName,
+				ConditionGetterFunc(KeystoneEC2CredentialConditionGetter),
+				keystonev1.DegradedCondition,
+				corev1.ConditionTrue,
+			)
+
+			// a degraded instance gets requeued slowly, the spec change
+			// triggers the next reconcile
+			osClient.InjectError("OS-EC2", nil)
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneEC2Credential(ec2CredentialName)
+				instance.Spec.SecretName = ec2CredentialName.Name + "-secret"
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectCondition(
+				ec2CredentialName,
+				ConditionGetterFunc(KeystoneEC2CredentialConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			instance := GetKeystoneEC2Credential(ec2CredentialName)
+			Expect(GetFakeEC2Credential(userID, instance.Status.AccessKey)).NotTo(BeNil())
+			Eventually(func(g Gomega) {
+				conditions := GetKeystoneEC2Credential(ec2CredentialName).Status.Conditions
+				g.Expect(conditions.Has(keystonev1.DegradedCondition)).To(BeFalse())
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+})
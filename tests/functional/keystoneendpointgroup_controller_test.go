@@ -0,0 +1,389 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const endpointGroupFinalizer = "openstack.org/keystoneendpointgroup"
+
+func CreateKeystoneEndpointGroup(name types.NamespacedName, spec map[string]interface{}) client.Object {
+	raw := map[string]interface{}{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneEndpointGroup",
+		"metadata": map[string]interface{}{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneEndpointGroup(name types.NamespacedName) *keystonev1.KeystoneEndpointGroup {
+	instance := &keystonev1.KeystoneEndpointGroup{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+func KeystoneEndpointGroupConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetKeystoneEndpointGroup(name)
+	return instance.Status.Conditions
+}
+
+// GetFakeEndpointGroup - returns the endpoint group with the name from the
+// keystone API of the controllers, nil if it does not exist
+func GetFakeEndpointGroup(name string) map[string]interface{} {
+	for _, endpointGroup := range osClient.ListResources("OS-EP-FILTER/endpoint_groups") {
+		if endpointGroup["name"] == name {
+			return endpointGroup
+		}
+	}
+	return nil
+}
+
+func endpointGroupProjectPath(endpointGroupID string, projectID string) string {
+	return fmt.Sprintf("OS-EP-FILTER/endpoint_groups/%s/projects/%s", endpointGroupID, projectID)
+}
+
+var _ = Describe("KeystoneEndpointGroup controller", func() {
+
+	var keystoneAPIName types.NamespacedName
+	var endpointGroupName types.NamespacedName
+	var projectNames []string
+	var projectIDs []string
+
+	BeforeEach(func() {
+		keystoneAPIName = types.NamespacedName{
+			Name:      "keystone",
+			Namespace: namespace,
+		}
+		// the keystone API is shared by all tests, the names of the
+		// endpoint group and projects need to be unique
+		endpointGroupName = types.NamespacedName{
+			Name:      "eg-" + uuid.New().String()[:8],
+			Namespace: namespace,
+		}
+		projectNames = []string{}
+		projectIDs = []string{}
+		for i := 0; i < 2; i++ {
+			projectName := fmt.Sprintf("%s-project%d", endpointGroupName.Name, i)
+			projectID, err := osClient.CreateProject(logger, openstack.Project{
+				Name:     projectName,
+				DomainID: "default",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			projectNames = append(projectNames, projectName)
+			projectIDs = append(projectIDs, projectID)
+		}
+	})
+
+	When("the KeystoneAPI does not exist", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneEndpointGroup(endpointGroupName, map[string]interface{}{
+				"filters": map[string]interface{}{
+					"interface": "public",
+				},
+			}))
+		})
+
+		It("waits for the KeystoneAPI", func() {
+			th.ExpectConditionWithDetails(
+				endpointGroupName,
+				ConditionGetterFunc(KeystoneEndpointGroupConditionGetter),
+				keystonev1.KeystoneAPIReadyCondition,
+				corev1.ConditionFalse,
+				condition.ErrorReason,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			)
+			th.ExpectCondition(
+				endpointGroupName,
+				ConditionGetterFunc(KeystoneEndpointGroupConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+			)
+		})
+
+		It("can be deleted", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneEndpointGroup(endpointGroupName).Finalizers).To(ContainElement(endpointGroupFinalizer))
+			}, timeout, interval).Should(Succeed())
+
+			th.DeleteInstance(GetKeystoneEndpointGroup(endpointGroupName))
+		})
+	})
+
+	When("an endpoint group is created", func() {
+		BeforeEach(func() {
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneEndpointGroup(endpointGroupName, map[string]interface{}{
+				"description": "public endpoints",
+				"filters": map[string]interface{}{
+					"interface": "public",
+					"region":    "regionOne",
+				},
+				"projects": projectNames,
+			}))
+		})
+
+		It("creates the endpoint group and associates it with the projects", func() {
+			th.ExpectCondition(
+				endpointGroupName,
+				ConditionGetterFunc(KeystoneEndpointGroupConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			for _, conditionType := range []condition.Type{
+				keystonev1.KeystoneAPIReadyCondition,
+				keystonev1.AdminServiceClientReadyCondition,
+				keystonev1.KeystoneEndpointGroupReadyCondition,
+				keystonev1.KeystoneEndpointGroupProjectsReadyCondition,
+			} {
+				th.ExpectCondition(
+					endpointGroupName,
+					ConditionGetterFunc(KeystoneEndpointGroupConditionGetter),
+					conditionType,
+					corev1.ConditionTrue,
+				)
+			}
+
+			endpointGroup := GetFakeEndpointGroup(endpointGroupName.Name)
+			Expect(endpointGroup).NotTo(BeNil())
+			Expect(endpointGroup["description"]).To(Equal("public endpoints"))
+			Expect(endpointGroup["filters"]).To(Equal(map[string]interface{}{
+				"interface": "public",
+				"region_id": "regionOne",
+			}))
+
+			instance := GetKeystoneEndpointGroup(endpointGroupName)
+			Expect(instance.Status.EndpointGroupID).To(Equal(endpointGroup["id"]))
+			Expect(instance.Status.ProjectIDs).To(Equal(map[string]string{
+				projectNames[0]: projectIDs[0],
+				projectNames[1]: projectIDs[1],
+			}))
+			for _, projectID := range projectIDs {
+				Expect(osClient.HasResource(endpointGroupProjectPath(instance.Status.EndpointGroupID, projectID))).To(BeTrue())
+			}
+		})
+
+		It("adds the finalizers to itself and the KeystoneAPI", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneEndpointGroup(endpointGroupName).Finalizers).To(ContainElement(endpointGroupFinalizer))
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Finalizers).To(
+					ContainElement(fmt.Sprintf("%s-%s", endpointGroupFinalizer, endpointGroupName.Name)))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("labels itself with the KeystoneAPI", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneEndpointGroup(endpointGroupName).Labels).To(
+					HaveKeyWithValue(keystonev1.KeystoneAPILabel, keystoneAPIName.Name))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("updates the endpoint group and its projects", func() {
+			th.ExpectCondition(
+				endpointGroupName,
+				ConditionGetterFunc(KeystoneEndpointGroupConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			endpointGroupID := GetKeystoneEndpointGroup(endpointGroupName).Status.EndpointGroupID
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneEndpointGroup(endpointGroupName)
+				instance.Spec.Description = "internal endpoints"
+				instance.Spec.Filters.Interface = "internal"
+				instance.Spec.Projects = projectNames[:1]
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				endpointGroup := GetFakeEndpointGroup(endpointGroupName.Name)
+				g.Expect(endpointGroup).NotTo(BeNil())
+				g.Expect(endpointGroup["id"]).To(Equal(endpointGroupID))
+				g.Expect(endpointGroup["description"]).To(Equal("internal endpoints"))
+				g.Expect(endpointGroup["filters"]).To(HaveKeyWithValue("interface", "internal"))
+
+				instance := GetKeystoneEndpointGroup(endpointGroupName)
+				g.Expect(instance.Status.ProjectIDs).To(Equal(map[string]string{
+					projectNames[0]: projectIDs[0],
+				}))
+				g.Expect(instance.Status.ObservedGeneration).To(Equal(instance.Generation))
+			}, timeout, interval).Should(Succeed())
+			Expect(osClient.HasResource(endpointGroupProjectPath(endpointGroupID, projectIDs[0]))).To(BeTrue())
+			Expect(osClient.HasResource(endpointGroupProjectPath(endpointGroupID, projectIDs[1]))).To(BeFalse())
+		})
+
+		It("deletes the endpoint group and removes the finalizers", func() {
+			th.ExpectCondition(
+				endpointGroupName,
+				ConditionGetterFunc(KeystoneEndpointGroupConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			endpointGroupID := GetKeystoneEndpointGroup(endpointGroupName).Status.EndpointGroupID
+
+			th.DeleteInstance(GetKeystoneEndpointGroup(endpointGroupName))
+
+			Expect(GetFakeEndpointGroup(endpointGroupName.Name)).To(BeNil())
+			for _, projectID := range projectIDs {
+				Expect(osClient.HasResource(endpointGroupProjectPath(endpointGroupID, projectID))).To(BeFalse())
+			}
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Finalizers).NotTo(
+					ContainElement(fmt.Sprintf("%s-%s", endpointGroupFinalizer, endpointGroupName.Name)))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("a project of the endpoint group does not exist", func() {
+		BeforeEach(func() {
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneEndpointGroup(endpointGroupName, map[string]interface{}{
+				"filters": map[string]interface{}{
+					"interface": "public",
+				},
+				"projects": []string{"missing"},
+			}))
+		})
+
+		It("reports the project", func() {
+			th.ExpectCondition(
+				endpointGroupName,
+				ConditionGetterFunc(KeystoneEndpointGroupConditionGetter),
+				keystonev1.KeystoneEndpointGroupReadyCondition,
+				corev1.ConditionTrue,
+			)
+			th.ExpectConditionWithDetails(
+				endpointGroupName,
+				ConditionGetterFunc(KeystoneEndpointGroupConditionGetter),
+				keystonev1.KeystoneEndpointGroupProjectsReadyCondition,
+				corev1.ConditionFalse,
+				condition.ErrorReason,
+				fmt.Sprintf(keystonev1.KeystoneEndpointGroupProjectsReadyErrorMessage,
+					"project missing not found in domain Default"),
+			)
+			th.ExpectCondition(
+				endpointGroupName,
+				ConditionGetterFunc(KeystoneEndpointGroupConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+			)
+		})
+	})
+
+	When("the keystone API fails", func() {
+		BeforeEach(func() {
+			osClient.InjectError("endpoint_groups", errors.New("keystone is down"))
+			DeferCleanup(func() {
+				osClient.InjectError("endpoint_groups", nil)
+			})
+
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneEndpointGroup(endpointGroupName, map[string]interface{}{
+				"filters": map[string]interface{}{
+					"interface": "public",
+				},
+			}))
+		})
+
+		It("reports the error and recovers", func() {
+			Eventually(func(g Gomega) {
+				conditions := GetKeystoneEndpointGroup(endpointGroupName).Status.Conditions
+				g.Expect(conditions.IsFalse(keystonev1.KeystoneEndpointGroupReadyCondition)).To(BeTrue())
+				readyCondition := conditions.Get(keystonev1.KeystoneEndpointGroupReadyCondition)
+				g.Expect(readyCondition.Reason).To(BeEquivalentTo(condition.ErrorReason))
+				g.Expect(readyCondition.Message).To(ContainSubstring("keystone is down"))
+			}, timeout, interval).Should(Succeed())
+			Expect(GetFakeEndpointGroup(endpointGroupName.Name)).To(BeNil())
+			th.ExpectCondition(
+				endpointGroupName,
+				ConditionGetterFunc(KeystoneEndpointGroupConditionGetter),
+				keystonev1.DegradedCondition,
+				corev1.ConditionTrue,
+			)
+
+			// a degraded instance gets requeued slowly, the spec change
+			// triggers the next reconcile
+			osClient.InjectError("endpoint_groups", nil)
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneEndpointGroup(endpointGroupName)
+				instance.Spec.Description = "public endpoints"
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectCondition(
+				endpointGroupName,
+				ConditionGetterFunc(KeystoneEndpointGroupConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			Expect(GetFakeEndpointGroup(endpointGroupName.Name)).NotTo(BeNil())
+			Eventually(func(g Gomega) {
+				conditions := GetKeystoneEndpointGroup(endpointGroupName).Status.Conditions
+				g.Expect(conditions.Has(keystonev1.DegradedCondition)).To(BeFalse())
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("the endpoint group is deleted with the KeystoneAPI", func() {
+		BeforeEach(func() {
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			CreateKeystoneEndpointGroup(endpointGroupName, map[string]interface{}{
+				"filters": map[string]interface{}{
+					"interface": "public",
+				},
+			})
+		})
+
+		It("does not block the deletion of the KeystoneAPI", func() {
+			th.ExpectCondition(
+				endpointGroupName,
+				ConditionGetterFunc(KeystoneEndpointGroupConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			Expect(k8sClient.Delete(ctx, GetKeystoneAPI(keystoneAPIName))).To(Succeed())
+			th.DeleteInstance(GetKeystoneEndpointGroup(endpointGroupName))
+			Eventually(func(g Gomega) {
+				err := k8sClient.Get(ctx, keystoneAPIName, &keystonev1.KeystoneAPI{})
+				g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,369 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const limitFinalizer = "openstack.org/keystonelimit"
+
+func CreateKeystoneLimit(name types.NamespacedName, spec map[string]interface{}) client.Object {
+	raw := map[string]interface{}{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneLimit",
+		"metadata": map[string]interface{}{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneLimit(name types.NamespacedName) *keystonev1.KeystoneLimit {
+	instance := &keystonev1.KeystoneLimit{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+func KeystoneLimitConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetKeystoneLimit(name)
+	return instance.Status.Conditions
+}
+
+var _ = Describe("KeystoneLimit controller", func() {
+
+	var keystoneAPIName types.NamespacedName
+	var keystoneServiceName types.NamespacedName
+	var limitName types.NamespacedName
+	var serviceID string
+	var projectName string
+	var projectID string
+	var spec map[string]interface{}
+
+	BeforeEach(func() {
+		keystoneAPIName = types.NamespacedName{
+			Name:      "keystone",
+			Namespace: namespace,
+		}
+		keystoneServiceName = types.NamespacedName{
+			Name:      "nova",
+			Namespace: namespace,
+		}
+		limitName = types.NamespacedName{
+			Name:      "limit-" + uuid.New().String()[:8],
+			Namespace: namespace,
+		}
+		// the keystone API is shared by all tests, the names of the
+		// projects and the IDs of the services need to be unique
+		serviceID = uuid.New().String()
+		projectName = limitName.Name + "-project"
+		var err error
+		projectID, err = osClient.CreateProject(logger, openstack.Project{
+			Name:     projectName,
+			DomainID: "default",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		spec = map[string]interface{}{
+			"serviceName":   keystoneServiceName.Name,
+			"resourceName":  "instances",
+			"resourceLimit": 10,
+			"description":   "instances of the project",
+			"projectName":   projectName,
+		}
+	})
+
+	When("the KeystoneAPI does not exist", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneLimit(limitName, spec))
+		})
+
+		It("waits for the KeystoneAPI", func() {
+			th.ExpectConditionWithDetails(
+				limitName,
+				ConditionGetterFunc(KeystoneLimitConditionGetter),
+				keystonev1.KeystoneAPIReadyCondition,
+				corev1.ConditionFalse,
+				condition.ErrorReason,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			)
+			th.ExpectCondition(
+				limitName,
+				ConditionGetterFunc(KeystoneLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+			)
+		})
+
+		It("can be deleted", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneLimit(limitName).Finalizers).To(ContainElement(limitFinalizer))
+			}, timeout, interval).Should(Succeed())
+
+			th.DeleteInstance(GetKeystoneLimit(limitName))
+		})
+	})
+
+	When("the KeystoneService has no service ID yet", func() {
+		BeforeEach(func() {
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneLimit(limitName, spec))
+		})
+
+		It("waits for the KeystoneService and creates the limit once it has", func() {
+			th.ExpectConditionWithDetails(
+				limitName,
+				ConditionGetterFunc(KeystoneLimitConditionGetter),
+				keystonev1.KeystoneLimitReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				fmt.Sprintf(keystonev1.KeystoneLimitReadyWaitingMessage,
+					fmt.Sprintf("KeystoneService %s", keystoneServiceName.Name)),
+			)
+
+			CreateKeystoneServiceWithID(keystoneServiceName, serviceID)
+
+			th.ExpectCondition(
+				limitName,
+				ConditionGetterFunc(KeystoneLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("a project limit is created", func() {
+		BeforeEach(func() {
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			CreateKeystoneServiceWithID(keystoneServiceName, serviceID)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneLimit(limitName, spec))
+		})
+
+		It("creates the limit of the project", func() {
+			th.ExpectCondition(
+				limitName,
+				ConditionGetterFunc(KeystoneLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			for _, conditionType := range []condition.Type{
+				keystonev1.KeystoneAPIReadyCondition,
+				keystonev1.AdminServiceClientReadyCondition,
+				keystonev1.KeystoneLimitReadyCondition,
+			} {
+				th.ExpectCondition(
+					limitName,
+					ConditionGetterFunc(KeystoneLimitConditionGetter),
+					conditionType,
+					corev1.ConditionTrue,
+				)
+			}
+
+			instance := GetKeystoneLimit(limitName)
+			Expect(instance.Status.LimitID).NotTo(BeEmpty())
+			limit := GetFakeLimit("limits", instance.Status.LimitID)
+			Expect(limit).NotTo(BeNil())
+			Expect(limit["service_id"]).To(Equal(serviceID))
+			Expect(limit["project_id"]).To(Equal(projectID))
+			Expect(limit).NotTo(HaveKey("domain_id"))
+			Expect(limit["resource_name"]).To(Equal("instances"))
+			Expect(limit["resource_limit"]).To(BeEquivalentTo(10))
+			Expect(limit["description"]).To(Equal("instances of the project"))
+		})
+
+		It("adds the finalizers to itself and the KeystoneAPI", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneLimit(limitName).Finalizers).To(ContainElement(limitFinalizer))
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Finalizers).To(
+					ContainElement(fmt.Sprintf("%s-%s", limitFinalizer, limitName.Name)))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("labels itself with the KeystoneAPI", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneLimit(limitName).Labels).To(
+					HaveKeyWithValue(keystonev1.KeystoneAPILabel, keystoneAPIName.Name))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("updates the limit", func() {
+			th.ExpectCondition(
+				limitName,
+				ConditionGetterFunc(KeystoneLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			limitID := GetKeystoneLimit(limitName).Status.LimitID
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneLimit(limitName)
+				instance.Spec.ResourceLimit = 20
+				instance.Spec.Description = "more instances of the project"
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				limit := GetFakeLimit("limits", limitID)
+				g.Expect(limit).NotTo(BeNil())
+				g.Expect(limit["resource_limit"]).To(BeEquivalentTo(20))
+				g.Expect(limit["description"]).To(Equal("more instances of the project"))
+
+				instance := GetKeystoneLimit(limitName)
+				g.Expect(instance.Status.LimitID).To(Equal(limitID))
+				g.Expect(instance.Status.ObservedGeneration).To(Equal(instance.Generation))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("deletes the limit and removes the finalizers", func() {
+			th.ExpectCondition(
+				limitName,
+				ConditionGetterFunc(KeystoneLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			limitID := GetKeystoneLimit(limitName).Status.LimitID
+
+			th.DeleteInstance(GetKeystoneLimit(limitName))
+
+			Expect(GetFakeLimit("limits", limitID)).To(BeNil())
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Finalizers).NotTo(
+					ContainElement(fmt.Sprintf("%s-%s", limitFinalizer, limitName.Name)))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("a domain limit is created", func() {
+		BeforeEach(func() {
+			delete(spec, "projectName")
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			CreateKeystoneServiceWithID(keystoneServiceName, serviceID)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneLimit(limitName, spec))
+		})
+
+		It("creates the limit of the domain", func() {
+			th.ExpectCondition(
+				limitName,
+				ConditionGetterFunc(KeystoneLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			limit := GetFakeLimit("limits", GetKeystoneLimit(limitName).Status.LimitID)
+			Expect(limit).NotTo(BeNil())
+			Expect(limit["domain_id"]).To(Equal("default"))
+			Expect(limit).NotTo(HaveKey("project_id"))
+		})
+	})
+
+	When("the project of the limit does not exist", func() {
+		BeforeEach(func() {
+			spec["projectName"] = "missing"
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			CreateKeystoneServiceWithID(keystoneServiceName, serviceID)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneLimit(limitName, spec))
+		})
+
+		It("waits for the project", func() {
+			th.ExpectConditionWithDetails(
+				limitName,
+				ConditionGetterFunc(KeystoneLimitConditionGetter),
+				keystonev1.KeystoneLimitReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				fmt.Sprintf(keystonev1.KeystoneLimitReadyWaitingMessage, "project missing"),
+			)
+			th.ExpectCondition(
+				limitName,
+				ConditionGetterFunc(KeystoneLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+			)
+			Expect(GetKeystoneLimit(limitName).Status.LimitID).To(BeEmpty())
+		})
+	})
+
+	When("the keystone API fails", func() {
+		BeforeEach(func() {
+			osClient.InjectError("limits", errors.New("keystone is down"))
+			DeferCleanup(func() {
+				osClient.InjectError("limits", nil)
+			})
+
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			CreateKeystoneServiceWithID(keystoneServiceName, serviceID)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneLimit(limitName, spec))
+		})
+
+		It("reports the error and recovers", func() {
+			Eventually(func(g Gomega) {
+				conditions := GetKeystoneLimit(limitName).Status.Conditions
+				g.Expect(conditions.IsFalse(keystonev1.KeystoneLimitReadyCondition)).To(BeTrue())
+				readyCondition := conditions.Get(keystonev1.KeystoneLimitReadyCondition)
+				g.Expect(readyCondition.Reason).To(BeEquivalentTo(condition.ErrorReason))
+				g.Expect(readyCondition.Message).To(ContainSubstring("keystone is down"))
+			}, timeout, interval).Should(Succeed())
+			th.ExpectCondition(
+				limitName,
+				ConditionGetterFunc(KeystoneLimitConditionGetter),
+				keystonev1.DegradedCondition,
+				corev1.ConditionTrue,
+			)
+
+			// a degraded instance gets requeued slowly, the spec change
+			// triggers the next reconcile
+			osClient.InjectError("limits", nil)
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneLimit(limitName)
+				instance.Spec.ResourceLimit = 5
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectCondition(
+				limitName,
+				ConditionGetterFunc(KeystoneLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			limit := GetFakeLimit("limits", GetKeystoneLimit(limitName).Status.LimitID)
+			Expect(limit).NotTo(BeNil())
+			Expect(limit["resource_limit"]).To(BeEquivalentTo(5))
+			Eventually(func(g Gomega) {
+				conditions := GetKeystoneLimit(limitName).Status.Conditions
+				g.Expect(conditions.Has(keystonev1.DegradedCondition)).To(BeFalse())
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+})
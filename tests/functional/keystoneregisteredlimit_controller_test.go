@@ -0,0 +1,316 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const registeredLimitFinalizer = "openstack.org/keystoneregisteredlimit"
+
+func CreateKeystoneRegisteredLimit(name types.NamespacedName, spec map[string]interface{}) client.Object {
+	raw := map[string]interface{}{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneRegisteredLimit",
+		"metadata": map[string]interface{}{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneRegisteredLimit(name types.NamespacedName) *keystonev1.KeystoneRegisteredLimit {
+	instance := &keystonev1.KeystoneRegisteredLimit{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+func KeystoneRegisteredLimitConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetKeystoneRegisteredLimit(name)
+	return instance.Status.Conditions
+}
+
+// GetFakeLimit - returns the limit or registered limit with the ID from the
+// keystone API of the controllers, nil if it does not exist
+func GetFakeLimit(collectionPath string, id string) map[string]interface{} {
+	for _, limit := range osClient.ListResources(collectionPath) {
+		if limit["id"] == id {
+			return limit
+		}
+	}
+	return nil
+}
+
+var _ = Describe("KeystoneRegisteredLimit controller", func() {
+
+	var keystoneAPIName types.NamespacedName
+	var keystoneServiceName types.NamespacedName
+	var registeredLimitName types.NamespacedName
+	var serviceID string
+	var spec map[string]interface{}
+
+	BeforeEach(func() {
+		keystoneAPIName = types.NamespacedName{
+			Name:      "keystone",
+			Namespace: namespace,
+		}
+		keystoneServiceName = types.NamespacedName{
+			Name:      "nova",
+			Namespace: namespace,
+		}
+		registeredLimitName = types.NamespacedName{
+			Name:      "rl-" + uuid.New().String()[:8],
+			Namespace: namespace,
+		}
+		// the keystone API is shared by all tests, the registered limits
+		// are told apart by the ID of their service
+		serviceID = uuid.New().String()
+		spec = map[string]interface{}{
+			"serviceName":  keystoneServiceName.Name,
+			"resourceName": "instances",
+			"defaultLimit": 10,
+			"description":  "instances per project",
+		}
+	})
+
+	When("the KeystoneAPI does not exist", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneRegisteredLimit(registeredLimitName, spec))
+		})
+
+		It("waits for the KeystoneAPI", func() {
+			th.ExpectConditionWithDetails(
+				registeredLimitName,
+				ConditionGetterFunc(KeystoneRegisteredLimitConditionGetter),
+				keystonev1.KeystoneAPIReadyCondition,
+				corev1.ConditionFalse,
+				condition.ErrorReason,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			)
+			th.ExpectCondition(
+				registeredLimitName,
+				ConditionGetterFunc(KeystoneRegisteredLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+			)
+		})
+
+		It("can be deleted", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneRegisteredLimit(registeredLimitName).Finalizers).To(ContainElement(registeredLimitFinalizer))
+			}, timeout, interval).Should(Succeed())
+
+			th.DeleteInstance(GetKeystoneRegisteredLimit(registeredLimitName))
+		})
+	})
+
+	When("the KeystoneService has no service ID yet", func() {
+		BeforeEach(func() {
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneRegisteredLimit(registeredLimitName, spec))
+		})
+
+		It("waits for the KeystoneService and creates the registered limit once it has", func() {
+			th.ExpectConditionWithDetails(
+				registeredLimitName,
+				ConditionGetterFunc(KeystoneRegisteredLimitConditionGetter),
+				keystonev1.KeystoneRegisteredLimitReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				fmt.Sprintf(keystonev1.KeystoneRegisteredLimitReadyWaitingMessage, keystoneServiceName.Name),
+			)
+
+			CreateKeystoneServiceWithID(keystoneServiceName, serviceID)
+
+			th.ExpectCondition(
+				registeredLimitName,
+				ConditionGetterFunc(KeystoneRegisteredLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("a registered limit is created", func() {
+		BeforeEach(func() {
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			CreateKeystoneServiceWithID(keystoneServiceName, serviceID)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneRegisteredLimit(registeredLimitName, spec))
+		})
+
+		It("creates the registered limit", func() {
+			th.ExpectCondition(
+				registeredLimitName,
+				ConditionGetterFunc(KeystoneRegisteredLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			for _, conditionType := range []condition.Type{
+				keystonev1.KeystoneAPIReadyCondition,
+				keystonev1.AdminServiceClientReadyCondition,
+				keystonev1.KeystoneRegisteredLimitReadyCondition,
+			} {
+				th.ExpectCondition(
+					registeredLimitName,
+					ConditionGetterFunc(KeystoneRegisteredLimitConditionGetter),
+					conditionType,
+					corev1.ConditionTrue,
+				)
+			}
+
+			instance := GetKeystoneRegisteredLimit(registeredLimitName)
+			Expect(instance.Status.RegisteredLimitID).NotTo(BeEmpty())
+			registeredLimit := GetFakeLimit("registered_limits", instance.Status.RegisteredLimitID)
+			Expect(registeredLimit).NotTo(BeNil())
+			Expect(registeredLimit["service_id"]).To(Equal(serviceID))
+			Expect(registeredLimit["resource_name"]).To(Equal("instances"))
+			Expect(registeredLimit["default_limit"]).To(BeEquivalentTo(10))
+			Expect(registeredLimit["description"]).To(Equal("instances per project"))
+		})
+
+		It("adds the finalizers to itself and the KeystoneAPI", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneRegisteredLimit(registeredLimitName).Finalizers).To(ContainElement(registeredLimitFinalizer))
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Finalizers).To(
+					ContainElement(fmt.Sprintf("%s-%s", registeredLimitFinalizer, registeredLimitName.Name)))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("labels itself with the KeystoneAPI", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneRegisteredLimit(registeredLimitName).Labels).To(
+					HaveKeyWithValue(keystonev1.KeystoneAPILabel, keystoneAPIName.Name))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("updates the registered limit", func() {
+			th.ExpectCondition(
+				registeredLimitName,
+				ConditionGetterFunc(KeystoneRegisteredLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			registeredLimitID := GetKeystoneRegisteredLimit(registeredLimitName).Status.RegisteredLimitID
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneRegisteredLimit(registeredLimitName)
+				instance.Spec.DefaultLimit = 20
+				instance.Spec.Description = "more instances per project"
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				registeredLimit := GetFakeLimit("registered_limits", registeredLimitID)
+				g.Expect(registeredLimit).NotTo(BeNil())
+				g.Expect(registeredLimit["default_limit"]).To(BeEquivalentTo(20))
+				g.Expect(registeredLimit["description"]).To(Equal("more instances per project"))
+
+				instance := GetKeystoneRegisteredLimit(registeredLimitName)
+				g.Expect(instance.Status.RegisteredLimitID).To(Equal(registeredLimitID))
+				g.Expect(instance.Status.ObservedGeneration).To(Equal(instance.Generation))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("deletes the registered limit and removes the finalizers", func() {
+			th.ExpectCondition(
+				registeredLimitName,
+				ConditionGetterFunc(KeystoneRegisteredLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			registeredLimitID := GetKeystoneRegisteredLimit(registeredLimitName).Status.RegisteredLimitID
+
+			th.DeleteInstance(GetKeystoneRegisteredLimit(registeredLimitName))
+
+			Expect(GetFakeLimit("registered_limits", registeredLimitID)).To(BeNil())
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Finalizers).NotTo(
+					ContainElement(fmt.Sprintf("%s-%s", registeredLimitFinalizer, registeredLimitName.Name)))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("the keystone API fails", func() {
+		BeforeEach(func() {
+			osClient.InjectError("registered_limits", errors.New("keystone is down"))
+			DeferCleanup(func() {
+				osClient.InjectError("registered_limits", nil)
+			})
+
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			CreateKeystoneServiceWithID(keystoneServiceName, serviceID)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneRegisteredLimit(registeredLimitName, spec))
+		})
+
+		It("reports the error and recovers", func() {
+			Eventually(func(g Gomega) {
+				conditions := GetKeystoneRegisteredLimit(registeredLimitName).Status.Conditions
+				g.Expect(conditions.IsFalse(keystonev1.KeystoneRegisteredLimitReadyCondition)).To(BeTrue())
+				readyCondition := conditions.Get(keystonev1.KeystoneRegisteredLimitReadyCondition)
+				g.Expect(readyCondition.Reason).To(BeEquivalentTo(condition.ErrorReason))
+				g.Expect(readyCondition.Message).To(ContainSubstring("keystone is down"))
+			}, timeout, interval).Should(Succeed())
+			th.ExpectCondition(
+				registeredLimitName,
+				ConditionGetterFunc(KeystoneRegisteredLimitConditionGetter),
+				keystonev1.DegradedCondition,
+				corev1.ConditionTrue,
+			)
+
+			// a degraded instance gets requeued slowly, the spec change
+			// triggers the next reconcile
+			osClient.InjectError("registered_limits", nil)
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneRegisteredLimit(registeredLimitName)
+				instance.Spec.DefaultLimit = 5
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectCondition(
+				registeredLimitName,
+				ConditionGetterFunc(KeystoneRegisteredLimitConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			registeredLimit := GetFakeLimit("registered_limits", GetKeystoneRegisteredLimit(registeredLimitName).Status.RegisteredLimitID)
+			Expect(registeredLimit).NotTo(BeNil())
+			Expect(registeredLimit["default_limit"]).To(BeEquivalentTo(5))
+			Eventually(func(g Gomega) {
+				conditions := GetKeystoneRegisteredLimit(registeredLimitName).Status.Conditions
+				g.Expect(conditions.Has(keystonev1.DegradedCondition)).To(BeFalse())
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+})
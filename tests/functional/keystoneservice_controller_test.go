@@ -0,0 +1,226 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/controllers"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newKeystoneServiceReconciler - the KeystoneService controller does not run
+// in the manager as other tests create KeystoneServices with a fixed service
+// ID, its tests call the reconcile directly
+func newKeystoneServiceReconciler() *controllers.KeystoneServiceReconciler {
+	kclient, err := kubernetes.NewForConfig(cfg)
+	Expect(err).ToNot(HaveOccurred())
+	return &controllers.KeystoneServiceReconciler{
+		Client:          k8sClient,
+		Scheme:          k8sClient.Scheme(),
+		Kclient:         kclient,
+		Requeue:         controllers.RequeueIntervals{KeystoneAPI: interval, KeystoneService: interval},
+		OpenStackClient: osClient.Factory(),
+	}
+}
+
+func CreateKeystoneService(name types.NamespacedName, spec map[string]interface{}) client.Object {
+	raw := map[string]interface{}{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneService",
+		"metadata": map[string]interface{}{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+// ReconcileKeystoneService - reconciles the KeystoneService until it is ready
+func ReconcileKeystoneService(r *controllers.KeystoneServiceReconciler, name types.NamespacedName) *keystonev1.KeystoneService {
+	Eventually(func(g Gomega) {
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(keystone.GetKeystoneService(name).IsReady()).To(BeTrue())
+	}, timeout, interval).Should(Succeed())
+	return keystone.GetKeystoneService(name)
+}
+
+// UpdateKeystoneServiceSpec - sets the field of the spec of the KeystoneService
+func UpdateKeystoneServiceSpec(name types.NamespacedName, update func(spec *keystonev1.KeystoneServiceSpec)) {
+	Eventually(func(g Gomega) {
+		instance := keystone.GetKeystoneService(name)
+		update(&instance.Spec)
+		g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+	}, timeout, interval).Should(Succeed())
+}
+
+// DeleteKeystoneService - deletes the KeystoneService and reconciles it until
+// its finalizer is removed
+func DeleteKeystoneService(r *controllers.KeystoneServiceReconciler, name types.NamespacedName) {
+	Eventually(func(g Gomega) {
+		instance := &keystonev1.KeystoneService{}
+		err := k8sClient.Get(ctx, name, instance)
+		if k8s_errors.IsNotFound(err) {
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		if instance.DeletionTimestamp.IsZero() {
+			g.Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+		}
+		_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: name})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(k8s_errors.IsNotFound(k8sClient.Get(ctx, name, instance))).To(BeTrue())
+	}, timeout, interval).Should(Succeed())
+}
+
+var _ = Describe("KeystoneService controller", func() {
+
+	var reconciler *controllers.KeystoneServiceReconciler
+	var keystoneAPIName types.NamespacedName
+	var keystoneServiceName types.NamespacedName
+	var serviceUser string
+	var spec map[string]interface{}
+
+	BeforeEach(func() {
+		reconciler = newKeystoneServiceReconciler()
+		keystoneAPIName = types.NamespacedName{
+			Name:      "keystone",
+			Namespace: namespace,
+		}
+		// the keystone API is shared by all tests, the names of the services
+		// and users need to be unique
+		keystoneServiceName = types.NamespacedName{
+			Name:      "service-" + uuid.New().String()[:8],
+			Namespace: namespace,
+		}
+		serviceUser = keystoneServiceName.Name
+		passwordSecretName := types.NamespacedName{
+			Name:      keystoneServiceName.Name + "-password",
+			Namespace: namespace,
+		}
+		spec = map[string]interface{}{
+			"serviceType":      keystoneServiceName.Name,
+			"serviceName":      keystoneServiceName.Name,
+			"enabled":          true,
+			"serviceUser":      serviceUser,
+			"secret":           passwordSecretName.Name,
+			"passwordSelector": "ServicePassword",
+		}
+
+		CreateReadyKeystoneAPI(keystoneAPIName)
+		DeferCleanup(k8sClient.Delete, ctx, th.CreateSecret(passwordSecretName, map[string][]byte{
+			"ServicePassword": []byte("12345678"),
+		}))
+		CreateKeystoneService(keystoneServiceName, spec)
+		// runs before the cleanup of the KeystoneAPI, the KeystoneService
+		// holds a finalizer on it
+		DeferCleanup(func() {
+			DeleteKeystoneService(reconciler, keystoneServiceName)
+		})
+	})
+
+	It("records the IDs of the domain, project and user of the service user", func() {
+		instance := ReconcileKeystoneService(reconciler, keystoneServiceName)
+
+		user, err := osClient.GetUser(logger, serviceUser, "default")
+		Expect(err).NotTo(HaveOccurred())
+		project, err := osClient.GetProject(logger, "service", "default")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(instance.Status.DomainID).To(Equal("default"))
+		Expect(instance.Status.ProjectID).To(Equal(project.ID))
+		Expect(instance.Status.UserID).To(Equal(user.ID))
+		Expect(osClient.HasRoleAssignment("admin", user.ID, project.ID)).To(BeTrue())
+		Expect(osClient.HasRoleAssignment("service", user.ID, project.ID)).To(BeTrue())
+	})
+
+	It("moves the role assignments to a new ServiceProject", func() {
+		instance := ReconcileKeystoneService(reconciler, keystoneServiceName)
+		userID := instance.Status.UserID
+		oldProjectID := instance.Status.ProjectID
+
+		UpdateKeystoneServiceSpec(keystoneServiceName, func(spec *keystonev1.KeystoneServiceSpec) {
+			spec.ServiceProject = keystoneServiceName.Name
+		})
+		instance = ReconcileKeystoneService(reconciler, keystoneServiceName)
+
+		project, err := osClient.GetProject(logger, keystoneServiceName.Name, "default")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(instance.Status.ProjectID).To(Equal(project.ID))
+		Expect(instance.Status.UserID).To(Equal(userID))
+		Expect(osClient.HasRoleAssignment("admin", userID, project.ID)).To(BeTrue())
+		Expect(osClient.HasRoleAssignment("service", userID, project.ID)).To(BeTrue())
+		Expect(osClient.HasRoleAssignment("admin", userID, oldProjectID)).To(BeFalse())
+		Expect(osClient.HasRoleAssignment("service", userID, oldProjectID)).To(BeFalse())
+	})
+
+	It("deletes the former user when the ServiceUser gets renamed", func() {
+		oldUserID := ReconcileKeystoneService(reconciler, keystoneServiceName).Status.UserID
+
+		UpdateKeystoneServiceSpec(keystoneServiceName, func(spec *keystonev1.KeystoneServiceSpec) {
+			spec.ServiceUser = serviceUser + "-renamed"
+		})
+		instance := ReconcileKeystoneService(reconciler, keystoneServiceName)
+
+		user, err := osClient.GetUser(logger, serviceUser+"-renamed", "default")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(instance.Status.UserID).To(Equal(user.ID))
+		Expect(osClient.GetUserByID(oldUserID)).To(BeNil())
+	})
+
+	It("deletes the user of the former ServiceDomain", func() {
+		oldUserID := ReconcileKeystoneService(reconciler, keystoneServiceName).Status.UserID
+
+		domainName := keystoneServiceName.Name + "-domain"
+		UpdateKeystoneServiceSpec(keystoneServiceName, func(spec *keystonev1.KeystoneServiceSpec) {
+			spec.ServiceDomain = domainName
+		})
+		instance := ReconcileKeystoneService(reconciler, keystoneServiceName)
+
+		domain, err := osClient.GetDomain(domainName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(domain).NotTo(BeNil())
+		user, err := osClient.GetUser(logger, serviceUser, domain.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(instance.Status.DomainID).To(Equal(domain.ID))
+		Expect(instance.Status.UserID).To(Equal(user.ID))
+		Expect(osClient.GetUserByID(oldUserID)).To(BeNil())
+	})
+
+	It("deletes the recorded user even if the spec changed since", func() {
+		userID := ReconcileKeystoneService(reconciler, keystoneServiceName).Status.UserID
+
+		// not reconciled, the user still lives in the default domain
+		UpdateKeystoneServiceSpec(keystoneServiceName, func(spec *keystonev1.KeystoneServiceSpec) {
+			spec.ServiceDomain = keystoneServiceName.Name + "-domain"
+		})
+		DeleteKeystoneService(reconciler, keystoneServiceName)
+
+		Expect(osClient.GetUserByID(userID)).To(BeNil())
+		_, err := osClient.GetService(logger, keystoneServiceName.Name, keystoneServiceName.Name)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,502 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/users"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/keystone-operator/controllers"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/identity"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const userFinalizer = "openstack.org/keystoneuser"
+
+func CreateKeystoneUser(name types.NamespacedName, spec map[string]interface{}) client.Object {
+	raw := map[string]interface{}{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneUser",
+		"metadata": map[string]interface{}{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneUser(name types.NamespacedName) *keystonev1.KeystoneUser {
+	instance := &keystonev1.KeystoneUser{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+func KeystoneUserConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetKeystoneUser(name)
+	return instance.Status.Conditions
+}
+
+// TriggerKeystoneUserReconcile - the controller checks the users in keystone
+// only every few minutes, a change of the metadata makes it reconcile now
+func TriggerKeystoneUserReconcile(name types.NamespacedName) {
+	Eventually(func(g Gomega) {
+		instance := GetKeystoneUser(name)
+		if instance.Annotations == nil {
+			instance.Annotations = map[string]string{}
+		}
+		instance.Annotations["test/trigger"] = uuid.New().String()
+		g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+	}, timeout, interval).Should(Succeed())
+}
+
+var _ = Describe("KeystoneUser controller", func() {
+
+	var keystoneAPIName types.NamespacedName
+	var keystoneUserName types.NamespacedName
+	var passwordSecretName types.NamespacedName
+	var userName string
+	var spec map[string]interface{}
+
+	BeforeEach(func() {
+		keystoneAPIName = types.NamespacedName{
+			Name:      "keystone",
+			Namespace: namespace,
+		}
+		keystoneUserName = types.NamespacedName{
+			Name:      "user",
+			Namespace: namespace,
+		}
+		passwordSecretName = types.NamespacedName{
+			Name:      "user-password",
+			Namespace: namespace,
+		}
+		// the keystone API is shared by all tests, the names of the users
+		// need to be unique
+		userName = "user-" + uuid.New().String()[:8]
+		spec = map[string]interface{}{
+			"userName":         userName,
+			"secret":           passwordSecretName.Name,
+			"passwordSelector": "Password",
+		}
+	})
+
+	When("the KeystoneAPI does not exist", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneUser(keystoneUserName, spec))
+		})
+
+		It("waits for the KeystoneAPI", func() {
+			th.ExpectConditionWithDetails(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				keystonev1.KeystoneAPIReadyCondition,
+				corev1.ConditionFalse,
+				condition.ErrorReason,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			)
+			th.ExpectCondition(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+			)
+		})
+
+		It("can be deleted", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneUser(keystoneUserName).Finalizers).To(ContainElement(userFinalizer))
+			}, timeout, interval).Should(Succeed())
+
+			th.DeleteInstance(GetKeystoneUser(keystoneUserName))
+		})
+	})
+
+	When("the Secret of the password does not exist", func() {
+		BeforeEach(func() {
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneUser(keystoneUserName, spec))
+		})
+
+		It("waits for the Secret", func() {
+			th.ExpectConditionWithDetails(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				keystonev1.KeystoneUserReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				fmt.Sprintf(keystonev1.KeystoneUserReadyWaitingMessage,
+					fmt.Sprintf("secret %s", passwordSecretName.Name)),
+			)
+			Expect(osClient.GetUser(logger, userName, "default")).Error().To(HaveOccurred())
+		})
+	})
+
+	When("a user is created", func() {
+		BeforeEach(func() {
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(k8sClient.Delete, ctx, th.CreateSecret(passwordSecretName, map[string][]byte{
+				"Password": []byte("12345678"),
+			}))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneUser(keystoneUserName, spec))
+		})
+
+		It("creates the user with the password", func() {
+			th.ExpectCondition(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			for _, conditionType := range []condition.Type{
+				keystonev1.KeystoneAPIReadyCondition,
+				keystonev1.AdminServiceClientReadyCondition,
+				keystonev1.KeystoneUserReadyCondition,
+			} {
+				th.ExpectCondition(
+					keystoneUserName,
+					ConditionGetterFunc(KeystoneUserConditionGetter),
+					conditionType,
+					corev1.ConditionTrue,
+				)
+			}
+
+			instance := GetKeystoneUser(keystoneUserName)
+			Expect(instance.Status.Created).To(BeTrue())
+			Expect(instance.Status.DomainID).To(Equal("default"))
+			Expect(instance.Status.Enabled).To(HaveValue(BeTrue()))
+
+			user := osClient.GetUserByID(instance.Status.UserID)
+			Expect(user).NotTo(BeNil())
+			Expect(user.Name).To(Equal(userName))
+			Expect(user.Enabled).To(BeTrue())
+			Expect(user.Extra).To(HaveKeyWithValue(controllers.OwnerUIDAttribute, string(instance.UID)))
+			Expect(osClient.GetPassword(instance.Status.UserID)).To(Equal("12345678"))
+		})
+
+		It("does not store a hash of the password", func() {
+			th.ExpectCondition(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			passwordHash, err := util.ObjectHash("12345678")
+			Expect(err).NotTo(HaveOccurred())
+
+			instance := GetKeystoneUser(keystoneUserName)
+			Expect(instance.Status.PasswordSecretHash).NotTo(BeEmpty())
+			Expect(instance.Status.PasswordSecretHash).NotTo(Equal(passwordHash))
+		})
+
+		It("adds the finalizers to itself and the KeystoneAPI", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneUser(keystoneUserName).Finalizers).To(ContainElement(userFinalizer))
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Finalizers).To(
+					ContainElement(fmt.Sprintf("%s-%s", userFinalizer, keystoneUserName.Name)))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("labels itself with the KeystoneAPI", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneUser(keystoneUserName).Labels).To(
+					HaveKeyWithValue(keystonev1.KeystoneAPILabel, keystoneAPIName.Name))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("updates the password when the Secret changes", func() {
+			th.ExpectCondition(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			instance := GetKeystoneUser(keystoneUserName)
+			userID := instance.Status.UserID
+			passwordSecretHash := instance.Status.PasswordSecretHash
+
+			Eventually(func(g Gomega) {
+				secret := th.GetSecret(passwordSecretName)
+				secret.Data["Password"] = []byte("87654321")
+				g.Expect(k8sClient.Update(ctx, &secret)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+			TriggerKeystoneUserReconcile(keystoneUserName)
+
+			Eventually(func(g Gomega) {
+				g.Expect(osClient.GetPassword(userID)).To(Equal("87654321"))
+				instance := GetKeystoneUser(keystoneUserName)
+				g.Expect(instance.Status.PasswordSecretHash).NotTo(Equal(passwordSecretHash))
+				g.Expect(instance.Status.UserID).To(Equal(userID))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("disables the user and reverts out of band changes", func() {
+			th.ExpectCondition(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			userID := GetKeystoneUser(keystoneUserName).Status.UserID
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneUser(keystoneUserName)
+				instance.Spec.Enabled = false
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(osClient.GetUserByID(userID).Enabled).To(BeFalse())
+				instance := GetKeystoneUser(keystoneUserName)
+				g.Expect(instance.Status.Enabled).To(HaveValue(BeFalse()))
+				g.Expect(instance.Status.EnabledChangeTime).NotTo(BeNil())
+				g.Expect(instance.Status.LastDriftRepairTime).To(BeNil())
+			}, timeout, interval).Should(Succeed())
+
+			Expect(identity.SetUserEnabled(logger, osClient, userID, true)).To(Succeed())
+			TriggerKeystoneUserReconcile(keystoneUserName)
+
+			Eventually(func(g Gomega) {
+				g.Expect(osClient.GetUserByID(userID).Enabled).To(BeFalse())
+				g.Expect(GetKeystoneUser(keystoneUserName).Status.LastDriftRepairTime).NotTo(BeNil())
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("deletes the previous user when the user gets renamed", func() {
+			th.ExpectCondition(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			userID := GetKeystoneUser(keystoneUserName).Status.UserID
+			newUserName := userName + "-renamed"
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneUser(keystoneUserName)
+				instance.Spec.UserName = newUserName
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneUser(keystoneUserName)
+				g.Expect(instance.Status.UserID).NotTo(BeEmpty())
+				g.Expect(instance.Status.UserID).NotTo(Equal(userID))
+				g.Expect(instance.Status.Created).To(BeTrue())
+
+				user := osClient.GetUserByID(instance.Status.UserID)
+				g.Expect(user).NotTo(BeNil())
+				g.Expect(user.Name).To(Equal(newUserName))
+				g.Expect(osClient.GetPassword(user.ID)).To(Equal("12345678"))
+			}, timeout, interval).Should(Succeed())
+			Expect(osClient.GetUserByID(userID)).To(BeNil())
+		})
+
+		It("deletes the user by its ID and removes the finalizers", func() {
+			th.ExpectCondition(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			userID := GetKeystoneUser(keystoneUserName).Status.UserID
+
+			th.DeleteInstance(GetKeystoneUser(keystoneUserName))
+
+			Expect(osClient.GetUserByID(userID)).To(BeNil())
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Finalizers).NotTo(
+					ContainElement(fmt.Sprintf("%s-%s", userFinalizer, keystoneUserName.Name)))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("a user is created with options", func() {
+		BeforeEach(func() {
+			spec["options"] = map[string]interface{}{
+				"ignoreChangePasswordUponFirstUse": true,
+				"lockPassword":                     true,
+				"multiFactorAuthRules":             [][]string{{"password", "totp"}},
+			}
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(k8sClient.Delete, ctx, th.CreateSecret(passwordSecretName, map[string][]byte{
+				"Password": []byte("12345678"),
+			}))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneUser(keystoneUserName, spec))
+		})
+
+		It("creates the user with the options", func() {
+			th.ExpectCondition(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			user := osClient.GetUserByID(GetKeystoneUser(keystoneUserName).Status.UserID)
+			Expect(user.Options).To(HaveKeyWithValue("ignore_change_password_upon_first_use", true))
+			Expect(user.Options).To(HaveKeyWithValue("lock_password", true))
+			Expect(user.Options).To(HaveKeyWithValue("multi_factor_auth_rules",
+				[]interface{}{[]interface{}{"password", "totp"}}))
+			Expect(user.Options).NotTo(HaveKey("ignore_password_expiry"))
+		})
+
+		It("reverts out of band changes and keeps the unmanaged options", func() {
+			th.ExpectCondition(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			userID := GetKeystoneUser(keystoneUserName).Status.UserID
+
+			Expect(identity.SetUserOptions(logger, osClient, userID, map[users.Option]interface{}{
+				"lock_password":            false,
+				users.IgnorePasswordExpiry: true,
+			})).To(Succeed())
+			TriggerKeystoneUserReconcile(keystoneUserName)
+
+			Eventually(func(g Gomega) {
+				user := osClient.GetUserByID(userID)
+				g.Expect(user.Options).To(HaveKeyWithValue("lock_password", true))
+				g.Expect(user.Options).To(HaveKeyWithValue("ignore_password_expiry", true))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("sets the options added to the spec", func() {
+			th.ExpectCondition(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			userID := GetKeystoneUser(keystoneUserName).Status.UserID
+
+			Eventually(func(g Gomega) {
+				instance := GetKeystoneUser(keystoneUserName)
+				instance.Spec.Options.MultiFactorAuthEnabled = ptr.To(true)
+				instance.Spec.Options.LockPassword = ptr.To(false)
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				user := osClient.GetUserByID(userID)
+				g.Expect(user.Options).To(HaveKeyWithValue("multi_factor_auth_enabled", true))
+				g.Expect(user.Options).To(HaveKeyWithValue("lock_password", false))
+				g.Expect(user.Options).To(HaveKeyWithValue("ignore_change_password_upon_first_use", true))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("the user exists in keystone", func() {
+		var userID string
+
+		BeforeEach(func() {
+			var err error
+			userID, err = osClient.CreateUser(logger, openstack.User{
+				Name:     userName,
+				DomainID: "default",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			delete(spec, "secret")
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneUser(keystoneUserName, spec))
+		})
+
+		It("adopts the user and keeps it on delete", func() {
+			th.ExpectCondition(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			instance := GetKeystoneUser(keystoneUserName)
+			Expect(instance.Status.UserID).To(Equal(userID))
+			Expect(instance.Status.Created).To(BeFalse())
+			Expect(instance.Status.PasswordSecretHash).To(BeEmpty())
+
+			th.DeleteInstance(GetKeystoneUser(keystoneUserName))
+
+			Expect(osClient.GetUserByID(userID)).NotTo(BeNil())
+		})
+	})
+
+	When("the keystone API fails", func() {
+		BeforeEach(func() {
+			osClient.InjectError("users", errors.New("keystone is down"))
+			DeferCleanup(func() {
+				osClient.InjectError("users", nil)
+			})
+
+			CreateReadyKeystoneAPI(keystoneAPIName)
+			DeferCleanup(k8sClient.Delete, ctx, th.CreateSecret(passwordSecretName, map[string][]byte{
+				"Password": []byte("12345678"),
+			}))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneUser(keystoneUserName, spec))
+		})
+
+		It("reports the error and recovers", func() {
+			Eventually(func(g Gomega) {
+				conditions := GetKeystoneUser(keystoneUserName).Status.Conditions
+				g.Expect(conditions.IsFalse(keystonev1.KeystoneUserReadyCondition)).To(BeTrue())
+				readyCondition := conditions.Get(keystonev1.KeystoneUserReadyCondition)
+				g.Expect(readyCondition.Reason).To(BeEquivalentTo(condition.ErrorReason))
+				g.Expect(readyCondition.Message).To(ContainSubstring("keystone is down"))
+			}, timeout, interval).Should(Succeed())
+			th.ExpectCondition(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				keystonev1.DegradedCondition,
+				corev1.ConditionTrue,
+			)
+
+			// a degraded instance gets requeued slowly, the change of the
+			// metadata triggers the next reconcile
+			osClient.InjectError("users", nil)
+			TriggerKeystoneUserReconcile(keystoneUserName)
+
+			th.ExpectCondition(
+				keystoneUserName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			Expect(osClient.GetUserByID(GetKeystoneUser(keystoneUserName).Status.UserID)).NotTo(BeNil())
+			Eventually(func(g Gomega) {
+				conditions := GetKeystoneUser(keystoneUserName).Status.Conditions
+				g.Expect(conditions.Has(keystonev1.DegradedCondition)).To(BeFalse())
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+})
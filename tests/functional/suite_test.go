@@ -35,7 +35,9 @@ import (
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
 	"github.com/openstack-k8s-operators/keystone-operator/controllers"
 	keystone_base "github.com/openstack-k8s-operators/keystone-operator/pkg/keystone"
+	"github.com/openstack-k8s-operators/keystone-operator/pkg/openstackclient/fake"
 	common_test "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+	"github.com/openstack-k8s-operators/lib-common/modules/openstack"
 	test "github.com/openstack-k8s-operators/lib-common/modules/test"
 	mariadb_test "github.com/openstack-k8s-operators/mariadb-operator/api/test/helpers"
 	mariadbv1 "github.com/openstack-k8s-operators/mariadb-operator/api/v1beta1"
@@ -57,6 +59,8 @@ var (
 	mariadb   *mariadb_test.TestHelper
 	infra     *infra_test.TestHelper
 	namespace string
+	// osClient - the keystone API of the controllers
+	osClient *fake.OpenStackClient
 )
 
 const (
@@ -114,6 +118,10 @@ var _ = BeforeSuite(func() {
 			LocalServingHost: "127.0.0.1",
 		},
 	}
+	// envtest runs no namespace controller, the Services of the KeystoneAPIs
+	// of all tests stay around and exhaust the default /24 service range
+	testEnv.ControlPlane.GetAPIServer().Configure().Set(
+		"service-cluster-ip-range", "10.0.0.0/16")
 
 	logger = ctrl.Log.WithName("---Test---")
 
@@ -121,6 +129,9 @@ var _ = BeforeSuite(func() {
 	cfg, err = testEnv.Start()
 	Expect(err).NotTo(HaveOccurred())
 	Expect(cfg).NotTo(BeNil())
+	// the client rate limits of ctrl.GetConfig main.go uses
+	cfg.QPS = 20
+	cfg.Burst = 30
 
 	err = keystonev1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
@@ -172,13 +183,106 @@ var _ = BeforeSuite(func() {
 
 	keystonev1.SetupDefaults()
 
+	// envtest runs no keystone, the controllers talk to the in-memory one
+	// holding the objects of the bootstrap job
+	osClient = fake.NewOpenStackClient("regionOne")
+	adminProjectID, err := osClient.CreateProject(logger, openstack.Project{
+		Name:     "admin",
+		DomainID: "default",
+	})
+	Expect(err).NotTo(HaveOccurred())
+	_, err = osClient.CreateUser(logger, openstack.User{
+		Name:      "admin",
+		DomainID:  "default",
+		ProjectID: adminProjectID,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	_, err = osClient.CreateService(logger, openstack.Service{
+		Type:    keystone_base.ServiceType,
+		Name:    keystone_base.ServiceName,
+		Enabled: true,
+	})
+	Expect(err).NotTo(HaveOccurred())
+
 	err = (&controllers.KeystoneAPIReconciler{
-		Client:  k8sManager.GetClient(),
-		Scheme:  k8sManager.GetScheme(),
-		Kclient: kclient,
+		Client:          k8sManager.GetClient(),
+		Scheme:          k8sManager.GetScheme(),
+		Kclient:         kclient,
+		Config:          cfg,
+		OpenStackClient: osClient.Factory(),
 	}).SetupWithManager(context.Background(), k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
+	// requeue fast while waiting for the KeystoneAPI or KeystoneServices
+	// so that the tests do not wait for the production intervals
+	requeue := controllers.RequeueIntervals{
+		KeystoneAPI:     interval,
+		KeystoneService: interval,
+	}
+
+	err = (&controllers.KeystoneEndpointGroupReconciler{
+		Client:          k8sManager.GetClient(),
+		Scheme:          k8sManager.GetScheme(),
+		Kclient:         kclient,
+		Requeue:         requeue,
+		OpenStackClient: osClient.Factory(),
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&controllers.KeystoneCatalogAuditReconciler{
+		Client:          k8sManager.GetClient(),
+		Scheme:          k8sManager.GetScheme(),
+		Kclient:         kclient,
+		Requeue:         requeue,
+		OpenStackClient: osClient.Factory(),
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&controllers.KeystoneRegisteredLimitReconciler{
+		Client:          k8sManager.GetClient(),
+		Scheme:          k8sManager.GetScheme(),
+		Kclient:         kclient,
+		Requeue:         requeue,
+		OpenStackClient: osClient.Factory(),
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&controllers.KeystoneLimitReconciler{
+		Client:          k8sManager.GetClient(),
+		Scheme:          k8sManager.GetScheme(),
+		Kclient:         kclient,
+		Requeue:         requeue,
+		OpenStackClient: osClient.Factory(),
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&controllers.KeystoneEC2CredentialReconciler{
+		Client:          k8sManager.GetClient(),
+		Scheme:          k8sManager.GetScheme(),
+		Kclient:         kclient,
+		Requeue:         requeue,
+		OpenStackClient: osClient.Factory(),
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&controllers.KeystoneCredentialReconciler{
+		Client:          k8sManager.GetClient(),
+		Scheme:          k8sManager.GetScheme(),
+		Kclient:         kclient,
+		Requeue:         requeue,
+		OpenStackClient: osClient.Factory(),
+	}).SetupWithManager(context.Background(), k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&controllers.KeystoneUserReconciler{
+		Client:          k8sManager.GetClient(),
+		Scheme:          k8sManager.GetScheme(),
+		Kclient:         kclient,
+		Requeue:         requeue,
+		OpenStackClient: osClient.Factory(),
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
 	go func() {
 		defer GinkgoRecover()
 		err = k8sManager.Start(ctx)
@@ -196,6 +300,10 @@ var _ = BeforeSuite(func() {
 		conn.Close()
 		return nil
 	}).Should(Succeed())
+
+	// the first spec must not race the start of the controllers, their
+	// first reconciles are slower than the timeout of the helpers
+	Expect(k8sManager.GetCache().WaitForCacheSync(ctx)).To(BeTrue())
 })
 
 var _ = AfterSuite(func() {